@@ -0,0 +1,49 @@
+// Command migrate-qdrant-ids moves vectors stored under legacy point IDs,
+// hashed numeric ones and UUIDs shared across workspaces, to the current UUID
+// point IDs. Run it once per collection after upgrading.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+
+	"yuon/configuration"
+	"yuon/internal/rag/vectorstore"
+	"yuon/package/logger"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 256, "스크롤/업서트 배치 크기")
+	flag.Parse()
+
+	cfg, err := configuration.Load()
+	if err != nil {
+		slog.Error("설정 로드 실패", "error", err)
+		os.Exit(1)
+	}
+
+	logger.New(cfg.App.Environment)
+
+	client, err := vectorstore.NewQdrantClient(&cfg.Qdrant)
+	if err != nil {
+		slog.Error("Qdrant 클라이언트 초기화 실패", "error", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	slog.Info("포인트 ID 마이그레이션 시작", "collection", cfg.Qdrant.Collection)
+
+	result, err := client.MigratePointIDs(context.Background(), *batchSize)
+	if err != nil {
+		slog.Error("포인트 ID 마이그레이션 실패", "error", err, "scanned", result.Scanned, "migrated", result.Migrated)
+		os.Exit(1)
+	}
+
+	slog.Info("포인트 ID 마이그레이션 완료",
+		"scanned", result.Scanned,
+		"migrated", result.Migrated,
+		"skipped", result.Skipped,
+	)
+}
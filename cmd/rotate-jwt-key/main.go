@@ -0,0 +1,50 @@
+// Command rotate-jwt-key makes a new JWT signing key current. Tokens signed
+// with earlier keys stay valid until they expire, so nobody is logged out.
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"yuon/configuration"
+	"yuon/internal/auth"
+	"yuon/internal/database"
+	"yuon/package/logger"
+)
+
+func main() {
+	cfg, err := configuration.Load()
+	if err != nil {
+		slog.Error("설정 로드 실패", "error", err)
+		os.Exit(1)
+	}
+
+	logger.New(cfg.App.Environment)
+
+	db, err := database.Connect(&cfg.Database)
+	if err != nil {
+		slog.Error("데이터베이스 연결 실패", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := database.EnsureSchemas(db); err != nil {
+		slog.Error("DB 스키마 초기화 실패", "error", err)
+		os.Exit(1)
+	}
+
+	manager := auth.NewManager(cfg.Auth.JWTSecret, nil)
+	manager.SetTokenLifetimes(cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL)
+	if err := manager.SetSigningKeyStore(auth.NewPostgresSigningKeyStore(db)); err != nil {
+		slog.Error("서명 키 로드 실패", "error", err)
+		os.Exit(1)
+	}
+
+	key, err := manager.RotateSigningKey()
+	if err != nil {
+		slog.Error("서명 키 교체 실패", "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("서명 키 교체 완료", "kid", key.ID)
+}
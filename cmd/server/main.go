@@ -12,14 +12,24 @@ import (
 	"time"
 
 	"yuon/configuration"
+	"yuon/internal/alert"
+	"yuon/internal/audit"
 	"yuon/internal/auth"
+	"yuon/internal/auth/ldap"
+	"yuon/internal/auth/saml"
 	"yuon/internal/database"
 	httpserver "yuon/internal/http"
+	"yuon/internal/idempotency"
+	"yuon/internal/mail"
+	"yuon/internal/rag"
 	"yuon/internal/rag/llm"
 	"yuon/internal/rag/search"
 	"yuon/internal/rag/service"
 	"yuon/internal/rag/vectorstore"
+	"yuon/internal/ratelimit"
 	"yuon/internal/storage"
+	"yuon/internal/upload"
+	"yuon/internal/webhook"
 	"yuon/package/logger"
 	"yuon/package/validator"
 )
@@ -34,7 +44,7 @@ func main() {
 	}
 
 	logger.New(cfg.App.Environment)
-	validator.Init()
+	validator.Init(cfg.Document.Categories)
 
 	logConfig(cfg)
 
@@ -75,18 +85,132 @@ func main() {
 
 	userStore := auth.NewPostgresUserStore(db)
 	authManager := auth.NewManager(cfg.Auth.JWTSecret, userStore)
+	authManager.SetTokenLifetimes(cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL)
+	authManager.SetTokenIssuer(cfg.Auth.JWTIssuer, cfg.Auth.JWTAudience)
+	if err := authManager.SetSigningKeyStore(auth.NewPostgresSigningKeyStore(db)); err != nil {
+		slog.Error("JWT 서명 키 로드 실패", "error", err)
+		os.Exit(1)
+	}
+	authManager.SetRefreshTokenStore(auth.NewPostgresRefreshTokenStore(db))
+	authManager.SetAPIKeyStore(auth.NewPostgresAPIKeyStore(db))
+	authManager.SetPasswordResetStore(auth.NewPostgresPasswordResetStore(db), cfg.Auth.PasswordResetTTL)
+	authManager.SetInvitationStore(auth.NewPostgresInvitationStore(db), cfg.Auth.InvitationTTL)
+	authManager.SetSSOStore(auth.NewPostgresSSOStore(db))
+	authManager.SetMFAStore(auth.NewPostgresMFAStore(db), cfg.Auth.MFAIssuer)
+	authManager.SetLoginAttemptStore(auth.NewPostgresLoginAttemptStore(db), auth.LockoutPolicy{
+		MaxFailures:      cfg.Auth.LoginMaxFailures,
+		MaxFailuresPerIP: cfg.Auth.LoginMaxFailuresPerIP,
+		LockoutDuration:  cfg.Auth.LoginLockoutDuration,
+		Window:           cfg.Auth.LoginFailureWindow,
+		BaseDelay:        cfg.Auth.LoginBackoffBase,
+		MaxDelay:         cfg.Auth.LoginBackoffMax,
+	})
 	if err := authManager.EnsureRootUser("root@yuon.root", cfg.Auth.RootPassword); err != nil {
 		slog.Error("루트 사용자 초기화 실패", "error", err)
 		os.Exit(1)
 	}
 
+	auditLogger := audit.NewPostgresLogger(db)
 	router := httpserver.NewRouter(cfg, authManager, storageClient)
+	router.SetAuditLogger(auditLogger)
+	mailer := mail.New(&cfg.Mail)
+	router.SetMailer(mailer)
+	idempotencyStore := idempotency.NewPostgresStore(db)
+	router.SetIdempotencyStore(idempotencyStore, cfg.Document.IdempotencyTTL)
+	uploadSessions := upload.NewPostgresStore(db)
+	router.SetUploadSessionStore(uploadSessions)
+	var webhooks *webhook.Dispatcher
+	if cfg.Webhook.Enabled {
+		webhooks = webhook.NewDispatcher(webhook.NewPostgresStore(db), &cfg.Webhook)
+		router.SetWebhookDispatcher(webhooks)
+	}
+	if cfg.RateLimit.Enabled {
+		limiter, err := ratelimit.New(&cfg.RateLimit)
+		if err != nil {
+			slog.Error("요청 한도 초기화 실패", "error", err)
+			os.Exit(1)
+		}
+		router.SetRateLimiter(limiter)
+	}
+	if cfg.OIDC.Enabled {
+		provider, err := auth.NewOIDCProvider(&cfg.OIDC)
+		if err != nil {
+			slog.Error("OIDC 초기화 실패", "error", err)
+			os.Exit(1)
+		}
+		router.SetOIDCProvider(provider)
+		slog.Info("OIDC 로그인 활성화", "issuer", cfg.OIDC.Issuer, "hostedDomain", cfg.OIDC.HostedDomain)
+	}
+	if cfg.LDAP.Enabled {
+		ldapCfg, err := ldap.LoadConfig(cfg.LDAP.ConfigPath)
+		if err != nil {
+			slog.Error("LDAP 설정 로드 실패", "error", err)
+			os.Exit(1)
+		}
+		if cfg.LDAP.BindPassword != "" {
+			ldapCfg.BindPassword = cfg.LDAP.BindPassword
+		}
+		authManager.SetDirectoryAuthenticator(ldap.NewClient(ldapCfg))
+		slog.Info("LDAP 로그인 활성화", "url", ldapCfg.URL, "baseDN", ldapCfg.BaseDN)
+	}
+	if cfg.SAML.Enabled {
+		samlCfg, err := saml.LoadConfig(cfg.SAML.ConfigPath)
+		if err != nil {
+			slog.Error("SAML 설정 로드 실패", "error", err)
+			os.Exit(1)
+		}
+		samlSP := saml.NewServiceProvider(samlCfg, []byte(cfg.Auth.JWTSecret))
+		samlSP.SetReplayCache(saml.NewPostgresReplayCache(db))
+		router.SetSAMLServiceProvider(samlSP)
+		slog.Info("SAML SSO 활성화", "idp", samlCfg.IdP.EntityID)
+	}
 	if chatbotSvc != nil {
 		router.SetChatbotService(chatbotSvc)
 		slog.Info("RAG 챗봇 서비스 활성화")
 	}
+	var alerts *alert.Monitor
+	if cfg.Alert.Enabled && chatbotSvc != nil {
+		alerts = newAlertMonitor(&cfg.Alert, chatbotSvc, webhooks, mailer)
+		router.SetAlertMonitor(alerts)
+	}
 	router.SetupRoutes()
 
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if alerts != nil {
+		go alerts.Run(jobs)
+		slog.Info("이상 징후 경보 활성화", "interval", cfg.Alert.Interval, "chatErrorRate", cfg.Alert.ChatErrorRate, "p95Latency", cfg.Alert.P95Latency, "llmFailureRate", cfg.Alert.LLMFailureRate)
+	}
+	if cfg.Document.IdempotencyPruneInterval > 0 {
+		go idempotency.RunPrune(jobs, idempotencyStore, cfg.Document.IdempotencyPruneInterval)
+	}
+	if cfg.Document.ResumableCleanupInterval > 0 {
+		go upload.RunExpiry(jobs, uploadSessions, storageClient, cfg.Document.ResumableCleanupInterval)
+	}
+	if cfg.Retention.Enabled() && chatbotSvc != nil {
+		go chatbotSvc.RunConversationRetention(jobs, retentionPolicy(&cfg.Retention), cfg.Retention.Interval, auditLogger)
+		slog.Info("대화 보존 기간 정리 활성화", "days", cfg.Retention.Days, "workspaces", cfg.Retention.WorkspaceDays, "mode", cfg.Retention.Mode)
+	}
+	if cfg.Analytics.DailySnapshot && chatbotSvc != nil && db != nil {
+		loc, _ := cfg.Analytics.Location()
+		go chatbotSvc.RunDailyStats(jobs, loc, cfg.Analytics.BackfillDays)
+		slog.Info("일별 통계 스냅샷 활성화", "timezone", loc.String(), "backfillDays", cfg.Analytics.BackfillDays)
+	}
+	if (cfg.Analytics.RawRetentionDays > 0 || cfg.Analytics.HourlyRetentionDays > 0) && chatbotSvc != nil && db != nil {
+		loc, _ := cfg.Analytics.Location()
+		day := 24 * time.Hour
+		go chatbotSvc.RunAnalyticsRetention(jobs, service.AnalyticsRetention{
+			Raw:    time.Duration(cfg.Analytics.RawRetentionDays) * day,
+			Hourly: time.Duration(cfg.Analytics.HourlyRetentionDays) * day,
+		}, loc)
+		slog.Info("응답 지표 보존 정리 활성화", "rawDays", cfg.Analytics.RawRetentionDays, "hourlyDays", cfg.Analytics.HourlyRetentionDays)
+	}
+	if cfg.Analytics.GapReport && chatbotSvc != nil && db != nil {
+		loc, _ := cfg.Analytics.Location()
+		go chatbotSvc.RunKnowledgeGapReports(jobs, loc)
+		slog.Info("지식 공백 주간 보고서 활성화", "minScore", cfg.Analytics.GapMinScore, "similarity", cfg.Analytics.GapSimilarity)
+	}
+
 	srv := createServer(cfg, router)
 
 	go startServer(srv, cfg)
@@ -94,6 +218,38 @@ func main() {
 	waitForShutdown(srv)
 }
 
+func newAlertMonitor(cfg *configuration.AlertConfig, source alert.Source, webhooks *webhook.Dispatcher, mailer mail.Mailer) *alert.Monitor {
+	var notifiers []alert.Notifier
+	if webhooks != nil {
+		notifiers = append(notifiers, alert.WebhookNotifier{Dispatcher: webhooks})
+	}
+	if cfg.SlackWebhookURL != "" {
+		notifiers = append(notifiers, alert.NewSlackNotifier(cfg.SlackWebhookURL))
+	}
+	if len(cfg.EmailTo) > 0 {
+		notifiers = append(notifiers, alert.MailNotifier{Mailer: mailer, To: cfg.EmailTo})
+	}
+	return alert.NewMonitor(source, alert.Thresholds{
+		ChatErrorRate:  cfg.ChatErrorRate,
+		P95Latency:     cfg.P95Latency,
+		LLMFailureRate: cfg.LLMFailureRate,
+		MinSamples:     int64(cfg.MinSamples),
+	}, cfg.Interval, cfg.RepeatInterval, notifiers...)
+}
+
+func retentionPolicy(cfg *configuration.RetentionConfig) service.RetentionPolicy {
+	day := 24 * time.Hour
+	policy := service.RetentionPolicy{
+		Default:    time.Duration(cfg.Days) * day,
+		Workspaces: make(map[string]time.Duration, len(cfg.WorkspaceDays)),
+		Anonymize:  cfg.Mode == "anonymize",
+	}
+	for workspace, days := range cfg.WorkspaceDays {
+		policy.Workspaces[workspace] = time.Duration(days) * day
+	}
+	return policy
+}
+
 func safeClose(db *sql.DB) {
 	if db != nil {
 		_ = db.Close()
@@ -152,12 +308,11 @@ func initializeRAG(cfg *configuration.Config, db *sql.DB) (*service.ChatbotServi
 	llmClient := llm.NewOpenAIClient(&cfg.OpenAI)
 	slog.Info("OpenAI 클라이언트 초기화 완료")
 
-	// Qdrant 클라이언트
-	qdrantClient, err := vectorstore.NewQdrantClient(&cfg.Qdrant)
+	// 벡터 저장소
+	vectorStore, err := newVectorStore(cfg, db)
 	if err != nil {
-		return nil, nil, fmt.Errorf("Qdrant 초기화 실패: %w", err)
+		return nil, nil, err
 	}
-	slog.Info("Qdrant 클라이언트 초기화 완료", "url", cfg.Qdrant.URL)
 
 	// OpenSearch 클라이언트
 	opensearchClient, err := search.NewOpenSearchClient(&cfg.OpenSearch)
@@ -174,18 +329,72 @@ func initializeRAG(cfg *configuration.Config, db *sql.DB) (*service.ChatbotServi
 	}
 
 	// 챗봇 서비스
-	chatbotSvc := service.NewChatbotService(llmClient, qdrantClient, opensearchClient, convStore, analyticsStore)
+	chatbotSvc := service.NewChatbotService(llmClient, vectorStore, opensearchClient, convStore, analyticsStore, cfg.App.ConversationCacheSize)
+	chatbotSvc.SetKnowledgeGapOptions(service.KnowledgeGapOptions{
+		MinScore:   cfg.Analytics.GapMinScore,
+		Similarity: cfg.Analytics.GapSimilarity,
+	})
+	chatbotSvc.StartAnalyticsRecorder(service.AnalyticsQueueOptions{
+		Size:          cfg.Analytics.QueueSize,
+		BatchSize:     cfg.Analytics.BatchSize,
+		FlushInterval: cfg.Analytics.FlushInterval,
+	})
+
+	if cfg.Vector.ValidateDimensions {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := chatbotSvc.ValidateEmbeddingDimensions(ctx)
+		cancel()
+		if err != nil {
+			_ = chatbotSvc.StopAnalyticsRecorder(context.Background())
+			vectorStore.Close()
+			return nil, nil, err
+		}
+		slog.Info("임베딩 차원 확인 완료")
+	}
 
 	cleanup := func() {
-		if qdrantClient != nil {
-			qdrantClient.Close()
-			slog.Info("Qdrant 연결 종료")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := chatbotSvc.StopAnalyticsRecorder(ctx); err != nil {
+			slog.Warn("남은 분석 기록을 모두 저장하지 못했습니다", "error", err)
+		}
+		cancel()
+		if vectorStore != nil {
+			vectorStore.Close()
+			slog.Info("벡터 저장소 연결 종료")
 		}
 	}
 
 	return chatbotSvc, cleanup, nil
 }
 
+func newVectorStore(cfg *configuration.Config, db *sql.DB) (rag.VectorStore, error) {
+	switch cfg.Vector.Backend {
+	case vectorstore.BackendPgVector:
+		store, err := vectorstore.NewPgVectorStore(db, &cfg.Qdrant)
+		if err != nil {
+			return nil, fmt.Errorf("pgvector 초기화 실패: %w", err)
+		}
+		slog.Info("pgvector 저장소 초기화 완료")
+		return store, nil
+	case vectorstore.BackendWeaviate:
+		store, err := vectorstore.NewWeaviateStore(&cfg.Vector, &cfg.Qdrant)
+		if err != nil {
+			return nil, fmt.Errorf("Weaviate 초기화 실패: %w", err)
+		}
+		slog.Info("Weaviate 저장소 초기화 완료", "url", cfg.Vector.WeaviateURL, "class", cfg.Vector.WeaviateClass)
+		return store, nil
+	case vectorstore.BackendQdrant, "":
+		client, err := vectorstore.NewQdrantClient(&cfg.Qdrant)
+		if err != nil {
+			return nil, fmt.Errorf("Qdrant 초기화 실패: %w", err)
+		}
+		slog.Info("Qdrant 클라이언트 초기화 완료", "url", cfg.Qdrant.URL)
+		return client, nil
+	default:
+		return nil, fmt.Errorf("지원하지 않는 벡터 저장소입니다: %s", cfg.Vector.Backend)
+	}
+}
+
 func waitForShutdown(srv *http.Server) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -2,8 +2,13 @@ package configuration
 
 import (
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
+	"yuon/package/validator"
 )
 
 type Config struct {
@@ -12,15 +17,47 @@ type Config struct {
 	App        AppConfig
 	OpenAI     OpenAIConfig
 	Qdrant     QdrantConfig
+	Vector     VectorStoreConfig
 	OpenSearch OpenSearchConfig
 	Auth       AuthConfig
+	Mail       MailConfig
+	OIDC       OIDCConfig
+	SAML       SAMLConfig
+	LDAP       LDAPConfig
 	Storage    StorageConfig
+	Document   DocumentConfig
+	Antivirus  AntivirusConfig
+	RateLimit  RateLimitConfig
+	Webhook    WebhookConfig
+	WebSocket  WebSocketConfig
+	Retention  RetentionConfig
+	Share      ShareConfig
+	Analytics  AnalyticsConfig
+	Alert      AlertConfig
 }
 
 type ServerConfig struct {
 	Port int    `envconfig:"SERVER_PORT" default:"8080"`
 	Host string `envconfig:"SERVER_HOST" default:"0.0.0.0"`
 	Mode string `envconfig:"SERVER_MODE" default:"release"`
+	// DocsEnabled serves the Swagger UI and OpenAPI spec under /docs.
+	DocsEnabled bool `envconfig:"SERVER_DOCS_ENABLED" default:"true"`
+	// MetricsEnabled serves WebSocket gauges and counters on /metrics.
+	MetricsEnabled bool `envconfig:"SERVER_METRICS_ENABLED" default:"true"`
+	// MetricsToken is the Bearer token scrapers send to /metrics. Without
+	// one the endpoint requires a root or admin JWT.
+	MetricsToken string `envconfig:"SERVER_METRICS_TOKEN"`
+	// TrustedProxies lists the addresses or CIDR ranges of reverse proxies
+	// whose X-Forwarded-For and X-Real-IP headers are believed. Client IPs
+	// drive the public rate limits and login lockouts, so with none listed
+	// the peer address is used and those headers are ignored.
+	TrustedProxies []string `envconfig:"SERVER_TRUSTED_PROXIES"`
+
+	// RequestTimeout bounds ordinary API calls; LongRequestTimeout covers
+	// ingestion and maintenance routes. WebSocket, export and download
+	// routes are unbounded. Zero disables a timeout.
+	RequestTimeout     time.Duration `envconfig:"SERVER_REQUEST_TIMEOUT" default:"10s"`
+	LongRequestTimeout time.Duration `envconfig:"SERVER_LONG_REQUEST_TIMEOUT" default:"120s"`
 }
 
 type DatabaseConfig struct {
@@ -36,14 +73,48 @@ type AppConfig struct {
 	Name        string `envconfig:"APP_NAME" default:"YUON"`
 	Version     string `envconfig:"APP_VERSION" default:"1.0.0"`
 	Environment string `envconfig:"APP_ENV" default:"development"`
+	// ConversationCacheSize is how many conversation histories are cached
+	// in memory over the conversation store.
+	ConversationCacheSize int `envconfig:"CONVERSATION_CACHE_SIZE" default:"1000"`
 }
 
 type OpenAIConfig struct {
 	APIKey         string  `envconfig:"OPENAI_API_KEY"`
 	Model          string  `envconfig:"OPENAI_MODEL" default:"gpt-4o-mini"`
 	EmbeddingModel string  `envconfig:"OPENAI_EMBEDDING_MODEL" default:"text-embedding-3-small"`
+	VisionModel    string  `envconfig:"OPENAI_VISION_MODEL" default:"gpt-4o-mini"`
 	MaxTokens      int     `envconfig:"OPENAI_MAX_TOKENS" default:"1000"`
 	Temperature    float32 `envconfig:"OPENAI_TEMPERATURE" default:"0.7"`
+	// Prices are "model:prompt/completion" in USD per million tokens, used
+	// to estimate conversation costs.
+	Prices map[string]string `envconfig:"OPENAI_MODEL_PRICES" default:"gpt-4o-mini:0.15/0.6,gpt-4o:2.5/10"`
+}
+
+// ModelPrice is the USD price of a million prompt and completion tokens.
+type ModelPrice struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// ModelPrices parses Prices.
+func (c OpenAIConfig) ModelPrices() (map[string]ModelPrice, error) {
+	prices := make(map[string]ModelPrice, len(c.Prices))
+	for model, value := range c.Prices {
+		prompt, completion, ok := strings.Cut(value, "/")
+		if !ok {
+			return nil, fmt.Errorf("OPENAI_MODEL_PRICES의 %s 값은 prompt/completion 형식이어야 합니다: %s", model, value)
+		}
+		var price ModelPrice
+		var err error
+		if price.Prompt, err = strconv.ParseFloat(prompt, 64); err != nil || price.Prompt < 0 {
+			return nil, fmt.Errorf("OPENAI_MODEL_PRICES의 %s 입력 토큰 가격이 올바르지 않습니다: %s", model, prompt)
+		}
+		if price.Completion, err = strconv.ParseFloat(completion, 64); err != nil || price.Completion < 0 {
+			return nil, fmt.Errorf("OPENAI_MODEL_PRICES의 %s 출력 토큰 가격이 올바르지 않습니다: %s", model, completion)
+		}
+		prices[model] = price
+	}
+	return prices, nil
 }
 
 type QdrantConfig struct {
@@ -51,6 +122,31 @@ type QdrantConfig struct {
 	APIKey     string `envconfig:"QDRANT_API_KEY"`
 	Collection string `envconfig:"QDRANT_COLLECTION" default:"documents"`
 	VectorSize int    `envconfig:"QDRANT_VECTOR_SIZE" default:"1536"`
+	BatchSize  int    `envconfig:"QDRANT_UPSERT_BATCH_SIZE" default:"64"`
+	Distance   string `envconfig:"QDRANT_DISTANCE" default:"cosine"`
+
+	// Named vectors, e.g. "content:1536,title:1536". Empty keeps a single
+	// unnamed vector of VectorSize.
+	NamedVectors  map[string]int    `envconfig:"QDRANT_NAMED_VECTORS"`
+	VectorSources map[string]string `envconfig:"QDRANT_VECTOR_SOURCES"`
+	VectorModels  map[string]string `envconfig:"QDRANT_VECTOR_MODELS"`
+	SearchVector  string            `envconfig:"QDRANT_SEARCH_VECTOR"`
+
+	// Quantization is "scalar" (int8), "product", "none" to disable, or empty
+	// to leave an existing collection as is.
+	Quantization            string  `envconfig:"QDRANT_QUANTIZATION"`
+	QuantizationAlwaysRAM   bool    `envconfig:"QDRANT_QUANTIZATION_ALWAYS_RAM" default:"true"`
+	QuantizationQuantile    float32 `envconfig:"QDRANT_QUANTIZATION_QUANTILE" default:"0.99"`
+	QuantizationCompression string  `envconfig:"QDRANT_QUANTIZATION_COMPRESSION" default:"x16"`
+	OnDiskVectors           bool    `envconfig:"QDRANT_ON_DISK_VECTORS" default:"false"`
+
+	// SparseVector names a BM25-style sparse vector stored next to the dense
+	// vectors. HybridSearch fuses both in Qdrant instead of querying OpenSearch.
+	SparseVector string `envconfig:"QDRANT_SPARSE_VECTOR"`
+	HybridSearch bool   `envconfig:"QDRANT_HYBRID_SEARCH" default:"false"`
+
+	// PayloadIndexes adds keyword indexes beyond the filter fields, e.g. "parentId".
+	PayloadIndexes []string `envconfig:"QDRANT_PAYLOAD_INDEXES"`
 }
 
 type OpenSearchConfig struct {
@@ -58,11 +154,240 @@ type OpenSearchConfig struct {
 	Username string `envconfig:"OPENSEARCH_USERNAME" default:"admin"`
 	Password string `envconfig:"OPENSEARCH_PASSWORD" default:"admin"`
 	Index    string `envconfig:"OPENSEARCH_INDEX" default:"documents"`
+
+	Analyzer           string   `envconfig:"OPENSEARCH_ANALYZER" default:"nori"`
+	NoriUserDictionary string   `envconfig:"OPENSEARCH_NORI_USER_DICTIONARY"`
+	NoriUserWords      []string `envconfig:"OPENSEARCH_NORI_USER_WORDS"`
+
+	BM25K1             float64  `envconfig:"OPENSEARCH_BM25_K1" default:"1.2"`
+	BM25B              float64  `envconfig:"OPENSEARCH_BM25_B" default:"0.75"`
+	SearchFields       []string `envconfig:"OPENSEARCH_SEARCH_FIELDS" default:"title^3,keywords^2,content"`
+	MinimumShouldMatch string   `envconfig:"OPENSEARCH_MINIMUM_SHOULD_MATCH"`
+
+	MaxRetries   int           `envconfig:"OPENSEARCH_MAX_RETRIES" default:"3"`
+	RetryBackoff time.Duration `envconfig:"OPENSEARCH_RETRY_BACKOFF" default:"200ms"`
 }
 
 type AuthConfig struct {
-	RootPassword string `envconfig:"ROOT_ADMIN_PASSWORD"`
-	JWTSecret    string `envconfig:"JWT_SECRET"`
+	RootPassword    string        `envconfig:"ROOT_ADMIN_PASSWORD"`
+	JWTSecret       string        `envconfig:"JWT_SECRET"`
+	AccessTokenTTL  time.Duration `envconfig:"JWT_ACCESS_TTL" default:"24h"`
+	RefreshTokenTTL time.Duration `envconfig:"JWT_REFRESH_TTL" default:"720h"`
+	// JWTIssuer and JWTAudience are written to and required in the iss/aud
+	// claims of access tokens.
+	JWTIssuer   string `envconfig:"JWT_ISSUER" default:"yuon"`
+	JWTAudience string `envconfig:"JWT_AUDIENCE" default:"yuon-api"`
+
+	// PasswordResetURL is the frontend page receiving `?token=`.
+	PasswordResetURL string        `envconfig:"PASSWORD_RESET_URL" default:"http://localhost:3000/reset-password"`
+	PasswordResetTTL time.Duration `envconfig:"PASSWORD_RESET_TTL" default:"30m"`
+
+	// InvitationURL is the frontend signup page receiving `?token=`.
+	InvitationURL string        `envconfig:"INVITATION_URL" default:"http://localhost:3000/signup"`
+	InvitationTTL time.Duration `envconfig:"INVITATION_TTL" default:"168h"`
+
+	// MFAIssuer is the account issuer shown in authenticator apps.
+	MFAIssuer string `envconfig:"MFA_ISSUER" default:"YUON"`
+
+	// Brute-force protection; see auth.LockoutPolicy.
+	LoginMaxFailures      int           `envconfig:"LOGIN_MAX_FAILURES" default:"5"`
+	LoginMaxFailuresPerIP int           `envconfig:"LOGIN_MAX_FAILURES_PER_IP" default:"20"`
+	LoginLockoutDuration  time.Duration `envconfig:"LOGIN_LOCKOUT_DURATION" default:"15m"`
+	LoginFailureWindow    time.Duration `envconfig:"LOGIN_FAILURE_WINDOW" default:"24h"`
+	LoginBackoffBase      time.Duration `envconfig:"LOGIN_BACKOFF_BASE" default:"1s"`
+	LoginBackoffMax       time.Duration `envconfig:"LOGIN_BACKOFF_MAX" default:"30s"`
+}
+
+// OIDCConfig enables OpenID Connect login (Google Workspace by default).
+// RedirectURL must point at /api/v1/auth/oidc/callback on this server.
+type OIDCConfig struct {
+	Enabled      bool   `envconfig:"OIDC_ENABLED" default:"false"`
+	Issuer       string `envconfig:"OIDC_ISSUER" default:"https://accounts.google.com"`
+	ClientID     string `envconfig:"OIDC_CLIENT_ID"`
+	ClientSecret string `envconfig:"OIDC_CLIENT_SECRET"`
+	RedirectURL  string `envconfig:"OIDC_REDIRECT_URL"`
+	// HostedDomain restricts logins to a Google Workspace domain (hd claim).
+	HostedDomain string `envconfig:"OIDC_HOSTED_DOMAIN"`
+	// AllowedDomains restricts logins to these email domains. Either it or
+	// HostedDomain must be set, or every account of the issuer could sign up.
+	AllowedDomains []string `envconfig:"OIDC_ALLOWED_DOMAINS"`
+	// DefaultRole is given to provisioned users: user or editor.
+	DefaultRole string `envconfig:"OIDC_DEFAULT_ROLE" default:"user"`
+	// PostLoginRedirect receives a single-use login code in the URL fragment
+	// (#code=...), which the client trades for tokens at /auth/sso/exchange.
+	// Without it the callback responds with the code as JSON. SAML's
+	// postLoginRedirect works the same way.
+	PostLoginRedirect string `envconfig:"OIDC_POST_LOGIN_REDIRECT"`
+}
+
+// SAMLConfig enables SAML SSO. IdP settings and attribute-to-role mapping
+// live in the YAML file at ConfigPath.
+type SAMLConfig struct {
+	Enabled    bool   `envconfig:"SAML_ENABLED" default:"false"`
+	ConfigPath string `envconfig:"SAML_CONFIG" default:"configuration/saml.yaml"`
+}
+
+// LDAPConfig enables LDAP/Active Directory password login. Server, search
+// and group-to-role settings live in the YAML file at ConfigPath.
+type LDAPConfig struct {
+	Enabled    bool   `envconfig:"LDAP_ENABLED" default:"false"`
+	ConfigPath string `envconfig:"LDAP_CONFIG" default:"configuration/ldap.yaml"`
+	// BindPassword overrides bindPassword from the YAML file.
+	BindPassword string `envconfig:"LDAP_BIND_PASSWORD"`
+}
+
+// RateLimitConfig sets per-user/per-API-key token buckets per route group.
+// A zero per-minute value disables that group. Without REDIS_URL limits are
+// kept in process and apply per instance.
+type RateLimitConfig struct {
+	Enabled  bool   `envconfig:"RATE_LIMIT_ENABLED" default:"true"`
+	RedisURL string `envconfig:"REDIS_URL"`
+
+	ChatPerMinute      int `envconfig:"RATE_LIMIT_CHAT_PER_MINUTE" default:"60"`
+	ChatBurst          int `envconfig:"RATE_LIMIT_CHAT_BURST" default:"20"`
+	DocumentsPerMinute int `envconfig:"RATE_LIMIT_DOCUMENTS_PER_MINUTE" default:"300"`
+	DocumentsBurst     int `envconfig:"RATE_LIMIT_DOCUMENTS_BURST" default:"60"`
+	IngestPerMinute    int `envconfig:"RATE_LIMIT_INGEST_PER_MINUTE" default:"30"`
+	IngestBurst        int `envconfig:"RATE_LIMIT_INGEST_BURST" default:"10"`
+
+	// Public endpoints (login, signup, password reset, /ws) are limited per
+	// client IP and globally across all clients.
+	PublicPerMinute int `envconfig:"RATE_LIMIT_PUBLIC_PER_MINUTE" default:"20"`
+	PublicBurst     int `envconfig:"RATE_LIMIT_PUBLIC_BURST" default:"10"`
+	GlobalPerMinute int `envconfig:"RATE_LIMIT_GLOBAL_PER_MINUTE" default:"600"`
+	GlobalBurst     int `envconfig:"RATE_LIMIT_GLOBAL_BURST" default:"100"`
+}
+
+// WebhookConfig controls outbound webhook delivery. Failed deliveries are
+// retried with exponential backoff up to MaxAttempts in total.
+type WebhookConfig struct {
+	Enabled        bool          `envconfig:"WEBHOOK_ENABLED" default:"true"`
+	Timeout        time.Duration `envconfig:"WEBHOOK_TIMEOUT" default:"10s"`
+	MaxAttempts    int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"5"`
+	RetryBaseDelay time.Duration `envconfig:"WEBHOOK_RETRY_BASE_DELAY" default:"10s"`
+}
+
+// WebSocketConfig controls chat connection liveness. The server pings every
+// PingInterval and drops connections that miss a pong for two intervals or
+// send no message for IdleTimeout (zero disables the idle check).
+type WebSocketConfig struct {
+	PingInterval time.Duration `envconfig:"WS_PING_INTERVAL" default:"30s"`
+	IdleTimeout  time.Duration `envconfig:"WS_IDLE_TIMEOUT" default:"10m"`
+
+	// MessagesPerMinute throttles append_message per connection, allowing
+	// MessageBurst at once; zero disables it. RATE_LIMIT_CHAT_* additionally
+	// limits each user across connections.
+	MessagesPerMinute int `envconfig:"WS_MESSAGES_PER_MINUTE" default:"20"`
+	MessageBurst      int `envconfig:"WS_MESSAGE_BURST" default:"5"`
+
+	// DashboardInterval is how often admins subscribed to the dashboard get
+	// live analytics updates; zero disables the subscription.
+	DashboardInterval time.Duration `envconfig:"WS_DASHBOARD_INTERVAL" default:"5s"`
+}
+
+// RetentionConfig removes conversations with no activity for Days, or for
+// the days given per workspace in WorkspaceDays ("campus-a:30,campus-b:365").
+// Zero keeps conversations forever. Mode "anonymize" keeps the conversations
+// for statistics but erases their owner and message contents instead.
+type RetentionConfig struct {
+	Days          int            `envconfig:"CONVERSATION_RETENTION_DAYS" default:"0"`
+	WorkspaceDays map[string]int `envconfig:"CONVERSATION_RETENTION_WORKSPACE_DAYS"`
+	Mode          string         `envconfig:"CONVERSATION_RETENTION_MODE" default:"delete"`
+	Interval      time.Duration  `envconfig:"CONVERSATION_RETENTION_INTERVAL" default:"24h"`
+}
+
+// Enabled reports whether any conversation expires.
+func (c RetentionConfig) Enabled() bool {
+	if c.Days > 0 {
+		return true
+	}
+	for _, days := range c.WorkspaceDays {
+		if days > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ShareConfig controls public conversation share links. Links expire after
+// TTL unless created with a shorter or longer expiry up to MaxTTL; URL is
+// the public base the token is appended to, "/share" on this server by
+// default.
+type ShareConfig struct {
+	TTL    time.Duration `envconfig:"CONVERSATION_SHARE_TTL" default:"168h"`
+	MaxTTL time.Duration `envconfig:"CONVERSATION_SHARE_MAX_TTL" default:"720h"`
+	URL    string        `envconfig:"CONVERSATION_SHARE_URL"`
+}
+
+// AnalyticsConfig controls the daily statistics snapshots. Each day is
+// recorded into daily_stats after midnight in Timezone (the server's local
+// time when empty), and on startup the last BackfillDays days without a
+// snapshot are filled in.
+//
+// Response metrics older than RawRetentionDays are rolled up into hourly
+// totals, and those older than HourlyRetentionDays into daily totals. Zero
+// keeps them as they are.
+//
+// Questions answered without any document, or whose best vector match is
+// below GapMinScore, are kept as knowledge gaps; every Monday they are
+// clustered at GapSimilarity into a report on the previous week.
+//
+// Analytics are recorded off the chat path: events wait in a queue of
+// QueueSize and are written in batches of up to BatchSize, at least every
+// FlushInterval. Events arriving while the queue is full are dropped.
+type AnalyticsConfig struct {
+	DailySnapshot bool   `envconfig:"ANALYTICS_DAILY_SNAPSHOT" default:"true"`
+	Timezone      string `envconfig:"ANALYTICS_TIMEZONE"`
+	BackfillDays  int    `envconfig:"ANALYTICS_BACKFILL_DAYS" default:"7"`
+
+	RawRetentionDays    int `envconfig:"ANALYTICS_RAW_RETENTION_DAYS" default:"30"`
+	HourlyRetentionDays int `envconfig:"ANALYTICS_HOURLY_RETENTION_DAYS" default:"180"`
+
+	GapReport     bool    `envconfig:"ANALYTICS_GAP_REPORT" default:"true"`
+	GapMinScore   float64 `envconfig:"ANALYTICS_GAP_MIN_SCORE" default:"0.35"`
+	GapSimilarity float64 `envconfig:"ANALYTICS_GAP_SIMILARITY" default:"0.8"`
+
+	QueueSize     int           `envconfig:"ANALYTICS_QUEUE_SIZE" default:"1000"`
+	BatchSize     int           `envconfig:"ANALYTICS_BATCH_SIZE" default:"50"`
+	FlushInterval time.Duration `envconfig:"ANALYTICS_FLUSH_INTERVAL" default:"2s"`
+}
+
+// Location is the time zone days are counted in.
+func (c AnalyticsConfig) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(c.Timezone)
+}
+
+// AlertConfig controls anomaly alerts. Every Interval the chat error rate and
+// OpenAI failure rate over that interval, and the p95 response time, are
+// compared with their thresholds; a zero threshold disables the rule, and
+// intervals with fewer than MinSamples requests are not judged. Alerts are
+// sent when a rule starts firing, every RepeatInterval while it keeps firing
+// (zero: only once) and when it resolves, to the alert.fired/alert.resolved
+// webhooks, SlackWebhookURL and EmailTo.
+type AlertConfig struct {
+	Enabled    bool          `envconfig:"ALERT_ENABLED" default:"false"`
+	Interval   time.Duration `envconfig:"ALERT_INTERVAL" default:"5m"`
+	MinSamples int           `envconfig:"ALERT_MIN_SAMPLES" default:"20"`
+
+	ChatErrorRate  float64       `envconfig:"ALERT_CHAT_ERROR_RATE" default:"0.05"`
+	P95Latency     time.Duration `envconfig:"ALERT_P95_LATENCY" default:"20s"`
+	LLMFailureRate float64       `envconfig:"ALERT_LLM_FAILURE_RATE" default:"0.1"`
+	RepeatInterval time.Duration `envconfig:"ALERT_REPEAT_INTERVAL" default:"1h"`
+
+	SlackWebhookURL string   `envconfig:"ALERT_SLACK_WEBHOOK_URL"`
+	EmailTo         []string `envconfig:"ALERT_EMAIL_TO"`
+}
+
+// MailConfig configures outgoing mail. Without SMTP_HOST mail is only logged.
+type MailConfig struct {
+	SMTPHost     string `envconfig:"SMTP_HOST"`
+	SMTPPort     int    `envconfig:"SMTP_PORT" default:"587"`
+	SMTPUsername string `envconfig:"SMTP_USERNAME"`
+	SMTPPassword string `envconfig:"SMTP_PASSWORD"`
+	From         string `envconfig:"MAIL_FROM" default:"no-reply@yuon.local"`
 }
 
 type StorageConfig struct {
@@ -75,6 +400,43 @@ type StorageConfig struct {
 	BaseURL    string `envconfig:"S3_BASE_URL"`
 }
 
+type DocumentConfig struct {
+	RequiredMetadata []string          `envconfig:"DOCUMENT_METADATA_REQUIRED"`
+	Categories       []string          `envconfig:"DOCUMENT_METADATA_CATEGORIES"`
+	MetadataTypes    map[string]string `envconfig:"DOCUMENT_METADATA_TYPES"`
+
+	MaxResumableUploadMB int `envconfig:"DOCUMENT_RESUMABLE_MAX_MB" default:"200"`
+	// ResumableCleanupInterval is how often expired resumable uploads are
+	// aborted in storage.
+	ResumableCleanupInterval time.Duration `envconfig:"DOCUMENT_RESUMABLE_CLEANUP_INTERVAL" default:"1h"`
+
+	// IdempotencyTTL is how long an Idempotency-Key on create, upload and
+	// bulk-ingest replays the original response.
+	IdempotencyTTL time.Duration `envconfig:"IDEMPOTENCY_TTL" default:"24h"`
+	// IdempotencyPruneInterval is how often expired Idempotency-Key records
+	// are deleted; zero keeps them.
+	IdempotencyPruneInterval time.Duration `envconfig:"IDEMPOTENCY_PRUNE_INTERVAL" default:"1h"`
+}
+
+// VectorStoreConfig selects the vector backend. pgvector and Weaviate reuse
+// the QDRANT_VECTOR_SIZE and QDRANT_NAMED_VECTORS layout.
+type VectorStoreConfig struct {
+	Backend string `envconfig:"VECTOR_STORE" default:"qdrant"`
+	// ValidateDimensions probes the embedding model at startup and fails when
+	// its output size differs from the store's vector size.
+	ValidateDimensions bool `envconfig:"VECTOR_VALIDATE_DIMENSIONS" default:"true"`
+
+	WeaviateURL    string `envconfig:"WEAVIATE_URL" default:"http://localhost:8081"`
+	WeaviateAPIKey string `envconfig:"WEAVIATE_API_KEY"`
+	WeaviateClass  string `envconfig:"WEAVIATE_CLASS" default:"Document"`
+}
+
+type AntivirusConfig struct {
+	Enabled bool          `envconfig:"ANTIVIRUS_ENABLED" default:"false"`
+	Address string        `envconfig:"CLAMAV_ADDRESS" default:"localhost:3310"`
+	Timeout time.Duration `envconfig:"CLAMAV_TIMEOUT" default:"30s"`
+}
+
 func Load() (*Config, error) {
 	var cfg Config
 
@@ -89,6 +451,11 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
+const (
+	maxAccessTokenTTL  = 24 * time.Hour
+	maxRefreshTokenTTL = 90 * 24 * time.Hour
+)
+
 func (c *Config) Validate() error {
 	if c.Server.Port < 1 || c.Server.Port > 65535 {
 		return fmt.Errorf("유효하지 않은 서버 포트: %d", c.Server.Port)
@@ -98,10 +465,102 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("유효하지 않은 서버 모드: %s (debug 또는 release 사용)", c.Server.Mode)
 	}
 
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("SERVER_TRUSTED_PROXIES에 IP 또는 CIDR이 아닌 값이 있습니다: %s", proxy)
+		}
+	}
+
+	if c.OIDC.Enabled {
+		if c.OIDC.HostedDomain == "" && len(c.OIDC.AllowedDomains) == 0 {
+			return fmt.Errorf("OIDC_ENABLED이면 OIDC_HOSTED_DOMAIN 또는 OIDC_ALLOWED_DOMAINS로 로그인할 수 있는 도메인을 제한해야 합니다")
+		}
+		if c.OIDC.DefaultRole != "user" && c.OIDC.DefaultRole != "editor" {
+			return fmt.Errorf("OIDC_DEFAULT_ROLE은 user 또는 editor여야 합니다: %s", c.OIDC.DefaultRole)
+		}
+	}
+
 	if c.App.Environment != "development" && c.App.Environment != "staging" && c.App.Environment != "production" {
 		return fmt.Errorf("유효하지 않은 환경: %s", c.App.Environment)
 	}
 
+	if c.Auth.AccessTokenTTL < time.Minute || c.Auth.AccessTokenTTL > maxAccessTokenTTL {
+		return fmt.Errorf("JWT_ACCESS_TTL은 1m 이상 %s 이하여야 합니다: %s", maxAccessTokenTTL, c.Auth.AccessTokenTTL)
+	}
+	if c.Auth.RefreshTokenTTL <= c.Auth.AccessTokenTTL || c.Auth.RefreshTokenTTL > maxRefreshTokenTTL {
+		return fmt.Errorf("JWT_REFRESH_TTL은 JWT_ACCESS_TTL보다 길고 %s 이하여야 합니다: %s", maxRefreshTokenTTL, c.Auth.RefreshTokenTTL)
+	}
+
+	if err := validator.CheckMetadataTypes(c.Document.MetadataTypes); err != nil {
+		return fmt.Errorf("DOCUMENT_METADATA_TYPES가 올바르지 않습니다: %w", err)
+	}
+
+	if c.Webhook.Enabled && (c.Webhook.MaxAttempts < 1 || c.Webhook.Timeout <= 0) {
+		return fmt.Errorf("WEBHOOK_MAX_ATTEMPTS는 1 이상, WEBHOOK_TIMEOUT은 0보다 커야 합니다")
+	}
+
+	if c.WebSocket.PingInterval < time.Second {
+		return fmt.Errorf("WS_PING_INTERVAL은 1s 이상이어야 합니다: %s", c.WebSocket.PingInterval)
+	}
+	if c.WebSocket.MessagesPerMinute < 0 || c.WebSocket.MessageBurst < 0 {
+		return fmt.Errorf("WS_MESSAGES_PER_MINUTE와 WS_MESSAGE_BURST는 0 이상이어야 합니다")
+	}
+	if c.WebSocket.DashboardInterval != 0 && c.WebSocket.DashboardInterval < time.Second {
+		return fmt.Errorf("WS_DASHBOARD_INTERVAL은 0 또는 1s 이상이어야 합니다: %s", c.WebSocket.DashboardInterval)
+	}
+
+	if c.Retention.Days < 0 {
+		return fmt.Errorf("CONVERSATION_RETENTION_DAYS는 0 이상이어야 합니다: %d", c.Retention.Days)
+	}
+	for workspace, days := range c.Retention.WorkspaceDays {
+		if days < 0 {
+			return fmt.Errorf("CONVERSATION_RETENTION_WORKSPACE_DAYS의 %s 값은 0 이상이어야 합니다: %d", workspace, days)
+		}
+	}
+	if c.Retention.Mode != "delete" && c.Retention.Mode != "anonymize" {
+		return fmt.Errorf("CONVERSATION_RETENTION_MODE는 delete 또는 anonymize여야 합니다: %s", c.Retention.Mode)
+	}
+	if c.Retention.Enabled() && c.Retention.Interval < time.Minute {
+		return fmt.Errorf("CONVERSATION_RETENTION_INTERVAL은 1m 이상이어야 합니다: %s", c.Retention.Interval)
+	}
+	if _, err := c.OpenAI.ModelPrices(); err != nil {
+		return err
+	}
+	if c.Share.TTL <= 0 || c.Share.MaxTTL < c.Share.TTL {
+		return fmt.Errorf("CONVERSATION_SHARE_TTL은 0보다 크고 CONVERSATION_SHARE_MAX_TTL 이하여야 합니다: %s, %s", c.Share.TTL, c.Share.MaxTTL)
+	}
+
+	if _, err := c.Analytics.Location(); err != nil {
+		return fmt.Errorf("ANALYTICS_TIMEZONE이 올바르지 않습니다: %w", err)
+	}
+	if c.Analytics.BackfillDays < 0 {
+		return fmt.Errorf("ANALYTICS_BACKFILL_DAYS는 0 이상이어야 합니다: %d", c.Analytics.BackfillDays)
+	}
+	if c.Analytics.RawRetentionDays < 0 || c.Analytics.HourlyRetentionDays < 0 {
+		return fmt.Errorf("ANALYTICS_RAW_RETENTION_DAYS와 ANALYTICS_HOURLY_RETENTION_DAYS는 0 이상이어야 합니다")
+	}
+	if c.Analytics.HourlyRetentionDays > 0 && c.Analytics.HourlyRetentionDays < c.Analytics.RawRetentionDays {
+		return fmt.Errorf("ANALYTICS_HOURLY_RETENTION_DAYS는 ANALYTICS_RAW_RETENTION_DAYS 이상이어야 합니다: %d, %d", c.Analytics.HourlyRetentionDays, c.Analytics.RawRetentionDays)
+	}
+	if c.Analytics.GapMinScore < 0 || c.Analytics.GapMinScore > 1 || c.Analytics.GapSimilarity <= 0 || c.Analytics.GapSimilarity > 1 {
+		return fmt.Errorf("ANALYTICS_GAP_MIN_SCORE는 0~1, ANALYTICS_GAP_SIMILARITY는 0 초과 1 이하여야 합니다: %g, %g", c.Analytics.GapMinScore, c.Analytics.GapSimilarity)
+	}
+	if c.Analytics.QueueSize < 1 || c.Analytics.BatchSize < 1 || c.Analytics.FlushInterval <= 0 {
+		return fmt.Errorf("ANALYTICS_QUEUE_SIZE와 ANALYTICS_BATCH_SIZE는 1 이상, ANALYTICS_FLUSH_INTERVAL은 0보다 커야 합니다")
+	}
+
+	if c.Alert.Enabled {
+		if c.Alert.Interval < 10*time.Second || c.Alert.MinSamples < 1 {
+			return fmt.Errorf("ALERT_INTERVAL은 10s 이상, ALERT_MIN_SAMPLES는 1 이상이어야 합니다")
+		}
+		if c.Alert.ChatErrorRate < 0 || c.Alert.ChatErrorRate > 1 || c.Alert.LLMFailureRate < 0 || c.Alert.LLMFailureRate > 1 {
+			return fmt.Errorf("ALERT_CHAT_ERROR_RATE와 ALERT_LLM_FAILURE_RATE는 0~1 사이여야 합니다")
+		}
+		if c.Alert.P95Latency < 0 || c.Alert.RepeatInterval < 0 {
+			return fmt.Errorf("ALERT_P95_LATENCY와 ALERT_REPEAT_INTERVAL은 0 이상이어야 합니다")
+		}
+	}
+
 	return nil
 }
 
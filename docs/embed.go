@@ -1,6 +0,0 @@
-package docs
-
-import _ "embed"
-
-//go:embed openapi.yaml
-var OpenAPISpec []byte
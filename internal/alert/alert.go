@@ -0,0 +1,287 @@
+// Package alert watches chat error rate, response latency and OpenAI
+// failure rate, and notifies operators when one crosses its threshold.
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	RuleChatErrorRate  = "chat_error_rate"
+	RuleP95Latency     = "p95_latency"
+	RuleLLMFailureRate = "llm_failure_rate"
+
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// Counters are cumulative counts since start; rules look at how much they
+// grew over an interval.
+type Counters struct {
+	ChatRequests int64
+	ChatErrors   int64
+	LLMRequests  int64
+	LLMFailures  int64
+}
+
+// Source provides the figures rules are evaluated on.
+type Source interface {
+	AlertCounters() Counters
+	// P95Latency is the 95th percentile response time of the answers in
+	// [from, to) and how many answers it covers.
+	P95Latency(ctx context.Context, from, to time.Time) (time.Duration, int64, error)
+}
+
+// Thresholds fire a rule when exceeded; a zero threshold disables its rule.
+// Rates are fractions of 1. Intervals with fewer than MinSamples requests
+// or answers leave their rule as it was.
+type Thresholds struct {
+	ChatErrorRate  float64
+	P95Latency     time.Duration
+	LLMFailureRate float64
+	MinSamples     int64
+}
+
+// Alert is a notification that a rule started or stopped firing. Value and
+// Threshold are fractions of 1 for rates and milliseconds for latency.
+type Alert struct {
+	Rule      string    `json:"rule"`
+	Status    string    `json:"status"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Samples   int64     `json:"samples"`
+	Message   string    `json:"message"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	// FiredAt is when the rule started firing.
+	FiredAt time.Time `json:"firedAt"`
+}
+
+// Notifier delivers alerts to one channel.
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// RuleStatus is the outcome of the latest evaluation of a rule.
+type RuleStatus struct {
+	Rule        string     `json:"rule"`
+	Enabled     bool       `json:"enabled"`
+	Firing      bool       `json:"firing"`
+	Value       float64    `json:"value"`
+	Threshold   float64    `json:"threshold"`
+	Samples     int64      `json:"samples"`
+	FiringSince *time.Time `json:"firingSince"`
+	EvaluatedAt *time.Time `json:"evaluatedAt"`
+}
+
+type ruleState struct {
+	status     RuleStatus
+	notifiedAt time.Time
+}
+
+// Monitor evaluates the rules every interval over that interval. A firing
+// rule is notified when it starts firing, every repeat while it keeps
+// firing unless repeat is zero, and once it resolves.
+type Monitor struct {
+	source     Source
+	thresholds Thresholds
+	interval   time.Duration
+	repeat     time.Duration
+	notifiers  []Notifier
+
+	mu    sync.Mutex
+	last  Counters
+	rules []*ruleState
+}
+
+func NewMonitor(source Source, thresholds Thresholds, interval, repeat time.Duration, notifiers ...Notifier) *Monitor {
+	m := &Monitor{
+		source:     source,
+		thresholds: thresholds,
+		interval:   interval,
+		repeat:     repeat,
+		notifiers:  notifiers,
+	}
+	for _, rule := range []struct {
+		name      string
+		threshold float64
+	}{
+		{RuleChatErrorRate, thresholds.ChatErrorRate},
+		{RuleP95Latency, float64(thresholds.P95Latency.Milliseconds())},
+		{RuleLLMFailureRate, thresholds.LLMFailureRate},
+	} {
+		m.rules = append(m.rules, &ruleState{status: RuleStatus{
+			Rule:      rule.name,
+			Enabled:   rule.threshold > 0,
+			Threshold: rule.threshold,
+		}})
+	}
+	return m
+}
+
+// Interval is how often, and over how long, rules are evaluated.
+func (m *Monitor) Interval() time.Duration {
+	return m.interval
+}
+
+// Run evaluates the rules every interval until ctx is done.
+func (m *Monitor) Run(ctx context.Context) {
+	m.mu.Lock()
+	m.last = m.source.AlertCounters()
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.Evaluate(ctx, now)
+		}
+	}
+}
+
+// Evaluate judges the interval ending at now and sends the alerts it
+// raises, which it also returns.
+func (m *Monitor) Evaluate(ctx context.Context, now time.Time) []Alert {
+	from := now.Add(-m.interval)
+	current := m.source.AlertCounters()
+
+	m.mu.Lock()
+	delta := Counters{
+		ChatRequests: current.ChatRequests - m.last.ChatRequests,
+		ChatErrors:   current.ChatErrors - m.last.ChatErrors,
+		LLMRequests:  current.LLMRequests - m.last.LLMRequests,
+		LLMFailures:  current.LLMFailures - m.last.LLMFailures,
+	}
+	m.last = current
+	m.mu.Unlock()
+
+	var latency time.Duration
+	var answers int64
+	if m.thresholds.P95Latency > 0 {
+		var err error
+		latency, answers, err = m.source.P95Latency(ctx, from, now)
+		if err != nil {
+			slog.WarnContext(ctx, "응답 지연 조회 실패", "error", err)
+			answers = 0
+		}
+	}
+
+	values := map[string]struct {
+		value   float64
+		samples int64
+	}{
+		RuleChatErrorRate:  {rate(delta.ChatErrors, delta.ChatRequests), delta.ChatRequests},
+		RuleP95Latency:     {float64(latency.Milliseconds()), answers},
+		RuleLLMFailureRate: {rate(delta.LLMFailures, delta.LLMRequests), delta.LLMRequests},
+	}
+
+	m.mu.Lock()
+	var alerts []Alert
+	for _, rule := range m.rules {
+		st := &rule.status
+		if !st.Enabled {
+			continue
+		}
+		v := values[st.Rule]
+		evaluatedAt := now
+		st.EvaluatedAt = &evaluatedAt
+		st.Value, st.Samples = v.value, v.samples
+		if v.samples < m.thresholds.MinSamples {
+			continue
+		}
+
+		exceeded := v.value > st.Threshold
+		switch {
+		case exceeded && !st.Firing:
+			since := now
+			st.Firing, st.FiringSince = true, &since
+		case exceeded:
+			if m.repeat <= 0 || now.Sub(rule.notifiedAt) < m.repeat {
+				continue
+			}
+		case st.Firing:
+			st.Firing = false
+		default:
+			continue
+		}
+
+		a := Alert{
+			Rule:      st.Rule,
+			Status:    StatusFiring,
+			Value:     v.value,
+			Threshold: st.Threshold,
+			Samples:   v.samples,
+			From:      from,
+			To:        now,
+			FiredAt:   *st.FiringSince,
+		}
+		if !st.Firing {
+			a.Status = StatusResolved
+			st.FiringSince = nil
+		}
+		a.Message = describe(a, m.interval)
+		rule.notifiedAt = now
+		alerts = append(alerts, a)
+	}
+	m.mu.Unlock()
+
+	for _, a := range alerts {
+		slog.WarnContext(ctx, "경보", "rule", a.Rule, "status", a.Status, "value", a.Value, "threshold", a.Threshold)
+		for _, n := range m.notifiers {
+			if err := n.Notify(ctx, a); err != nil {
+				slog.ErrorContext(ctx, "경보 알림 전송 실패", "rule", a.Rule, "error", err)
+			}
+		}
+	}
+	return alerts
+}
+
+// Status returns the latest evaluation of every rule.
+func (m *Monitor) Status() []RuleStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make([]RuleStatus, len(m.rules))
+	for i, rule := range m.rules {
+		statuses[i] = rule.status
+	}
+	return statuses
+}
+
+func rate(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// describe words a for people, e.g. "채팅 오류율 12.0% (기준 5.0%, 최근 5m0s 동안 40건)".
+func describe(a Alert, interval time.Duration) string {
+	var name, value, threshold string
+	switch a.Rule {
+	case RuleChatErrorRate:
+		name = "채팅 오류율"
+	case RuleLLMFailureRate:
+		name = "OpenAI 요청 실패율"
+	case RuleP95Latency:
+		name = "p95 응답 시간"
+	}
+	if a.Rule == RuleP95Latency {
+		value = (time.Duration(a.Value) * time.Millisecond).String()
+		threshold = (time.Duration(a.Threshold) * time.Millisecond).String()
+	} else {
+		value = fmt.Sprintf("%.1f%%", a.Value*100)
+		threshold = fmt.Sprintf("%.1f%%", a.Threshold*100)
+	}
+	state := "기준 초과"
+	if a.Status == StatusResolved {
+		state = "정상 회복"
+	}
+	return fmt.Sprintf("%s %s: %s (기준 %s, 최근 %s 동안 %d건)", name, state, value, threshold, interval, a.Samples)
+}
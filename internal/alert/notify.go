@@ -0,0 +1,83 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"yuon/internal/mail"
+	"yuon/internal/webhook"
+)
+
+// WebhookNotifier publishes alerts as alert.fired and alert.resolved events
+// to the subscribed webhooks.
+type WebhookNotifier struct {
+	Dispatcher *webhook.Dispatcher
+}
+
+func (n WebhookNotifier) Notify(ctx context.Context, a Alert) error {
+	event := webhook.EventAlertFired
+	if a.Status == StatusResolved {
+		event = webhook.EventAlertResolved
+	}
+	n.Dispatcher.Publish(ctx, event, a)
+	return nil
+}
+
+// SlackNotifier posts alerts to a Slack incoming webhook URL.
+type SlackNotifier struct {
+	URL    string
+	client *http.Client
+}
+
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{URL: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(map[string]string{"text": title(a) + "\n" + a.Message})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack webhook request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// MailNotifier mails alerts to each of To.
+type MailNotifier struct {
+	Mailer mail.Mailer
+	To     []string
+}
+
+func (n MailNotifier) Notify(ctx context.Context, a Alert) error {
+	body := fmt.Sprintf("%s\n\n기간: %s ~ %s\n발생 시각: %s\n",
+		a.Message, a.From.Format(time.RFC3339), a.To.Format(time.RFC3339), a.FiredAt.Format(time.RFC3339))
+	for _, to := range n.To {
+		if err := n.Mailer.Send(ctx, mail.Message{To: to, Subject: title(a), Body: body}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func title(a Alert) string {
+	if a.Status == StatusResolved {
+		return "[YUON 경보 해소] " + a.Rule
+	}
+	return "[YUON 경보] " + a.Rule
+}
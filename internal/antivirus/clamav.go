@@ -0,0 +1,123 @@
+package antivirus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"yuon/configuration"
+)
+
+const clamdChunkSize = 64 * 1024
+
+// Result is the outcome of a scan.
+type Result struct {
+	Infected  bool
+	Signature string
+}
+
+// Scanner scans file contents for malware, reading them from r as they
+// are streamed to the scanner.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (*Result, error)
+}
+
+// ClamAVScanner talks to clamd over its INSTREAM protocol.
+type ClamAVScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewScanner returns nil when scanning is disabled.
+func NewScanner(cfg *configuration.AntivirusConfig) Scanner {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+
+	network := "tcp"
+	address := cfg.Address
+	if strings.HasPrefix(address, "unix://") {
+		network = "unix"
+		address = strings.TrimPrefix(address, "unix://")
+	}
+
+	return &ClamAVScanner{
+		network: network,
+		address: address,
+		timeout: cfg.Timeout,
+	}
+}
+
+func (s *ClamAVScanner) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return nil, fmt.Errorf("clamd 연결 실패: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("clamd 명령 전송 실패: %w", err)
+	}
+
+	size := make([]byte, 4)
+	chunk := make([]byte, clamdChunkSize)
+	for {
+		n, err := io.ReadFull(r, chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return nil, fmt.Errorf("clamd 스트림 전송 실패: %w", err)
+			}
+			if _, err := conn.Write(chunk[:n]); err != nil {
+				return nil, fmt.Errorf("clamd 스트림 전송 실패: %w", err)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("검사할 파일 읽기 실패: %w", err)
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return nil, fmt.Errorf("clamd 스트림 종료 실패: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("clamd 응답 수신 실패: %w", err)
+	}
+
+	return parseReply(string(bytes.TrimRight(reply, "\x00\n")))
+}
+
+func parseReply(reply string) (*Result, error) {
+	reply = strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+
+	switch {
+	case reply == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(reply, "FOUND"):
+		return &Result{
+			Infected:  true,
+			Signature: strings.TrimSpace(strings.TrimSuffix(reply, "FOUND")),
+		}, nil
+	default:
+		return nil, fmt.Errorf("clamd 오류 응답: %s", reply)
+	}
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	ActionUploadInfected      = "document.upload.infected"
+	ActionConversationsPurged = "conversation.retention.purge"
+)
+
+// Event is a single security-relevant action recorded in the audit log.
+type Event struct {
+	ID         int64                  `json:"id"`
+	Action     string                 `json:"action"`
+	ActorID    string                 `json:"actorId,omitempty"`
+	ActorName  string                 `json:"actorName,omitempty"`
+	TargetType string                 `json:"targetType,omitempty"`
+	TargetID   string                 `json:"targetId,omitempty"`
+	IP         string                 `json:"ip,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+}
+
+// Logger persists audit events.
+type Logger interface {
+	Record(ctx context.Context, event Event) error
+}
+
+type PostgresLogger struct {
+	db *sql.DB
+}
+
+func NewPostgresLogger(db *sql.DB) *PostgresLogger {
+	return &PostgresLogger{db: db}
+}
+
+func (l *PostgresLogger) Record(ctx context.Context, event Event) error {
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return fmt.Errorf("marshal audit details failed: %w", err)
+	}
+
+	_, err = l.db.ExecContext(ctx, `
+		INSERT INTO audit_logs (action, actor_id, actor_name, target_type, target_id, ip, details)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, event.Action, event.ActorID, event.ActorName, event.TargetType, event.TargetID, event.IP, details)
+	if err != nil {
+		return fmt.Errorf("insert audit log failed: %w", err)
+	}
+	return nil
+}
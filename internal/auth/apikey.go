@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes granted to API keys and service accounts.
+const (
+	ScopeDocumentsRead  = "documents:read"
+	ScopeDocumentsWrite = "documents:write"
+	ScopeChatRead       = "chat:read"
+	ScopeChatWrite      = "chat:write"
+)
+
+var validScopes = map[string]bool{
+	ScopeDocumentsRead:  true,
+	ScopeDocumentsWrite: true,
+	ScopeChatRead:       true,
+	ScopeChatWrite:      true,
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !validScopes[scope] {
+			return fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+	return nil
+}
+
+const apiKeyPrefix = "yk_"
+
+var (
+	ErrInvalidAPIKey        = errors.New("invalid api key")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrAPIKeysNotConfigured = errors.New("api key store is not configured")
+)
+
+type APIKey struct {
+	ID         string
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	Workspace  string
+	CreatedBy  string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// HasScope reports whether the key was granted scope.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyUsage struct {
+	KeyID      string     `json:"keyId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Requests   int64      `json:"requests"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// SetAPIKeyStore enables API key authentication.
+func (m *Manager) SetAPIKeyStore(store APIKeyStore) {
+	m.apiKeys = store
+}
+
+// CreateAPIKey issues a new key. The plaintext key is only returned here;
+// the store keeps its SHA-256 hash.
+func (m *Manager) CreateAPIKey(name string, scopes []string, workspace, createdBy string) (string, *APIKey, error) {
+	if m.apiKeys == nil {
+		return "", nil, ErrAPIKeysNotConfigured
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, errors.New("api key name is required")
+	}
+	if err := validateScopes(scopes); err != nil {
+		return "", nil, err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("api key generation failed: %w", err)
+	}
+	raw := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	key := &APIKey{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Prefix:    raw[:len(apiKeyPrefix)+6],
+		KeyHash:   hashToken(raw),
+		Scopes:    scopes,
+		Workspace: workspace,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := m.apiKeys.Create(context.Background(), key); err != nil {
+		return "", nil, err
+	}
+	return raw, key, nil
+}
+
+func (m *Manager) ListAPIKeys() ([]*APIKey, error) {
+	if m.apiKeys == nil {
+		return nil, ErrAPIKeysNotConfigured
+	}
+	return m.apiKeys.List(context.Background())
+}
+
+func (m *Manager) RevokeAPIKey(id string) error {
+	if m.apiKeys == nil {
+		return ErrAPIKeysNotConfigured
+	}
+	return m.apiKeys.Revoke(context.Background(), id)
+}
+
+// ValidateAPIKey resolves a plaintext key to an active API key.
+func (m *Manager) ValidateAPIKey(raw string) (*APIKey, error) {
+	if m.apiKeys == nil {
+		return nil, ErrAPIKeysNotConfigured
+	}
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := m.apiKeys.FindByHash(context.Background(), hashToken(raw))
+	if err != nil || key.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// RecordAPIKeyUsage counts one request against the key.
+func (m *Manager) RecordAPIKeyUsage(ctx context.Context, id string) error {
+	if m.apiKeys == nil {
+		return ErrAPIKeysNotConfigured
+	}
+	return m.apiKeys.RecordUsage(ctx, id)
+}
+
+// APIKeyUsage returns per-key request counts for the last days days.
+func (m *Manager) APIKeyUsage(days int) ([]APIKeyUsage, error) {
+	if m.apiKeys == nil {
+		return nil, ErrAPIKeysNotConfigured
+	}
+	if days <= 0 {
+		days = 30
+	}
+	since := time.Now().UTC().AddDate(0, 0, -(days - 1))
+	return m.apiKeys.Usage(context.Background(), since)
+}
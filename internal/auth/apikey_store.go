@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type APIKeyStore interface {
+	Create(ctx context.Context, k *APIKey) error
+	FindByHash(ctx context.Context, hash string) (*APIKey, error)
+	List(ctx context.Context) ([]*APIKey, error)
+	Revoke(ctx context.Context, id string) error
+	RecordUsage(ctx context.Context, id string) error
+	Usage(ctx context.Context, since time.Time) ([]APIKeyUsage, error)
+}
+
+type PostgresAPIKeyStore struct {
+	db *sql.DB
+}
+
+func NewPostgresAPIKeyStore(db *sql.DB) *PostgresAPIKeyStore {
+	return &PostgresAPIKeyStore{db: db}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, workspace, created_by, created_at, last_used_at, revoked_at`
+
+func (s *PostgresAPIKeyStore) Create(ctx context.Context, k *APIKey) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO api_keys (id, name, prefix, key_hash, scopes, workspace, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		k.ID, k.Name, k.Prefix, k.KeyHash, pq.Array(k.Scopes), k.Workspace, k.CreatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("create api key failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresAPIKeyStore) FindByHash(ctx context.Context, hash string) (*APIKey, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, hash)
+	return scanAPIKey(row)
+}
+
+func (s *PostgresAPIKeyStore) List(ctx context.Context) ([]*APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (s *PostgresAPIKeyStore) Revoke(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("revoke api key failed: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *PostgresAPIKeyStore) RecordUsage(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("record api key usage failed: %w", err)
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO api_key_usage (key_id, day, requests) VALUES ($1, CURRENT_DATE, 1)
+		ON CONFLICT (key_id, day) DO UPDATE SET requests = api_key_usage.requests + 1`, id)
+	if err != nil {
+		return fmt.Errorf("record api key usage failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresAPIKeyStore) Usage(ctx context.Context, since time.Time) ([]APIKeyUsage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT k.id, k.name, k.prefix, COALESCE(SUM(u.requests), 0), k.last_used_at
+		FROM api_keys k
+		LEFT JOIN api_key_usage u ON u.key_id = k.id AND u.day >= $1::date
+		GROUP BY k.id, k.name, k.prefix, k.last_used_at
+		ORDER BY 4 DESC, k.name`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []APIKeyUsage
+	for rows.Next() {
+		var (
+			u        APIKeyUsage
+			lastUsed sql.NullTime
+		)
+		if err := rows.Scan(&u.KeyID, &u.Name, &u.Prefix, &u.Requests, &lastUsed); err != nil {
+			return nil, err
+		}
+		if lastUsed.Valid {
+			u.LastUsedAt = &lastUsed.Time
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row rowScanner) (*APIKey, error) {
+	var (
+		k         APIKey
+		lastUsed  sql.NullTime
+		revokedAt sql.NullTime
+	)
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.KeyHash, pq.Array(&k.Scopes), &k.Workspace, &k.CreatedBy, &k.CreatedAt, &lastUsed, &revokedAt); err != nil {
+		return nil, err
+	}
+	if lastUsed.Valid {
+		k.LastUsedAt = &lastUsed.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return &k, nil
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrDirectoryInvalidCredentials is returned by a DirectoryAuthenticator when
+// the directory has no such user or rejects the password. Login then falls
+// back to local users.
+var ErrDirectoryInvalidCredentials = errors.New("directory rejected credentials")
+
+// DirectoryIdentity is a user verified by an external directory. DN names
+// the entry and is what local users are linked to.
+type DirectoryIdentity struct {
+	DN    string
+	Email string
+	Name  string
+	Role  string
+}
+
+// DirectoryAuthenticator verifies passwords against an external directory
+// such as LDAP or Active Directory.
+type DirectoryAuthenticator interface {
+	Authenticate(ctx context.Context, username, password string) (*DirectoryIdentity, error)
+}
+
+// SetDirectoryAuthenticator makes Login try the directory before local
+// passwords.
+func (m *Manager) SetDirectoryAuthenticator(directory DirectoryAuthenticator) {
+	m.directory = directory
+}
+
+// directoryLogin returns the local user for a directory login. It returns
+// nil and no error when Login should fall back to local passwords: no
+// directory, rejected credentials or an unreachable server.
+func (m *Manager) directoryLogin(ctx context.Context, username, password string) (*User, error) {
+	if m.directory == nil {
+		return nil, nil
+	}
+	identity, err := m.directory.Authenticate(ctx, username, password)
+	if err != nil {
+		if !errors.Is(err, ErrDirectoryInvalidCredentials) {
+			slog.Warn("디렉터리 인증 실패, 로컬 사용자로 대체", "error", err)
+		}
+		return nil, nil
+	}
+	return m.directoryUser(ctx, identity)
+}
+
+// directoryProvider is the provider directory entries are linked under in
+// the SSO store, with the entry DN as subject.
+const directoryProvider = "ldap"
+
+// directoryUser returns the local user linked to the DN of identity,
+// provisioning it with the directory role on first login. An entry not
+// linked yet is matched by email and linked, except to root and admin
+// accounts: anyone able to set that email in the directory would otherwise
+// take them over, so those keep signing in with their local password.
+// Existing users keep their role, as with OIDC and SAML.
+func (m *Manager) directoryUser(ctx context.Context, identity *DirectoryIdentity) (*User, error) {
+	if m.ssoStore != nil && identity.DN != "" {
+		userID, err := m.ssoStore.FindIdentity(ctx, directoryProvider, identity.DN)
+		if err != nil {
+			return nil, err
+		}
+		if userID != "" {
+			user, err := m.store.FindByID(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			if user.ServiceAccount {
+				return nil, ErrInvalidClientCredentials
+			}
+			return user, nil
+		}
+	}
+
+	email := strings.ToLower(identity.Email)
+	user, err := m.store.FindByEmail(ctx, email)
+	if err == nil {
+		if user.ServiceAccount {
+			return nil, ErrInvalidClientCredentials
+		}
+		if user.Role == RoleRoot || user.Role == RoleAdmin {
+			slog.Warn("디렉터리 계정을 관리자 계정에 연결하지 않음, 로컬 비밀번호로 대체", "dn", identity.DN, "userId", user.ID)
+			return nil, nil
+		}
+		if err := m.linkDirectoryIdentity(ctx, identity, user.ID); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	secret, err := NewOIDCState()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	role := identity.Role
+	if !ValidRole(role) {
+		role = RoleUser
+	}
+	user = &User{
+		ID:           uuid.New().String(),
+		Email:        email,
+		PasswordHash: hash,
+		Role:         role,
+		Name:         identity.Name,
+		Active:       true,
+	}
+	if err := m.store.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := m.linkDirectoryIdentity(ctx, identity, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (m *Manager) linkDirectoryIdentity(ctx context.Context, identity *DirectoryIdentity, userID string) error {
+	if m.ssoStore == nil || identity.DN == "" {
+		return nil
+	}
+	return m.ssoStore.LinkIdentity(ctx, directoryProvider, identity.DN, userID)
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+// staticDirectory accepts password for identity and rejects anything else.
+type staticDirectory struct {
+	identity *DirectoryIdentity
+	password string
+}
+
+func (d staticDirectory) Authenticate(ctx context.Context, username, password string) (*DirectoryIdentity, error) {
+	if password != d.password {
+		return nil, ErrDirectoryInvalidCredentials
+	}
+	return d.identity, nil
+}
+
+func TestDirectoryUser(t *testing.T) {
+	ctx := context.Background()
+	admin := &User{ID: "admin", Email: "admin@example.com", Role: RoleAdmin, Active: true}
+	root := &User{ID: "root", Email: "root@example.com", Role: RoleRoot, Active: true}
+	member := &User{ID: "member", Email: "member@example.com", Role: RoleUser, Active: true}
+
+	t.Run("privileged accounts are not linked by email", func(t *testing.T) {
+		for _, target := range []*User{admin, root} {
+			sso := newMemorySSO()
+			m := NewManager("secret", newMemoryUsers(admin, root, member))
+			m.SetSSOStore(sso)
+
+			user, err := m.directoryUser(ctx, &DirectoryIdentity{DN: "uid=mallory,dc=example", Email: target.Email})
+			if err != nil || user != nil {
+				t.Fatalf("%s: directoryUser = %v, %v; want fallback to local password", target.Role, user, err)
+			}
+			if len(sso.links) != 0 {
+				t.Errorf("%s: identity linked: %v", target.Role, sso.links)
+			}
+		}
+	})
+
+	t.Run("other accounts are linked by email", func(t *testing.T) {
+		sso := newMemorySSO()
+		m := NewManager("secret", newMemoryUsers(member))
+		m.SetSSOStore(sso)
+
+		user, err := m.directoryUser(ctx, &DirectoryIdentity{DN: "uid=member,dc=example", Email: "Member@Example.com"})
+		if err != nil || user == nil || user.ID != member.ID {
+			t.Fatalf("directoryUser = %v, %v; want %s", user, err, member.ID)
+		}
+		if got := sso.links[directoryProvider+"|uid=member,dc=example"]; got != member.ID {
+			t.Errorf("linked to %q, want %q", got, member.ID)
+		}
+	})
+
+	t.Run("linked entries sign in by DN", func(t *testing.T) {
+		sso := newMemorySSO()
+		sso.links[directoryProvider+"|uid=boss,dc=example"] = admin.ID
+		m := NewManager("secret", newMemoryUsers(admin))
+		m.SetSSOStore(sso)
+
+		user, err := m.directoryUser(ctx, &DirectoryIdentity{DN: "uid=boss,dc=example", Email: "changed@example.com"})
+		if err != nil || user == nil || user.ID != admin.ID {
+			t.Fatalf("directoryUser = %v, %v; want %s", user, err, admin.ID)
+		}
+	})
+
+	t.Run("unknown entries are provisioned and linked", func(t *testing.T) {
+		sso := newMemorySSO()
+		users := newMemoryUsers()
+		m := NewManager("secret", users)
+		m.SetSSOStore(sso)
+
+		user, err := m.directoryUser(ctx, &DirectoryIdentity{DN: "uid=new,dc=example", Email: "new@example.com", Role: RoleEditor})
+		if err != nil || user == nil {
+			t.Fatalf("directoryUser = %v, %v", user, err)
+		}
+		if user.Role != RoleEditor {
+			t.Errorf("role = %s, want %s", user.Role, RoleEditor)
+		}
+		if got := sso.links[directoryProvider+"|uid=new,dc=example"]; got != user.ID {
+			t.Errorf("linked to %q, want %q", got, user.ID)
+		}
+	})
+}
+
+func TestDirectoryLoginCannotTakeOverAdmin(t *testing.T) {
+	admin := &User{ID: "admin", Email: "admin@example.com", PasswordHash: []byte("not a bcrypt hash"), Role: RoleAdmin, Active: true}
+	m := NewManager("secret", newMemoryUsers(admin))
+	m.SetSSOStore(newMemorySSO())
+	m.SetDirectoryAuthenticator(staticDirectory{
+		identity: &DirectoryIdentity{DN: "uid=mallory,dc=example", Email: admin.Email},
+		password: "directory-password",
+	})
+
+	if _, _, err := m.Login(admin.Email, "directory-password", "203.0.113.7"); err == nil {
+		t.Fatal("directory login with an admin's email succeeded")
+	}
+}
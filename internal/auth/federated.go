@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ssoCodeTTL is how long the client has to exchange an SSO login code.
+const ssoCodeTTL = 2 * time.Minute
+
+var (
+	ErrSSONotConfigured = errors.New("sso store is not configured")
+	ErrInvalidSSOCode   = errors.New("invalid or expired sso login code")
+	// ErrSSOLinkPasswordRequired means the code links an identity to a
+	// privileged account and the account password must confirm it.
+	ErrSSOLinkPasswordRequired = errors.New("password required to link sso identity")
+)
+
+// FederatedIdentity is a user as asserted by an external identity provider.
+type FederatedIdentity struct {
+	// Provider names the identity provider, e.g. "oidc:<issuer>".
+	Provider string
+	// Subject identifies the user at Provider.
+	Subject string
+	Email   string
+	// Role is given to users provisioned on their first login.
+	Role string
+}
+
+// SSOLoginCode hands a federated login over to the client. Only its hash
+// is stored.
+type SSOLoginCode struct {
+	CodeHash string
+	UserID   string
+	Provider string
+	Subject  string
+	// LinkRequired codes link Provider and Subject to UserID once the
+	// account password confirms it.
+	LinkRequired bool
+	ExpiresAt    time.Time
+}
+
+// SSOLogin is the outcome of LoginFederated: Code is exchanged through
+// ExchangeSSOCode, with the account password when LinkRequired.
+type SSOLogin struct {
+	Code         string
+	LinkRequired bool
+	ExpiresIn    time.Duration
+}
+
+type SSOStore interface {
+	// FindIdentity returns the user linked to subject at provider, or ""
+	// when none is.
+	FindIdentity(ctx context.Context, provider, subject string) (string, error)
+	LinkIdentity(ctx context.Context, provider, subject, userID string) error
+	CreateLoginCode(ctx context.Context, code SSOLoginCode) error
+	// ConsumeLoginCode marks an unused, unexpired code used and returns it.
+	ConsumeLoginCode(ctx context.Context, codeHash string) (*SSOLoginCode, error)
+}
+
+type PostgresSSOStore struct {
+	db *sql.DB
+}
+
+func NewPostgresSSOStore(db *sql.DB) *PostgresSSOStore {
+	return &PostgresSSOStore{db: db}
+}
+
+func (s *PostgresSSOStore) FindIdentity(ctx context.Context, provider, subject string) (string, error) {
+	var userID string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT user_id FROM federated_identities WHERE provider = $1 AND subject = $2`,
+		provider, subject).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("find federated identity failed: %w", err)
+	}
+	return userID, nil
+}
+
+func (s *PostgresSSOStore) LinkIdentity(ctx context.Context, provider, subject, userID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO federated_identities (provider, subject, user_id) VALUES ($1, $2, $3)
+		ON CONFLICT (provider, subject) DO UPDATE SET user_id = EXCLUDED.user_id`,
+		provider, subject, userID)
+	if err != nil {
+		return fmt.Errorf("link federated identity failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresSSOStore) CreateLoginCode(ctx context.Context, code SSOLoginCode) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM sso_login_codes WHERE expires_at < NOW() - INTERVAL '1 day'`); err != nil {
+		return fmt.Errorf("prune sso login codes failed: %w", err)
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sso_login_codes (code_hash, user_id, provider, subject, link_required, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		code.CodeHash, code.UserID, code.Provider, code.Subject, code.LinkRequired, code.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("create sso login code failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresSSOStore) ConsumeLoginCode(ctx context.Context, codeHash string) (*SSOLoginCode, error) {
+	code := &SSOLoginCode{CodeHash: codeHash}
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE sso_login_codes SET used_at = NOW()
+		WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id, provider, subject, link_required, expires_at`, codeHash).
+		Scan(&code.UserID, &code.Provider, &code.Subject, &code.LinkRequired, &code.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidSSOCode
+	}
+	if err != nil {
+		return nil, fmt.Errorf("consume sso login code failed: %w", err)
+	}
+	return code, nil
+}
+
+// SetSSOStore enables OIDC and SAML logins.
+func (m *Manager) SetSSOStore(store SSOStore) {
+	m.ssoStore = store
+}
+
+// LoginFederated resolves a user authenticated by an external identity
+// provider and returns a single-use code for the client to exchange, so no
+// token passes through the browser's address bar.
+//
+// Identities already linked sign in as their user. Otherwise users are
+// matched by email and linked, except root and admin accounts: their codes
+// need the account password to link. Unknown users are created with
+// identity.Role and an unusable local password.
+func (m *Manager) LoginFederated(identity FederatedIdentity) (*SSOLogin, error) {
+	if m.store == nil {
+		return nil, errors.New("user store is not configured")
+	}
+	if m.ssoStore == nil {
+		return nil, ErrSSONotConfigured
+	}
+	if identity.Email == "" || identity.Provider == "" || identity.Subject == "" {
+		return nil, errors.New("email, provider and subject are required")
+	}
+
+	ctx := context.Background()
+	code := SSOLoginCode{Provider: identity.Provider, Subject: identity.Subject, ExpiresAt: time.Now().Add(ssoCodeTTL)}
+
+	userID, err := m.ssoStore.FindIdentity(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	var user *User
+	if userID != "" {
+		if user, err = m.store.FindByID(ctx, userID); err != nil {
+			return nil, err
+		}
+	} else if user, err = m.store.FindByEmail(ctx, identity.Email); err == nil {
+		if user.Role == RoleRoot || user.Role == RoleAdmin {
+			code.LinkRequired = true
+		} else if err := m.ssoStore.LinkIdentity(ctx, identity.Provider, identity.Subject, user.ID); err != nil {
+			return nil, err
+		}
+	} else {
+		if user, err = m.createFederatedUser(ctx, identity); err != nil {
+			return nil, err
+		}
+	}
+	if user.ServiceAccount {
+		return nil, ErrInvalidClientCredentials
+	}
+	if !user.Active {
+		return nil, ErrUserDisabled
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("sso code generation failed: %w", err)
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+	code.CodeHash = hashToken(raw)
+	code.UserID = user.ID
+	if err := m.ssoStore.CreateLoginCode(ctx, code); err != nil {
+		return nil, err
+	}
+	return &SSOLogin{Code: raw, LinkRequired: code.LinkRequired, ExpiresIn: ssoCodeTTL}, nil
+}
+
+func (m *Manager) createFederatedUser(ctx context.Context, identity FederatedIdentity) (*User, error) {
+	secret, err := NewOIDCState()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	role := identity.Role
+	if role == "" {
+		role = RoleUser
+	}
+	user := &User{
+		ID:           uuid.New().String(),
+		Email:        identity.Email,
+		PasswordHash: hash,
+		Role:         role,
+		Active:       true,
+	}
+	if err := m.store.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := m.ssoStore.LinkIdentity(ctx, identity.Provider, identity.Subject, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ExchangeSSOCode trades a code from LoginFederated for a login, with the
+// same result as Login: a token pair, or only an MFA token when the user
+// has MFA enabled. Codes that link a privileged account need its password,
+// checked and throttled like Login with ip; a code is used up by its first
+// exchange either way.
+func (m *Manager) ExchangeSSOCode(raw, password, ip string) (*TokenPair, *User, error) {
+	if m.ssoStore == nil {
+		return nil, nil, ErrSSONotConfigured
+	}
+	if raw == "" {
+		return nil, nil, ErrInvalidSSOCode
+	}
+
+	ctx := context.Background()
+	code, err := m.ssoStore.ConsumeLoginCode(ctx, hashToken(raw))
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := m.store.FindByID(ctx, code.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidSSOCode
+	}
+	if !user.Active {
+		return nil, nil, ErrUserDisabled
+	}
+
+	if code.LinkRequired {
+		if password == "" {
+			return nil, nil, ErrSSOLinkPasswordRequired
+		}
+		keys := m.loginKeys(user.Email, ip)
+		if err := m.checkLoginAllowed(ctx, keys); err != nil {
+			return nil, nil, err
+		}
+		if err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)); err != nil {
+			if err := m.recordLoginFailure(ctx, keys); err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, errors.New("invalid credentials")
+		}
+		if err := m.ssoStore.LinkIdentity(ctx, code.Provider, code.Subject, user.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	required, err := m.mfaRequired(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if required {
+		mfaToken, err := m.generateMFAToken(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &TokenPair{MFAToken: mfaToken}, user, nil
+	}
+
+	tokens, err := m.issueTokens(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, user, nil
+}
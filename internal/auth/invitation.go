@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultInvitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrInvalidInvitation        = errors.New("invalid or expired invitation")
+	ErrInvitationNotFound       = errors.New("invitation not found")
+	ErrInvitationsNotConfigured = errors.New("invitation store is not configured")
+	ErrRoleNotGrantable         = errors.New("role cannot be granted by inviter")
+)
+
+type Invitation struct {
+	ID        string
+	Email     string
+	Role      string
+	Workspace string
+	TokenHash string
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+// Status is pending, used, revoked or expired.
+func (i *Invitation) Status(now time.Time) string {
+	switch {
+	case i.UsedAt != nil:
+		return "used"
+	case i.RevokedAt != nil:
+		return "revoked"
+	case !i.ExpiresAt.After(now):
+		return "expired"
+	}
+	return "pending"
+}
+
+type InvitationStore interface {
+	Create(ctx context.Context, inv *Invitation) error
+	List(ctx context.Context) ([]*Invitation, error)
+	// FindPending returns an unused, unrevoked, unexpired invitation.
+	FindPending(ctx context.Context, tokenHash string) (*Invitation, error)
+	// Consume marks a pending invitation used and returns it.
+	Consume(ctx context.Context, tokenHash string) (*Invitation, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+type PostgresInvitationStore struct {
+	db *sql.DB
+}
+
+func NewPostgresInvitationStore(db *sql.DB) *PostgresInvitationStore {
+	return &PostgresInvitationStore{db: db}
+}
+
+const invitationColumns = `id, email, role, workspace, token_hash, created_by, created_at, expires_at, used_at, revoked_at`
+
+const invitationPending = `used_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()`
+
+func scanInvitation(row rowScanner) (*Invitation, error) {
+	inv := &Invitation{}
+	var createdBy sql.NullString
+	var usedAt, revokedAt sql.NullTime
+	if err := row.Scan(&inv.ID, &inv.Email, &inv.Role, &inv.Workspace, &inv.TokenHash, &createdBy,
+		&inv.CreatedAt, &inv.ExpiresAt, &usedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	inv.CreatedBy = createdBy.String
+	if usedAt.Valid {
+		inv.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		inv.RevokedAt = &revokedAt.Time
+	}
+	return inv, nil
+}
+
+func (s *PostgresInvitationStore) Create(ctx context.Context, inv *Invitation) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO invitations (id, email, role, workspace, token_hash, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		inv.ID, inv.Email, inv.Role, inv.Workspace, inv.TokenHash, inv.CreatedBy, inv.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create invitation failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresInvitationStore) List(ctx context.Context) ([]*Invitation, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+invitationColumns+` FROM invitations ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("list invitations failed: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []*Invitation
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, rows.Err()
+}
+
+func (s *PostgresInvitationStore) FindPending(ctx context.Context, tokenHash string) (*Invitation, error) {
+	inv, err := scanInvitation(s.db.QueryRowContext(ctx,
+		`SELECT `+invitationColumns+` FROM invitations WHERE token_hash = $1 AND `+invitationPending, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find invitation failed: %w", err)
+	}
+	return inv, nil
+}
+
+func (s *PostgresInvitationStore) Consume(ctx context.Context, tokenHash string) (*Invitation, error) {
+	inv, err := scanInvitation(s.db.QueryRowContext(ctx, `
+		UPDATE invitations SET used_at = NOW()
+		WHERE token_hash = $1 AND `+invitationPending+`
+		RETURNING `+invitationColumns, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, fmt.Errorf("consume invitation failed: %w", err)
+	}
+	return inv, nil
+}
+
+func (s *PostgresInvitationStore) Revoke(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE invitations SET revoked_at = NOW() WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("revoke invitation failed: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// SetInvitationStore enables invitation-based signup.
+func (m *Manager) SetInvitationStore(store InvitationStore, ttl time.Duration) {
+	m.invitations = store
+	if ttl > 0 {
+		m.invitationTTL = ttl
+	}
+}
+
+// CreateInvitation issues a single-use invitation for email. inviterRole
+// limits the grantable roles: only root may invite root. The plaintext token
+// is only returned here.
+func (m *Manager) CreateInvitation(email, role, workspace, createdBy, inviterRole string) (string, *Invitation, error) {
+	if m.invitations == nil {
+		return "", nil, ErrInvitationsNotConfigured
+	}
+	if m.store == nil {
+		return "", nil, errors.New("user store is not configured")
+	}
+
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", nil, errors.New("email is required")
+	}
+	if role == "" {
+		role = RoleUser
+	}
+	if !ValidRole(role) {
+		return "", nil, ErrInvalidRole
+	}
+	if role == RoleRoot && inviterRole != RoleRoot {
+		return "", nil, ErrRoleNotGrantable
+	}
+
+	ctx := context.Background()
+	if existing, err := m.store.FindByEmail(ctx, email); err == nil && existing != nil {
+		return "", nil, ErrEmailTaken
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("invitation token generation failed: %w", err)
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+
+	inv := &Invitation{
+		ID:        uuid.New().String(),
+		Email:     email,
+		Role:      role,
+		Workspace: workspace,
+		TokenHash: hashToken(raw),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: time.Now().Add(m.invitationTTL).UTC(),
+	}
+	if err := m.invitations.Create(ctx, inv); err != nil {
+		return "", nil, err
+	}
+	return raw, inv, nil
+}
+
+func (m *Manager) ListInvitations() ([]*Invitation, error) {
+	if m.invitations == nil {
+		return nil, ErrInvitationsNotConfigured
+	}
+	return m.invitations.List(context.Background())
+}
+
+func (m *Manager) RevokeInvitation(id string) error {
+	if m.invitations == nil {
+		return ErrInvitationsNotConfigured
+	}
+	return m.invitations.Revoke(context.Background(), id)
+}
+
+// LookupInvitation returns a pending invitation so the signup page can show
+// the invited email and role.
+func (m *Manager) LookupInvitation(token string) (*Invitation, error) {
+	if m.invitations == nil {
+		return nil, ErrInvitationsNotConfigured
+	}
+	if token == "" {
+		return nil, ErrInvalidInvitation
+	}
+	return m.invitations.FindPending(context.Background(), hashToken(token))
+}
+
+// SignupWithInvitation creates the invited user with the invitation's email,
+// role and workspace and consumes the invitation.
+func (m *Manager) SignupWithInvitation(token, password string) (*TokenPair, *User, error) {
+	inv, err := m.LookupInvitation(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if password == "" {
+		return nil, nil, errors.New("password is required")
+	}
+
+	ctx := context.Background()
+	if existing, err := m.store.FindByEmail(ctx, inv.Email); err == nil && existing != nil {
+		return nil, nil, ErrEmailTaken
+	}
+	if inv, err = m.invitations.Consume(ctx, inv.TokenHash); err != nil {
+		return nil, nil, err
+	}
+	return m.Signup(inv.Email, password, inv.Role, inv.Workspace)
+}
@@ -0,0 +1,173 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Minimal BER encoding for the LDAPv3 messages used by Client (RFC 4511).
+
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagBoolean     = 0x01
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x30
+
+	appBindRequest      = 0x60
+	appBindResponse     = 0x61
+	appUnbindRequest    = 0x42
+	appSearchRequest    = 0x63
+	appSearchEntry      = 0x64
+	appSearchDone       = 0x65
+	appSearchReference  = 0x73
+	appExtendedRequest  = 0x77
+	appExtendedResponse = 0x78
+
+	ctxSimpleAuth   = 0x80
+	ctxExtendedName = 0x80
+	ctxFilterAnd    = 0xa0
+	ctxFilterEqual  = 0xa3
+)
+
+const maxPacketSize = 1 << 20
+
+type element struct {
+	tag     byte
+	content []byte
+}
+
+func encode(tag byte, content []byte) []byte {
+	n := len(content)
+	var length []byte
+	switch {
+	case n < 0x80:
+		length = []byte{byte(n)}
+	default:
+		var b []byte
+		for v := n; v > 0; v >>= 8 {
+			b = append([]byte{byte(v)}, b...)
+		}
+		length = append([]byte{0x80 | byte(len(b))}, b...)
+	}
+	out := make([]byte, 0, 1+len(length)+n)
+	out = append(out, tag)
+	out = append(out, length...)
+	return append(out, content...)
+}
+
+func encodeInt(tag byte, v int) []byte {
+	var b []byte
+	for {
+		b = append([]byte{byte(v)}, b...)
+		if v >= -128 && v < 128 {
+			break
+		}
+		v >>= 8
+	}
+	return encode(tag, b)
+}
+
+func encodeString(tag byte, s string) []byte {
+	return encode(tag, []byte(s))
+}
+
+func encodeBool(v bool) []byte {
+	if v {
+		return encode(tagBoolean, []byte{0xff})
+	}
+	return encode(tagBoolean, []byte{0x00})
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// readPacket reads one top-level BER element from r.
+func readPacket(r *bufio.Reader) (element, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return element{}, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return element{}, err
+	}
+
+	length := int(first)
+	if first&0x80 != 0 {
+		n := int(first & 0x7f)
+		if n == 0 || n > 4 {
+			return element{}, errors.New("ldap: unsupported ber length")
+		}
+		length = 0
+		for i := 0; i < n; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return element{}, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxPacketSize {
+		return element{}, fmt.Errorf("ldap: packet too large (%d bytes)", length)
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return element{}, err
+	}
+	return element{tag: tag, content: content}, nil
+}
+
+// children splits the content of a constructed element.
+func (e element) children() ([]element, error) {
+	var out []element
+	b := e.content
+	for len(b) > 0 {
+		if len(b) < 2 {
+			return nil, errors.New("ldap: truncated ber element")
+		}
+		tag := b[0]
+		length := int(b[1])
+		offset := 2
+		if b[1]&0x80 != 0 {
+			n := int(b[1] & 0x7f)
+			if n == 0 || n > 4 || len(b) < 2+n {
+				return nil, errors.New("ldap: invalid ber length")
+			}
+			length = 0
+			for _, c := range b[2 : 2+n] {
+				length = length<<8 | int(c)
+			}
+			offset += n
+		}
+		if length < 0 || len(b) < offset+length {
+			return nil, errors.New("ldap: truncated ber element")
+		}
+		out = append(out, element{tag: tag, content: b[offset : offset+length]})
+		b = b[offset+length:]
+	}
+	return out, nil
+}
+
+func (e element) int() int {
+	if len(e.content) == 0 {
+		return 0
+	}
+	v := int(int8(e.content[0]))
+	for _, c := range e.content[1:] {
+		v = v<<8 | int(c)
+	}
+	return v
+}
+
+func (e element) string() string {
+	return string(e.content)
+}
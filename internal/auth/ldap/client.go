@@ -0,0 +1,368 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"yuon/internal/auth"
+)
+
+const (
+	resultSuccess            = 0
+	resultInvalidCredentials = 49
+
+	oidStartTLS = "1.3.6.1.4.1.1466.20037"
+)
+
+// Client authenticates users with a search-then-bind against an LDAP server
+// or Active Directory. A new connection is used per login.
+type Client struct {
+	cfg *Config
+}
+
+func NewClient(cfg *Config) *Client {
+	return &Client{cfg: cfg}
+}
+
+// Authenticate implements auth.DirectoryAuthenticator.
+func (c *Client) Authenticate(ctx context.Context, username, password string) (*auth.DirectoryIdentity, error) {
+	username = strings.TrimSpace(username)
+	// An empty password is an unauthenticated bind, which servers accept.
+	if username == "" || password == "" {
+		return nil, auth.ErrDirectoryInvalidCredentials
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.close()
+
+	if c.cfg.BindDN != "" {
+		code, msg, err := conn.bind(c.cfg.BindDN, c.cfg.BindPassword)
+		if err != nil {
+			return nil, err
+		}
+		if code != resultSuccess {
+			return nil, fmt.Errorf("ldap service bind failed: result %d: %s", code, msg)
+		}
+	}
+
+	entries, err := conn.search(c.cfg.BaseDN, c.userFilter(username),
+		[]string{c.cfg.EmailAttribute, c.cfg.NameAttribute, c.cfg.GroupAttribute})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, auth.ErrDirectoryInvalidCredentials
+	}
+	if len(entries) > 1 {
+		return nil, fmt.Errorf("ldap search for %q matched %d entries", username, len(entries))
+	}
+	entry := entries[0]
+
+	code, msg, err := conn.bind(entry.dn, password)
+	if err != nil {
+		return nil, err
+	}
+	switch code {
+	case resultSuccess:
+	case resultInvalidCredentials:
+		return nil, auth.ErrDirectoryInvalidCredentials
+	default:
+		return nil, fmt.Errorf("ldap user bind failed: result %d: %s", code, msg)
+	}
+
+	email := entry.first(c.cfg.EmailAttribute)
+	if email == "" && strings.Contains(username, "@") {
+		email = username
+	}
+	if email == "" {
+		return nil, fmt.Errorf("ldap entry %s has no %s attribute", entry.dn, c.cfg.EmailAttribute)
+	}
+
+	return &auth.DirectoryIdentity{
+		DN:    entry.dn,
+		Email: email,
+		Name:  entry.first(c.cfg.NameAttribute),
+		Role:  c.cfg.MapRole(entry.attrs[strings.ToLower(c.cfg.GroupAttribute)]),
+	}, nil
+}
+
+// userFilter builds (&(objectClass=<class>)(<loginAttribute>=<username>)).
+// Values are sent as BER octet strings, so no filter escaping is needed.
+func (c *Client) userFilter(username string) []byte {
+	match := encode(ctxFilterEqual, concat(
+		encodeString(tagOctetString, c.cfg.LoginAttribute),
+		encodeString(tagOctetString, username),
+	))
+	if c.cfg.UserObjectClass == "" {
+		return match
+	}
+	return encode(ctxFilterAnd, concat(
+		encode(ctxFilterEqual, concat(
+			encodeString(tagOctetString, "objectClass"),
+			encodeString(tagOctetString, c.cfg.UserObjectClass),
+		)),
+		match,
+	))
+}
+
+type conn struct {
+	net    net.Conn
+	r      *bufio.Reader
+	nextID int
+}
+
+type entry struct {
+	dn    string
+	attrs map[string][]string
+}
+
+func (e entry) first(attr string) string {
+	if v := e.attrs[strings.ToLower(attr)]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	u, err := url.Parse(c.cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "ldaps" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+	}
+	tlsConfig := &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: c.cfg.InsecureSkipVerify}
+
+	deadline := time.Now().Add(c.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	dialer := &net.Dialer{Deadline: deadline}
+
+	var nc net.Conn
+	if u.Scheme == "ldaps" {
+		nc, err = tls.DialWithDialer(dialer, "tcp", host, tlsConfig)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ldap connect failed: %w", err)
+	}
+	if err := nc.SetDeadline(deadline); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	cn := &conn{net: nc, r: bufio.NewReader(nc)}
+	if c.cfg.StartTLS {
+		if err := cn.startTLS(tlsConfig); err != nil {
+			nc.Close()
+			return nil, err
+		}
+		if err := cn.net.SetDeadline(deadline); err != nil {
+			cn.net.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+func (c *conn) startTLS(cfg *tls.Config) error {
+	id, err := c.send(encode(appExtendedRequest, encodeString(ctxExtendedName, oidStartTLS)))
+	if err != nil {
+		return err
+	}
+	op, err := c.receive(id)
+	if err != nil {
+		return err
+	}
+	if op.tag != appExtendedResponse {
+		return fmt.Errorf("ldap: unexpected StartTLS response 0x%x", op.tag)
+	}
+	code, msg, err := result(op)
+	if err != nil {
+		return err
+	}
+	if code != resultSuccess {
+		return fmt.Errorf("ldap StartTLS failed: result %d: %s", code, msg)
+	}
+
+	tc := tls.Client(c.net, cfg)
+	if err := tc.Handshake(); err != nil {
+		return fmt.Errorf("ldap StartTLS handshake failed: %w", err)
+	}
+	c.net = tc
+	c.r = bufio.NewReader(tc)
+	return nil
+}
+
+// bind performs a simple bind and returns the LDAP result code.
+func (c *conn) bind(dn, password string) (int, string, error) {
+	id, err := c.send(encode(appBindRequest, concat(
+		encodeInt(tagInteger, 3),
+		encodeString(tagOctetString, dn),
+		encodeString(ctxSimpleAuth, password),
+	)))
+	if err != nil {
+		return 0, "", err
+	}
+	op, err := c.receive(id)
+	if err != nil {
+		return 0, "", err
+	}
+	if op.tag != appBindResponse {
+		return 0, "", fmt.Errorf("ldap: unexpected bind response 0x%x", op.tag)
+	}
+	return result(op)
+}
+
+// search runs a subtree search and collects the entries. At most two entries
+// are requested since only a unique match is useful.
+func (c *conn) search(baseDN string, filter []byte, attributes []string) ([]entry, error) {
+	var attrs []byte
+	for _, a := range attributes {
+		attrs = append(attrs, encodeString(tagOctetString, a)...)
+	}
+	id, err := c.send(encode(appSearchRequest, concat(
+		encodeString(tagOctetString, baseDN),
+		encodeInt(tagEnumerated, 2), // wholeSubtree
+		encodeInt(tagEnumerated, 0), // neverDerefAliases
+		encodeInt(tagInteger, 2),
+		encodeInt(tagInteger, 10),
+		encodeBool(false),
+		filter,
+		encode(tagSequence, attrs),
+	)))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for {
+		op, err := c.receive(id)
+		if err != nil {
+			return nil, err
+		}
+		switch op.tag {
+		case appSearchEntry:
+			e, err := parseEntry(op)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		case appSearchReference:
+		case appSearchDone:
+			code, msg, err := result(op)
+			if err != nil {
+				return nil, err
+			}
+			// sizeLimitExceeded (4) still tells us the match is ambiguous.
+			if code != resultSuccess && code != 4 {
+				return nil, fmt.Errorf("ldap search failed: result %d: %s", code, msg)
+			}
+			return entries, nil
+		default:
+			return nil, fmt.Errorf("ldap: unexpected search response 0x%x", op.tag)
+		}
+	}
+}
+
+func (c *conn) close() {
+	c.nextID++
+	_, _ = c.net.Write(encode(tagSequence, concat(encodeInt(tagInteger, c.nextID), encode(appUnbindRequest, nil))))
+	c.net.Close()
+}
+
+func (c *conn) send(op []byte) (int, error) {
+	c.nextID++
+	if _, err := c.net.Write(encode(tagSequence, concat(encodeInt(tagInteger, c.nextID), op))); err != nil {
+		return 0, fmt.Errorf("ldap write failed: %w", err)
+	}
+	return c.nextID, nil
+}
+
+// receive reads the next message for id and returns its protocol op.
+func (c *conn) receive(id int) (element, error) {
+	for {
+		packet, err := readPacket(c.r)
+		if err != nil {
+			return element{}, fmt.Errorf("ldap read failed: %w", err)
+		}
+		if packet.tag != tagSequence {
+			return element{}, errors.New("ldap: malformed message")
+		}
+		parts, err := packet.children()
+		if err != nil {
+			return element{}, err
+		}
+		if len(parts) < 2 || parts[0].tag != tagInteger {
+			return element{}, errors.New("ldap: malformed message")
+		}
+		msgID := parts[0].int()
+		if msgID == 0 {
+			// Unsolicited notification, e.g. notice of disconnection.
+			return element{}, errors.New("ldap: server closed the connection")
+		}
+		if msgID == id {
+			return parts[1], nil
+		}
+	}
+}
+
+// result decodes the LDAPResult fields shared by responses.
+func result(op element) (int, string, error) {
+	parts, err := op.children()
+	if err != nil {
+		return 0, "", err
+	}
+	if len(parts) < 3 || parts[0].tag != tagEnumerated {
+		return 0, "", errors.New("ldap: malformed result")
+	}
+	return parts[0].int(), parts[2].string(), nil
+}
+
+func parseEntry(op element) (entry, error) {
+	parts, err := op.children()
+	if err != nil {
+		return entry{}, err
+	}
+	if len(parts) < 2 {
+		return entry{}, errors.New("ldap: malformed search entry")
+	}
+
+	e := entry{dn: parts[0].string(), attrs: map[string][]string{}}
+	attrs, err := parts[1].children()
+	if err != nil {
+		return entry{}, err
+	}
+	for _, a := range attrs {
+		fields, err := a.children()
+		if err != nil || len(fields) < 2 {
+			return entry{}, errors.New("ldap: malformed attribute")
+		}
+		values, err := fields[1].children()
+		if err != nil {
+			return entry{}, err
+		}
+		name := strings.ToLower(fields[0].string())
+		for _, v := range values {
+			e.attrs[name] = append(e.attrs[name], v.string())
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,113 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is loaded from the YAML file at LDAP_CONFIG.
+type Config struct {
+	// URL is ldap://host:389 or ldaps://host:636.
+	URL string `yaml:"url"`
+	// StartTLS upgrades an ldap:// connection before binding.
+	StartTLS           bool `yaml:"startTLS"`
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+
+	// BindDN and BindPassword are the service account used to look up users.
+	// Leave empty when the directory allows anonymous search.
+	// LDAP_BIND_PASSWORD overrides BindPassword.
+	BindDN       string `yaml:"bindDN"`
+	BindPassword string `yaml:"bindPassword"`
+
+	BaseDN string `yaml:"baseDN"`
+	// UserObjectClass restricts the user search, e.g. "user" for AD.
+	UserObjectClass string `yaml:"userObjectClass"`
+	// LoginAttribute is matched against the login name: mail,
+	// userPrincipalName or sAMAccountName.
+	LoginAttribute string `yaml:"loginAttribute"`
+	EmailAttribute string `yaml:"emailAttribute"`
+	NameAttribute  string `yaml:"nameAttribute"`
+	GroupAttribute string `yaml:"groupAttribute"`
+
+	RoleMapping []RoleMapping `yaml:"roleMapping"`
+	DefaultRole string        `yaml:"defaultRole"`
+
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// RoleMapping maps a group to a role. Group is either the full group DN or
+// its CN. The first matching entry wins, so list more privileged groups first.
+type RoleMapping struct {
+	Group string `yaml:"group"`
+	Role  string `yaml:"role"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read ldap config failed: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse ldap config failed: %w", err)
+	}
+	if cfg.URL == "" || cfg.BaseDN == "" {
+		return nil, errors.New("ldap url and baseDN are required")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+		return nil, fmt.Errorf("invalid ldap url: %s", cfg.URL)
+	}
+	if cfg.StartTLS && u.Scheme == "ldaps" {
+		return nil, errors.New("ldap startTLS cannot be combined with ldaps://")
+	}
+	if cfg.LoginAttribute == "" {
+		cfg.LoginAttribute = "mail"
+	}
+	if cfg.EmailAttribute == "" {
+		cfg.EmailAttribute = "mail"
+	}
+	if cfg.NameAttribute == "" {
+		cfg.NameAttribute = "displayName"
+	}
+	if cfg.GroupAttribute == "" {
+		cfg.GroupAttribute = "memberOf"
+	}
+	if cfg.DefaultRole == "" {
+		cfg.DefaultRole = "user"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &cfg, nil
+}
+
+// MapRole returns the role for the given group DNs.
+func (c *Config) MapRole(groups []string) string {
+	for _, m := range c.RoleMapping {
+		for _, g := range groups {
+			if strings.EqualFold(g, m.Group) || strings.EqualFold(groupCN(g), m.Group) {
+				return m.Role
+			}
+		}
+	}
+	return c.DefaultRole
+}
+
+// groupCN returns the value of the first RDN, e.g. "Teachers" for
+// "CN=Teachers,OU=Groups,DC=school,DC=kr".
+func groupCN(dn string) string {
+	rdn, _, _ := strings.Cut(dn, ",")
+	_, value, ok := strings.Cut(rdn, "=")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
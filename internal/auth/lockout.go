@@ -0,0 +1,306 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	LoginScopeAccount = "account"
+	LoginScopeIP      = "ip"
+
+	maxLockoutDuration = 24 * time.Hour
+)
+
+var ErrLoginLocked = errors.New("too many failed login attempts")
+
+// LoginLockedError is returned by Login while an account or client IP is
+// throttled or locked out.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrLoginLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginLockedError) Is(target error) bool {
+	return target == ErrLoginLocked
+}
+
+// LockoutPolicy controls brute-force protection. After each failure the next
+// attempt is delayed by BaseDelay doubled per failure (capped at MaxDelay);
+// at MaxFailures the key is locked for LockoutDuration, doubling for every
+// further failure. Failures older than Window are forgotten.
+type LockoutPolicy struct {
+	MaxFailures      int
+	MaxFailuresPerIP int
+	LockoutDuration  time.Duration
+	Window           time.Duration
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+}
+
+type LoginAttempt struct {
+	Scope         string
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// Locked reports whether the attempt is locked at now.
+func (a *LoginAttempt) Locked(now time.Time) bool {
+	return a.LockedUntil != nil && a.LockedUntil.After(now)
+}
+
+type LoginAttemptStore interface {
+	// Get returns nil when the key has no recorded failures.
+	Get(ctx context.Context, scope, key string) (*LoginAttempt, error)
+	// RecordFailure increments the counter, restarting it when the previous
+	// failure is older than window.
+	RecordFailure(ctx context.Context, scope, key string, window time.Duration) (*LoginAttempt, error)
+	Lock(ctx context.Context, scope, key string, until time.Time) error
+	Reset(ctx context.Context, scope, key string) error
+	// List returns keys that failed since the given time or are still locked.
+	List(ctx context.Context, since time.Time) ([]*LoginAttempt, error)
+}
+
+type PostgresLoginAttemptStore struct {
+	db *sql.DB
+}
+
+func NewPostgresLoginAttemptStore(db *sql.DB) *PostgresLoginAttemptStore {
+	return &PostgresLoginAttemptStore{db: db}
+}
+
+func (s *PostgresLoginAttemptStore) Get(ctx context.Context, scope, key string) (*LoginAttempt, error) {
+	a := &LoginAttempt{Scope: scope, Key: key}
+	var lockedUntil sql.NullTime
+	err := s.db.QueryRowContext(ctx,
+		`SELECT failures, last_failure_at, locked_until FROM login_attempts WHERE scope = $1 AND key = $2`,
+		scope, key,
+	).Scan(&a.Failures, &a.LastFailureAt, &lockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get login attempts failed: %w", err)
+	}
+	if lockedUntil.Valid {
+		a.LockedUntil = &lockedUntil.Time
+	}
+	return a, nil
+}
+
+func (s *PostgresLoginAttemptStore) RecordFailure(ctx context.Context, scope, key string, window time.Duration) (*LoginAttempt, error) {
+	a := &LoginAttempt{Scope: scope, Key: key}
+	var lockedUntil sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO login_attempts (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.last_failure_at < NOW() - $3 * INTERVAL '1 second' THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure_at = NOW()
+		RETURNING failures, last_failure_at, locked_until`,
+		scope, key, int64(window/time.Second),
+	).Scan(&a.Failures, &a.LastFailureAt, &lockedUntil)
+	if err != nil {
+		return nil, fmt.Errorf("record login failure failed: %w", err)
+	}
+	if lockedUntil.Valid {
+		a.LockedUntil = &lockedUntil.Time
+	}
+	return a, nil
+}
+
+func (s *PostgresLoginAttemptStore) Lock(ctx context.Context, scope, key string, until time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE login_attempts SET locked_until = $3 WHERE scope = $1 AND key = $2`,
+		scope, key, until,
+	)
+	if err != nil {
+		return fmt.Errorf("lock login failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresLoginAttemptStore) Reset(ctx context.Context, scope, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE scope = $1 AND key = $2`, scope, key)
+	if err != nil {
+		return fmt.Errorf("reset login attempts failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresLoginAttemptStore) List(ctx context.Context, since time.Time) ([]*LoginAttempt, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT scope, key, failures, last_failure_at, locked_until
+		FROM login_attempts
+		WHERE last_failure_at >= $1 OR locked_until > NOW()
+		ORDER BY last_failure_at DESC`, since)
+	if err != nil {
+		return nil, fmt.Errorf("list login attempts failed: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*LoginAttempt
+	for rows.Next() {
+		a := &LoginAttempt{}
+		var lockedUntil sql.NullTime
+		if err := rows.Scan(&a.Scope, &a.Key, &a.Failures, &a.LastFailureAt, &lockedUntil); err != nil {
+			return nil, err
+		}
+		if lockedUntil.Valid {
+			a.LockedUntil = &lockedUntil.Time
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// SetLoginAttemptStore enables brute-force protection on Login. Zero policy
+// fields fall back to sane defaults.
+func (m *Manager) SetLoginAttemptStore(store LoginAttemptStore, policy LockoutPolicy) {
+	if policy.MaxFailures <= 0 {
+		policy.MaxFailures = 5
+	}
+	if policy.MaxFailuresPerIP <= 0 {
+		policy.MaxFailuresPerIP = 20
+	}
+	if policy.LockoutDuration <= 0 {
+		policy.LockoutDuration = 15 * time.Minute
+	}
+	if policy.Window <= 0 {
+		policy.Window = 24 * time.Hour
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = time.Second
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = 30 * time.Second
+	}
+	m.attempts = store
+	m.lockout = policy
+}
+
+// LoginAttempts lists accounts and IPs with recent failures for admins.
+func (m *Manager) LoginAttempts() ([]*LoginAttempt, error) {
+	if m.attempts == nil {
+		return nil, nil
+	}
+	return m.attempts.List(context.Background(), time.Now().Add(-m.lockout.Window))
+}
+
+// UnlockLogin clears the failures and lockout of an account or IP.
+func (m *Manager) UnlockLogin(scope, key string) error {
+	if m.attempts == nil {
+		return nil
+	}
+	if scope == LoginScopeAccount {
+		key = normalizeLoginEmail(key)
+	}
+	return m.attempts.Reset(context.Background(), scope, key)
+}
+
+type loginKey struct {
+	scope string
+	key   string
+	max   int
+}
+
+func (m *Manager) loginKeys(email, ip string) []loginKey {
+	keys := []loginKey{{scope: LoginScopeAccount, key: normalizeLoginEmail(email), max: m.lockout.MaxFailures}}
+	if ip != "" {
+		keys = append(keys, loginKey{scope: LoginScopeIP, key: ip, max: m.lockout.MaxFailuresPerIP})
+	}
+	return keys
+}
+
+// checkLoginAllowed rejects the attempt while any key is locked or still
+// inside its backoff delay.
+func (m *Manager) checkLoginAllowed(ctx context.Context, keys []loginKey) error {
+	if m.attempts == nil {
+		return nil
+	}
+
+	now := time.Now()
+	for _, k := range keys {
+		a, err := m.attempts.Get(ctx, k.scope, k.key)
+		if err != nil {
+			return err
+		}
+		if a == nil {
+			continue
+		}
+		if a.Locked(now) {
+			return &LoginLockedError{RetryAfter: a.LockedUntil.Sub(now), Locked: true}
+		}
+		if a.Failures >= k.max || now.Sub(a.LastFailureAt) > m.lockout.Window {
+			continue
+		}
+		if next := a.LastFailureAt.Add(m.backoffDelay(a.Failures)); next.After(now) {
+			return &LoginLockedError{RetryAfter: next.Sub(now)}
+		}
+	}
+	return nil
+}
+
+func (m *Manager) recordLoginFailure(ctx context.Context, keys []loginKey) error {
+	if m.attempts == nil {
+		return nil
+	}
+
+	for _, k := range keys {
+		a, err := m.attempts.RecordFailure(ctx, k.scope, k.key, m.lockout.Window)
+		if err != nil {
+			return err
+		}
+		if a.Failures < k.max {
+			continue
+		}
+		duration := m.lockout.LockoutDuration
+		for i := k.max; i < a.Failures && duration < maxLockoutDuration; i++ {
+			duration *= 2
+		}
+		if duration > maxLockoutDuration {
+			duration = maxLockoutDuration
+		}
+		if err := m.attempts.Lock(ctx, k.scope, k.key, a.LastFailureAt.Add(duration)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearLoginFailures resets the account counter after a successful login.
+// IP counters are left alone so one valid account cannot unlock an IP.
+func (m *Manager) clearLoginFailures(ctx context.Context, email string) error {
+	if m.attempts == nil {
+		return nil
+	}
+	return m.attempts.Reset(ctx, LoginScopeAccount, normalizeLoginEmail(email))
+}
+
+func (m *Manager) backoffDelay(failures int) time.Duration {
+	delay := m.lockout.BaseDelay
+	for i := 1; i < failures && delay < m.lockout.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > m.lockout.MaxDelay {
+		delay = m.lockout.MaxDelay
+	}
+	return delay
+}
+
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -16,23 +16,129 @@ type User struct {
 	Email        string
 	PasswordHash []byte
 	Role         string
+	Workspace    string
+	Name         string
+	Department   string
+	AvatarURL    string
 	CreatedAt    time.Time
+
+	// ServiceAccount marks non-interactive accounts authorized by Scopes.
+	ServiceAccount bool
+	Scopes         []string
+
+	// Active is false for disabled users, who can neither log in nor use
+	// tokens issued before they were disabled.
+	Active bool
+}
+
+// DisplayName returns the profile name, falling back to the email.
+func (u *User) DisplayName() string {
+	if u.Name != "" {
+		return u.Name
+	}
+	return u.Email
+}
+
+// Profile holds the self-service fields of a user.
+type Profile struct {
+	Name       string
+	Department string
+	AvatarURL  string
 }
 
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailTaken   = errors.New("email already registered")
+	ErrInvalidRole  = errors.New("invalid role")
+	ErrLastRoot     = errors.New("cannot remove the last root user")
+	ErrUserDisabled = errors.New("user is disabled")
+)
+
+const (
+	defaultAccessTokenTTL  = 24 * time.Hour
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
 type Manager struct {
 	jwtSecret []byte
 
-	mu    sync.RWMutex
-	store UserStore
+	mu        sync.RWMutex
+	store     UserStore
+	directory DirectoryAuthenticator
+
+	refreshStore    RefreshTokenStore
+	apiKeys         APIKeyStore
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	issuer          string
+	audience        string
+
+	resetStore       PasswordResetStore
+	passwordResetTTL time.Duration
+
+	mfaStore  MFAStore
+	mfaIssuer string
+
+	attempts LoginAttemptStore
+	lockout  LockoutPolicy
+
+	invitations   InvitationStore
+	invitationTTL time.Duration
+
+	ssoStore SSOStore
+
+	keyStore     SigningKeyStore
+	keysMu       sync.RWMutex
+	keys         map[string]*SigningKey
+	currentKID   string
+	keysLoadedAt time.Time
+}
+
+// TokenPair is issued on signup, login and refresh. RefreshToken is empty
+// when no refresh token store is configured. When the user has MFA enabled,
+// Login only sets MFAToken, to be exchanged via CompleteMFALogin.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+	MFAToken     string
 }
 
 func NewManager(jwtSecret string, store UserStore) *Manager {
 	return &Manager{
-		jwtSecret: []byte(jwtSecret),
-		store:     store,
+		jwtSecret:       []byte(jwtSecret),
+		store:           store,
+		accessTokenTTL:  defaultAccessTokenTTL,
+		refreshTokenTTL: defaultRefreshTokenTTL,
+
+		passwordResetTTL: defaultPasswordResetTTL,
+		invitationTTL:    defaultInvitationTTL,
+	}
+}
+
+// SetTokenLifetimes overrides the access/refresh token lifetimes. Zero values
+// keep the current setting.
+func (m *Manager) SetTokenLifetimes(access, refresh time.Duration) {
+	if access > 0 {
+		m.accessTokenTTL = access
+	}
+	if refresh > 0 {
+		m.refreshTokenTTL = refresh
 	}
 }
 
+// SetTokenIssuer sets the iss and aud claims of access tokens. Once set,
+// tokens without matching claims are rejected.
+func (m *Manager) SetTokenIssuer(issuer, audience string) {
+	m.issuer = issuer
+	m.audience = audience
+}
+
+// SetRefreshTokenStore enables refresh tokens.
+func (m *Manager) SetRefreshTokenStore(store RefreshTokenStore) {
+	m.refreshStore = store
+}
+
 func (m *Manager) EnsureRootUser(email, password string) error {
 	if email == "" || password == "" {
 		return errors.New("root email/password required")
@@ -48,6 +154,7 @@ func (m *Manager) EnsureRootUser(email, password string) error {
 		Email:        email,
 		PasswordHash: hash,
 		Role:         "root",
+		Active:       true,
 	}
 
 	if m.store == nil {
@@ -56,9 +163,11 @@ func (m *Manager) EnsureRootUser(email, password string) error {
 	return m.store.Upsert(context.Background(), user)
 }
 
-func (m *Manager) Signup(email, password, role string) (string, *User, error) {
+// Signup creates a user. An empty workspace keeps the user on the shared
+// knowledge base.
+func (m *Manager) Signup(email, password, role, workspace string) (*TokenPair, *User, error) {
 	if email == "" || password == "" {
-		return "", nil, errors.New("email and password are required")
+		return nil, nil, errors.New("email and password are required")
 	}
 
 	if role == "" {
@@ -66,16 +175,16 @@ func (m *Manager) Signup(email, password, role string) (string, *User, error) {
 	}
 
 	if m.store == nil {
-		return "", nil, errors.New("user store is not configured")
+		return nil, nil, errors.New("user store is not configured")
 	}
 
 	if existing, err := m.store.FindByEmail(context.Background(), email); err == nil && existing != nil {
-		return "", nil, errors.New("email already registered")
+		return nil, nil, ErrEmailTaken
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 
 	user := &User{
@@ -83,64 +192,121 @@ func (m *Manager) Signup(email, password, role string) (string, *User, error) {
 		Email:        email,
 		PasswordHash: hash,
 		Role:         role,
+		Workspace:    workspace,
+		Active:       true,
 	}
 
 	if err := m.store.Create(context.Background(), user); err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 
-	token, err := m.generateJWT(user)
+	tokens, err := m.issueTokens(context.Background(), user)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 
-	return token, user, nil
+	return tokens, user, nil
 }
 
-func (m *Manager) Login(email, password string) (string, *User, error) {
+// Login verifies a password, first against the directory when one is
+// configured and then against local users. ip is the client address used for
+// per-IP brute-force tracking and may be empty.
+func (m *Manager) Login(email, password, ip string) (*TokenPair, *User, error) {
 	if m.store == nil {
-		return "", nil, errors.New("user store is not configured")
+		return nil, nil, errors.New("user store is not configured")
 	}
 
-	user, err := m.store.FindByEmail(context.Background(), email)
+	ctx := context.Background()
+	keys := m.loginKeys(email, ip)
+	if err := m.checkLoginAllowed(ctx, keys); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := m.directoryLogin(ctx, email, password)
+	if user == nil && err == nil {
+		user, err = m.store.FindByEmail(ctx, email)
+		if err == nil && user.ServiceAccount {
+			err = ErrInvalidClientCredentials
+		}
+		if err == nil {
+			err = bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password))
+		}
+	}
 	if err != nil {
-		return "", nil, errors.New("invalid credentials")
+		if err := m.recordLoginFailure(ctx, keys); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("invalid credentials")
 	}
 
-	if err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)); err != nil {
-		return "", nil, errors.New("invalid credentials")
+	if !user.Active {
+		return nil, nil, ErrUserDisabled
 	}
 
-	token, err := m.generateJWT(user)
+	required, err := m.mfaRequired(ctx, user.ID)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
+	}
+	// With MFA the login only succeeds once CompleteMFALogin verifies the
+	// code, so failures are kept until then; clearing them here would let
+	// anyone with the password reset the count between code guesses.
+	if required {
+		mfaToken, err := m.generateMFAToken(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &TokenPair{MFAToken: mfaToken}, user, nil
+	}
+	if err := m.clearLoginFailures(ctx, email); err != nil {
+		return nil, nil, err
 	}
 
-	return token, user, nil
+	tokens, err := m.issueTokens(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tokens, user, nil
 }
 
 func (m *Manager) ValidateJWT(token string) (*Claims, error) {
 	claims := &Claims{}
-	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return m.jwtSecret, nil
-	})
+	opts := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+	if m.audience != "" {
+		opts = append(opts, jwt.WithAudience(m.audience))
+	}
+	parsed, err := jwt.ParseWithClaims(token, claims, m.verificationKey, opts...)
 	if err != nil || !parsed.Valid {
 		return nil, errors.New("invalid token")
 	}
+	for _, aud := range claims.Audience {
+		if aud == mfaTokenAudience {
+			return nil, errors.New("invalid token")
+		}
+	}
 
 	if m.store != nil {
-		if _, err := m.store.FindByID(context.Background(), claims.Subject); err != nil {
+		user, err := m.store.FindByID(context.Background(), claims.Subject)
+		if err != nil {
 			return nil, errors.New("user not found")
 		}
+		if !user.Active {
+			return nil, ErrUserDisabled
+		}
+		// Use the stored role so demotions apply to tokens already issued.
+		claims.Role = user.Role
+		claims.Name = user.DisplayName()
+		claims.Scopes = user.Scopes
 	}
 
 	return claims, nil
 }
 
-// AllUsers returns a shallow copy of users for read-only purposes.
+// AllUsers returns a shallow copy of human users for read-only purposes.
+// Service accounts are listed by ServiceAccounts.
 func (m *Manager) AllUsers() []*User {
 	if m.store == nil {
 		return nil
@@ -149,34 +315,175 @@ func (m *Manager) AllUsers() []*User {
 	if err != nil {
 		return nil
 	}
-	return users
+
+	humans := users[:0]
+	for _, u := range users {
+		if !u.ServiceAccount {
+			humans = append(humans, u)
+		}
+	}
+	return humans
 }
 
-// DeleteUser deletes a user by ID.
+// GetUser returns a user by ID.
+func (m *Manager) GetUser(id string) (*User, error) {
+	if m.store == nil {
+		return nil, errors.New("user store is not configured")
+	}
+	return m.store.FindByID(context.Background(), id)
+}
+
+// UpdateProfile replaces the profile fields of a user.
+func (m *Manager) UpdateProfile(id string, profile Profile) (*User, error) {
+	if m.store == nil {
+		return nil, errors.New("user store is not configured")
+	}
+	ctx := context.Background()
+	if err := m.store.UpdateProfile(ctx, id, profile); err != nil {
+		return nil, err
+	}
+	return m.store.FindByID(ctx, id)
+}
+
+// UpdateUser changes the email and/or role of a user. Empty values keep the
+// current setting. The last root user cannot be demoted.
+func (m *Manager) UpdateUser(id, email, role string) (*User, error) {
+	if m.store == nil {
+		return nil, errors.New("user store is not configured")
+	}
+	if role != "" && !ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctx := context.Background()
+	user, err := m.store.FindByID(ctx, id)
+	if err != nil || user.ServiceAccount {
+		return nil, ErrUserNotFound
+	}
+
+	if email != "" && email != user.Email {
+		if existing, err := m.store.FindByEmail(ctx, email); err == nil && existing != nil {
+			return nil, ErrEmailTaken
+		}
+		user.Email = email
+	}
+	if role != "" && role != user.Role {
+		if user.Role == RoleRoot {
+			if err := m.ensureOtherRoot(ctx, user); err != nil {
+				return nil, err
+			}
+		}
+		user.Role = role
+	}
+
+	if err := m.store.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser deletes a user by ID. The last root user cannot be deleted.
 func (m *Manager) DeleteUser(id string) error {
 	if m.store == nil {
 		return errors.New("user store is not configured")
 	}
-	return m.store.Delete(context.Background(), id)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctx := context.Background()
+	if user, err := m.store.FindByID(ctx, id); err == nil && user.Role == RoleRoot {
+		if err := m.ensureOtherRoot(ctx, user); err != nil {
+			return err
+		}
+	}
+	return m.store.Delete(ctx, id)
+}
+
+// SetUserActive enables or disables a user. Disabling revokes the user's
+// refresh tokens; access tokens stop validating immediately. The last active
+// root user cannot be disabled.
+func (m *Manager) SetUserActive(id string, active bool) (*User, error) {
+	if m.store == nil {
+		return nil, errors.New("user store is not configured")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctx := context.Background()
+	user, err := m.store.FindByID(ctx, id)
+	if err != nil || user.ServiceAccount {
+		return nil, ErrUserNotFound
+	}
+	if user.Active == active {
+		return user, nil
+	}
+	if !active && user.Role == RoleRoot {
+		if err := m.ensureOtherRoot(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.store.SetActive(ctx, id, active); err != nil {
+		return nil, err
+	}
+	if !active && m.refreshStore != nil {
+		if err := m.refreshStore.RevokeAllForUser(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	user.Active = active
+	return user, nil
+}
+
+// ensureOtherRoot fails unless an active root user other than user exists.
+func (m *Manager) ensureOtherRoot(ctx context.Context, user *User) error {
+	roots, err := m.store.CountByRole(ctx, RoleRoot)
+	if err != nil {
+		return err
+	}
+	// CountByRole only counts active users, so user is among them only when
+	// it is active itself.
+	if user.Active {
+		roots--
+	}
+	if roots < 1 {
+		return ErrLastRoot
+	}
+	return nil
 }
 
 type Claims struct {
 	jwt.RegisteredClaims
-	Email string `json:"email"`
-	Role  string `json:"role"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	Workspace string `json:"workspace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// Scopes is only set for service accounts.
+	Scopes []string `json:"scopes,omitempty"`
 }
 
 func (m *Manager) generateJWT(user *User) (string, error) {
 	claims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
 			Subject:   user.ID,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.accessTokenTTL)),
 		},
-		Email: user.Email,
-		Role:  user.Role,
+		Email:     user.Email,
+		Role:      user.Role,
+		Workspace: user.Workspace,
+		Name:      user.DisplayName(),
+		Scopes:    user.Scopes,
+	}
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.jwtSecret)
+	return m.signToken(claims)
 }
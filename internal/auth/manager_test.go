@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLastRootGuard(t *testing.T) {
+	tests := []struct {
+		name    string
+		roots   []*User
+		target  string
+		action  func(m *Manager, id string) error
+		wantErr error
+	}{
+		{
+			name:   "delete disabled root while another is active",
+			roots:  []*User{{ID: "active", Active: true}, {ID: "disabled"}},
+			target: "disabled",
+			action: func(m *Manager, id string) error { return m.DeleteUser(id) },
+		},
+		{
+			name:    "delete disabled root without an active one",
+			roots:   []*User{{ID: "disabled"}},
+			target:  "disabled",
+			action:  func(m *Manager, id string) error { return m.DeleteUser(id) },
+			wantErr: ErrLastRoot,
+		},
+		{
+			name:    "delete the only active root",
+			roots:   []*User{{ID: "active", Active: true}, {ID: "disabled"}},
+			target:  "active",
+			action:  func(m *Manager, id string) error { return m.DeleteUser(id) },
+			wantErr: ErrLastRoot,
+		},
+		{
+			name:   "delete one of two active roots",
+			roots:  []*User{{ID: "a", Active: true}, {ID: "b", Active: true}},
+			target: "a",
+			action: func(m *Manager, id string) error { return m.DeleteUser(id) },
+		},
+		{
+			name:   "demote disabled root while another is active",
+			roots:  []*User{{ID: "active", Active: true}, {ID: "disabled"}},
+			target: "disabled",
+			action: func(m *Manager, id string) error {
+				_, err := m.UpdateUser(id, "", RoleUser)
+				return err
+			},
+		},
+		{
+			name:    "disable the only active root",
+			roots:   []*User{{ID: "active", Active: true}, {ID: "disabled"}},
+			target:  "active",
+			action:  func(m *Manager, id string) error { _, err := m.SetUserActive(id, false); return err },
+			wantErr: ErrLastRoot,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, u := range tt.roots {
+				u.Email = u.ID + "@example.com"
+				u.Role = RoleRoot
+			}
+			m := NewManager("secret", newMemoryUsers(tt.roots...))
+
+			if err := tt.action(m, tt.target); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
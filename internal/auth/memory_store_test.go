@@ -0,0 +1,265 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryUsers is a UserStore kept in memory.
+type memoryUsers struct {
+	mu    sync.Mutex
+	users map[string]*User
+}
+
+func newMemoryUsers(users ...*User) *memoryUsers {
+	s := &memoryUsers{users: make(map[string]*User)}
+	for _, u := range users {
+		s.users[u.ID] = u
+	}
+	return s
+}
+
+func (s *memoryUsers) Create(ctx context.Context, u *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.users {
+		if strings.EqualFold(existing.Email, u.Email) {
+			return ErrEmailTaken
+		}
+	}
+	copied := *u
+	s.users[u.ID] = &copied
+	return nil
+}
+
+func (s *memoryUsers) Upsert(ctx context.Context, u *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *u
+	s.users[u.ID] = &copied
+	return nil
+}
+
+func (s *memoryUsers) FindByEmail(ctx context.Context, email string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if strings.EqualFold(u.Email, email) {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (s *memoryUsers) FindByID(ctx context.Context, id string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	copied := *u
+	return &copied, nil
+}
+
+func (s *memoryUsers) List(ctx context.Context) ([]*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]*User, 0, len(s.users))
+	for _, u := range s.users {
+		copied := *u
+		users = append(users, &copied)
+	}
+	return users, nil
+}
+
+func (s *memoryUsers) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[id]; !ok {
+		return ErrUserNotFound
+	}
+	delete(s.users, id)
+	return nil
+}
+
+func (s *memoryUsers) UpdatePassword(ctx context.Context, id string, passwordHash []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	u.PasswordHash = passwordHash
+	return nil
+}
+
+func (s *memoryUsers) UpdateProfile(ctx context.Context, id string, profile Profile) error {
+	return nil
+}
+
+func (s *memoryUsers) Update(ctx context.Context, u *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.users[u.ID]
+	if !ok {
+		return ErrUserNotFound
+	}
+	existing.Email = u.Email
+	existing.Role = u.Role
+	return nil
+}
+
+func (s *memoryUsers) CountByRole(ctx context.Context, role string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, u := range s.users {
+		if u.Role == role && u.Active {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memoryUsers) SetActive(ctx context.Context, id string, active bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	u.Active = active
+	return nil
+}
+
+// memorySSO keeps linked identities in memory; login codes are not
+// supported.
+type memorySSO struct {
+	SSOStore
+	links map[string]string
+}
+
+func newMemorySSO() *memorySSO {
+	return &memorySSO{links: make(map[string]string)}
+}
+
+func (s *memorySSO) FindIdentity(ctx context.Context, provider, subject string) (string, error) {
+	return s.links[provider+"|"+subject], nil
+}
+
+func (s *memorySSO) LinkIdentity(ctx context.Context, provider, subject, userID string) error {
+	s.links[provider+"|"+subject] = userID
+	return nil
+}
+
+// memoryAttempts is a LoginAttemptStore kept in memory.
+type memoryAttempts struct {
+	attempts map[string]*LoginAttempt
+}
+
+func newMemoryAttempts() *memoryAttempts {
+	return &memoryAttempts{attempts: make(map[string]*LoginAttempt)}
+}
+
+func (s *memoryAttempts) Get(ctx context.Context, scope, key string) (*LoginAttempt, error) {
+	if a, ok := s.attempts[scope+"/"+key]; ok {
+		copied := *a
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (s *memoryAttempts) RecordFailure(ctx context.Context, scope, key string, window time.Duration) (*LoginAttempt, error) {
+	a, ok := s.attempts[scope+"/"+key]
+	if !ok {
+		a = &LoginAttempt{Scope: scope, Key: key}
+		s.attempts[scope+"/"+key] = a
+	}
+	a.Failures++
+	a.LastFailureAt = time.Now()
+	copied := *a
+	return &copied, nil
+}
+
+func (s *memoryAttempts) Lock(ctx context.Context, scope, key string, until time.Time) error {
+	s.attempts[scope+"/"+key].LockedUntil = &until
+	return nil
+}
+
+func (s *memoryAttempts) Reset(ctx context.Context, scope, key string) error {
+	delete(s.attempts, scope+"/"+key)
+	return nil
+}
+
+func (s *memoryAttempts) List(ctx context.Context, since time.Time) ([]*LoginAttempt, error) {
+	return nil, nil
+}
+
+// memoryMFA is an MFAStore kept in memory.
+type memoryMFA struct {
+	states   map[string]*MFAState
+	recovery map[string]map[string]bool
+}
+
+func newMemoryMFA() *memoryMFA {
+	return &memoryMFA{states: make(map[string]*MFAState), recovery: make(map[string]map[string]bool)}
+}
+
+func (s *memoryMFA) Get(ctx context.Context, userID string) (*MFAState, error) {
+	st, ok := s.states[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *st
+	return &copied, nil
+}
+
+func (s *memoryMFA) SaveSecret(ctx context.Context, userID, secret string) error {
+	s.states[userID] = &MFAState{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (s *memoryMFA) Enable(ctx context.Context, userID string, recoveryHashes []string) error {
+	s.states[userID].Enabled = true
+	s.recovery[userID] = make(map[string]bool)
+	for _, h := range recoveryHashes {
+		s.recovery[userID][h] = true
+	}
+	return nil
+}
+
+func (s *memoryMFA) Disable(ctx context.Context, userID string) error {
+	delete(s.states, userID)
+	delete(s.recovery, userID)
+	return nil
+}
+
+func (s *memoryMFA) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	st := s.states[userID]
+	if st.LastUsedStep >= step {
+		return false, nil
+	}
+	st.LastUsedStep = step
+	return true, nil
+}
+
+func (s *memoryMFA) UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error) {
+	if !s.recovery[userID][hash] {
+		return false, nil
+	}
+	delete(s.recovery[userID], hash)
+	return true, nil
+}
+
+func (s *memoryMFA) RemainingRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	return len(s.recovery[userID]), nil
+}
+
+func (s *memorySSO) CreateLoginCode(ctx context.Context, code SSOLoginCode) error {
+	return nil
+}
@@ -0,0 +1,468 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	totpPeriod         = 30
+	totpDigits         = 6
+	totpSkewSteps      = 1
+	mfaTokenTTL        = 5 * time.Minute
+	mfaTokenAudience   = "yuon-mfa"
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var (
+	ErrMFANotConfigured   = errors.New("mfa store is not configured")
+	ErrMFANotEnrolled     = errors.New("mfa is not enrolled")
+	ErrMFAAlreadyEnabled  = errors.New("mfa is already enabled")
+	ErrInvalidMFACode     = errors.New("invalid mfa code")
+	ErrInvalidMFAToken    = errors.New("invalid mfa token")
+	base32NoPad           = base32.StdEncoding.WithPadding(base32.NoPadding)
+	recoveryCodeAlphabet  = "abcdefghjkmnpqrstuvwxyz23456789"
+	errRecoveryCodeRandom = errors.New("recovery code generation failed")
+)
+
+type MFAState struct {
+	UserID       string
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+	EnabledAt    *time.Time
+}
+
+type MFAStore interface {
+	Get(ctx context.Context, userID string) (*MFAState, error)
+	// SaveSecret stores a pending (not yet enabled) secret.
+	SaveSecret(ctx context.Context, userID, secret string) error
+	// Enable activates MFA and replaces the recovery codes.
+	Enable(ctx context.Context, userID string, recoveryHashes []string) error
+	Disable(ctx context.Context, userID string) error
+	// UseStep records step as used; false means it (or a later one) was used.
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error)
+	RemainingRecoveryCodes(ctx context.Context, userID string) (int, error)
+}
+
+type PostgresMFAStore struct {
+	db *sql.DB
+}
+
+func NewPostgresMFAStore(db *sql.DB) *PostgresMFAStore {
+	return &PostgresMFAStore{db: db}
+}
+
+func (s *PostgresMFAStore) Get(ctx context.Context, userID string) (*MFAState, error) {
+	var (
+		st        MFAState
+		enabledAt sql.NullTime
+	)
+	err := s.db.QueryRowContext(ctx,
+		`SELECT user_id, secret, enabled, last_used_step, enabled_at FROM user_mfa WHERE user_id = $1`, userID,
+	).Scan(&st.UserID, &st.Secret, &st.Enabled, &st.LastUsedStep, &enabledAt)
+	if err != nil {
+		return nil, err
+	}
+	if enabledAt.Valid {
+		st.EnabledAt = &enabledAt.Time
+	}
+	return &st, nil
+}
+
+func (s *PostgresMFAStore) SaveSecret(ctx context.Context, userID, secret string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_mfa (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, enabled = FALSE, last_used_step = 0, enabled_at = NULL
+		WHERE user_mfa.enabled = FALSE`, userID, secret)
+	if err != nil {
+		return fmt.Errorf("save mfa secret failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresMFAStore) Enable(ctx context.Context, userID string, recoveryHashes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("enable mfa failed: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE user_mfa SET enabled = TRUE, enabled_at = NOW() WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("enable mfa failed: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("enable mfa failed: %w", err)
+	}
+	for _, h := range recoveryHashes {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, h); err != nil {
+			return fmt.Errorf("enable mfa failed: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("enable mfa failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresMFAStore) Disable(ctx context.Context, userID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("disable mfa failed: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("disable mfa failed: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("disable mfa failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("disable mfa failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresMFAStore) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE user_mfa SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("record mfa step failed: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (s *PostgresMFAStore) UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE mfa_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, hash)
+	if err != nil {
+		return false, fmt.Errorf("use recovery code failed: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (s *PostgresMFAStore) RemainingRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&n)
+	return n, err
+}
+
+// SetMFAStore enables TOTP multi-factor authentication. issuer is shown in
+// authenticator apps.
+func (m *Manager) SetMFAStore(store MFAStore, issuer string) {
+	m.mfaStore = store
+	m.mfaIssuer = issuer
+}
+
+// MFAStatus reports whether MFA is enabled and how many recovery codes are
+// left.
+func (m *Manager) MFAStatus(userID string) (bool, int, error) {
+	if m.mfaStore == nil {
+		return false, 0, ErrMFANotConfigured
+	}
+	ctx := context.Background()
+	st, err := m.mfaStore.Get(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	if !st.Enabled {
+		return false, 0, nil
+	}
+	remaining, err := m.mfaStore.RemainingRecoveryCodes(ctx, userID)
+	return true, remaining, err
+}
+
+// EnrollMFA creates a pending TOTP secret and returns it with its otpauth://
+// URI. MFA is only enforced after ActivateMFA confirms a code.
+func (m *Manager) EnrollMFA(userID string) (string, string, error) {
+	if m.mfaStore == nil {
+		return "", "", ErrMFANotConfigured
+	}
+	ctx := context.Background()
+	user, err := m.store.FindByID(ctx, userID)
+	if err != nil {
+		return "", "", errors.New("user not found")
+	}
+	if st, err := m.mfaStore.Get(ctx, userID); err == nil && st.Enabled {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("mfa secret generation failed: %w", err)
+	}
+	secret := base32NoPad.EncodeToString(buf)
+	if err := m.mfaStore.SaveSecret(ctx, userID, secret); err != nil {
+		return "", "", err
+	}
+
+	issuer := m.mfaIssuer
+	if issuer == "" {
+		issuer = "YUON"
+	}
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	uri := "otpauth://totp/" + url.PathEscape(issuer+":"+user.Email) + "?" + q.Encode()
+
+	return secret, uri, nil
+}
+
+// ActivateMFA confirms enrollment with a TOTP code and returns the recovery
+// codes. The plaintext codes are only returned here.
+func (m *Manager) ActivateMFA(userID, code string) ([]string, error) {
+	if m.mfaStore == nil {
+		return nil, ErrMFANotConfigured
+	}
+	ctx := context.Background()
+	st, err := m.mfaStore.Get(ctx, userID)
+	if err != nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if st.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if err := m.checkTOTP(ctx, st, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.mfaStore.Enable(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableMFA turns MFA off after verifying a TOTP or recovery code. ip is
+// the client address and may be empty. Wrong codes count against the account
+// and ip like in CompleteMFALogin, so a stolen access token cannot be used to
+// guess the code and strip the second factor.
+func (m *Manager) DisableMFA(userID, code, ip string) error {
+	if m.mfaStore == nil {
+		return ErrMFANotConfigured
+	}
+
+	ctx := context.Background()
+	user, err := m.store.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	keys := m.loginKeys(user.Email, ip)
+	if err := m.checkLoginAllowed(ctx, keys); err != nil {
+		return err
+	}
+	if err := m.verifyMFA(ctx, userID, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := m.recordLoginFailure(ctx, keys); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+	if err := m.clearLoginFailures(ctx, user.Email); err != nil {
+		return err
+	}
+	return m.mfaStore.Disable(ctx, userID)
+}
+
+// ResetMFA removes MFA without a code, for administrators helping locked out
+// users.
+func (m *Manager) ResetMFA(userID string) error {
+	if m.mfaStore == nil {
+		return ErrMFANotConfigured
+	}
+	return m.mfaStore.Disable(context.Background(), userID)
+}
+
+// CompleteMFALogin exchanges the MFA token issued by Login and a TOTP or
+// recovery code for a token pair. ip is the client address, tracked like in
+// Login, and may be empty.
+func (m *Manager) CompleteMFALogin(mfaToken, code, ip string) (*TokenPair, *User, error) {
+	if m.mfaStore == nil {
+		return nil, nil, ErrMFANotConfigured
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	parsed, err := jwt.ParseWithClaims(mfaToken, claims, m.verificationKey,
+		jwt.WithAudience(mfaTokenAudience), jwt.WithIssuer(m.issuer), jwt.WithExpirationRequired())
+	if err != nil || !parsed.Valid {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	ctx := context.Background()
+	user, err := m.store.FindByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, nil, ErrInvalidMFAToken
+	}
+	if !user.Active {
+		return nil, nil, ErrUserDisabled
+	}
+	// Wrong second factors count against the account like wrong passwords.
+	keys := m.loginKeys(user.Email, ip)
+	if err := m.checkLoginAllowed(ctx, keys); err != nil {
+		return nil, nil, err
+	}
+	if err := m.verifyMFA(ctx, user.ID, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := m.recordLoginFailure(ctx, keys); err != nil {
+				return nil, nil, err
+			}
+		}
+		return nil, nil, err
+	}
+	if err := m.clearLoginFailures(ctx, user.Email); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := m.issueTokens(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, user, nil
+}
+
+// mfaRequired reports whether the user must complete a second factor.
+func (m *Manager) mfaRequired(ctx context.Context, userID string) (bool, error) {
+	if m.mfaStore == nil {
+		return false, nil
+	}
+	st, err := m.mfaStore.Get(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return st.Enabled, nil
+}
+
+func (m *Manager) generateMFAToken(user *User) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Issuer:    m.issuer,
+		Subject:   user.ID,
+		Audience:  jwt.ClaimStrings{mfaTokenAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(mfaTokenTTL)),
+	}
+	return m.signToken(claims)
+}
+
+// verifyMFA accepts a TOTP code or an unused recovery code.
+func (m *Manager) verifyMFA(ctx context.Context, userID, code string) error {
+	st, err := m.mfaStore.Get(ctx, userID)
+	if err != nil || !st.Enabled {
+		return ErrMFANotEnrolled
+	}
+
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) == totpDigits {
+		return m.checkTOTP(ctx, st, code)
+	}
+
+	ok, err := m.mfaStore.UseRecoveryCode(ctx, userID, hashToken(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (m *Manager) checkTOTP(ctx context.Context, st *MFAState, code string) error {
+	key, err := base32NoPad.DecodeString(st.Secret)
+	if err != nil {
+		return fmt.Errorf("invalid mfa secret: %w", err)
+	}
+
+	now := time.Now().Unix() / totpPeriod
+	for offset := int64(-totpSkewSteps); offset <= totpSkewSteps; offset++ {
+		step := now + offset
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) != 1 {
+			continue
+		}
+		ok, err := m.mfaStore.UseStep(ctx, st.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+	return ErrInvalidMFACode
+}
+
+// totpCode implements RFC 6238 with HMAC-SHA1.
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	buf := make([]byte, recoveryCodeLength)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, errRecoveryCodeRandom
+		}
+		var b strings.Builder
+		for j, c := range buf {
+			if j == recoveryCodeLength/2 {
+				b.WriteByte('-')
+			}
+			b.WriteByte(recoveryCodeAlphabet[int(c)%len(recoveryCodeAlphabet)])
+		}
+		codes[i] = b.String()
+		hashes[i] = hashToken(strings.ReplaceAll(codes[i], "-", ""))
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// enabledMFAManager returns a manager whose user "u1" has MFA enabled, with
+// the TOTP key to compute valid codes.
+func enabledMFAManager(t *testing.T, policy LockoutPolicy) (*Manager, *memoryMFA, []byte) {
+	t.Helper()
+	m := NewManager("secret", newMemoryUsers(&User{ID: "u1", Email: "user@example.com", Role: RoleUser, Active: true}))
+	mfa := newMemoryMFA()
+	m.SetMFAStore(mfa, "")
+	m.SetLoginAttemptStore(newMemoryAttempts(), policy)
+
+	secret, _, err := m.EnrollMFA("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := base32NoPad.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 활성화에 쓴 단계는 재사용할 수 없으므로 이전 단계 코드로 활성화
+	if _, err := m.ActivateMFA("u1", totpCode(key, time.Now().Unix()/totpPeriod-1)); err != nil {
+		t.Fatal(err)
+	}
+	return m, mfa, key
+}
+
+func TestDisableMFALocksOutAfterWrongCodes(t *testing.T) {
+	m, mfa, key := enabledMFAManager(t, LockoutPolicy{
+		MaxFailures: 3,
+		BaseDelay:   time.Nanosecond,
+		MaxDelay:    time.Nanosecond,
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := m.DisableMFA("u1", "000000", "203.0.113.7"); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidMFACode", i+1, err)
+		}
+	}
+
+	var locked *LoginLockedError
+	err := m.DisableMFA("u1", totpCode(key, time.Now().Unix()/totpPeriod), "198.51.100.1")
+	if !errors.As(err, &locked) || !locked.Locked {
+		t.Fatalf("valid code after lockout: err = %v, want a lockout", err)
+	}
+	if st := mfa.states["u1"]; st == nil || !st.Enabled {
+		t.Error("MFA was disabled while locked out")
+	}
+}
+
+func TestDisableMFAWithValidCode(t *testing.T) {
+	m, mfa, key := enabledMFAManager(t, LockoutPolicy{MaxFailures: 3, BaseDelay: time.Nanosecond, MaxDelay: time.Nanosecond})
+
+	if err := m.DisableMFA("u1", "000000", ""); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("wrong code: err = %v", err)
+	}
+	if err := m.DisableMFA("u1", totpCode(key, time.Now().Unix()/totpPeriod), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mfa.states["u1"]; ok {
+		t.Error("MFA still enabled")
+	}
+	if a, _ := m.attempts.Get(t.Context(), LoginScopeAccount, "user@example.com"); a != nil {
+		t.Errorf("failures kept after a valid code: %+v", a)
+	}
+}
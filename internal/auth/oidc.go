@@ -0,0 +1,321 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"yuon/configuration"
+)
+
+var (
+	ErrOIDCEmailNotVerified = errors.New("oidc email is not verified")
+	ErrOIDCDomainNotAllowed = errors.New("oidc hosted domain is not allowed")
+)
+
+// OIDCIdentity is the verified subset of ID token claims used for login.
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	HostedDomain  string
+	Name          string
+}
+
+// OIDCProvider implements the authorization code flow against an OpenID
+// Connect issuer (Google by default). Discovery and JWKS are fetched lazily
+// and cached.
+type OIDCProvider struct {
+	cfg        *configuration.OIDCConfig
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	authEndpoint  string
+	tokenEndpoint string
+	jwksURI       string
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewOIDCProvider(cfg *configuration.OIDCConfig) (*OIDCProvider, error) {
+	if cfg == nil {
+		return nil, errors.New("oidc config is nil")
+	}
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.ClientSecret == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("oidc issuer, client id, client secret and redirect url are required")
+	}
+	if cfg.HostedDomain == "" && len(cfg.AllowedDomains) == 0 {
+		return nil, errors.New("oidc hosted domain or allowed domains are required")
+	}
+	if !ValidRole(cfg.DefaultRole) || cfg.DefaultRole == RoleRoot || cfg.DefaultRole == RoleAdmin {
+		return nil, fmt.Errorf("oidc default role %q is not allowed", cfg.DefaultRole)
+	}
+	return &OIDCProvider{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// NewOIDCState returns a random value usable as state or nonce.
+func NewOIDCState() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// AuthCodeURL builds the authorization endpoint URL the browser is sent to.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", "openid email profile")
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	if p.cfg.HostedDomain != "" {
+		q.Set("hd", p.cfg.HostedDomain)
+	}
+	return p.authEndpoint + "?" + q.Encode(), nil
+}
+
+// Exchange redeems an authorization code and verifies the returned ID token.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, nonce string) (*OIDCIdentity, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("client_secret", p.cfg.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token exchange failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc token exchange failed: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("oidc token response decode failed: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("oidc token response has no id_token")
+	}
+
+	return p.verifyIDToken(ctx, token.IDToken, nonce)
+}
+
+type oidcClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"`
+	HostedDomain  string `json:"hd"`
+	Name          string `json:"name"`
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, raw, nonce string) (*OIDCIdentity, error) {
+	claims := &oidcClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc id token invalid: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("oidc nonce mismatch")
+	}
+
+	verified := false
+	switch v := claims.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+
+	return &OIDCIdentity{
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: verified,
+		HostedDomain:  claims.HostedDomain,
+		Name:          claims.Name,
+	}, nil
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tokenEndpoint != "" {
+		return nil
+	}
+
+	var doc struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+	wellKnown := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &doc); err != nil {
+		return fmt.Errorf("oidc discovery failed: %w", err)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return errors.New("oidc discovery document is incomplete")
+	}
+
+	p.authEndpoint = doc.AuthorizationEndpoint
+	p.tokenEndpoint = doc.TokenEndpoint
+	p.jwksURI = doc.JWKSURI
+	return nil
+}
+
+// publicKey returns the signing key for kid, refetching the JWKS when the key
+// is unknown (rotation) at most once a minute.
+func (p *OIDCProvider) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < time.Minute && p.keys != nil {
+		return nil, fmt.Errorf("oidc signing key %q not found", kid)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.jwksURI, &jwks); err != nil {
+		return nil, fmt.Errorf("oidc jwks fetch failed: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("oidc signing key %q not found", kid)
+	}
+	return key, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, endpoint string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// LoginWithOIDC signs in a verified OIDC identity through LoginFederated.
+// Users from the allowed hosted domain and email domains are provisioned with
+// the configured default role. Without either restriction every login is
+// refused, since any account of the issuer would otherwise get a user.
+func (m *Manager) LoginWithOIDC(identity *OIDCIdentity, cfg *configuration.OIDCConfig) (*SSOLogin, error) {
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+	if cfg.HostedDomain == "" && len(cfg.AllowedDomains) == 0 {
+		return nil, ErrOIDCDomainNotAllowed
+	}
+	if cfg.HostedDomain != "" && !strings.EqualFold(identity.HostedDomain, cfg.HostedDomain) {
+		return nil, ErrOIDCDomainNotAllowed
+	}
+	if len(cfg.AllowedDomains) > 0 && !emailDomainAllowed(identity.Email, cfg.AllowedDomains) {
+		return nil, ErrOIDCDomainNotAllowed
+	}
+	return m.LoginFederated(FederatedIdentity{
+		Provider: "oidc:" + cfg.Issuer,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		Role:     cfg.DefaultRole,
+	})
+}
+
+func emailDomainAllowed(email string, domains []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, allowed := range domains {
+		if strings.EqualFold(domain, strings.TrimSpace(allowed)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"yuon/configuration"
+)
+
+func TestLoginWithOIDCDomains(t *testing.T) {
+	identity := func(email, hd string) *OIDCIdentity {
+		return &OIDCIdentity{Subject: "sub-" + email, Email: email, EmailVerified: true, HostedDomain: hd}
+	}
+	tests := []struct {
+		name     string
+		cfg      configuration.OIDCConfig
+		identity *OIDCIdentity
+		allowed  bool
+	}{
+		{"no restriction", configuration.OIDCConfig{}, identity("anyone@gmail.com", ""), false},
+		{"hosted domain", configuration.OIDCConfig{HostedDomain: "example.com"}, identity("kim@example.com", "example.com"), true},
+		{"other hosted domain", configuration.OIDCConfig{HostedDomain: "example.com"}, identity("kim@gmail.com", ""), false},
+		{"allowed email domain", configuration.OIDCConfig{AllowedDomains: []string{"example.com", "example.org"}}, identity("lee@Example.org", ""), true},
+		{"other email domain", configuration.OIDCConfig{AllowedDomains: []string{"example.com"}}, identity("lee@example.com.evil", ""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newMemoryUsers()
+			m := NewManager("secret", users)
+			m.SetSSOStore(newMemorySSO())
+			tt.cfg.Issuer = "https://accounts.google.com"
+			tt.cfg.DefaultRole = RoleUser
+
+			_, err := m.LoginWithOIDC(tt.identity, &tt.cfg)
+			if tt.allowed && err != nil {
+				t.Fatalf("err = %v, want a login", err)
+			}
+			if !tt.allowed {
+				if !errors.Is(err, ErrOIDCDomainNotAllowed) {
+					t.Fatalf("err = %v, want ErrOIDCDomainNotAllowed", err)
+				}
+				if _, err := users.FindByEmail(context.Background(), tt.identity.Email); !errors.Is(err, ErrUserNotFound) {
+					t.Errorf("user provisioned for a refused login: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewOIDCProviderRejectsUnsafeConfig(t *testing.T) {
+	base := configuration.OIDCConfig{
+		Issuer:       "https://accounts.google.com",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "https://yuon.example.com/api/v1/auth/oidc/callback",
+		HostedDomain: "example.com",
+		DefaultRole:  RoleUser,
+	}
+	if _, err := NewOIDCProvider(&base); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*configuration.OIDCConfig){
+		"no domain restriction": func(c *configuration.OIDCConfig) { c.HostedDomain = "" },
+		"root default role":     func(c *configuration.OIDCConfig) { c.DefaultRole = RoleRoot },
+		"admin default role":    func(c *configuration.OIDCConfig) { c.DefaultRole = RoleAdmin },
+		"unknown default role":  func(c *configuration.OIDCConfig) { c.DefaultRole = "superuser" },
+	} {
+		cfg := base
+		mutate(&cfg)
+		if _, err := NewOIDCProvider(&cfg); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const defaultPasswordResetTTL = 30 * time.Minute
+
+var (
+	ErrInvalidResetToken          = errors.New("invalid or expired reset token")
+	ErrPasswordResetNotConfigured = errors.New("password reset store is not configured")
+)
+
+type PasswordResetStore interface {
+	Create(ctx context.Context, id, userID, tokenHash string, expiresAt time.Time) error
+	// Consume marks an unused, unexpired token as used and returns its user.
+	Consume(ctx context.Context, tokenHash string) (string, error)
+}
+
+type PostgresPasswordResetStore struct {
+	db *sql.DB
+}
+
+func NewPostgresPasswordResetStore(db *sql.DB) *PostgresPasswordResetStore {
+	return &PostgresPasswordResetStore{db: db}
+}
+
+func (s *PostgresPasswordResetStore) Create(ctx context.Context, id, userID, tokenHash string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		id, userID, tokenHash, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create password reset token failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresPasswordResetStore) Consume(ctx context.Context, tokenHash string) (string, error) {
+	var userID string
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`, tokenHash).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrInvalidResetToken
+	}
+	if err != nil {
+		return "", fmt.Errorf("consume password reset token failed: %w", err)
+	}
+	return userID, nil
+}
+
+// SetPasswordResetStore enables the forgot/reset password flow.
+func (m *Manager) SetPasswordResetStore(store PasswordResetStore, ttl time.Duration) {
+	m.resetStore = store
+	if ttl > 0 {
+		m.passwordResetTTL = ttl
+	}
+}
+
+// RequestPasswordReset issues a single-use reset token for email. Unknown
+// emails return an empty token and no error so callers cannot probe accounts.
+func (m *Manager) RequestPasswordReset(email string) (string, *User, error) {
+	if m.resetStore == nil {
+		return "", nil, ErrPasswordResetNotConfigured
+	}
+	if m.store == nil {
+		return "", nil, errors.New("user store is not configured")
+	}
+
+	ctx := context.Background()
+	user, err := m.store.FindByEmail(ctx, email)
+	if err != nil || user.ServiceAccount {
+		return "", nil, nil
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("reset token generation failed: %w", err)
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+
+	if err := m.resetStore.Create(ctx, uuid.New().String(), user.ID, hashToken(raw), time.Now().Add(m.passwordResetTTL)); err != nil {
+		return "", nil, err
+	}
+	return raw, user, nil
+}
+
+// ResetPassword sets a new password using a reset token and revokes the
+// user's refresh tokens.
+func (m *Manager) ResetPassword(token, password string) error {
+	if m.resetStore == nil {
+		return ErrPasswordResetNotConfigured
+	}
+	if m.store == nil {
+		return errors.New("user store is not configured")
+	}
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	if password == "" {
+		return errors.New("password is required")
+	}
+
+	ctx := context.Background()
+	userID, err := m.resetStore.Consume(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := m.store.UpdatePassword(ctx, userID, hash); err != nil {
+		return err
+	}
+
+	if m.refreshStore != nil {
+		return m.refreshStore.RevokeAllForUser(ctx, userID)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package auth
+
+// Roles.
+const (
+	RoleRoot   = "root"
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleUser   = "user"
+)
+
+// PermissionUsersManage is only granted to root. The document and chat
+// permissions share their names with the API key scopes.
+const PermissionUsersManage = "users:manage"
+
+var rolePermissions = map[string][]string{
+	RoleAdmin:  {ScopeDocumentsRead, ScopeDocumentsWrite, ScopeChatRead, ScopeChatWrite},
+	RoleEditor: {ScopeDocumentsRead, ScopeDocumentsWrite, ScopeChatRead, ScopeChatWrite},
+	RoleUser:   {ScopeDocumentsRead, ScopeChatRead, ScopeChatWrite},
+}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	if role == RoleRoot {
+		return true
+	}
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RoleHasPermission reports whether role grants permission. Root is granted
+// everything; unknown roles are granted nothing.
+func RoleHasPermission(role, permission string) bool {
+	if role == RoleRoot {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
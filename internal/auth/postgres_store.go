@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+
+	"github.com/lib/pq"
 )
 
 type UserStore interface {
@@ -13,6 +15,24 @@ type UserStore interface {
 	FindByID(ctx context.Context, id string) (*User, error)
 	List(ctx context.Context) ([]*User, error)
 	Delete(ctx context.Context, id string) error
+	UpdatePassword(ctx context.Context, id string, passwordHash []byte) error
+	UpdateProfile(ctx context.Context, id string, profile Profile) error
+	// Update changes the email and role of a user.
+	Update(ctx context.Context, u *User) error
+	// CountByRole counts active users with role.
+	CountByRole(ctx context.Context, role string) (int, error)
+	SetActive(ctx context.Context, id string, active bool) error
+}
+
+const userColumns = `id, email, password_hash, role, workspace, name, department, avatar_url, created_at, service_account, scopes, active`
+
+func scanUser(row rowScanner) (*User, error) {
+	var u User
+	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.Workspace, &u.Name, &u.Department, &u.AvatarURL, &u.CreatedAt,
+		&u.ServiceAccount, pq.Array(&u.Scopes), &u.Active); err != nil {
+		return nil, err
+	}
+	return &u, nil
 }
 
 type PostgresUserStore struct {
@@ -25,8 +45,9 @@ func NewPostgresUserStore(db *sql.DB) *PostgresUserStore {
 
 func (s *PostgresUserStore) Create(ctx context.Context, u *User) error {
 	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO users (id, email, password_hash, role) VALUES ($1, $2, $3, $4)`,
-		u.ID, u.Email, u.PasswordHash, u.Role,
+		`INSERT INTO users (id, email, password_hash, role, workspace, name, department, avatar_url, service_account, scopes, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		u.ID, u.Email, u.PasswordHash, u.Role, u.Workspace, u.Name, u.Department, u.AvatarURL, u.ServiceAccount, pq.Array(u.Scopes), u.Active,
 	)
 	if err != nil {
 		return fmt.Errorf("create user failed: %w", err)
@@ -36,13 +57,13 @@ func (s *PostgresUserStore) Create(ctx context.Context, u *User) error {
 
 func (s *PostgresUserStore) Upsert(ctx context.Context, u *User) error {
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO users (id, email, password_hash, role)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (id, email, password_hash, role, workspace)
+		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (email) DO UPDATE SET
 			password_hash = EXCLUDED.password_hash,
 			role = EXCLUDED.role,
 			updated_at = NOW()`,
-		u.ID, u.Email, u.PasswordHash, u.Role,
+		u.ID, u.Email, u.PasswordHash, u.Role, u.Workspace,
 	)
 	if err != nil {
 		return fmt.Errorf("upsert user failed: %w", err)
@@ -51,25 +72,15 @@ func (s *PostgresUserStore) Upsert(ctx context.Context, u *User) error {
 }
 
 func (s *PostgresUserStore) FindByEmail(ctx context.Context, email string) (*User, error) {
-	row := s.db.QueryRowContext(ctx, `SELECT id, email, password_hash, role, created_at FROM users WHERE email = $1`, email)
-	var u User
-	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt); err != nil {
-		return nil, err
-	}
-	return &u, nil
+	return scanUser(s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE email = $1`, email))
 }
 
 func (s *PostgresUserStore) FindByID(ctx context.Context, id string) (*User, error) {
-	row := s.db.QueryRowContext(ctx, `SELECT id, email, password_hash, role, created_at FROM users WHERE id = $1`, id)
-	var u User
-	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt); err != nil {
-		return nil, err
-	}
-	return &u, nil
+	return scanUser(s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
 }
 
 func (s *PostgresUserStore) List(ctx context.Context) ([]*User, error) {
-	rows, err := s.db.QueryContext(ctx, `SELECT id, email, password_hash, role, created_at FROM users ORDER BY created_at DESC`)
+	rows, err := s.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, err
 	}
@@ -77,11 +88,11 @@ func (s *PostgresUserStore) List(ctx context.Context) ([]*User, error) {
 
 	var users []*User
 	for rows.Next() {
-		var u User
-		if err := rows.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt); err != nil {
+		u, err := scanUser(rows)
+		if err != nil {
 			return nil, err
 		}
-		users = append(users, &u)
+		users = append(users, u)
 	}
 	return users, nil
 }
@@ -103,3 +114,92 @@ func (s *PostgresUserStore) Delete(ctx context.Context, id string) error {
 
 	return nil
 }
+
+func (s *PostgresUserStore) UpdatePassword(ctx context.Context, id string, passwordHash []byte) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1`, id, passwordHash)
+	if err != nil {
+		return fmt.Errorf("update password failed: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (s *PostgresUserStore) UpdateProfile(ctx context.Context, id string, profile Profile) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users SET name = $2, department = $3, avatar_url = $4, updated_at = NOW()
+		WHERE id = $1`,
+		id, profile.Name, profile.Department, profile.AvatarURL,
+	)
+	if err != nil {
+		return fmt.Errorf("update profile failed: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (s *PostgresUserStore) Update(ctx context.Context, u *User) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE users SET email = $2, role = $3, updated_at = NOW() WHERE id = $1`,
+		u.ID, u.Email, u.Role,
+	)
+	if err != nil {
+		return fmt.Errorf("update user failed: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (s *PostgresUserStore) CountByRole(ctx context.Context, role string) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role = $1 AND active`, role).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count users failed: %w", err)
+	}
+	return count, nil
+}
+
+func (s *PostgresUserStore) SetActive(ctx context.Context, id string, active bool) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE users SET active = $2, updated_at = NOW() WHERE id = $1`, id, active)
+	if err != nil {
+		return fmt.Errorf("update user status failed: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRefreshToken   = errors.New("invalid refresh token")
+	ErrRefreshNotConfigured  = errors.New("refresh token store is not configured")
+	errRefreshTokenGenerator = errors.New("refresh token generation failed")
+)
+
+// Refresh exchanges a refresh token for a new token pair. The presented token
+// is revoked in the process; presenting an already revoked token is treated as
+// token theft and revokes every refresh token of the user.
+func (m *Manager) Refresh(token string) (*TokenPair, *User, error) {
+	if m.refreshStore == nil {
+		return nil, nil, ErrRefreshNotConfigured
+	}
+	if m.store == nil {
+		return nil, nil, errors.New("user store is not configured")
+	}
+	if token == "" {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	ctx := context.Background()
+	current, err := m.refreshStore.FindByHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	if current.RevokedAt != nil {
+		_ = m.refreshStore.RevokeAllForUser(ctx, current.UserID)
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	user, err := m.store.FindByID(ctx, current.UserID)
+	if err != nil || !user.Active {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	raw, next, err := m.newRefreshToken(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := m.refreshStore.Rotate(ctx, current.ID, next); err != nil {
+		if errors.Is(err, ErrRefreshTokenRevoked) {
+			_ = m.refreshStore.RevokeAllForUser(ctx, current.UserID)
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	access, err := m.generateJWT(user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &TokenPair{AccessToken: access, RefreshToken: raw, ExpiresIn: m.accessTokenTTL}, user, nil
+}
+
+// RevokeRefreshToken revokes a single refresh token (logout). Unknown tokens
+// are ignored so logout stays idempotent.
+func (m *Manager) RevokeRefreshToken(token string) error {
+	if m.refreshStore == nil {
+		return ErrRefreshNotConfigured
+	}
+	current, err := m.refreshStore.FindByHash(context.Background(), hashToken(token))
+	if err != nil {
+		return nil
+	}
+	return m.refreshStore.Revoke(context.Background(), current.ID)
+}
+
+// RevokeUserRefreshTokens revokes every refresh token issued to a user.
+func (m *Manager) RevokeUserRefreshTokens(userID string) error {
+	if m.refreshStore == nil {
+		return ErrRefreshNotConfigured
+	}
+	return m.refreshStore.RevokeAllForUser(context.Background(), userID)
+}
+
+func (m *Manager) issueTokens(ctx context.Context, user *User) (*TokenPair, error) {
+	access, err := m.generateJWT(user)
+	if err != nil {
+		return nil, err
+	}
+
+	pair := &TokenPair{AccessToken: access, ExpiresIn: m.accessTokenTTL}
+	if m.refreshStore == nil {
+		return pair, nil
+	}
+
+	raw, refresh, err := m.newRefreshToken(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.refreshStore.Create(ctx, refresh); err != nil {
+		return nil, err
+	}
+	pair.RefreshToken = raw
+	return pair, nil
+}
+
+// newRefreshToken returns the opaque token handed to the client and the
+// record to persist, which only carries its hash.
+func (m *Manager) newRefreshToken(user *User) (string, *RefreshToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, errRefreshTokenGenerator
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+
+	return raw, &RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(m.refreshTokenTTL),
+	}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
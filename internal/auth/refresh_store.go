@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRefreshTokenRevoked is returned by Rotate when the token was already
+// rotated or revoked by a concurrent request.
+var ErrRefreshTokenRevoked = errors.New("refresh token revoked")
+
+type RefreshToken struct {
+	ID         string
+	UserID     string
+	TokenHash  string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy string
+	CreatedAt  time.Time
+}
+
+type RefreshTokenStore interface {
+	Create(ctx context.Context, t *RefreshToken) error
+	FindByHash(ctx context.Context, hash string) (*RefreshToken, error)
+	// Rotate revokes oldID and stores next in a single transaction.
+	Rotate(ctx context.Context, oldID string, next *RefreshToken) error
+	Revoke(ctx context.Context, id string) error
+	RevokeAllForUser(ctx context.Context, userID string) error
+}
+
+type PostgresRefreshTokenStore struct {
+	db *sql.DB
+}
+
+func NewPostgresRefreshTokenStore(db *sql.DB) *PostgresRefreshTokenStore {
+	return &PostgresRefreshTokenStore{db: db}
+}
+
+func (s *PostgresRefreshTokenStore) Create(ctx context.Context, t *RefreshToken) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		t.ID, t.UserID, t.TokenHash, t.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create refresh token failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresRefreshTokenStore) FindByHash(ctx context.Context, hash string) (*RefreshToken, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, token_hash, expires_at, revoked_at, COALESCE(replaced_by, ''), created_at
+		FROM refresh_tokens WHERE token_hash = $1`, hash)
+
+	var (
+		t         RefreshToken
+		revokedAt sql.NullTime
+	)
+	if err := row.Scan(&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &revokedAt, &t.ReplacedBy, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+	return &t, nil
+}
+
+func (s *PostgresRefreshTokenStore) Rotate(ctx context.Context, oldID string, next *RefreshToken) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("rotate refresh token failed: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		next.ID, next.UserID, next.TokenHash, next.ExpiresAt,
+	); err != nil {
+		return fmt.Errorf("rotate refresh token failed: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW(), replaced_by = $2
+		WHERE id = $1 AND revoked_at IS NULL`, oldID, next.ID)
+	if err != nil {
+		return fmt.Errorf("rotate refresh token failed: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRefreshTokenRevoked
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("rotate refresh token failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresRefreshTokenStore) Revoke(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("revoke refresh token failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresRefreshTokenStore) RevokeAllForUser(ctx context.Context, userID string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("revoke refresh tokens failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is loaded from the YAML file at SAML_CONFIG.
+type Config struct {
+	// EntityID identifies this service provider, usually the metadata URL.
+	EntityID string `yaml:"entityId"`
+	// ACSURL is the assertion consumer service (/api/v1/auth/saml/acs).
+	ACSURL string `yaml:"acsUrl"`
+
+	IdP IdPConfig `yaml:"idp"`
+
+	// EmailAttribute names the attribute holding the email. Empty uses NameID.
+	EmailAttribute string `yaml:"emailAttribute"`
+	// RoleAttribute names the attribute matched against RoleMapping.
+	RoleAttribute string        `yaml:"roleAttribute"`
+	RoleMapping   []RoleMapping `yaml:"roleMapping"`
+	DefaultRole   string        `yaml:"defaultRole"`
+
+	// PostLoginRedirect is handled like configuration.OIDCConfig's.
+	PostLoginRedirect string        `yaml:"postLoginRedirect"`
+	ClockSkew         time.Duration `yaml:"clockSkew"`
+	// AllowIdPInitiated accepts unsolicited responses (no InResponseTo),
+	// e.g. from an IdP portal. Responses to our own requests must still
+	// answer them.
+	AllowIdPInitiated bool `yaml:"allowIdpInitiated"`
+
+	idpCert *x509.Certificate
+}
+
+type IdPConfig struct {
+	EntityID    string `yaml:"entityId"`
+	SSOURL      string `yaml:"ssoUrl"`
+	Certificate string `yaml:"certificate"`
+}
+
+// RoleMapping maps an attribute value to a role. The first matching entry
+// wins, so list more privileged values first.
+type RoleMapping struct {
+	Value string `yaml:"value"`
+	Role  string `yaml:"role"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read saml config failed: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse saml config failed: %w", err)
+	}
+	if cfg.EntityID == "" || cfg.ACSURL == "" {
+		return nil, errors.New("saml entityId and acsUrl are required")
+	}
+	if cfg.IdP.EntityID == "" || cfg.IdP.SSOURL == "" || cfg.IdP.Certificate == "" {
+		return nil, errors.New("saml idp entityId, ssoUrl and certificate are required")
+	}
+	if cfg.DefaultRole == "" {
+		cfg.DefaultRole = "user"
+	}
+	if cfg.ClockSkew <= 0 {
+		cfg.ClockSkew = 2 * time.Minute
+	}
+
+	cert, err := parseCertificate(cfg.IdP.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	cfg.idpCert = cert
+	return &cfg, nil
+}
+
+// MapRole returns the role for the given attribute values.
+func (c *Config) MapRole(values []string) string {
+	for _, m := range c.RoleMapping {
+		for _, v := range values {
+			if strings.EqualFold(v, m.Value) {
+				return m.Role
+			}
+		}
+	}
+	return c.DefaultRole
+}
+
+// parseCertificate accepts PEM or the bare base64 body found in IdP metadata.
+func parseCertificate(s string) (*x509.Certificate, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "-----BEGIN") {
+		s = "-----BEGIN CERTIFICATE-----\n" + s + "\n-----END CERTIFICATE-----"
+	}
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("invalid saml idp certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse saml idp certificate failed: %w", err)
+	}
+	return cert, nil
+}
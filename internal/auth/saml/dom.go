@@ -0,0 +1,88 @@
+package saml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+func parseDocument(data []byte) (*etree.Element, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, fmt.Errorf("xml parse failed: %w", err)
+	}
+	for _, tok := range doc.Child {
+		// DTDs enable entity expansion attacks; SAML messages never need them.
+		if _, ok := tok.(*etree.Directive); ok {
+			return nil, errors.New("xml with DTD is not allowed")
+		}
+	}
+	switch roots := doc.ChildElements(); len(roots) {
+	case 0:
+		return nil, errors.New("empty xml document")
+	case 1:
+		return roots[0], nil
+	default:
+		return nil, errors.New("multiple xml root elements")
+	}
+}
+
+func is(el *etree.Element, space, local string) bool {
+	return el.Tag == local && el.NamespaceURI() == space
+}
+
+// attr returns the unqualified attribute local of el.
+func attr(el *etree.Element, local string) string {
+	for _, a := range el.Attr {
+		if a.Space == "" && a.Key == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func children(el *etree.Element, space, local string) []*etree.Element {
+	var out []*etree.Element
+	for _, c := range el.ChildElements() {
+		if is(c, space, local) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func child(el *etree.Element, space, local string) *etree.Element {
+	if c := children(el, space, local); len(c) > 0 {
+		return c[0]
+	}
+	return nil
+}
+
+// text concatenates all character data below el. Reading only the first
+// text node would let a comment truncate a signed value.
+func text(el *etree.Element) string {
+	var b strings.Builder
+	var walk func(*etree.Element)
+	walk = func(e *etree.Element) {
+		for _, tok := range e.Child {
+			switch v := tok.(type) {
+			case *etree.CharData:
+				b.WriteString(v.Data)
+			case *etree.Element:
+				walk(v)
+			}
+		}
+	}
+	walk(el)
+	return strings.TrimSpace(b.String())
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(s string) string { return textEscaper.Replace(s) }
+func escapeAttr(s string) string { return attrEscaper.Replace(s) }
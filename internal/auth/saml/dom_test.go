@@ -0,0 +1,17 @@
+package saml
+
+import "testing"
+
+func TestParseDocumentRejects(t *testing.T) {
+	for name, doc := range map[string]string{
+		"dtd":           `<!DOCTYPE r [<!ENTITY x "boom">]><r>&x;</r>`,
+		"multiple root": `<a/><b/>`,
+		"mismatched":    `<a><b></a></b>`,
+		"unclosed":      `<a><b></b>`,
+		"empty":         ``,
+	} {
+		if _, err := parseDocument([]byte(doc)); err == nil {
+			t.Errorf("%s: parsed without error", name)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package saml
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ReplayCache remembers consumed assertion IDs so a captured SAMLResponse
+// cannot be posted to the ACS again while it is still valid.
+type ReplayCache interface {
+	// Consume records id until expires and reports whether it was unused.
+	Consume(ctx context.Context, id string, expires time.Time) (bool, error)
+}
+
+// MemoryReplayCache only covers the current process.
+type MemoryReplayCache struct {
+	mu  sync.Mutex
+	ids map[string]time.Time
+}
+
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{ids: map[string]time.Time{}}
+}
+
+func (c *MemoryReplayCache) Consume(_ context.Context, id string, expires time.Time) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, exp := range c.ids {
+		if !now.Before(exp) {
+			delete(c.ids, k)
+		}
+	}
+	if _, used := c.ids[id]; used {
+		return false, nil
+	}
+	c.ids[id] = expires
+	return true, nil
+}
+
+// PostgresReplayCache shares consumed IDs between server instances.
+type PostgresReplayCache struct {
+	db *sql.DB
+}
+
+func NewPostgresReplayCache(db *sql.DB) *PostgresReplayCache {
+	return &PostgresReplayCache{db: db}
+}
+
+func (c *PostgresReplayCache) Consume(ctx context.Context, id string, expires time.Time) (bool, error) {
+	if _, err := c.db.ExecContext(ctx, `DELETE FROM saml_consumed_assertions WHERE expires_at < NOW()`); err != nil {
+		return false, fmt.Errorf("prune saml assertion ids failed: %w", err)
+	}
+	res, err := c.db.ExecContext(ctx, `
+		INSERT INTO saml_consumed_assertions (id, expires_at) VALUES ($1, $2)
+		ON CONFLICT (id) DO NOTHING`, id, expires)
+	if err != nil {
+		return false, fmt.Errorf("record saml assertion id failed: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("record saml assertion id failed: %w", err)
+	}
+	return n == 1, nil
+}
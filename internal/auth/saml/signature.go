@@ -0,0 +1,67 @@
+package saml
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+// verifySignature checks the enveloped XML-DSig signature of el against cert
+// and returns the signed content. Callers must read only from the returned
+// element; anything else in the document is unauthenticated.
+func verifySignature(el *etree.Element, cert *x509.Certificate, now time.Time) (*etree.Element, error) {
+	// el may use prefixes declared on its ancestors, which the validator
+	// cannot see once it copies el out of the document.
+	nsCtx, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		return nil, err
+	}
+	detached, err := etreeutils.NSDetatch(nsCtx, el)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{
+		Roots: []*x509.Certificate{cert},
+	})
+	ctx.Clock = dsig.NewFakeClockAt(now)
+	return ctx.Validate(detached)
+}
+
+// verify requires a valid signature on the response or on its single
+// assertion. A signature that is present must always verify. The returned
+// response is the signed one when the response is signed, and the returned
+// assertion always comes from signed content.
+func (sp *ServiceProvider) verify(response *etree.Element, now time.Time) (*etree.Element, *etree.Element, error) {
+	responseSigned := false
+	signed, err := verifySignature(response, sp.cfg.idpCert, now)
+	switch {
+	case err == nil:
+		response, responseSigned = signed, true
+	case !errors.Is(err, dsig.ErrMissingSignature):
+		return nil, nil, fmt.Errorf("saml response signature invalid: %w", err)
+	}
+
+	if len(children(response, nsAssertion, "EncryptedAssertion")) > 0 {
+		return nil, nil, errors.New("encrypted assertions are not supported")
+	}
+	assertions := children(response, nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, nil, errors.New("saml response must contain exactly one assertion")
+	}
+	assertion, err := verifySignature(assertions[0], sp.cfg.idpCert, now)
+	switch {
+	case err == nil:
+		return response, assertion, nil
+	case !errors.Is(err, dsig.ErrMissingSignature):
+		return nil, nil, fmt.Errorf("saml assertion signature invalid: %w", err)
+	case !responseSigned:
+		return nil, nil, errors.New("saml response is not signed")
+	}
+	return response, assertions[0], nil
+}
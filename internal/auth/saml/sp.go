@@ -0,0 +1,371 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+const (
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+
+	statusSuccess   = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	bindingPOST     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	nameIDEmail     = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	confirmBearer   = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	relayStateValid = 10 * time.Minute
+)
+
+// ServiceProvider implements SP-initiated SSO with the HTTP-Redirect binding
+// for requests and HTTP-POST for responses. The outstanding request ID travels
+// in an HMAC-protected RelayState so no server-side session is needed.
+type ServiceProvider struct {
+	cfg    *Config
+	secret []byte
+	replay ReplayCache
+}
+
+func NewServiceProvider(cfg *Config, secret []byte) *ServiceProvider {
+	return &ServiceProvider{cfg: cfg, secret: secret, replay: NewMemoryReplayCache()}
+}
+
+// SetReplayCache replaces the default in-memory cache, which does not see
+// assertions consumed by other instances.
+func (sp *ServiceProvider) SetReplayCache(cache ReplayCache) {
+	sp.replay = cache
+}
+
+func (sp *ServiceProvider) Config() *Config {
+	return sp.cfg
+}
+
+// Assertion is the validated result of a SAML response.
+type Assertion struct {
+	NameID     string
+	Email      string
+	Role       string
+	Attributes map[string][]string
+}
+
+// Metadata returns the SP metadata document to register with the IdP.
+func (sp *ServiceProvider) Metadata() []byte {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="` + escapeAttr(sp.cfg.EntityID) + `">`)
+	b.WriteString(`<md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="` + nsProtocol + `">`)
+	b.WriteString(`<md:NameIDFormat>` + nameIDEmail + `</md:NameIDFormat>`)
+	b.WriteString(`<md:AssertionConsumerService Binding="` + bindingPOST + `" Location="` + escapeAttr(sp.cfg.ACSURL) + `" index="0" isDefault="true"/>`)
+	b.WriteString(`</md:SPSSODescriptor></md:EntityDescriptor>`)
+	return []byte(b.String())
+}
+
+// AuthnRequestURL returns the IdP URL carrying a new AuthnRequest and the
+// RelayState it sends. The caller should bind the RelayState to the browser
+// so a response started by someone else is not accepted (login CSRF).
+func (sp *ServiceProvider) AuthnRequestURL(now time.Time) (string, string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	id := "_" + hex.EncodeToString(buf)
+
+	request := `<samlp:AuthnRequest xmlns:samlp="` + nsProtocol + `" xmlns:saml="` + nsAssertion + `"` +
+		` ID="` + id + `" Version="2.0" IssueInstant="` + now.UTC().Format(time.RFC3339) + `"` +
+		` Destination="` + escapeAttr(sp.cfg.IdP.SSOURL) + `"` +
+		` AssertionConsumerServiceURL="` + escapeAttr(sp.cfg.ACSURL) + `" ProtocolBinding="` + bindingPOST + `">` +
+		`<saml:Issuer>` + escapeText(sp.cfg.EntityID) + `</saml:Issuer>` +
+		`<samlp:NameIDPolicy Format="` + nameIDEmail + `" AllowCreate="true"/>` +
+		`</samlp:AuthnRequest>`
+
+	var deflated bytes.Buffer
+	w, err := flate.NewWriter(&deflated, flate.BestCompression)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := w.Write([]byte(request)); err != nil {
+		return "", "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+
+	q := url.Values{}
+	q.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	relayState := sp.relayState(id, now.Add(relayStateValid))
+	q.Set("RelayState", relayState)
+
+	sep := "?"
+	if strings.Contains(sp.cfg.IdP.SSOURL, "?") {
+		sep = "&"
+	}
+	return sp.cfg.IdP.SSOURL + sep + q.Encode(), relayState, nil
+}
+
+// ParseResponse validates a base64 SAMLResponse posted to the ACS. The
+// response must answer the request in relayState unless IdP-initiated login
+// is allowed, in which case a response without InResponseTo is accepted
+// too. Each assertion is accepted once.
+func (sp *ServiceProvider) ParseResponse(ctx context.Context, encoded, relayState string, now time.Time) (*Assertion, error) {
+	requestID, err := sp.parseRelayState(relayState, now)
+	if err != nil && !sp.cfg.AllowIdPInitiated {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+	if err != nil {
+		return nil, errors.New("invalid SAMLResponse encoding")
+	}
+	root, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if !is(root, nsProtocol, "Response") {
+		return nil, errors.New("not a saml response")
+	}
+	if err := uniqueIDs(root); err != nil {
+		return nil, err
+	}
+	response, assertion, err := sp.verify(root, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if dest := attr(response, "Destination"); dest != "" && dest != sp.cfg.ACSURL {
+		return nil, errors.New("saml response destination mismatch")
+	}
+	if attr(response, "InResponseTo") != requestID {
+		return nil, errors.New("saml response InResponseTo mismatch")
+	}
+
+	status := child(response, nsProtocol, "Status")
+	if status == nil {
+		return nil, errors.New("saml response has no status")
+	}
+	if code := child(status, nsProtocol, "StatusCode"); code == nil || attr(code, "Value") != statusSuccess {
+		return nil, errors.New("saml authentication failed at idp")
+	}
+
+	assertionID := attr(assertion, "ID")
+	if assertionID == "" {
+		return nil, errors.New("saml assertion has no ID")
+	}
+
+	if issuer := child(assertion, nsAssertion, "Issuer"); issuer == nil || text(issuer) != sp.cfg.IdP.EntityID {
+		return nil, errors.New("saml assertion issuer mismatch")
+	}
+	if err := sp.checkConditions(assertion, now); err != nil {
+		return nil, err
+	}
+	nameID, expires, err := sp.checkSubject(assertion, requestID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := attributes(assertion)
+	result := &Assertion{NameID: nameID, Attributes: attrs, Role: sp.cfg.DefaultRole}
+
+	result.Email = nameID
+	if sp.cfg.EmailAttribute != "" {
+		result.Email = ""
+		if v := attrs[sp.cfg.EmailAttribute]; len(v) > 0 {
+			result.Email = v[0]
+		}
+	}
+	result.Email = strings.ToLower(strings.TrimSpace(result.Email))
+	if result.Email == "" || !strings.Contains(result.Email, "@") {
+		return nil, errors.New("saml assertion has no email")
+	}
+
+	if sp.cfg.RoleAttribute != "" {
+		result.Role = sp.cfg.MapRole(attrs[sp.cfg.RoleAttribute])
+	}
+
+	fresh, err := sp.replay.Consume(ctx, assertionID, expires.Add(sp.cfg.ClockSkew))
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return nil, errors.New("saml assertion has already been used")
+	}
+	return result, nil
+}
+
+func (sp *ServiceProvider) checkConditions(assertion *etree.Element, now time.Time) error {
+	cond := child(assertion, nsAssertion, "Conditions")
+	if cond == nil {
+		return errors.New("saml assertion has no conditions")
+	}
+	if err := checkWindow(attr(cond, "NotBefore"), attr(cond, "NotOnOrAfter"), now, sp.cfg.ClockSkew); err != nil {
+		return err
+	}
+
+	restrictions := children(cond, nsAssertion, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return errors.New("saml assertion has no audience restriction")
+	}
+	for _, r := range restrictions {
+		matched := false
+		for _, a := range children(r, nsAssertion, "Audience") {
+			if text(a) == sp.cfg.EntityID {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return errors.New("saml assertion audience mismatch")
+		}
+	}
+	return nil
+}
+
+// checkSubject returns the NameID and the end of the bearer confirmation
+// window.
+func (sp *ServiceProvider) checkSubject(assertion *etree.Element, requestID string, now time.Time) (string, time.Time, error) {
+	subject := child(assertion, nsAssertion, "Subject")
+	if subject == nil {
+		return "", time.Time{}, errors.New("saml assertion has no subject")
+	}
+	nameID := child(subject, nsAssertion, "NameID")
+	if nameID == nil {
+		return "", time.Time{}, errors.New("saml assertion has no NameID")
+	}
+
+	for _, sc := range children(subject, nsAssertion, "SubjectConfirmation") {
+		if attr(sc, "Method") != confirmBearer {
+			continue
+		}
+		data := child(sc, nsAssertion, "SubjectConfirmationData")
+		if data == nil {
+			continue
+		}
+		if attr(data, "Recipient") != sp.cfg.ACSURL {
+			continue
+		}
+		if attr(data, "InResponseTo") != requestID {
+			continue
+		}
+		notOnOrAfter, err := time.Parse(time.RFC3339, attr(data, "NotOnOrAfter"))
+		if err != nil {
+			continue
+		}
+		if err := checkWindow(attr(data, "NotBefore"), attr(data, "NotOnOrAfter"), now, sp.cfg.ClockSkew); err != nil {
+			continue
+		}
+		return text(nameID), notOnOrAfter, nil
+	}
+	return "", time.Time{}, errors.New("saml assertion has no valid bearer confirmation")
+}
+
+func checkWindow(notBefore, notOnOrAfter string, now time.Time, skew time.Duration) error {
+	if notBefore != "" {
+		t, err := time.Parse(time.RFC3339, notBefore)
+		if err != nil {
+			return errors.New("invalid saml NotBefore")
+		}
+		if now.Add(skew).Before(t) {
+			return errors.New("saml assertion is not yet valid")
+		}
+	}
+	if notOnOrAfter != "" {
+		t, err := time.Parse(time.RFC3339, notOnOrAfter)
+		if err != nil {
+			return errors.New("invalid saml NotOnOrAfter")
+		}
+		if !now.Add(-skew).Before(t) {
+			return errors.New("saml assertion has expired")
+		}
+	}
+	return nil
+}
+
+func attributes(assertion *etree.Element) map[string][]string {
+	out := map[string][]string{}
+	for _, stmt := range children(assertion, nsAssertion, "AttributeStatement") {
+		for _, a := range children(stmt, nsAssertion, "Attribute") {
+			var values []string
+			for _, v := range children(a, nsAssertion, "AttributeValue") {
+				values = append(values, text(v))
+			}
+			if name := attr(a, "Name"); name != "" {
+				out[name] = append(out[name], values...)
+			}
+			if friendly := attr(a, "FriendlyName"); friendly != "" {
+				out[friendly] = append(out[friendly], values...)
+			}
+		}
+	}
+	return out
+}
+
+// uniqueIDs rejects documents with duplicate ID attributes, which signature
+// wrapping attacks rely on.
+func uniqueIDs(root *etree.Element) error {
+	seen := map[string]bool{}
+	var walk func(*etree.Element) error
+	walk = func(el *etree.Element) error {
+		if id := attr(el, "ID"); id != "" {
+			if seen[id] {
+				return errors.New("saml response contains duplicate IDs")
+			}
+			seen[id] = true
+		}
+		for _, c := range el.ChildElements() {
+			if err := walk(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(root)
+}
+
+func (sp *ServiceProvider) relayState(requestID string, expires time.Time) string {
+	payload := requestID + "." + strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, sp.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (sp *ServiceProvider) parseRelayState(state string, now time.Time) (string, error) {
+	encPayload, encMAC, ok := strings.Cut(state, ".")
+	if !ok {
+		return "", errors.New("invalid saml relay state")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return "", errors.New("invalid saml relay state")
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(encMAC)
+	if err != nil {
+		return "", errors.New("invalid saml relay state")
+	}
+
+	mac := hmac.New(sha256.New, sp.secret)
+	mac.Write(payload)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return "", errors.New("invalid saml relay state")
+	}
+
+	requestID, exp, ok := strings.Cut(string(payload), ".")
+	if !ok {
+		return "", errors.New("invalid saml relay state")
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.Unix() > expires {
+		return "", errors.New("saml login request expired")
+	}
+	return requestID, nil
+}
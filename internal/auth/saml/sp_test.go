@@ -0,0 +1,343 @@
+package saml
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const (
+	testSP  = "https://sp.example.com/metadata"
+	testACS = "https://sp.example.com/acs"
+	testIdP = "https://idp.example.com"
+)
+
+type testIdPKey struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestIdPKey(t *testing.T) testIdPKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testIdPKey{key: key, cert: cert}
+}
+
+func newTestSP(cert *x509.Certificate) *ServiceProvider {
+	return NewServiceProvider(&Config{
+		EntityID:    testSP,
+		ACSURL:      testACS,
+		IdP:         IdPConfig{EntityID: testIdP},
+		DefaultRole: "user",
+		ClockSkew:   time.Minute,
+		idpCert:     cert,
+	}, []byte("secret"))
+}
+
+type responseSpec struct {
+	requestID    string // InResponseTo; empty omits it
+	assertionID  string
+	nameID       string
+	signResponse bool
+	now          time.Time
+}
+
+func assertionXML(s responseSpec) string {
+	irt := ""
+	if s.requestID != "" {
+		irt = ` InResponseTo="` + s.requestID + `"`
+	}
+	return `<saml:Assertion ID="` + s.assertionID + `" Version="2.0" IssueInstant="` + s.now.Format(time.RFC3339) + `">` +
+		`<saml:Issuer>` + testIdP + `</saml:Issuer>` +
+		`<saml:Subject><saml:NameID Format="` + nameIDEmail + `">` + s.nameID + `</saml:NameID>` +
+		`<saml:SubjectConfirmation Method="` + confirmBearer + `"><saml:SubjectConfirmationData Recipient="` + testACS + `"` +
+		` NotOnOrAfter="` + s.now.Add(5*time.Minute).Format(time.RFC3339) + `"` + irt + `/></saml:SubjectConfirmation></saml:Subject>` +
+		`<saml:Conditions NotBefore="` + s.now.Add(-time.Minute).Format(time.RFC3339) + `" NotOnOrAfter="` + s.now.Add(5*time.Minute).Format(time.RFC3339) + `">` +
+		`<saml:AudienceRestriction><saml:Audience>` + testSP + `</saml:Audience></saml:AudienceRestriction></saml:Conditions>` +
+		`</saml:Assertion>`
+}
+
+func responseXML(s responseSpec) string {
+	irt := ""
+	if s.requestID != "" {
+		irt = ` InResponseTo="` + s.requestID + `"`
+	}
+	return `<samlp:Response xmlns:samlp="` + nsProtocol + `" xmlns:saml="` + nsAssertion + `" ID="_response" Version="2.0"` +
+		` IssueInstant="` + s.now.Format(time.RFC3339) + `" Destination="` + testACS + `"` + irt + `>` +
+		`<saml:Issuer>` + testIdP + `</saml:Issuer>` +
+		`<samlp:Status><samlp:StatusCode Value="` + statusSuccess + `"/></samlp:Status>` +
+		assertionXML(s) +
+		`</samlp:Response>`
+}
+
+// sign adds an enveloped signature by idp to the element with the given ID,
+// after its Issuer as the SAML schema places it.
+func sign(t *testing.T, doc, id string, idp testIdPKey) string {
+	t.Helper()
+	root, err := parseDocument([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	el := root.FindElement("//[@ID='" + id + "']")
+	if el == nil {
+		t.Fatalf("element %s not found", id)
+	}
+
+	nsCtx, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		t.Fatal(err)
+	}
+	detached, err := etreeutils.NSDetatch(nsCtx, el)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := dsig.NewSigningContext(idp.key, [][]byte{idp.cert.Raw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+	sig, err := ctx.ConstructSignature(detached, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	el.InsertChildAt(child(el, nsAssertion, "Issuer").Index()+1, sig)
+
+	out := etree.NewDocument()
+	out.SetRoot(root)
+	signed, err := out.WriteToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// signedResponse signs the response or, by default, its assertion.
+func signedResponse(t *testing.T, s responseSpec, idp testIdPKey) string {
+	t.Helper()
+	id := s.assertionID
+	if s.signResponse {
+		id = "_response"
+	}
+	return sign(t, responseXML(s), id, idp)
+}
+
+func encode(doc string) string {
+	return base64.StdEncoding.EncodeToString([]byte(doc))
+}
+
+// assertionSpan returns the start and end offsets of the first assertion.
+func assertionSpan(t *testing.T, doc string) (int, int) {
+	t.Helper()
+	start := strings.Index(doc, "<saml:Assertion ")
+	end := strings.Index(doc, "</saml:Assertion>")
+	if start < 0 || end < 0 {
+		t.Fatal("no assertion")
+	}
+	return start, end + len("</saml:Assertion>")
+}
+
+func TestParseResponse(t *testing.T) {
+	idp := newTestIdPKey(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("signed assertion", func(t *testing.T) {
+		sp := newTestSP(idp.cert)
+		spec := responseSpec{requestID: "_req1", assertionID: "_a1", nameID: "User@Example.com", now: now}
+		doc := signedResponse(t, spec, idp)
+
+		got, err := sp.ParseResponse(context.Background(), encode(doc), sp.relayState("_req1", now.Add(time.Minute)), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Email != "user@example.com" || got.NameID != "User@Example.com" || got.Role != "user" {
+			t.Fatalf("unexpected assertion %+v", got)
+		}
+	})
+
+	t.Run("signed response", func(t *testing.T) {
+		sp := newTestSP(idp.cert)
+		spec := responseSpec{requestID: "_req2", assertionID: "_a2", nameID: "user@example.com", signResponse: true, now: now}
+		doc := signedResponse(t, spec, idp)
+
+		if _, err := sp.ParseResponse(context.Background(), encode(doc), sp.relayState("_req2", now.Add(time.Minute)), now); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("replay rejected", func(t *testing.T) {
+		sp := newTestSP(idp.cert)
+		spec := responseSpec{requestID: "_req3", assertionID: "_a3", nameID: "user@example.com", now: now}
+		doc := encode(signedResponse(t, spec, idp))
+		relay := sp.relayState("_req3", now.Add(time.Minute))
+
+		if _, err := sp.ParseResponse(context.Background(), doc, relay, now); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sp.ParseResponse(context.Background(), doc, relay, now); err == nil || !strings.Contains(err.Error(), "already been used") {
+			t.Fatalf("replayed assertion accepted: %v", err)
+		}
+	})
+}
+
+func TestParseResponseInResponseTo(t *testing.T) {
+	idp := newTestIdPKey(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name         string
+		requestID    string // in the response
+		relayFor     string // request the relay state was issued for; empty sends none
+		idpInitiated bool
+		wantErr      bool
+	}{
+		{name: "matching request", requestID: "_req", relayFor: "_req"},
+		{name: "other request", requestID: "_other", relayFor: "_req", wantErr: true},
+		{name: "missing InResponseTo", relayFor: "_req", wantErr: true},
+		{name: "unsolicited by default", wantErr: true},
+		{name: "unsolicited when allowed", idpInitiated: true},
+		{name: "unknown request when allowed", requestID: "_forged", idpInitiated: true, wantErr: true},
+		{name: "missing InResponseTo to own request when allowed", relayFor: "_req", idpInitiated: true, wantErr: true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := newTestSP(idp.cert)
+			sp.cfg.AllowIdPInitiated = tt.idpInitiated
+			spec := responseSpec{requestID: tt.requestID, assertionID: "_irt" + string(rune('a'+i)), nameID: "user@example.com", now: now}
+			doc := signedResponse(t, spec, idp)
+			relay := ""
+			if tt.relayFor != "" {
+				relay = sp.relayState(tt.relayFor, now.Add(time.Minute))
+			}
+
+			_, err := sp.ParseResponse(context.Background(), encode(doc), relay, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseResponseSignatureAttacks(t *testing.T) {
+	idp := newTestIdPKey(t)
+	other := newTestIdPKey(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	relay := func(sp *ServiceProvider) string { return sp.relayState("_req", now.Add(time.Minute)) }
+
+	spec := responseSpec{requestID: "_req", assertionID: "_good", nameID: "user@example.com", now: now}
+	good := signedResponse(t, spec, idp)
+	start, end := assertionSpan(t, good)
+	signedAssertion := good[start:end]
+	sigStart := strings.Index(signedAssertion, "<ds:Signature")
+	sigEnd := strings.Index(signedAssertion, "</ds:Signature>") + len("</ds:Signature>")
+	signature := signedAssertion[sigStart:sigEnd]
+
+	evil := spec
+	evil.assertionID = "_evil"
+	evil.nameID = "root@example.com"
+	evilAssertion := assertionXML(evil)
+	withSignature := func(assertion, sig string) string {
+		i := strings.Index(assertion, "</saml:Issuer>") + len("</saml:Issuer>")
+		return assertion[:i] + sig + assertion[i:]
+	}
+
+	tests := map[string]string{
+		"unsigned": strings.Replace(good, signature, "", 1),
+		"signed by another key": signedResponse(t, responseSpec{
+			requestID: "_req", assertionID: "_other", nameID: "user@example.com", now: now,
+		}, other),
+		"tampered NameID":    strings.Replace(good, "user@example.com", "root@example.com", 1),
+		"tampered attribute": strings.Replace(good, `Recipient="`+testACS+`"`, `Recipient="`+testACS+`" Extra="1"`, 1),
+		// The signed assertion is hidden in Extensions and an unsigned one
+		// takes its place.
+		"wrapped in extensions": good[:start] + `<samlp:Extensions>` + signedAssertion + `</samlp:Extensions>` + evilAssertion + good[end:],
+		// The original signature is copied onto a forged assertion.
+		"copied signature": good[:start] + withSignature(evilAssertion, signature) + good[end:],
+		// The forged assertion reuses the signed ID and carries the original
+		// inside its signature so a resolver could find it by ID.
+		"same ID with original in signature object": good[:start] +
+			withSignature(strings.Replace(evilAssertion, `ID="_evil"`, `ID="_good"`, 1),
+				strings.Replace(signature, "</ds:Signature>", "<ds:Object>"+signedAssertion+"</ds:Object></ds:Signature>", 1)) +
+			good[end:],
+		"second assertion":         good[:end] + evilAssertion + good[end:],
+		"digest algorithm swapped": strings.Replace(good, "http://www.w3.org/2001/04/xmlenc#sha256", "http://www.w3.org/2001/04/xmldsig-more#md5", 1),
+		"inclusive c14n": strings.Replace(good, `<ds:CanonicalizationMethod Algorithm="`+string(dsig.CanonicalXML10ExclusiveAlgorithmId)+`"/>`,
+			`<ds:CanonicalizationMethod Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"/>`, 1),
+		"xslt transform": strings.Replace(good, `<ds:Transform Algorithm="`+dsig.EnvelopedSignatureAltorithmId.String()+`"/>`,
+			`<ds:Transform Algorithm="http://www.w3.org/TR/1999/REC-xslt-19991116"/>`, 1),
+	}
+	for name, doc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sp := newTestSP(idp.cert)
+			got, err := sp.ParseResponse(context.Background(), encode(doc), relay(sp), now)
+			if err == nil {
+				t.Fatalf("accepted as %+v", got)
+			}
+		})
+	}
+}
+
+// TestParseResponseCommentInjection covers NameIDs split by a comment, which
+// is outside the signed canonical form. The whole value must be used, not
+// the text before the comment.
+func TestParseResponseCommentInjection(t *testing.T) {
+	idp := newTestIdPKey(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	sp := newTestSP(idp.cert)
+
+	spec := responseSpec{requestID: "_req", assertionID: "_comment", nameID: "admin@example.com.evil.test", now: now}
+	doc := strings.Replace(signedResponse(t, spec, idp), "admin@example.com.evil.test", "admin@example.com<!---->.evil.test", 1)
+
+	got, err := sp.ParseResponse(context.Background(), encode(doc), sp.relayState("_req", now.Add(time.Minute)), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Email != "admin@example.com.evil.test" || got.NameID != "admin@example.com.evil.test" {
+		t.Fatalf("comment truncated the NameID: %+v", got)
+	}
+}
+
+func TestMemoryReplayCache(t *testing.T) {
+	cache := NewMemoryReplayCache()
+	ctx := context.Background()
+
+	if ok, _ := cache.Consume(ctx, "a", time.Now().Add(time.Minute)); !ok {
+		t.Fatal("first use rejected")
+	}
+	if ok, _ := cache.Consume(ctx, "a", time.Now().Add(time.Minute)); ok {
+		t.Fatal("second use accepted")
+	}
+	if ok, _ := cache.Consume(ctx, "b", time.Now().Add(-time.Second)); !ok {
+		t.Fatal("first use rejected")
+	}
+	if ok, _ := cache.Consume(ctx, "b", time.Now().Add(time.Minute)); !ok {
+		t.Fatal("expired id still recorded")
+	}
+}
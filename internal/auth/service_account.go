@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RoleService is the role of service accounts. It grants nothing by itself;
+// service accounts are authorized by their scopes only.
+const RoleService = "service"
+
+const (
+	serviceAccountSecretPrefix = "ysa_"
+	serviceAccountEmailDomain  = "service-accounts.yuon"
+)
+
+var (
+	ErrServiceAccountNotFound   = errors.New("service account not found")
+	ErrInvalidClientCredentials = errors.New("invalid client credentials")
+)
+
+// CreateServiceAccount creates a non-interactive account for automation. The
+// returned client secret is only available here; its ID is the client ID.
+func (m *Manager) CreateServiceAccount(name string, scopes []string, workspace string) (*User, string, error) {
+	if m.store == nil {
+		return nil, "", errors.New("user store is not configured")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("service account name is required")
+	}
+	if err := validateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+
+	secret, hash, err := newServiceAccountSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	id := uuid.New().String()
+	user := &User{
+		ID:             id,
+		Email:          id + "@" + serviceAccountEmailDomain,
+		PasswordHash:   hash,
+		Role:           RoleService,
+		Workspace:      workspace,
+		Name:           name,
+		ServiceAccount: true,
+		Scopes:         scopes,
+		Active:         true,
+	}
+	if err := m.store.Create(context.Background(), user); err != nil {
+		return nil, "", err
+	}
+	return user, secret, nil
+}
+
+// ServiceAccounts lists service accounts; AllUsers lists the rest.
+func (m *Manager) ServiceAccounts() ([]*User, error) {
+	if m.store == nil {
+		return nil, errors.New("user store is not configured")
+	}
+	users, err := m.store.List(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []*User
+	for _, u := range users {
+		if u.ServiceAccount {
+			accounts = append(accounts, u)
+		}
+	}
+	return accounts, nil
+}
+
+// RotateServiceAccountSecret replaces the client secret. Access tokens
+// already issued stay valid until they expire.
+func (m *Manager) RotateServiceAccountSecret(id string) (string, error) {
+	if _, err := m.serviceAccount(id); err != nil {
+		return "", err
+	}
+
+	secret, hash, err := newServiceAccountSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := m.store.UpdatePassword(context.Background(), id, hash); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+func (m *Manager) DeleteServiceAccount(id string) error {
+	if _, err := m.serviceAccount(id); err != nil {
+		return err
+	}
+	return m.store.Delete(context.Background(), id)
+}
+
+// LoginServiceAccount exchanges client credentials for an access token. No
+// refresh token is issued; clients authenticate again when it expires.
+func (m *Manager) LoginServiceAccount(clientID, secret, ip string) (*TokenPair, *User, error) {
+	if m.store == nil {
+		return nil, nil, errors.New("user store is not configured")
+	}
+
+	ctx := context.Background()
+	keys := m.loginKeys(clientID, ip)
+	if err := m.checkLoginAllowed(ctx, keys); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := m.store.FindByID(ctx, clientID)
+	if err == nil && !user.ServiceAccount {
+		err = ErrInvalidClientCredentials
+	}
+	if err == nil {
+		err = bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(secret))
+	}
+	if err != nil {
+		if err := m.recordLoginFailure(ctx, keys); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidClientCredentials
+	}
+
+	if err := m.clearLoginFailures(ctx, clientID); err != nil {
+		return nil, nil, err
+	}
+
+	access, err := m.generateJWT(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &TokenPair{AccessToken: access, ExpiresIn: m.accessTokenTTL}, user, nil
+}
+
+func (m *Manager) serviceAccount(id string) (*User, error) {
+	if m.store == nil {
+		return nil, errors.New("user store is not configured")
+	}
+	user, err := m.store.FindByID(context.Background(), id)
+	if err != nil || !user.ServiceAccount {
+		return nil, ErrServiceAccountNotFound
+	}
+	return user, nil
+}
+
+func newServiceAccountSecret() (string, []byte, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("service account secret generation failed: %w", err)
+	}
+	secret := serviceAccountSecretPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", nil, err
+	}
+	return secret, hash, nil
+}
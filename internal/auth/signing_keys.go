@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// envSigningKeyID stands for JWT_SECRET. Tokens without a kid header were
+	// signed with it.
+	envSigningKeyID          = "env"
+	signingKeyReloadInterval = time.Minute
+	signingKeyMissRetry      = 10 * time.Second
+)
+
+// SigningKey is an HMAC key used to sign JWTs. The current key has no
+// ExpiresAt; rotated keys keep verifying tokens until ExpiresAt.
+type SigningKey struct {
+	ID        string
+	Secret    []byte
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+}
+
+type SigningKeyStore interface {
+	// List returns unexpired keys, newest first.
+	List(ctx context.Context) ([]*SigningKey, error)
+	// EnsureInitial inserts key only when no key exists yet.
+	EnsureInitial(ctx context.Context, key *SigningKey) error
+	// Rotate sets retireAt on the current keys and inserts next.
+	Rotate(ctx context.Context, next *SigningKey, retireAt time.Time) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type PostgresSigningKeyStore struct {
+	db *sql.DB
+}
+
+func NewPostgresSigningKeyStore(db *sql.DB) *PostgresSigningKeyStore {
+	return &PostgresSigningKeyStore{db: db}
+}
+
+func (s *PostgresSigningKeyStore) List(ctx context.Context) ([]*SigningKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT kid, secret, created_at, expires_at FROM jwt_signing_keys
+		WHERE expires_at IS NULL OR expires_at > NOW()
+		ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("list signing keys failed: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*SigningKey
+	for rows.Next() {
+		k := &SigningKey{}
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&k.ID, &k.Secret, &k.CreatedAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			k.ExpiresAt = &expiresAt.Time
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (s *PostgresSigningKeyStore) EnsureInitial(ctx context.Context, key *SigningKey) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO jwt_signing_keys (kid, secret)
+		SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM jwt_signing_keys)
+		ON CONFLICT (kid) DO NOTHING`, key.ID, key.Secret)
+	if err != nil {
+		return fmt.Errorf("create initial signing key failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresSigningKeyStore) Rotate(ctx context.Context, next *SigningKey, retireAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE jwt_signing_keys SET expires_at = $1 WHERE expires_at IS NULL`, retireAt); err != nil {
+		return fmt.Errorf("retire signing keys failed: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO jwt_signing_keys (kid, secret) VALUES ($1, $2)`, next.ID, next.Secret); err != nil {
+		return fmt.Errorf("create signing key failed: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresSigningKeyStore) DeleteExpired(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM jwt_signing_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return fmt.Errorf("delete expired signing keys failed: %w", err)
+	}
+	return nil
+}
+
+// SetSigningKeyStore enables signing key rotation. JWT_SECRET becomes the
+// first key (kid "env") when the store is empty. Keys are reloaded every
+// minute so rotations reach every instance.
+func (m *Manager) SetSigningKeyStore(store SigningKeyStore) error {
+	ctx := context.Background()
+	if err := store.EnsureInitial(ctx, &SigningKey{ID: envSigningKeyID}); err != nil {
+		return err
+	}
+	m.keyStore = store
+	return m.reloadSigningKeys(ctx)
+}
+
+// SigningKeys lists the unexpired signing keys.
+func (m *Manager) SigningKeys() ([]*SigningKey, error) {
+	if m.keyStore == nil {
+		return nil, errors.New("signing key store is not configured")
+	}
+	return m.keyStore.List(context.Background())
+}
+
+// RotateSigningKey makes a new random key current. Previous keys keep
+// verifying until every token they signed has expired.
+func (m *Manager) RotateSigningKey() (*SigningKey, error) {
+	if m.keyStore == nil {
+		return nil, errors.New("signing key store is not configured")
+	}
+
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	key := &SigningKey{ID: hex.EncodeToString(id), Secret: secret, CreatedAt: time.Now()}
+
+	// Other instances may sign with the old key until their next reload.
+	grace := max(m.accessTokenTTL, mfaTokenTTL) + signingKeyReloadInterval
+	ctx := context.Background()
+	if err := m.keyStore.Rotate(ctx, key, time.Now().Add(grace)); err != nil {
+		return nil, err
+	}
+	if err := m.keyStore.DeleteExpired(ctx); err != nil {
+		return nil, err
+	}
+	if err := m.reloadSigningKeys(ctx); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (m *Manager) reloadSigningKeys(ctx context.Context) error {
+	keys, err := m.keyStore.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]*SigningKey, len(keys))
+	current := ""
+	for _, k := range keys {
+		if k.ID == envSigningKeyID {
+			k.Secret = m.jwtSecret
+		}
+		byID[k.ID] = k
+		if current == "" && k.ExpiresAt == nil {
+			current = k.ID
+		}
+	}
+
+	m.keysMu.Lock()
+	m.keys = byID
+	m.currentKID = current
+	m.keysLoadedAt = time.Now()
+	m.keysMu.Unlock()
+	return nil
+}
+
+// signToken signs claims with the current key and sets its kid.
+func (m *Manager) signToken(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if m.keyStore == nil {
+		return token.SignedString(m.jwtSecret)
+	}
+
+	m.keysMu.RLock()
+	stale := time.Since(m.keysLoadedAt) > signingKeyReloadInterval
+	m.keysMu.RUnlock()
+	if stale {
+		// A failed reload keeps the previous keys.
+		_ = m.reloadSigningKeys(context.Background())
+	}
+
+	m.keysMu.RLock()
+	key := m.keys[m.currentKID]
+	m.keysMu.RUnlock()
+	if key == nil {
+		return "", errors.New("no active signing key")
+	}
+	if key.ID != envSigningKeyID {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.Secret)
+}
+
+// verificationKey is the jwt.Keyfunc for tokens issued by this manager.
+func (m *Manager) verificationKey(t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, errors.New("unexpected signing method")
+	}
+	if m.keyStore == nil {
+		return m.jwtSecret, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		kid = envSigningKeyID
+	}
+
+	m.keysMu.RLock()
+	key := m.keys[kid]
+	stale := time.Since(m.keysLoadedAt) > signingKeyReloadInterval
+	missRetry := key == nil && time.Since(m.keysLoadedAt) > signingKeyMissRetry
+	m.keysMu.RUnlock()
+
+	if stale || missRetry {
+		if err := m.reloadSigningKeys(context.Background()); err == nil {
+			m.keysMu.RLock()
+			key = m.keys[kid]
+			m.keysMu.RUnlock()
+		}
+	}
+
+	if key == nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now())) {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key.Secret, nil
+}
@@ -48,6 +48,122 @@ func EnsureSchemas(db *sql.DB) error {
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		);`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS workspace TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS department TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS service_account BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL DEFAULT '{}';`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;`,
+		// JWT signing keys; kid 'env' (no secret) stands for JWT_SECRET
+		`CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+			kid TEXT PRIMARY KEY,
+			secret BYTEA,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			expires_at TIMESTAMPTZ
+		);`,
+		// Refresh tokens (SHA-256 hashes only)
+		`CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			token_hash TEXT UNIQUE NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			revoked_at TIMESTAMPTZ,
+			replaced_by TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);`,
+		// Password reset tokens (single use, SHA-256 hashes only)
+		`CREATE TABLE IF NOT EXISTS password_reset_tokens (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			token_hash TEXT UNIQUE NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);`,
+		// Single-use signup invitations (SHA-256 token hashes only)
+		`CREATE TABLE IF NOT EXISTS invitations (
+			id TEXT PRIMARY KEY,
+			email TEXT NOT NULL,
+			role TEXT NOT NULL,
+			workspace TEXT NOT NULL DEFAULT '',
+			token_hash TEXT UNIQUE NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			expires_at TIMESTAMPTZ NOT NULL,
+			used_at TIMESTAMPTZ,
+			revoked_at TIMESTAMPTZ
+		);`,
+		// OIDC/SAML identities linked to local users
+		`CREATE TABLE IF NOT EXISTS federated_identities (
+			provider TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (provider, subject)
+		);`,
+		// Single-use codes handing SSO logins to the client (SHA-256 hashes only)
+		`CREATE TABLE IF NOT EXISTS sso_login_codes (
+			code_hash TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			provider TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			link_required BOOLEAN NOT NULL DEFAULT FALSE,
+			expires_at TIMESTAMPTZ NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);`,
+		// SAML assertion IDs consumed at the ACS, kept until they expire
+		`CREATE TABLE IF NOT EXISTS saml_consumed_assertions (
+			id TEXT PRIMARY KEY,
+			expires_at TIMESTAMPTZ NOT NULL
+		);`,
+		// TOTP MFA secrets and hashed recovery codes
+		`CREATE TABLE IF NOT EXISTS user_mfa (
+			user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			secret TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT FALSE,
+			last_used_step BIGINT NOT NULL DEFAULT 0,
+			enabled_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+			id BIGSERIAL PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			code_hash TEXT NOT NULL,
+			used_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);`,
+		// Failed login counters per account (email) and client IP
+		`CREATE TABLE IF NOT EXISTS login_attempts (
+			scope TEXT NOT NULL,
+			key TEXT NOT NULL,
+			failures INTEGER NOT NULL DEFAULT 0,
+			last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			locked_until TIMESTAMPTZ,
+			PRIMARY KEY (scope, key)
+		);`,
+		// API keys (SHA-256 hashes only) and daily usage
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			key_hash TEXT UNIQUE NOT NULL,
+			scopes TEXT[] NOT NULL DEFAULT '{}',
+			workspace TEXT NOT NULL DEFAULT '',
+			created_by TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			last_used_at TIMESTAMPTZ,
+			revoked_at TIMESTAMPTZ
+		);`,
+		`CREATE TABLE IF NOT EXISTS api_key_usage (
+			key_id TEXT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+			day DATE NOT NULL,
+			requests BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (key_id, day)
+		);`,
 		// Conversations
 		`CREATE TABLE IF NOT EXISTS conversations (
 			id TEXT PRIMARY KEY,
@@ -57,6 +173,15 @@ func EnsureSchemas(db *sql.DB) error {
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		);`,
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS owner_id TEXT;`,
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS owner_name TEXT;`,
+		`CREATE INDEX IF NOT EXISTS idx_conversations_owner ON conversations(owner_id, updated_at DESC);`,
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMPTZ;`,
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS summary TEXT;`,
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS action_items TEXT[];`,
+		`ALTER TABLE conversations ADD COLUMN IF NOT EXISTS summarized_at TIMESTAMPTZ;`,
 		// Conversation messages
 		`CREATE TABLE IF NOT EXISTS conversation_messages (
 			id BIGSERIAL PRIMARY KEY,
@@ -65,6 +190,35 @@ func EnsureSchemas(db *sql.DB) error {
 			content TEXT NOT NULL,
 			ts TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		);`,
+		`ALTER TABLE conversation_messages ADD COLUMN IF NOT EXISTS sources JSONB;`,
+		`ALTER TABLE conversation_messages ADD COLUMN IF NOT EXISTS redacted_at TIMESTAMPTZ;`,
+		`ALTER TABLE conversation_messages ADD COLUMN IF NOT EXISTS model TEXT;`,
+		`ALTER TABLE conversation_messages ADD COLUMN IF NOT EXISTS prompt_tokens INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE conversation_messages ADD COLUMN IF NOT EXISTS completion_tokens INTEGER NOT NULL DEFAULT 0;`,
+		`CREATE INDEX IF NOT EXISTS idx_conversation_messages_conversation ON conversation_messages(conversation_id, ts, id);`,
+		// Full-text search over messages; 'simple' since there is no Korean stemmer
+		`CREATE INDEX IF NOT EXISTS idx_conversation_messages_content_fts ON conversation_messages USING GIN (to_tsvector('simple', content));`,
+		// Conversation tags for staff triage
+		`CREATE TABLE IF NOT EXISTS conversation_tags (
+			conversation_id TEXT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+			tag TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (conversation_id, tag)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_conversation_tags_tag ON conversation_tags(tag);`,
+		// Public read-only conversation links; only token hashes are stored
+		`CREATE TABLE IF NOT EXISTS conversation_shares (
+			id TEXT PRIMARY KEY,
+			conversation_id TEXT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+			token_hash TEXT NOT NULL UNIQUE,
+			created_by TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			expires_at TIMESTAMPTZ NOT NULL,
+			revoked_at TIMESTAMPTZ,
+			view_count INTEGER NOT NULL DEFAULT 0
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_conversation_shares_conversation ON conversation_shares(conversation_id, created_at DESC);`,
 		// Analytics keyword/category/hourly counters
 		`CREATE TABLE IF NOT EXISTS analytics_keywords (
 			keyword TEXT PRIMARY KEY,
@@ -74,10 +228,57 @@ func EnsureSchemas(db *sql.DB) error {
 			category TEXT PRIMARY KEY,
 			count BIGINT NOT NULL DEFAULT 0
 		);`,
-		`CREATE TABLE IF NOT EXISTS analytics_hourly (
+		// analytics_hourly used to be keyed by hour of day ("15:00"), adding up
+		// every day's same hour; those totals move to analytics_hourly_legacy
+		// and still count towards the hour-of-day view
+		`DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'analytics_hourly' AND column_name = 'hour_key') THEN
+				ALTER TABLE analytics_hourly RENAME TO analytics_hourly_legacy;
+				ALTER TABLE analytics_hourly_legacy RENAME CONSTRAINT analytics_hourly_pkey TO analytics_hourly_legacy_pkey;
+			END IF;
+		END $$;`,
+		`CREATE TABLE IF NOT EXISTS analytics_hourly_legacy (
 			hour_key TEXT PRIMARY KEY,
 			count BIGINT NOT NULL DEFAULT 0
 		);`,
+		`CREATE TABLE IF NOT EXISTS analytics_hourly (
+			hour TIMESTAMPTZ PRIMARY KEY,
+			count BIGINT NOT NULL DEFAULT 0
+		);`,
+		`CREATE OR REPLACE VIEW analytics_hour_of_day AS
+			SELECT hour_key, SUM(count)::BIGINT AS count
+			FROM (
+				SELECT to_char(hour AT TIME ZONE 'UTC', 'HH24:00') AS hour_key, count FROM analytics_hourly
+				UNION ALL
+				SELECT hour_key, count FROM analytics_hourly_legacy
+			) h
+			GROUP BY hour_key;`,
+		// Keyword counts by day, for week-over-week trends
+		`CREATE TABLE IF NOT EXISTS analytics_keyword_daily (
+			date DATE NOT NULL,
+			keyword TEXT NOT NULL,
+			count BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (date, keyword)
+		);`,
+		// Per-user chat volume and topics by day
+		`CREATE TABLE IF NOT EXISTS analytics_user_daily (
+			user_id TEXT NOT NULL,
+			date DATE NOT NULL,
+			messages BIGINT NOT NULL DEFAULT 0,
+			prompt_tokens BIGINT NOT NULL DEFAULT 0,
+			completion_tokens BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (user_id, date)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_analytics_user_daily_date ON analytics_user_daily(date);`,
+		`CREATE TABLE IF NOT EXISTS analytics_user_topics (
+			user_id TEXT NOT NULL,
+			date DATE NOT NULL,
+			kind TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			count BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (user_id, date, kind, topic)
+		);`,
 		// Active sessions tracking
 		`CREATE TABLE IF NOT EXISTS active_sessions (
 			session_id TEXT PRIMARY KEY,
@@ -95,7 +296,52 @@ func EnsureSchemas(db *sql.DB) error {
 			token_count INTEGER,
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		);`,
+		`ALTER TABLE response_metrics ADD COLUMN IF NOT EXISTS retrieval_ms INTEGER;`,
+		`ALTER TABLE response_metrics ADD COLUMN IF NOT EXISTS llm_ms INTEGER;`,
 		`CREATE INDEX IF NOT EXISTS idx_metrics_created_at ON response_metrics(created_at);`,
+		// Response metrics rolled up by hour, then by day, once past retention
+		`CREATE TABLE IF NOT EXISTS response_metrics_hourly (
+			hour TIMESTAMPTZ PRIMARY KEY,
+			responses BIGINT NOT NULL DEFAULT 0,
+			conversations BIGINT NOT NULL DEFAULT 0,
+			total_response_ms BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0
+		);`,
+		`CREATE TABLE IF NOT EXISTS response_metrics_daily (
+			day TIMESTAMPTZ PRIMARY KEY,
+			responses BIGINT NOT NULL DEFAULT 0,
+			conversations BIGINT NOT NULL DEFAULT 0,
+			total_response_ms BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0
+		);`,
+		// How often each document was retrieved as a source and cited, by day
+		`CREATE TABLE IF NOT EXISTS document_hits (
+			document_id TEXT NOT NULL,
+			date DATE NOT NULL,
+			filename TEXT NOT NULL DEFAULT '',
+			retrieved BIGINT NOT NULL DEFAULT 0,
+			cited BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (document_id, date)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_document_hits_date ON document_hits(date);`,
+		// Questions the knowledge base could not answer, and weekly reports on them
+		`CREATE TABLE IF NOT EXISTS knowledge_gap_queries (
+			id BIGSERIAL PRIMARY KEY,
+			query TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			best_score REAL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_knowledge_gap_queries_created_at ON knowledge_gap_queries(created_at);`,
+		`CREATE TABLE IF NOT EXISTS knowledge_gap_reports (
+			id TEXT PRIMARY KEY,
+			period_start TIMESTAMPTZ NOT NULL,
+			period_end TIMESTAMPTZ NOT NULL,
+			total_queries INTEGER NOT NULL DEFAULT 0,
+			clusters JSONB NOT NULL DEFAULT '[]',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_knowledge_gap_reports_period_end ON knowledge_gap_reports(period_end);`,
 		// Daily stats snapshot
 		`CREATE TABLE IF NOT EXISTS daily_stats (
 			date DATE PRIMARY KEY,
@@ -106,6 +352,75 @@ func EnsureSchemas(db *sql.DB) error {
 			avg_response_time REAL,
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		);`,
+		// Audit log
+		`CREATE TABLE IF NOT EXISTS audit_logs (
+			id BIGSERIAL PRIMARY KEY,
+			action TEXT NOT NULL,
+			actor_id TEXT,
+			target_type TEXT,
+			target_id TEXT,
+			ip TEXT,
+			details JSONB,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);`,
+		`ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS actor_name TEXT;`,
+		// Outbound webhooks and their delivery log
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			events TEXT[] NOT NULL DEFAULT '{}',
+			secret TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_by TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			webhook_id TEXT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+			event TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_status INTEGER,
+			error TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at DESC);`,
+		// Idempotency-Key records: request hash plus the stored response to replay
+		`CREATE TABLE IF NOT EXISTS idempotency_keys (
+			principal TEXT NOT NULL,
+			key TEXT NOT NULL,
+			endpoint TEXT NOT NULL,
+			request_hash TEXT NOT NULL,
+			status_code INTEGER,
+			content_type TEXT,
+			response_body BYTEA,
+			response_hash TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			completed_at TIMESTAMPTZ,
+			expires_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (principal, key)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);`,
+		// Resumable multipart uploads in progress, bound to the caller that started them
+		`CREATE TABLE IF NOT EXISTS upload_sessions (
+			id TEXT PRIMARY KEY,
+			upload_id TEXT NOT NULL,
+			key TEXT NOT NULL,
+			principal TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			document_id TEXT NOT NULL DEFAULT '',
+			size BIGINT NOT NULL,
+			metadata JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			expires_at TIMESTAMPTZ NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions(expires_at);`,
 	}
 
 	for _, stmt := range statements {
@@ -0,0 +1,511 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Limits that keep a single query from fanning out without bound. maxDepth
+// bounds nested selections, maxAliases bounds aliased fields (which let one
+// field be resolved many times), and maxComplexity bounds the estimated
+// number of resolved fields, counting each list as listCost items.
+const (
+	maxDepth      = 10
+	maxAliases    = 30
+	maxComplexity = 1000
+	listCost      = 10
+)
+
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// Result follows the GraphQL response format. Data is omitted when the
+// request failed to parse or validate.
+type Result struct {
+	Data   *orderedMap `json:"data,omitempty"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// Execute parses, validates and runs a query against schema. Resolver errors
+// become entries in Result.Errors with the field path, and the field is null.
+// Unlike the reference implementation, a null non-null field does not null
+// its parent; the error is still reported.
+func Execute(ctx context.Context, schema *Schema, req Request) *Result {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return &Result{Errors: []Error{{Message: "syntax error: " + err.Error()}}}
+	}
+
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return &Result{Errors: []Error{{Message: err.Error()}}}
+	}
+	if op.kind != "query" {
+		return &Result{Errors: []Error{{Message: fmt.Sprintf("%s operations are not supported", op.kind)}}}
+	}
+
+	vars, err := coerceVariables(op.variables, req.Variables)
+	if err != nil {
+		return &Result{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	e := &executor{ctx: ctx, doc: doc, vars: vars}
+	v := &validator{executor: e, fragmentPath: map[string]bool{}}
+	if cost := v.selectionSet(schema.Query, op.selections, 1); cost > maxComplexity {
+		v.fail("query is too complex: cost exceeds %d", maxComplexity)
+	}
+	if len(v.errors) > 0 {
+		return &Result{Errors: v.errors}
+	}
+
+	data := e.selectionSet(schema.Query, nil, op.selections, nil)
+	return &Result{Data: data, Errors: e.errors}
+}
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) != 1 {
+			return nil, errors.New("operationName is required when the document has several operations")
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+func coerceVariables(defs []variableDefinition, input map[string]any) (map[string]any, error) {
+	vars := map[string]any{}
+	for _, def := range defs {
+		v, ok := input[def.name]
+		if !ok && def.defaultValue != nil {
+			resolved, err := resolveValue(def.defaultValue, nil)
+			if err != nil {
+				return nil, err
+			}
+			v, ok = resolved, true
+		}
+		if def.nonNull && v == nil {
+			return nil, fmt.Errorf("variable $%s is required", def.name)
+		}
+		if ok {
+			vars[def.name] = v
+		}
+	}
+	return vars, nil
+}
+
+type executor struct {
+	ctx    context.Context
+	doc    *document
+	vars   map[string]any
+	errors []Error
+}
+
+func (e *executor) addError(path []any, format string, args ...any) {
+	e.errors = append(e.errors, Error{Message: fmt.Sprintf(format, args...), Path: path})
+}
+
+func (e *executor) selectionSet(obj *Object, source any, sels []selection, path []any) *orderedMap {
+	keys, grouped := e.collectFields(sels, map[string]bool{})
+
+	out := newOrderedMap()
+	for _, key := range keys {
+		fields := grouped[key]
+		f := fields[0]
+		if f.name == "__typename" {
+			out.set(key, obj.Name)
+			continue
+		}
+
+		var subs []selection
+		for _, other := range fields {
+			subs = append(subs, other.selections...)
+		}
+
+		fieldPath := append(path[:len(path):len(path)], key)
+		def := obj.Fields[f.name]
+		args, err := e.coerceArguments(def, f.arguments)
+		if err != nil {
+			e.addError(fieldPath, "%s", err)
+			out.set(key, nil)
+			continue
+		}
+
+		var resolved any
+		if def.Resolve != nil {
+			resolved, err = def.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: args})
+			if err != nil {
+				e.addError(fieldPath, "%s", err)
+				out.set(key, nil)
+				continue
+			}
+		} else {
+			resolved = defaultResolve(source, f.name)
+		}
+		out.set(key, e.complete(def.Type, resolved, subs, fieldPath))
+	}
+	return out
+}
+
+func (e *executor) complete(t Type, v any, sels []selection, path []any) any {
+	if nn, ok := t.(*NonNull); ok {
+		result := e.complete(nn.OfType, v, sels, path)
+		if result == nil {
+			e.addError(path, "non-null field resolved to null")
+		}
+		return result
+	}
+	if isNil(v) {
+		return nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.addError(path, "expected a list, got %T", v)
+			return nil
+		}
+		items := make([]any, rv.Len())
+		for i := range items {
+			items[i] = e.complete(t.OfType, rv.Index(i).Interface(), sels, append(path[:len(path):len(path)], i))
+		}
+		return items
+	case *Object:
+		return e.selectionSet(t, v, sels, path)
+	default:
+		return serialize(v)
+	}
+}
+
+// collectFields flattens fragments and groups fields by response key,
+// keeping the order in which keys first appear.
+func (e *executor) collectFields(sels []selection, visited map[string]bool) ([]string, map[string][]*field) {
+	var keys []string
+	grouped := map[string][]*field{}
+
+	var collect func(sels []selection)
+	collect = func(sels []selection) {
+		for _, sel := range sels {
+			switch sel := sel.(type) {
+			case *field:
+				if ok, _ := e.included(sel.directives); !ok {
+					continue
+				}
+				key := sel.responseKey()
+				if _, seen := grouped[key]; !seen {
+					keys = append(keys, key)
+				}
+				grouped[key] = append(grouped[key], sel)
+			case *fragmentSpread:
+				if ok, _ := e.included(sel.directives); !ok || visited[sel.name] {
+					continue
+				}
+				visited[sel.name] = true
+				collect(e.doc.fragments[sel.name].selections)
+			case *inlineFragment:
+				if ok, _ := e.included(sel.directives); !ok {
+					continue
+				}
+				collect(sel.selections)
+			}
+		}
+	}
+	collect(sels)
+	return keys, grouped
+}
+
+// included evaluates @skip and @include.
+func (e *executor) included(dirs []directive) (bool, error) {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			return false, fmt.Errorf("unknown directive @%s", d.name)
+		}
+		if len(d.arguments) != 1 || d.arguments[0].name != "if" {
+			return false, fmt.Errorf("@%s requires a single \"if\" argument", d.name)
+		}
+		v, err := resolveValue(d.arguments[0].value, e.vars)
+		if err != nil {
+			return false, err
+		}
+		cond, ok := v.(bool)
+		if !ok {
+			return false, fmt.Errorf("@%s(if:) must be a Boolean", d.name)
+		}
+		if (d.name == "skip") == cond {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (e *executor) coerceArguments(def *Field, args []argument) (map[string]any, error) {
+	out := map[string]any{}
+	for _, a := range def.Args {
+		var (
+			v     any
+			found bool
+		)
+		for _, arg := range args {
+			if arg.name != a.Name {
+				continue
+			}
+			if ref, ok := arg.value.(variableRef); ok {
+				v, found = e.vars[string(ref)]
+			} else {
+				resolved, err := resolveValue(arg.value, e.vars)
+				if err != nil {
+					return nil, err
+				}
+				v, found = resolved, true
+			}
+		}
+		if !found {
+			if a.Default == nil {
+				if _, ok := a.Type.(*NonNull); ok {
+					return nil, fmt.Errorf("argument %q is required", a.Name)
+				}
+				continue
+			}
+			v = a.Default
+		}
+
+		coerced, err := coerceInput(a.Type, v)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", a.Name, err)
+		}
+		out[a.Name] = coerced
+	}
+	return out, nil
+}
+
+// resolveValue converts a parsed literal into plain Go values, substituting
+// variables. Absent variables become null.
+func resolveValue(v value, vars map[string]any) (any, error) {
+	switch v := v.(type) {
+	case variableRef:
+		return vars[string(v)], nil
+	case enumValue:
+		return v, nil
+	case []value:
+		list := make([]any, len(v))
+		for i, item := range v {
+			resolved, err := resolveValue(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = resolved
+		}
+		return list, nil
+	case map[string]value:
+		obj := make(map[string]any, len(v))
+		for k, item := range v {
+			resolved, err := resolveValue(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = resolved
+		}
+		return obj, nil
+	}
+	return v, nil
+}
+
+// coerceInput checks an argument value against its type. Variables arrive
+// decoded from JSON, so integral float64 values are accepted as Int, and
+// schema defaults are plain Go ints.
+func coerceInput(t Type, v any) (any, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected %s, got null", t)
+		}
+		return coerceInput(nn.OfType, v)
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		items, ok := v.([]any)
+		if !ok {
+			items = []any{v}
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			coerced, err := coerceInput(t.OfType, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = coerced
+		}
+		return out, nil
+	case *Scalar:
+		switch t {
+		case Int:
+			switch n := v.(type) {
+			case int:
+				if n >= math.MinInt32 && n <= math.MaxInt32 {
+					return n, nil
+				}
+			case int64:
+				if n >= math.MinInt32 && n <= math.MaxInt32 {
+					return int(n), nil
+				}
+			case float64:
+				if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+					return int(n), nil
+				}
+			}
+		case Float:
+			switch n := v.(type) {
+			case int:
+				return float64(n), nil
+			case int64:
+				return float64(n), nil
+			case float64:
+				return n, nil
+			}
+		case String:
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+		case ID:
+			switch id := v.(type) {
+			case string:
+				return id, nil
+			case int64:
+				return fmt.Sprint(id), nil
+			}
+		case Boolean:
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+		default:
+			if enum, ok := v.(enumValue); ok {
+				return string(enum), nil
+			}
+			return v, nil
+		}
+		return nil, fmt.Errorf("expected %s, got %v", t, v)
+	}
+	return nil, fmt.Errorf("input type %s is not supported", t)
+}
+
+// defaultResolve reads name from a map or from a struct field whose JSON
+// tag (or, without a tag, Go name ignoring case) matches.
+func defaultResolve(source any, name string) any {
+	rv := reflect.ValueOf(source)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		v := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+		if !v.IsValid() {
+			return nil
+		}
+		return v.Interface()
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			sf := rt.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			tag, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if tag == name || (tag == "" && strings.EqualFold(sf.Name, name)) {
+				return rv.Field(i).Interface()
+			}
+		}
+	}
+	return nil
+}
+
+func serialize(v any) any {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return v
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// orderedMap is a JSON object that keeps the order of the selection set.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: map[string]any{}}
+}
+
+func (m *orderedMap) set(key string, v any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,234 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testItem struct {
+	ID      string    `json:"id"`
+	Name    string    // matched by name, ignoring case
+	Created time.Time `json:"created"`
+}
+
+func testSchema() *Schema {
+	item := &Object{Name: "Item"}
+	item.Fields = Fields{
+		"id":      {Type: NewNonNull(ID)},
+		"name":    {Type: String},
+		"created": {Type: String},
+		"parent": {
+			Type: item,
+			Resolve: func(p ResolveParams) (any, error) {
+				return testItem{ID: p.Source.(testItem).ID + ".parent"}, nil
+			},
+		},
+		"children": {
+			Type: NewNonNull(NewList(NewNonNull(item))),
+			Resolve: func(p ResolveParams) (any, error) {
+				return []testItem{{ID: p.Source.(testItem).ID + ".1"}}, nil
+			},
+		},
+	}
+
+	return &Schema{Query: &Object{
+		Name: "Query",
+		Fields: Fields{
+			"item": {
+				Type: item,
+				Args: []Arg{{Name: "id", Type: NewNonNull(ID)}},
+				Resolve: func(p ResolveParams) (any, error) {
+					if p.Args["id"] == "missing" {
+						return nil, nil
+					}
+					return testItem{ID: p.Args["id"].(string), Name: "item " + p.Args["id"].(string), Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}, nil
+				},
+			},
+			"items": {
+				Type: NewNonNull(NewList(NewNonNull(item))),
+				Args: []Arg{{Name: "limit", Type: Int, Default: 2}},
+				Resolve: func(p ResolveParams) (any, error) {
+					items := []testItem{}
+					for i := 0; i < p.Args["limit"].(int); i++ {
+						items = append(items, testItem{ID: string(rune('a' + i))})
+					}
+					return items, nil
+				},
+			},
+			"greet": {
+				Type: String,
+				Args: []Arg{{Name: "name", Type: NewNonNull(String)}, {Name: "tags", Type: NewList(String)}},
+				Resolve: func(p ResolveParams) (any, error) {
+					return "hello " + p.Args["name"].(string), nil
+				},
+			},
+			"fail": {
+				Type:    String,
+				Resolve: func(p ResolveParams) (any, error) { return nil, errors.New("boom") },
+			},
+			"required": {
+				Type:    NewNonNull(String),
+				Resolve: func(p ResolveParams) (any, error) { return nil, nil },
+			},
+		},
+	}}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       Request
+		wantData  string
+		wantError string
+	}{
+		{
+			name:     "default resolvers and time values",
+			req:      Request{Query: `{ item(id: "1") { id name created } }`},
+			wantData: `{"item":{"id":"1","name":"item 1","created":"2024-01-02T03:04:05Z"}}`,
+		},
+		{
+			name:     "aliases keep selection order",
+			req:      Request{Query: `{ b: item(id: "2") { id } a: item(id: "1") { id } __typename }`},
+			wantData: `{"b":{"id":"2"},"a":{"id":"1"},"__typename":"Query"}`,
+		},
+		{
+			name:     "variables and argument defaults",
+			req:      Request{Query: `query ($n: Int) { items(limit: $n) { id } }`, Variables: map[string]any{"n": float64(3)}},
+			wantData: `{"items":[{"id":"a"},{"id":"b"},{"id":"c"}]}`,
+		},
+		{
+			name:     "absent variable falls back to the argument default",
+			req:      Request{Query: `query ($n: Int) { items(limit: $n) { id } }`},
+			wantData: `{"items":[{"id":"a"},{"id":"b"}]}`,
+		},
+		{
+			name:     "fragments merge and directives apply",
+			req:      Request{Query: `query ($skip: Boolean!) { item(id: "1") { ...F name @skip(if: $skip) children { id } } } fragment F on Item { id }`, Variables: map[string]any{"skip": true}},
+			wantData: `{"item":{"id":"1","children":[{"id":"1.1"}]}}`,
+		},
+		{
+			name:     "named operation",
+			req:      Request{Query: `query A { fail } query B { greet(name: "x") }`, OperationName: "B"},
+			wantData: `{"greet":"hello x"}`,
+		},
+		{
+			name:      "resolver error nulls the field",
+			req:       Request{Query: `{ fail greet(name: "x") }`},
+			wantData:  `{"fail":null,"greet":"hello x"}`,
+			wantError: "boom",
+		},
+		{
+			name:      "null non-null field",
+			req:       Request{Query: `{ required }`},
+			wantData:  `{"required":null}`,
+			wantError: "non-null field resolved to null",
+		},
+		{
+			name:      "argument of the wrong type",
+			req:       Request{Query: `{ greet(name: 1) }`},
+			wantData:  `{"greet":null}`,
+			wantError: `argument "name": expected String`,
+		},
+		{name: "syntax error", req: Request{Query: `{ item(`}, wantError: "syntax error"},
+		{name: "mutation", req: Request{Query: `mutation { a }`}, wantError: "mutation operations are not supported"},
+		{name: "several operations without a name", req: Request{Query: `query A { fail } query B { fail }`}, wantError: "operationName is required"},
+		{name: "missing required variable", req: Request{Query: `query ($id: ID!) { item(id: $id) { id } }`}, wantError: "variable $id is required"},
+		{name: "unknown field", req: Request{Query: `{ nope }`}, wantError: `cannot query field "nope" on type Query`},
+		{name: "unknown argument", req: Request{Query: `{ greet(name: "x", loud: true) }`}, wantError: `unknown argument "loud"`},
+		{name: "object without selection", req: Request{Query: `{ item(id: "1") }`}, wantError: "must have a selection"},
+		{name: "scalar with selection", req: Request{Query: `{ fail { id } }`}, wantError: "must not have a selection"},
+		{name: "conflicting aliases", req: Request{Query: `{ x: fail x: required }`}, wantError: "conflict"},
+		{name: "unknown directive", req: Request{Query: `{ fail @deprecated }`}, wantError: "unknown directive @deprecated"},
+		{name: "fragment on the wrong type", req: Request{Query: `{ ...F } fragment F on Item { id }`}, wantError: "cannot be spread within Query"},
+		{name: "fragment cycle", req: Request{Query: `{ item(id: "1") { ...A } } fragment A on Item { ...B } fragment B on Item { ...A }`}, wantError: "spreads itself"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Execute(context.Background(), testSchema(), tt.req)
+
+			data := ""
+			if result.Data != nil {
+				b, err := json.Marshal(result.Data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				data = string(b)
+			}
+			if data != tt.wantData {
+				t.Errorf("data = %s, want %s", data, tt.wantData)
+			}
+			if !hasError(result.Errors, tt.wantError) {
+				t.Errorf("errors = %+v, want %q", result.Errors, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestExecuteLimits(t *testing.T) {
+	// nested returns a query with levels selection sets, counting the
+	// operation's own.
+	nested := func(levels int) string {
+		return `{ item(id: "1") ` + strings.Repeat("{ parent ", levels-2) + "{ id }" + strings.Repeat(" }", levels-2) + " }"
+	}
+	aliased := func(n int) string {
+		var b strings.Builder
+		b.WriteString("{")
+		for i := 0; i < n; i++ {
+			b.WriteString(" a" + strconv.Itoa(i) + `: greet(name: "x")`)
+		}
+		return b.String() + " }"
+	}
+	// Each fragment spreads the next one twice, doubling the cost per level.
+	bomb := `{ item(id: "1") { ...F0 } } fragment F40 on Item { id }`
+	for i := 0; i < 40; i++ {
+		next := "F" + strconv.Itoa(i+1)
+		bomb += " fragment F" + strconv.Itoa(i) + " on Item { ..." + next + " ..." + next + " }"
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantError string
+	}{
+		{name: "depth at the limit", query: nested(maxDepth)},
+		{name: "too deep", query: nested(maxDepth + 1), wantError: "nested deeper than"},
+		{name: "aliases at the limit", query: aliased(maxAliases)},
+		{name: "too many aliases", query: aliased(maxAliases + 1), wantError: "more than 30 aliases"},
+		{name: "list fields within budget", query: `{ items { id children { id name } } }`},
+		{name: "nested lists over budget", query: `{ items { children { children { id name } } } }`, wantError: "too complex"},
+		{name: "fragment fan-out", query: bomb, wantError: "too complex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Execute(context.Background(), testSchema(), Request{Query: tt.query})
+			if tt.wantError == "" {
+				if len(result.Errors) > 0 || result.Data == nil {
+					t.Fatalf("errors = %+v", result.Errors)
+				}
+				return
+			}
+			if result.Data != nil || !hasError(result.Errors, tt.wantError) {
+				t.Fatalf("errors = %+v, want %q", result.Errors, tt.wantError)
+			}
+		})
+	}
+}
+
+// hasError reports whether an error contains want, or whether there are no
+// errors when want is empty.
+func hasError(errs []Error, want string) bool {
+	if want == "" {
+		return len(errs) == 0
+	}
+	for _, e := range errs {
+		if strings.Contains(e.Message, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,628 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The parser covers the executable subset of GraphQL used by clients:
+// operations with variables, fields with aliases and arguments, named and
+// inline fragments, and the @skip/@include directives.
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string
+	name       string
+	variables  []variableDefinition
+	selections []selection
+}
+
+type variableDefinition struct {
+	name         string
+	nonNull      bool
+	defaultValue value
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []selection
+}
+
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []argument
+	directives []directive
+	selections []selection
+}
+
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []directive
+}
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []directive
+	selections    []selection
+}
+
+type argument struct {
+	name  string
+	value value
+}
+
+type directive struct {
+	name      string
+	arguments []argument
+}
+
+// value is a parsed literal: nil, bool, int64, float64, string, enumValue,
+// variableRef, []value or map[string]value.
+type value interface{}
+
+type enumValue string
+
+type variableRef string
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+func parse(src string) (*document, error) {
+	p := &parser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: map[string]*fragment{}}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.tok.kind == tokenPunct && p.tok.value == "{":
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: sels})
+		case p.tok.kind == tokenName && p.tok.value == "fragment":
+			frag, err := p.fragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[frag.name]; ok {
+				return nil, fmt.Errorf("duplicate fragment %q", frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		case p.tok.kind == tokenName:
+			op, err := p.operationDefinition()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document has no operations")
+	}
+	return doc, nil
+}
+
+func (p *parser) operationDefinition() (*operation, error) {
+	op := &operation{kind: p.tok.value}
+	if op.kind != "query" && op.kind != "mutation" && op.kind != "subscription" {
+		return nil, p.unexpected()
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		vars, err := p.variableDefinitions()
+		if err != nil {
+			return nil, err
+		}
+		op.variables = vars
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+func (p *parser) variableDefinitions() ([]variableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var defs []variableDefinition
+	for !p.peek(")") {
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		nonNull, err := p.typeReference()
+		if err != nil {
+			return nil, err
+		}
+		def := variableDefinition{name: name, nonNull: nonNull}
+		if p.peek("=") {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if def.defaultValue, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+		defs = append(defs, def)
+	}
+	return defs, p.expect(")")
+}
+
+// typeReference skips a variable type and reports whether it is non-null.
+// Values are checked against the argument types they are used for.
+func (p *parser) typeReference() (bool, error) {
+	if p.peek("[") {
+		if err := p.next(); err != nil {
+			return false, err
+		}
+		if _, err := p.typeReference(); err != nil {
+			return false, err
+		}
+		if err := p.expect("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.name(); err != nil {
+		return false, err
+	}
+	if p.peek("!") {
+		return true, p.next()
+	}
+	return false, nil
+}
+
+func (p *parser) fragmentDefinition() (*fragment, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("invalid fragment name %q", name)
+	}
+	if err := p.keyword("on"); err != nil {
+		return nil, err
+	}
+	typeCondition, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, typeCondition: typeCondition, selections: sels}, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []selection
+	for !p.peek("}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, fmt.Errorf("empty selection set at %d", p.tok.pos)
+	}
+	return sels, p.expect("}")
+}
+
+func (p *parser) selection() (selection, error) {
+	if p.peek("...") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			name := p.tok.value
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			dirs, err := p.directives()
+			if err != nil {
+				return nil, err
+			}
+			return &fragmentSpread{name: name, directives: dirs}, nil
+		}
+
+		inline := &inlineFragment{}
+		if p.tok.kind == tokenName {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			typeCondition, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			inline.typeCondition = typeCondition
+		}
+		dirs, err := p.directives()
+		if err != nil {
+			return nil, err
+		}
+		inline.directives = dirs
+		if inline.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return inline, nil
+	}
+
+	f := &field{}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek(":") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		f.alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	f.name = name
+
+	if p.peek("(") {
+		if f.arguments, err = p.arguments(); err != nil {
+			return nil, err
+		}
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments() ([]argument, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []argument
+	for !p.peek(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.value(false)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, argument{name: name, value: v})
+	}
+	return args, p.expect(")")
+}
+
+func (p *parser) directives() ([]directive, error) {
+	var dirs []directive
+	for p.peek("@") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		d := directive{name: name}
+		if p.peek("(") {
+			if d.arguments, err = p.arguments(); err != nil {
+				return nil, err
+			}
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+func (p *parser) value(constant bool) (value, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenInt:
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int %s at %d", tok.value, tok.pos)
+		}
+		return n, p.next()
+	case tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %s at %d", tok.value, tok.pos)
+		}
+		return f, p.next()
+	case tokenString:
+		return tok.value, p.next()
+	case tokenName:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		switch tok.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return enumValue(tok.value), nil
+	case tokenPunct:
+		switch tok.value {
+		case "$":
+			if constant {
+				return nil, fmt.Errorf("variable not allowed in constant value at %d", tok.pos)
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			return variableRef(name), nil
+		case "[":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			list := []value{}
+			for !p.peek("]") {
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			return list, p.expect("]")
+		case "{":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			obj := map[string]value{}
+			for !p.peek("}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if obj[name], err = p.value(constant); err != nil {
+					return nil, err
+				}
+			}
+			return obj, p.expect("}")
+		}
+	}
+	return nil, p.unexpected()
+}
+
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == punct
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.peek(punct) {
+		return fmt.Errorf("expected %q at %d, got %s", punct, p.tok.pos, p.describe())
+	}
+	return p.next()
+}
+
+func (p *parser) keyword(word string) error {
+	if p.tok.kind != tokenName || p.tok.value != word {
+		return fmt.Errorf("expected %q at %d, got %s", word, p.tok.pos, p.describe())
+	}
+	return p.next()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", fmt.Errorf("expected name at %d, got %s", p.tok.pos, p.describe())
+	}
+	name := p.tok.value
+	return name, p.next()
+}
+
+func (p *parser) unexpected() error {
+	return fmt.Errorf("unexpected %s at %d", p.describe(), p.tok.pos)
+}
+
+func (p *parser) describe() string {
+	if p.tok.kind == tokenEOF {
+		return "end of document"
+	}
+	return strconv.Quote(p.tok.value)
+}
+
+// next advances to the following token, skipping whitespace, commas and
+// comments, which are insignificant in GraphQL.
+func (p *parser) next() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+			continue
+		}
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		// Skip a UTF-8 byte order mark.
+		if strings.HasPrefix(p.src[p.pos:], "\ufeff") {
+			p.pos += len("\ufeff")
+			continue
+		}
+		break
+	}
+
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tokenEOF, pos: start}
+		return nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokenPunct, value: "...", pos: start}
+	case strings.IndexByte("!$():=@[]{|}", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenPunct, value: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenName, value: p.src[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		return p.number()
+	case c == '"':
+		return p.string()
+	default:
+		return fmt.Errorf("unexpected character %q at %d", c, start)
+	}
+	return nil
+}
+
+func (p *parser) number() error {
+	start := p.pos
+	kind := tokenInt
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() {
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	digits()
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		kind = tokenFloat
+		p.pos++
+		digits()
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		kind = tokenFloat
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+	p.tok = token{kind: kind, value: p.src[start:p.pos], pos: start}
+	return nil
+}
+
+// string reads a quoted string. GraphQL escapes are a subset of JSON's, so
+// the literal is decoded with encoding/json. Block strings are not supported.
+func (p *parser) string() error {
+	start := p.pos
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		return fmt.Errorf("block strings are not supported (at %d)", start)
+	}
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '\n', '\r':
+			return fmt.Errorf("unterminated string at %d", start)
+		case '"':
+			p.pos++
+			var s string
+			if err := json.Unmarshal([]byte(p.src[start:p.pos]), &s); err != nil {
+				return fmt.Errorf("invalid string at %d", start)
+			}
+			p.tok = token{kind: tokenString, value: s, pos: start}
+			return nil
+		}
+		p.pos++
+	}
+	return fmt.Errorf("unterminated string at %d", start)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
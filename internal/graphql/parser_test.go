@@ -0,0 +1,119 @@
+package graphql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  *document
+	}{
+		{
+			name:  "shorthand query",
+			query: "{ a }",
+			want: &document{
+				operations: []*operation{{kind: "query", selections: []selection{&field{name: "a"}}}},
+				fragments:  map[string]*fragment{},
+			},
+		},
+		{
+			name:  "alias, arguments and nested selection",
+			query: `query Q { x: a(n: 1, f: -1.5e2, s: "é\n", b: true, z: null, e: RED, l: [1 2], o: {k: "v"}) { b } }`,
+			want: &document{
+				operations: []*operation{{kind: "query", name: "Q", selections: []selection{&field{
+					alias: "x",
+					name:  "a",
+					arguments: []argument{
+						{name: "n", value: int64(1)},
+						{name: "f", value: -150.0},
+						{name: "s", value: "é\n"},
+						{name: "b", value: true},
+						{name: "z", value: nil},
+						{name: "e", value: enumValue("RED")},
+						{name: "l", value: []value{int64(1), int64(2)}},
+						{name: "o", value: map[string]value{"k": "v"}},
+					},
+					selections: []selection{&field{name: "b"}},
+				}}}},
+				fragments: map[string]*fragment{},
+			},
+		},
+		{
+			name:  "variables with defaults",
+			query: "query ($id: ID!, $n: [Int] = [1]) { a(id: $id, n: $n) }",
+			want: &document{
+				operations: []*operation{{
+					kind: "query",
+					variables: []variableDefinition{
+						{name: "id", nonNull: true},
+						{name: "n", defaultValue: []value{int64(1)}},
+					},
+					selections: []selection{&field{name: "a", arguments: []argument{
+						{name: "id", value: variableRef("id")},
+						{name: "n", value: variableRef("n")},
+					}}},
+				}},
+				fragments: map[string]*fragment{},
+			},
+		},
+		{
+			name:  "fragments and directives",
+			query: "# comment\n{ ...F @skip(if: true) ... on Query { b } ... @include(if: $x) { c } } fragment F on Query { a }",
+			want: &document{
+				operations: []*operation{{kind: "query", selections: []selection{
+					&fragmentSpread{name: "F", directives: []directive{{name: "skip", arguments: []argument{{name: "if", value: true}}}}},
+					&inlineFragment{typeCondition: "Query", selections: []selection{&field{name: "b"}}},
+					&inlineFragment{directives: []directive{{name: "include", arguments: []argument{{name: "if", value: variableRef("x")}}}}, selections: []selection{&field{name: "c"}}},
+				}}},
+				fragments: map[string]*fragment{
+					"F": {name: "F", typeCondition: "Query", selections: []selection{&field{name: "a"}}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse(%q) = %#v, want %#v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{name: "empty document", query: "  ", wantErr: "no operations"},
+		{name: "only fragments", query: "fragment F on Query { a }", wantErr: "no operations"},
+		{name: "empty selection", query: "{ }", wantErr: "empty selection set"},
+		{name: "unclosed selection", query: "{ a", wantErr: "end of document"},
+		{name: "unknown operation kind", query: "update { a }", wantErr: "unexpected"},
+		{name: "duplicate fragment", query: "{ ...F } fragment F on Query { a } fragment F on Query { b }", wantErr: "duplicate fragment"},
+		{name: "fragment named on", query: "fragment on on Query { a }", wantErr: "invalid fragment name"},
+		{name: "variable in default", query: "query ($a: Int = $b) { a }", wantErr: "variable not allowed"},
+		{name: "unterminated string", query: `{ a(s: "x) }`, wantErr: "unterminated string"},
+		{name: "block string", query: `{ a(s: """x""") }`, wantErr: "block strings"},
+		{name: "invalid escape", query: `{ a(s: "\q") }`, wantErr: "invalid string"},
+		{name: "int overflow", query: "{ a(n: 99999999999999999999) }", wantErr: "invalid int"},
+		{name: "bad character", query: "{ a; }", wantErr: "unexpected character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(tt.query)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("parse(%q) error = %v, want %q", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
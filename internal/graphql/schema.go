@@ -0,0 +1,173 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Type is a GraphQL output or input type: *Scalar, *Object, *List or *NonNull.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type. Resolved values are serialized as JSON as is,
+// except time.Time which is formatted as RFC 3339.
+type Scalar struct {
+	Name        string
+	Description string
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+var (
+	String  = &Scalar{Name: "String"}
+	Int     = &Scalar{Name: "Int"}
+	Float   = &Scalar{Name: "Float"}
+	Boolean = &Scalar{Name: "Boolean"}
+	ID      = &Scalar{Name: "ID"}
+	// JSON passes arbitrary JSON through, for nested data without a schema
+	// such as document metadata.
+	JSON = &Scalar{Name: "JSON", Description: "Arbitrary JSON value"}
+)
+
+type Object struct {
+	Name        string
+	Description string
+	Fields      Fields
+}
+
+func (o *Object) String() string { return o.Name }
+
+type Fields map[string]*Field
+
+type List struct {
+	OfType Type
+}
+
+func (l *List) String() string { return "[" + l.OfType.String() + "]" }
+
+type NonNull struct {
+	OfType Type
+}
+
+func (n *NonNull) String() string { return n.OfType.String() + "!" }
+
+func NewList(t Type) *List { return &List{OfType: t} }
+
+func NewNonNull(t Type) *NonNull { return &NonNull{OfType: t} }
+
+// Field describes an object field. Without Resolve the value is read from
+// the parent: a map key, or a struct field matched by JSON tag or name.
+type Field struct {
+	Type        Type
+	Args        []Arg
+	Description string
+	Resolve     ResolveFunc
+}
+
+type Arg struct {
+	Name    string
+	Type    Type
+	Default any
+}
+
+type ResolveFunc func(p ResolveParams) (any, error)
+
+type ResolveParams struct {
+	Context context.Context
+	// Source is the value resolved for the parent object.
+	Source any
+	Args   map[string]any
+}
+
+// Schema is a read-only schema; only query operations are executed.
+type Schema struct {
+	Query *Object
+}
+
+// SDL renders the schema in GraphQL schema definition language.
+func (s *Schema) SDL() string {
+	objects := map[string]*Object{}
+	scalars := map[string]*Scalar{}
+	var walk func(t Type)
+	walk = func(t Type) {
+		switch t := t.(type) {
+		case *NonNull:
+			walk(t.OfType)
+		case *List:
+			walk(t.OfType)
+		case *Scalar:
+			scalars[t.Name] = t
+		case *Object:
+			if _, ok := objects[t.Name]; ok {
+				return
+			}
+			objects[t.Name] = t
+			for _, f := range t.Fields {
+				walk(f.Type)
+				for _, a := range f.Args {
+					walk(a.Type)
+				}
+			}
+		}
+	}
+	walk(s.Query)
+
+	var b strings.Builder
+	b.WriteString("schema {\n  query: " + s.Query.Name + "\n}\n")
+
+	for _, name := range sortedKeys(scalars) {
+		switch name {
+		case "String", "Int", "Float", "Boolean", "ID":
+			continue
+		}
+		b.WriteString("\n")
+		writeDescription(&b, "", scalars[name].Description)
+		fmt.Fprintf(&b, "scalar %s\n", name)
+	}
+
+	names := sortedKeys(objects)
+	// The query type goes first.
+	sort.SliceStable(names, func(i, j int) bool { return names[i] == s.Query.Name && names[j] != s.Query.Name })
+	for _, name := range names {
+		obj := objects[name]
+		b.WriteString("\n")
+		writeDescription(&b, "", obj.Description)
+		fmt.Fprintf(&b, "type %s {\n", name)
+		for _, fname := range sortedKeys(obj.Fields) {
+			f := obj.Fields[fname]
+			writeDescription(&b, "  ", f.Description)
+			b.WriteString("  " + fname)
+			if len(f.Args) > 0 {
+				args := make([]string, len(f.Args))
+				for i, a := range f.Args {
+					args[i] = a.Name + ": " + a.Type.String()
+					if a.Default != nil {
+						args[i] += fmt.Sprintf(" = %v", a.Default)
+					}
+				}
+				b.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			b.WriteString(": " + f.Type.String() + "\n")
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description != "" {
+		fmt.Fprintf(b, "%s%q\n", indent, description)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
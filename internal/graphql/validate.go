@@ -0,0 +1,153 @@
+package graphql
+
+import "fmt"
+
+// validator checks a selection set against the schema before anything is
+// resolved: fields and arguments must exist, objects need a sub-selection
+// and scalars must not have one, fragments must apply to the object type and
+// must not form cycles, and nesting, aliases and cost are bounded by
+// maxDepth, maxAliases and maxComplexity.
+type validator struct {
+	*executor
+	fragmentPath map[string]bool
+	aliases      int
+	errors       []Error
+}
+
+func (v *validator) fail(format string, args ...any) {
+	v.errors = append(v.errors, Error{Message: fmt.Sprintf(format, args...)})
+}
+
+// selectionSet returns the cost of sels. It stops early once the cost is
+// over maxComplexity, so repeated fragment spreads cannot blow up validation.
+func (v *validator) selectionSet(obj *Object, sels []selection, depth int) int {
+	if depth > maxDepth {
+		v.fail("query is nested deeper than %d levels", maxDepth)
+		return 0
+	}
+
+	cost := 0
+	names := map[string]string{}
+	for _, sel := range sels {
+		if cost > maxComplexity {
+			return cost
+		}
+		switch sel := sel.(type) {
+		case *field:
+			if _, err := v.included(sel.directives); err != nil {
+				v.fail("%s", err)
+				continue
+			}
+			key := sel.responseKey()
+			if other, ok := names[key]; ok && other != sel.name {
+				v.fail("fields %q and %q conflict because they share the response name %q", other, sel.name, key)
+			}
+			names[key] = sel.name
+			if sel.alias != "" {
+				if v.aliases++; v.aliases == maxAliases+1 {
+					v.fail("query has more than %d aliases", maxAliases)
+				}
+			}
+			cost += v.field(obj, sel, depth)
+		case *fragmentSpread:
+			if _, err := v.included(sel.directives); err != nil {
+				v.fail("%s", err)
+				continue
+			}
+			frag, ok := v.doc.fragments[sel.name]
+			if !ok {
+				v.fail("unknown fragment %q", sel.name)
+				continue
+			}
+			if frag.typeCondition != obj.Name {
+				v.fail("fragment %q on %s cannot be spread within %s", sel.name, frag.typeCondition, obj.Name)
+				continue
+			}
+			if v.fragmentPath[sel.name] {
+				v.fail("fragment %q spreads itself", sel.name)
+				continue
+			}
+			v.fragmentPath[sel.name] = true
+			cost += v.selectionSet(obj, frag.selections, depth)
+			delete(v.fragmentPath, sel.name)
+		case *inlineFragment:
+			if _, err := v.included(sel.directives); err != nil {
+				v.fail("%s", err)
+				continue
+			}
+			if sel.typeCondition != "" && sel.typeCondition != obj.Name {
+				v.fail("inline fragment on %s cannot be spread within %s", sel.typeCondition, obj.Name)
+				continue
+			}
+			cost += v.selectionSet(obj, sel.selections, depth)
+		}
+	}
+	return cost
+}
+
+// field returns the cost of f: one for the field itself plus its
+// sub-selection, multiplied by listCost for each list around the field type.
+func (v *validator) field(obj *Object, f *field, depth int) int {
+	if f.name == "__typename" {
+		if len(f.selections) > 0 {
+			v.fail("field \"__typename\" must not have a selection")
+		}
+		return 0
+	}
+
+	def, ok := obj.Fields[f.name]
+	if !ok {
+		v.fail("cannot query field %q on type %s", f.name, obj.Name)
+		return 1
+	}
+
+	for _, arg := range f.arguments {
+		known := false
+		for _, a := range def.Args {
+			known = known || a.Name == arg.name
+		}
+		if !known {
+			v.fail("unknown argument %q on field %s.%s", arg.name, obj.Name, f.name)
+		}
+	}
+
+	named := namedType(def.Type)
+	child, isObject := named.(*Object)
+	switch {
+	case isObject && len(f.selections) == 0:
+		v.fail("field %q of type %s must have a selection of subfields", f.name, def.Type)
+	case !isObject && len(f.selections) > 0:
+		v.fail("field %q must not have a selection since type %s has no subfields", f.name, def.Type)
+	case isObject:
+		return 1 + listMultiplier(def.Type)*v.selectionSet(child, f.selections, depth+1)
+	}
+	return 1
+}
+
+func listMultiplier(t Type) int {
+	n := 1
+	for {
+		switch w := t.(type) {
+		case *NonNull:
+			t = w.OfType
+		case *List:
+			n *= listCost
+			t = w.OfType
+		default:
+			return n
+		}
+	}
+}
+
+func namedType(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *NonNull:
+			t = w.OfType
+		case *List:
+			t = w.OfType
+		default:
+			return t
+		}
+	}
+}
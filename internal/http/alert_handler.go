@@ -0,0 +1,22 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"yuon/internal/alert"
+)
+
+type AlertHandler struct {
+	monitor *alert.Monitor
+}
+
+func NewAlertHandler(monitor *alert.Monitor) *AlertHandler {
+	return &AlertHandler{monitor: monitor}
+}
+
+// Status reports the latest evaluation of each alert rule.
+func (h *AlertHandler) Status(c *gin.Context) {
+	SuccessResponse(c, gin.H{
+		"intervalSeconds": int(h.monitor.Interval().Seconds()),
+		"rules":           h.monitor.Status(),
+	})
+}
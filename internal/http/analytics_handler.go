@@ -1,16 +1,27 @@
 package http
 
 import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"yuon/internal/rag/service"
+	"yuon/package/scheduler"
 )
 
 type AnalyticsHandler struct {
 	service *service.ChatbotService
+	// loc is the time zone daily statistics are counted in.
+	loc *time.Location
 }
 
-func NewAnalyticsHandler(service *service.ChatbotService) *AnalyticsHandler {
-	return &AnalyticsHandler{service: service}
+func NewAnalyticsHandler(service *service.ChatbotService, loc *time.Location) *AnalyticsHandler {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &AnalyticsHandler{service: service, loc: loc}
 }
 
 func (h *AnalyticsHandler) ChatStats(c *gin.Context) {
@@ -18,13 +29,419 @@ func (h *AnalyticsHandler) ChatStats(c *gin.Context) {
 	SuccessResponse(c, stats)
 }
 
+// KnowledgeNeed suggests missing material in prose, together with the
+// latest knowledge gap report it drew on.
 func (h *AnalyticsHandler) KnowledgeNeed(c *gin.Context) {
-	analysis, err := h.service.GenerateKnowledgeNeedAnalysis(c.Request.Context())
+	analysis, report, err := h.service.GenerateKnowledgeNeedAnalysis(c.Request.Context())
 	if err != nil {
 		InternalServerErrorResponse(c, "분석 생성에 실패했습니다")
 		return
 	}
 	SuccessResponse(c, gin.H{
 		"analysis": analysis,
+		"report":   report,
+	})
+}
+
+// KnowledgeGapReports lists the latest `limit` (default 12, at most 52)
+// knowledge gap reports, newest period first, to compare over time.
+func (h *AnalyticsHandler) KnowledgeGapReports(c *gin.Context) {
+	limit := parseQueryInt(c, "limit", 12)
+	if limit <= 0 || limit > 52 {
+		BadRequestResponse(c, "limit은 1~52 사이여야 합니다")
+		return
+	}
+	reports, err := h.service.ListKnowledgeGapReports(c.Request.Context(), limit)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "지식 공백 보고서 조회에 실패했습니다")
+		return
+	}
+	if reports == nil {
+		reports = []service.KnowledgeGapReport{}
+	}
+	SuccessResponse(c, gin.H{"reports": reports})
+}
+
+// TimeSeries buckets `metric` (messages, tokens or active_users) by
+// `interval` (hour or day) over from through to, the last 24 hours by hour
+// or the last 30 days by day by default. from and to are RFC 3339 times or
+// YYYY-MM-DD dates, a date `to` including its day.
+func (h *AnalyticsHandler) TimeSeries(c *gin.Context) {
+	metric := c.DefaultQuery("metric", service.MetricMessages)
+	switch metric {
+	case service.MetricMessages, service.MetricTokens, service.MetricActiveUsers:
+	default:
+		BadRequestResponse(c, "metric은 messages, tokens, active_users 중 하나여야 합니다")
+		return
+	}
+	interval := c.DefaultQuery("interval", service.IntervalDay)
+	if interval != service.IntervalHour && interval != service.IntervalDay {
+		BadRequestResponse(c, "interval은 hour 또는 day여야 합니다")
+		return
+	}
+
+	now := time.Now()
+	defTo, span := scheduler.Day(now, h.loc).AddDate(0, 0, 1), 30*24*time.Hour
+	if interval == service.IntervalHour {
+		defTo, span = now.Truncate(time.Hour).Add(time.Hour), 24*time.Hour
+	}
+	to, ok := h.parseTime(c, "to는", c.Query("to"), defTo, true)
+	if !ok {
+		return
+	}
+	from, ok := h.parseTime(c, "from은", c.Query("from"), to.Add(-span), false)
+	if !ok {
+		return
+	}
+	if !from.Before(to) {
+		BadRequestResponse(c, "from은 to보다 앞서야 합니다")
+		return
+	}
+	if interval == service.IntervalHour && to.Sub(from) > service.MaxTimeSeriesPoints*time.Hour {
+		BadRequestResponse(c, fmt.Sprintf("hour 간격의 기간은 최대 %d일입니다", service.MaxTimeSeriesPoints/24))
+		return
+	}
+	if interval == service.IntervalDay && to.Sub(from) > service.MaxDailyStatsRange*24*time.Hour {
+		BadRequestResponse(c, fmt.Sprintf("기간은 최대 %d일입니다", service.MaxDailyStatsRange))
+		return
+	}
+
+	series, err := h.service.TimeSeries(c.Request.Context(), metric, interval, from, to, h.loc)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "시계열 통계 조회에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, series)
+}
+
+// HourlyRequests reports the answered messages of each hour from through
+// to, the last 7 days by default and at most 31 days, and the same added up
+// by hour of day. from and to are as in TimeSeries.
+func (h *AnalyticsHandler) HourlyRequests(c *gin.Context) {
+	defTo := time.Now().Truncate(time.Hour).Add(time.Hour)
+	to, ok := h.parseTime(c, "to는", c.Query("to"), defTo, true)
+	if !ok {
+		return
+	}
+	from, ok := h.parseTime(c, "from은", c.Query("from"), to.AddDate(0, 0, -7), false)
+	if !ok {
+		return
+	}
+	if !from.Before(to) {
+		BadRequestResponse(c, "from은 to보다 앞서야 합니다")
+		return
+	}
+	if to.Sub(from.Truncate(time.Hour)) > service.MaxTimeSeriesPoints*time.Hour {
+		BadRequestResponse(c, fmt.Sprintf("기간은 최대 %d일입니다", service.MaxTimeSeriesPoints/24))
+		return
+	}
+
+	hourly, err := h.service.HourlyRequests(c.Request.Context(), from, to, h.loc)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "시간대별 요청 수 조회에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, hourly)
+}
+
+// KeywordTrends lists up to `limit` (default 20, at most 100) keywords each
+// rising and falling in the last `days` (default 7) against the days before,
+// ignoring keywords asked fewer than `min_count` (default 3) times in both.
+func (h *AnalyticsHandler) KeywordTrends(c *gin.Context) {
+	days, ok := parseDays(c, 7)
+	if !ok {
+		return
+	}
+	limit := parseQueryInt(c, "limit", 20)
+	if limit <= 0 || limit > 100 {
+		BadRequestResponse(c, "limit은 1~100 사이여야 합니다")
+		return
+	}
+	minCount := parseQueryInt(c, "min_count", 3)
+	if minCount < 1 {
+		BadRequestResponse(c, "min_count는 1 이상이어야 합니다")
+		return
+	}
+
+	trends, err := h.service.KeywordTrends(c.Request.Context(), days, limit, minCount)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "키워드 추이 조회에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, trends)
+}
+
+// parseTime reads an RFC 3339 time or a YYYY-MM-DD date in h.loc, or def
+// when value is empty. A date is its midnight, or the next one with
+// endOfDay.
+func (h *AnalyticsHandler) parseTime(c *gin.Context, subject, value string, def time.Time, endOfDay bool) (time.Time, bool) {
+	if value == "" {
+		return def, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	day, err := time.ParseInLocation(time.DateOnly, value, h.loc)
+	if err != nil {
+		BadRequestResponse(c, subject+" RFC 3339 시각 또는 YYYY-MM-DD 형식이어야 합니다")
+		return time.Time{}, false
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, true
+}
+
+type knowledgeGapReportRequest struct {
+	// Days is how many days up to now the report covers, 7 by default.
+	Days int `json:"days"`
+}
+
+// CreateKnowledgeGapReport reports on the last days right away, besides
+// the weekly reports.
+func (h *AnalyticsHandler) CreateKnowledgeGapReport(c *gin.Context) {
+	var req knowledgeGapReportRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+			return
+		}
+	}
+	if req.Days == 0 {
+		req.Days = 7
+	}
+	if req.Days < 1 || req.Days > 90 {
+		ErrorResponse(c, http.StatusBadRequest, "INVALID_DAYS", "days는 1~90 사이여야 합니다")
+		return
+	}
+
+	to := time.Now().UTC()
+	report, err := h.service.GenerateKnowledgeGapReport(c.Request.Context(), to.AddDate(0, 0, -req.Days), to)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "지식 공백 보고서 생성에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, report)
+}
+
+// Dashboard combines document, conversation and chat activity figures for
+// the last `days` (default 1) with trends against the previous period.
+func (h *AnalyticsHandler) Dashboard(c *gin.Context) {
+	days, ok := parseDays(c, 1)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.GetDashboardStats(c.Request.Context(), time.Duration(days)*24*time.Hour)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "대시보드 통계 조회에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, stats)
+}
+
+// Users ranks the users who chatted most in the last `days` (default 30),
+// up to `limit` (default 10, at most 100).
+func (h *AnalyticsHandler) Users(c *gin.Context) {
+	days, ok := parseDays(c, 30)
+	if !ok {
+		return
+	}
+	limit := parseQueryInt(c, "limit", 10)
+	if limit <= 0 || limit > 100 {
+		BadRequestResponse(c, "limit은 1~100 사이여야 합니다")
+		return
+	}
+
+	users, err := h.service.TopUsers(c.Request.Context(), days, limit)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "사용자별 통계 조회에 실패했습니다")
+		return
+	}
+	if users == nil {
+		users = []service.UserUsage{}
+	}
+	SuccessResponse(c, gin.H{
+		"days":  days,
+		"users": users,
 	})
 }
+
+// TopDocuments ranks the documents used as sources in the last `days`
+// (default 30), by `sort` retrieved (default) or cited, up to `limit`
+// (default 20, at most 100).
+func (h *AnalyticsHandler) TopDocuments(c *gin.Context) {
+	days, ok := parseDays(c, 30)
+	if !ok {
+		return
+	}
+	limit := parseQueryInt(c, "limit", 20)
+	if limit <= 0 || limit > 100 {
+		BadRequestResponse(c, "limit은 1~100 사이여야 합니다")
+		return
+	}
+	sortBy := c.DefaultQuery("sort", "retrieved")
+	if sortBy != "retrieved" && sortBy != "cited" {
+		BadRequestResponse(c, "sort는 retrieved 또는 cited여야 합니다")
+		return
+	}
+
+	docs, err := h.service.TopDocuments(c.Request.Context(), days, limit, sortBy == "cited")
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "문서별 통계 조회에 실패했습니다")
+		return
+	}
+	if docs == nil {
+		docs = []service.DocumentHitStats{}
+	}
+	SuccessResponse(c, gin.H{
+		"days":      days,
+		"sort":      sortBy,
+		"documents": docs,
+	})
+}
+
+// UnusedDocuments lists documents, oldest first, that no answer retrieved
+// in the last `days` (default 30) although they were uploaded before.
+func (h *AnalyticsHandler) UnusedDocuments(c *gin.Context) {
+	days, ok := parseDays(c, 30)
+	if !ok {
+		return
+	}
+	limit := parseQueryInt(c, "limit", 50)
+	if limit <= 0 || limit > 200 {
+		BadRequestResponse(c, "limit은 1~200 사이여야 합니다")
+		return
+	}
+
+	docs, total, err := h.service.UnusedDocuments(c.Request.Context(), days, limit)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "검색되지 않은 문서 조회에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, gin.H{
+		"days":      days,
+		"total":     total,
+		"documents": docs,
+	})
+}
+
+// parseDays reads the `days` query parameter, 1~365.
+func parseDays(c *gin.Context, def int) (int, bool) {
+	v := c.Query("days")
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 || n > 365 {
+		ErrorResponse(c, http.StatusBadRequest, "INVALID_DAYS", "days는 1~365 사이여야 합니다")
+		return 0, false
+	}
+	return n, true
+}
+
+// DailyStats lists the recorded daily snapshots from through to
+// (YYYY-MM-DD, inclusive), the 30 days up to yesterday by default. Days
+// without a snapshot are left out.
+func (h *AnalyticsHandler) DailyStats(c *gin.Context) {
+	yesterday := scheduler.Day(time.Now(), h.loc).AddDate(0, 0, -1)
+	to, ok := h.parseDay(c, "to는", c.Query("to"), yesterday)
+	if !ok {
+		return
+	}
+	from, ok := h.parseDay(c, "from은", c.Query("from"), to.AddDate(0, 0, -29))
+	if !ok || !h.validDayRange(c, from, to) {
+		return
+	}
+
+	days, err := h.service.ListDailyStats(c.Request.Context(), from, to)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "일별 통계 조회에 실패했습니다")
+		return
+	}
+	if days == nil {
+		days = []service.DailyStatsSnapshot{}
+	}
+	SuccessResponse(c, gin.H{
+		"from":     from.Format(time.DateOnly),
+		"to":       to.Format(time.DateOnly),
+		"timezone": h.loc.String(),
+		"days":     days,
+	})
+}
+
+type backfillDailyStatsRequest struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+	// Overwrite recomputes days that already have a snapshot.
+	Overwrite bool `json:"overwrite"`
+}
+
+// BackfillDailyStats records the daily snapshots of past days from through
+// to, skipping recorded days unless overwrite.
+func (h *AnalyticsHandler) BackfillDailyStats(c *gin.Context) {
+	var req backfillDailyStatsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+	from, ok := h.parseDay(c, "from은", req.From, time.Time{})
+	if !ok {
+		return
+	}
+	to, ok := h.parseDay(c, "to는", req.To, time.Time{})
+	if !ok || !h.validDayRange(c, from, to) {
+		return
+	}
+	if !to.Before(scheduler.Day(time.Now(), h.loc)) {
+		BadRequestResponse(c, "to는 오늘 이전 날짜여야 합니다")
+		return
+	}
+
+	dates, err := h.service.BackfillDailyStats(c.Request.Context(), from, to, req.Overwrite)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "일별 통계 보충에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, gin.H{
+		"recorded": dates,
+		"count":    len(dates),
+	})
+}
+
+// parseDay reads a YYYY-MM-DD date as its midnight in h.loc, or def when
+// value is empty. subject names the field in the error message.
+func (h *AnalyticsHandler) parseDay(c *gin.Context, subject, value string, def time.Time) (time.Time, bool) {
+	if value == "" {
+		return def, true
+	}
+	day, err := time.ParseInLocation(time.DateOnly, value, h.loc)
+	if err != nil {
+		BadRequestResponse(c, subject+" YYYY-MM-DD 형식이어야 합니다")
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+func (h *AnalyticsHandler) validDayRange(c *gin.Context, from, to time.Time) bool {
+	if from.After(to) {
+		BadRequestResponse(c, "from은 to보다 늦을 수 없습니다")
+		return false
+	}
+	if to.Sub(from) >= service.MaxDailyStatsRange*24*time.Hour {
+		BadRequestResponse(c, fmt.Sprintf("기간은 최대 %d일입니다", service.MaxDailyStatsRange))
+		return false
+	}
+	return true
+}
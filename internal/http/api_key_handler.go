@@ -0,0 +1,131 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth"
+)
+
+type APIKeyHandler struct {
+	manager *auth.Manager
+}
+
+func NewAPIKeyHandler(manager *auth.Manager) *APIKeyHandler {
+	return &APIKeyHandler{manager: manager}
+}
+
+type createAPIKeyRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Scopes    []string `json:"scopes" binding:"required,min=1"`
+	Workspace string   `json:"workspace"`
+}
+
+type apiKeyResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	Workspace  string   `json:"workspace,omitempty"`
+	CreatedBy  string   `json:"createdBy,omitempty"`
+	CreatedAt  string   `json:"createdAt"`
+	LastUsedAt string   `json:"lastUsedAt,omitempty"`
+	Revoked    bool     `json:"revoked"`
+}
+
+func newAPIKeyResponse(k *auth.APIKey) apiKeyResponse {
+	resp := apiKeyResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		Workspace: k.Workspace,
+		CreatedBy: k.CreatedBy,
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+		Revoked:   k.RevokedAt != nil,
+	}
+	if k.LastUsedAt != nil {
+		resp.LastUsedAt = k.LastUsedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// Create issues a key. The plaintext key is only included in this response.
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	raw, key, err := h.manager.CreateAPIKey(req.Name, req.Scopes, req.Workspace, c.GetString("userID"))
+	if err != nil {
+		if errors.Is(err, auth.ErrAPIKeysNotConfigured) {
+			c.Error(err)
+			InternalServerErrorResponse(c, "API 키 저장소가 설정되지 않았습니다")
+			return
+		}
+		BadRequestResponse(c, err.Error())
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"key":    raw,
+		"apiKey": newAPIKeyResponse(key),
+	})
+}
+
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.manager.ListAPIKeys()
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "API 키 목록 조회에 실패했습니다")
+		return
+	}
+
+	resp := make([]apiKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		resp = append(resp, newAPIKeyResponse(k))
+	}
+	SuccessResponse(c, gin.H{"apiKeys": resp})
+}
+
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	if err := h.manager.RevokeAPIKey(c.Param("id")); err != nil {
+		if errors.Is(err, auth.ErrAPIKeyNotFound) {
+			NotFoundResponse(c, "API 키를 찾을 수 없습니다")
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "API 키 폐기에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, gin.H{"message": "API 키가 폐기되었습니다"})
+}
+
+// Usage returns per-key request counts for the last `days` days (default 30).
+func (h *APIKeyHandler) Usage(c *gin.Context) {
+	days := 30
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 365 {
+			ErrorResponse(c, http.StatusBadRequest, "INVALID_DAYS", "days는 1~365 사이여야 합니다")
+			return
+		}
+		days = n
+	}
+
+	usage, err := h.manager.APIKeyUsage(days)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "API 키 사용량 조회에 실패했습니다")
+		return
+	}
+	if usage == nil {
+		usage = []auth.APIKeyUsage{}
+	}
+	SuccessResponse(c, gin.H{"days": days, "usage": usage})
+}
@@ -1,24 +1,36 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"yuon/internal/auth"
+	"yuon/internal/mail"
 )
 
 type AuthHandler struct {
-	manager *auth.Manager
+	manager  *auth.Manager
+	mailer   mail.Mailer
+	resetURL string
 }
 
-func NewAuthHandler(manager *auth.Manager) *AuthHandler {
-	return &AuthHandler{manager: manager}
+func NewAuthHandler(manager *auth.Manager, mailer mail.Mailer, resetURL string) *AuthHandler {
+	if mailer == nil {
+		mailer = mail.LogMailer{}
+	}
+	return &AuthHandler{manager: manager, mailer: mailer, resetURL: resetURL}
 }
 
 type signupRequest struct {
-	Email    string `json:"email" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	Role     string `json:"role"`
+	InviteToken string `json:"inviteToken" binding:"required"`
+	Password    string `json:"password" binding:"required,min=6"`
 }
 
 type loginRequest struct {
@@ -26,6 +38,21 @@ type loginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// Signup completes an invitation. Email, role and workspace come from the
+// invitation, never from the request.
 func (h *AuthHandler) Signup(c *gin.Context) {
 	if h.manager == nil {
 		InternalServerErrorResponse(c, "인증 관리자가 설정되지 않았습니다")
@@ -34,24 +61,24 @@ func (h *AuthHandler) Signup(c *gin.Context) {
 
 	var req signupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		BadRequestResponse(c, "잘못된 요청 형식입니다")
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
 		return
 	}
 
-	token, user, err := h.manager.Signup(req.Email, req.Password, req.Role)
+	tokens, user, err := h.manager.SignupWithInvitation(req.InviteToken, req.Password)
 	if err != nil {
-		ErrorResponse(c, http.StatusBadRequest, "SIGNUP_FAILED", err.Error())
+		switch {
+		case errors.Is(err, auth.ErrInvalidInvitation):
+			ErrorResponse(c, http.StatusBadRequest, "INVALID_INVITATION", "초대가 유효하지 않거나 만료되었습니다")
+		case errors.Is(err, auth.ErrEmailTaken):
+			ErrorResponse(c, http.StatusConflict, "EMAIL_TAKEN", "이미 가입된 이메일입니다")
+		default:
+			ErrorResponse(c, http.StatusBadRequest, "SIGNUP_FAILED", err.Error())
+		}
 		return
 	}
 
-	SuccessResponse(c, gin.H{
-		"token": token,
-		"user": gin.H{
-			"id":    user.ID,
-			"email": user.Email,
-			"role":  user.Role,
-		},
-	})
+	SuccessResponse(c, tokenResponse(tokens, user))
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -62,22 +89,175 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	var req loginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		BadRequestResponse(c, "잘못된 요청 형식입니다")
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
 		return
 	}
 
-	jwtToken, user, err := h.manager.Login(req.Email, req.Password)
+	tokens, user, err := h.manager.Login(req.Email, req.Password, c.ClientIP())
+	var locked *auth.LoginLockedError
+	if errors.As(err, &locked) {
+		loginLockedResponse(c, locked)
+		return
+	}
+	if errors.Is(err, auth.ErrUserDisabled) {
+		userDisabledResponse(c)
+		return
+	}
 	if err != nil {
 		ErrorResponse(c, http.StatusUnauthorized, "INVALID_CREDENTIALS", err.Error())
 		return
 	}
 
-	SuccessResponse(c, gin.H{
-		"token": jwtToken,
+	if tokens.MFAToken != "" {
+		SuccessResponse(c, gin.H{
+			"mfaRequired": true,
+			"mfaToken":    tokens.MFAToken,
+		})
+		return
+	}
+
+	SuccessResponse(c, tokenResponse(tokens, user))
+}
+
+// Refresh rotates a refresh token and returns a new token pair.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	if h.manager == nil {
+		InternalServerErrorResponse(c, "인증 관리자가 설정되지 않았습니다")
+		return
+	}
+
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	tokens, user, err := h.manager.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidRefreshToken) {
+			ErrorResponse(c, http.StatusUnauthorized, "INVALID_REFRESH_TOKEN", "리프레시 토큰이 유효하지 않습니다")
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "토큰 갱신에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, tokenResponse(tokens, user))
+}
+
+// Logout revokes the given refresh token.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if h.manager == nil {
+		InternalServerErrorResponse(c, "인증 관리자가 설정되지 않았습니다")
+		return
+	}
+
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	if err := h.manager.RevokeRefreshToken(req.RefreshToken); err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "토큰 폐기에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{"revoked": true})
+}
+
+// ForgotPassword mails a reset link. The response is the same whether or not
+// the email is registered.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	token, user, err := h.manager.RequestPasswordReset(req.Email)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "비밀번호 재설정 요청에 실패했습니다")
+		return
+	}
+
+	if token != "" {
+		msg := mail.Message{
+			To:      user.Email,
+			Subject: "[YUON] 비밀번호 재설정 안내",
+			Body:    "아래 링크에서 비밀번호를 재설정하세요. 링크는 한 번만 사용할 수 있습니다.\n\n" + h.resetLink(token) + "\n\n요청하지 않았다면 이 메일을 무시하세요.\n",
+		}
+		// 응답 시간으로 가입 여부가 드러나지 않도록 비동기로 발송한다.
+		reqCtx := context.WithoutCancel(c.Request.Context())
+		go func() {
+			ctx, cancel := context.WithTimeout(reqCtx, 30*time.Second)
+			defer cancel()
+			if err := h.mailer.Send(ctx, msg); err != nil {
+				slog.ErrorContext(ctx, "비밀번호 재설정 메일 발송 실패", "error", err)
+			}
+		}()
+	}
+
+	SuccessResponse(c, gin.H{"message": "가입된 이메일이라면 재설정 링크가 발송됩니다"})
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	if err := h.manager.ResetPassword(req.Token, req.Password); err != nil {
+		if errors.Is(err, auth.ErrInvalidResetToken) {
+			ErrorResponse(c, http.StatusBadRequest, "INVALID_RESET_TOKEN", "재설정 토큰이 유효하지 않거나 만료되었습니다")
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "비밀번호 재설정에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{"message": "비밀번호가 변경되었습니다"})
+}
+
+func (h *AuthHandler) resetLink(token string) string {
+	u, err := url.Parse(h.resetURL)
+	if err != nil {
+		return h.resetURL + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func tokenResponse(tokens *auth.TokenPair, user *auth.User) gin.H {
+	resp := gin.H{
+		"token":     tokens.AccessToken,
+		"expiresIn": int64(tokens.ExpiresIn.Seconds()),
 		"user": gin.H{
 			"id":    user.ID,
 			"email": user.Email,
+			"name":  user.DisplayName(),
 			"role":  user.Role,
 		},
-	})
+	}
+	if tokens.RefreshToken != "" {
+		resp["refreshToken"] = tokens.RefreshToken
+	}
+	return resp
+}
+
+// loginLockedResponse answers 429 with Retry-After while login is throttled.
+func loginLockedResponse(c *gin.Context, locked *auth.LoginLockedError) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+	ErrorResponse(c, http.StatusTooManyRequests, "LOGIN_LOCKED", "로그인 시도가 너무 많습니다. 잠시 후 다시 시도해주세요")
+}
+
+func userDisabledResponse(c *gin.Context) {
+	ErrorResponse(c, http.StatusForbidden, "USER_DISABLED", "비활성화된 계정입니다. 관리자에게 문의하세요")
 }
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"yuon/configuration"
+	"yuon/internal/auth"
+)
+
+// noUsers is an auth.UserStore without any user; other methods are left
+// unimplemented.
+type noUsers struct {
+	auth.UserStore
+}
+
+func (noUsers) FindByEmail(ctx context.Context, email string) (*auth.User, error) {
+	return nil, auth.ErrUserNotFound
+}
+
+// memoryAttempts is an auth.LoginAttemptStore kept in memory.
+type memoryAttempts struct {
+	mu       sync.Mutex
+	attempts map[string]*auth.LoginAttempt
+}
+
+func (s *memoryAttempts) Get(ctx context.Context, scope, key string) (*auth.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.attempts[scope+"/"+key]; ok {
+		copied := *a
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (s *memoryAttempts) RecordFailure(ctx context.Context, scope, key string, window time.Duration) (*auth.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[scope+"/"+key]
+	if !ok {
+		a = &auth.LoginAttempt{Scope: scope, Key: key}
+		s.attempts[scope+"/"+key] = a
+	}
+	a.Failures++
+	a.LastFailureAt = time.Now()
+	copied := *a
+	return &copied, nil
+}
+
+func (s *memoryAttempts) Lock(ctx context.Context, scope, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[scope+"/"+key].LockedUntil = &until
+	return nil
+}
+
+func (s *memoryAttempts) Reset(ctx context.Context, scope, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, scope+"/"+key)
+	return nil
+}
+
+func (s *memoryAttempts) List(ctx context.Context, since time.Time) ([]*auth.LoginAttempt, error) {
+	return nil, nil
+}
+
+func TestLoginIPLockoutIgnoresForgedForwardedFor(t *testing.T) {
+	attempts := &memoryAttempts{attempts: make(map[string]*auth.LoginAttempt)}
+	manager := auth.NewManager("secret", noUsers{})
+	manager.SetLoginAttemptStore(attempts, auth.LockoutPolicy{
+		MaxFailures:      100,
+		MaxFailuresPerIP: 2,
+		BaseDelay:        time.Nanosecond,
+		MaxDelay:         time.Nanosecond,
+	})
+
+	cfg := &configuration.Config{Server: configuration.ServerConfig{Mode: "release"}}
+	engine := NewRouter(cfg, manager, nil).engine
+	engine.POST("/login", NewAuthHandler(manager, nil, "").Login)
+
+	login := func(email, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"`+email+`","password":"wrong-password"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.RemoteAddr = "203.0.113.7:4000"
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i, forwarded := range []string{"198.51.100.1", "198.51.100.2"} {
+		if code := login("user"+forwarded+"@example.com", forwarded); code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d = %d, want 401", i+1, code)
+		}
+	}
+	if code := login("fresh@example.com", "198.51.100.3"); code != http.StatusTooManyRequests {
+		t.Errorf("attempt with a new X-Forwarded-For = %d, want 429", code)
+	}
+	if a, _ := attempts.Get(context.Background(), auth.LoginScopeIP, "203.0.113.7"); a == nil || !a.Locked(time.Now()) {
+		t.Errorf("peer address not locked: %+v", a)
+	}
+	for _, forwarded := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+		if a, _ := attempts.Get(context.Background(), auth.LoginScopeIP, forwarded); a != nil {
+			t.Errorf("failures recorded for forged address %s", forwarded)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package http
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"yuon/internal/auth"
+	"yuon/internal/rag"
 )
 
 func authMiddleware(manager *auth.Manager) gin.HandlerFunc {
@@ -33,6 +35,114 @@ func authMiddleware(manager *auth.Manager) gin.HandlerFunc {
 
 		c.Set("userID", claims.Subject)
 		c.Set("userRole", claims.Role)
+		c.Set("userName", claims.Name)
+		c.Set("workspace", claims.Workspace)
+		if claims.Role == auth.RoleService {
+			c.Set("scopes", claims.Scopes)
+		}
+		c.Request = c.Request.WithContext(rag.WithWorkspace(c.Request.Context(), claims.Workspace))
+		c.Next()
+	}
+}
+
+// requireRoles must run after authMiddleware.
+func requireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("userRole")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "권한이 없습니다")
+		c.Abort()
+	}
+}
+
+// requirePermission must run after authMiddleware or apiKeyOrJWT. JWT users
+// are checked against their role's permissions, API keys and service accounts
+// against their scopes.
+func requirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v, ok := c.Get("scopes"); ok {
+			scopes, _ := v.([]string)
+			for _, scope := range scopes {
+				if scope == permission {
+					c.Next()
+					return
+				}
+			}
+			ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "'"+permission+"' 스코프가 없습니다")
+			c.Abort()
+			return
+		}
+
+		if auth.RoleHasPermission(c.GetString("userRole"), permission) {
+			c.Next()
+			return
+		}
+
+		ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "권한이 없습니다")
+		c.Abort()
+	}
+}
+
+// apiKeyOrJWT accepts either an `X-API-Key` header or a Bearer JWT. API key
+// requests are attributed to `apikey:<id>` and counted in key usage; pair it
+// with requirePermission to enforce the key's scopes.
+func apiKeyOrJWT(manager *auth.Manager) gin.HandlerFunc {
+	jwtAuth := authMiddleware(manager)
+	return func(c *gin.Context) {
+		raw := strings.TrimSpace(c.GetHeader("X-API-Key"))
+		if raw == "" {
+			jwtAuth(c)
+			return
+		}
+		if manager == nil {
+			InternalServerErrorResponse(c, "인증 구성이 설정되지 않았습니다")
+			c.Abort()
+			return
+		}
+
+		key, err := manager.ValidateAPIKey(raw)
+		if err != nil {
+			ErrorResponse(c, http.StatusUnauthorized, "INVALID_API_KEY", "API 키가 유효하지 않습니다")
+			c.Abort()
+			return
+		}
+
+		if err := manager.RecordAPIKeyUsage(c.Request.Context(), key.ID); err != nil {
+			c.Error(err)
+		}
+
+		c.Set("userID", "apikey:"+key.ID)
+		c.Set("userRole", "apikey")
+		c.Set("userName", key.Name)
+		c.Set("apiKeyID", key.ID)
+		c.Set("scopes", key.Scopes)
+		c.Set("workspace", key.Workspace)
+		c.Request = c.Request.WithContext(rag.WithWorkspace(c.Request.Context(), key.Workspace))
 		c.Next()
 	}
 }
+
+// metricsAuth guards /metrics. With a token configured, scrapers send it as
+// a Bearer token; otherwise a root or admin JWT is required.
+func metricsAuth(token string, manager *auth.Manager) []gin.HandlerFunc {
+	if token == "" {
+		return []gin.HandlerFunc{authMiddleware(manager), requireRoles(auth.RoleRoot, auth.RoleAdmin)}
+	}
+
+	return []gin.HandlerFunc{func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(strings.ToLower(header), "bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimSpace(header[7:])), []byte(token)) != 1 {
+			ErrorResponse(c, http.StatusUnauthorized, "UNAUTHENTICATED", "지표 토큰이 필요합니다")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}}
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth"
+)
+
+func TestMetricsAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := auth.NewManager("secret", noUsers{})
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{name: "token without header", token: "scrape", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "scrape", header: "Bearer other", want: http.StatusUnauthorized},
+		{name: "token", token: "scrape", header: "Bearer scrape", want: http.StatusOK},
+		{name: "token as basic auth", token: "scrape", header: "Basic scrape", want: http.StatusUnauthorized},
+		{name: "no token configured requires a JWT", want: http.StatusUnauthorized},
+		{name: "no token configured rejects other bearers", header: "Bearer scrape", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.GET("/metrics", append(metricsAuth(tt.token, manager), func(c *gin.Context) {
+				c.String(http.StatusOK, "ok")
+			})...)
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
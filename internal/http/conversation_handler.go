@@ -1,16 +1,87 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
 	"github.com/gin-gonic/gin"
+	"yuon/configuration"
+	"yuon/internal/auth"
 	"yuon/internal/rag/service"
+	"yuon/package/pagination"
 )
 
 type ConversationHandler struct {
 	service *service.ChatbotService
+	share   configuration.ShareConfig
+	// prices estimate the cost of answers, by model.
+	prices map[string]configuration.ModelPrice
+}
+
+func NewConversationHandler(svc *service.ChatbotService, share configuration.ShareConfig, prices map[string]configuration.ModelPrice) *ConversationHandler {
+	return &ConversationHandler{service: svc, share: share, prices: prices}
+}
+
+// conversationStaff reports whether role may read and manage every user's
+// conversations, over REST as well as by observing them over WebSocket.
+func conversationStaff(role string) bool {
+	switch role {
+	case auth.RoleRoot, auth.RoleAdmin, auth.RoleEditor:
+		return true
+	}
+	return false
+}
+
+// conversationOwner returns the user the caller's conversations are limited
+// to, or "" when it sees all of them: staff, and API keys or service
+// accounts whose chat scopes were granted by an administrator.
+func conversationOwner(c *gin.Context) string {
+	if _, scoped := c.Get("scopes"); scoped || conversationStaff(c.GetString("userRole")) {
+		return ""
+	}
+	return c.GetString("userID")
+}
+
+// conversation loads id for the caller. Conversations of other users, and
+// those without an owner for non-staff, answer 404 like missing ones so
+// their existence is not revealed.
+func (h *ConversationHandler) conversation(c *gin.Context, id string) (*service.ConversationSummary, bool) {
+	summary, err := h.service.GetConversationSummary(c.Request.Context(), id)
+	if errors.Is(err, service.ErrConversationNotFound) {
+		NotFoundResponse(c, "대화를 찾을 수 없습니다")
+		return nil, false
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "대화를 불러오지 못했습니다")
+		return nil, false
+	}
+	if owner := conversationOwner(c); owner != "" {
+		if summary.OwnerID != owner {
+			NotFoundResponse(c, "대화를 찾을 수 없습니다")
+			return nil, false
+		}
+		summary.Tags = nil
+	}
+	return summary, true
 }
 
-func NewConversationHandler(svc *service.ChatbotService) *ConversationHandler {
-	return &ConversationHandler{service: svc}
+// staffConversations limits conversation triage to callers that see every
+// conversation; tags are not shown to anyone else.
+func staffConversations(c *gin.Context) {
+	if conversationOwner(c) != "" {
+		ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "권한이 없습니다")
+		c.Abort()
+		return
+	}
+	c.Next()
 }
 
 func (h *ConversationHandler) List(c *gin.Context) {
@@ -18,7 +89,33 @@ func (h *ConversationHandler) List(c *gin.Context) {
 		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
 		return
 	}
-	items, err := h.service.ListConversationSummaries(c.Request.Context(), 100)
+	// Archived conversations are left out unless asked for with
+	// archived=true (only those) or archived=all.
+	filter := service.ConversationFilter{OwnerID: conversationOwner(c)}
+	archived, ok := parseFlagQuery(c, "archived")
+	if !ok {
+		return
+	}
+	if c.Query("archived") != "all" {
+		if archived == nil {
+			archived = new(bool)
+		}
+		filter.Archived = archived
+	}
+	if filter.Pinned, ok = parseFlagQuery(c, "pinned"); !ok {
+		return
+	}
+	if filter.Tags = parseQueryList(c, "tags"); len(filter.Tags) > 0 && filter.OwnerID != "" {
+		ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "태그 필터는 운영진만 사용할 수 있습니다")
+		return
+	}
+
+	limit := pagination.Limit(parseQueryInt(c, "limit", 100), 100)
+	items, page, err := h.service.ListConversationSummaries(c.Request.Context(), filter, limit, c.Query("cursor"))
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		BadRequestResponse(c, "유효하지 않은 cursor입니다")
+		return
+	}
 	if err != nil {
 		InternalServerErrorResponse(c, "대화 목록을 불러오지 못했습니다")
 		return
@@ -26,28 +123,399 @@ func (h *ConversationHandler) List(c *gin.Context) {
 
 	var resp []gin.H
 	for _, item := range items {
-		resp = append(resp, gin.H{
+		conv := gin.H{
 			"id":           item.ID,
-			"preview":      item.Preview,
+			"preview":      item.ListPreview(),
+			"summarizedAt": item.SummarizedAt,
 			"messageCount": item.MessageCount,
 			"createdAt":    item.CreatedAt,
 			"tokenUsage":   item.TokenUsage,
-		})
+			"ownerId":      item.OwnerID,
+			"ownerName":    item.OwnerName,
+			"pinned":       item.Pinned,
+			"archived":     item.Archived,
+		}
+		if filter.OwnerID == "" {
+			conv["tags"] = item.Tags
+		}
+		resp = append(resp, conv)
 	}
 
 	SuccessResponse(c, gin.H{
 		"conversations": resp,
+		"nextCursor":    page.NextCursor,
+		"hasMore":       page.HasMore,
 	})
 }
 
-func (h *ConversationHandler) Detail(c *gin.Context) {
+// parseFlagQuery reads an optional true/false query parameter; "all" and an
+// empty value give nil. It responds 400 itself.
+func parseFlagQuery(c *gin.Context, key string) (*bool, bool) {
+	switch c.Query(key) {
+	case "", "all":
+		return nil, true
+	case "true":
+		v := true
+		return &v, true
+	case "false":
+		v := false
+		return &v, true
+	}
+	BadRequestResponse(c, key+"는 true, false 또는 all이어야 합니다")
+	return nil, false
+}
+
+// Pin keeps a conversation marked for follow-up by support staff.
+func (h *ConversationHandler) Pin(c *gin.Context) {
+	h.setFlag(c, "pinned", true, "대화를 고정했습니다")
+}
+
+func (h *ConversationHandler) Unpin(c *gin.Context) {
+	h.setFlag(c, "pinned", false, "대화 고정을 해제했습니다")
+}
+
+// Archive hides a conversation from the default list; it stays searchable
+// and exportable.
+func (h *ConversationHandler) Archive(c *gin.Context) {
+	h.setFlag(c, "archived", true, "대화를 보관했습니다")
+}
+
+func (h *ConversationHandler) Unarchive(c *gin.Context) {
+	h.setFlag(c, "archived", false, "대화 보관을 해제했습니다")
+}
+
+func (h *ConversationHandler) setFlag(c *gin.Context, flag string, value bool, message string) {
 	if h.service == nil {
 		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
 		return
 	}
 
 	id := c.Param("id")
+	if _, ok := h.conversation(c, id); !ok {
+		return
+	}
+	var err error
+	if flag == "pinned" {
+		err = h.service.SetConversationPinned(c.Request.Context(), id, value)
+	} else {
+		err = h.service.SetConversationArchived(c.Request.Context(), id, value)
+	}
+	if errors.Is(err, service.ErrConversationNotFound) {
+		NotFoundResponse(c, "대화를 찾을 수 없습니다")
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "대화 상태 변경에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"id":      id,
+		flag:      value,
+		"message": message,
+	})
+}
+
+// Search finds conversations whose messages contain every word of q, for
+// support staff reviewing what users asked.
+func (h *ConversationHandler) Search(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		BadRequestResponse(c, "검색어(q)가 필요합니다")
+		return
+	}
+	if utf8.RuneCountInString(query) > 200 {
+		BadRequestResponse(c, "검색어는 200자 이하여야 합니다")
+		return
+	}
+
+	limit := pagination.Limit(parseQueryInt(c, "limit", 20), 100)
+	items, page, err := h.service.SearchConversations(c.Request.Context(), query, conversationOwner(c), limit, c.Query("cursor"))
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		BadRequestResponse(c, "유효하지 않은 cursor입니다")
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "대화 검색에 실패했습니다")
+		return
+	}
+
+	staff := conversationOwner(c) == ""
+	resp := make([]gin.H, 0, len(items))
+	for _, item := range items {
+		matches := make([]gin.H, 0, len(item.Matches))
+		for _, m := range item.Matches {
+			matches = append(matches, gin.H{
+				"role":      m.Role,
+				"snippet":   m.Snippet,
+				"timestamp": m.Timestamp,
+			})
+		}
+		conv := gin.H{
+			"id":           item.ID,
+			"preview":      item.ListPreview(),
+			"summarizedAt": item.SummarizedAt,
+			"messageCount": item.MessageCount,
+			"createdAt":    item.CreatedAt,
+			"updatedAt":    item.UpdatedAt,
+			"ownerId":      item.OwnerID,
+			"ownerName":    item.OwnerName,
+			"pinned":       item.Pinned,
+			"archived":     item.Archived,
+			"matchCount":   item.MatchCount,
+			"matches":      matches,
+		}
+		if staff {
+			conv["tags"] = item.Tags
+		}
+		resp = append(resp, conv)
+	}
+
+	SuccessResponse(c, gin.H{
+		"conversations": resp,
+		"nextCursor":    page.NextCursor,
+		"hasMore":       page.HasMore,
+	})
+}
+
+// Export streams every conversation as NDJSON: one line per conversation
+// with its summary fields and full message history. from and to restrict it
+// to conversations started in that range, for compliance archiving.
+func (h *ConversationHandler) Export(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+	filter, ok := parseConversationRange(c)
+	if !ok {
+		return
+	}
+	filter.OwnerID = conversationOwner(c)
+
+	ctx := c.Request.Context()
+	items, page, err := h.service.ListConversationSummaries(ctx, filter, exportPageSize, "")
+	if err != nil {
+		InternalServerErrorResponse(c, "대화 내보내기에 실패했습니다")
+		return
+	}
+
+	stream := newNDJSONStream(c, "conversations.ndjson")
+	for {
+		for _, item := range items {
+			if filter.OwnerID != "" {
+				item.Tags = nil
+			}
+			messages, err := h.service.GetConversationMessages(ctx, item.ID)
+			if err != nil {
+				stream.Fail(err, "대화 내보내기 중 오류가 발생했습니다")
+				return
+			}
+			if err := stream.Write(conversationTranscript(item, messages)); err != nil {
+				return
+			}
+		}
+		if !page.HasMore || page.NextCursor == "" {
+			break
+		}
+
+		if items, page, err = h.service.ListConversationSummaries(ctx, filter, exportPageSize, page.NextCursor); err != nil {
+			stream.Fail(err, "대화 내보내기 중 오류가 발생했습니다")
+			return
+		}
+	}
+	stream.Flush()
+}
+
+// ExportOne downloads a single conversation as a shareable transcript with
+// timestamps and the sources of each answer, in JSON (default) or Markdown.
+func (h *ConversationHandler) ExportOne(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "markdown" {
+		BadRequestResponse(c, "format은 json 또는 markdown이어야 합니다")
+		return
+	}
+
+	id := c.Param("id")
+	summary, ok := h.conversation(c, id)
+	if !ok {
+		return
+	}
 	messages, err := h.service.GetConversationMessages(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "대화 내보내기에 실패했습니다")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if format == "markdown" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "conversation-"+id+".md"))
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(conversationMarkdown(*summary, messages)))
+		return
+	}
+	body, err := json.MarshalIndent(conversationTranscript(*summary, messages), "", "  ")
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "대화 내보내기에 실패했습니다")
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "conversation-"+id+".json"))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// parseConversationRange reads the from/to query of exports, RFC3339 or a
+// date; a plain to date includes that whole day. It responds 400 itself.
+func parseConversationRange(c *gin.Context) (service.ConversationFilter, bool) {
+	var filter service.ConversationFilter
+	from, err := parseQueryTime(c, "from")
+	if err != nil {
+		BadRequestResponse(c, "from은 RFC3339 또는 YYYY-MM-DD 형식이어야 합니다")
+		return filter, false
+	}
+	to, err := parseQueryTime(c, "to")
+	if err != nil {
+		BadRequestResponse(c, "to는 RFC3339 또는 YYYY-MM-DD 형식이어야 합니다")
+		return filter, false
+	}
+	if to != nil && !strings.Contains(c.Query("to"), "T") {
+		end := to.AddDate(0, 0, 1)
+		to = &end
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		BadRequestResponse(c, "from은 to보다 이전이어야 합니다")
+		return filter, false
+	}
+	filter.CreatedFrom, filter.CreatedTo = from, to
+	return filter, true
+}
+
+// conversationTranscript is the JSON form of a conversation in exports.
+func conversationTranscript(item service.ConversationSummary, messages []service.ConversationMessage) gin.H {
+	msgs := make([]gin.H, 0, len(messages))
+	for _, m := range messages {
+		msg := gin.H{
+			"id":        m.ID,
+			"role":      m.Role,
+			"content":   m.Content,
+			"timestamp": m.Timestamp,
+		}
+		if len(m.Sources) > 0 {
+			msg["sources"] = m.Sources
+		}
+		if m.Redacted {
+			msg["redacted"] = true
+		}
+		msgs = append(msgs, msg)
+	}
+	transcript := gin.H{
+		"id":           item.ID,
+		"preview":      item.Preview,
+		"messageCount": item.MessageCount,
+		"createdAt":    item.CreatedAt,
+		"updatedAt":    item.UpdatedAt,
+		"tokenUsage":   item.TokenUsage,
+		"ownerId":      item.OwnerID,
+		"ownerName":    item.OwnerName,
+		"pinned":       item.Pinned,
+		"archived":     item.Archived,
+		"messages":     msgs,
+	}
+	if item.Tags != nil {
+		transcript["tags"] = item.Tags
+	}
+	if item.SummarizedAt != nil {
+		transcript["summary"] = item.Summary
+		transcript["actionItems"] = item.ActionItems
+		transcript["summarizedAt"] = item.SummarizedAt
+	}
+	return transcript
+}
+
+func conversationMarkdown(item service.ConversationSummary, messages []service.ConversationMessage) string {
+	var b strings.Builder
+	title := item.Preview
+	if title == "" {
+		title = item.ID
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "- 대화 ID: `%s`\n", item.ID)
+	if item.OwnerID != "" {
+		fmt.Fprintf(&b, "- 사용자: %s (`%s`)\n", item.OwnerName, item.OwnerID)
+	}
+	fmt.Fprintf(&b, "- 시작: %s\n", item.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "- 최근 갱신: %s\n", item.UpdatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "- 메시지 수: %d, 토큰 사용량: %d\n", item.MessageCount, item.TokenUsage)
+	if len(item.Tags) > 0 {
+		fmt.Fprintf(&b, "- 태그: %s\n", strings.Join(item.Tags, ", "))
+	}
+	if item.SummarizedAt != nil {
+		fmt.Fprintf(&b, "\n## 요약\n\n%s\n", item.Summary)
+		if len(item.ActionItems) > 0 {
+			b.WriteString("\n**할 일**\n\n")
+			for _, action := range item.ActionItems {
+				fmt.Fprintf(&b, "- %s\n", action)
+			}
+		}
+	}
+
+	for _, m := range messages {
+		speaker := "사용자"
+		if m.Role == "assistant" {
+			speaker = "챗봇"
+		}
+		fmt.Fprintf(&b, "\n---\n\n### %s · %s\n\n%s\n", speaker, m.Timestamp.UTC().Format(time.RFC3339), m.Content)
+		if len(m.Sources) > 0 {
+			b.WriteString("\n**출처**\n\n")
+			for _, src := range m.Sources {
+				name := src.Filename
+				if name == "" {
+					name = src.DocumentID
+				}
+				fmt.Fprintf(&b, "- %s (`%s`, 점수 %.2f)\n", name, src.DocumentID, src.Score)
+			}
+		}
+	}
+	return b.String()
+}
+
+func (h *ConversationHandler) Detail(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+
+	id := c.Param("id")
+	fields, ok := parseFields(c)
+	if !ok {
+		BadRequestResponse(c, fieldsFormatMessage)
+		return
+	}
+	order := c.DefaultQuery("order", "desc")
+	if order != "desc" && order != "asc" {
+		BadRequestResponse(c, "order는 desc 또는 asc여야 합니다")
+		return
+	}
+	if _, ok := h.conversation(c, id); !ok {
+		return
+	}
+
+	limit := pagination.Limit(parseQueryInt(c, "limit", 100), 100)
+	messages, page, err := h.service.ListConversationMessages(c.Request.Context(), id, limit, c.Query("cursor"), order == "asc")
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		BadRequestResponse(c, "유효하지 않은 cursor입니다")
+		return
+	}
 	if err != nil {
 		InternalServerErrorResponse(c, "대화 상세를 불러오지 못했습니다")
 		return
@@ -56,15 +524,76 @@ func (h *ConversationHandler) Detail(c *gin.Context) {
 	var resp []gin.H
 	for _, m := range messages {
 		resp = append(resp, gin.H{
+			"id":        m.ID,
 			"role":      m.Role,
 			"content":   m.Content,
 			"timestamp": m.Timestamp,
+			"redacted":  m.Redacted,
 		})
 	}
 
+	data, err := fields.apply(gin.H{"id": id, "messages": resp, "nextCursor": page.NextCursor, "hasMore": page.HasMore}, "messages")
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "응답 생성에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, data)
+}
+
+// Usage breaks the tokens of a conversation down by answer, with their
+// estimated cost from OPENAI_MODEL_PRICES.
+func (h *ConversationHandler) Usage(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+
+	id := c.Param("id")
+	if _, ok := h.conversation(c, id); !ok {
+		return
+	}
+	usage, err := h.service.ConversationTokenUsage(c.Request.Context(), id, h.prices)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "토큰 사용량을 불러오지 못했습니다")
+		return
+	}
+	SuccessResponse(c, usage)
+}
+
+// Summarize generates and stores a summary with action items of the
+// conversation, which lists then show as its preview.
+func (h *ConversationHandler) Summarize(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+
+	id := c.Param("id")
+	if _, ok := h.conversation(c, id); !ok {
+		return
+	}
+	item, err := h.service.SummarizeConversation(c.Request.Context(), id)
+	if errors.Is(err, service.ErrConversationEmpty) {
+		BadRequestResponse(c, "요약할 메시지가 없습니다")
+		return
+	}
+	if errors.Is(err, service.ErrConversationNotFound) {
+		NotFoundResponse(c, "대화를 찾을 수 없습니다")
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "대화 요약에 실패했습니다")
+		return
+	}
+
 	SuccessResponse(c, gin.H{
-		"id":       id,
-		"messages": resp,
+		"id":           item.ID,
+		"summary":      item.Summary,
+		"actionItems":  item.ActionItems,
+		"summarizedAt": item.SummarizedAt,
 	})
 }
 
@@ -79,6 +608,9 @@ func (h *ConversationHandler) Delete(c *gin.Context) {
 		BadRequestResponse(c, "대화 ID가 필요합니다")
 		return
 	}
+	if _, ok := h.conversation(c, id); !ok {
+		return
+	}
 
 	if err := h.service.DeleteConversation(c.Request.Context(), id); err != nil {
 		InternalServerErrorResponse(c, err.Error())
@@ -89,3 +621,206 @@ func (h *ConversationHandler) Delete(c *gin.Context) {
 		"message": "대화가 삭제되었습니다",
 	})
 }
+
+// DeleteMessage removes one message, e.g. personal data a user pasted into
+// the chat.
+func (h *ConversationHandler) DeleteMessage(c *gin.Context) {
+	h.changeMessage(c, h.service.DeleteConversationMessage, "메시지가 삭제되었습니다")
+}
+
+// RedactMessage replaces the content of one message with a placeholder and
+// keeps it in the conversation, so counts and the exchange stay intact.
+func (h *ConversationHandler) RedactMessage(c *gin.Context) {
+	h.changeMessage(c, h.service.RedactConversationMessage, "메시지 내용이 가려졌습니다")
+}
+
+func (h *ConversationHandler) changeMessage(c *gin.Context, change func(ctx context.Context, id, messageID string) error, message string) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+
+	id, messageID := c.Param("id"), c.Param("messageId")
+	if _, ok := h.conversation(c, id); !ok {
+		return
+	}
+	err := change(c.Request.Context(), id, messageID)
+	if errors.Is(err, service.ErrMessageNotFound) {
+		NotFoundResponse(c, "메시지를 찾을 수 없습니다")
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "메시지 변경에 실패했습니다")
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), message,
+		"conversation_id", id, "message_id", messageID, "by", c.GetString("userID"))
+	SuccessResponse(c, gin.H{
+		"id":        id,
+		"messageId": messageID,
+		"message":   message,
+	})
+}
+
+type conversationTagsRequest struct {
+	Tags []string `json:"tags" binding:"required,min=1"`
+}
+
+type renameConversationTagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+var conversationTagMessage = fmt.Sprintf("태그는 %d자 이하여야 하며 비어 있거나 쉼표를 포함할 수 없습니다", service.MaxConversationTagLength)
+
+// Tags lists the tags in use with how many conversations have each.
+func (h *ConversationHandler) Tags(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+	tags, err := h.service.ListConversationTags(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "태그 목록을 불러오지 못했습니다")
+		return
+	}
+	if tags == nil {
+		tags = []service.ConversationTagCount{}
+	}
+	SuccessResponse(c, gin.H{"tags": tags})
+}
+
+func (h *ConversationHandler) AddTags(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+	var req conversationTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "tags 목록이 필요합니다")
+		return
+	}
+
+	id := c.Param("id")
+	if _, ok := h.conversation(c, id); !ok {
+		return
+	}
+	err := h.service.AddConversationTags(c.Request.Context(), id, req.Tags, c.GetString("userID"))
+	if errors.Is(err, service.ErrInvalidTag) {
+		BadRequestResponse(c, conversationTagMessage)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "태그 추가에 실패했습니다")
+		return
+	}
+	h.respondTags(c, id)
+}
+
+func (h *ConversationHandler) RemoveTag(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+
+	id := c.Param("id")
+	if _, ok := h.conversation(c, id); !ok {
+		return
+	}
+	err := h.service.RemoveConversationTag(c.Request.Context(), id, c.Param("tag"))
+	if errors.Is(err, service.ErrTagNotFound) {
+		NotFoundResponse(c, "태그를 찾을 수 없습니다")
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "태그 삭제에 실패했습니다")
+		return
+	}
+	h.respondTags(c, id)
+}
+
+// respondTags answers with the current tags of conversation id.
+func (h *ConversationHandler) respondTags(c *gin.Context, id string) {
+	summary, err := h.service.GetConversationSummary(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "대화를 불러오지 못했습니다")
+		return
+	}
+	SuccessResponse(c, gin.H{"id": id, "tags": summary.Tags})
+}
+
+// RenameTag renames a tag on every conversation, merging it into an existing
+// tag of the new name.
+func (h *ConversationHandler) RenameTag(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+	var req renameConversationTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "새 태그 이름(name)이 필요합니다")
+		return
+	}
+
+	tag := c.Param("tag")
+	name := strings.TrimSpace(req.Name)
+	if name == tag {
+		BadRequestResponse(c, "새 태그 이름이 기존 이름과 같습니다")
+		return
+	}
+	count, err := h.service.RenameConversationTag(c.Request.Context(), tag, name)
+	if errors.Is(err, service.ErrInvalidTag) {
+		BadRequestResponse(c, conversationTagMessage)
+		return
+	}
+	if errors.Is(err, service.ErrTagNotFound) {
+		NotFoundResponse(c, "태그를 찾을 수 없습니다")
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "태그 이름 변경에 실패했습니다")
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "대화 태그 이름 변경",
+		"tag", tag, "name", name, "count", count, "by", c.GetString("userID"))
+	SuccessResponse(c, gin.H{
+		"tag":     name,
+		"count":   count,
+		"message": "태그 이름이 변경되었습니다",
+	})
+}
+
+// DeleteTag removes a tag from every conversation.
+func (h *ConversationHandler) DeleteTag(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+
+	tag := c.Param("tag")
+	count, err := h.service.DeleteConversationTag(c.Request.Context(), tag)
+	if errors.Is(err, service.ErrTagNotFound) {
+		NotFoundResponse(c, "태그를 찾을 수 없습니다")
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "태그 삭제에 실패했습니다")
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "대화 태그 삭제",
+		"tag", tag, "count", count, "by", c.GetString("userID"))
+	SuccessResponse(c, gin.H{
+		"tag":     tag,
+		"count":   count,
+		"message": "태그가 삭제되었습니다",
+	})
+}
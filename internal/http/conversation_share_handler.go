@@ -0,0 +1,238 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/rag/service"
+)
+
+type createShareRequest struct {
+	// ExpiresInHours overrides CONVERSATION_SHARE_TTL, up to
+	// CONVERSATION_SHARE_MAX_TTL.
+	ExpiresInHours int `json:"expiresInHours"`
+}
+
+// CreateShare creates a public read-only link to the conversation. The
+// token is only included in this response.
+func (h *ConversationHandler) CreateShare(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+	var req createShareRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+			return
+		}
+	}
+	ttl := h.share.TTL
+	if req.ExpiresInHours != 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	if ttl <= 0 || ttl > h.share.MaxTTL {
+		BadRequestResponse(c, "expiresInHours는 1 이상 "+h.share.MaxTTL.String()+" 이하여야 합니다")
+		return
+	}
+
+	id := c.Param("id")
+	if _, ok := h.conversation(c, id); !ok {
+		return
+	}
+	token, share, err := h.service.CreateConversationShare(c.Request.Context(), id, c.GetString("userID"), ttl)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "공유 링크 생성에 실패했습니다")
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "대화 공유 링크 생성",
+		"conversation_id", id, "share_id", share.ID, "expires_at", share.ExpiresAt, "by", c.GetString("userID"))
+	SuccessResponse(c, gin.H{
+		"token": token,
+		"url":   h.shareURL(token),
+		"share": share,
+	})
+}
+
+// shareURL is the public link for token, on CONVERSATION_SHARE_URL or
+// relative to this server.
+func (h *ConversationHandler) shareURL(token string) string {
+	base := strings.TrimRight(h.share.URL, "/")
+	if base == "" {
+		base = "/share"
+	}
+	return base + "/" + token
+}
+
+func (h *ConversationHandler) Shares(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+
+	id := c.Param("id")
+	if _, ok := h.conversation(c, id); !ok {
+		return
+	}
+	shares, err := h.service.ListConversationShares(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "공유 링크 목록을 불러오지 못했습니다")
+		return
+	}
+	if shares == nil {
+		shares = []service.ConversationShare{}
+	}
+	SuccessResponse(c, gin.H{"shares": shares})
+}
+
+func (h *ConversationHandler) RevokeShare(c *gin.Context) {
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+
+	id, shareID := c.Param("id"), c.Param("shareId")
+	if _, ok := h.conversation(c, id); !ok {
+		return
+	}
+	err := h.service.RevokeConversationShare(c.Request.Context(), id, shareID)
+	if errors.Is(err, service.ErrShareNotFound) {
+		NotFoundResponse(c, "공유 링크를 찾을 수 없거나 이미 취소되었습니다")
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "공유 링크 취소에 실패했습니다")
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "대화 공유 링크 취소",
+		"conversation_id", id, "share_id", shareID, "by", c.GetString("userID"))
+	SuccessResponse(c, gin.H{
+		"id":      shareID,
+		"message": "공유 링크가 취소되었습니다",
+	})
+}
+
+// sharedMessage is a message as shown on a share page, without ids or
+// anything about its owner.
+type sharedMessage struct {
+	Speaker   string    `json:"-"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+	Sources   []string  `json:"sources,omitempty"`
+}
+
+var sharePage = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="ko">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>{{.Title}} · 공유된 대화</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 760px; margin: 2rem auto; padding: 0 1rem; color: #1f2328; }
+h1 { font-size: 1.4rem; }
+.meta { color: #656d76; font-size: .9rem; }
+.msg { border-radius: 8px; padding: .75rem 1rem; margin: 1rem 0; white-space: pre-wrap; }
+.user { background: #ddf4ff; }
+.assistant { background: #f6f8fa; }
+.who { font-weight: 600; margin-bottom: .25rem; white-space: normal; }
+.sources { color: #656d76; font-size: .85rem; margin-top: .5rem; white-space: normal; }
+</style>
+</head>
+<body>
+{{if .Missing}}
+<h1>공유 링크를 열 수 없습니다</h1>
+<p>링크가 만료되었거나 취소되었습니다.</p>
+{{else}}
+<h1>{{.Title}}</h1>
+<p class="meta">읽기 전용으로 공유된 대화입니다 · {{.CreatedAt.Format "2006-01-02 15:04"}} 시작</p>
+{{range .Messages}}
+<div class="msg {{.Role}}"><div class="who">{{.Speaker}} · {{.Timestamp.Format "2006-01-02 15:04"}}</div>{{.Content}}{{if .Sources}}<div class="sources">출처: {{range $i, $s := .Sources}}{{if $i}}, {{end}}{{$s}}{{end}}</div>{{end}}</div>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// ViewShare renders the read-only transcript behind a share token, as HTML
+// or, with format=json, as JSON. It needs no account; unknown, expired and
+// revoked links all answer 404.
+func (h *ConversationHandler) ViewShare(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	asJSON := c.Query("format") == "json"
+
+	if h.service == nil {
+		InternalServerErrorResponse(c, "대화 서비스가 구성되지 않았습니다")
+		return
+	}
+	summary, messages, err := h.service.SharedConversation(c.Request.Context(), c.Param("token"))
+	if errors.Is(err, service.ErrShareNotFound) || errors.Is(err, service.ErrConversationNotFound) {
+		if asJSON {
+			NotFoundResponse(c, "공유 링크가 만료되었거나 취소되었습니다")
+			return
+		}
+		h.renderShare(c, http.StatusNotFound, gin.H{"Missing": true, "Title": "공유 링크"})
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "공유된 대화를 불러오지 못했습니다")
+		return
+	}
+
+	title := summary.Preview
+	if title == "" {
+		title = "대화"
+	}
+	shared := make([]sharedMessage, 0, len(messages))
+	for _, m := range messages {
+		msg := sharedMessage{Speaker: "사용자", Role: m.Role, Content: m.Content, Timestamp: m.Timestamp}
+		if m.Role == "assistant" {
+			msg.Speaker = "챗봇"
+		}
+		for _, src := range m.Sources {
+			if src.Filename != "" {
+				msg.Sources = append(msg.Sources, src.Filename)
+			}
+		}
+		shared = append(shared, msg)
+	}
+
+	if asJSON {
+		SuccessResponse(c, gin.H{
+			"title":     title,
+			"createdAt": summary.CreatedAt,
+			"messages":  shared,
+		})
+		return
+	}
+	h.renderShare(c, http.StatusOK, gin.H{
+		"Title":     title,
+		"CreatedAt": summary.CreatedAt,
+		"Messages":  shared,
+	})
+}
+
+func (h *ConversationHandler) renderShare(c *gin.Context, status int, data gin.H) {
+	var buf bytes.Buffer
+	if err := sharePage.Execute(&buf, data); err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "공유 페이지 생성에 실패했습니다")
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
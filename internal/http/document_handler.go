@@ -2,51 +2,126 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
+	"log/slog"
 	"net/http"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"yuon/configuration"
+	"yuon/internal/antivirus"
+	"yuon/internal/audit"
+	"yuon/internal/auth"
 	"yuon/internal/rag"
 	"yuon/internal/rag/search"
 	"yuon/internal/rag/service"
+	"yuon/internal/rag/vectorstore"
 	"yuon/internal/storage"
 	"yuon/internal/textextract"
+	"yuon/internal/upload"
+	"yuon/internal/webhook"
+	"yuon/package/validator"
 )
 
 type DocumentHandler struct {
 	service *service.ChatbotService
 	storage storage.FileStorage
+	schema  *validator.MetadataSchema
+	uploads upload.Store
+	scanner antivirus.Scanner
+	audit   audit.Logger
+	events  *webhook.Dispatcher
+	// progress, when set, receives ingestion progress for WebSocket clients.
+	progress *wsHub
+
+	maxResumableSize int64
 }
 
-func NewDocumentHandler(service *service.ChatbotService, storage storage.FileStorage) *DocumentHandler {
+func NewDocumentHandler(
+	service *service.ChatbotService,
+	storage storage.FileStorage,
+	cfg *configuration.DocumentConfig,
+	scanner antivirus.Scanner,
+	auditLogger audit.Logger,
+	events *webhook.Dispatcher,
+) *DocumentHandler {
 	return &DocumentHandler{
 		service: service,
 		storage: storage,
+		schema: &validator.MetadataSchema{
+			Required:   cfg.RequiredMetadata,
+			Categories: cfg.Categories,
+			Types:      cfg.MetadataTypes,
+		},
+		scanner:          scanner,
+		audit:            auditLogger,
+		events:           events,
+		maxResumableSize: int64(cfg.MaxResumableUploadMB) * 1024 * 1024,
 	}
 }
 
+// setUploadSessions enables resumable uploads, keeping their sessions in
+// store.
+func (h *DocumentHandler) setUploadSessions(store upload.Store) {
+	h.uploads = store
+}
+
+// setIngestionProgress pushes upload and bulk ingest progress to the
+// WebSocket clients of hub that subscribed to it.
+func (h *DocumentHandler) setIngestionProgress(hub *wsHub) {
+	h.progress = hub
+}
+
 func (h *DocumentHandler) ListDocuments(c *gin.Context) {
 	page := parseQueryInt(c, "page", 1)
 	pageSize := parseQueryInt(c, "pageSize", 20)
 
 	params := &rag.DocumentListParams{
-		Page:     page,
-		PageSize: pageSize,
-		Query:    c.Query("q"),
-		Category: c.Query("category"),
+		Page:      page,
+		PageSize:  pageSize,
+		Query:     c.Query("q"),
+		SortBy:    c.Query("sortBy"),
+		SortOrder: strings.ToLower(c.Query("sortOrder")),
+		Cursor:    c.Query("cursor"),
+	}
+
+	if params.SortBy != "" && !search.IsValidSortField(params.SortBy) {
+		BadRequestResponse(c, "sortBy는 score, createdAt, updatedAt, filename, size 중 하나여야 합니다")
+		return
+	}
+	if params.SortOrder != "" && params.SortOrder != "asc" && params.SortOrder != "desc" {
+		BadRequestResponse(c, "sortOrder는 asc 또는 desc여야 합니다")
+		return
+	}
+
+	filters, ok := parseDocumentFilters(c)
+	if !ok {
+		return
+	}
+	params.SearchFilters = filters
+
+	fields, ok := parseFields(c)
+	if !ok {
+		BadRequestResponse(c, fieldsFormatMessage)
+		return
 	}
 
 	result, err := h.service.ListDocuments(c.Request.Context(), params)
 	if err != nil {
+		if errors.Is(err, search.ErrInvalidCursor) {
+			BadRequestResponse(c, "유효하지 않은 cursor입니다")
+			return
+		}
 		InternalServerErrorResponse(c, "문서 목록 조회에 실패했습니다")
 		return
 	}
@@ -55,26 +130,189 @@ func (h *DocumentHandler) ListDocuments(c *gin.Context) {
 		populateFileFields(&result.Documents[i])
 	}
 
-	SuccessResponse(c, result)
+	data, err := fields.apply(result, "documents")
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "응답 생성에 실패했습니다")
+		return
+	}
+	SuccessResponseWithETag(c, data)
+}
+
+// parseDocumentFilters reads category, tags and the upload date range, and
+// restricts non-admin callers to documents their role may see. It responds
+// with 400 and returns false on an unknown category or malformed dates.
+func parseDocumentFilters(c *gin.Context) (rag.SearchFilters, bool) {
+	var query struct {
+		Category string `form:"category" binding:"omitempty,category"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		BindErrorResponse(c, err, "잘못된 검색 조건입니다")
+		return rag.SearchFilters{}, false
+	}
+	uploadedAfter, err := parseQueryTime(c, "uploadedAfter")
+	if err != nil {
+		BadRequestResponse(c, "uploadedAfter는 RFC3339 또는 YYYY-MM-DD 형식이어야 합니다")
+		return rag.SearchFilters{}, false
+	}
+	uploadedBefore, err := parseQueryTime(c, "uploadedBefore")
+	if err != nil {
+		BadRequestResponse(c, "uploadedBefore는 RFC3339 또는 YYYY-MM-DD 형식이어야 합니다")
+		return rag.SearchFilters{}, false
+	}
+	filters := rag.SearchFilters{
+		Category:       query.Category,
+		Tags:           parseQueryList(c, "tags"),
+		UploadedAfter:  uploadedAfter,
+		UploadedBefore: uploadedBefore,
+	}
+	filters.Roles = callerDocumentRoles(c)
+	return filters, true
+}
+
+// documentRoles returns the roles whose documents a caller with role may
+// see, for SearchFilters.Roles: nil for root and admin, who see them all.
+func documentRoles(role string) []string {
+	if role == auth.RoleRoot || role == auth.RoleAdmin {
+		return nil
+	}
+	return []string{role}
+}
+
+// callerDocumentRoles is documentRoles for the authenticated caller.
+func callerDocumentRoles(c *gin.Context) []string {
+	return documentRoles(c.GetString("userRole"))
+}
+
+// visibleDocument loads document id for the caller. It responds with 404
+// when the document does not exist or the caller's role may not see it,
+// so restricted documents are indistinguishable from missing ones.
+func (h *DocumentHandler) visibleDocument(c *gin.Context, id string) (*rag.Document, bool) {
+	doc, err := h.service.GetDocument(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, search.ErrDocumentNotFound) {
+			NotFoundResponse(c, "문서를 찾을 수 없습니다")
+			return nil, false
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "문서 조회에 실패했습니다")
+		return nil, false
+	}
+	if !rag.RolesAllow(doc.Metadata, callerDocumentRoles(c)) {
+		NotFoundResponse(c, "문서를 찾을 수 없습니다")
+		return nil, false
+	}
+	return doc, true
+}
+
+// replaceableDocument reports whether the caller may write a document under
+// id: it does not exist yet or the caller can see it. A hidden document is
+// answered as not found, like visibleDocument, so creating under its ID
+// cannot replace it or its allowedRoles.
+func (h *DocumentHandler) replaceableDocument(c *gin.Context, id string) bool {
+	existing, err := h.service.GetDocument(c.Request.Context(), id)
+	if errors.Is(err, search.ErrDocumentNotFound) {
+		return true
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "문서 조회에 실패했습니다")
+		return false
+	}
+	if !rag.RolesAllow(existing.Metadata, callerDocumentRoles(c)) {
+		NotFoundResponse(c, "문서를 찾을 수 없습니다")
+		return false
+	}
+	return true
+}
+
+// ExportDocuments streams every document matching the list filters as
+// NDJSON, one document per line, paging through the index with cursors.
+func (h *DocumentHandler) ExportDocuments(c *gin.Context) {
+	filters, ok := parseDocumentFilters(c)
+	if !ok {
+		return
+	}
+	params := &rag.DocumentListParams{
+		Page:          1,
+		PageSize:      exportPageSize,
+		Query:         c.Query("q"),
+		SearchFilters: filters,
+	}
+
+	ctx := c.Request.Context()
+	result, err := h.service.ListDocuments(ctx, params)
+	if err != nil {
+		InternalServerErrorResponse(c, "문서 내보내기에 실패했습니다")
+		return
+	}
+
+	stream := newNDJSONStream(c, "documents.ndjson")
+	for {
+		for i := range result.Documents {
+			populateFileFields(&result.Documents[i])
+			if err := stream.Write(result.Documents[i]); err != nil {
+				return
+			}
+		}
+		if !result.HasMore || result.NextCursor == "" {
+			break
+		}
+
+		params.Cursor = result.NextCursor
+		if result, err = h.service.ListDocuments(ctx, params); err != nil {
+			stream.Fail(err, "문서 내보내기 중 오류가 발생했습니다")
+			return
+		}
+	}
+	stream.Flush()
+}
+
+func (h *DocumentHandler) SuggestDocuments(c *gin.Context) {
+	limit := parseQueryInt(c, "limit", 10)
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	suggestions, err := h.service.SuggestDocuments(c.Request.Context(), c.Query("q"), limit, callerDocumentRoles(c))
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "자동완성 조회에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"suggestions": suggestions,
+	})
 }
 
 func (h *DocumentHandler) CreateDocument(c *gin.Context) {
 	var doc rag.Document
 	if err := c.ShouldBindJSON(&doc); err != nil {
-		BadRequestResponse(c, "잘못된 문서 형식입니다")
+		BindErrorResponse(c, err, "잘못된 문서 형식입니다")
 		return
 	}
 
 	if doc.ID == "" {
 		doc.ID = uuid.New().String()
+	} else if !h.replaceableDocument(c, doc.ID) {
+		return
 	}
 	ensureMetadata(&doc)
 
+	if !h.validateMetadata(c, doc.Metadata) {
+		return
+	}
+
 	if err := h.service.AddDocument(c.Request.Context(), doc); err != nil {
 		c.Error(err) // Log the actual error
 		InternalServerErrorResponse(c, fmt.Sprintf("문서 생성에 실패했습니다: %v", err))
 		return
 	}
+	h.events.Publish(c.Request.Context(), webhook.EventDocumentCreated, gin.H{
+		"id":       doc.ID,
+		"metadata": doc.Metadata,
+	})
 
 	SuccessResponse(c, gin.H{
 		"id":      doc.ID,
@@ -85,7 +323,7 @@ func (h *DocumentHandler) CreateDocument(c *gin.Context) {
 func (h *DocumentHandler) BulkIngestDocuments(c *gin.Context) {
 	var docs []rag.Document
 	if err := c.ShouldBindJSON(&docs); err != nil {
-		BadRequestResponse(c, "잘못된 문서 형식입니다")
+		BindErrorResponse(c, err, "잘못된 문서 형식입니다")
 		return
 	}
 
@@ -94,17 +332,47 @@ func (h *DocumentHandler) BulkIngestDocuments(c *gin.Context) {
 		return
 	}
 
+	var invalid []validator.ValidationError
 	for i := range docs {
 		if docs[i].ID == "" {
 			docs[i].ID = uuid.New().String()
+		} else if !h.replaceableDocument(c, docs[i].ID) {
+			return
 		}
 		ensureMetadata(&docs[i])
+
+		for _, e := range validator.ValidateMetadata(h.schema, docs[i].Metadata) {
+			e.Field = fmt.Sprintf("%d.%s", i, e.Field)
+			invalid = append(invalid, e)
+		}
+	}
+	if len(invalid) > 0 {
+		ValidationErrorResponse(c, "문서 메타데이터가 스키마와 일치하지 않습니다", invalid)
+		return
+	}
+
+	ids := make([]string, len(docs))
+	for i := range docs {
+		ids[i] = docs[i].ID
 	}
 
+	job := h.startIngestion(c, "")
+	defer job.finish(c)
+
 	if err := h.service.BulkAddDocuments(c.Request.Context(), docs); err != nil {
+		h.events.Publish(c.Request.Context(), webhook.EventIngestionFinished, gin.H{
+			"status":      "failed",
+			"count":       len(docs),
+			"documentIds": ids,
+		})
 		InternalServerErrorResponse(c, "벌크 문서 추가에 실패했습니다")
 		return
 	}
+	h.events.Publish(c.Request.Context(), webhook.EventIngestionFinished, gin.H{
+		"status":      "succeeded",
+		"count":       len(docs),
+		"documentIds": ids,
+	})
 
 	SuccessResponse(c, gin.H{
 		"message": "문서가 성공적으로 추가되었습니다",
@@ -114,18 +382,85 @@ func (h *DocumentHandler) BulkIngestDocuments(c *gin.Context) {
 
 func (h *DocumentHandler) GetDocument(c *gin.Context) {
 	id := c.Param("id")
-	doc, err := h.service.GetDocument(c.Request.Context(), id)
-	if err != nil {
-		if errors.Is(err, search.ErrDocumentNotFound) {
-			NotFoundResponse(c, "문서를 찾을 수 없습니다")
-			return
-		}
-		InternalServerErrorResponse(c, "문서 조회에 실패했습니다")
+	fields, ok := parseFields(c)
+	if !ok {
+		BadRequestResponse(c, fieldsFormatMessage)
+		return
+	}
+
+	doc, ok := h.visibleDocument(c, id)
+	if !ok {
 		return
 	}
 
 	populateFileFields(doc)
-	SuccessResponse(c, doc)
+	data, err := fields.apply(doc, "")
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "응답 생성에 실패했습니다")
+		return
+	}
+	SuccessResponseWithETag(c, data)
+}
+
+const (
+	defaultPreviewLength = 500
+	maxPreviewLength     = 5000
+	previewURLExpiry     = 15 * time.Minute
+
+	defaultDownloadURLExpiry = 5 * time.Minute
+	maxDownloadURLExpiry     = time.Hour
+)
+
+var previewMetadataKeys = []string{"title", "category", "filename", "contentType", "uploadedAt", "keywords"}
+
+func (h *DocumentHandler) PreviewDocument(c *gin.Context) {
+	doc, ok := h.visibleDocument(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	length := parseQueryInt(c, "length", defaultPreviewLength)
+	if length <= 0 {
+		length = defaultPreviewLength
+	}
+	if length > maxPreviewLength {
+		length = maxPreviewLength
+	}
+
+	runes := []rune(doc.Content)
+	preview := rag.DocumentPreview{
+		ID:            doc.ID,
+		ContentLength: len(runes),
+		Metadata:      map[string]interface{}{},
+	}
+	if len(runes) > length {
+		preview.Excerpt = string(runes[:length])
+		preview.Truncated = true
+	} else {
+		preview.Excerpt = doc.Content
+	}
+
+	if summary, ok := doc.Metadata["summary"].(string); ok {
+		preview.Summary = summary
+	}
+	for _, key := range previewMetadataKeys {
+		if v, ok := doc.Metadata[key]; ok {
+			preview.Metadata[key] = v
+		}
+	}
+
+	if fileKey, _ := doc.Metadata["fileKey"].(string); fileKey != "" && h.storage != nil {
+		url, err := h.storage.PresignURL(c.Request.Context(), fileKey, "", previewURLExpiry)
+		if err != nil {
+			c.Error(err)
+		} else {
+			preview.FileURL = url
+			preview.FileURLExpiresAt = time.Now().UTC().Add(previewURLExpiry).Format(time.RFC3339)
+		}
+	}
+
+	SuccessResponse(c, preview)
 }
 
 func (h *DocumentHandler) UpdateDocument(c *gin.Context) {
@@ -133,7 +468,7 @@ func (h *DocumentHandler) UpdateDocument(c *gin.Context) {
 
 	var doc rag.Document
 	if err := c.ShouldBindJSON(&doc); err != nil {
-		BadRequestResponse(c, "잘못된 문서 형식입니다")
+		BindErrorResponse(c, err, "잘못된 문서 형식입니다")
 		return
 	}
 
@@ -148,6 +483,14 @@ func (h *DocumentHandler) UpdateDocument(c *gin.Context) {
 
 	ensureMetadata(&doc)
 
+	if !h.validateMetadata(c, doc.Metadata) {
+		return
+	}
+
+	if !h.replaceableDocument(c, id) {
+		return
+	}
+
 	if err := h.service.UpdateDocument(c.Request.Context(), doc); err != nil {
 		InternalServerErrorResponse(c, "문서 업데이트에 실패했습니다")
 		return
@@ -161,6 +504,9 @@ func (h *DocumentHandler) UpdateDocument(c *gin.Context) {
 
 func (h *DocumentHandler) DeleteDocument(c *gin.Context) {
 	id := c.Param("id")
+	if _, ok := h.visibleDocument(c, id); !ok {
+		return
+	}
 	if err := h.service.DeleteDocument(c.Request.Context(), id); err != nil {
 		if errors.Is(err, search.ErrDocumentNotFound) {
 			NotFoundResponse(c, "문서를 찾을 수 없습니다")
@@ -169,6 +515,7 @@ func (h *DocumentHandler) DeleteDocument(c *gin.Context) {
 		InternalServerErrorResponse(c, "문서 삭제에 실패했습니다")
 		return
 	}
+	h.events.Publish(c.Request.Context(), webhook.EventDocumentDeleted, gin.H{"id": id})
 
 	SuccessResponse(c, gin.H{
 		"id":      id,
@@ -176,10 +523,45 @@ func (h *DocumentHandler) DeleteDocument(c *gin.Context) {
 	})
 }
 
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+func (h *DocumentHandler) DeleteDocumentsByFilter(c *gin.Context) {
+	var req rag.DeleteByFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Filter) == 0 {
+		BadRequestResponse(c, "삭제 조건(filter)이 필요합니다")
+		return
+	}
+	for key, value := range req.Filter {
+		if !metadataKeyPattern.MatchString(key) || value == "" {
+			BadRequestResponse(c, fmt.Sprintf("잘못된 삭제 조건입니다: %s", key))
+			return
+		}
+	}
+
+	result, err := h.service.DeleteDocumentsByFilter(c.Request.Context(), req.Filter)
+	if err != nil {
+		if errors.Is(err, vectorstore.ErrUnsupported) {
+			ErrorResponse(c, http.StatusNotImplemented, "NOT_SUPPORTED", "현재 벡터 저장소는 조건 삭제를 지원하지 않습니다")
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "조건 삭제에 실패했습니다")
+		return
+	}
+	if result.Documents > 0 {
+		h.events.Publish(c.Request.Context(), webhook.EventDocumentDeleted, gin.H{
+			"filter":    req.Filter,
+			"documents": result.Documents,
+		})
+	}
+
+	SuccessResponse(c, result)
+}
+
 func (h *DocumentHandler) ReindexDocuments(c *gin.Context) {
 	var req rag.ReindexRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		BadRequestResponse(c, "잘못된 요청 형식입니다")
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
 		return
 	}
 
@@ -197,9 +579,20 @@ func (h *DocumentHandler) ReindexDocuments(c *gin.Context) {
 	SuccessResponse(c, result)
 }
 
+func (h *DocumentHandler) MigrateSearchIndex(c *gin.Context) {
+	result, err := h.service.MigrateSearchIndex(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "검색 인덱스 마이그레이션에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, result)
+}
+
 func (h *DocumentHandler) GetStats(c *gin.Context) {
 	// Return dashboard stats instead of just document stats
-	dashboardStats, err := h.service.GetDashboardStats(c.Request.Context())
+	dashboardStats, err := h.service.GetDashboardStats(c.Request.Context(), 24*time.Hour)
 	if err != nil {
 		InternalServerErrorResponse(c, "대시보드 통계 조회에 실패했습니다")
 		return
@@ -208,9 +601,52 @@ func (h *DocumentHandler) GetStats(c *gin.Context) {
 	SuccessResponse(c, dashboardStats)
 }
 
+func (h *DocumentHandler) GetDetailedStats(c *gin.Context) {
+	stats, err := h.service.GetDetailedIndexStats(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "인덱스 상세 통계 조회에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, stats)
+}
+
+func (h *DocumentHandler) GetAggregations(c *gin.Context) {
+	size := parseQueryInt(c, "size", 20)
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+
+	result, err := h.service.GetDocumentAggregations(c.Request.Context(), size)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "문서 집계 조회에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, result)
+}
+
+func (h *DocumentHandler) GetVectorStats(c *gin.Context) {
+	stats, err := h.service.GetVectorStats(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "벡터 컬렉션 정보 조회에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, stats)
+}
+
 func (h *DocumentHandler) FetchDocumentVector(c *gin.Context) {
 	id := c.Param("id")
 	withPayload := c.DefaultQuery("withPayload", "true") == "true"
+	if callerDocumentRoles(c) != nil {
+		if _, ok := h.visibleDocument(c, id); !ok {
+			return
+		}
+	}
 
 	vector, err := h.service.FetchDocumentVector(c.Request.Context(), id, withPayload)
 	if err != nil {
@@ -226,7 +662,7 @@ func (h *DocumentHandler) QueryDocumentVectors(c *gin.Context) {
 		WithPayload: true,
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		BadRequestResponse(c, "잘못된 요청 형식입니다")
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
 		return
 	}
 
@@ -236,6 +672,7 @@ func (h *DocumentHandler) QueryDocumentVectors(c *gin.Context) {
 	if req.Limit > 512 {
 		req.Limit = 512
 	}
+	req.Roles = callerDocumentRoles(c)
 
 	result, err := h.service.QueryDocumentVectors(c.Request.Context(), &req)
 	if err != nil {
@@ -249,13 +686,14 @@ func (h *DocumentHandler) QueryDocumentVectors(c *gin.Context) {
 func (h *DocumentHandler) ProjectVectors(c *gin.Context) {
 	var req rag.VectorProjectionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		BadRequestResponse(c, "잘못된 요청 형식입니다")
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
 		return
 	}
 
 	if req.Limit == 0 {
 		req.Limit = 200
 	}
+	req.Roles = callerDocumentRoles(c)
 
 	result, err := h.service.ProjectVectors(c.Request.Context(), &req)
 	if err != nil {
@@ -272,14 +710,8 @@ func (h *DocumentHandler) DownloadDocumentFile(c *gin.Context) {
 		return
 	}
 
-	id := c.Param("id")
-	doc, err := h.service.GetDocument(c.Request.Context(), id)
-	if err != nil {
-		if errors.Is(err, search.ErrDocumentNotFound) {
-			NotFoundResponse(c, "문서를 찾을 수 없습니다")
-			return
-		}
-		InternalServerErrorResponse(c, "문서 조회에 실패했습니다")
+	doc, ok := h.visibleDocument(c, c.Param("id"))
+	if !ok {
 		return
 	}
 
@@ -305,6 +737,53 @@ func (h *DocumentHandler) DownloadDocumentFile(c *gin.Context) {
 	c.Data(http.StatusOK, contentType, data)
 }
 
+// DocumentFileURL returns a presigned link to the document's original file
+// valid for `expiresIn` seconds (default 300, at most 3600), so large files
+// download straight from storage instead of through DownloadDocumentFile.
+func (h *DocumentHandler) DocumentFileURL(c *gin.Context) {
+	if h.storage == nil {
+		InternalServerErrorResponse(c, "파일 저장소가 구성되지 않았습니다")
+		return
+	}
+
+	expires := time.Duration(parseQueryInt(c, "expiresIn", int(defaultDownloadURLExpiry.Seconds()))) * time.Second
+	if expires <= 0 || expires > maxDownloadURLExpiry {
+		BadRequestResponse(c, fmt.Sprintf("expiresIn은 1~%d초 사이여야 합니다", int(maxDownloadURLExpiry.Seconds())))
+		return
+	}
+
+	// A presigned link works for anyone it is passed on to, so only callers
+	// allowed to see the document get one.
+	doc, ok := h.visibleDocument(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	fileKey, _ := doc.Metadata["fileKey"].(string)
+	if fileKey == "" {
+		NotFoundResponse(c, "해당 문서에는 원본 파일이 없습니다")
+		return
+	}
+
+	filename := "download"
+	if name, ok := doc.Metadata["filename"].(string); ok && name != "" {
+		filename = name
+	}
+
+	url, err := h.storage.PresignURL(c.Request.Context(), fileKey, filename, expires)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "다운로드 링크 생성에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"url":       url,
+		"filename":  filename,
+		"expiresAt": time.Now().UTC().Add(expires).Format(time.RFC3339),
+	})
+}
+
 const maxUploadSize = 20 * 1024 * 1024
 
 func (h *DocumentHandler) UploadDocument(c *gin.Context) {
@@ -331,12 +810,25 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		filename = fmt.Sprintf("upload-%s", uuid.New().String())
 	}
 
-	text, err := textextract.ExtractText(filename, data)
-	if err != nil {
-		BadRequestResponse(c, err.Error())
+	docID := c.PostForm("documentId")
+	if docID == "" {
+		docID = uuid.New().String()
+	} else if !h.replaceableDocument(c, docID) {
 		return
 	}
 
+	job := h.startIngestion(c, filename)
+	defer job.finish(c)
+
+	if !h.scanFile(c, filename, bytes.NewReader(data), int64(len(data))) {
+		return
+	}
+
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
 	metadata := make(map[string]interface{})
 	if raw := c.PostForm("metadata"); raw != "" {
 		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
@@ -345,25 +837,71 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		}
 	}
 
-	contentType := header.Header.Get("Content-Type")
-	if contentType == "" {
-		contentType = http.DetectContentType(data)
+	if !h.validateMetadata(c, metadata) {
+		return
+	}
+
+	job.report(rag.StageExtracting, 0, 1)
+	text, ok := h.extractContent(c, filename, contentType, data)
+	if !ok {
+		return
+	}
+
+	if textextract.IsImage(filename) {
+		metadata["sourceType"] = "image"
 	}
 
-	key := fmt.Sprintf("documents/%s/%s", time.Now().UTC().Format("20060102"), uuid.New().String()+strings.ToLower(filepath.Ext(filename)))
+	key := newUploadKey(filename)
 	url, err := h.storage.Upload(c.Request.Context(), key, data, contentType)
 	if err != nil {
 		InternalServerErrorResponse(c, fmt.Sprintf("파일 업로드 실패: %v", err))
 		return
 	}
 
-	metadata["fileKey"] = key
-	metadata["fileUrl"] = url
-	metadata["filename"] = filename
-	metadata["contentType"] = contentType
+	job.document(docID)
+	doc, err := h.addStoredFileDocument(c.Request.Context(), docID, text, storedFile{
+		Key:         key,
+		URL:         url,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}, metadata)
+	if err != nil {
+		c.Error(err) // Log the actual error
+		InternalServerErrorResponse(c, fmt.Sprintf("문서 생성에 실패했습니다: %v", err))
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"message":  "파일이 업로드되고 문서가 생성되었습니다",
+		"id":       doc.ID,
+		"fileUrl":  url,
+		"fileKey":  key,
+		"fileName": filename,
+	})
+}
+
+type storedFile struct {
+	Key         string
+	URL         string
+	Filename    string
+	ContentType string
+	Size        int64
+}
+
+// addStoredFileDocument indexes text extracted from a file that has already
+// been written to storage, recording the file location in metadata.
+func (h *DocumentHandler) addStoredFileDocument(ctx context.Context, docID, text string, file storedFile, metadata map[string]interface{}) (rag.Document, error) {
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["fileKey"] = file.Key
+	metadata["fileUrl"] = file.URL
+	metadata["filename"] = file.Filename
+	metadata["contentType"] = file.ContentType
+	metadata["fileSize"] = file.Size
 	metadata["uploadedAt"] = time.Now().UTC().Format(time.RFC3339)
 
-	docID := c.PostForm("documentId")
 	if docID == "" {
 		docID = uuid.New().String()
 	}
@@ -374,22 +912,21 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		Metadata: metadata,
 	}
 
-	if err := h.service.AddDocument(c.Request.Context(), doc); err != nil {
-		c.Error(err) // Log the actual error
-		InternalServerErrorResponse(c, fmt.Sprintf("문서 생성에 실패했습니다: %v", err))
-		return
+	if err := h.service.AddDocument(ctx, doc); err != nil {
+		return doc, err
 	}
-
-	SuccessResponse(c, gin.H{
-		"message":  "파일이 업로드되고 문서가 생성되었습니다",
+	h.events.Publish(ctx, webhook.EventDocumentCreated, gin.H{
 		"id":       doc.ID,
-		"fileUrl":  url,
-		"fileKey":  key,
-		"fileName": filename,
+		"metadata": doc.Metadata,
 	})
+	return doc, nil
+}
+
+func newUploadKey(filename string) string {
+	return fmt.Sprintf("documents/%s/%s", time.Now().UTC().Format("20060102"), uuid.New().String()+strings.ToLower(filepath.Ext(filename)))
 }
 
-func readFileWithLimit(file multipart.File, limit int) ([]byte, error) {
+func readFileWithLimit(file io.Reader, limit int) ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
 	if _, err := io.CopyN(buf, file, int64(limit)+1); err != nil && err != io.EOF {
 		return nil, fmt.Errorf("파일을 읽는 중 오류가 발생했습니다: %w", err)
@@ -400,6 +937,115 @@ func readFileWithLimit(file multipart.File, limit int) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// scanFile runs the configured antivirus scanner and writes an error response
+// when the file is infected or cannot be scanned. Infections are recorded in
+// the audit log.
+func (h *DocumentHandler) scanFile(c *gin.Context, filename string, r io.Reader, size int64) bool {
+	if h.scanner == nil {
+		return true
+	}
+
+	result, err := h.scanner.Scan(c.Request.Context(), r)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "바이러스 검사 실패", "error", err, "filename", filename)
+		ErrorResponse(c, http.StatusServiceUnavailable, string(ErrServiceUnavailable), "바이러스 검사를 수행할 수 없습니다")
+		return false
+	}
+
+	if !result.Infected {
+		return true
+	}
+
+	slog.WarnContext(c.Request.Context(), "악성 파일 업로드 차단", "filename", filename, "signature", result.Signature, "ip", c.ClientIP())
+	if h.audit != nil {
+		if err := h.audit.Record(c.Request.Context(), audit.Event{
+			Action:     audit.ActionUploadInfected,
+			ActorID:    c.GetString("userID"),
+			ActorName:  c.GetString("userName"),
+			TargetType: "file",
+			TargetID:   filename,
+			IP:         c.ClientIP(),
+			Details: map[string]interface{}{
+				"signature": result.Signature,
+				"size":      size,
+			},
+		}); err != nil {
+			slog.ErrorContext(c.Request.Context(), "감사 로그 기록 실패", "error", err)
+		}
+	}
+
+	ErrorResponseWithDetails(c, http.StatusUnprocessableEntity, "FILE_INFECTED", "악성 파일이 감지되어 업로드가 거부되었습니다", gin.H{
+		"filename":  filename,
+		"signature": result.Signature,
+	})
+	return false
+}
+
+// extractFileContent is extractContent for an upload spooled to path. Images
+// are still read into memory for the vision model, so they are held to the
+// regular upload limit.
+func (h *DocumentHandler) extractFileContent(c *gin.Context, filename, contentType, path string) (string, bool) {
+	if textextract.IsImage(filename) {
+		file, err := os.Open(path)
+		if err != nil {
+			c.Error(err)
+			InternalServerErrorResponse(c, "업로드된 파일을 읽지 못했습니다")
+			return "", false
+		}
+		defer file.Close()
+		data, err := readFileWithLimit(file, maxUploadSize)
+		if err != nil {
+			BadRequestResponse(c, fmt.Sprintf("이미지는 %dMB 이하여야 합니다", maxUploadSize/1024/1024))
+			return "", false
+		}
+		return h.extractContent(c, filename, contentType, data)
+	}
+
+	text, err := textextract.ExtractTextFile(filename, path)
+	if err != nil {
+		c.Error(err)
+		BadRequestResponse(c, "파일에서 텍스트를 추출하지 못했습니다")
+		return "", false
+	}
+	return text, true
+}
+
+// extractContent returns indexable text for an uploaded file. Images are
+// captioned by the vision model; other formats go through textextract.
+func (h *DocumentHandler) extractContent(c *gin.Context, filename, contentType string, data []byte) (string, bool) {
+	if !textextract.IsImage(filename) {
+		text, err := textextract.ExtractText(filename, data)
+		if err != nil {
+			BadRequestResponse(c, err.Error())
+			return "", false
+		}
+		return text, true
+	}
+
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType = http.DetectContentType(data)
+	}
+
+	text, err := h.service.DescribeImage(c.Request.Context(), data, contentType)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "이미지 분석에 실패했습니다")
+		return "", false
+	}
+	return text, true
+}
+
+// validateMetadata writes a validation error response and returns false when
+// metadata violates the configured schema.
+func (h *DocumentHandler) validateMetadata(c *gin.Context, metadata map[string]interface{}) bool {
+	errs := validator.ValidateMetadata(h.schema, metadata)
+	if len(errs) == 0 {
+		return true
+	}
+	ValidationErrorResponse(c, "문서 메타데이터가 스키마와 일치하지 않습니다", errs)
+	return false
+}
+
 func ensureMetadata(doc *rag.Document) {
 	if doc.Metadata == nil {
 		doc.Metadata = map[string]interface{}{}
@@ -418,6 +1064,34 @@ func parseQueryInt(c *gin.Context, key string, defaultValue int) int {
 	return defaultValue
 }
 
+// parseQueryList splits a comma-separated query parameter.
+func parseQueryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, v := range strings.Split(c.Query(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parseQueryTime accepts RFC3339 timestamps or plain dates.
+func parseQueryTime(c *gin.Context, key string) (*time.Time, error) {
+	val := c.Query(key)
+	if val == "" {
+		return nil, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, val); err == nil {
+		return &parsed, nil
+	}
+	parsed, err := time.Parse("2006-01-02", val)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
 func populateFileFields(doc *rag.Document) {
 	if doc == nil || doc.Metadata == nil {
 		return
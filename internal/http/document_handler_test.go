@@ -0,0 +1,228 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/configuration"
+	"yuon/internal/auth"
+	"yuon/internal/rag"
+	"yuon/internal/rag/search"
+	"yuon/internal/rag/service"
+	"yuon/package/validator"
+)
+
+// fakeOpenSearch serves documents by ID from an in-memory index and
+// records every request that changes it.
+type fakeOpenSearch struct {
+	docs map[string]map[string]interface{}
+
+	mu     sync.Mutex
+	writes []string
+}
+
+func (f *fakeOpenSearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 3 && parts[1] == "_doc" {
+		id := parts[2]
+		if r.Method != http.MethodGet {
+			f.mu.Lock()
+			f.writes = append(f.writes, r.Method+" "+id)
+			f.mu.Unlock()
+		}
+		metadata, ok := f.docs[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"found":false}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"_id":    id,
+			"found":  true,
+			"result": "deleted",
+			"_source": map[string]interface{}{
+				"content":  "내용",
+				"metadata": metadata,
+			},
+		})
+		return
+	}
+	w.Write([]byte(`{}`))
+}
+
+func (f *fakeOpenSearch) writeCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.writes)
+}
+
+// deletingVectors is a rag.VectorStore that only deletes; other methods are
+// left unimplemented.
+type deletingVectors struct {
+	rag.VectorStore
+}
+
+func (deletingVectors) DeleteDocument(ctx context.Context, docID string) error {
+	return nil
+}
+
+func documentTestRouter(t *testing.T, docs map[string]map[string]interface{}) (*gin.Engine, *fakeOpenSearch) {
+	t.Helper()
+	return documentTestRouterWithSchema(t, docs, &validator.MetadataSchema{})
+}
+
+func documentTestRouterWithSchema(t *testing.T, docs map[string]map[string]interface{}, schema *validator.MetadataSchema) (*gin.Engine, *fakeOpenSearch) {
+	t.Helper()
+	backend := &fakeOpenSearch{docs: docs}
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+
+	fullText, err := search.NewOpenSearchClient(&configuration.OpenSearchConfig{URL: server.URL, Index: "documents", RetryBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &DocumentHandler{
+		service: service.NewChatbotService(nil, deletingVectors{}, fullText, nil, nil, 0),
+		storage: &objectStorage{objects: make(map[string]string)},
+		schema:  schema,
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userRole", c.GetHeader("X-Test-Role"))
+		c.Next()
+	})
+	engine.POST("/documents", h.CreateDocument)
+	engine.POST("/documents/bulk", h.BulkIngestDocuments)
+	engine.POST("/documents/upload", h.UploadDocument)
+	engine.PUT("/documents/:id", h.UpdateDocument)
+	engine.DELETE("/documents/:id", h.DeleteDocument)
+	return engine, backend
+}
+
+func serveRole(engine *gin.Engine, role, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-Role", role)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDocumentMutationsRespectAllowedRoles(t *testing.T) {
+	docs := map[string]map[string]interface{}{
+		"restricted": {"allowedRoles": []interface{}{auth.RoleEditor}},
+	}
+
+	t.Run("update hidden document", func(t *testing.T) {
+		engine, backend := documentTestRouter(t, docs)
+		rec := serveRole(engine, auth.RoleUser, http.MethodPut, "/documents/restricted", `{"content":"덮어쓰기"}`)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404: %s", rec.Code, rec.Body)
+		}
+		if n := backend.writeCount(); n != 0 {
+			t.Errorf("%d writes reached the index", n)
+		}
+	})
+
+	t.Run("create under hidden document ID", func(t *testing.T) {
+		for _, r := range []struct{ path, body string }{
+			{"/documents", `{"id":"restricted","content":"덮어쓰기","metadata":{"allowedRoles":["user"]}}`},
+			{"/documents/bulk", `[{"content":"새 문서"},{"id":"restricted","content":"덮어쓰기"}]`},
+		} {
+			engine, backend := documentTestRouter(t, docs)
+			rec := serveRole(engine, auth.RoleUser, http.MethodPost, r.path, r.body)
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("%s: status = %d, want 404: %s", r.path, rec.Code, rec.Body)
+			}
+			if n := backend.writeCount(); n != 0 {
+				t.Errorf("%s: %d writes reached the index", r.path, n)
+			}
+		}
+	})
+
+	t.Run("upload under hidden document ID", func(t *testing.T) {
+		engine, backend := documentTestRouter(t, docs)
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("documentId", "restricted")
+		part, _ := form.CreateFormFile("file", "notes.txt")
+		part.Write([]byte("덮어쓰기"))
+		form.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/documents/upload", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("X-Test-Role", auth.RoleUser)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404: %s", rec.Code, rec.Body)
+		}
+		if n := backend.writeCount(); n != 0 {
+			t.Errorf("%d writes reached the index", n)
+		}
+	})
+
+	t.Run("delete hidden document", func(t *testing.T) {
+		engine, backend := documentTestRouter(t, docs)
+		rec := serveRole(engine, auth.RoleUser, http.MethodDelete, "/documents/restricted", "")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404: %s", rec.Code, rec.Body)
+		}
+		if n := backend.writeCount(); n != 0 {
+			t.Errorf("%d writes reached the index", n)
+		}
+	})
+
+	t.Run("delete visible document", func(t *testing.T) {
+		for _, role := range []string{auth.RoleEditor, auth.RoleAdmin} {
+			engine, backend := documentTestRouter(t, docs)
+			rec := serveRole(engine, role, http.MethodDelete, "/documents/restricted", "")
+			if rec.Code != http.StatusOK {
+				t.Errorf("%s: status = %d, want 200: %s", role, rec.Code, rec.Body)
+			}
+			if n := backend.writeCount(); n != 1 {
+				t.Errorf("%s: %d writes reached the index, want 1", role, n)
+			}
+		}
+	})
+
+	t.Run("delete missing document", func(t *testing.T) {
+		engine, _ := documentTestRouter(t, docs)
+		if rec := serveRole(engine, auth.RoleAdmin, http.MethodDelete, "/documents/missing", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", rec.Code)
+		}
+	})
+}
+
+func TestBulkIngestValidatesMetadata(t *testing.T) {
+	engine, backend := documentTestRouterWithSchema(t, nil, &validator.MetadataSchema{
+		Required: []string{"title"},
+		Types:    map[string]string{"year": "number"},
+	})
+
+	body := `[{"content":"a","metadata":{"title":"ok","year":2024}},{"content":"b","metadata":{"year":"2024"}}]`
+	rec := serveRole(engine, auth.RoleEditor, http.MethodPost, "/documents/bulk", body)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+	}
+	for _, field := range []string{`"1.metadata.title"`, `"1.metadata.year"`} {
+		if !strings.Contains(rec.Body.String(), field) {
+			t.Errorf("response does not name %s: %s", field, rec.Body)
+		}
+	}
+	if n := backend.writeCount(); n != 0 {
+		t.Errorf("%d writes reached the index", n)
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SuccessResponseWithETag writes a success response tagged with a hash of its
+// body. When If-None-Match already names that hash it answers 304 without a
+// body, so polling clients only download changed content.
+func SuccessResponseWithETag(c *gin.Context, data interface{}) {
+	body, err := json.Marshal(Response{Success: true, Data: data})
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "응답 생성에 실패했습니다")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	// Caches must revalidate, since documents change without a known expiry.
+	c.Header("Cache-Control", "no-cache")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatches implements the weak comparison If-None-Match uses (RFC 9110).
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const fieldsFormatMessage = "fields는 쉼표로 구분한 필드 경로여야 합니다 (예: id,metadata.filename)"
+
+// fieldSet is a parsed ?fields= selection. Each key is a JSON property; a
+// nested set narrows that property further and an empty one keeps it whole.
+type fieldSet map[string]fieldSet
+
+// parseFields reads the comma-separated ?fields= list of dotted paths, e.g.
+// "id,metadata.filename,score". A nil set means the parameter was absent and
+// everything is returned; ok is false for malformed input.
+func parseFields(c *gin.Context) (fields fieldSet, ok bool) {
+	raw := strings.TrimSpace(c.Query("fields"))
+	if raw == "" {
+		return nil, true
+	}
+
+	fields = fieldSet{}
+	for _, path := range strings.Split(raw, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !fields.add(strings.Split(path, ".")) {
+			return nil, false
+		}
+	}
+	if len(fields) == 0 {
+		return nil, false
+	}
+	return fields, true
+}
+
+// add inserts one path. Selecting a property whole wins over any narrower
+// path into it, whichever comes first.
+func (f fieldSet) add(parts []string) bool {
+	if parts[0] == "" {
+		return false
+	}
+	child, exists := f[parts[0]]
+	if len(parts) == 1 {
+		f[parts[0]] = fieldSet{}
+		return true
+	}
+	if exists && len(child) == 0 {
+		return validPath(parts[1:])
+	}
+	if !exists {
+		child = fieldSet{}
+		f[parts[0]] = child
+	}
+	return child.add(parts[1:])
+}
+
+func validPath(parts []string) bool {
+	for _, part := range parts {
+		if part == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// apply returns v with the selection applied to the property named key, or
+// to v itself when key is empty. Other properties are left untouched, so the
+// selection can target the items of a list inside a response envelope.
+func (f fieldSet) apply(v any, key string) (any, error) {
+	if f == nil {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+
+	if key == "" {
+		return f.prune(decoded), nil
+	}
+	if m, ok := decoded.(map[string]any); ok {
+		m[key] = f.prune(m[key])
+	}
+	return decoded, nil
+}
+
+// prune keeps the selected properties of objects, element-wise for arrays.
+func (f fieldSet) prune(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(f))
+		for name, sub := range f {
+			value, ok := v[name]
+			if !ok {
+				continue
+			}
+			if len(sub) > 0 {
+				value = sub.prune(value)
+			}
+			out[name] = value
+		}
+		return out
+	case []any:
+		for i := range v {
+			v[i] = f.prune(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
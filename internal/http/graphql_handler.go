@@ -0,0 +1,71 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth"
+	"yuon/internal/graphql"
+	"yuon/internal/rag/service"
+)
+
+const maxGraphQLQueryLength = 32 * 1024
+
+type GraphQLHandler struct {
+	schema *graphql.Schema
+	sdl    []byte
+}
+
+func NewGraphQLHandler(svc *service.ChatbotService, manager *auth.Manager) *GraphQLHandler {
+	schema := newGraphQLSchema(svc, manager)
+	return &GraphQLHandler{schema: schema, sdl: []byte(schema.SDL())}
+}
+
+// Query executes a read-only GraphQL query. Responses use the GraphQL format
+// ({data, errors}) rather than the REST envelope so standard clients work;
+// requests that fail to parse or validate get 400 without data.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req graphql.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if raw := c.Query("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				graphqlError(c, "variables는 JSON 객체여야 합니다")
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		graphqlError(c, "잘못된 GraphQL 요청 형식입니다")
+		return
+	}
+
+	if req.Query == "" {
+		graphqlError(c, "query는 필수입니다")
+		return
+	}
+	if len(req.Query) > maxGraphQLQueryLength {
+		graphqlError(c, "query가 너무 깁니다")
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), graphqlRoleKey{}, c.GetString("userRole"))
+	result := graphql.Execute(ctx, h.schema, req)
+	if result.Data == nil {
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Schema returns the schema in SDL for code generation and editor tooling.
+// Introspection queries (__schema, __type) are not supported.
+func (h *GraphQLHandler) Schema(c *gin.Context) {
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", h.sdl)
+}
+
+func graphqlError(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, graphql.Result{Errors: []graphql.Error{{Message: message}}})
+}
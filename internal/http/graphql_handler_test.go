@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth"
+)
+
+// listedUsers is an auth.UserStore holding a fixed set of users; other
+// methods are left unimplemented.
+type listedUsers struct {
+	auth.UserStore
+	users []*auth.User
+}
+
+func (s listedUsers) List(ctx context.Context) ([]*auth.User, error) {
+	return append([]*auth.User(nil), s.users...), nil
+}
+
+func (s listedUsers) FindByID(ctx context.Context, id string) (*auth.User, error) {
+	for _, u := range s.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, auth.ErrUserNotFound
+}
+
+func TestGraphQLUsersRootOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := auth.NewManager("secret", listedUsers{users: []*auth.User{
+		{ID: "u1", Email: "root@example.com", Role: auth.RoleRoot, Active: true},
+	}})
+	h := NewGraphQLHandler(nil, manager)
+
+	tests := []struct {
+		name      string
+		role      string
+		query     string
+		wantData  string
+		wantError bool
+	}{
+		{name: "root lists users", role: auth.RoleRoot, query: `{ users { id email } }`, wantData: `{"users":[{"id":"u1","email":"root@example.com"}]}`},
+		{name: "root reads a user", role: auth.RoleRoot, query: `{ user(id: "u1") { id } }`, wantData: `{"user":{"id":"u1"}}`},
+		{name: "admin cannot list users", role: auth.RoleAdmin, query: `{ users { id } }`, wantData: `{"users":null}`, wantError: true},
+		{name: "admin cannot read a user", role: auth.RoleAdmin, query: `{ user(id: "u1") { id } }`, wantData: `{"user":null}`, wantError: true},
+		{name: "aliases do not bypass the check", role: auth.RoleAdmin, query: `{ a: users { id } b: user(id: "u1") { id } }`, wantData: `{"a":null,"b":null}`, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.POST("/graphql", func(c *gin.Context) {
+				c.Set("userRole", tt.role)
+				c.Next()
+			}, h.Query)
+
+			body, _ := json.Marshal(map[string]string{"query": tt.query})
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+
+			var resp struct {
+				Data   json.RawMessage `json:"data"`
+				Errors []struct {
+					Message string `json:"message"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if string(resp.Data) != tt.wantData {
+				t.Errorf("data = %s, want %s", resp.Data, tt.wantData)
+			}
+			if tt.wantError && (len(resp.Errors) == 0 || resp.Errors[0].Message != "root 권한이 필요합니다") {
+				t.Errorf("errors = %+v, want root-only error", resp.Errors)
+			}
+			if !tt.wantError && len(resp.Errors) > 0 {
+				t.Errorf("unexpected errors: %+v", resp.Errors)
+			}
+		})
+	}
+}
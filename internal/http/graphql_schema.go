@@ -0,0 +1,282 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	"yuon/internal/auth"
+	"yuon/internal/graphql"
+	"yuon/internal/rag"
+	"yuon/internal/rag/search"
+	"yuon/internal/rag/service"
+)
+
+type graphqlRoleKey struct{}
+
+// requireRootRole mirrors the REST routes, where user management is
+// root-only while the rest of the admin API is open to admins.
+func requireRootRole(ctx context.Context) error {
+	if role, _ := ctx.Value(graphqlRoleKey{}).(string); role != "root" {
+		return errors.New("root 권한이 필요합니다")
+	}
+	return nil
+}
+
+// graphqlDocumentRoles is documentRoles for the caller of the query.
+func graphqlDocumentRoles(ctx context.Context) []string {
+	role, _ := ctx.Value(graphqlRoleKey{}).(string)
+	return documentRoles(role)
+}
+
+// intArg returns an optional Int argument, falling back to def when the
+// client passed null.
+func intArg(args map[string]any, name string, def int) int {
+	if v, ok := args[name].(int); ok {
+		return v
+	}
+	return def
+}
+
+// newGraphQLSchema exposes documents, conversations, users and analytics
+// for the admin dashboard. Fields without a resolver read the struct field
+// with the same JSON name.
+func newGraphQLSchema(svc *service.ChatbotService, manager *auth.Manager) *graphql.Schema {
+	nonNull := graphql.NewNonNull
+	list := graphql.NewList
+
+	user := &graphql.Object{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":             {Type: nonNull(graphql.ID)},
+			"email":          {Type: nonNull(graphql.String)},
+			"name":           {Type: nonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) { return p.Source.(*auth.User).DisplayName(), nil }},
+			"role":           {Type: nonNull(graphql.String)},
+			"workspace":      {Type: graphql.String},
+			"department":     {Type: graphql.String},
+			"avatarUrl":      {Type: graphql.String, Resolve: func(p graphql.ResolveParams) (any, error) { return p.Source.(*auth.User).AvatarURL, nil }},
+			"status":         {Type: nonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) { return userStatus(p.Source.(*auth.User)), nil }},
+			"serviceAccount": {Type: nonNull(graphql.Boolean)},
+			"createdAt":      {Type: graphql.String},
+		},
+	}
+
+	message := &graphql.Object{
+		Name:        "Message",
+		Description: "A stored chat message. Sources are not persisted, so they are not available here.",
+		Fields: graphql.Fields{
+			"role":      {Type: nonNull(graphql.String)},
+			"content":   {Type: nonNull(graphql.String)},
+			"timestamp": {Type: nonNull(graphql.String)},
+		},
+	}
+
+	conversation := &graphql.Object{
+		Name: "Conversation",
+		Fields: graphql.Fields{
+			"id":           {Type: nonNull(graphql.ID)},
+			"preview":      {Type: graphql.String},
+			"messageCount": {Type: nonNull(graphql.Int)},
+			"tokenUsage":   {Type: nonNull(graphql.Int)},
+			"ownerId":      {Type: graphql.String},
+			"ownerName":    {Type: graphql.String},
+			"createdAt":    {Type: nonNull(graphql.String)},
+			"updatedAt":    {Type: nonNull(graphql.String)},
+			"messages": {
+				Type: nonNull(list(nonNull(message))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					messages, err := svc.GetConversationMessages(p.Context, p.Source.(*service.ConversationSummary).ID)
+					if messages == nil && err == nil {
+						messages = []service.ConversationMessage{}
+					}
+					return messages, err
+				},
+			},
+			"owner": {
+				Type:        user,
+				Description: "The user who started the conversation (root only).",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					ownerID := p.Source.(*service.ConversationSummary).OwnerID
+					if ownerID == "" {
+						return nil, nil
+					}
+					if err := requireRootRole(p.Context); err != nil {
+						return nil, err
+					}
+					u, err := manager.GetUser(ownerID)
+					if err != nil {
+						return nil, nil
+					}
+					return u, nil
+				},
+			},
+		},
+	}
+
+	document := &graphql.Object{
+		Name: "Document",
+		Fields: graphql.Fields{
+			"id":        {Type: nonNull(graphql.ID)},
+			"content":   {Type: graphql.String},
+			"metadata":  {Type: graphql.JSON},
+			"score":     {Type: graphql.Float},
+			"fileKey":   {Type: graphql.String},
+			"fileUrl":   {Type: graphql.String},
+			"createdAt": {Type: graphql.String},
+			"updatedAt": {Type: graphql.String},
+		},
+	}
+
+	documentPage := &graphql.Object{
+		Name: "DocumentPage",
+		Fields: graphql.Fields{
+			"documents":  {Type: nonNull(list(nonNull(document)))},
+			"total":      {Type: nonNull(graphql.Float), Description: "Total matching documents (may exceed Int range)."},
+			"page":       {Type: nonNull(graphql.Int)},
+			"pageSize":   {Type: nonNull(graphql.Int)},
+			"hasNext":    {Type: nonNull(graphql.Boolean)},
+			"nextCursor": {Type: graphql.String},
+		},
+	}
+
+	documentStats := &graphql.Object{
+		Name: "DocumentStats",
+		Fields: graphql.Fields{
+			"totalDocuments": {Type: nonNull(graphql.Float)},
+			"index":          {Type: graphql.String},
+			"lastUpdatedAt":  {Type: graphql.String},
+		},
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"documents": {
+				Type: nonNull(documentPage),
+				Args: []graphql.Arg{
+					{Name: "page", Type: graphql.Int, Default: 1},
+					{Name: "pageSize", Type: graphql.Int, Default: 20},
+					{Name: "q", Type: graphql.String},
+					{Name: "category", Type: graphql.String},
+					{Name: "tags", Type: list(nonNull(graphql.String))},
+					{Name: "cursor", Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					params := &rag.DocumentListParams{
+						Page:     intArg(p.Args, "page", 1),
+						PageSize: intArg(p.Args, "pageSize", 20),
+					}
+					params.Query, _ = p.Args["q"].(string)
+					params.Cursor, _ = p.Args["cursor"].(string)
+					params.Category, _ = p.Args["category"].(string)
+					if tags, ok := p.Args["tags"].([]any); ok {
+						for _, t := range tags {
+							params.Tags = append(params.Tags, t.(string))
+						}
+					}
+
+					params.Roles = graphqlDocumentRoles(p.Context)
+
+					result, err := svc.ListDocuments(p.Context, params)
+					if err != nil {
+						if errors.Is(err, search.ErrInvalidCursor) {
+							return nil, errors.New("유효하지 않은 cursor입니다")
+						}
+						return nil, err
+					}
+					for i := range result.Documents {
+						populateFileFields(&result.Documents[i])
+					}
+					return result, nil
+				},
+			},
+			"document": {
+				Type: document,
+				Args: []graphql.Arg{{Name: "id", Type: nonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					doc, err := svc.GetDocument(p.Context, p.Args["id"].(string))
+					if errors.Is(err, search.ErrDocumentNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					if !rag.RolesAllow(doc.Metadata, graphqlDocumentRoles(p.Context)) {
+						return nil, nil
+					}
+					populateFileFields(doc)
+					return doc, nil
+				},
+			},
+			"documentStats": {
+				Type: nonNull(documentStats),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return svc.GetDocumentStats(p.Context)
+				},
+			},
+			"conversations": {
+				Type: nonNull(list(nonNull(conversation))),
+				Args: []graphql.Arg{{Name: "limit", Type: graphql.Int, Default: 100}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					items, _, err := svc.ListConversationSummaries(p.Context, service.ConversationFilter{}, intArg(p.Args, "limit", 100), "")
+					if err != nil {
+						return nil, err
+					}
+					resp := make([]*service.ConversationSummary, len(items))
+					for i := range items {
+						resp[i] = &items[i]
+					}
+					return resp, nil
+				},
+			},
+			"conversation": {
+				Type: conversation,
+				Args: []graphql.Arg{{Name: "id", Type: nonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					item, err := svc.GetConversationSummary(p.Context, p.Args["id"].(string))
+					if errors.Is(err, service.ErrConversationNotFound) {
+						return nil, nil
+					}
+					return item, err
+				},
+			},
+			"users": {
+				Type:        nonNull(list(nonNull(user))),
+				Description: "All users (root only).",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					if err := requireRootRole(p.Context); err != nil {
+						return nil, err
+					}
+					users := manager.AllUsers()
+					if users == nil {
+						users = []*auth.User{}
+					}
+					return users, nil
+				},
+			},
+			"user": {
+				Type:        user,
+				Description: "A single user (root only).",
+				Args:        []graphql.Arg{{Name: "id", Type: nonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					if err := requireRootRole(p.Context); err != nil {
+						return nil, err
+					}
+					u, err := manager.GetUser(p.Args["id"].(string))
+					if err != nil {
+						return nil, nil
+					}
+					return u, nil
+				},
+			},
+			"chatAnalytics": {
+				Type:        nonNull(graphql.JSON),
+				Description: "Same payload as GET /analytics/chat.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return svc.GetAnalyticsStats(), nil
+				},
+			},
+		},
+	}
+
+	return &graphql.Schema{Query: query}
+}
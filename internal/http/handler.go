@@ -1,7 +1,12 @@
 package http
 
 import (
+	"context"
+	"net/http"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"yuon/internal/rag"
 )
 
 type HealthCheckResponse struct {
@@ -19,3 +24,18 @@ func (r *Router) healthCheck(c *gin.Context) {
 		Environment: r.config.App.Environment,
 	})
 }
+
+// readinessCheck reports 503 only when retrieval is impossible; a down vector
+// store leaves the server ready in degraded (full-text only) mode.
+func (r *Router) readinessCheck(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	report := r.chatbotService.Readiness(ctx)
+	if report.Status == rag.ReadinessUnavailable {
+		c.JSON(http.StatusServiceUnavailable, Response{Success: false, Data: report})
+		return
+	}
+
+	SuccessResponse(c, report)
+}
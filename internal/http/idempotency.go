@@ -0,0 +1,138 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/idempotency"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = maxUploadSize + 1024*1024
+)
+
+// idempotencyKey makes a mutating route safe to retry. A request carrying an
+// Idempotency-Key is recorded per caller; repeating it within ttl replays the
+// stored response instead of running the handler again. Reusing a key for a
+// different request is rejected, as is a retry while the first one is still
+// running. 5xx responses, panics and handlers that wrote nothing release the
+// key so the client can retry.
+func idempotencyKey(store idempotency.Store, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if store == nil || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			BadRequestResponse(c, "Idempotency-Key는 255자 이하여야 합니다")
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentRequestBytes+1))
+		if err != nil {
+			BadRequestResponse(c, "요청 본문을 읽을 수 없습니다")
+			c.Abort()
+			return
+		}
+		if len(body) > maxIdempotentRequestBytes {
+			ErrorResponse(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "요청 본문이 너무 큽니다")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		principal := rateLimitPrincipal(c)
+		endpoint := c.Request.Method + " " + c.FullPath()
+		existing, err := store.Reserve(ctx, &idempotency.Record{
+			Principal:   principal,
+			Key:         key,
+			Endpoint:    endpoint,
+			RequestHash: idempotency.Hash([]byte(endpoint), []byte(c.GetHeader("Content-Type")), body),
+			ExpiresAt:   time.Now().Add(ttl),
+		})
+		if err != nil {
+			c.Error(err)
+			InternalServerErrorResponse(c, "Idempotency-Key 처리 중 오류가 발생했습니다")
+			c.Abort()
+			return
+		}
+		if existing != nil {
+			replayIdempotent(c, existing, endpoint, body)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		defer func() {
+			// The request context may already be cancelled; the record must
+			// still be completed or released.
+			ctx := context.WithoutCancel(ctx)
+			// A panicking handler has written nothing yet; recovery answers
+			// with a 500 later, so the key is released rather than storing
+			// an empty 200.
+			p := recover()
+			status := recorder.Status()
+			if p != nil || !recorder.Written() || status >= http.StatusInternalServerError {
+				err = store.Release(ctx, principal, key)
+			} else {
+				err = store.Complete(ctx, principal, key, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+			}
+			if err != nil {
+				slog.WarnContext(ctx, "Idempotency-Key 저장 실패", "error", err)
+			}
+			if p != nil {
+				panic(p)
+			}
+		}()
+		c.Next()
+	}
+}
+
+func replayIdempotent(c *gin.Context, rec *idempotency.Record, endpoint string, body []byte) {
+	defer c.Abort()
+
+	if rec.Endpoint != endpoint || rec.RequestHash != idempotency.Hash([]byte(endpoint), []byte(c.GetHeader("Content-Type")), body) {
+		ErrorResponse(c, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key가 다른 요청에 이미 사용되었습니다")
+		return
+	}
+	if !rec.Completed {
+		ErrorResponse(c, http.StatusConflict, "IDEMPOTENCY_IN_PROGRESS", "같은 Idempotency-Key의 요청이 아직 처리 중입니다")
+		return
+	}
+	if rec.ResponseHash != idempotency.Hash(rec.Body) {
+		c.Error(errors.New("stored idempotent response hash mismatch"))
+		InternalServerErrorResponse(c, "저장된 응답을 재생할 수 없습니다")
+		return
+	}
+
+	c.Header(idempotentReplayedHeader, "true")
+	c.Data(rec.StatusCode, rec.ContentType, rec.Body)
+}
+
+// responseRecorder tees the response body so it can be stored for replays.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/idempotency"
+)
+
+// memoryIdempotency is an idempotency.Store kept in memory.
+type memoryIdempotency struct {
+	mu      sync.Mutex
+	records map[string]*idempotency.Record
+}
+
+func newMemoryIdempotency() *memoryIdempotency {
+	return &memoryIdempotency{records: make(map[string]*idempotency.Record)}
+}
+
+func (s *memoryIdempotency) Reserve(ctx context.Context, rec *idempotency.Record) (*idempotency.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := rec.Principal + "|" + rec.Key
+	if existing, ok := s.records[id]; ok && existing.ExpiresAt.After(time.Now()) {
+		copied := *existing
+		return &copied, nil
+	}
+	copied := *rec
+	s.records[id] = &copied
+	return nil, nil
+}
+
+func (s *memoryIdempotency) Complete(ctx context.Context, principal, key string, status int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.records[principal+"|"+key]
+	rec.StatusCode = status
+	rec.ContentType = contentType
+	rec.Body = append([]byte(nil), body...)
+	rec.ResponseHash = idempotency.Hash(body)
+	rec.Completed = true
+	return nil
+}
+
+func (s *memoryIdempotency) Release(ctx context.Context, principal, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, principal+"|"+key)
+	return nil
+}
+
+func (s *memoryIdempotency) Prune(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for id, rec := range s.records {
+		if !rec.ExpiresAt.After(now) {
+			delete(s.records, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memoryIdempotency) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+func idempotencyTestRouter(store idempotency.Store, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(recoveryMiddleware())
+	engine.POST("/documents", idempotencyKey(store, time.Hour), handler)
+	return engine
+}
+
+func postIdempotent(engine *gin.Engine, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/documents", strings.NewReader(`{"title":"a"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotencyKeyHeader, key)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyKeyReplaysCompletedResponse(t *testing.T) {
+	store := newMemoryIdempotency()
+	calls := 0
+	engine := idempotencyTestRouter(store, func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"id": "doc-1"})
+	})
+
+	first := postIdempotent(engine, "k1")
+	second := postIdempotent(engine, "k1")
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Error("replay not marked as replayed")
+	}
+}
+
+func TestIdempotencyKeyReleasedWithoutResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		want    int
+	}{
+		{"panic", func(c *gin.Context) { panic("boom") }, http.StatusInternalServerError},
+		{"server error", func(c *gin.Context) { InternalServerErrorResponse(c, "실패") }, http.StatusInternalServerError},
+		{"nothing written", func(c *gin.Context) {}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryIdempotency()
+			engine := idempotencyTestRouter(store, tt.handler)
+
+			if rec := postIdempotent(engine, "k1"); rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if n := store.len(); n != 0 {
+				t.Fatalf("%d records kept, want the key released", n)
+			}
+			if rec := postIdempotent(engine, "k1"); rec.Header().Get(idempotentReplayedHeader) != "" {
+				t.Error("retry was replayed instead of running the handler")
+			}
+		})
+	}
+}
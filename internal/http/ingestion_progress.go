@@ -0,0 +1,101 @@
+package http
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/rag"
+)
+
+// ingestionJob reports the progress of one upload or bulk ingest to staff
+// WebSocket clients watching ingestion in the same workspace. The job ID is
+// the request ID, which an upload UI can choose through X-Request-ID to match
+// events to its upload.
+type ingestionJob struct {
+	hub       *wsHub
+	workspace string
+
+	mu      sync.Mutex
+	payload ingestionProgressPayload
+}
+
+type ingestionProgressPayload struct {
+	JobID      string `json:"job_id"`
+	Filename   string `json:"filename,omitempty"`
+	DocumentID string `json:"document_id,omitempty"`
+	Stage      string `json:"stage"`
+	// Percent estimates overall completion. Done and Total count the
+	// documents of a bulk ingest that passed Stage.
+	Percent int `json:"percent"`
+	Done    int `json:"done,omitempty"`
+	Total   int `json:"total,omitempty"`
+}
+
+// startIngestion begins reporting for the request. The service reports its
+// stages through the request context, so it must be called before the
+// handler reads c.Request.Context(). A nil job, returned when no hub is
+// configured, ignores every call.
+func (h *DocumentHandler) startIngestion(c *gin.Context, filename string) *ingestionJob {
+	if h.progress == nil {
+		return nil
+	}
+	job := &ingestionJob{
+		hub:       h.progress,
+		workspace: rag.WorkspaceFromContext(c.Request.Context()),
+		payload:   ingestionProgressPayload{JobID: c.GetString("requestID"), Filename: filename},
+	}
+	c.Request = c.Request.WithContext(rag.WithProgress(c.Request.Context(), job.report))
+	return job
+}
+
+// report publishes a stage. Embedding and indexing take most of the time, so
+// they cover 10-80% and 80-100% of the estimate.
+func (j *ingestionJob) report(stage string, done, total int) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	fraction := 0.0
+	if total > 0 {
+		fraction = float64(done) / float64(total)
+	}
+	switch stage {
+	case rag.StageExtracting:
+		j.payload.Percent = 0
+	case rag.StageEmbedding:
+		j.payload.Percent = 10 + int(70*fraction)
+	case rag.StageIndexing:
+		j.payload.Percent = 80 + int(20*fraction)
+	case rag.StageDone:
+		j.payload.Percent = 100
+	}
+	j.payload.Stage = stage
+	j.payload.Done = done
+	j.payload.Total = total
+	if total <= 1 {
+		j.payload.Done, j.payload.Total = 0, 0
+	}
+	j.hub.notifyWatchers(wsEnvelope{Type: "ingestion_progress", Payload: mustMarshal(j.payload)}, j.workspace)
+}
+
+// document records the ID of the document being created.
+func (j *ingestionJob) document(id string) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.payload.DocumentID = id
+}
+
+// finish reports done, or failed when the handler wrote an error response.
+// Deferred right after startIngestion, it covers every early return.
+func (j *ingestionJob) finish(c *gin.Context) {
+	if c.Writer.Status() >= 400 {
+		j.report(rag.StageFailed, 0, 0)
+		return
+	}
+	j.report(rag.StageDone, 0, 0)
+}
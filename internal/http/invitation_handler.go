@@ -0,0 +1,168 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth"
+	"yuon/internal/mail"
+)
+
+type InvitationHandler struct {
+	manager   *auth.Manager
+	mailer    mail.Mailer
+	inviteURL string
+}
+
+func NewInvitationHandler(manager *auth.Manager, mailer mail.Mailer, inviteURL string) *InvitationHandler {
+	if mailer == nil {
+		mailer = mail.LogMailer{}
+	}
+	return &InvitationHandler{manager: manager, mailer: mailer, inviteURL: inviteURL}
+}
+
+type createInvitationRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	Role      string `json:"role"`
+	Workspace string `json:"workspace"`
+}
+
+type invitationResponse struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	Workspace string `json:"workspace,omitempty"`
+	CreatedBy string `json:"createdBy,omitempty"`
+	CreatedAt string `json:"createdAt"`
+	ExpiresAt string `json:"expiresAt"`
+	UsedAt    string `json:"usedAt,omitempty"`
+	Status    string `json:"status"`
+}
+
+func newInvitationResponse(inv *auth.Invitation) invitationResponse {
+	resp := invitationResponse{
+		ID:        inv.ID,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		Workspace: inv.Workspace,
+		CreatedBy: inv.CreatedBy,
+		CreatedAt: inv.CreatedAt.Format(time.RFC3339),
+		ExpiresAt: inv.ExpiresAt.Format(time.RFC3339),
+		Status:    inv.Status(time.Now()),
+	}
+	if inv.UsedAt != nil {
+		resp.UsedAt = inv.UsedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// Create issues an invitation and mails the signup link. The token is only
+// included in this response so it can be delivered out of band.
+func (h *InvitationHandler) Create(c *gin.Context) {
+	var req createInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	token, inv, err := h.manager.CreateInvitation(req.Email, req.Role, req.Workspace, c.GetString("userID"), c.GetString("userRole"))
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidRole):
+			BadRequestResponse(c, "유효하지 않은 역할입니다")
+		case errors.Is(err, auth.ErrRoleNotGrantable):
+			ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "해당 역할로 초대할 권한이 없습니다")
+		case errors.Is(err, auth.ErrEmailTaken):
+			ErrorResponse(c, http.StatusConflict, "EMAIL_TAKEN", "이미 가입된 이메일입니다")
+		default:
+			c.Error(err)
+			InternalServerErrorResponse(c, "초대 생성에 실패했습니다")
+		}
+		return
+	}
+
+	link := h.inviteLink(token)
+	msg := mail.Message{
+		To:      inv.Email,
+		Subject: "[YUON] 가입 초대",
+		Body:    "YUON에 초대되었습니다. 아래 링크에서 비밀번호를 설정해 가입을 완료하세요. 링크는 한 번만 사용할 수 있으며 " + inv.ExpiresAt.Format("2006-01-02 15:04 MST") + "에 만료됩니다.\n\n" + link + "\n",
+	}
+	reqCtx := context.WithoutCancel(c.Request.Context())
+	go func() {
+		ctx, cancel := context.WithTimeout(reqCtx, 30*time.Second)
+		defer cancel()
+		if err := h.mailer.Send(ctx, msg); err != nil {
+			slog.ErrorContext(ctx, "초대 메일 발송 실패", "error", err)
+		}
+	}()
+
+	SuccessResponse(c, gin.H{
+		"token":      token,
+		"link":       link,
+		"invitation": newInvitationResponse(inv),
+	})
+}
+
+func (h *InvitationHandler) List(c *gin.Context) {
+	invitations, err := h.manager.ListInvitations()
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "초대 목록 조회에 실패했습니다")
+		return
+	}
+
+	resp := make([]invitationResponse, 0, len(invitations))
+	for _, inv := range invitations {
+		resp = append(resp, newInvitationResponse(inv))
+	}
+	SuccessResponse(c, gin.H{"invitations": resp})
+}
+
+func (h *InvitationHandler) Revoke(c *gin.Context) {
+	if err := h.manager.RevokeInvitation(c.Param("id")); err != nil {
+		if errors.Is(err, auth.ErrInvitationNotFound) {
+			NotFoundResponse(c, "대기 중인 초대를 찾을 수 없습니다")
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "초대 취소에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, gin.H{"message": "초대가 취소되었습니다"})
+}
+
+// Lookup lets the signup page show the invited email and role.
+func (h *InvitationHandler) Lookup(c *gin.Context) {
+	inv, err := h.manager.LookupInvitation(c.Query("token"))
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidInvitation) {
+			ErrorResponse(c, http.StatusBadRequest, "INVALID_INVITATION", "초대가 유효하지 않거나 만료되었습니다")
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "초대 조회에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"email":     inv.Email,
+		"role":      inv.Role,
+		"expiresAt": inv.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+func (h *InvitationHandler) inviteLink(token string) string {
+	u, err := url.Parse(h.inviteURL)
+	if err != nil {
+		return h.inviteURL + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
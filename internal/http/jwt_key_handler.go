@@ -0,0 +1,66 @@
+package http
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth"
+)
+
+type JWTKeyHandler struct {
+	manager *auth.Manager
+}
+
+func NewJWTKeyHandler(manager *auth.Manager) *JWTKeyHandler {
+	return &JWTKeyHandler{manager: manager}
+}
+
+type jwtKeyResponse struct {
+	ID        string `json:"id"`
+	Current   bool   `json:"current"`
+	CreatedAt string `json:"createdAt"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+func newJWTKeyResponse(k *auth.SigningKey) jwtKeyResponse {
+	resp := jwtKeyResponse{
+		ID:        k.ID,
+		Current:   k.ExpiresAt == nil,
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+	}
+	if k.ExpiresAt != nil {
+		resp.ExpiresAt = k.ExpiresAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// List returns the signing keys still accepted for verification. Secrets are
+// never returned.
+func (h *JWTKeyHandler) List(c *gin.Context) {
+	keys, err := h.manager.SigningKeys()
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "서명 키 목록을 불러오지 못했습니다")
+		return
+	}
+
+	resp := make([]jwtKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		resp = append(resp, newJWTKeyResponse(k))
+	}
+	SuccessResponse(c, gin.H{"keys": resp})
+}
+
+// Rotate makes a new signing key current.
+func (h *JWTKeyHandler) Rotate(c *gin.Context) {
+	key, err := h.manager.RotateSigningKey()
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "서명 키 교체에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, gin.H{
+		"key":     newJWTKeyResponse(key),
+		"message": "서명 키가 교체되었습니다",
+	})
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth"
+)
+
+type LoginAttemptHandler struct {
+	manager *auth.Manager
+}
+
+func NewLoginAttemptHandler(manager *auth.Manager) *LoginAttemptHandler {
+	return &LoginAttemptHandler{manager: manager}
+}
+
+type loginAttemptResponse struct {
+	Scope         string `json:"scope"`
+	Key           string `json:"key"`
+	Failures      int    `json:"failures"`
+	LastFailureAt string `json:"lastFailureAt"`
+	Locked        bool   `json:"locked"`
+	LockedUntil   string `json:"lockedUntil,omitempty"`
+}
+
+// List returns accounts and IPs with recent failed logins.
+func (h *LoginAttemptHandler) List(c *gin.Context) {
+	attempts, err := h.manager.LoginAttempts()
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "로그인 시도 조회에 실패했습니다")
+		return
+	}
+
+	now := time.Now()
+	resp := make([]loginAttemptResponse, 0, len(attempts))
+	for _, a := range attempts {
+		item := loginAttemptResponse{
+			Scope:         a.Scope,
+			Key:           a.Key,
+			Failures:      a.Failures,
+			LastFailureAt: a.LastFailureAt.Format(time.RFC3339),
+			Locked:        a.Locked(now),
+		}
+		if item.Locked {
+			item.LockedUntil = a.LockedUntil.Format(time.RFC3339)
+		}
+		resp = append(resp, item)
+	}
+
+	SuccessResponse(c, gin.H{"attempts": resp})
+}
+
+// Unlock clears the failures of an account (scope "account", key email) or
+// client IP (scope "ip").
+func (h *LoginAttemptHandler) Unlock(c *gin.Context) {
+	scope := c.Param("scope")
+	if scope != auth.LoginScopeAccount && scope != auth.LoginScopeIP {
+		BadRequestResponse(c, "scope는 account 또는 ip여야 합니다")
+		return
+	}
+
+	if err := h.manager.UnlockLogin(scope, c.Param("key")); err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "잠금 해제에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, gin.H{"message": "잠금이 해제되었습니다"})
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth"
+)
+
+type MFAHandler struct {
+	manager *auth.Manager
+}
+
+func NewMFAHandler(manager *auth.Manager) *MFAHandler {
+	return &MFAHandler{manager: manager}
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type mfaLoginRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+func (h *MFAHandler) Status(c *gin.Context) {
+	enabled, remaining, err := h.manager.MFAStatus(c.GetString("userID"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	SuccessResponse(c, gin.H{
+		"enabled":                enabled,
+		"recoveryCodesRemaining": remaining,
+	})
+}
+
+// Enroll returns a pending secret; the client renders otpauthUrl as a QR code.
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	secret, uri, err := h.manager.EnrollMFA(c.GetString("userID"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	SuccessResponse(c, gin.H{
+		"secret":     secret,
+		"otpauthUrl": uri,
+	})
+}
+
+func (h *MFAHandler) Activate(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	codes, err := h.manager.ActivateMFA(c.GetString("userID"), req.Code)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	SuccessResponse(c, gin.H{
+		"enabled":       true,
+		"recoveryCodes": codes,
+	})
+}
+
+func (h *MFAHandler) Disable(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	if err := h.manager.DisableMFA(c.GetString("userID"), req.Code, c.ClientIP()); err != nil {
+		h.fail(c, err)
+		return
+	}
+	SuccessResponse(c, gin.H{"enabled": false})
+}
+
+// Login completes a password login that returned mfaRequired.
+func (h *MFAHandler) Login(c *gin.Context) {
+	var req mfaLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	tokens, user, err := h.manager.CompleteMFALogin(req.MFAToken, req.Code, c.ClientIP())
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	SuccessResponse(c, tokenResponse(tokens, user))
+}
+
+// Reset removes MFA from another user (root only).
+func (h *MFAHandler) Reset(c *gin.Context) {
+	if err := h.manager.ResetMFA(c.Param("id")); err != nil {
+		h.fail(c, err)
+		return
+	}
+	SuccessResponse(c, gin.H{"message": "MFA가 초기화되었습니다"})
+}
+
+func (h *MFAHandler) fail(c *gin.Context, err error) {
+	var locked *auth.LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		loginLockedResponse(c, locked)
+	case errors.Is(err, auth.ErrUserDisabled):
+		userDisabledResponse(c)
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		ErrorResponse(c, http.StatusUnauthorized, "INVALID_MFA_CODE", "인증 코드가 올바르지 않습니다")
+	case errors.Is(err, auth.ErrInvalidMFAToken):
+		ErrorResponse(c, http.StatusUnauthorized, "INVALID_MFA_TOKEN", "MFA 토큰이 유효하지 않거나 만료되었습니다")
+	case errors.Is(err, auth.ErrMFANotEnrolled):
+		ErrorResponse(c, http.StatusBadRequest, "MFA_NOT_ENROLLED", "MFA가 등록되지 않았습니다")
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		ErrorResponse(c, http.StatusConflict, "MFA_ALREADY_ENABLED", "MFA가 이미 활성화되어 있습니다")
+	default:
+		c.Error(err)
+		InternalServerErrorResponse(c, "MFA 처리에 실패했습니다")
+	}
+}
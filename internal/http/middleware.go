@@ -5,8 +5,43 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"yuon/package/logger"
 )
 
+const requestIDHeader = "X-Request-ID"
+
+// requestIDMiddleware honors a well-formed incoming X-Request-ID or generates
+// one, echoes it in the response and stores it in the request context so
+// context-aware log lines and error payloads carry it.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
+
+		c.Set("requestID", id)
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// validRequestID accepts up to 128 visible ASCII characters so client values
+// cannot inject into logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
 func slogMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -21,7 +56,7 @@ func logRequest(c *gin.Context, start time.Time) {
 	statusCode := c.Writer.Status()
 	logLevel := getLogLevel(statusCode)
 
-	slog.Log(c.Request.Context(), logLevel, "HTTP Request",
+	attrs := []any{
 		"status", statusCode,
 		"method", c.Request.Method,
 		"path", c.Request.URL.Path,
@@ -29,7 +64,11 @@ func logRequest(c *gin.Context, start time.Time) {
 		"ip", c.ClientIP(),
 		"latency", time.Since(start).String(),
 		"user_agent", c.Request.UserAgent(),
-	)
+	}
+	if len(c.Errors) > 0 {
+		attrs = append(attrs, "errors", c.Errors.String())
+	}
+	slog.Log(c.Request.Context(), logLevel, "HTTP Request", attrs...)
 }
 
 func getLogLevel(statusCode int) slog.Level {
@@ -52,7 +91,7 @@ func recoveryMiddleware() gin.HandlerFunc {
 
 func handlePanic(c *gin.Context) {
 	if err := recover(); err != nil {
-		slog.Error("패닉 복구",
+		slog.ErrorContext(c.Request.Context(), "패닉 복구",
 			"error", err,
 			"path", c.Request.URL.Path,
 			"method", c.Request.Method,
@@ -77,9 +116,9 @@ func corsMiddleware() gin.HandlerFunc {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		}
 
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin, X-Request-ID, If-None-Match, Idempotency-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Type")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Type, X-Request-ID, ETag, Idempotent-Replayed")
 		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
 
 		// Handle preflight requests
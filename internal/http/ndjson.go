@@ -0,0 +1,72 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ndjsonFlushEvery = 100
+	// exportPageSize is how many rows exports fetch per backend round trip.
+	exportPageSize = 100
+)
+
+// ndjsonStream writes newline-delimited JSON straight to the client. Lines
+// are flushed in small batches and nothing is buffered beyond that, so a
+// slow reader blocks Write and in turn the producer fetching the next page.
+type ndjsonStream struct {
+	c       *gin.Context
+	enc     *json.Encoder
+	pending int
+}
+
+// newNDJSONStream commits a 200 response with attachment headers; errors
+// after this point can only be reported in-band via Fail. The server-wide
+// write timeout is lifted since an export may outlast it.
+func newNDJSONStream(c *gin.Context, filename string) *ndjsonStream {
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	return &ndjsonStream{c: c, enc: json.NewEncoder(c.Writer)}
+}
+
+// Write encodes v as one line. It fails once the client has gone away.
+func (s *ndjsonStream) Write(v any) error {
+	if err := s.c.Request.Context().Err(); err != nil {
+		return err
+	}
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	s.pending++
+	if s.pending >= ndjsonFlushEvery {
+		s.Flush()
+	}
+	return nil
+}
+
+func (s *ndjsonStream) Flush() {
+	s.pending = 0
+	s.c.Writer.Flush()
+}
+
+// Fail ends a stream that broke midway with a final error line, so clients
+// can tell a truncated export from a complete one.
+func (s *ndjsonStream) Fail(err error, message string) {
+	slog.ErrorContext(s.c.Request.Context(), message, "error", err)
+	if s.c.Request.Context().Err() == nil {
+		_ = s.enc.Encode(gin.H{"error": ErrorInfo{
+			Code:      "EXPORT_FAILED",
+			Message:   message,
+			RequestID: s.c.GetString("requestID"),
+		}})
+	}
+	s.Flush()
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NotificationHandler lets admins push notices, such as maintenance windows
+// or newly added knowledge base content, to connected chat clients.
+type NotificationHandler struct {
+	hub *wsHub
+}
+
+func NewNotificationHandler(ws *WebSocketHandler) *NotificationHandler {
+	return &NotificationHandler{hub: ws.hub}
+}
+
+type broadcastRequest struct {
+	Message string `json:"message" binding:"required,max=1000"`
+	// Kind lets clients render notices differently; general by default.
+	Kind string `json:"kind" binding:"omitempty,oneof=general maintenance content"`
+	// Workspace limits the notice to users of one workspace.
+	Workspace string `json:"workspace"`
+}
+
+type announcementPayload struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Message   string    `json:"message"`
+	Workspace string    `json:"workspace,omitempty"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+type broadcastResponse struct {
+	ID         string `json:"id"`
+	Recipients int    `json:"recipients"`
+}
+
+// Broadcast sends an announcement event to every connected WebSocket client
+// of this instance.
+func (h *NotificationHandler) Broadcast(c *gin.Context) {
+	var req broadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = "general"
+	}
+
+	announcement := announcementPayload{
+		ID:        uuid.New().String(),
+		Kind:      req.Kind,
+		Message:   req.Message,
+		Workspace: req.Workspace,
+		SentAt:    time.Now().UTC(),
+	}
+	recipients := h.hub.notify(wsEnvelope{Type: "announcement", Payload: mustMarshal(announcement)}, req.Workspace)
+	slog.InfoContext(c.Request.Context(), "공지 전송",
+		"id", announcement.ID, "kind", req.Kind, "recipients", recipients, "by", c.GetString("userID"))
+
+	SuccessResponse(c, broadcastResponse{ID: announcement.ID, Recipients: recipients})
+}
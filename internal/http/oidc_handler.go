@@ -0,0 +1,103 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"yuon/configuration"
+	"yuon/internal/auth"
+)
+
+const (
+	oidcStateCookie = "yuon_oidc_state"
+	oidcNonceCookie = "yuon_oidc_nonce"
+	oidcCookieTTL   = 600
+)
+
+type OIDCHandler struct {
+	manager  *auth.Manager
+	provider *auth.OIDCProvider
+	cfg      *configuration.OIDCConfig
+}
+
+func NewOIDCHandler(manager *auth.Manager, provider *auth.OIDCProvider, cfg *configuration.OIDCConfig) *OIDCHandler {
+	return &OIDCHandler{manager: manager, provider: provider, cfg: cfg}
+}
+
+// Login redirects the browser to the identity provider.
+func (h *OIDCHandler) Login(c *gin.Context) {
+	state, err := auth.NewOIDCState()
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "OIDC 로그인 준비에 실패했습니다")
+		return
+	}
+	nonce, err := auth.NewOIDCState()
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "OIDC 로그인 준비에 실패했습니다")
+		return
+	}
+
+	target, err := h.provider.AuthCodeURL(c.Request.Context(), state, nonce)
+	if err != nil {
+		c.Error(err)
+		ErrorResponse(c, http.StatusBadGateway, "OIDC_UNAVAILABLE", "OIDC 제공자에 연결할 수 없습니다")
+		return
+	}
+
+	secure := c.Request.TLS != nil
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, oidcCookieTTL, "/", "", secure, true)
+	c.SetCookie(oidcNonceCookie, nonce, oidcCookieTTL, "/", "", secure, true)
+	c.Redirect(http.StatusFound, target)
+}
+
+// Callback completes the authorization code flow and hands the login to
+// the client as a code for /auth/sso/exchange.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if errParam := c.Query("error"); errParam != "" {
+		ErrorResponse(c, http.StatusUnauthorized, "OIDC_DENIED", "OIDC 로그인이 거부되었습니다: "+errParam)
+		return
+	}
+
+	state, _ := c.Cookie(oidcStateCookie)
+	nonce, _ := c.Cookie(oidcNonceCookie)
+	c.SetCookie(oidcStateCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+	c.SetCookie(oidcNonceCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+
+	if state == "" || nonce == "" || c.Query("state") != state {
+		ErrorResponse(c, http.StatusBadRequest, "INVALID_OIDC_STATE", "OIDC 상태 값이 일치하지 않습니다")
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		BadRequestResponse(c, "인가 코드가 필요합니다")
+		return
+	}
+
+	identity, err := h.provider.Exchange(c.Request.Context(), code, nonce)
+	if err != nil {
+		c.Error(err)
+		ErrorResponse(c, http.StatusUnauthorized, "OIDC_FAILED", "OIDC 인증에 실패했습니다")
+		return
+	}
+
+	login, err := h.manager.LoginWithOIDC(identity, h.cfg)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrOIDCEmailNotVerified):
+			ErrorResponse(c, http.StatusForbidden, "OIDC_EMAIL_NOT_VERIFIED", "이메일이 인증되지 않은 계정입니다")
+		case errors.Is(err, auth.ErrOIDCDomainNotAllowed):
+			ErrorResponse(c, http.StatusForbidden, "OIDC_DOMAIN_NOT_ALLOWED", "허용되지 않은 도메인의 계정입니다")
+		case ssoLoginError(c, err):
+		default:
+			c.Error(err)
+			InternalServerErrorResponse(c, "OIDC 로그인에 실패했습니다")
+		}
+		return
+	}
+
+	ssoLoginResponse(c, login, h.cfg.PostLoginRedirect)
+}
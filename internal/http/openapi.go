@@ -0,0 +1,379 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v2"
+	"yuon/internal/alert"
+	"yuon/internal/auth"
+	"yuon/internal/openapi"
+	"yuon/internal/rag"
+	"yuon/internal/rag/service"
+	"yuon/internal/webhook"
+)
+
+// routeDoc describes one route for the generated OpenAPI spec. Request and
+// response schemas are reflected from the values given here, so they follow
+// the structs handlers actually bind and return.
+type routeDoc struct {
+	summary string
+	public  bool
+	// query lists query parameters as "name" or "name:type".
+	query []string
+	// body is the JSON request body; multipartFile marks a file upload.
+	body any
+	// response is the value under "data"; openapi.Object describes gin.H.
+	response any
+	// raw is the content type of a response sent without the JSON envelope.
+	raw       string
+	websocket bool
+}
+
+type multipartFile struct{}
+
+var (
+	msg        = openapi.Object{"message": ""}
+	idMsg      = openapi.Object{"id": "", "message": ""}
+	ssoCode    = openapi.Object{"code": "", "linkRequired": false, "expiresIn": int64(0)}
+	tokenPair  = openapi.Object{"token": "", "expiresIn": int64(0), "refreshToken": "", "user": openapi.Object{"id": "", "email": "", "name": "", "role": ""}}
+	profile    = openapi.Object{"id": "", "email": "", "name": "", "department": "", "avatarUrl": "", "role": "", "workspace": "", "createdAt": ""}
+	userResult = openapi.Object{"id": "", "email": "", "role": "", "workspace": "", "message": ""}
+	uploadDone = openapi.Object{"message": "", "id": "", "fileUrl": "", "fileKey": "", "fileName": ""}
+)
+
+// routeDocs is keyed by "METHOD path" with gin path syntax. Registered
+// routes missing here still appear in the spec, without schemas, and are
+// logged when the spec is built.
+var routeDocs = map[string]routeDoc{
+	"GET /api/v1/health":        {summary: "기본 헬스 체크", public: true, response: HealthCheckResponse{}},
+	"GET /api/v1/system/health": {summary: "시스템 헬스 체크", public: true, response: HealthCheckResponse{}},
+	"GET /api/v1/system/ready":  {summary: "준비 상태 (OpenSearch 장애 시 503)", public: true, response: rag.ReadinessReport{}},
+
+	"POST /api/v1/auth/signup":          {summary: "회원가입 (초대 토큰 필요)", public: true, body: signupRequest{}, response: tokenPair},
+	"POST /api/v1/auth/login":           {summary: "로그인. MFA 계정은 mfaToken 반환", public: true, body: loginRequest{}, response: openapi.Object{"token": "", "expiresIn": int64(0), "refreshToken": "", "user": tokenPair["user"], "mfaRequired": false, "mfaToken": ""}},
+	"POST /api/v1/auth/refresh":         {summary: "리프레시 토큰으로 재발급", public: true, body: refreshRequest{}, response: tokenPair},
+	"POST /api/v1/auth/logout":          {summary: "리프레시 토큰 폐기", public: true, body: refreshRequest{}, response: openapi.Object{"revoked": false}},
+	"POST /api/v1/auth/forgot-password": {summary: "비밀번호 재설정 메일 발송", public: true, body: forgotPasswordRequest{}, response: msg},
+	"POST /api/v1/auth/reset-password":  {summary: "비밀번호 재설정", public: true, body: resetPasswordRequest{}, response: msg},
+	"GET /api/v1/auth/invitation":       {summary: "초대 토큰 조회", public: true, query: []string{"token"}, response: openapi.Object{"email": "", "role": "", "expiresAt": ""}},
+	"POST /api/v1/auth/token":           {summary: "서비스 계정 client credentials 토큰 발급", public: true, body: clientCredentialsRequest{}, response: openapi.Object{"token": "", "tokenType": "", "expiresIn": int64(0)}},
+	"POST /api/v1/auth/mfa/login":       {summary: "MFA 로그인 완료", public: true, body: mfaLoginRequest{}, response: tokenPair},
+	"GET /api/v1/auth/mfa":              {summary: "MFA 상태", response: openapi.Object{"enabled": false, "recoveryCodesRemaining": 0}},
+	"POST /api/v1/auth/mfa/enroll":      {summary: "TOTP 비밀키 발급", response: openapi.Object{"secret": "", "otpauthUrl": ""}},
+	"POST /api/v1/auth/mfa/activate":    {summary: "MFA 활성화 및 복구 코드 발급", body: mfaCodeRequest{}, response: openapi.Object{"enabled": false, "recoveryCodes": []string{}}},
+	"POST /api/v1/auth/mfa/disable":     {summary: "MFA 비활성화", body: mfaCodeRequest{}, response: openapi.Object{"enabled": false}},
+	"GET /api/v1/auth/oidc/login":       {summary: "OIDC 로그인 시작 (IdP로 리다이렉트)", public: true},
+	"GET /api/v1/auth/oidc/callback":    {summary: "OIDC 콜백. POST_LOGIN_REDIRECT가 있으면 #code=로 리다이렉트", public: true, query: []string{"code", "state"}, response: ssoCode},
+	"GET /api/v1/auth/saml/metadata":    {summary: "SAML SP 메타데이터", public: true, raw: "application/samlmetadata+xml"},
+	"GET /api/v1/auth/saml/login":       {summary: "SAML 로그인 시작 (IdP로 리다이렉트)", public: true},
+	"POST /api/v1/auth/saml/acs":        {summary: "SAML Assertion Consumer Service. postLoginRedirect가 있으면 #code=로 리다이렉트", public: true, response: ssoCode},
+	"POST /api/v1/auth/sso/exchange":    {summary: "SSO 로그인 코드를 토큰(또는 MFA 토큰)으로 교환", public: true, body: ssoExchangeRequest{}, response: tokenPair},
+
+	"GET /api/v1/ws": {summary: "챗봇 WebSocket. token 쿼리 또는 첫 authenticate 메시지로 인증. 메시지 스키마는 x-websocket 참고", public: true, query: []string{"token"}, websocket: true},
+
+	"GET /api/v1/analytics/chat":            {summary: "챗봇 사용 통계", response: service.AnalyticsStats{}},
+	"GET /api/v1/analytics/needs":           {summary: "지식 수요 분석과 최신 지식 공백 보고서", response: openapi.Object{"analysis": "", "report": &service.KnowledgeGapReport{}}},
+	"GET /api/v1/analytics/gaps":            {summary: "주간 지식 공백 보고서 목록 (최신 기간부터)", query: []string{"limit:integer"}, response: openapi.Object{"reports": []service.KnowledgeGapReport{}}},
+	"GET /api/v1/analytics/timeseries":      {summary: "기간별 메시지·토큰·활성 사용자 추이", query: []string{"metric", "interval", "from", "to"}, response: service.TimeSeries{}},
+	"GET /api/v1/analytics/hourly":          {summary: "시간별 답변 수와 시각(0~23시)별 합계", query: []string{"from", "to"}, response: service.HourlyRequests{}},
+	"GET /api/v1/analytics/keywords/trends": {summary: "직전 기간 대비 늘어난·줄어든 질문 키워드", query: []string{"days:integer", "limit:integer", "min_count:integer"}, response: service.KeywordTrends{}},
+	"GET /api/v1/analytics/api-keys":        {summary: "API 키 일별 사용량 (root/admin)", query: []string{"days:integer"}, response: openapi.Object{"days": 0, "usage": []auth.APIKeyUsage{}}},
+
+	"GET /api/v1/me": {summary: "내 프로필", response: profile},
+	"PUT /api/v1/me": {summary: "내 프로필 수정", body: updateProfileRequest{}, response: profile},
+
+	"GET /api/v1/users":                        {summary: "사용자 목록 (root)", query: []string{"limit:integer", "cursor"}, response: openapi.Object{"users": []userResponse{}, "nextCursor": "", "hasMore": false}},
+	"POST /api/v1/users":                       {summary: "사용자 생성 (root)", body: createUserRequest{}, response: userResult},
+	"PUT /api/v1/users/:id":                    {summary: "사용자 수정 (root)", body: updateUserRequest{}, response: userResult},
+	"POST /api/v1/users/:id/enable":            {summary: "사용자 활성화 (root)", response: openapi.Object{"id": "", "status": "", "message": ""}},
+	"POST /api/v1/users/:id/disable":           {summary: "사용자 비활성화 (root)", response: openapi.Object{"id": "", "status": "", "message": ""}},
+	"DELETE /api/v1/users/:id":                 {summary: "사용자 삭제 (root)", response: msg},
+	"DELETE /api/v1/users/:id/mfa":             {summary: "사용자 MFA 초기화 (root)", response: msg},
+	"GET /api/v1/service-accounts":             {summary: "서비스 계정 목록 (root)", response: openapi.Object{"serviceAccounts": []serviceAccountResponse{}}},
+	"POST /api/v1/service-accounts":            {summary: "서비스 계정 생성 (root)", body: createServiceAccountRequest{}, response: openapi.Object{"clientId": "", "clientSecret": "", "serviceAccount": serviceAccountResponse{}}},
+	"POST /api/v1/service-accounts/:id/secret": {summary: "클라이언트 시크릿 재발급 (root)", response: openapi.Object{"clientId": "", "clientSecret": ""}},
+	"DELETE /api/v1/service-accounts/:id":      {summary: "서비스 계정 삭제 (root)", response: msg},
+
+	"GET /api/v1/conversations":                                 {summary: "대화 목록 (최근 갱신 순, 기본적으로 보관된 대화 제외, tags는 운영진만)", query: []string{"limit:integer", "cursor", "pinned", "archived", "tags"}, response: openapi.Object{"conversations": []openapi.Object{{"id": "", "preview": "", "summarizedAt": "", "messageCount": 0, "createdAt": "", "tokenUsage": 0, "ownerId": "", "ownerName": "", "pinned": false, "archived": false, "tags": []string{}}}, "nextCursor": "", "hasMore": false}},
+	"GET /api/v1/conversations/export":                          {summary: "대화를 메시지·출처와 함께 NDJSON으로 내보내기 (from/to로 시작 시각 범위 지정)", query: []string{"from", "to"}, raw: "application/x-ndjson"},
+	"GET /api/v1/conversations/:id/export":                      {summary: "대화 기록 다운로드 (JSON 또는 Markdown)", query: []string{"format"}, raw: "application/json"},
+	"GET /api/v1/conversations/search":                          {summary: "대화 메시지 전문 검색 (일치 부분은 <mark>로 강조)", query: []string{"q", "limit:integer", "cursor"}, response: openapi.Object{"conversations": []openapi.Object{{"id": "", "preview": "", "summarizedAt": "", "messageCount": 0, "createdAt": "", "updatedAt": "", "ownerId": "", "ownerName": "", "pinned": false, "archived": false, "tags": []string{}, "matchCount": 0, "matches": []openapi.Object{{"role": "", "snippet": "", "timestamp": ""}}}}, "nextCursor": "", "hasMore": false}},
+	"GET /api/v1/conversations/:id":                             {summary: "대화 메시지 (기본 최신순, order=asc로 오래된 순)", query: []string{"fields", "limit:integer", "cursor", "order"}, response: openapi.Object{"id": "", "messages": []openapi.Object{{"id": "", "role": "", "content": "", "timestamp": "", "redacted": false}}, "nextCursor": "", "hasMore": false}},
+	"DELETE /api/v1/conversations/:id":                          {summary: "대화 삭제", response: msg},
+	"POST /api/v1/conversations/:id/pin":                        {summary: "대화 고정", response: openapi.Object{"id": "", "pinned": true, "message": ""}},
+	"POST /api/v1/conversations/:id/unpin":                      {summary: "대화 고정 해제", response: openapi.Object{"id": "", "pinned": false, "message": ""}},
+	"POST /api/v1/conversations/:id/archive":                    {summary: "대화 보관 (기본 목록에서 제외)", response: openapi.Object{"id": "", "archived": true, "message": ""}},
+	"GET /api/v1/conversations/tags":                            {summary: "사용 중인 대화 태그와 대화 수 (운영진)", response: openapi.Object{"tags": []service.ConversationTagCount{}}},
+	"PATCH /api/v1/conversations/tags/:tag":                     {summary: "대화 태그 이름 변경 (같은 이름의 태그와 병합, 운영진)", body: renameConversationTagRequest{}, response: openapi.Object{"tag": "", "count": 0, "message": ""}},
+	"DELETE /api/v1/conversations/tags/:tag":                    {summary: "모든 대화에서 태그 삭제 (운영진)", response: openapi.Object{"tag": "", "count": 0, "message": ""}},
+	"POST /api/v1/conversations/:id/tags":                       {summary: "대화에 태그 추가 (운영진)", body: conversationTagsRequest{}, response: openapi.Object{"id": "", "tags": []string{}}},
+	"DELETE /api/v1/conversations/:id/tags/:tag":                {summary: "대화에서 태그 제거 (운영진)", response: openapi.Object{"id": "", "tags": []string{}}},
+	"GET /api/v1/conversations/:id/shares":                      {summary: "대화 공유 링크 목록 (토큰 제외)", response: openapi.Object{"shares": []service.ConversationShare{}}},
+	"POST /api/v1/conversations/:id/shares":                     {summary: "만료·취소 가능한 공개 공유 링크 생성 (토큰은 이 응답에만 포함)", body: createShareRequest{}, response: openapi.Object{"token": "", "url": "", "share": service.ConversationShare{}}},
+	"DELETE /api/v1/conversations/:id/shares/:shareId":          {summary: "대화 공유 링크 취소", response: openapi.Object{"id": "", "message": ""}},
+	"GET /api/v1/conversations/:id/usage":                       {summary: "대화의 답변별 입력·출력 토큰과 추정 비용 (USD)", response: service.ConversationUsage{}},
+	"POST /api/v1/conversations/:id/summarize":                  {summary: "대화 요약과 할 일 생성 (목록 미리보기에 요약 표시)", response: openapi.Object{"id": "", "summary": "", "actionItems": []string{}, "summarizedAt": ""}},
+	"POST /api/v1/conversations/:id/unarchive":                  {summary: "대화 보관 해제", response: openapi.Object{"id": "", "archived": false, "message": ""}},
+	"DELETE /api/v1/conversations/:id/messages/:messageId":      {summary: "메시지 삭제", response: openapi.Object{"id": "", "messageId": "", "message": ""}},
+	"POST /api/v1/conversations/:id/messages/:messageId/redact": {summary: "메시지 내용 가리기 (메시지 수 유지)", response: openapi.Object{"id": "", "messageId": "", "message": ""}},
+
+	"GET /api/v1/admin/dashboard":                        {summary: "대시보드 통계와 직전 기간 대비 추세", query: []string{"days:integer"}, response: rag.DashboardStats{}},
+	"GET /api/v1/admin/dashboard/daily":                  {summary: "일별 통계 스냅샷 목록 (기본: 어제까지 30일)", query: []string{"from", "to"}, response: openapi.Object{"from": "", "to": "", "timezone": "", "days": []service.DailyStatsSnapshot{}}},
+	"POST /api/v1/admin/dashboard/daily/backfill":        {summary: "지난 날짜의 일별 통계 스냅샷 보충", body: backfillDailyStatsRequest{}, response: openapi.Object{"recorded": []string{}, "count": 0}},
+	"GET /api/v1/admin/analytics/users":                  {summary: "메시지가 많은 사용자와 사용자별 일별 추이·주제", query: []string{"days:integer", "limit:integer"}, response: openapi.Object{"days": 0, "users": []service.UserUsage{}}},
+	"GET /api/v1/admin/analytics/documents/top":          {summary: "출처로 많이 검색·인용된 문서", query: []string{"days:integer", "limit:integer", "sort"}, response: openapi.Object{"days": 0, "sort": "", "documents": []service.DocumentHitStats{}}},
+	"GET /api/v1/admin/analytics/documents/unused":       {summary: "기간 동안 한 번도 검색되지 않은 문서 (오래된 순)", query: []string{"days:integer", "limit:integer"}, response: openapi.Object{"days": 0, "total": 0, "documents": []service.UnusedDocument{}}},
+	"POST /api/v1/admin/analytics/gaps":                  {summary: "최근 days일의 지식 공백 보고서 즉시 생성", body: knowledgeGapReportRequest{}, response: service.KnowledgeGapReport{}},
+	"GET /api/v1/admin/vectors/snapshots":                {summary: "벡터 스냅샷 목록", response: openapi.Object{"snapshots": []rag.VectorSnapshot{}}},
+	"POST /api/v1/admin/vectors/snapshots":               {summary: "벡터 스냅샷 생성", body: createSnapshotRequest{}, response: rag.VectorSnapshot{}},
+	"POST /api/v1/admin/vectors/snapshots/restore":       {summary: "저장소의 스냅샷으로 복원", body: restoreSnapshotRequest{}, response: openapi.Object{"collection": "", "fileKey": "", "message": ""}},
+	"GET /api/v1/admin/vectors/snapshots/:name/download": {summary: "스냅샷 다운로드", raw: "application/octet-stream"},
+	"POST /api/v1/admin/vectors/snapshots/:name/upload":  {summary: "스냅샷을 저장소에 업로드", response: rag.VectorSnapshot{}},
+	"GET /api/v1/admin/alerts":                           {summary: "경보 규칙별 최근 평가 결과", response: openapi.Object{"intervalSeconds": 0, "rules": []alert.RuleStatus{}}},
+	"GET /api/v1/admin/api-keys":                         {summary: "API 키 목록", response: openapi.Object{"apiKeys": []apiKeyResponse{}}},
+	"POST /api/v1/admin/api-keys":                        {summary: "API 키 발급 (key는 이 응답에서만 확인 가능)", body: createAPIKeyRequest{}, response: openapi.Object{"key": "", "apiKey": apiKeyResponse{}}},
+	"DELETE /api/v1/admin/api-keys/:id":                  {summary: "API 키 폐기", response: msg},
+	"GET /api/v1/admin/jwt-keys":                         {summary: "JWT 서명 키 목록 (root)", response: openapi.Object{"keys": []jwtKeyResponse{}}},
+	"POST /api/v1/admin/jwt-keys/rotate":                 {summary: "JWT 서명 키 교체 (root)", response: openapi.Object{"key": jwtKeyResponse{}, "message": ""}},
+	"GET /api/v1/admin/login-attempts":                   {summary: "로그인 실패·잠금 현황", response: openapi.Object{"attempts": []loginAttemptResponse{}}},
+	"DELETE /api/v1/admin/login-attempts/:scope/:key":    {summary: "로그인 잠금 해제", response: msg},
+	"GET /api/v1/admin/invitations":                      {summary: "초대 목록", response: openapi.Object{"invitations": []invitationResponse{}}},
+	"POST /api/v1/admin/invitations":                     {summary: "초대 생성", body: createInvitationRequest{}, response: openapi.Object{"token": "", "link": "", "invitation": invitationResponse{}}},
+	"DELETE /api/v1/admin/invitations/:id":               {summary: "초대 취소", response: msg},
+	"POST /api/v1/admin/notifications":                   {summary: "접속 중인 WebSocket 클라이언트에 공지 전송", body: broadcastRequest{}, response: broadcastResponse{}},
+	"GET /api/v1/admin/ws/connections":                   {summary: "접속 중인 WebSocket 연결 목록", response: openapi.Object{"connections": []wsConnectionInfo{}, "count": 0}},
+	"GET /api/v1/admin/graphql":                          {summary: "GraphQL 쿼리 (GraphQL 응답 형식)", query: []string{"query", "operationName", "variables"}, raw: "application/json"},
+	"POST /api/v1/admin/graphql":                         {summary: "GraphQL 쿼리 (GraphQL 응답 형식)", body: openapi.Object{"query": "", "operationName": "", "variables": map[string]any{}}, raw: "application/json"},
+	"GET /api/v1/admin/graphql/schema":                   {summary: "GraphQL 스키마 (SDL)", raw: "text/plain"},
+	"GET /api/v1/admin/webhooks":                         {summary: "웹훅 목록", response: openapi.Object{"webhooks": []webhook.Webhook{}}},
+	"GET /api/v1/admin/webhooks/events":                  {summary: "구독 가능한 이벤트", response: openapi.Object{"events": []string{}}},
+	"POST /api/v1/admin/webhooks":                        {summary: "웹훅 등록 (secret은 이 응답에서만 확인 가능)", body: createWebhookRequest{}, response: openapi.Object{"secret": "", "webhook": webhook.Webhook{}}},
+	"PUT /api/v1/admin/webhooks/:id":                     {summary: "웹훅 수정", body: updateWebhookRequest{}, response: openapi.Object{"webhook": webhook.Webhook{}, "secret": ""}},
+	"DELETE /api/v1/admin/webhooks/:id":                  {summary: "웹훅 삭제", response: msg},
+	"GET /api/v1/admin/webhooks/:id/deliveries":          {summary: "웹훅 전송 이력", query: []string{"limit:integer"}, response: openapi.Object{"deliveries": []webhook.Delivery{}}},
+
+	"POST /api/v1/documents/upload":                             {summary: "파일 업로드 후 문서 생성", body: multipartFile{}, response: uploadDone},
+	"POST /api/v1/documents/uploads":                            {summary: "재개 가능한 업로드 세션 생성", body: initUploadRequest{}, response: openapi.Object{"uploadId": "", "fileKey": "", "partSize": int64(0), "expiresAt": ""}},
+	"GET /api/v1/documents/uploads/:uploadId":                   {summary: "수신된 파트 목록", response: openapi.Object{"uploadId": "", "fileName": "", "size": int64(0), "receivedBytes": int64(0), "parts": []openapi.Object{{"partNumber": 0, "etag": "", "size": int64(0)}}}},
+	"PUT /api/v1/documents/uploads/:uploadId/parts/:partNumber": {summary: "파트 바이너리 업로드", response: openapi.Object{"uploadId": "", "partNumber": 0, "etag": "", "size": 0}},
+	"POST /api/v1/documents/uploads/:uploadId/complete":         {summary: "멀티파트 업로드 완료 후 색인", response: uploadDone},
+	"DELETE /api/v1/documents/uploads/:uploadId":                {summary: "업로드 세션 취소", response: openapi.Object{"uploadId": "", "message": ""}},
+	"GET /api/v1/documents":                                     {summary: "문서 목록·검색", query: []string{"page:integer", "pageSize:integer", "q", "category", "tags", "sortBy", "sortOrder", "uploadedAfter", "uploadedBefore", "cursor", "fields"}, response: rag.DocumentListResult{}},
+	"GET /api/v1/documents/export":                              {summary: "목록 필터에 맞는 문서를 NDJSON으로 내보내기", query: []string{"q", "category", "tags", "uploadedAfter", "uploadedBefore"}, raw: "application/x-ndjson"},
+	"GET /api/v1/documents/stats":                               {summary: "문서 통계", response: rag.DashboardStats{}},
+	"GET /api/v1/documents/stats/detailed":                      {summary: "인덱스 상세 통계", response: rag.DetailedIndexStats{}},
+	"GET /api/v1/documents/suggest":                             {summary: "검색어 자동완성", query: []string{"q", "limit:integer"}, response: openapi.Object{"suggestions": []rag.Suggestion{}}},
+	"GET /api/v1/documents/aggregations":                        {summary: "카테고리·태그·월별 집계", query: []string{"size:integer"}, response: rag.DocumentAggregations{}},
+	"POST /api/v1/documents":                                    {summary: "문서 생성", body: rag.Document{}, response: idMsg},
+	"POST /api/v1/documents/bulk-ingest":                        {summary: "문서 일괄 생성", body: []rag.Document{}, response: openapi.Object{"message": "", "count": 0}},
+	"POST /api/v1/documents/bulk":                               {summary: "문서 일괄 생성 (bulk-ingest 별칭)", body: []rag.Document{}, response: openapi.Object{"message": "", "count": 0}},
+	"POST /api/v1/documents/delete-by-filter":                   {summary: "메타데이터 필터로 일괄 삭제 (root/admin)", body: rag.DeleteByFilterRequest{}, response: rag.DeleteByFilterResult{}},
+	"POST /api/v1/documents/reindex":                            {summary: "벡터 재색인", body: rag.ReindexRequest{}, response: rag.ReindexResult{}},
+	"POST /api/v1/documents/index/migrate":                      {summary: "검색 인덱스 마이그레이션", response: rag.IndexMigrationResult{}},
+	"GET /api/v1/documents/vectors/stats":                       {summary: "벡터 컬렉션 통계", response: rag.VectorCollectionStats{}},
+	"POST /api/v1/documents/vectors/query":                      {summary: "벡터 조회", body: rag.VectorQueryRequest{}, response: rag.VectorQueryResponse{}},
+	"POST /api/v1/documents/vectors/projection":                 {summary: "벡터 2D 투영", body: rag.VectorProjectionRequest{}, response: rag.VectorProjectionResponse{}},
+	"GET /api/v1/documents/:id/file":                            {summary: "원본 파일 다운로드", raw: "application/octet-stream"},
+	"GET /api/v1/documents/:id/file/url":                        {summary: "원본 파일 presigned 다운로드 링크", query: []string{"expiresIn:integer"}, response: openapi.Object{"url": "", "filename": "", "expiresAt": ""}},
+	"GET /api/v1/documents/:id/preview":                         {summary: "문서 미리보기", query: []string{"length:integer"}, response: rag.DocumentPreview{}},
+	"GET /api/v1/documents/:id/vector":                          {summary: "문서 벡터 조회", query: []string{"withPayload:boolean"}, response: rag.DocumentVector{}},
+	"GET /api/v1/documents/:id":                                 {summary: "문서 조회", query: []string{"fields"}, response: rag.Document{}},
+	"PUT /api/v1/documents/:id":                                 {summary: "문서 수정", body: rag.Document{}, response: idMsg},
+	"DELETE /api/v1/documents/:id":                              {summary: "문서 삭제", response: idMsg},
+}
+
+// wsMessages lists WebSocket payloads by message type; every frame is a
+// wsEnvelope.
+func wsMessages(g *openapi.Generator) *openapi.WebSocketMessages {
+	conversationOnly := openapi.Object{"conversation_id": ""}
+	return &openapi.WebSocketMessages{
+		Envelope: g.Schema(wsEnvelope{}),
+		Client: map[string]*openapi.Schema{
+			"authenticate":          g.Schema(authenticatePayload{}),
+			"start_conversation":    g.Schema(startConversationPayload{}),
+			"append_message":        g.Schema(appendMessagePayload{}),
+			"cancel_message":        g.Schema(openapi.Object{"message_id": ""}),
+			"cancel_generation":     g.Schema(conversationOnly),
+			"subscribe_ingestion":   g.Schema(openapi.Object{}),
+			"unsubscribe_ingestion": g.Schema(openapi.Object{}),
+			"typing":                g.Schema(conversationOnly),
+			"end_conversation":      g.Schema(conversationOnly),
+		},
+		Server: map[string]*openapi.Schema{
+			"message_ack":          g.Schema(messageAckPayload{}),
+			"stream_chunk":         g.Schema(streamChunkPayload{}),
+			"stream_end":           g.Schema(streamEndPayload{}),
+			"stream_cancelled":     g.Schema(messageAckPayload{}),
+			"typing":               g.Schema(typingPayload{}),
+			"announcement":         g.Schema(announcementPayload{}),
+			"ingestion_progress":   g.Schema(ingestionProgressPayload{}),
+			"conversation_history": g.Schema(conversationHistoryPayload{}),
+			"error":                g.Schema(wsErrorPayload{}),
+			"system_notice":        g.Schema(openapi.Object{"message": "", "conversation_id": ""}),
+		},
+	}
+}
+
+var pathParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+// buildOpenAPI documents every route registered on the engine.
+func (r *Router) buildOpenAPI() *openapi.Document {
+	g := openapi.NewGenerator()
+	errorResponse := g.Component("ErrorResponse", &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"success": {Type: "boolean"},
+			"error":   g.Schema(ErrorInfo{}),
+		},
+	})
+	errorContent := openapi.JSONContent(errorResponse)
+	errorContent[problemContentType] = openapi.MediaType{Schema: g.Schema(Problem{})}
+
+	spec := &openapi.Document{
+		OpenAPI: "3.0.3",
+		Info: openapi.Info{
+			Title:       r.config.App.Name + " API",
+			Version:     r.config.App.Version,
+			Description: "Generated from the registered routes and handler types.",
+		},
+		Servers:  []openapi.Server{{URL: "https://yuon-api.dsmhs.kr"}, {URL: "http://localhost:8080"}},
+		Security: []openapi.SecurityRequirement{{"BearerAuth": {}}},
+		Paths:    make(map[string]map[string]*openapi.Operation),
+	}
+
+	routes := r.engine.Routes()
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	operationIDs := make(map[string]int)
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		doc, ok := routeDocs[route.Method+" "+route.Path]
+		if !ok {
+			slog.Warn("OpenAPI 문서가 없는 라우트", "method", route.Method, "path", route.Path)
+		}
+
+		op := &openapi.Operation{
+			OperationID: handlerName(route.HandlerFunc),
+			Summary:     doc.summary,
+			Tags:        []string{routeTag(route.Path)},
+			Responses:   map[string]openapi.Response{"default": {Description: "오류", Content: errorContent}},
+		}
+		if n := operationIDs[op.OperationID]; n > 0 {
+			op.OperationID += strconv.Itoa(n + 1)
+		}
+		operationIDs[handlerName(route.HandlerFunc)]++
+
+		switch {
+		case doc.public:
+			op.Security = []openapi.SecurityRequirement{{}}
+		case strings.HasPrefix(route.Path, "/api/v1/documents") || strings.HasPrefix(route.Path, "/api/v1/conversations"):
+			op.Security = []openapi.SecurityRequirement{{"BearerAuth": {}}, {"ApiKeyAuth": {}}}
+		}
+
+		for _, m := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+			op.Parameters = append(op.Parameters, openapi.Parameter{Name: m[1], In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}})
+		}
+		for _, q := range doc.query {
+			name, typ, found := strings.Cut(q, ":")
+			if !found {
+				typ = "string"
+			}
+			op.Parameters = append(op.Parameters, openapi.Parameter{Name: name, In: "query", Schema: &openapi.Schema{Type: typ}})
+		}
+
+		switch doc.body.(type) {
+		case nil:
+		case multipartFile:
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+				"multipart/form-data": {Schema: &openapi.Schema{
+					Type:     "object",
+					Required: []string{"file"},
+					Properties: map[string]*openapi.Schema{
+						"file":     {Type: "string", Format: "binary"},
+						"metadata": {Type: "string", Description: "JSON 객체 문자열"},
+					},
+				}},
+			}}
+		default:
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSONContent(g.Schema(doc.body))}
+		}
+
+		switch {
+		case doc.websocket:
+			op.Responses["101"] = openapi.Response{Description: "WebSocket 업그레이드"}
+			op.WebSocket = wsMessages(g)
+		case doc.raw != "":
+			op.Responses["200"] = openapi.Response{Description: "성공", Content: map[string]openapi.MediaType{doc.raw: {Schema: &openapi.Schema{Type: "string", Format: "binary"}}}}
+		default:
+			op.Responses["200"] = openapi.Response{Description: "성공", Content: openapi.JSONContent(&openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"success": {Type: "boolean"},
+					"data":    g.Schema(doc.response),
+				},
+			})}
+		}
+
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		if spec.Paths[path] == nil {
+			spec.Paths[path] = make(map[string]*openapi.Operation)
+		}
+		spec.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	spec.Components = openapi.Components{
+		Schemas: g.Schemas(),
+		SecuritySchemes: map[string]openapi.SecurityScheme{
+			"BearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			"ApiKeyAuth": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+		},
+	}
+	return spec
+}
+
+// openAPISpec renders the spec once, after all routes are registered.
+func (r *Router) openAPISpec() (jsonSpec, yamlSpec []byte) {
+	r.openAPIOnce.Do(func() {
+		spec := r.buildOpenAPI()
+		var err error
+		if r.openAPIJSON, err = json.Marshal(spec); err != nil {
+			slog.Error("OpenAPI JSON 생성 실패", "error", err)
+		}
+		if r.openAPIYAML, err = yaml.Marshal(spec); err != nil {
+			slog.Error("OpenAPI YAML 생성 실패", "error", err)
+		}
+	})
+	return r.openAPIJSON, r.openAPIYAML
+}
+
+// handlerName turns "yuon/internal/http.(*DocumentHandler).GetDocument-fm"
+// into "DocumentHandler.GetDocument".
+func handlerName(h gin.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = strings.TrimSuffix(name, "-fm")
+	if _, rest, ok := strings.Cut(name, "."); ok {
+		name = rest
+	}
+	return strings.NewReplacer("(", "", ")", "", "*", "").Replace(name)
+}
+
+func routeTag(path string) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/api/v1/"), "/")
+	return segment
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is the RFC 7807 form of an error response, sent instead of the
+// usual envelope to clients that list application/problem+json in Accept.
+// Type is derived from the error code, which is also kept as an extension
+// member so both formats carry the same information.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"requestId,omitempty"`
+	// Errors carries ErrorInfo.Details, e.g. field-level validation errors.
+	Errors any `json:"errors,omitempty"`
+}
+
+// problemType maps an error code such as NOT_FOUND to a stable type URI,
+// "urn:yuon:error:not-found".
+func problemType(code string) string {
+	return "urn:yuon:error:" + strings.ReplaceAll(strings.ToLower(code), "_", "-")
+}
+
+func newProblem(c *gin.Context, status int, info *ErrorInfo) Problem {
+	return Problem{
+		Type:      problemType(info.Code),
+		Title:     statusTitle(status),
+		Status:    status,
+		Detail:    info.Message,
+		Instance:  c.Request.URL.Path,
+		Code:      info.Code,
+		RequestID: info.RequestID,
+		Errors:    info.Details,
+	}
+}
+
+func statusTitle(status int) string {
+	if text := http.StatusText(status); text != "" {
+		return text
+	}
+	return "HTTP " + strconv.Itoa(status)
+}
+
+// wantsProblem reports whether the Accept header asks for problem+json with
+// a non-zero quality.
+func wantsProblem(c *gin.Context) bool {
+	for _, part := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != problemContentType {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// writeError sends an error in the format the client negotiated. Error
+// responses vary by Accept, so caches must key on it.
+func writeError(c *gin.Context, status int, info *ErrorInfo) {
+	c.Header("Vary", "Accept")
+	if wantsProblem(c) {
+		if body, err := json.Marshal(newProblem(c, status, info)); err == nil {
+			c.Data(status, problemContentType, body)
+			return
+		}
+	}
+	c.JSON(status, Response{Success: false, Error: info})
+}
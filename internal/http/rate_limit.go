@@ -0,0 +1,104 @@
+package http
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/ratelimit"
+)
+
+type rateLimitRule struct {
+	group string
+	limit ratelimit.Limit
+}
+
+// rateLimit throttles requests per API key or user with a token bucket. Safe
+// methods use read, everything else write. It must run after authMiddleware
+// or apiKeyOrJWT. Limiter failures let the request through.
+func rateLimit(limiter ratelimit.Limiter, read, write rateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule := write
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			rule = read
+		}
+
+		if res, ok := checkRateLimit(c, limiter, rule, rateLimitPrincipal(c)); ok {
+			setRateLimitHeaders(c, res)
+			if !res.Allowed {
+				rejectRateLimited(c, res)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// ipRateLimit throttles public endpoints before authentication: perIP per
+// client address and global across all clients, which caps the total cost of
+// anonymous traffic. The per-IP bucket is checked first so a throttled client
+// does not drain the global one.
+func ipRateLimit(limiter ratelimit.Limiter, perIP, global rateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if res, ok := checkRateLimit(c, limiter, perIP, "ip:"+c.ClientIP()); ok {
+			setRateLimitHeaders(c, res)
+			if !res.Allowed {
+				rejectRateLimited(c, res)
+				return
+			}
+		}
+		if res, ok := checkRateLimit(c, limiter, global, "global"); ok && !res.Allowed {
+			rejectRateLimited(c, res)
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkRateLimit takes a token for principal. ok is false when the rule is
+// disabled or the limiter failed; the request is then allowed.
+func checkRateLimit(c *gin.Context, limiter ratelimit.Limiter, rule rateLimitRule, principal string) (ratelimit.Result, bool) {
+	if limiter == nil || rule.limit.PerMinute <= 0 {
+		return ratelimit.Result{}, false
+	}
+	res, err := limiter.Allow(c.Request.Context(), rateLimitKey(rule.group, principal), rule.limit)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "요청 한도 확인 실패", "error", err, "group", rule.group)
+		return ratelimit.Result{}, false
+	}
+	return res, true
+}
+
+func rejectRateLimited(c *gin.Context, res ratelimit.Result) {
+	c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+	ErrorResponse(c, http.StatusTooManyRequests, "RATE_LIMITED", "요청 한도를 초과했습니다. 잠시 후 다시 시도해주세요")
+	c.Abort()
+}
+
+// rateLimitPrincipal identifies the caller: API key, then user, then IP.
+func rateLimitPrincipal(c *gin.Context) string {
+	if id := c.GetString("apiKeyID"); id != "" {
+		return "key:" + id
+	}
+	if id := c.GetString("userID"); id != "" {
+		return "user:" + id
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func rateLimitKey(group, principal string) string {
+	return "ratelimit:" + group + ":" + principal
+}
+
+func setRateLimitHeaders(c *gin.Context, res ratelimit.Result) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"yuon/configuration"
+	"yuon/internal/ratelimit"
+)
+
+// publicLimitRouter serves /limited behind a one-request-per-IP limit on an
+// engine configured like the server's, trusting proxies.
+func publicLimitRouter(proxies ...string) *gin.Engine {
+	cfg := &configuration.Config{Server: configuration.ServerConfig{Mode: "release", TrustedProxies: proxies}}
+	engine := NewRouter(cfg, nil, nil).engine
+	limit := ipRateLimit(ratelimit.NewMemoryLimiter(),
+		rateLimitRule{group: "public", limit: ratelimit.Limit{PerMinute: 1, Burst: 1}},
+		rateLimitRule{group: "public"},
+	)
+	engine.GET("/limited", limit, func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine
+}
+
+func requestFrom(engine *gin.Engine, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.Header.Set("X-Real-IP", forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestIPRateLimitIgnoresForgedForwardedFor(t *testing.T) {
+	engine := publicLimitRouter()
+
+	if code := requestFrom(engine, "203.0.113.7:5000", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("first request = %d, want 200", code)
+	}
+	if code := requestFrom(engine, "203.0.113.7:5001", "198.51.100.2"); code != http.StatusTooManyRequests {
+		t.Errorf("request with a new X-Forwarded-For = %d, want 429", code)
+	}
+	if code := requestFrom(engine, "203.0.113.8:5000", ""); code != http.StatusOK {
+		t.Errorf("request from another peer = %d, want 200", code)
+	}
+}
+
+func TestIPRateLimitBehindTrustedProxy(t *testing.T) {
+	engine := publicLimitRouter("10.0.0.0/8")
+
+	if code := requestFrom(engine, "10.0.0.2:5000", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("first client = %d, want 200", code)
+	}
+	if code := requestFrom(engine, "10.0.0.2:5001", "198.51.100.2"); code != http.StatusOK {
+		t.Errorf("second client behind the proxy = %d, want 200", code)
+	}
+	if code := requestFrom(engine, "10.0.0.3:5000", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Errorf("first client again = %d, want 429", code)
+	}
+}
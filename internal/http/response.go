@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"yuon/package/validator"
 )
 
 type Response struct {
@@ -15,6 +16,9 @@ type Response struct {
 type ErrorInfo struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+	// RequestID matches the X-Request-ID response header.
+	RequestID string `json:"requestId,omitempty"`
 }
 
 func SuccessResponse(c *gin.Context, data interface{}) {
@@ -25,23 +29,51 @@ func SuccessResponse(c *gin.Context, data interface{}) {
 }
 
 func ErrorResponse(c *gin.Context, statusCode int, code string, message string) {
-	c.JSON(statusCode, Response{
-		Success: false,
-		Error: &ErrorInfo{
-			Code:    code,
-			Message: message,
-		},
+	writeError(c, statusCode, &ErrorInfo{
+		Code:      code,
+		Message:   message,
+		RequestID: c.GetString("requestID"),
 	})
 }
 
+func ErrorResponseWithDetails(c *gin.Context, statusCode int, code string, message string, details any) {
+	writeError(c, statusCode, &ErrorInfo{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: c.GetString("requestID"),
+	})
+}
+
+func ValidationErrorResponse(c *gin.Context, message string, details any) {
+	ErrorResponseWithDetails(c, http.StatusBadRequest, string(ErrValidation), message, details)
+}
+
 func BadRequestResponse(c *gin.Context, message string) {
 	ErrorResponse(c, http.StatusBadRequest, "BAD_REQUEST", message)
 }
 
+// BindErrorResponse reports a ShouldBind failure. Rule violations and type
+// mismatches become a VALIDATION_ERROR listing each field in details;
+// anything else, such as malformed JSON, is a plain BAD_REQUEST with message.
+func BindErrorResponse(c *gin.Context, err error, message string) {
+	if fields := validator.GetValidationErrors(err); len(fields) > 0 {
+		ValidationErrorResponse(c, "입력값이 올바르지 않습니다", fields)
+		return
+	}
+	BadRequestResponse(c, message)
+}
+
 func NotFoundResponse(c *gin.Context, message string) {
 	ErrorResponse(c, http.StatusNotFound, "NOT_FOUND", message)
 }
 
+// InternalServerErrorResponse reports a server failure, or a 504 when the
+// failure came from the route's request timeout expiring.
 func InternalServerErrorResponse(c *gin.Context, message string) {
+	if requestTimedOut(c) {
+		timeoutResponse(c)
+		return
+	}
 	ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", message)
 }
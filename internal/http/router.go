@@ -1,13 +1,22 @@
 package http
 
 import (
-	"net/http"
+	"sync"
+	"time"
 
 	"yuon/configuration"
-	"yuon/docs"
+	"yuon/internal/alert"
+	"yuon/internal/antivirus"
+	"yuon/internal/audit"
 	"yuon/internal/auth"
+	"yuon/internal/auth/saml"
+	"yuon/internal/idempotency"
+	"yuon/internal/mail"
 	"yuon/internal/rag/service"
+	"yuon/internal/ratelimit"
 	"yuon/internal/storage"
+	"yuon/internal/upload"
+	"yuon/internal/webhook"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,12 +27,31 @@ type Router struct {
 	chatbotService *service.ChatbotService
 	authManager    *auth.Manager
 	storage        storage.FileStorage
+	auditLogger    audit.Logger
+	mailer         mail.Mailer
+	oidcProvider   *auth.OIDCProvider
+	samlSP         *saml.ServiceProvider
+	rateLimiter    ratelimit.Limiter
+	webhooks       *webhook.Dispatcher
+	alerts         *alert.Monitor
+	idempotency    idempotency.Store
+	idempotencyTTL time.Duration
+	uploads        upload.Store
+
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+	openAPIYAML []byte
 }
 
 func NewRouter(cfg *configuration.Config, authManager *auth.Manager, storage storage.FileStorage) *Router {
 	setGinMode(cfg.Server.Mode)
 
 	engine := gin.New()
+	// 신뢰하는 프록시가 없으면 X-Forwarded-For를 무시하고 접속 주소를 사용
+	if err := engine.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		panic("invalid SERVER_TRUSTED_PROXIES: " + err.Error())
+	}
+	engine.Use(requestIDMiddleware())
 	engine.Use(slogMiddleware())
 	engine.Use(recoveryMiddleware())
 	engine.Use(corsMiddleware())
@@ -40,6 +68,51 @@ func (r *Router) SetChatbotService(service *service.ChatbotService) {
 	r.chatbotService = service
 }
 
+func (r *Router) SetAuditLogger(logger audit.Logger) {
+	r.auditLogger = logger
+}
+
+func (r *Router) SetMailer(mailer mail.Mailer) {
+	r.mailer = mailer
+}
+
+// SetOIDCProvider enables the /auth/oidc routes.
+func (r *Router) SetOIDCProvider(provider *auth.OIDCProvider) {
+	r.oidcProvider = provider
+}
+
+// SetSAMLServiceProvider enables the /auth/saml routes.
+func (r *Router) SetSAMLServiceProvider(sp *saml.ServiceProvider) {
+	r.samlSP = sp
+}
+
+// SetRateLimiter enables per-user/per-API-key limits on chat and document routes.
+func (r *Router) SetRateLimiter(limiter ratelimit.Limiter) {
+	r.rateLimiter = limiter
+}
+
+// SetWebhookDispatcher enables /admin/webhooks and event delivery.
+func (r *Router) SetWebhookDispatcher(dispatcher *webhook.Dispatcher) {
+	r.webhooks = dispatcher
+}
+
+// SetAlertMonitor enables /admin/alerts.
+func (r *Router) SetAlertMonitor(monitor *alert.Monitor) {
+	r.alerts = monitor
+}
+
+// SetIdempotencyStore enables Idempotency-Key on document create, upload and
+// bulk ingest; stored responses are replayed for ttl.
+func (r *Router) SetIdempotencyStore(store idempotency.Store, ttl time.Duration) {
+	r.idempotency = store
+	r.idempotencyTTL = ttl
+}
+
+// SetUploadSessionStore enables the resumable /documents/uploads routes.
+func (r *Router) SetUploadSessionStore(store upload.Store) {
+	r.uploads = store
+}
+
 func setGinMode(mode string) {
 	if mode == "debug" {
 		gin.SetMode(gin.DebugMode)
@@ -65,100 +138,255 @@ func (r *Router) SetupRoutes() {
 	{
 		v1.GET("/health", r.healthCheck)
 		v1.GET("/system/health", r.healthCheck)
+		v1.GET("/system/ready", r.readinessCheck)
+
+		// WebSocket, export and download routes go without a timeout.
+		timeout := requestTimeout(r.config.Server.RequestTimeout)
+		longTimeout := requestTimeout(r.config.Server.LongRequestTimeout)
 
-		authHandler := NewAuthHandler(r.authManager)
-		v1.POST("/auth/signup", authHandler.Signup)
-		v1.POST("/auth/login", authHandler.Login)
+		limits := r.config.RateLimit
+		chatLimit := rateLimitRule{group: "chat", limit: ratelimit.Limit{PerMinute: limits.ChatPerMinute, Burst: limits.ChatBurst}}
+		docsLimit := rateLimitRule{group: "documents", limit: ratelimit.Limit{PerMinute: limits.DocumentsPerMinute, Burst: limits.DocumentsBurst}}
+		ingestLimit := rateLimitRule{group: "ingest", limit: ratelimit.Limit{PerMinute: limits.IngestPerMinute, Burst: limits.IngestBurst}}
+		publicLimit := ipRateLimit(r.rateLimiter,
+			rateLimitRule{group: "public", limit: ratelimit.Limit{PerMinute: limits.PublicPerMinute, Burst: limits.PublicBurst}},
+			rateLimitRule{group: "public", limit: ratelimit.Limit{PerMinute: limits.GlobalPerMinute, Burst: limits.GlobalBurst}},
+		)
 
-		wsHandler := NewWebSocketHandler(r.chatbotService)
-		v1.GET("/ws", wsHandler.Handle)
+		authHandler := NewAuthHandler(r.authManager, r.mailer, r.config.Auth.PasswordResetURL)
+		v1.POST("/auth/signup", timeout, publicLimit, authHandler.Signup)
+		v1.POST("/auth/login", timeout, publicLimit, authHandler.Login)
+		v1.POST("/auth/refresh", timeout, authHandler.Refresh)
+		v1.POST("/auth/logout", timeout, authHandler.Logout)
+		v1.POST("/auth/forgot-password", timeout, publicLimit, authHandler.ForgotPassword)
+		v1.POST("/auth/reset-password", timeout, publicLimit, authHandler.ResetPassword)
 
-		analyticsHandler := NewAnalyticsHandler(r.chatbotService)
+		invitations := NewInvitationHandler(r.authManager, r.mailer, r.config.Auth.InvitationURL)
+		v1.GET("/auth/invitation", timeout, publicLimit, invitations.Lookup)
+
+		serviceAccounts := NewServiceAccountHandler(r.authManager)
+		v1.POST("/auth/token", timeout, publicLimit, serviceAccounts.Token)
+
+		mfaHandler := NewMFAHandler(r.authManager)
+		v1.POST("/auth/mfa/login", timeout, publicLimit, mfaHandler.Login)
+		mfaGroup := v1.Group("/auth/mfa")
+		mfaGroup.Use(timeout, authMiddleware(r.authManager))
+		{
+			mfaGroup.GET("", mfaHandler.Status)
+			mfaGroup.POST("/enroll", mfaHandler.Enroll)
+			mfaGroup.POST("/activate", mfaHandler.Activate)
+			mfaGroup.POST("/disable", publicLimit, mfaHandler.Disable)
+		}
+
+		if r.oidcProvider != nil {
+			oidcHandler := NewOIDCHandler(r.authManager, r.oidcProvider, &r.config.OIDC)
+			v1.GET("/auth/oidc/login", timeout, oidcHandler.Login)
+			v1.GET("/auth/oidc/callback", timeout, oidcHandler.Callback)
+		}
+
+		if r.oidcProvider != nil || r.samlSP != nil {
+			v1.POST("/auth/sso/exchange", timeout, publicLimit, authHandler.ExchangeSSOCode)
+		}
+
+		if r.samlSP != nil {
+			samlHandler := NewSAMLHandler(r.authManager, r.samlSP)
+			v1.GET("/auth/saml/metadata", timeout, samlHandler.Metadata)
+			v1.GET("/auth/saml/login", timeout, samlHandler.Login)
+			v1.POST("/auth/saml/acs", timeout, samlHandler.ACS)
+		}
+
+		wsHandler := NewWebSocketHandler(r.chatbotService, r.authManager)
+		wsHandler.setRateLimit(r.rateLimiter, chatLimit)
+		wsHandler.setMessageLimit(ratelimit.Limit{PerMinute: r.config.WebSocket.MessagesPerMinute, Burst: r.config.WebSocket.MessageBurst})
+		wsHandler.setWebhooks(r.webhooks)
+		wsHandler.setAllowAnonymous(r.config.App.Environment == "development")
+		wsHandler.setHeartbeat(r.config.WebSocket.PingInterval, r.config.WebSocket.IdleTimeout)
+		wsHandler.setDashboardInterval(r.config.WebSocket.DashboardInterval)
+		v1.GET("/ws", publicLimit, wsHandler.Handle)
+		if r.config.Server.MetricsEnabled {
+			r.engine.GET("/metrics", append(metricsAuth(r.config.Server.MetricsToken, r.authManager), wsHandler.Metrics)...)
+		}
+
+		loc, _ := r.config.Analytics.Location()
+		analyticsHandler := NewAnalyticsHandler(r.chatbotService, loc)
+		apiKeys := NewAPIKeyHandler(r.authManager)
 		analyticsGroup := v1.Group("/analytics")
-		analyticsGroup.Use(authMiddleware(r.authManager))
+		analyticsGroup.Use(timeout, authMiddleware(r.authManager))
 		{
 			analyticsGroup.GET("/chat", analyticsHandler.ChatStats)
 			analyticsGroup.GET("/needs", analyticsHandler.KnowledgeNeed)
+			analyticsGroup.GET("/gaps", requireRoles("root", "admin"), analyticsHandler.KnowledgeGapReports)
+			analyticsGroup.GET("/timeseries", requireRoles("root", "admin"), analyticsHandler.TimeSeries)
+			analyticsGroup.GET("/hourly", requireRoles("root", "admin"), analyticsHandler.HourlyRequests)
+			analyticsGroup.GET("/keywords/trends", requireRoles("root", "admin"), analyticsHandler.KeywordTrends)
+			analyticsGroup.GET("/api-keys", requireRoles("root", "admin"), apiKeys.Usage)
 		}
 
 		// Users
 		userHandler := NewUserHandler(r.authManager)
+		meGroup := v1.Group("/me")
+		meGroup.Use(timeout, authMiddleware(r.authManager))
+		{
+			meGroup.GET("", userHandler.Me)
+			meGroup.PUT("", userHandler.UpdateMe)
+		}
+
 		userGroup := v1.Group("/users")
-		userGroup.Use(authMiddleware(r.authManager))
+		userGroup.Use(timeout, authMiddleware(r.authManager), requireRoles("root"))
 		{
 			userGroup.GET("", userHandler.List)
 			userGroup.POST("", userHandler.Create)
+			userGroup.PUT("/:id", userHandler.Update)
+			userGroup.POST("/:id/enable", userHandler.Enable)
+			userGroup.POST("/:id/disable", userHandler.Disable)
 			userGroup.DELETE("/:id", userHandler.Delete)
+			userGroup.DELETE("/:id/mfa", mfaHandler.Reset)
+		}
+
+		saGroup := v1.Group("/service-accounts")
+		saGroup.Use(timeout, authMiddleware(r.authManager), requireRoles("root"))
+		{
+			saGroup.GET("", serviceAccounts.List)
+			saGroup.POST("", serviceAccounts.Create)
+			saGroup.POST("/:id/secret", serviceAccounts.RotateSecret)
+			saGroup.DELETE("/:id", serviceAccounts.Delete)
 		}
 
 		// Conversations
-		conversationHandler := NewConversationHandler(r.chatbotService)
+		// Prices were validated when the configuration was loaded.
+		prices, _ := r.config.OpenAI.ModelPrices()
+		conversationHandler := NewConversationHandler(r.chatbotService, r.config.Share, prices)
+		r.engine.GET("/share/:token", timeout, publicLimit, conversationHandler.ViewShare)
 		convGroup := v1.Group("/conversations")
-		convGroup.Use(authMiddleware(r.authManager))
+		convGroup.Use(apiKeyOrJWT(r.authManager), rateLimit(r.rateLimiter, chatLimit, chatLimit))
 		{
-			convGroup.GET("", conversationHandler.List)
-			convGroup.GET("/:id", conversationHandler.Detail)
-			convGroup.DELETE("/:id", conversationHandler.Delete)
+			readChat := requirePermission(auth.ScopeChatRead)
+			writeChat := requirePermission(auth.ScopeChatWrite)
+			convGroup.GET("", timeout, readChat, conversationHandler.List)
+			convGroup.GET("/export", readChat, conversationHandler.Export)
+			convGroup.GET("/search", timeout, readChat, conversationHandler.Search)
+			convGroup.GET("/tags", timeout, readChat, staffConversations, conversationHandler.Tags)
+			convGroup.PATCH("/tags/:tag", timeout, writeChat, staffConversations, conversationHandler.RenameTag)
+			convGroup.DELETE("/tags/:tag", timeout, writeChat, staffConversations, conversationHandler.DeleteTag)
+			convGroup.GET("/:id", timeout, readChat, conversationHandler.Detail)
+			convGroup.GET("/:id/export", timeout, readChat, conversationHandler.ExportOne)
+			convGroup.GET("/:id/usage", timeout, readChat, conversationHandler.Usage)
+			convGroup.DELETE("/:id", timeout, writeChat, conversationHandler.Delete)
+			convGroup.POST("/:id/pin", timeout, writeChat, conversationHandler.Pin)
+			convGroup.POST("/:id/unpin", timeout, writeChat, conversationHandler.Unpin)
+			convGroup.POST("/:id/archive", timeout, writeChat, conversationHandler.Archive)
+			convGroup.POST("/:id/unarchive", timeout, writeChat, conversationHandler.Unarchive)
+			convGroup.POST("/:id/summarize", longTimeout, writeChat, conversationHandler.Summarize)
+			convGroup.GET("/:id/shares", timeout, readChat, conversationHandler.Shares)
+			convGroup.POST("/:id/shares", timeout, writeChat, conversationHandler.CreateShare)
+			convGroup.DELETE("/:id/shares/:shareId", timeout, writeChat, conversationHandler.RevokeShare)
+			convGroup.POST("/:id/tags", timeout, writeChat, staffConversations, conversationHandler.AddTags)
+			convGroup.DELETE("/:id/tags/:tag", timeout, writeChat, staffConversations, conversationHandler.RemoveTag)
+			convGroup.DELETE("/:id/messages/:messageId", timeout, writeChat, conversationHandler.DeleteMessage)
+			convGroup.POST("/:id/messages/:messageId/redact", timeout, writeChat, conversationHandler.RedactMessage)
 		}
 
-		documents := NewDocumentHandler(r.chatbotService, r.storage)
+		snapshots := NewSnapshotHandler(r.chatbotService, r.storage)
+		adminGroup := v1.Group("/admin")
+		adminGroup.Use(authMiddleware(r.authManager), requireRoles("root", "admin"))
+		{
+			adminGroup.GET("/dashboard", timeout, analyticsHandler.Dashboard)
+			adminGroup.GET("/dashboard/daily", timeout, analyticsHandler.DailyStats)
+			adminGroup.POST("/dashboard/daily/backfill", longTimeout, analyticsHandler.BackfillDailyStats)
+			adminGroup.GET("/analytics/users", timeout, analyticsHandler.Users)
+			adminGroup.GET("/analytics/documents/top", timeout, analyticsHandler.TopDocuments)
+			adminGroup.GET("/analytics/documents/unused", longTimeout, analyticsHandler.UnusedDocuments)
+			adminGroup.POST("/analytics/gaps", longTimeout, analyticsHandler.CreateKnowledgeGapReport)
+
+			adminGroup.GET("/vectors/snapshots", timeout, snapshots.List)
+			adminGroup.POST("/vectors/snapshots", longTimeout, snapshots.Create)
+			adminGroup.POST("/vectors/snapshots/restore", longTimeout, snapshots.Restore)
+			adminGroup.GET("/vectors/snapshots/:name/download", snapshots.Download)
+			adminGroup.POST("/vectors/snapshots/:name/upload", longTimeout, snapshots.UploadToStorage)
+
+			adminGroup.GET("/api-keys", timeout, apiKeys.List)
+			adminGroup.POST("/api-keys", timeout, apiKeys.Create)
+			adminGroup.DELETE("/api-keys/:id", timeout, apiKeys.Revoke)
+
+			jwtKeys := NewJWTKeyHandler(r.authManager)
+			adminGroup.GET("/jwt-keys", timeout, requireRoles("root"), jwtKeys.List)
+			adminGroup.POST("/jwt-keys/rotate", timeout, requireRoles("root"), jwtKeys.Rotate)
+
+			loginAttempts := NewLoginAttemptHandler(r.authManager)
+			adminGroup.GET("/login-attempts", timeout, loginAttempts.List)
+			adminGroup.DELETE("/login-attempts/:scope/:key", timeout, loginAttempts.Unlock)
+
+			notifications := NewNotificationHandler(wsHandler)
+			adminGroup.POST("/notifications", timeout, notifications.Broadcast)
+			adminGroup.GET("/ws/connections", timeout, wsHandler.Connections)
+
+			adminGroup.GET("/invitations", timeout, invitations.List)
+			adminGroup.POST("/invitations", timeout, invitations.Create)
+			adminGroup.DELETE("/invitations/:id", timeout, invitations.Revoke)
+
+			graphqlHandler := NewGraphQLHandler(r.chatbotService, r.authManager)
+			adminGroup.GET("/graphql", timeout, graphqlHandler.Query)
+			adminGroup.POST("/graphql", timeout, graphqlHandler.Query)
+			adminGroup.GET("/graphql/schema", timeout, graphqlHandler.Schema)
+
+			if r.webhooks != nil {
+				webhooks := NewWebhookHandler(r.webhooks)
+				adminGroup.GET("/webhooks", timeout, webhooks.List)
+				adminGroup.GET("/webhooks/events", timeout, webhooks.Events)
+				adminGroup.POST("/webhooks", timeout, webhooks.Create)
+				adminGroup.PUT("/webhooks/:id", timeout, webhooks.Update)
+				adminGroup.DELETE("/webhooks/:id", timeout, webhooks.Delete)
+				adminGroup.GET("/webhooks/:id/deliveries", timeout, webhooks.Deliveries)
+			}
+			if r.alerts != nil {
+				adminGroup.GET("/alerts", timeout, NewAlertHandler(r.alerts).Status)
+			}
+		}
+
+		documents := NewDocumentHandler(r.chatbotService, r.storage, &r.config.Document, antivirus.NewScanner(&r.config.Antivirus), r.auditLogger, r.webhooks)
+		documents.setIngestionProgress(wsHandler.hub)
+		if r.uploads != nil {
+			documents.setUploadSessions(r.uploads)
+		}
 
 		docGroup := v1.Group("/documents")
-		docGroup.Use(authMiddleware(r.authManager))
+		docGroup.Use(apiKeyOrJWT(r.authManager), rateLimit(r.rateLimiter, docsLimit, ingestLimit))
 		{
-			docGroup.POST("/upload", documents.UploadDocument)
-			docGroup.GET("", documents.ListDocuments)
-			docGroup.GET("/stats", documents.GetStats)
-			docGroup.POST("", documents.CreateDocument)
-			docGroup.POST("/bulk-ingest", documents.BulkIngestDocuments)
-			docGroup.POST("/bulk", documents.BulkIngestDocuments)
-			docGroup.POST("/reindex", documents.ReindexDocuments)
-			docGroup.POST("/vectors/query", documents.QueryDocumentVectors)
-			docGroup.POST("/vectors/projection", documents.ProjectVectors)
-			docGroup.GET("/:id/file", documents.DownloadDocumentFile)
-			docGroup.GET("/:id/vector", documents.FetchDocumentVector)
-			docGroup.GET("/:id", documents.GetDocument)
-			docGroup.PUT("/:id", documents.UpdateDocument)
-			docGroup.DELETE("/:id", documents.DeleteDocument)
+			readDocs := requirePermission(auth.ScopeDocumentsRead)
+			writeDocs := requirePermission(auth.ScopeDocumentsWrite)
+			idempotent := idempotencyKey(r.idempotency, r.idempotencyTTL)
+			docGroup.POST("/upload", longTimeout, writeDocs, idempotent, documents.UploadDocument)
+			docGroup.POST("/uploads", timeout, writeDocs, documents.InitResumableUpload)
+			docGroup.GET("/uploads/:uploadId", timeout, writeDocs, documents.GetResumableUpload)
+			docGroup.PUT("/uploads/:uploadId/parts/:partNumber", longTimeout, writeDocs, documents.UploadResumablePart)
+			docGroup.POST("/uploads/:uploadId/complete", longTimeout, writeDocs, documents.CompleteResumableUpload)
+			docGroup.DELETE("/uploads/:uploadId", timeout, writeDocs, documents.AbortResumableUpload)
+			docGroup.GET("", timeout, readDocs, documents.ListDocuments)
+			docGroup.GET("/export", readDocs, documents.ExportDocuments)
+			docGroup.GET("/stats", timeout, readDocs, documents.GetStats)
+			docGroup.GET("/stats/detailed", timeout, readDocs, documents.GetDetailedStats)
+			docGroup.GET("/suggest", timeout, readDocs, documents.SuggestDocuments)
+			docGroup.GET("/aggregations", timeout, readDocs, documents.GetAggregations)
+			docGroup.POST("", timeout, writeDocs, idempotent, documents.CreateDocument)
+			docGroup.POST("/bulk-ingest", longTimeout, writeDocs, idempotent, documents.BulkIngestDocuments)
+			docGroup.POST("/bulk", longTimeout, writeDocs, idempotent, documents.BulkIngestDocuments)
+			docGroup.POST("/delete-by-filter", longTimeout, writeDocs, requireRoles("root", "admin"), documents.DeleteDocumentsByFilter)
+			docGroup.POST("/reindex", longTimeout, writeDocs, documents.ReindexDocuments)
+			docGroup.POST("/index/migrate", longTimeout, writeDocs, requireRoles("root", "admin"), documents.MigrateSearchIndex)
+			docGroup.GET("/vectors/stats", timeout, readDocs, documents.GetVectorStats)
+			docGroup.POST("/vectors/query", timeout, readDocs, documents.QueryDocumentVectors)
+			docGroup.POST("/vectors/projection", longTimeout, readDocs, documents.ProjectVectors)
+			docGroup.GET("/:id/file", readDocs, documents.DownloadDocumentFile)
+			docGroup.GET("/:id/file/url", timeout, readDocs, documents.DocumentFileURL)
+			docGroup.GET("/:id/preview", timeout, readDocs, documents.PreviewDocument)
+			docGroup.GET("/:id/vector", timeout, readDocs, documents.FetchDocumentVector)
+			docGroup.GET("/:id", timeout, readDocs, documents.GetDocument)
+			docGroup.PUT("/:id", timeout, writeDocs, documents.UpdateDocument)
+			docGroup.DELETE("/:id", timeout, writeDocs, documents.DeleteDocument)
 		}
 	}
 }
 
-func (r *Router) registerSwaggerRoutes() {
-	r.engine.GET("/docs/openapi.yaml", func(c *gin.Context) {
-		c.Data(http.StatusOK, "application/yaml", docs.OpenAPISpec)
-	})
-
-	r.engine.GET("/docs", func(c *gin.Context) {
-		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerHTML))
-	})
-}
-
-const swaggerHTML = `<!DOCTYPE html>
-<html lang="ko">
-  <head>
-    <meta charset="UTF-8" />
-    <title>YUON API Docs</title>
-    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
-    <style>
-      body { margin: 0; background: #fff; }
-      #swagger-ui { max-width: 960px; margin: 0 auto; }
-    </style>
-  </head>
-  <body>
-    <div id="swagger-ui"></div>
-    <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
-    <script>
-      window.onload = () => {
-        SwaggerUIBundle({
-          url: '/docs/openapi.yaml',
-          dom_id: '#swagger-ui',
-        });
-      };
-    </script>
-  </body>
-</html>`
-
 func (r *Router) Run(addr string) error {
 	return r.engine.Run(addr)
 }
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth"
+	"yuon/internal/auth/saml"
+)
+
+const (
+	samlRelayCookie    = "yuon_saml_relay"
+	samlRelayCookieTTL = 600
+)
+
+type SAMLHandler struct {
+	manager *auth.Manager
+	sp      *saml.ServiceProvider
+}
+
+func NewSAMLHandler(manager *auth.Manager, sp *saml.ServiceProvider) *SAMLHandler {
+	return &SAMLHandler{manager: manager, sp: sp}
+}
+
+// Metadata serves the SP metadata to register with the IdP.
+func (h *SAMLHandler) Metadata(c *gin.Context) {
+	c.Data(http.StatusOK, "application/samlmetadata+xml", h.sp.Metadata())
+}
+
+// Login starts SP-initiated SSO by redirecting to the IdP.
+func (h *SAMLHandler) Login(c *gin.Context) {
+	target, relayState, err := h.sp.AuthnRequestURL(time.Now())
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "SAML 로그인 요청 생성에 실패했습니다")
+		return
+	}
+
+	// The IdP posts back cross-site, which only SameSite=None cookies
+	// survive, and browsers require Secure for those.
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie(samlRelayCookie, relayState, samlRelayCookieTTL, "/", "", true, true)
+	c.Redirect(http.StatusFound, target)
+}
+
+// ACS validates the posted assertion and hands the login to the client
+// as a code for /auth/sso/exchange.
+func (h *SAMLHandler) ACS(c *gin.Context) {
+	encoded := c.PostForm("SAMLResponse")
+	if encoded == "" {
+		BadRequestResponse(c, "SAMLResponse가 필요합니다")
+		return
+	}
+
+	// A RelayState must come from a login this browser started; otherwise a
+	// response obtained by someone else could sign the browser in as them.
+	relayState := c.PostForm("RelayState")
+	cookie, _ := c.Cookie(samlRelayCookie)
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie(samlRelayCookie, "", -1, "/", "", true, true)
+	if relayState != "" && subtle.ConstantTimeCompare([]byte(cookie), []byte(relayState)) != 1 {
+		ErrorResponse(c, http.StatusBadRequest, "INVALID_SAML_STATE", "SAML 상태 값이 일치하지 않습니다")
+		return
+	}
+
+	assertion, err := h.sp.ParseResponse(c.Request.Context(), encoded, relayState, time.Now())
+	if err != nil {
+		c.Error(err)
+		ErrorResponse(c, http.StatusUnauthorized, "SAML_FAILED", "SAML 인증에 실패했습니다")
+		return
+	}
+
+	login, err := h.manager.LoginFederated(auth.FederatedIdentity{
+		Provider: "saml:" + h.sp.Config().IdP.EntityID,
+		Subject:  assertion.NameID,
+		Email:    assertion.Email,
+		Role:     assertion.Role,
+	})
+	if err != nil {
+		if !ssoLoginError(c, err) {
+			c.Error(err)
+			InternalServerErrorResponse(c, "SAML 로그인에 실패했습니다")
+		}
+		return
+	}
+
+	ssoLoginResponse(c, login, h.sp.Config().PostLoginRedirect)
+}
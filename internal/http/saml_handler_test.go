@@ -0,0 +1,83 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth/saml"
+)
+
+func samlTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	sp := saml.NewServiceProvider(&saml.Config{
+		EntityID: "https://sp.example.com/metadata",
+		ACSURL:   "https://sp.example.com/acs",
+		IdP:      saml.IdPConfig{EntityID: "https://idp.example.com", SSOURL: "https://idp.example.com/sso"},
+	}, []byte("secret"))
+	h := NewSAMLHandler(nil, sp)
+
+	engine := gin.New()
+	engine.GET("/saml/login", h.Login)
+	engine.POST("/saml/acs", h.ACS)
+	return engine
+}
+
+func TestSAMLRelayStateBoundToCookie(t *testing.T) {
+	engine := samlTestRouter()
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/saml/login", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login status = %d", rec.Code)
+	}
+	target, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	relayState := target.Query().Get("RelayState")
+
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == samlRelayCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != relayState {
+		t.Fatalf("relay state cookie = %+v, want %q", cookie, relayState)
+	}
+	if !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteNoneMode {
+		t.Fatalf("relay state cookie attributes = %+v", cookie)
+	}
+
+	tests := []struct {
+		name   string
+		cookie string
+		want   int
+	}{
+		{name: "no cookie", want: http.StatusBadRequest},
+		{name: "other login", cookie: relayState + "x", want: http.StatusBadRequest},
+		// The response itself is invalid, so getting past the cookie check
+		// ends in SAML_FAILED.
+		{name: "same browser", cookie: relayState, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"SAMLResponse": {"bm90IHhtbA=="}, "RelayState": {relayState}}
+			req := httptest.NewRequest(http.MethodPost, "/saml/acs", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: samlRelayCookie, Value: tt.cookie})
+			}
+
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
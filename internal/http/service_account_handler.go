@@ -0,0 +1,138 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth"
+)
+
+type ServiceAccountHandler struct {
+	manager *auth.Manager
+}
+
+func NewServiceAccountHandler(manager *auth.Manager) *ServiceAccountHandler {
+	return &ServiceAccountHandler{manager: manager}
+}
+
+type createServiceAccountRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Scopes    []string `json:"scopes" binding:"required,min=1"`
+	Workspace string   `json:"workspace"`
+}
+
+type clientCredentialsRequest struct {
+	ClientID     string `json:"clientId" binding:"required"`
+	ClientSecret string `json:"clientSecret" binding:"required"`
+}
+
+type serviceAccountResponse struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	Workspace string   `json:"workspace,omitempty"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+func newServiceAccountResponse(u *auth.User) serviceAccountResponse {
+	return serviceAccountResponse{
+		ID:        u.ID,
+		Name:      u.Name,
+		Scopes:    u.Scopes,
+		Workspace: u.Workspace,
+		CreatedAt: u.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (h *ServiceAccountHandler) List(c *gin.Context) {
+	accounts, err := h.manager.ServiceAccounts()
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "서비스 계정 목록을 불러오지 못했습니다")
+		return
+	}
+
+	resp := make([]serviceAccountResponse, 0, len(accounts))
+	for _, a := range accounts {
+		resp = append(resp, newServiceAccountResponse(a))
+	}
+	SuccessResponse(c, gin.H{"serviceAccounts": resp})
+}
+
+// Create returns the client secret; it cannot be retrieved again.
+func (h *ServiceAccountHandler) Create(c *gin.Context) {
+	var req createServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	account, secret, err := h.manager.CreateServiceAccount(req.Name, req.Scopes, req.Workspace)
+	if err != nil {
+		BadRequestResponse(c, err.Error())
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"clientId":       account.ID,
+		"clientSecret":   secret,
+		"serviceAccount": newServiceAccountResponse(account),
+	})
+}
+
+func (h *ServiceAccountHandler) RotateSecret(c *gin.Context) {
+	secret, err := h.manager.RotateServiceAccountSecret(c.Param("id"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	SuccessResponse(c, gin.H{
+		"clientId":     c.Param("id"),
+		"clientSecret": secret,
+	})
+}
+
+func (h *ServiceAccountHandler) Delete(c *gin.Context) {
+	if err := h.manager.DeleteServiceAccount(c.Param("id")); err != nil {
+		h.fail(c, err)
+		return
+	}
+	SuccessResponse(c, gin.H{"message": "서비스 계정이 삭제되었습니다"})
+}
+
+// Token exchanges client credentials for an access token.
+func (h *ServiceAccountHandler) Token(c *gin.Context) {
+	var req clientCredentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	tokens, _, err := h.manager.LoginServiceAccount(req.ClientID, req.ClientSecret, c.ClientIP())
+	var locked *auth.LoginLockedError
+	if errors.As(err, &locked) {
+		loginLockedResponse(c, locked)
+		return
+	}
+	if err != nil {
+		ErrorResponse(c, http.StatusUnauthorized, "INVALID_CLIENT", "클라이언트 인증 정보가 올바르지 않습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"token":     tokens.AccessToken,
+		"tokenType": "Bearer",
+		"expiresIn": int64(tokens.ExpiresIn.Seconds()),
+	})
+}
+
+func (h *ServiceAccountHandler) fail(c *gin.Context, err error) {
+	if errors.Is(err, auth.ErrServiceAccountNotFound) {
+		NotFoundResponse(c, "서비스 계정을 찾을 수 없습니다")
+		return
+	}
+	c.Error(err)
+	InternalServerErrorResponse(c, "서비스 계정 처리에 실패했습니다")
+}
@@ -0,0 +1,214 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/rag"
+	"yuon/internal/rag/service"
+	"yuon/internal/rag/vectorstore"
+	"yuon/internal/storage"
+)
+
+const snapshotKeyPrefix = "snapshots/qdrant"
+
+type SnapshotHandler struct {
+	service *service.ChatbotService
+	storage storage.FileStorage
+}
+
+func NewSnapshotHandler(service *service.ChatbotService, storage storage.FileStorage) *SnapshotHandler {
+	return &SnapshotHandler{service: service, storage: storage}
+}
+
+type createSnapshotRequest struct {
+	Upload bool `json:"upload"`
+}
+
+type restoreSnapshotRequest struct {
+	FileKey    string `json:"fileKey" binding:"required"`
+	Collection string `json:"collection" binding:"required"`
+}
+
+func (h *SnapshotHandler) List(c *gin.Context) {
+	snapshots, err := h.service.ListVectorSnapshots(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, vectorstore.ErrUnsupported) {
+			snapshotsUnsupported(c)
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "스냅샷 목록 조회에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"snapshots": snapshots,
+	})
+}
+
+// Create takes a snapshot of the active collection and, with upload=true,
+// copies it to object storage.
+func (h *SnapshotHandler) Create(c *gin.Context) {
+	var req createSnapshotRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+			return
+		}
+	}
+
+	snapshot, err := h.service.CreateVectorSnapshot(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, vectorstore.ErrUnsupported) {
+			snapshotsUnsupported(c)
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "스냅샷 생성에 실패했습니다")
+		return
+	}
+
+	if req.Upload {
+		fileKey, err := h.copyToStorage(c.Request.Context(), snapshot.Collection, snapshot.Name)
+		if err != nil {
+			c.Error(err)
+			InternalServerErrorResponse(c, "스냅샷을 저장소에 업로드하지 못했습니다")
+			return
+		}
+		snapshot.FileKey = fileKey
+	}
+
+	SuccessResponse(c, snapshot)
+}
+
+func (h *SnapshotHandler) Download(c *gin.Context) {
+	name := c.Param("name")
+	snapshot, size, err := h.service.DownloadVectorSnapshot(c.Request.Context(), name)
+	if err != nil {
+		if errors.Is(err, vectorstore.ErrSnapshotNotFound) {
+			NotFoundResponse(c, "스냅샷을 찾을 수 없습니다")
+			return
+		}
+		if errors.Is(err, vectorstore.ErrUnsupported) {
+			snapshotsUnsupported(c)
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "스냅샷 다운로드에 실패했습니다")
+		return
+	}
+
+	defer snapshot.Close()
+
+	c.DataFromReader(http.StatusOK, size, "application/octet-stream", snapshot, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=\"%s\"", name),
+	})
+}
+
+func (h *SnapshotHandler) UploadToStorage(c *gin.Context) {
+	name := c.Param("name")
+	snapshots, err := h.service.ListVectorSnapshots(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, vectorstore.ErrUnsupported) {
+			snapshotsUnsupported(c)
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "스냅샷 목록 조회에 실패했습니다")
+		return
+	}
+
+	var snapshot *rag.VectorSnapshot
+	for i := range snapshots {
+		if snapshots[i].Name == name {
+			snapshot = &snapshots[i]
+			break
+		}
+	}
+	if snapshot == nil {
+		NotFoundResponse(c, "스냅샷을 찾을 수 없습니다")
+		return
+	}
+
+	fileKey, err := h.copyToStorage(c.Request.Context(), snapshot.Collection, snapshot.Name)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "스냅샷을 저장소에 업로드하지 못했습니다")
+		return
+	}
+	snapshot.FileKey = fileKey
+
+	SuccessResponse(c, snapshot)
+}
+
+// Restore recovers a snapshot stored in object storage into a new collection.
+// Point QDRANT_COLLECTION at it and restart to switch over.
+func (h *SnapshotHandler) Restore(c *gin.Context) {
+	var req restoreSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "fileKey와 collection이 필요합니다")
+		return
+	}
+	if !isSnapshotKey(req.FileKey) {
+		BadRequestResponse(c, "fileKey는 "+snapshotKeyPrefix+"/ 아래의 스냅샷 파일이어야 합니다")
+		return
+	}
+
+	snapshot, err := h.storage.Open(c.Request.Context(), req.FileKey)
+	if err != nil {
+		c.Error(err)
+		NotFoundResponse(c, "저장소에서 스냅샷 파일을 찾을 수 없습니다")
+		return
+	}
+	defer snapshot.Close()
+
+	if err := h.service.RestoreVectorSnapshot(c.Request.Context(), req.Collection, path.Base(req.FileKey), snapshot); err != nil {
+		if errors.Is(err, vectorstore.ErrInvalidRestoreTarget) {
+			BadRequestResponse(c, err.Error())
+			return
+		}
+		if errors.Is(err, vectorstore.ErrUnsupported) {
+			snapshotsUnsupported(c)
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "스냅샷 복원에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"collection": req.Collection,
+		"fileKey":    req.FileKey,
+		"message":    "스냅샷이 복원되었습니다",
+	})
+}
+
+func snapshotsUnsupported(c *gin.Context) {
+	ErrorResponse(c, http.StatusNotImplemented, "NOT_SUPPORTED", "현재 벡터 저장소는 스냅샷을 지원하지 않습니다")
+}
+
+// isSnapshotKey reports whether key names a file under snapshotKeyPrefix,
+// so a restore cannot read arbitrary objects from the bucket.
+func isSnapshotKey(key string) bool {
+	return strings.HasPrefix(key, snapshotKeyPrefix+"/") && path.Clean(key) == key
+}
+
+func (h *SnapshotHandler) copyToStorage(ctx context.Context, collection, name string) (string, error) {
+	snapshot, _, err := h.service.DownloadVectorSnapshot(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	defer snapshot.Close()
+
+	fileKey := path.Join(snapshotKeyPrefix, collection, name)
+	if _, err := h.storage.UploadStream(ctx, fileKey, snapshot, "application/octet-stream"); err != nil {
+		return "", err
+	}
+	return fileKey, nil
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/rag"
+	"yuon/internal/rag/service"
+	"yuon/internal/rag/vectorstore"
+	"yuon/internal/storage"
+)
+
+// snapshotVectors is a rag.VectorStore with snapshots kept in memory; other
+// methods are left unimplemented.
+type snapshotVectors struct {
+	rag.VectorStore
+
+	snapshots map[string]string
+	restored  map[string]string
+}
+
+func (v *snapshotVectors) CreateSnapshot(ctx context.Context) (*rag.VectorSnapshot, error) {
+	return nil, vectorstore.ErrUnsupported
+}
+
+func (v *snapshotVectors) ListSnapshots(ctx context.Context) ([]rag.VectorSnapshot, error) {
+	var snapshots []rag.VectorSnapshot
+	for name := range v.snapshots {
+		snapshots = append(snapshots, rag.VectorSnapshot{Name: name, Collection: "docs"})
+	}
+	return snapshots, nil
+}
+
+func (v *snapshotVectors) DownloadSnapshot(ctx context.Context, name string) (io.ReadCloser, int64, error) {
+	data, ok := v.snapshots[name]
+	if !ok {
+		return nil, 0, vectorstore.ErrSnapshotNotFound
+	}
+	return io.NopCloser(strings.NewReader(data)), -1, nil
+}
+
+func (v *snapshotVectors) RestoreSnapshot(ctx context.Context, collection, filename string, snapshot io.Reader) error {
+	data, err := io.ReadAll(snapshot)
+	if err != nil {
+		return err
+	}
+	v.restored[collection+"/"+filename] = string(data)
+	return nil
+}
+
+// objectStorage fakes the streaming calls of storage.FileStorage; every
+// other method is left unimplemented.
+type objectStorage struct {
+	storage.FileStorage
+
+	objects map[string]string
+	opened  []string
+}
+
+func (s *objectStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.opened = append(s.opened, key)
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, errors.New("s3 download failed: NoSuchKey")
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+func (s *objectStorage) UploadStream(ctx context.Context, key string, r io.Reader, contentType string) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	s.objects[key] = string(data)
+	return key, nil
+}
+
+func snapshotTestRouter() (*gin.Engine, *snapshotVectors, *objectStorage) {
+	vectors := &snapshotVectors{
+		snapshots: map[string]string{"snap-1.snapshot": "snapshot-bytes"},
+		restored:  make(map[string]string),
+	}
+	files := &objectStorage{objects: map[string]string{
+		"snapshots/qdrant/docs/snap-1.snapshot": "stored-snapshot",
+		"documents/secret.pdf":                  "secret",
+	}}
+	h := NewSnapshotHandler(service.NewChatbotService(nil, vectors, nil, nil, nil, 0), files)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/snapshots/restore", h.Restore)
+	engine.GET("/snapshots/:name/download", h.Download)
+	engine.POST("/snapshots/:name/upload", h.UploadToStorage)
+	return engine, vectors, files
+}
+
+func serveSnapshot(engine *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRestoreSnapshotRequiresSnapshotKey(t *testing.T) {
+	for _, key := range []string{
+		"documents/secret.pdf",
+		"snapshots/qdrant",
+		"snapshots/qdrant/",
+		"snapshots/qdrant/../../documents/secret.pdf",
+		"snapshots/qdrantx/docs/snap-1.snapshot",
+		"/snapshots/qdrant/docs/snap-1.snapshot",
+	} {
+		engine, vectors, files := snapshotTestRouter()
+		rec := serveSnapshot(engine, http.MethodPost, "/snapshots/restore", `{"fileKey":"`+key+`","collection":"restored"}`)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: status = %d, want 400", key, rec.Code)
+		}
+		if len(files.opened) != 0 || len(vectors.restored) != 0 {
+			t.Errorf("%q: storage opened %v, restored %v", key, files.opened, vectors.restored)
+		}
+	}
+}
+
+func TestRestoreSnapshotStreamsStoredFile(t *testing.T) {
+	engine, vectors, _ := snapshotTestRouter()
+
+	rec := serveSnapshot(engine, http.MethodPost, "/snapshots/restore", `{"fileKey":"snapshots/qdrant/docs/snap-1.snapshot","collection":"restored"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if got := vectors.restored["restored/snap-1.snapshot"]; got != "stored-snapshot" {
+		t.Errorf("restored %q, want the stored snapshot", got)
+	}
+
+	rec = serveSnapshot(engine, http.MethodPost, "/snapshots/restore", `{"fileKey":"snapshots/qdrant/docs/missing.snapshot","collection":"restored"}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing file: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestSnapshotDownloadAndCopy(t *testing.T) {
+	engine, _, files := snapshotTestRouter()
+
+	rec := serveSnapshot(engine, http.MethodGet, "/snapshots/snap-1.snapshot/download", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "snapshot-bytes" {
+		t.Errorf("download = %d %q", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="snap-1.snapshot"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if rec := serveSnapshot(engine, http.MethodGet, "/snapshots/missing.snapshot/download", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("missing download = %d, want 404", rec.Code)
+	}
+
+	if rec := serveSnapshot(engine, http.MethodPost, "/snapshots/snap-1.snapshot/upload", ""); rec.Code != http.StatusOK {
+		t.Fatalf("upload = %d: %s", rec.Code, rec.Body)
+	}
+	if got := files.objects["snapshots/qdrant/docs/snap-1.snapshot"]; got != "snapshot-bytes" {
+		t.Errorf("stored %q, want the Qdrant snapshot", got)
+	}
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/auth"
+)
+
+type ssoExchangeRequest struct {
+	Code     string `json:"code" binding:"required"`
+	Password string `json:"password"`
+}
+
+// ssoLoginResponse hands a federated login to the client as a single-use
+// code: in the URL fragment of redirect when set, as JSON otherwise.
+func ssoLoginResponse(c *gin.Context, login *auth.SSOLogin, redirect string) {
+	if redirect == "" {
+		SuccessResponse(c, gin.H{
+			"code":         login.Code,
+			"linkRequired": login.LinkRequired,
+			"expiresIn":    int64(login.ExpiresIn.Seconds()),
+		})
+		return
+	}
+
+	fragment := url.Values{}
+	fragment.Set("code", login.Code)
+	if login.LinkRequired {
+		fragment.Set("linkRequired", strconv.FormatBool(true))
+	}
+	c.Redirect(http.StatusFound, redirect+"#"+fragment.Encode())
+}
+
+// ssoLoginError responds to a failed LoginFederated and reports whether
+// err was one it recognized.
+func ssoLoginError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, auth.ErrUserDisabled):
+		userDisabledResponse(c)
+	case errors.Is(err, auth.ErrInvalidClientCredentials):
+		ErrorResponse(c, http.StatusForbidden, "SSO_NOT_ALLOWED", "서비스 계정은 SSO로 로그인할 수 없습니다")
+	default:
+		return false
+	}
+	return true
+}
+
+// ExchangeSSOCode trades the code from the OIDC callback or SAML ACS for
+// tokens, or for an MFA token like Login.
+func (h *AuthHandler) ExchangeSSOCode(c *gin.Context) {
+	if h.manager == nil {
+		InternalServerErrorResponse(c, "인증 관리자가 설정되지 않았습니다")
+		return
+	}
+
+	var req ssoExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	tokens, user, err := h.manager.ExchangeSSOCode(req.Code, req.Password, c.ClientIP())
+	var locked *auth.LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		loginLockedResponse(c, locked)
+		return
+	case errors.Is(err, auth.ErrInvalidSSOCode):
+		ErrorResponse(c, http.StatusUnauthorized, "INVALID_SSO_CODE", "SSO 로그인 코드가 유효하지 않거나 만료되었습니다")
+		return
+	case errors.Is(err, auth.ErrSSOLinkPasswordRequired):
+		ErrorResponse(c, http.StatusUnauthorized, "SSO_LINK_PASSWORD_REQUIRED", "관리자 계정에 SSO를 연결하려면 계정 비밀번호가 필요합니다. 다시 SSO 로그인하세요")
+		return
+	case errors.Is(err, auth.ErrUserDisabled):
+		userDisabledResponse(c)
+		return
+	case errors.Is(err, auth.ErrSSONotConfigured):
+		c.Error(err)
+		InternalServerErrorResponse(c, "SSO 로그인이 설정되지 않았습니다")
+		return
+	case err != nil:
+		ErrorResponse(c, http.StatusUnauthorized, "INVALID_CREDENTIALS", err.Error())
+		return
+	}
+
+	if tokens.MFAToken != "" {
+		SuccessResponse(c, gin.H{
+			"mfaRequired": true,
+			"mfaToken":    tokens.MFAToken,
+		})
+		return
+	}
+
+	SuccessResponse(c, tokenResponse(tokens, user))
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"embed"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerUI holds the index page and, once `make swagger-ui` has vendored
+// them, the swagger-ui-dist stylesheet and bundle pinned in VERSION.
+//
+//go:embed swagger-ui
+var swaggerUI embed.FS
+
+func (r *Router) registerSwaggerRoutes() {
+	if !r.config.Server.DocsEnabled {
+		return
+	}
+
+	r.engine.GET("/docs/openapi.yaml", func(c *gin.Context) {
+		_, spec := r.openAPISpec()
+		c.Data(http.StatusOK, "application/yaml", spec)
+	})
+	r.engine.GET("/docs/openapi.json", func(c *gin.Context) {
+		spec, _ := r.openAPISpec()
+		c.Data(http.StatusOK, "application/json", spec)
+	})
+
+	// The UI is only ever served from the pinned assets; a build without
+	// them keeps the spec endpoints but has no /docs page.
+	assets, _ := fs.Sub(swaggerUI, "swagger-ui")
+	if _, err := fs.Stat(assets, "swagger-ui-bundle.js"); err != nil {
+		slog.Warn("Swagger UI 자산이 포함되지 않아 /docs를 제공하지 않습니다. make swagger-ui 후 다시 빌드하세요")
+		return
+	}
+	base := "/docs/assets"
+	r.engine.StaticFS(base, http.FS(assets))
+
+	index, _ := fs.ReadFile(assets, "index.html")
+	page := []byte(strings.ReplaceAll(string(index), "{{assets}}", base))
+	r.engine.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	})
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeGrace is how long past its timeout a request may still spend sending
+// the error or result it produced.
+const writeGrace = 5 * time.Second
+
+// requestTimeout bounds the request context handlers pass to the search
+// engine, vector store and database, so a slow backend call is cancelled
+// instead of holding the worker. The connection's read and write deadlines
+// follow the timeout, which lets routes allowed more than the server-wide
+// limits still receive their body and respond. A zero d, i.e. a timeout
+// disabled in the configuration, leaves the request unbounded.
+func requestTimeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		rc := http.NewResponseController(c.Writer)
+		_ = rc.SetReadDeadline(time.Now().Add(d))
+		_ = rc.SetWriteDeadline(time.Now().Add(d + writeGrace))
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			timeoutResponse(c)
+		}
+	}
+}
+
+// requestTimedOut reports whether the request's deadline from requestTimeout
+// has passed, so errors caused by it can be reported as timeouts.
+func requestTimedOut(c *gin.Context) bool {
+	return errors.Is(c.Request.Context().Err(), context.DeadlineExceeded)
+}
+
+func timeoutResponse(c *gin.Context) {
+	ErrorResponse(c, http.StatusGatewayTimeout, "REQUEST_TIMEOUT", "요청 처리 시간이 초과되었습니다")
+}
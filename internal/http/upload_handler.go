@@ -0,0 +1,391 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"yuon/internal/rag"
+	"yuon/internal/textextract"
+	"yuon/internal/upload"
+)
+
+const (
+	minUploadPartSize     = 5 * 1024 * 1024
+	defaultUploadPartSize = 8 * 1024 * 1024
+	maxUploadPartSize     = 32 * 1024 * 1024
+	maxUploadParts        = 10000
+	uploadSessionTTL      = 24 * time.Hour
+)
+
+type initUploadRequest struct {
+	Filename    string                 `json:"filename" binding:"required"`
+	ContentType string                 `json:"contentType"`
+	Size        int64                  `json:"size" binding:"required,min=1"`
+	DocumentID  string                 `json:"documentId"`
+	Metadata    map[string]interface{} `json:"metadata"`
+}
+
+func (h *DocumentHandler) InitResumableUpload(c *gin.Context) {
+	if h.storage == nil || h.uploads == nil {
+		InternalServerErrorResponse(c, "파일 저장소가 구성되지 않았습니다")
+		return
+	}
+
+	var req initUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "filename, size 필드를 포함한 요청이 필요합니다")
+		return
+	}
+
+	if h.maxResumableSize > 0 && req.Size > h.maxResumableSize {
+		BadRequestResponse(c, fmt.Sprintf("파일 크기가 %dMB를 초과합니다", h.maxResumableSize/1024/1024))
+		return
+	}
+
+	if req.Metadata == nil {
+		req.Metadata = make(map[string]interface{})
+	}
+	if !h.validateMetadata(c, req.Metadata) {
+		return
+	}
+	if req.DocumentID != "" && !h.replaceableDocument(c, req.DocumentID) {
+		return
+	}
+
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	ctx := c.Request.Context()
+	key := newUploadKey(req.Filename)
+	uploadID, err := h.storage.CreateMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "업로드 세션 생성에 실패했습니다")
+		return
+	}
+
+	now := time.Now().UTC()
+	session := &upload.Session{
+		ID:          uuid.New().String(),
+		UploadID:    uploadID,
+		Key:         key,
+		Principal:   rateLimitPrincipal(c),
+		Filename:    req.Filename,
+		ContentType: contentType,
+		DocumentID:  req.DocumentID,
+		Size:        req.Size,
+		Metadata:    req.Metadata,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(uploadSessionTTL),
+	}
+	if err := h.uploads.Create(ctx, session); err != nil {
+		c.Error(err)
+		if err := h.storage.AbortMultipartUpload(context.WithoutCancel(ctx), key, uploadID); err != nil {
+			slog.WarnContext(ctx, "업로드 취소 실패", "error", err, "key", key)
+		}
+		InternalServerErrorResponse(c, "업로드 세션 생성에 실패했습니다")
+		return
+	}
+
+	partSize := int64(defaultUploadPartSize)
+	for (req.Size+partSize-1)/partSize > maxUploadParts && partSize < maxUploadPartSize {
+		partSize *= 2
+	}
+
+	SuccessResponse(c, gin.H{
+		"uploadId":  session.ID,
+		"fileKey":   key,
+		"partSize":  partSize,
+		"expiresAt": session.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// uploadSession loads the session named by the uploadId parameter, writing
+// a 404 when it does not exist, has expired or was started by someone else.
+func (h *DocumentHandler) uploadSession(c *gin.Context) (*upload.Session, bool) {
+	if h.uploads == nil {
+		NotFoundResponse(c, "업로드 세션을 찾을 수 없습니다")
+		return nil, false
+	}
+	session, err := h.uploads.Get(c.Request.Context(), c.Param("uploadId"))
+	if errors.Is(err, upload.ErrSessionNotFound) {
+		NotFoundResponse(c, "업로드 세션을 찾을 수 없습니다")
+		return nil, false
+	}
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "업로드 세션 조회에 실패했습니다")
+		return nil, false
+	}
+	if session.Principal != rateLimitPrincipal(c) {
+		NotFoundResponse(c, "업로드 세션을 찾을 수 없습니다")
+		return nil, false
+	}
+	return session, true
+}
+
+// uploadPartLimit is the highest part number an upload of size bytes can
+// use, since every part but the last is at least minUploadPartSize.
+func uploadPartLimit(size int64) int {
+	parts := (size + minUploadPartSize - 1) / minUploadPartSize
+	if parts < 1 {
+		parts = 1
+	}
+	if parts > maxUploadParts {
+		parts = maxUploadParts
+	}
+	return int(parts)
+}
+
+func (h *DocumentHandler) GetResumableUpload(c *gin.Context) {
+	session, ok := h.uploadSession(c)
+	if !ok {
+		return
+	}
+
+	parts, err := h.storage.ListParts(c.Request.Context(), session.Key, session.UploadID)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "업로드 상태 조회에 실패했습니다")
+		return
+	}
+
+	var received int64
+	for _, p := range parts {
+		received += p.Size
+	}
+
+	SuccessResponse(c, gin.H{
+		"uploadId":      session.ID,
+		"fileName":      session.Filename,
+		"size":          session.Size,
+		"receivedBytes": received,
+		"parts":         parts,
+	})
+}
+
+func (h *DocumentHandler) UploadResumablePart(c *gin.Context) {
+	session, ok := h.uploadSession(c)
+	if !ok {
+		return
+	}
+
+	limit := uploadPartLimit(session.Size)
+	partNumber, err := strconv.Atoi(c.Param("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > limit {
+		BadRequestResponse(c, fmt.Sprintf("partNumber는 1~%d 사이의 정수여야 합니다", limit))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadPartSize+1)
+	data, err := readFileWithLimit(c.Request.Body, maxUploadPartSize)
+	if err != nil {
+		BadRequestResponse(c, fmt.Sprintf("파트는 %dMB 이하여야 합니다", maxUploadPartSize/1024/1024))
+		return
+	}
+	if len(data) == 0 {
+		BadRequestResponse(c, "빈 파트는 업로드할 수 없습니다")
+		return
+	}
+	if int64(len(data)) > session.Size {
+		ErrorResponse(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "파트가 선언한 파일 크기를 초과합니다")
+		return
+	}
+
+	etag, err := h.storage.UploadPart(c.Request.Context(), session.Key, session.UploadID, int32(partNumber), data)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "파트 업로드에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"uploadId":   session.ID,
+		"partNumber": partNumber,
+		"etag":       etag,
+		"size":       len(data),
+	})
+}
+
+func (h *DocumentHandler) CompleteResumableUpload(c *gin.Context) {
+	session, ok := h.uploadSession(c)
+	if !ok {
+		return
+	}
+	// 세션을 만든 뒤 같은 ID로 볼 수 없는 문서가 생겼을 수 있어 다시 확인
+	if session.DocumentID != "" && !h.replaceableDocument(c, session.DocumentID) {
+		return
+	}
+
+	job := h.startIngestion(c, session.Filename)
+	defer job.finish(c)
+
+	ctx := c.Request.Context()
+	parts, err := h.storage.ListParts(ctx, session.Key, session.UploadID)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "업로드 상태 조회에 실패했습니다")
+		return
+	}
+	if len(parts) == 0 {
+		BadRequestResponse(c, "업로드된 파트가 없습니다")
+		return
+	}
+
+	var total int64
+	for i, p := range parts {
+		if p.PartNumber != int32(i+1) {
+			BadRequestResponse(c, fmt.Sprintf("%d번 파트가 누락되었습니다", i+1))
+			return
+		}
+		if i < len(parts)-1 && p.Size < minUploadPartSize {
+			BadRequestResponse(c, fmt.Sprintf("마지막 파트를 제외한 파트는 최소 %dMB 이상이어야 합니다", minUploadPartSize/1024/1024))
+			return
+		}
+		total += p.Size
+	}
+	// 선언한 크기나 최대 크기를 넘긴 업로드는 완료하지 않고 폐기
+	if total > session.Size || (h.maxResumableSize > 0 && total > h.maxResumableSize) {
+		h.discardUpload(ctx, session)
+		ErrorResponse(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "업로드된 파트가 선언한 파일 크기를 초과합니다")
+		return
+	}
+
+	url, err := h.storage.CompleteMultipartUpload(ctx, session.Key, session.UploadID, parts)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "업로드 완료 처리에 실패했습니다")
+		return
+	}
+	if err := h.uploads.Delete(ctx, session.ID); err != nil {
+		slog.WarnContext(ctx, "업로드 세션 삭제 실패", "error", err, "uploadId", session.ID)
+	}
+
+	file, err := h.spoolObject(ctx, session.Key, total)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "업로드된 파일을 읽지 못했습니다")
+		return
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	if !h.scanFile(c, session.Filename, file, total) {
+		if err := h.storage.Delete(ctx, session.Key); err != nil {
+			slog.ErrorContext(ctx, "감염 파일 삭제 실패", "error", err, "key", session.Key)
+		}
+		return
+	}
+
+	job.report(rag.StageExtracting, 0, 1)
+	text, ok := h.extractFileContent(c, session.Filename, session.ContentType, file.Name())
+	if !ok {
+		return
+	}
+	if textextract.IsImage(session.Filename) {
+		session.Metadata["sourceType"] = "image"
+	}
+
+	docID := session.DocumentID
+	if docID == "" {
+		docID = uuid.New().String()
+	}
+	job.document(docID)
+	doc, err := h.addStoredFileDocument(ctx, docID, text, storedFile{
+		Key:         session.Key,
+		URL:         url,
+		Filename:    session.Filename,
+		ContentType: session.ContentType,
+		Size:        total,
+	}, session.Metadata)
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "문서 생성에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"message":  "파일이 업로드되고 문서가 생성되었습니다",
+		"id":       doc.ID,
+		"fileUrl":  url,
+		"fileKey":  session.Key,
+		"fileName": session.Filename,
+	})
+}
+
+// spoolObject streams the object at key, at most size bytes, into a
+// temporary file positioned at its start, so large uploads are scanned and
+// extracted without holding them in memory. The caller removes the file.
+func (h *DocumentHandler) spoolObject(ctx context.Context, key string, size int64) (*os.File, error) {
+	body, err := h.storage.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	file, err := os.CreateTemp("", "resumable-*")
+	if err != nil {
+		return nil, fmt.Errorf("upload temp file create failed: %w", err)
+	}
+	n, err := io.Copy(file, io.LimitReader(body, size+1))
+	if err == nil && n > size {
+		err = fmt.Errorf("object %s is larger than its %d uploaded bytes", key, size)
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("upload spool failed: %w", err)
+	}
+	return file, nil
+}
+
+// discardUpload aborts the multipart upload of session and forgets it.
+func (h *DocumentHandler) discardUpload(ctx context.Context, session *upload.Session) {
+	ctx = context.WithoutCancel(ctx)
+	if err := h.storage.AbortMultipartUpload(ctx, session.Key, session.UploadID); err != nil {
+		slog.WarnContext(ctx, "업로드 취소 실패", "error", err, "key", session.Key)
+	}
+	if err := h.uploads.Delete(ctx, session.ID); err != nil {
+		slog.WarnContext(ctx, "업로드 세션 삭제 실패", "error", err, "uploadId", session.ID)
+	}
+}
+
+func (h *DocumentHandler) AbortResumableUpload(c *gin.Context) {
+	session, ok := h.uploadSession(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.storage.AbortMultipartUpload(ctx, session.Key, session.UploadID); err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "업로드 취소에 실패했습니다")
+		return
+	}
+	if err := h.uploads.Delete(ctx, session.ID); err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "업로드 취소에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"uploadId": session.ID,
+		"message":  "업로드가 취소되었습니다",
+	})
+}
@@ -0,0 +1,248 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/storage"
+	"yuon/internal/upload"
+)
+
+// memoryUploads is an upload.Store kept in memory.
+type memoryUploads struct {
+	mu       sync.Mutex
+	sessions map[string]upload.Session
+}
+
+func newMemoryUploads(sessions ...upload.Session) *memoryUploads {
+	s := &memoryUploads{sessions: make(map[string]upload.Session)}
+	for _, session := range sessions {
+		s.sessions[session.ID] = session
+	}
+	return s
+}
+
+func (s *memoryUploads) Create(ctx context.Context, session *upload.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *memoryUploads) Get(ctx context.Context, id string) (*upload.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok || !session.ExpiresAt.After(time.Now()) {
+		return nil, upload.ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (s *memoryUploads) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *memoryUploads) Expired(ctx context.Context, now time.Time, limit int) ([]upload.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired []upload.Session
+	for _, session := range s.sessions {
+		if !session.ExpiresAt.After(now) && len(expired) < limit {
+			expired = append(expired, session)
+		}
+	}
+	return expired, nil
+}
+
+// multipartStorage fakes the multipart calls of storage.FileStorage; every
+// other method is left unimplemented.
+type multipartStorage struct {
+	storage.FileStorage
+
+	mu      sync.Mutex
+	parts   []storage.UploadedPart
+	aborted []string
+}
+
+func (s *multipartStorage) UploadPart(ctx context.Context, key, uploadID string, partNumber int32, data []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parts = append(s.parts, storage.UploadedPart{PartNumber: partNumber, ETag: "etag", Size: int64(len(data))})
+	return "etag", nil
+}
+
+func (s *multipartStorage) ListParts(ctx context.Context, key, uploadID string) ([]storage.UploadedPart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]storage.UploadedPart(nil), s.parts...), nil
+}
+
+func (s *multipartStorage) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aborted = append(s.aborted, uploadID)
+	return nil
+}
+
+// uploadTestRouter serves the resumable upload routes, authenticating the
+// caller named by the X-Test-User header.
+func uploadTestRouter(h *DocumentHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userID", c.GetHeader("X-Test-User"))
+		c.Next()
+	})
+	engine.GET("/uploads/:uploadId", h.GetResumableUpload)
+	engine.PUT("/uploads/:uploadId/parts/:partNumber", h.UploadResumablePart)
+	engine.POST("/uploads/:uploadId/complete", h.CompleteResumableUpload)
+	engine.DELETE("/uploads/:uploadId", h.AbortResumableUpload)
+	return engine
+}
+
+func serveAs(engine *gin.Engine, user, method, path string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("X-Test-User", user)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func testUploadSession(size int64) upload.Session {
+	return upload.Session{
+		ID:        "s1",
+		UploadID:  "u1",
+		Key:       "documents/report.txt",
+		Principal: "user:alice",
+		Filename:  "report.txt",
+		Size:      size,
+		Metadata:  map[string]interface{}{},
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestResumableUploadBoundToPrincipal(t *testing.T) {
+	files := &multipartStorage{}
+	uploads := newMemoryUploads(testUploadSession(10))
+	engine := uploadTestRouter(&DocumentHandler{storage: files, uploads: uploads})
+
+	requests := []struct{ method, path string }{
+		{http.MethodGet, "/uploads/s1"},
+		{http.MethodPut, "/uploads/s1/parts/1"},
+		{http.MethodPost, "/uploads/s1/complete"},
+		{http.MethodDelete, "/uploads/s1"},
+	}
+	for _, r := range requests {
+		if rec := serveAs(engine, "mallory", r.method, r.path, []byte("data")); rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s by another user = %d, want 404", r.method, r.path, rec.Code)
+		}
+	}
+	if len(files.parts) != 0 || len(files.aborted) != 0 {
+		t.Fatalf("another user reached storage: parts %v, aborted %v", files.parts, files.aborted)
+	}
+
+	if rec := serveAs(engine, "alice", http.MethodGet, "/uploads/s1", nil); rec.Code != http.StatusOK {
+		t.Errorf("GET by the owner = %d, want 200", rec.Code)
+	}
+	if rec := serveAs(engine, "alice", http.MethodDelete, "/uploads/s1", nil); rec.Code != http.StatusOK {
+		t.Errorf("DELETE by the owner = %d, want 200", rec.Code)
+	}
+	if _, err := uploads.Get(context.Background(), "s1"); !errors.Is(err, upload.ErrSessionNotFound) {
+		t.Errorf("aborted session still stored: %v", err)
+	}
+}
+
+func TestResumableUploadPartLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		size int64
+		part string
+		body []byte
+		want int
+	}{
+		{"within declared size", 10, "1", []byte("0123456789"), http.StatusOK},
+		{"part larger than declared size", 4, "1", []byte("0123456789"), http.StatusRequestEntityTooLarge},
+		{"part number beyond declared size", 10, "2", []byte("0"), http.StatusBadRequest},
+		{"part number zero", 10, "0", []byte("0"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := uploadTestRouter(&DocumentHandler{
+				storage: &multipartStorage{},
+				uploads: newMemoryUploads(testUploadSession(tt.size)),
+			})
+			rec := serveAs(engine, "alice", http.MethodPut, "/uploads/s1/parts/"+tt.part, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestCompleteResumableUploadRejectsOversizedParts(t *testing.T) {
+	tests := []struct {
+		name     string
+		declared int64
+		max      int64
+		parts    []storage.UploadedPart
+	}{
+		{"over declared size", 1, 0, []storage.UploadedPart{{PartNumber: 1, ETag: "a", Size: 2}}},
+		{"over maximum size", 20 * minUploadPartSize, 6 * 1024 * 1024, []storage.UploadedPart{
+			{PartNumber: 1, ETag: "a", Size: minUploadPartSize},
+			{PartNumber: 2, ETag: "b", Size: minUploadPartSize},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := &multipartStorage{parts: tt.parts}
+			uploads := newMemoryUploads(testUploadSession(tt.declared))
+			engine := uploadTestRouter(&DocumentHandler{storage: files, uploads: uploads, maxResumableSize: tt.max})
+
+			rec := serveAs(engine, "alice", http.MethodPost, "/uploads/s1/complete", nil)
+			if rec.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("status = %d, want 413: %s", rec.Code, rec.Body)
+			}
+			if len(files.aborted) != 1 || files.aborted[0] != "u1" {
+				t.Errorf("multipart upload not aborted: %v", files.aborted)
+			}
+			if _, err := uploads.Get(context.Background(), "s1"); !errors.Is(err, upload.ErrSessionNotFound) {
+				t.Errorf("rejected session still stored: %v", err)
+			}
+		})
+	}
+}
+
+func TestResumableUploadErrorsHideStorageDetails(t *testing.T) {
+	engine := uploadTestRouter(&DocumentHandler{
+		storage: failingPartStorage{},
+		uploads: newMemoryUploads(testUploadSession(10)),
+	})
+	rec := serveAs(engine, "alice", http.MethodPut, "/uploads/s1/parts/1", []byte("0123"))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "bucket-secret") {
+		t.Errorf("response leaks the storage error: %s", rec.Body)
+	}
+}
+
+type failingPartStorage struct {
+	storage.FileStorage
+}
+
+func (failingPartStorage) UploadPart(ctx context.Context, key, uploadID string, partNumber int32, data []byte) (string, error) {
+	return "", errors.New("s3 upload part failed: bucket-secret is unreachable")
+}
@@ -1,10 +1,15 @@
 package http
 
 import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"yuon/internal/auth"
+	"yuon/package/pagination"
 )
 
 type UserHandler struct {
@@ -20,15 +25,25 @@ type userResponse struct {
 	Name       string `json:"name"`
 	Email      string `json:"email"`
 	Role       string `json:"role"`
+	Workspace  string `json:"workspace,omitempty"`
+	Department string `json:"department,omitempty"`
+	AvatarURL  string `json:"avatarUrl,omitempty"`
 	Status     string `json:"status"`
 	LastActive string `json:"lastActive"`
 	CreatedAt  string `json:"createdAt"`
 }
 
 type createUserRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role"`
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required,min=6"`
+	Role      string `json:"role"`
+	Workspace string `json:"workspace"`
+}
+
+type updateProfileRequest struct {
+	Name       string `json:"name" binding:"max=100"`
+	Department string `json:"department" binding:"max=100"`
+	AvatarURL  string `json:"avatarUrl" binding:"omitempty,url,max=2048"`
 }
 
 type updateUserRequest struct {
@@ -36,15 +51,21 @@ type updateUserRequest struct {
 	Role  string `json:"role,omitempty"`
 }
 
-// List returns users from the auth manager (in-memory) with basic metadata.
+// List returns users with basic metadata, newest first, `limit` (default
+// 100) at a time.
 func (h *UserHandler) List(c *gin.Context) {
 	if h.manager == nil {
 		InternalServerErrorResponse(c, "인증 관리자가 설정되지 않았습니다")
 		return
 	}
 
-	users := h.manager.AllUsers()
-	var resp []userResponse
+	limit := pagination.Limit(parseQueryInt(c, "limit", 100), 100)
+	users, page, err := pageUsers(h.manager.AllUsers(), limit, c.Query("cursor"))
+	if err != nil {
+		BadRequestResponse(c, "유효하지 않은 cursor입니다")
+		return
+	}
+	resp := []userResponse{}
 
 	for _, u := range users {
 		created := u.CreatedAt
@@ -53,41 +74,171 @@ func (h *UserHandler) List(c *gin.Context) {
 		}
 		resp = append(resp, userResponse{
 			ID:         u.ID,
-			Name:       u.Email, // 이름 데이터가 없어 이메일을 이름으로 사용
+			Name:       u.DisplayName(),
 			Email:      u.Email,
 			Role:       u.Role,
-			Status:     "active",
+			Workspace:  u.Workspace,
+			Department: u.Department,
+			AvatarURL:  u.AvatarURL,
+			Status:     userStatus(u),
 			LastActive: "방금 전",
 			CreatedAt:  created.Format(time.RFC3339),
 		})
 	}
 
 	SuccessResponse(c, gin.H{
-		"users": resp,
+		"users":      resp,
+		"nextCursor": page.NextCursor,
+		"hasMore":    page.HasMore,
 	})
 }
 
+// pageUsers orders users by (createdAt, id) descending and returns the page
+// after cursor. Users are held in memory, so this is a keyset over the slice.
+func pageUsers(users []*auth.User, limit int, cursor string) ([]*auth.User, pagination.Page, error) {
+	sort.SliceStable(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+
+	if cursor != "" {
+		after, afterID, err := pagination.DecodeTimeCursor(cursor)
+		if err != nil {
+			return nil, pagination.Page{}, err
+		}
+		start := sort.Search(len(users), func(i int) bool {
+			u := users[i]
+			return u.CreatedAt.Before(after) || (u.CreatedAt.Equal(after) && u.ID < afterID)
+		})
+		users = users[start:]
+	}
+
+	if len(users) <= limit {
+		return users, pagination.Page{}, nil
+	}
+	users = users[:limit]
+	last := users[limit-1]
+	return users, pagination.Page{NextCursor: pagination.EncodeTimeCursor(last.CreatedAt, last.ID), HasMore: true}, nil
+}
+
 func (h *UserHandler) Create(c *gin.Context) {
 	var req createUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		BadRequestResponse(c, "잘못된 요청입니다")
+		BindErrorResponse(c, err, "잘못된 요청입니다")
 		return
 	}
 
-	_, user, err := h.manager.Signup(req.Email, req.Password, req.Role)
+	_, user, err := h.manager.Signup(req.Email, req.Password, req.Role, req.Workspace)
 	if err != nil {
 		InternalServerErrorResponse(c, err.Error())
 		return
 	}
 
+	SuccessResponse(c, gin.H{
+		"id":        user.ID,
+		"email":     user.Email,
+		"role":      user.Role,
+		"workspace": user.Workspace,
+		"message":   "사용자가 생성되었습니다",
+	})
+}
+
+// Update changes a user's email and/or role.
+func (h *UserHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		BadRequestResponse(c, "사용자 ID가 필요합니다")
+		return
+	}
+
+	var req updateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청입니다")
+		return
+	}
+	if req.Email == "" && req.Role == "" {
+		BadRequestResponse(c, "email 또는 role이 필요합니다")
+		return
+	}
+
+	user, err := h.manager.UpdateUser(id, req.Email, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrUserNotFound):
+			NotFoundResponse(c, "사용자를 찾을 수 없습니다")
+		case errors.Is(err, auth.ErrInvalidRole):
+			BadRequestResponse(c, "알 수 없는 역할입니다")
+		case errors.Is(err, auth.ErrEmailTaken):
+			ErrorResponse(c, http.StatusConflict, "EMAIL_TAKEN", "이미 사용 중인 이메일입니다")
+		case errors.Is(err, auth.ErrLastRoot):
+			ErrorResponse(c, http.StatusConflict, "LAST_ROOT", "마지막 root 사용자의 역할은 변경할 수 없습니다")
+		default:
+			c.Error(err)
+			InternalServerErrorResponse(c, "사용자 수정에 실패했습니다")
+		}
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"id":        user.ID,
+		"email":     user.Email,
+		"role":      user.Role,
+		"workspace": user.Workspace,
+		"message":   "사용자가 수정되었습니다",
+	})
+}
+
+// Enable re-activates a disabled user.
+func (h *UserHandler) Enable(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+// Disable blocks login and invalidates the user's existing tokens.
+func (h *UserHandler) Disable(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+func (h *UserHandler) setActive(c *gin.Context, active bool) {
+	id := c.Param("id")
+	if id == "" {
+		BadRequestResponse(c, "사용자 ID가 필요합니다")
+		return
+	}
+
+	user, err := h.manager.SetUserActive(id, active)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrUserNotFound):
+			NotFoundResponse(c, "사용자를 찾을 수 없습니다")
+		case errors.Is(err, auth.ErrLastRoot):
+			ErrorResponse(c, http.StatusConflict, "LAST_ROOT", "마지막 root 사용자는 비활성화할 수 없습니다")
+		default:
+			c.Error(err)
+			InternalServerErrorResponse(c, "사용자 상태 변경에 실패했습니다")
+		}
+		return
+	}
+
+	message := "사용자가 활성화되었습니다"
+	if !active {
+		message = "사용자가 비활성화되었습니다"
+	}
 	SuccessResponse(c, gin.H{
 		"id":      user.ID,
-		"email":   user.Email,
-		"role":    user.Role,
-		"message": "사용자가 생성되었습니다",
+		"status":  userStatus(user),
+		"message": message,
 	})
 }
 
+func userStatus(u *auth.User) string {
+	if u.Active {
+		return "active"
+	}
+	return "disabled"
+}
+
 func (h *UserHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -96,6 +247,10 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.manager.DeleteUser(id); err != nil {
+		if errors.Is(err, auth.ErrLastRoot) {
+			ErrorResponse(c, http.StatusConflict, "LAST_ROOT", "마지막 root 사용자는 삭제할 수 없습니다")
+			return
+		}
 		InternalServerErrorResponse(c, err.Error())
 		return
 	}
@@ -104,3 +259,47 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		"message": "사용자가 삭제되었습니다",
 	})
 }
+
+// Me returns the profile of the authenticated user.
+func (h *UserHandler) Me(c *gin.Context) {
+	user, err := h.manager.GetUser(c.GetString("userID"))
+	if err != nil {
+		NotFoundResponse(c, "사용자를 찾을 수 없습니다")
+		return
+	}
+	SuccessResponse(c, profileResponse(user))
+}
+
+// UpdateMe replaces the name, department and avatar of the authenticated user.
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	var req updateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청입니다")
+		return
+	}
+
+	user, err := h.manager.UpdateProfile(c.GetString("userID"), auth.Profile{
+		Name:       strings.TrimSpace(req.Name),
+		Department: strings.TrimSpace(req.Department),
+		AvatarURL:  req.AvatarURL,
+	})
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "프로필 수정에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, profileResponse(user))
+}
+
+func profileResponse(u *auth.User) gin.H {
+	return gin.H{
+		"id":         u.ID,
+		"email":      u.Email,
+		"name":       u.Name,
+		"department": u.Department,
+		"avatarUrl":  u.AvatarURL,
+		"role":       u.Role,
+		"workspace":  u.Workspace,
+		"createdAt":  u.CreatedAt.Format(time.RFC3339),
+	}
+}
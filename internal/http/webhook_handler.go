@@ -0,0 +1,175 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"yuon/internal/webhook"
+)
+
+const maxWebhookDeliveries = 200
+
+type WebhookHandler struct {
+	dispatcher *webhook.Dispatcher
+}
+
+func NewWebhookHandler(dispatcher *webhook.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{dispatcher: dispatcher}
+}
+
+type createWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events" binding:"required,min=1"`
+	Description string   `json:"description"`
+}
+
+type updateWebhookRequest struct {
+	URL          *string  `json:"url"`
+	Events       []string `json:"events"`
+	Description  *string  `json:"description"`
+	Active       *bool    `json:"active"`
+	RotateSecret bool     `json:"rotateSecret"`
+}
+
+// Events lists the event types a webhook can subscribe to.
+func (h *WebhookHandler) Events(c *gin.Context) {
+	SuccessResponse(c, gin.H{"events": webhook.Events})
+}
+
+// Create registers a webhook. The signing secret is only included in this
+// response (and in the response of a secret rotation).
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req createWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	w, err := h.dispatcher.Create(c.Request.Context(), req.URL, req.Events, req.Description, c.GetString("userID"))
+	if err != nil {
+		if h.badWebhookRequest(c, err) {
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "웹훅 등록에 실패했습니다")
+		return
+	}
+
+	SuccessResponse(c, gin.H{
+		"secret":  w.Secret,
+		"webhook": w,
+	})
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	hooks, err := h.dispatcher.List(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		InternalServerErrorResponse(c, "웹훅 목록 조회에 실패했습니다")
+		return
+	}
+	if hooks == nil {
+		hooks = []*webhook.Webhook{}
+	}
+	SuccessResponse(c, gin.H{"webhooks": hooks})
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	var req updateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BindErrorResponse(c, err, "잘못된 요청 형식입니다")
+		return
+	}
+
+	ctx := c.Request.Context()
+	w, err := h.dispatcher.Get(ctx, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, webhook.ErrWebhookNotFound) {
+			NotFoundResponse(c, "웹훅을 찾을 수 없습니다")
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "웹훅 조회에 실패했습니다")
+		return
+	}
+
+	if req.URL != nil {
+		w.URL = *req.URL
+	}
+	if req.Events != nil {
+		w.Events = req.Events
+	}
+	if req.Description != nil {
+		w.Description = *req.Description
+	}
+	if req.Active != nil {
+		w.Active = *req.Active
+	}
+
+	if err := h.dispatcher.Update(ctx, w, req.RotateSecret); err != nil {
+		if h.badWebhookRequest(c, err) {
+			return
+		}
+		if errors.Is(err, webhook.ErrWebhookNotFound) {
+			NotFoundResponse(c, "웹훅을 찾을 수 없습니다")
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "웹훅 수정에 실패했습니다")
+		return
+	}
+
+	resp := gin.H{"webhook": w}
+	if req.RotateSecret {
+		resp["secret"] = w.Secret
+	}
+	SuccessResponse(c, resp)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	if err := h.dispatcher.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, webhook.ErrWebhookNotFound) {
+			NotFoundResponse(c, "웹훅을 찾을 수 없습니다")
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "웹훅 삭제에 실패했습니다")
+		return
+	}
+	SuccessResponse(c, gin.H{"message": "웹훅이 삭제되었습니다"})
+}
+
+// Deliveries returns the most recent deliveries of a webhook, newest first.
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	limit := parseQueryInt(c, "limit", 50)
+	if limit < 1 || limit > maxWebhookDeliveries {
+		limit = 50
+	}
+
+	deliveries, err := h.dispatcher.Deliveries(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		if errors.Is(err, webhook.ErrWebhookNotFound) {
+			NotFoundResponse(c, "웹훅을 찾을 수 없습니다")
+			return
+		}
+		c.Error(err)
+		InternalServerErrorResponse(c, "웹훅 전송 기록 조회에 실패했습니다")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*webhook.Delivery{}
+	}
+	SuccessResponse(c, gin.H{"deliveries": deliveries})
+}
+
+func (h *WebhookHandler) badWebhookRequest(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, webhook.ErrInvalidURL):
+		BadRequestResponse(c, "url은 http 또는 https 절대 주소여야 합니다")
+	case errors.Is(err, webhook.ErrInvalidEvents):
+		BadRequestResponse(c, "events는 지원하는 이벤트 타입 목록이어야 합니다")
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// wsSendBuffer is how many outbound frames may queue before senders block.
+const wsSendBuffer = 64
+
+// wsFrame is one queued write: an event, or a close frame that ends the
+// connection after everything queued before it has been sent.
+type wsFrame struct {
+	envelope  wsEnvelope
+	closeCode int
+	reason    string
+}
+
+// wsConn serializes writes to one connection, since gorilla/websocket allows
+// a single concurrent writer. Handlers queue events with send and a writer
+// goroutine delivers them together with heartbeat pings.
+type wsConn struct {
+	id          string
+	connectedAt time.Time
+	conn        *websocket.Conn
+	out         chan wsFrame
+	closed      chan struct{}
+
+	lastMessage atomic.Int64
+	// received and sent count client messages and delivered events.
+	received atomic.Int64
+	sent     atomic.Int64
+}
+
+func newWSConn(conn *websocket.Conn) *wsConn {
+	c := &wsConn{
+		id:          uuid.NewString(),
+		connectedAt: time.Now().UTC(),
+		conn:        conn,
+		out:         make(chan wsFrame, wsSendBuffer),
+		closed:      make(chan struct{}),
+	}
+	c.touch()
+	return c
+}
+
+// send queues envelope. It blocks while the queue is full, so a slow client
+// slows the answers streamed to it, and drops the event once the writer
+// stopped.
+func (c *wsConn) send(envelope wsEnvelope) {
+	c.queue(wsFrame{envelope: envelope})
+}
+
+// close queues a close frame; the writer stops after sending it.
+func (c *wsConn) close(code int, reason string) {
+	c.queue(wsFrame{closeCode: code, reason: reason})
+}
+
+func (c *wsConn) queue(frame wsFrame) {
+	select {
+	case c.out <- frame:
+	case <-c.closed:
+	}
+}
+
+// touch records client activity for the idle timeout.
+func (c *wsConn) touch() {
+	c.lastMessage.Store(time.Now().UnixNano())
+}
+
+// receive counts a client message and records the activity.
+func (c *wsConn) receive() {
+	c.received.Add(1)
+	c.touch()
+}
+
+// writeLoop delivers queued frames, pings every pingInterval so dead peers
+// are noticed through missing pongs, and closes connections idle for longer
+// than idleTimeout. It returns, closing the connection and so unblocking the
+// reader, when stop is closed, a write fails or a close frame was sent.
+func (c *wsConn) writeLoop(ctx context.Context, pingInterval, idleTimeout time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(pingInterval)
+	defer func() {
+		ticker.Stop()
+		close(c.closed)
+		_ = c.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-stop:
+			// Flush what the handler queued last, such as a closing error.
+			for {
+				select {
+				case frame := <-c.out:
+					if !c.deliver(ctx, frame) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		case frame := <-c.out:
+			if !c.deliver(ctx, frame) {
+				return
+			}
+		case now := <-ticker.C:
+			if idleTimeout > 0 && now.Sub(time.Unix(0, c.lastMessage.Load())) > idleTimeout {
+				slog.InfoContext(ctx, "유휴 웹소켓 연결 종료", "idle_timeout", idleTimeout)
+				_ = c.conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "idle timeout"),
+					now.Add(wsWriteWait))
+				return
+			}
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, now.Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// deliver writes frame and reports whether the connection stays open.
+func (c *wsConn) deliver(ctx context.Context, frame wsFrame) bool {
+	deadline := time.Now().Add(wsWriteWait)
+	if frame.closeCode != 0 {
+		_ = c.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(frame.closeCode, frame.reason), deadline)
+		return false
+	}
+	if frame.envelope.Version == 0 {
+		frame.envelope.Version = wsProtocolVersion
+	}
+	_ = c.conn.SetWriteDeadline(deadline)
+	if err := c.conn.WriteJSON(frame.envelope); err != nil {
+		slog.ErrorContext(ctx, "웹소켓 전송 실패", "error", err)
+		return false
+	}
+	c.sent.Add(1)
+	return true
+}
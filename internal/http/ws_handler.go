@@ -3,26 +3,125 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"yuon/internal/auth"
 	"yuon/internal/rag"
 	"yuon/internal/rag/service"
+	"yuon/internal/ratelimit"
+	"yuon/internal/webhook"
+	"yuon/package/validator"
 )
 
 type WebSocketHandler struct {
 	service *service.ChatbotService
+	manager *auth.Manager
+
+	limiter   ratelimit.Limiter
+	chatLimit rateLimitRule
+	// messageLimit throttles append_message per connection.
+	messageLimit ratelimit.Limit
+	events       *webhook.Dispatcher
+	// allowAnonymous lets unauthenticated clients chat; development only.
+	allowAnonymous bool
+
+	pingInterval time.Duration
+	idleTimeout  time.Duration
+
+	streams *wsStreams
+	hub     *wsHub
+
+	// dashboardInterval is how often dashboard subscribers get updates;
+	// zero disables them. dashboardRunning tells whether the update loop,
+	// which only runs while someone subscribes, is running.
+	dashboardInterval time.Duration
+	dashboardMu       sync.Mutex
+	dashboardRunning  bool
+}
+
+func NewWebSocketHandler(service *service.ChatbotService, manager *auth.Manager) *WebSocketHandler {
+	return &WebSocketHandler{
+		service:      service,
+		manager:      manager,
+		pingInterval: 30 * time.Second,
+		streams:      newWSStreams(),
+		hub:          newWSHub(),
+	}
+}
+
+// setRateLimit applies the chat limit to messages of authenticated users, on
+// top of the per-connection limit.
+func (h *WebSocketHandler) setRateLimit(limiter ratelimit.Limiter, rule rateLimitRule) {
+	h.limiter = limiter
+	h.chatLimit = rule
+}
+
+// setMessageLimit throttles append_message on each connection; a zero
+// PerMinute disables the per-connection limit.
+func (h *WebSocketHandler) setMessageLimit(limit ratelimit.Limit) {
+	h.messageLimit = limit
+}
+
+// setAllowAnonymous lets connections without a token chat instead of being
+// closed when their first message is not "authenticate".
+func (h *WebSocketHandler) setAllowAnonymous(allow bool) {
+	h.allowAnonymous = allow
+}
+
+// setHeartbeat sets how often connections are pinged and how long they may
+// go without a client message. A connection that misses pongs for two ping
+// intervals is considered dead; a zero idleTimeout disables the idle check.
+func (h *WebSocketHandler) setHeartbeat(pingInterval, idleTimeout time.Duration) {
+	if pingInterval > 0 {
+		h.pingInterval = pingInterval
+	}
+	h.idleTimeout = idleTimeout
 }
 
-func NewWebSocketHandler(service *service.ChatbotService) *WebSocketHandler {
-	return &WebSocketHandler{service: service}
+// setDashboardInterval sets how often live dashboard updates are pushed to
+// subscribed admins; zero disables the subscription.
+func (h *WebSocketHandler) setDashboardInterval(interval time.Duration) {
+	h.dashboardInterval = interval
 }
 
+// setWebhooks publishes conversation.completed when a client ends a
+// conversation.
+func (h *WebSocketHandler) setWebhooks(events *webhook.Dispatcher) {
+	h.events = events
+}
+
+// wsUser is the authenticated user of a connection. Anonymous connections,
+// allowed only in development, leave it empty and their conversations have
+// no owner.
+type wsUser struct {
+	ID        string
+	Name      string
+	Role      string
+	Workspace string
+	// IP limits chat messages of anonymous connections.
+	IP string
+}
+
+const (
+	// wsAuthTimeout is how long a connection that did not authenticate
+	// during the upgrade has to send its "authenticate" message.
+	wsAuthTimeout = 10 * time.Second
+	// wsWriteWait bounds control frame writes from the heartbeat.
+	wsWriteWait = 10 * time.Second
+)
+
 var wsUpgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -31,32 +130,114 @@ var wsUpgrader = websocket.Upgrader{
 	},
 }
 
+// wsProtocolVersion is the envelope version this server speaks. Server
+// events always carry it; clients may omit it, which means version 1.
+const wsProtocolVersion = 1
+
 type wsEnvelope struct {
+	Version int             `json:"v,omitempty"`
 	Type    string          `json:"type"`
 	Payload json.RawMessage `json:"payload"`
 }
 
+// Error codes of error events, so clients can react without parsing the
+// message.
+const (
+	wsErrInvalidJSON        = "invalid_json"
+	wsErrUnsupportedVersion = "unsupported_version"
+	wsErrUnknownEvent       = "unknown_event"
+	wsErrInvalidPayload     = "invalid_payload"
+	wsErrInvalidSequence    = "invalid_sequence"
+	wsErrUnauthenticated    = "unauthenticated"
+	wsErrForbidden          = "forbidden"
+	wsErrRateLimit          = "rate_limit"
+	wsErrConversationBusy   = "conversation_busy"
+	wsErrNoGeneration       = "no_generation"
+	wsErrGenerationFailed   = "generation_failed"
+)
+
+// Client payloads are validated with their binding tags like REST request
+// bodies, see decodePayload.
+
+type authenticatePayload struct {
+	Token string `json:"token" binding:"required"`
+}
+
 type startConversationPayload struct {
-	ConversationID string `json:"conversation_id,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty" binding:"omitempty,max=128"`
 }
 
 type appendMessagePayload struct {
-	ConversationID  string            `json:"conversation_id,omitempty"`
-	MessageID       string            `json:"message_id,omitempty"`
-	Message         string            `json:"message"`
+	ConversationID  string            `json:"conversation_id,omitempty" binding:"omitempty,max=128"`
+	MessageID       string            `json:"message_id,omitempty" binding:"omitempty,max=128"`
+	Message         string            `json:"message" binding:"required"`
 	UseVectorSearch *bool             `json:"use_vector_search,omitempty"`
 	UseFullText     *bool             `json:"use_full_text,omitempty"`
-	TopK            int               `json:"top_k,omitempty"`
+	TopK            int               `json:"top_k,omitempty" binding:"omitempty,min=1,max=50"`
 	History         []rag.ChatMessage `json:"history,omitempty"`
+	VectorSpace     string            `json:"vector_space,omitempty"`
+	Category        string            `json:"category,omitempty" binding:"omitempty,category"`
+	Tags            []string          `json:"tags,omitempty"`
+	UploadedAfter   *time.Time        `json:"uploaded_after,omitempty"`
+	UploadedBefore  *time.Time        `json:"uploaded_before,omitempty"`
+}
+
+type cancelMessagePayload struct {
+	MessageID string `json:"message_id" binding:"required,max=128"`
+}
+
+type cancelGenerationPayload struct {
+	ConversationID string `json:"conversation_id" binding:"required,max=128"`
+}
+
+// conversationPayload is the payload of typing and end_conversation.
+type conversationPayload struct {
+	ConversationID string `json:"conversation_id,omitempty" binding:"omitempty,max=128"`
+}
+
+type conversationHistoryPayload struct {
+	ConversationID string           `json:"conversation_id"`
+	Messages       []historyMessage `json:"messages"`
+	// Pending is the message still being answered; stream_chunk events with
+	// the chunks already produced follow, then the rest of the stream.
+	Pending *pendingMessagePayload `json:"pending,omitempty"`
+}
+
+type historyMessage struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+type pendingMessagePayload struct {
+	MessageID string `json:"message_id"`
+	Message   string `json:"message"`
 }
 
 type wsErrorPayload struct {
 	Message string `json:"message"`
+	Code    string `json:"code"`
+	// Event is the type of the client event that was rejected, if any.
+	Event string `json:"event,omitempty"`
+	// Details lists the fields of an invalid_payload error.
+	Details []validator.ValidationError `json:"details,omitempty"`
+	Scope   string                      `json:"scope,omitempty"`
+	// RetryAfter is the wait in seconds before a throttled message may be
+	// sent again.
+	RetryAfter int `json:"retry_after,omitempty"`
 }
 
 type messageAckPayload struct {
 	ConversationID string `json:"conversation_id"`
 	MessageID      string `json:"message_id"`
+	// Message is the question, so other participants can show it.
+	Message string `json:"message,omitempty"`
+}
+
+type typingPayload struct {
+	ConversationID string `json:"conversation_id"`
+	UserID         string `json:"user_id,omitempty"`
+	Name           string `json:"name,omitempty"`
 }
 
 type streamChunkPayload struct {
@@ -72,6 +253,8 @@ type streamEndPayload struct {
 	Answer         string         `json:"answer"`
 	Sources        []rag.Document `json:"sources,omitempty"`
 	TokensUsed     int            `json:"tokens_used,omitempty"`
+	Degraded       bool           `json:"degraded,omitempty"`
+	Warning        string         `json:"warning,omitempty"`
 }
 
 type rateLimiter struct {
@@ -82,16 +265,29 @@ type rateLimiter struct {
 	mu       sync.Mutex
 }
 
-func newRateLimiter(rate float64) *rateLimiter {
+// newRateLimiter returns a bucket for limit, or nil, which allows everything,
+// when the limit is disabled.
+func newRateLimiter(limit ratelimit.Limit) *rateLimiter {
+	if limit.PerMinute <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst <= 0 {
+		burst = float64(limit.PerMinute)
+	}
 	return &rateLimiter{
-		rate:     rate,
-		capacity: rate,
-		tokens:   rate,
+		rate:     float64(limit.PerMinute) / 60,
+		capacity: burst,
+		tokens:   burst,
 		last:     time.Now(),
 	}
 }
 
-func (r *rateLimiter) Allow() bool {
+// Allow takes a token, or reports how long until the next one is available.
+func (r *rateLimiter) Allow() (bool, time.Duration) {
+	if r == nil {
+		return true, 0
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -104,79 +300,393 @@ func (r *rateLimiter) Allow() bool {
 	}
 
 	if r.tokens < 1 {
-		return false
+		return false, time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
 	}
 
 	r.tokens -= 1
-	return true
+	return true, 0
 }
 
+// Handle serves a chat connection. Clients authenticate with a JWT in the
+// `token` query parameter or Authorization header, or by sending
+// {"type":"authenticate","payload":{"token":...}} as the first message.
+// Connections that do neither are closed unless anonymous chat is allowed.
 func (h *WebSocketHandler) Handle(c *gin.Context) {
+	var user wsUser
+	if token := wsToken(c); token != "" {
+		var err error
+		if user, err = h.authenticate(token); err != nil {
+			h.rejectUpgrade(c, err)
+			return
+		}
+	}
+	user.IP = c.ClientIP()
+
+	ctx := c.Request.Context()
 	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		slog.Error("웹소켓 업그레이드 실패", "error", err)
+		slog.ErrorContext(ctx, "웹소켓 업그레이드 실패", "error", err)
 		return
 	}
-	defer conn.Close()
 
-	limiter := newRateLimiter(5)
+	// Until the client is allowed to chat only the auth deadline applies;
+	// afterwards every pong or message pushes the read deadline out.
+	var live atomic.Bool
+	pongWait := 2 * h.pingInterval
+	extend := func() {
+		if live.Load() {
+			_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+		}
+	}
+	conn.SetPongHandler(func(string) error {
+		extend()
+		return nil
+	})
+
+	if user.ID != "" {
+		ctx = rag.WithWorkspace(ctx, user.Workspace)
+	}
+	if user.ID != "" || h.allowAnonymous {
+		live.Store(true)
+		extend()
+	} else {
+		_ = conn.SetReadDeadline(time.Now().Add(wsAuthTimeout))
+	}
 
-	for {
+	// Every write goes through wc's writer goroutine, which also closes conn.
+	wc := newWSConn(conn)
+	stop := make(chan struct{})
+	go wc.writeLoop(ctx, h.pingInterval, h.idleTimeout, stop)
+	h.hub.connect(wc, user)
+	defer func() {
+		h.hub.disconnect(wc)
+		close(stop)
+		<-wc.closed
+	}()
+
+	limiter := newRateLimiter(h.messageLimit)
+
+	for first := true; ; first = false {
+		extend()
 		_, data, err := conn.ReadMessage()
 		if err != nil {
-			slog.Warn("웹소켓 연결 종료", "error", err)
+			slog.WarnContext(ctx, "웹소켓 연결 종료", "error", err)
 			break
 		}
+		wc.receive()
 
 		var envelope wsEnvelope
-		if err := json.Unmarshal(data, &envelope); err != nil {
-			h.sendError(conn, "잘못된 메시지 형식입니다")
+		if err := json.Unmarshal(data, &envelope); err != nil || envelope.Type == "" {
+			h.sendError(wc, wsErrInvalidJSON, "잘못된 메시지 형식입니다")
+			continue
+		}
+		if envelope.Version > wsProtocolVersion || envelope.Version < 0 {
+			h.sendEventError(wc, envelope.Type, wsErrUnsupportedVersion,
+				fmt.Sprintf("지원하지 않는 프로토콜 버전입니다 (지원: %d)", wsProtocolVersion))
 			continue
 		}
 
+		if envelope.Type == "authenticate" {
+			if !first || user.ID != "" {
+				h.sendEventError(wc, envelope.Type, wsErrInvalidSequence, "authenticate는 첫 메시지로만 보낼 수 있습니다")
+				continue
+			}
+			var req authenticatePayload
+			if !h.decodePayload(wc, envelope, &req) {
+				wc.close(websocket.ClosePolicyViolation, "토큰이 필요합니다")
+				return
+			}
+			authed, err := h.authenticate(req.Token)
+			if err != nil {
+				h.closePolicy(wc, wsAuthErrorCode(err), wsAuthError(err))
+				return
+			}
+			authed.IP = user.IP
+			user = authed
+			ctx = rag.WithWorkspace(ctx, user.Workspace)
+			h.hub.connect(wc, user)
+			live.Store(true)
+			h.sendSystemNotice(wc, "", "authenticated")
+			continue
+		}
+		if user.ID == "" && !h.allowAnonymous {
+			h.closePolicy(wc, wsErrUnauthenticated, "인증이 필요합니다. 첫 메시지로 authenticate를 보내세요")
+			return
+		}
+
 		switch envelope.Type {
 		case "start_conversation":
-			h.handleStartConversation(conn, envelope.Payload)
+			var req startConversationPayload
+			if h.decodePayload(wc, envelope, &req) {
+				h.handleStartConversation(ctx, wc, req, user)
+			}
 		case "append_message":
-			if !limiter.Allow() {
-				h.sendError(conn, "채팅 속도를 초과했습니다. 잠시 후 다시 시도해주세요")
+			var req appendMessagePayload
+			if !h.decodePayload(wc, envelope, &req) {
+				continue
+			}
+			if ok, retryAfter := limiter.Allow(); !ok {
+				h.sendRateLimited(wc, "connection", retryAfter)
 				continue
 			}
-			h.handleAppendMessage(conn, envelope.Payload)
+			if ok, retryAfter := h.allowUser(ctx, user); !ok {
+				h.sendRateLimited(wc, "user", retryAfter)
+				continue
+			}
+			h.handleAppendMessage(ctx, wc, req, user)
+		case "cancel_message":
+			var req cancelMessagePayload
+			if h.decodePayload(wc, envelope, &req) {
+				h.handleCancelMessage(wc, req, user)
+			}
+		case "cancel_generation":
+			var req cancelGenerationPayload
+			if h.decodePayload(wc, envelope, &req) {
+				h.handleCancelGeneration(wc, req, user)
+			}
+		case "subscribe_ingestion", "unsubscribe_ingestion":
+			h.handleIngestionSubscription(wc, envelope.Type == "subscribe_ingestion", user)
+		case "subscribe_dashboard", "unsubscribe_dashboard":
+			h.handleDashboardSubscription(wc, envelope.Type == "subscribe_dashboard", user)
 		case "typing":
-			h.handleTyping(conn, envelope.Payload)
+			var req conversationPayload
+			if h.decodePayload(wc, envelope, &req) {
+				h.handleTyping(wc, req, user)
+			}
 		case "end_conversation":
-			h.handleEndConversation(conn, envelope.Payload)
+			var req conversationPayload
+			if h.decodePayload(wc, envelope, &req) {
+				h.handleEndConversation(ctx, wc, req, user)
+			}
 		default:
-			h.sendError(conn, "알 수 없는 이벤트 타입입니다")
+			h.sendEventError(wc, envelope.Type, wsErrUnknownEvent, "알 수 없는 이벤트 타입입니다")
 		}
 	}
 }
 
-func (h *WebSocketHandler) handleStartConversation(conn *websocket.Conn, payload json.RawMessage) {
-	req := startConversationPayload{}
-	_ = json.Unmarshal(payload, &req)
+// wsToken reads a JWT from the `token` query parameter (browsers cannot set
+// headers on WebSocket requests) or the Authorization header.
+func wsToken(c *gin.Context) string {
+	token := c.Query("token")
+	if header := c.GetHeader("Authorization"); token == "" && strings.HasPrefix(strings.ToLower(header), "bearer ") {
+		token = strings.TrimSpace(header[7:])
+	}
+	return token
+}
 
-	if req.ConversationID == "" {
+var errWSForbidden = errors.New("chat permission required")
+
+// authenticate validates token and checks the user may chat, the same
+// permission the REST conversation endpoints require.
+func (h *WebSocketHandler) authenticate(token string) (wsUser, error) {
+	if h.manager == nil {
+		return wsUser{}, errors.New("auth manager is not configured")
+	}
+	claims, err := h.manager.ValidateJWT(token)
+	if err != nil {
+		return wsUser{}, err
+	}
+
+	permitted := auth.RoleHasPermission(claims.Role, auth.ScopeChatWrite)
+	if claims.Role == auth.RoleService {
+		permitted = slices.Contains(claims.Scopes, auth.ScopeChatWrite)
+	}
+	if !permitted {
+		return wsUser{}, errWSForbidden
+	}
+	return wsUser{ID: claims.Subject, Name: claims.Name, Role: claims.Role, Workspace: claims.Workspace}, nil
+}
+
+// staff reports whether the user may observe other users' conversations.
+func (u wsUser) staff() bool {
+	return conversationStaff(u.Role)
+}
+
+func (h *WebSocketHandler) rejectUpgrade(c *gin.Context, err error) {
+	if errors.Is(err, errWSForbidden) {
+		ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "'"+auth.ScopeChatWrite+"' 권한이 없습니다")
+		return
+	}
+	ErrorResponse(c, http.StatusUnauthorized, "UNAUTHENTICATED", "토큰이 유효하지 않습니다")
+}
+
+func wsAuthErrorCode(err error) string {
+	if errors.Is(err, errWSForbidden) {
+		return wsErrForbidden
+	}
+	return wsErrUnauthenticated
+}
+
+func wsAuthError(err error) string {
+	if errors.Is(err, errWSForbidden) {
+		return "'" + auth.ScopeChatWrite + "' 권한이 없습니다"
+	}
+	return "토큰이 유효하지 않습니다"
+}
+
+// closePolicy reports msg as an error event and closes the connection with
+// 1008 (policy violation).
+func (h *WebSocketHandler) closePolicy(wc *wsConn, code, msg string) {
+	h.sendError(wc, code, msg)
+	wc.close(websocket.ClosePolicyViolation, msg)
+}
+
+// allowUser applies the chat limit shared by all connections of the user, or
+// of the client IP for anonymous connections.
+func (h *WebSocketHandler) allowUser(ctx context.Context, user wsUser) (bool, time.Duration) {
+	if h.limiter == nil || h.chatLimit.limit.PerMinute <= 0 {
+		return true, 0
+	}
+	principal := "ip:" + user.IP
+	if user.ID != "" {
+		principal = "user:" + user.ID
+	}
+	res, err := h.limiter.Allow(ctx, rateLimitKey(h.chatLimit.group, principal), h.chatLimit.limit)
+	if err != nil {
+		slog.WarnContext(ctx, "요청 한도 확인 실패", "error", err, "group", h.chatLimit.group)
+		return true, 0
+	}
+	return res.Allowed, res.RetryAfter
+}
+
+// sendRateLimited reports a throttled append_message. scope is "connection"
+// or "user", telling the client which limit it hit.
+func (h *WebSocketHandler) sendRateLimited(wc *wsConn, scope string, retryAfter time.Duration) {
+	h.write(wc, wsEnvelope{
+		Type: "error",
+		Payload: mustMarshal(wsErrorPayload{
+			Message:    "채팅 속도를 초과했습니다. 잠시 후 다시 시도해주세요",
+			Code:       wsErrRateLimit,
+			Scope:      scope,
+			RetryAfter: ceilSeconds(retryAfter),
+		}),
+	})
+}
+
+// handleStartConversation starts a conversation, or resumes the one named by
+// conversation_id after a reconnect by replaying its history and the answer
+// still being generated, if any.
+func (h *WebSocketHandler) handleStartConversation(ctx context.Context, wc *wsConn, req startConversationPayload, user wsUser) {
+	resume := req.ConversationID != ""
+	if !resume {
 		req.ConversationID = uuid.New().String()
 	}
+	access := h.access(ctx, req.ConversationID, user)
+	if access == wsNoAccess {
+		h.sendError(wc, wsErrForbidden, "대화에 접근할 권한이 없습니다")
+		return
+	}
 
-	h.service.EnsureConversation(req.ConversationID)
-	h.sendSystemNotice(conn, req.ConversationID, "conversation_started")
+	if access == wsParticipant {
+		h.service.EnsureConversation(req.ConversationID)
+		h.service.SetConversationOwner(req.ConversationID, user.ID, user.Name)
+	}
+	h.sendSystemNotice(wc, req.ConversationID, "conversation_started")
+	if resume {
+		h.replay(ctx, wc, req.ConversationID)
+		return
+	}
+	h.hub.join(req.ConversationID, wc)
 }
 
-func (h *WebSocketHandler) handleAppendMessage(conn *websocket.Conn, payload json.RawMessage) {
-	var req appendMessagePayload
-	if err := json.Unmarshal(payload, &req); err != nil {
-		h.sendError(conn, "잘못된 요청 데이터입니다")
+// wsAccess is how a user may take part in a conversation.
+type wsAccess int
+
+const (
+	wsNoAccess wsAccess = iota
+	// wsParticipant may chat: the owner, or anyone in a conversation that
+	// has no owner and no messages yet.
+	wsParticipant
+	// wsObserver receives the events of someone else's conversation but
+	// cannot send messages; staff only.
+	wsObserver
+)
+
+// access decides how user takes part in conversationID, following the same
+// ownership rules as the REST conversation routes. Without a conversation
+// store ownership is unknown and everyone participates. A conversation with
+// messages but no owner, recorded before ownership or by an anonymous
+// development client, is only open to staff and anonymous clients. An
+// unknown conversation is a new one the caller will own; any other lookup
+// failure denies access.
+func (h *WebSocketHandler) access(ctx context.Context, conversationID string, user wsUser) wsAccess {
+	summary, err := h.service.GetConversationSummary(ctx, conversationID)
+	if errors.Is(err, service.ErrConversationNotFound) || errors.Is(err, service.ErrNoConversationStore) {
+		return wsParticipant
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "대화 조회 실패", "conversation_id", conversationID, "error", err)
+		return wsNoAccess
+	}
+	if summary.OwnerID == user.ID {
+		return wsParticipant
+	}
+	if summary.OwnerID == "" && (summary.MessageCount == 0 || user.ID == "") {
+		return wsParticipant
+	}
+	if user.staff() {
+		return wsObserver
+	}
+	return wsNoAccess
+}
+
+// replay sends the stored history of conversationID and joins wc to it,
+// together with the answer being generated and the chunks produced so far.
+func (h *WebSocketHandler) replay(ctx context.Context, wc *wsConn, conversationID string) {
+	st := h.streams.get(conversationID)
+	if st == nil {
+		h.hub.join(conversationID, wc)
+		h.write(wc, wsEnvelope{
+			Type:    "conversation_history",
+			Payload: mustMarshal(conversationHistoryPayload{ConversationID: conversationID, Messages: h.history(ctx, conversationID)}),
+		})
 		return
 	}
 
-	if req.Message == "" {
-		h.sendError(conn, "message 필드는 필수입니다")
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	history := conversationHistoryPayload{ConversationID: conversationID, Messages: h.history(ctx, conversationID)}
+	if !st.done {
+		history.Pending = &pendingMessagePayload{MessageID: st.messageID, Message: st.message}
+	}
+	h.write(wc, wsEnvelope{Type: "conversation_history", Payload: mustMarshal(history)})
+	if st.done {
+		h.hub.join(conversationID, wc)
 		return
 	}
+	for idx, chunk := range st.chunks {
+		h.write(wc, wsEnvelope{
+			Type: "stream_chunk",
+			Payload: mustMarshal(streamChunkPayload{
+				ConversationID: conversationID,
+				MessageID:      st.messageID,
+				Chunk:          chunk,
+				Index:          idx,
+			}),
+		})
+	}
+	h.hub.join(conversationID, wc)
+}
 
+// history returns the stored messages of conversationID, falling back to the
+// in-memory history when no conversation store is available.
+func (h *WebSocketHandler) history(ctx context.Context, conversationID string) []historyMessage {
+	messages := []historyMessage{}
+	stored, err := h.service.GetConversationMessages(ctx, conversationID)
+	if err != nil {
+		for _, m := range h.service.ConversationHistory(conversationID) {
+			messages = append(messages, historyMessage{Role: m.Role, Content: m.Content})
+		}
+		return messages
+	}
+	for _, m := range stored {
+		ts := m.Timestamp
+		messages = append(messages, historyMessage{Role: m.Role, Content: m.Content, Timestamp: &ts})
+	}
+	return messages
+}
+
+func (h *WebSocketHandler) handleAppendMessage(ctx context.Context, wc *wsConn, req appendMessagePayload, user wsUser) {
 	if req.ConversationID == "" {
 		req.ConversationID = uuid.New().String()
 	}
@@ -184,13 +694,55 @@ func (h *WebSocketHandler) handleAppendMessage(conn *websocket.Conn, payload jso
 		req.MessageID = uuid.New().String()
 	}
 
+	switch h.access(ctx, req.ConversationID, user) {
+	case wsNoAccess:
+		h.sendError(wc, wsErrForbidden, "대화에 접근할 권한이 없습니다")
+		return
+	case wsObserver:
+		h.sendError(wc, wsErrForbidden, "참관 중인 대화에는 메시지를 보낼 수 없습니다")
+		return
+	}
+
 	h.service.EnsureConversation(req.ConversationID)
+	h.service.SetConversationOwner(req.ConversationID, user.ID, user.Name)
+	h.hub.join(req.ConversationID, wc)
 
-	h.write(conn, wsEnvelope{
-		Type:    "message_ack",
-		Payload: mustMarshal(messageAckPayload{ConversationID: req.ConversationID, MessageID: req.MessageID}),
+	// Generating can take a while, so it runs beside the read loop and the
+	// client can still cancel it, type or use other conversations meanwhile.
+	// It is not tied to the connection: a client that reconnects picks the
+	// answer up again with start_conversation.
+	st := &wsStream{
+		conversationID: req.ConversationID,
+		userID:         user.ID,
+		roles:          documentRoles(user.Role),
+		messageID:      req.MessageID,
+		message:        req.Message,
+		hub:            h.hub,
+	}
+	genCtx, ok := h.streams.start(context.WithoutCancel(ctx), st)
+	if !ok {
+		h.sendError(wc, wsErrConversationBusy, "이 대화의 이전 답변을 생성 중입니다. 완료되거나 취소된 후 다시 보내주세요")
+		return
+	}
+
+	st.publish(wsEnvelope{
+		Type: "message_ack",
+		Payload: mustMarshal(messageAckPayload{
+			ConversationID: req.ConversationID,
+			MessageID:      req.MessageID,
+			Message:        req.Message,
+		}),
 	})
 
+	go func() {
+		defer h.streams.finish(req.ConversationID)
+		h.generate(genCtx, st, req)
+	}()
+}
+
+// generate answers req and streams the answer to whichever connection
+// follows st.
+func (h *WebSocketHandler) generate(ctx context.Context, st *wsStream, req appendMessagePayload) {
 	useVector := true
 	useFullText := true
 
@@ -211,50 +763,57 @@ func (h *WebSocketHandler) handleAppendMessage(conn *websocket.Conn, payload jso
 		existingHistory = append(existingHistory, req.History...)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	chatCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 
 	startTime := time.Now()
-	resp, err := h.service.Chat(ctx, &rag.ChatRequest{
+	resp, err := h.service.Answer(chatCtx, &rag.ChatRequest{
 		Message:         req.Message,
 		ConversationID:  req.ConversationID,
 		UseVectorSearch: useVector,
 		UseFullText:     useFullText,
 		TopK:            req.TopK,
 		History:         existingHistory,
+		VectorSpace:     req.VectorSpace,
+		UserID:          st.userID,
+		Filters: &rag.SearchFilters{
+			Category:       req.Category,
+			Tags:           req.Tags,
+			UploadedAfter:  req.UploadedAfter,
+			UploadedBefore: req.UploadedBefore,
+			Roles:          st.roles,
+		},
 	})
 	responseTime := time.Since(startTime)
 
-	if err != nil {
-		slog.Error("웹소켓 챗 처리 실패", "error", err)
-		h.sendError(conn, "응답 생성에 실패했습니다")
+	if errors.Is(ctx.Err(), context.Canceled) {
+		h.cancelled(st, req)
 		return
 	}
-
-	h.service.AppendConversationMessage(req.ConversationID, rag.ChatMessage{
-		Role:    "user",
-		Content: req.Message,
-	})
-
-	// Generate conversation title from first user message
-	if len(existingHistory) == 0 {
-		go h.service.GenerateAndSetConversationTitle(context.Background(), req.ConversationID, req.Message)
+	if err != nil {
+		slog.ErrorContext(ctx, "웹소켓 챗 처리 실패", "error", err)
+		st.publish(errorEnvelope(wsErrGenerationFailed, "응답 생성에 실패했습니다"))
+		return
 	}
 
 	chunks := splitString(resp.Answer, 200)
 	for idx, chunk := range chunks {
-		h.write(conn, wsEnvelope{
-			Type: "stream_chunk",
-			Payload: mustMarshal(streamChunkPayload{
-				ConversationID: resp.ConversationID,
-				MessageID:      req.MessageID,
-				Chunk:          chunk,
-				Index:          idx,
-			}),
+		if ctx.Err() != nil {
+			h.cancelled(st, req)
+			return
+		}
+		st.chunk(streamChunkPayload{
+			ConversationID: resp.ConversationID,
+			MessageID:      req.MessageID,
+			Chunk:          chunk,
+			Index:          idx,
 		})
 	}
 
-	h.write(conn, wsEnvelope{
+	// The exchange is stored only once answered, together with stream_end.
+	st.end(func() {
+		h.service.SaveExchange(ctx, req.ConversationID, req.Message, startTime.UTC(), resp)
+	}, wsEnvelope{
 		Type: "stream_end",
 		Payload: mustMarshal(streamEndPayload{
 			ConversationID: resp.ConversationID,
@@ -262,61 +821,218 @@ func (h *WebSocketHandler) handleAppendMessage(conn *websocket.Conn, payload jso
 			Answer:         resp.Answer,
 			Sources:        resp.Sources,
 			TokensUsed:     resp.TokensUsed,
+			Degraded:       resp.Degraded,
+			Warning:        resp.Warning,
 		}),
 	})
-	h.service.AppendConversationMessage(req.ConversationID, rag.ChatMessage{
-		Role:    "assistant",
-		Content: resp.Answer,
-	})
-	h.service.RecordTokenUsage(req.ConversationID, resp.TokensUsed)
+
+	// Generate conversation title from first user message
+	if len(existingHistory) == 0 {
+		go h.service.GenerateAndSetConversationTitle(context.Background(), req.ConversationID, req.Message)
+	}
 
 	// Record session activity and response time
 	h.service.RecordSessionActivity(context.Background(), req.ConversationID, req.ConversationID)
-	h.service.RecordResponseMetrics(context.Background(), req.ConversationID, int(responseTime.Milliseconds()), resp.TokensUsed)
+	h.service.RecordResponseMetrics(context.Background(), req.ConversationID, responseTime, resp)
+}
+
+// cancelled ends a stream stopped by the client. Nothing is stored, so the
+// question can simply be asked again.
+func (h *WebSocketHandler) cancelled(st *wsStream, req appendMessagePayload) {
+	st.publish(wsEnvelope{
+		Type:    "stream_cancelled",
+		Payload: mustMarshal(messageAckPayload{ConversationID: req.ConversationID, MessageID: req.MessageID}),
+	})
+}
+
+func (h *WebSocketHandler) sendError(wc *wsConn, code, msg string) {
+	h.write(wc, errorEnvelope(code, msg))
+}
+
+// sendEventError reports an error caused by a client event of type event.
+func (h *WebSocketHandler) sendEventError(wc *wsConn, event, code, msg string) {
+	h.write(wc, wsEnvelope{
+		Type:    "error",
+		Payload: mustMarshal(wsErrorPayload{Message: msg, Code: code, Event: event}),
+	})
 }
 
-func (h *WebSocketHandler) sendError(conn *websocket.Conn, msg string) {
-	response := wsEnvelope{
+func errorEnvelope(code, msg string) wsEnvelope {
+	return wsEnvelope{
 		Type:    "error",
-		Payload: mustMarshal(wsErrorPayload{Message: msg}),
+		Payload: mustMarshal(wsErrorPayload{Message: msg, Code: code}),
+	}
+}
+
+// decodePayload unmarshals the payload of envelope into v and validates it
+// against v's binding tags. On failure it sends an invalid_payload error
+// listing the offending fields and returns false.
+func (h *WebSocketHandler) decodePayload(wc *wsConn, envelope wsEnvelope, v any) bool {
+	var err error
+	if len(envelope.Payload) > 0 && string(envelope.Payload) != "null" {
+		err = json.Unmarshal(envelope.Payload, v)
+	}
+	if err == nil {
+		err = binding.Validator.ValidateStruct(v)
+	}
+	if err == nil {
+		return true
 	}
-	h.write(conn, response)
+
+	h.write(wc, wsEnvelope{
+		Type: "error",
+		Payload: mustMarshal(wsErrorPayload{
+			Message: "잘못된 요청 데이터입니다",
+			Code:    wsErrInvalidPayload,
+			Event:   envelope.Type,
+			Details: validator.GetValidationErrors(err),
+		}),
+	})
+	return false
 }
 
-func (h *WebSocketHandler) handleTyping(conn *websocket.Conn, payload json.RawMessage) {
-	var req struct {
-		ConversationID string `json:"conversation_id,omitempty"`
+func (h *WebSocketHandler) handleCancelMessage(wc *wsConn, req cancelMessagePayload, user wsUser) {
+	if !h.streams.cancelMessage(req.MessageID, user.ID) {
+		h.sendError(wc, wsErrNoGeneration, "생성 중인 답변이 없습니다")
 	}
-	_ = json.Unmarshal(payload, &req)
-	h.sendSystemNotice(conn, req.ConversationID, "typing 이벤트가 수신되었습니다")
 }
 
-func (h *WebSocketHandler) handleEndConversation(conn *websocket.Conn, payload json.RawMessage) {
-	var req struct {
-		ConversationID string `json:"conversation_id,omitempty"`
+func (h *WebSocketHandler) handleCancelGeneration(wc *wsConn, req cancelGenerationPayload, user wsUser) {
+	if !h.streams.cancel(req.ConversationID, user.ID) {
+		h.sendError(wc, wsErrNoGeneration, "생성 중인 답변이 없습니다")
 	}
-	_ = json.Unmarshal(payload, &req)
+}
+
+// handleIngestionSubscription starts or stops sending ingestion_progress
+// events for the user's workspace to wc. Only staff may watch ingestion.
+func (h *WebSocketHandler) handleIngestionSubscription(wc *wsConn, on bool, user wsUser) {
+	if !user.staff() {
+		h.sendError(wc, wsErrForbidden, "문서 처리 현황은 운영자만 구독할 수 있습니다")
+		return
+	}
+	h.hub.watch(wc, on)
+	if on {
+		h.sendSystemNotice(wc, "", "ingestion_subscribed")
+		return
+	}
+	h.sendSystemNotice(wc, "", "ingestion_unsubscribed")
+}
+
+// handleDashboardSubscription starts or stops sending dashboard_update
+// events to wc. Only admins may watch the dashboard.
+func (h *WebSocketHandler) handleDashboardSubscription(wc *wsConn, on bool, user wsUser) {
+	if user.Role != auth.RoleRoot && user.Role != auth.RoleAdmin {
+		h.sendError(wc, wsErrForbidden, "실시간 대시보드는 관리자만 구독할 수 있습니다")
+		return
+	}
+	if on && h.dashboardInterval <= 0 {
+		h.sendError(wc, wsErrForbidden, "실시간 대시보드가 비활성화되어 있습니다")
+		return
+	}
+	h.hub.watchDashboard(wc, on)
+	if !on {
+		h.sendSystemNotice(wc, "", "dashboard_unsubscribed")
+		return
+	}
+	h.sendSystemNotice(wc, "", "dashboard_subscribed")
+
+	h.dashboardMu.Lock()
+	defer h.dashboardMu.Unlock()
+	if !h.dashboardRunning {
+		h.dashboardRunning = true
+		go h.pushDashboardUpdates()
+	}
+}
+
+// pushDashboardUpdates sends the activity of each interval to the dashboard
+// subscribers until none is left.
+func (h *WebSocketHandler) pushDashboardUpdates() {
+	ticker := time.NewTicker(h.dashboardInterval)
+	defer ticker.Stop()
+
+	from := time.Now()
+	for to := range ticker.C {
+		h.dashboardMu.Lock()
+		if h.hub.dashboardCount() == 0 {
+			h.dashboardRunning = false
+			h.dashboardMu.Unlock()
+			return
+		}
+		h.dashboardMu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.dashboardInterval)
+		delta, err := h.service.GetDashboardDelta(ctx, from, to)
+		cancel()
+		if err != nil {
+			slog.Warn("실시간 대시보드 통계 조회 실패", "error", err)
+			continue
+		}
+		from = to
+		h.hub.notifyDashboards(wsEnvelope{Type: "dashboard_update", Payload: mustMarshal(delta)})
+	}
+}
+
+// handleTyping tells the other participants of the conversation that user is
+// typing.
+func (h *WebSocketHandler) handleTyping(wc *wsConn, req conversationPayload, user wsUser) {
+	if req.ConversationID != "" && h.hub.member(req.ConversationID, wc) {
+		h.hub.broadcast(req.ConversationID, wsEnvelope{
+			Type:    "typing",
+			Payload: mustMarshal(typingPayload{ConversationID: req.ConversationID, UserID: user.ID, Name: user.Name}),
+		}, wc)
+	}
+	h.sendSystemNotice(wc, req.ConversationID, "typing 이벤트가 수신되었습니다")
+}
+
+func (h *WebSocketHandler) handleEndConversation(ctx context.Context, wc *wsConn, req conversationPayload, user wsUser) {
+	if req.ConversationID == "" {
+		h.sendSystemNotice(wc, "", "conversation_closed")
+		return
+	}
+	// An observer only stops following the conversation.
+	if h.access(ctx, req.ConversationID, user) != wsParticipant {
+		h.hub.leave(req.ConversationID, wc)
+		h.sendSystemNotice(wc, req.ConversationID, "conversation_closed")
+		return
+	}
+
+	h.streams.cancel(req.ConversationID, user.ID)
+	h.events.Publish(ctx, webhook.EventConversationCompleted, gin.H{
+		"conversationId": req.ConversationID,
+		"messageCount":   len(h.service.ConversationHistory(req.ConversationID)),
+		"userId":         user.ID,
+	})
 	h.service.CloseConversation(req.ConversationID)
-	h.sendSystemNotice(conn, req.ConversationID, "conversation_closed")
+
+	closed := noticeEnvelope(req.ConversationID, "conversation_closed")
+	members := h.hub.close(req.ConversationID)
+	if !slices.Contains(members, wc) {
+		members = append(members, wc)
+	}
+	for _, member := range members {
+		member.send(closed)
+	}
+}
+
+func (h *WebSocketHandler) sendSystemNotice(wc *wsConn, conversationID, message string) {
+	h.write(wc, noticeEnvelope(conversationID, message))
 }
 
-func (h *WebSocketHandler) sendSystemNotice(conn *websocket.Conn, conversationID, message string) {
+func noticeEnvelope(conversationID, message string) wsEnvelope {
 	payload := map[string]string{
 		"message": message,
 	}
 	if conversationID != "" {
 		payload["conversation_id"] = conversationID
 	}
-	h.write(conn, wsEnvelope{
+	return wsEnvelope{
 		Type:    "system_notice",
 		Payload: mustMarshal(payload),
-	})
+	}
 }
 
-func (h *WebSocketHandler) write(conn *websocket.Conn, envelope wsEnvelope) {
-	if err := conn.WriteJSON(envelope); err != nil {
-		slog.Error("웹소켓 전송 실패", "error", err)
-	}
+func (h *WebSocketHandler) write(wc *wsConn, envelope wsEnvelope) {
+	wc.send(envelope)
 }
 
 func mustMarshal(v interface{}) json.RawMessage {
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"yuon/internal/auth"
+	"yuon/internal/rag/service"
+)
+
+// summaryRepo answers conversation lookups with summary or err; every other
+// repository method is left unimplemented.
+type summaryRepo struct {
+	service.ConversationRepository
+	summary *service.ConversationSummary
+	err     error
+}
+
+func (r summaryRepo) Get(ctx context.Context, id string) (*service.ConversationSummary, error) {
+	return r.summary, r.err
+}
+
+func TestWebSocketAccess(t *testing.T) {
+	owner := wsUser{ID: "owner", Role: auth.RoleUser}
+	other := wsUser{ID: "other", Role: auth.RoleUser}
+	admin := wsUser{ID: "admin", Role: auth.RoleAdmin}
+	owned := &service.ConversationSummary{ID: "c1", OwnerID: "owner", MessageCount: 3}
+
+	tests := []struct {
+		name string
+		repo service.ConversationRepository
+		user wsUser
+		want wsAccess
+	}{
+		{"no store", nil, other, wsParticipant},
+		{"new conversation", summaryRepo{err: service.ErrConversationNotFound}, other, wsParticipant},
+		{"lookup failure", summaryRepo{err: errors.New("connection refused")}, other, wsNoAccess},
+		{"lookup failure for staff", summaryRepo{err: context.DeadlineExceeded}, admin, wsNoAccess},
+		{"owner", summaryRepo{summary: owned}, owner, wsParticipant},
+		{"someone else", summaryRepo{summary: owned}, other, wsNoAccess},
+		{"staff observes", summaryRepo{summary: owned}, admin, wsObserver},
+		{"unowned and empty", summaryRepo{summary: &service.ConversationSummary{ID: "c1"}}, other, wsParticipant},
+		{"unowned with messages", summaryRepo{summary: &service.ConversationSummary{ID: "c1", MessageCount: 2}}, other, wsNoAccess},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewWebSocketHandler(service.NewChatbotService(nil, nil, nil, tt.repo, nil, 0), nil)
+			if got := h.access(context.Background(), "c1", tt.user); got != tt.want {
+				t.Errorf("access = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package http
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// wsHub tracks the open connections, for notices sent to every client, and
+// the connections taking part in each conversation, so events of a
+// conversation reach every participant, e.g. a student and a staff member
+// observing the exchange.
+type wsHub struct {
+	mu sync.RWMutex
+	// conns maps each connection to its user.
+	conns map[*wsConn]wsUser
+	rooms map[string]map[*wsConn]struct{}
+	// watchers receive document ingestion progress, and dashboards live
+	// analytics updates.
+	watchers   map[*wsConn]struct{}
+	dashboards map[*wsConn]struct{}
+
+	// Totals since start; those of closed connections are kept here and
+	// live connections add their own counters on read.
+	connectionsTotal int64
+	closedReceived   int64
+	closedSent       int64
+}
+
+func newWSHub() *wsHub {
+	return &wsHub{
+		conns:      make(map[*wsConn]wsUser),
+		rooms:      make(map[string]map[*wsConn]struct{}),
+		watchers:   make(map[*wsConn]struct{}),
+		dashboards: make(map[*wsConn]struct{}),
+	}
+}
+
+// connect registers wc, or updates its user once the client authenticated.
+func (h *wsHub) connect(wc *wsConn, user wsUser) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.conns[wc]; !ok {
+		h.connectionsTotal++
+	}
+	h.conns[wc] = user
+}
+
+func (h *wsHub) join(conversationID string, wc *wsConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	room, ok := h.rooms[conversationID]
+	if !ok {
+		room = make(map[*wsConn]struct{})
+		h.rooms[conversationID] = room
+	}
+	room[wc] = struct{}{}
+}
+
+func (h *wsHub) leave(conversationID string, wc *wsConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(conversationID, wc)
+}
+
+// disconnect removes wc and its conversations once the connection is gone.
+func (h *wsHub) disconnect(wc *wsConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.conns[wc]; ok {
+		h.closedReceived += wc.received.Load()
+		h.closedSent += wc.sent.Load()
+	}
+	delete(h.conns, wc)
+	delete(h.watchers, wc)
+	delete(h.dashboards, wc)
+	for conversationID := range h.rooms {
+		h.remove(conversationID, wc)
+	}
+}
+
+// remove drops wc from a room and the room once empty. The caller holds h.mu.
+func (h *wsHub) remove(conversationID string, wc *wsConn) {
+	room := h.rooms[conversationID]
+	delete(room, wc)
+	if len(room) == 0 {
+		delete(h.rooms, conversationID)
+	}
+}
+
+// close removes every member of a conversation and returns them.
+func (h *wsHub) close(conversationID string) []*wsConn {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	members := make([]*wsConn, 0, len(h.rooms[conversationID]))
+	for wc := range h.rooms[conversationID] {
+		members = append(members, wc)
+	}
+	delete(h.rooms, conversationID)
+	return members
+}
+
+func (h *wsHub) member(conversationID string, wc *wsConn) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := h.rooms[conversationID][wc]
+	return ok
+}
+
+// broadcast sends envelope to the members of a conversation except skip,
+// which may be nil. Sends happen outside the lock since a slow member blocks
+// until its queue drains.
+func (h *wsHub) broadcast(conversationID string, envelope wsEnvelope, skip *wsConn) {
+	h.mu.RLock()
+	members := make([]*wsConn, 0, len(h.rooms[conversationID]))
+	for wc := range h.rooms[conversationID] {
+		if wc != skip {
+			members = append(members, wc)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, wc := range members {
+		wc.send(envelope)
+	}
+}
+
+// notify sends envelope to every connection, or only to those of workspace
+// when it is not empty, and returns how many were reached.
+func (h *wsHub) notify(envelope wsEnvelope, workspace string) int {
+	h.mu.RLock()
+	members := make([]*wsConn, 0, len(h.conns))
+	for wc, user := range h.conns {
+		if workspace == "" || user.Workspace == workspace {
+			members = append(members, wc)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, wc := range members {
+		wc.send(envelope)
+	}
+	return len(members)
+}
+
+// watch subscribes wc to ingestion progress, or unsubscribes it.
+func (h *wsHub) watch(wc *wsConn, on bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if on {
+		h.watchers[wc] = struct{}{}
+	} else {
+		delete(h.watchers, wc)
+	}
+}
+
+// notifyWatchers sends envelope to the ingestion watchers of workspace.
+func (h *wsHub) notifyWatchers(envelope wsEnvelope, workspace string) {
+	h.mu.RLock()
+	members := make([]*wsConn, 0, len(h.watchers))
+	for wc := range h.watchers {
+		if h.conns[wc].Workspace == workspace {
+			members = append(members, wc)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, wc := range members {
+		wc.send(envelope)
+	}
+}
+
+// watchDashboard subscribes wc to live dashboard updates, or unsubscribes
+// it.
+func (h *wsHub) watchDashboard(wc *wsConn, on bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if on {
+		h.dashboards[wc] = struct{}{}
+	} else {
+		delete(h.dashboards, wc)
+	}
+}
+
+// notifyDashboards sends envelope to the dashboard subscribers and returns
+// how many were reached.
+func (h *wsHub) notifyDashboards(envelope wsEnvelope) int {
+	h.mu.RLock()
+	members := make([]*wsConn, 0, len(h.dashboards))
+	for wc := range h.dashboards {
+		members = append(members, wc)
+	}
+	h.mu.RUnlock()
+
+	for _, wc := range members {
+		wc.send(envelope)
+	}
+	return len(members)
+}
+
+func (h *wsHub) dashboardCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.dashboards)
+}
+
+// wsConnectionInfo describes a live connection for the admin listing.
+type wsConnectionInfo struct {
+	ID               string    `json:"id"`
+	UserID           string    `json:"user_id"`
+	Username         string    `json:"username,omitempty"`
+	Role             string    `json:"role,omitempty"`
+	Workspace        string    `json:"workspace,omitempty"`
+	IP               string    `json:"ip,omitempty"`
+	ConnectedAt      time.Time `json:"connected_at"`
+	LastMessageAt    time.Time `json:"last_message_at"`
+	MessagesReceived int64     `json:"messages_received"`
+	MessagesSent     int64     `json:"messages_sent"`
+	Conversations    []string  `json:"conversations"`
+	IngestionWatcher bool      `json:"ingestion_watcher"`
+	DashboardWatcher bool      `json:"dashboard_watcher"`
+}
+
+// snapshot lists the live connections, oldest first.
+func (h *wsHub) snapshot() []wsConnectionInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	conversations := make(map[*wsConn][]string)
+	for conversationID, room := range h.rooms {
+		for wc := range room {
+			conversations[wc] = append(conversations[wc], conversationID)
+		}
+	}
+
+	infos := make([]wsConnectionInfo, 0, len(h.conns))
+	for wc, user := range h.conns {
+		joined := conversations[wc]
+		if joined == nil {
+			joined = []string{}
+		}
+		sort.Strings(joined)
+		_, watching := h.watchers[wc]
+		_, dashboard := h.dashboards[wc]
+		infos = append(infos, wsConnectionInfo{
+			ID:               wc.id,
+			UserID:           user.ID,
+			Username:         user.Name,
+			Role:             user.Role,
+			Workspace:        user.Workspace,
+			IP:               user.IP,
+			ConnectedAt:      wc.connectedAt,
+			LastMessageAt:    time.Unix(0, wc.lastMessage.Load()).UTC(),
+			MessagesReceived: wc.received.Load(),
+			MessagesSent:     wc.sent.Load(),
+			Conversations:    joined,
+			IngestionWatcher: watching,
+			DashboardWatcher: dashboard,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ConnectedAt.Before(infos[j].ConnectedAt)
+	})
+	return infos
+}
+
+// wsHubStats are the hub figures exported on /metrics.
+type wsHubStats struct {
+	connections      int
+	conversations    int
+	watchers         int
+	dashboards       int
+	connectionsTotal int64
+	received         int64
+	sent             int64
+}
+
+func (h *wsHub) stats() wsHubStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	stats := wsHubStats{
+		connections:      len(h.conns),
+		conversations:    len(h.rooms),
+		watchers:         len(h.watchers),
+		dashboards:       len(h.dashboards),
+		connectionsTotal: h.connectionsTotal,
+		received:         h.closedReceived,
+		sent:             h.closedSent,
+	}
+	for wc := range h.conns {
+		stats.received += wc.received.Load()
+		stats.sent += wc.sent.Load()
+	}
+	return stats
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics serves the WebSocket figures of this instance in the Prometheus
+// text exposition format.
+func (h *WebSocketHandler) Metrics(c *gin.Context) {
+	stats := h.hub.stats()
+
+	var b strings.Builder
+	metric := func(name, kind, help string, value int64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+	}
+	metric("yuon_ws_connections", "gauge", "Open WebSocket connections.", int64(stats.connections))
+	metric("yuon_ws_conversations", "gauge", "Conversations with at least one connected participant.", int64(stats.conversations))
+	metric("yuon_ws_ingestion_watchers", "gauge", "Connections subscribed to ingestion progress.", int64(stats.watchers))
+	metric("yuon_ws_dashboard_watchers", "gauge", "Connections subscribed to live dashboard updates.", int64(stats.dashboards))
+	metric("yuon_ws_active_generations", "gauge", "Answers being generated.", int64(h.streams.count()))
+	metric("yuon_ws_connections_total", "counter", "WebSocket connections accepted.", stats.connectionsTotal)
+	metric("yuon_ws_messages_received_total", "counter", "Messages received from WebSocket clients.", stats.received)
+	metric("yuon_ws_messages_sent_total", "counter", "Events delivered to WebSocket clients.", stats.sent)
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
+
+// Connections lists the live WebSocket connections of this instance with the
+// user and conversations each one is attached to.
+func (h *WebSocketHandler) Connections(c *gin.Context) {
+	connections := h.hub.snapshot()
+	SuccessResponse(c, gin.H{"connections": connections, "count": len(connections)})
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"context"
+	"sync"
+)
+
+// wsStream is an answer being generated for a conversation. It belongs to the
+// handler rather than to the connection that asked for it, so a dropped
+// connection does not lose the exchange: the generation runs to completion
+// and a client reconnecting with start_conversation receives the chunks sent
+// so far and the rest as they are produced. Events go to every participant
+// of the conversation.
+type wsStream struct {
+	conversationID string
+	userID         string
+	// roles restricts retrieval to the documents the sender may see.
+	roles     []string
+	messageID string
+	message   string
+	hub       *wsHub
+	cancel    context.CancelFunc
+
+	mu     sync.Mutex
+	chunks []string
+	done   bool
+}
+
+// publish sends envelope to the participants of the conversation.
+func (s *wsStream) publish(envelope wsEnvelope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hub.broadcast(s.conversationID, envelope, nil)
+}
+
+// chunk records and forwards the next part of the answer.
+func (s *wsStream) chunk(payload streamChunkPayload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chunks = append(s.chunks, payload.Chunk)
+	s.hub.broadcast(s.conversationID, wsEnvelope{Type: "stream_chunk", Payload: mustMarshal(payload)}, nil)
+}
+
+// end runs persist and sends the final event while holding s.mu, so a
+// joining client sees the answer either in the stored history or through
+// the stream but never in both.
+func (s *wsStream) end(persist func(), envelope wsEnvelope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	persist()
+	s.done = true
+	s.hub.broadcast(s.conversationID, envelope, nil)
+}
+
+// wsStreams tracks the answers being generated, at most one per conversation.
+type wsStreams struct {
+	mu      sync.Mutex
+	streams map[string]*wsStream
+}
+
+func newWSStreams() *wsStreams {
+	return &wsStreams{streams: make(map[string]*wsStream)}
+}
+
+// start registers st and returns its context, or false when an answer is
+// already being generated for the conversation. The caller must call finish
+// when done.
+func (s *wsStreams) start(ctx context.Context, st *wsStream) (context.Context, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, busy := s.streams[st.conversationID]; busy {
+		return nil, false
+	}
+	ctx, st.cancel = context.WithCancel(ctx)
+	s.streams[st.conversationID] = st
+	return ctx, true
+}
+
+func (s *wsStreams) finish(conversationID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.streams[conversationID]; ok {
+		st.cancel()
+		delete(s.streams, conversationID)
+	}
+}
+
+// get returns the stream of conversationID, if any.
+// count returns how many answers are being generated.
+func (s *wsStreams) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams)
+}
+
+func (s *wsStreams) get(conversationID string) *wsStream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streams[conversationID]
+}
+
+// cancel stops the generation for conversationID if userID started it and
+// reports whether there was one.
+func (s *wsStreams) cancel(conversationID, userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.streams[conversationID]
+	if !ok || st.userID != userID {
+		return false
+	}
+	st.cancel()
+	return true
+}
+
+// cancelMessage stops the generation answering messageID if userID started
+// it and reports whether there was one.
+func (s *wsStreams) cancelMessage(messageID, userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, st := range s.streams {
+		if st.messageID == messageID && st.userID == userID {
+			st.cancel()
+			return true
+		}
+	}
+	return false
+}
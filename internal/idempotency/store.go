@@ -0,0 +1,154 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Record is a claimed Idempotency-Key. Until Completed is set the original
+// request is still running.
+type Record struct {
+	Principal    string
+	Key          string
+	Endpoint     string
+	RequestHash  string
+	StatusCode   int
+	ContentType  string
+	Body         []byte
+	ResponseHash string
+	Completed    bool
+	ExpiresAt    time.Time
+}
+
+// Store persists idempotency records.
+type Store interface {
+	// Reserve claims rec.Key for rec.Principal. It returns nil when the key
+	// was free (or had expired) and the existing record otherwise.
+	Reserve(ctx context.Context, rec *Record) (*Record, error)
+	// Complete stores the response of a reserved key for later replays.
+	Complete(ctx context.Context, principal, key string, status int, contentType string, body []byte) error
+	// Release forgets a reserved key so the request can be retried.
+	Release(ctx context.Context, principal, key string) error
+	// Prune deletes records that expired before now and returns how many.
+	Prune(ctx context.Context, now time.Time) (int64, error)
+}
+
+// RunPrune calls store.Prune every interval, starting right away, until ctx
+// is done.
+func RunPrune(ctx context.Context, store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := store.Prune(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "만료된 Idempotency-Key 정리 실패", "error", err)
+		} else if n > 0 {
+			slog.InfoContext(ctx, "만료된 Idempotency-Key 정리", "count", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Hash returns the hex SHA-256 used for request and response fingerprints.
+func Hash(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Reserve(ctx context.Context, rec *Record) (*Record, error) {
+	if _, err := s.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE expires_at <= NOW() AND principal = $1 AND key = $2`,
+		rec.Principal, rec.Key); err != nil {
+		return nil, fmt.Errorf("purge idempotency key failed: %w", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (principal, key, endpoint, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (principal, key) DO NOTHING`,
+		rec.Principal, rec.Key, rec.Endpoint, rec.RequestHash, rec.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("reserve idempotency key failed: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if rows == 1 {
+		return nil, nil
+	}
+
+	var (
+		existing    Record
+		status      sql.NullInt64
+		contentType sql.NullString
+		respHash    sql.NullString
+		completedAt sql.NullTime
+	)
+	err = s.db.QueryRowContext(ctx, `
+		SELECT principal, key, endpoint, request_hash, status_code, content_type, response_body, response_hash, completed_at, expires_at
+		FROM idempotency_keys
+		WHERE principal = $1 AND key = $2`,
+		rec.Principal, rec.Key,
+	).Scan(&existing.Principal, &existing.Key, &existing.Endpoint, &existing.RequestHash,
+		&status, &contentType, &existing.Body, &respHash, &completedAt, &existing.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released between the insert and the select; let the caller retry.
+		return &Record{Principal: rec.Principal, Key: rec.Key, Endpoint: rec.Endpoint, RequestHash: rec.RequestHash}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load idempotency key failed: %w", err)
+	}
+	existing.StatusCode = int(status.Int64)
+	existing.ContentType = contentType.String
+	existing.ResponseHash = respHash.String
+	existing.Completed = completedAt.Valid
+	return &existing, nil
+}
+
+func (s *PostgresStore) Complete(ctx context.Context, principal, key string, status int, contentType string, body []byte) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $3, content_type = $4, response_body = $5, response_hash = $6, completed_at = NOW()
+		WHERE principal = $1 AND key = $2`,
+		principal, key, status, contentType, body, Hash(body))
+	if err != nil {
+		return fmt.Errorf("complete idempotency key failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, principal, key string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE principal = $1 AND key = $2`, principal, key); err != nil {
+		return fmt.Errorf("release idempotency key failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Prune(ctx context.Context, now time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("prune idempotency keys failed: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,112 @@
+// Package openapi builds an OpenAPI 3.0 document at runtime. Schemas are
+// generated from the Go types handlers bind and return, so the published
+// spec follows the code instead of a hand-written file.
+package openapi
+
+// Document is the root OpenAPI object. Paths are keyed by path, then by
+// lower-case HTTP method.
+type Document struct {
+	OpenAPI    string                           `json:"openapi" yaml:"openapi"`
+	Info       Info                             `json:"info" yaml:"info"`
+	Servers    []Server                         `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Security   []SecurityRequirement            `json:"security,omitempty" yaml:"security,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths" yaml:"paths"`
+	Components Components                       `json:"components" yaml:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title" yaml:"title"`
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url" yaml:"url"`
+}
+
+// SecurityRequirement maps a security scheme name to its scopes. An empty
+// requirement marks an operation as callable without credentials.
+type SecurityRequirement map[string][]string
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type" yaml:"type"`
+	Scheme       string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty" yaml:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty" yaml:"in,omitempty"`
+	Name         string `json:"name,omitempty" yaml:"name,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses" yaml:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	// WebSocket documents the messages exchanged after an upgrade, which
+	// OpenAPI has no native way to describe.
+	WebSocket *WebSocketMessages `json:"x-websocket,omitempty" yaml:"x-websocket,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name" yaml:"name"`
+	In          string  `json:"in" yaml:"in"`
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      *Schema `json:"schema" yaml:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]MediaType `json:"content" yaml:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description" yaml:"description"`
+	Content     map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema" yaml:"schema"`
+}
+
+// WebSocketMessages lists message payload schemas by message type, split
+// by direction.
+type WebSocketMessages struct {
+	Envelope *Schema            `json:"envelope" yaml:"envelope"`
+	Client   map[string]*Schema `json:"client" yaml:"client"`
+	Server   map[string]*Schema `json:"server" yaml:"server"`
+}
+
+// Schema is the subset of the OpenAPI schema object the generator emits.
+// The zero value means "any value".
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty" yaml:"enum,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+}
+
+// Ref returns a schema pointing at a component.
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// JSONContent wraps a schema as an application/json media type map.
+func JSONContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
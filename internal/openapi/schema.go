@@ -0,0 +1,212 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// Generator turns Go types into schemas. Named struct types become shared
+// components referenced by $ref; everything else is inlined.
+type Generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schemas returns the components collected so far.
+func (g *Generator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+// Object describes an ad-hoc JSON object (a gin.H payload) by example:
+// each value stands for the type of its property.
+type Object map[string]any
+
+// Schema returns the schema for the dynamic type of v. A nil v yields the
+// "any value" schema.
+func (g *Generator) Schema(v any) *Schema {
+	switch v := v.(type) {
+	case nil:
+		return &Schema{}
+	case Object:
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema, len(v))}
+		for name, example := range v {
+			s.Properties[name] = g.Schema(example)
+		}
+		return s
+	case []Object:
+		item := Object{}
+		if len(v) > 0 {
+			item = v[0]
+		}
+		return &Schema{Type: "array", Items: g.Schema(item)}
+	}
+	return g.schemaOf(reflect.TypeOf(v))
+}
+
+// Component registers s under name, for schemas that have no Go type.
+func (g *Generator) Component(name string, s *Schema) *Schema {
+	g.schemas[name] = s
+	return Ref(name)
+}
+
+func (g *Generator) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.namedStruct(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *Generator) namedStruct(t reflect.Type) *Schema {
+	if name, ok := g.names[t]; ok {
+		return Ref(name)
+	}
+
+	name := componentName(t.Name())
+	if _, taken := g.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = componentName(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+	}
+	// Register before descending so recursive types terminate.
+	g.names[t] = name
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchema(t)
+	return Ref(name)
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := g.structSchema(ft)
+				for k, v := range embedded.Properties {
+					s.Properties[k] = v
+				}
+				s.Required = append(s.Required, embedded.Required...)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := g.schemaOf(f.Type)
+		if applyBinding(prop, f.Tag.Get("binding")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+	return s
+}
+
+// applyBinding maps gin binding rules onto prop and reports whether the
+// field is required.
+func applyBinding(prop *Schema, binding string) bool {
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "oneof":
+			if prop.Ref == "" {
+				prop.Enum = strings.Fields(value)
+			}
+		case "max":
+			if n, err := strconv.Atoi(value); err == nil && prop.Type == "string" {
+				prop.MaxLength = &n
+			}
+		case "email":
+			prop.Format = "email"
+		case "url":
+			prop.Format = "uri"
+		case "uuid":
+			prop.Format = "uuid"
+		}
+	}
+	return required
+}
+
+func componentName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
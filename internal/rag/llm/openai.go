@@ -2,8 +2,12 @@ package llm
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"yuon/configuration"
 	"yuon/internal/rag"
@@ -14,18 +18,61 @@ import (
 type OpenAIClient struct {
 	client *openai.Client
 	config *configuration.OpenAIConfig
+	calls  *countingTransport
 }
 
 func NewOpenAIClient(cfg *configuration.OpenAIConfig) *OpenAIClient {
+	calls := &countingTransport{next: http.DefaultTransport}
+	clientConfig := openai.DefaultConfig(cfg.APIKey)
+	clientConfig.HTTPClient = &http.Client{Transport: calls}
 	return &OpenAIClient{
-		client: openai.NewClient(cfg.APIKey),
+		client: openai.NewClientWithConfig(clientConfig),
 		config: cfg,
+		calls:  calls,
 	}
 }
 
+// RequestCounts returns how many OpenAI API requests were sent since start
+// and how many of them failed: no response, a rate limit or a server error.
+func (c *OpenAIClient) RequestCounts() (requests, failures int64) {
+	return c.calls.requests.Load(), c.calls.failures.Load()
+}
+
+// countingTransport counts the requests it carries and their failures.
+type countingTransport struct {
+	next     http.RoundTripper
+	requests atomic.Int64
+	failures atomic.Int64
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	resp, err := t.next.RoundTrip(req)
+	switch {
+	case err != nil:
+		// A request the caller gave up on is not the API failing.
+		if req.Context().Err() == nil {
+			t.failures.Add(1)
+		}
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		t.failures.Add(1)
+	}
+	return resp, err
+}
+
 func (c *OpenAIClient) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return c.GenerateEmbeddingWithModel(ctx, text, "")
+}
+
+// GenerateEmbeddingWithModel embeds text with model, or the configured
+// embedding model when model is empty.
+func (c *OpenAIClient) GenerateEmbeddingWithModel(ctx context.Context, text, model string) ([]float32, error) {
+	if model == "" {
+		model = c.config.EmbeddingModel
+	}
+
 	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
-		Model: openai.EmbeddingModel(c.config.EmbeddingModel),
+		Model: openai.EmbeddingModel(model),
 		Input: []string{text},
 	})
 	if err != nil {
@@ -39,7 +86,40 @@ func (c *OpenAIClient) GenerateEmbedding(ctx context.Context, text string) ([]fl
 	return resp.Data[0].Embedding, nil
 }
 
-func (c *OpenAIClient) Chat(ctx context.Context, messages []rag.ChatMessage, documents []rag.Document) (string, int, error) {
+// GenerateEmbeddings embeds texts in one request with the configured
+// embedding model, in the order given.
+func (c *OpenAIClient) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Model: openai.EmbeddingModel(c.config.EmbeddingModel),
+		Input: texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("임베딩 생성 실패: %w", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index >= 0 && d.Index < len(vectors) {
+			vectors[d.Index] = d.Embedding
+		}
+	}
+	for _, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("임베딩 결과가 비어있습니다")
+		}
+	}
+	return vectors, nil
+}
+
+// Usage is the model and tokens a chat completion used.
+type Usage struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+func (c *OpenAIClient) Chat(ctx context.Context, messages []rag.ChatMessage, documents []rag.Document) (string, Usage, error) {
 	systemPrompt := c.buildSystemPrompt(documents)
 
 	openaiMessages := []openai.ChatCompletionMessage{
@@ -63,14 +143,20 @@ func (c *OpenAIClient) Chat(ctx context.Context, messages []rag.ChatMessage, doc
 		Temperature: c.config.Temperature,
 	})
 	if err != nil {
-		return "", 0, fmt.Errorf("채팅 생성 실패: %w", err)
+		return "", Usage{}, fmt.Errorf("채팅 생성 실패: %w", err)
 	}
 
 	if len(resp.Choices) == 0 {
-		return "", 0, fmt.Errorf("응답이 비어있습니다")
+		return "", Usage{}, fmt.Errorf("응답이 비어있습니다")
 	}
 
-	return resp.Choices[0].Message.Content, resp.Usage.TotalTokens, nil
+	usage := Usage{
+		Model:            c.config.Model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+	}
+	return resp.Choices[0].Message.Content, usage, nil
 }
 
 func (c *OpenAIClient) GenerateText(ctx context.Context, systemPrompt, userPrompt string, maxTokens int) (string, error) {
@@ -109,6 +195,7 @@ func (c *OpenAIClient) buildSystemPrompt(documents []rag.Document) string {
 				1. 제공된 문서의 내용을 바탕으로 답변하세요
 				2. 답변할 수 없다면 솔직하게 "제공된 정보로는 답변하기 어렵습니다"라고 말하세요
 				3. 가능한 한 구체적이고 명확하게 답변하세요
+				4. 문서의 내용을 사용한 문장 끝에는 [문서 1]처럼 참고한 문서 번호를 표시하세요
 
 				참고 문서:
 `
@@ -222,3 +309,170 @@ func (c *OpenAIClient) ExtractKeywords(ctx context.Context, text string, maxKeyw
 	}
 	return keywords, nil
 }
+
+// ImageDescription is the vision model's caption and OCR output for an image.
+type ImageDescription struct {
+	Description string `json:"description"`
+	Text        string `json:"text"`
+}
+
+// DescribeImage asks a vision-capable model for a detailed description of the
+// image and any text visible in it.
+func (c *OpenAIClient) DescribeImage(ctx context.Context, data []byte, contentType string) (*ImageDescription, error) {
+	systemPrompt := `당신은 학교 자료 이미지를 검색 가능한 텍스트로 변환하는 어시스턴트입니다.
+- description: 이미지의 종류(포스터, 도표, 사진 등), 주제, 구성 요소, 핵심 정보를 한국어로 자세히 설명하세요.
+- text: 이미지에 보이는 모든 글자를 원문 그대로 줄 단위로 옮겨 적으세요. 글자가 없으면 빈 문자열로 두세요.
+- 반드시 {"description": "...", "text": "..."} 형식의 JSON으로만 답하세요.`
+
+	dataURL := fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(data))
+
+	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: c.config.VisionModel,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{
+				Role: openai.ChatMessageRoleUser,
+				MultiContent: []openai.ChatMessagePart{
+					{
+						Type: openai.ChatMessagePartTypeImageURL,
+						ImageURL: &openai.ChatMessageImageURL{
+							URL:    dataURL,
+							Detail: openai.ImageURLDetailHigh,
+						},
+					},
+				},
+			},
+		},
+		MaxTokens:   1500,
+		Temperature: 0,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("이미지 설명 생성 실패: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("이미지 설명 응답이 비어있습니다")
+	}
+
+	var result ImageDescription
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &result); err != nil {
+		return nil, fmt.Errorf("이미지 설명 응답 파싱 실패: %w", err)
+	}
+
+	result.Description = strings.TrimSpace(result.Description)
+	result.Text = strings.TrimSpace(result.Text)
+	return &result, nil
+}
+
+// ConversationDigest is the model's summary of a conversation and the action
+// items it found in it.
+type ConversationDigest struct {
+	Summary     string   `json:"summary"`
+	ActionItems []string `json:"action_items"`
+}
+
+// SummarizeConversation summarizes a transcript of "role: content" lines.
+func (c *OpenAIClient) SummarizeConversation(ctx context.Context, transcript string) (*ConversationDigest, error) {
+	systemPrompt := `당신은 학교 챗봇 상담 대화를 정리하는 어시스턴트입니다.
+- summary: 사용자가 무엇을 물었고 어떤 답을 받았는지 한국어 2~3문장으로 요약하세요.
+- action_items: 사용자나 담당자가 이후에 해야 할 일을 짧은 문장 목록으로 뽑으세요. 없으면 빈 배열로 두세요.
+- 반드시 {"summary": "...", "action_items": ["..."]} 형식의 JSON으로만 답하세요.`
+
+	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: c.config.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: transcript},
+		},
+		MaxTokens:   600,
+		Temperature: 0.2,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("대화 요약 생성 실패: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("대화 요약 응답이 비어있습니다")
+	}
+
+	var result ConversationDigest
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &result); err != nil {
+		return nil, fmt.Errorf("대화 요약 응답 파싱 실패: %w", err)
+	}
+
+	result.Summary = strings.TrimSpace(result.Summary)
+	items := make([]string, 0, len(result.ActionItems))
+	for _, item := range result.ActionItems {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	result.ActionItems = items
+	return &result, nil
+}
+
+// TopicSuggestion is a document the model suggests writing for a group of
+// questions the knowledge base could not answer.
+type TopicSuggestion struct {
+	Topic  string `json:"topic"`
+	Reason string `json:"reason"`
+}
+
+// SuggestDocumentTopics suggests one document topic for each group of
+// example questions, in the order given.
+func (c *OpenAIClient) SuggestDocumentTopics(ctx context.Context, groups [][]string) ([]TopicSuggestion, error) {
+	systemPrompt := `당신은 학교 챗봇의 지식 베이스를 관리하는 분석가입니다.
+질문 묶음마다 챗봇이 자료가 없어 제대로 답하지 못한 질문 예시가 주어집니다.
+- topic: 이 질문들에 답하려면 새로 올려야 할 문서의 주제를 한국어 한 구절로 쓰세요.
+- reason: 그 문서가 필요한 이유를 한국어 한 문장으로 쓰세요.
+- 반드시 {"topics": [{"topic": "...", "reason": "..."}]} 형식의 JSON으로만, 묶음 순서대로 묶음 수만큼 답하세요.`
+
+	var prompt strings.Builder
+	for i, questions := range groups {
+		fmt.Fprintf(&prompt, "묶음 %d:\n", i+1)
+		for _, q := range questions {
+			fmt.Fprintf(&prompt, "- %s\n", q)
+		}
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: c.config.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: prompt.String()},
+		},
+		MaxTokens:   100 * len(groups),
+		Temperature: 0.2,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("문서 주제 제안 생성 실패: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("문서 주제 제안 응답이 비어있습니다")
+	}
+
+	var result struct {
+		Topics []TopicSuggestion `json:"topics"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &result); err != nil {
+		return nil, fmt.Errorf("문서 주제 제안 응답 파싱 실패: %w", err)
+	}
+	if len(result.Topics) != len(groups) {
+		return nil, fmt.Errorf("문서 주제 제안 수가 맞지 않습니다: %d개 중 %d개", len(groups), len(result.Topics))
+	}
+	for i := range result.Topics {
+		result.Topics[i].Topic = strings.TrimSpace(result.Topics[i].Topic)
+		result.Topics[i].Reason = strings.TrimSpace(result.Topics[i].Reason)
+	}
+	return result.Topics, nil
+}
@@ -0,0 +1,34 @@
+package rag
+
+import "context"
+
+// Ingestion stages reported through a ProgressFunc, in order. StageFailed
+// replaces StageDone when ingestion stops with an error.
+const (
+	StageExtracting = "extracting"
+	StageEmbedding  = "embedding"
+	StageIndexing   = "indexing"
+	StageDone       = "done"
+	StageFailed     = "failed"
+)
+
+// ProgressFunc receives the stage an ingestion reached. For batches, done of
+// total documents have passed the stage so far; single documents report 0 of 1.
+type ProgressFunc func(stage string, done, total int)
+
+type progressKey struct{}
+
+// WithProgress attaches fn to ctx so ingestion can report its progress.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress calls the ProgressFunc set by WithProgress, if any.
+func ReportProgress(ctx context.Context, stage string, done, total int) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		fn(stage, done, total)
+	}
+}
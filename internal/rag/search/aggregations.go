@@ -0,0 +1,111 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"yuon/internal/rag"
+)
+
+// Aggregations returns knowledge-base composition counts in a single query.
+func (o *OpenSearchClient) Aggregations(ctx context.Context, size int) (*rag.DocumentAggregations, error) {
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if size <= 0 {
+		size = 20
+	}
+
+	query := map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+		"aggs": map[string]interface{}{
+			"categories": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "metadata.category.keyword",
+					"size":  size,
+				},
+			},
+			"tags": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "metadata.tags.keyword",
+					"size":  size,
+				},
+			},
+			"uploads_by_month": map[string]interface{}{
+				"date_histogram": map[string]interface{}{
+					"field":             "metadata.uploadedAt",
+					"calendar_interval": "month",
+					"format":            "yyyy-MM",
+					"min_doc_count":     1,
+				},
+			},
+		},
+	}
+
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("집계 쿼리 직렬화 실패: %w", err)
+	}
+
+	req := opensearchapi.SearchRequest{
+		Index: []string{index},
+		Body:  bytes.NewReader(body),
+	}
+
+	res, err := req.Do(ctx, o.transport)
+	if err != nil {
+		return nil, fmt.Errorf("집계 조회 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("집계 조회 오류: %s", res.String())
+	}
+
+	type bucket struct {
+		Key         interface{} `json:"key"`
+		KeyAsString string      `json:"key_as_string"`
+		DocCount    int64       `json:"doc_count"`
+	}
+	type bucketAgg struct {
+		Buckets []bucket `json:"buckets"`
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+		} `json:"hits"`
+		Aggregations map[string]bucketAgg `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("집계 응답 파싱 실패: %w", err)
+	}
+
+	convert := func(name string) []rag.AggregationBucket {
+		agg := result.Aggregations[name]
+		buckets := make([]rag.AggregationBucket, 0, len(agg.Buckets))
+		for _, b := range agg.Buckets {
+			key := b.KeyAsString
+			if key == "" {
+				key = fmt.Sprintf("%v", b.Key)
+			}
+			buckets = append(buckets, rag.AggregationBucket{Key: key, Count: b.DocCount})
+		}
+		return buckets
+	}
+
+	return &rag.DocumentAggregations{
+		TotalDocuments: result.Hits.Total.Value,
+		Categories:     convert("categories"),
+		Tags:           convert("tags"),
+		UploadsByMonth: convert("uploads_by_month"),
+	}, nil
+}
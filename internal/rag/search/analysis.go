@@ -0,0 +1,346 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"yuon/internal/rag"
+)
+
+const (
+	analyzerNori     = "nori"
+	analyzerStandard = "standard"
+
+	koreanAnalyzerName  = "korean"
+	koreanTokenizerName = "korean_tokenizer"
+)
+
+type analysisConfig struct {
+	Analyzer       string
+	UserDictionary string
+	UserWords      []string
+}
+
+// contentAnalyzer returns the analyzer name used by the content field.
+func (a analysisConfig) contentAnalyzer() string {
+	if a.Analyzer == analyzerStandard {
+		return analyzerStandard
+	}
+	return koreanAnalyzerName
+}
+
+// settings builds the index analysis settings. The standard analyzer needs none.
+func (a analysisConfig) settings() map[string]interface{} {
+	if a.Analyzer == analyzerStandard {
+		return nil
+	}
+
+	tokenizer := map[string]interface{}{
+		"type":            "nori_tokenizer",
+		"decompound_mode": "mixed",
+	}
+	if a.UserDictionary != "" {
+		tokenizer["user_dictionary"] = a.UserDictionary
+	}
+	if len(a.UserWords) > 0 {
+		tokenizer["user_dictionary_rules"] = a.UserWords
+	}
+
+	return map[string]interface{}{
+		"analysis": map[string]interface{}{
+			"tokenizer": map[string]interface{}{
+				koreanTokenizerName: tokenizer,
+			},
+			"analyzer": map[string]interface{}{
+				koreanAnalyzerName: map[string]interface{}{
+					"type":      "custom",
+					"tokenizer": koreanTokenizerName,
+					"filter":    []string{"nori_part_of_speech", "nori_readingform", "lowercase"},
+				},
+			},
+		},
+	}
+}
+
+func (o *OpenSearchClient) indexBody() map[string]interface{} {
+	properties := map[string]interface{}{
+		"content": map[string]interface{}{
+			"type":     "text",
+			"analyzer": o.analysis.contentAnalyzer(),
+		},
+		"metadata": map[string]interface{}{
+			"type": "object",
+		},
+	}
+	for field, mapping := range o.additiveProperties() {
+		properties[field] = mapping
+	}
+
+	body := map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": properties,
+		},
+	}
+
+	settings := o.analysis.settings()
+	if similarity := o.relevance.similaritySettings(); similarity != nil {
+		if settings == nil {
+			settings = map[string]interface{}{}
+		}
+		settings["similarity"] = similarity
+	}
+	if settings != nil {
+		body["settings"] = settings
+	}
+
+	return body
+}
+
+// additiveProperties are fields that can be added to an existing index
+// without reindexing.
+func (o *OpenSearchClient) additiveProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"suggest": map[string]interface{}{
+			"type":     "search_as_you_type",
+			"analyzer": o.analysis.contentAnalyzer(),
+		},
+		"createdAt": map[string]interface{}{"type": "date"},
+		"updatedAt": map[string]interface{}{"type": "date"},
+		"filename":  map[string]interface{}{"type": "keyword"},
+		"size":      map[string]interface{}{"type": "long"},
+	}
+}
+
+// ensureMappings adds any missing additive fields to an existing index.
+func (o *OpenSearchClient) ensureMappings(ctx context.Context) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"properties": o.additiveProperties(),
+	})
+
+	req := opensearchapi.IndicesPutMappingRequest{
+		Index: []string{o.index},
+		Body:  bytes.NewReader(body),
+	}
+
+	res, err := req.Do(ctx, o.transport)
+	if err != nil {
+		slog.Warn("인덱스 매핑 갱신 실패", "index", o.index, "error", err)
+		return
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		slog.Warn("인덱스 매핑 갱신 오류. POST /api/v1/documents/index/migrate 로 재색인하세요", "index", o.index, "response", res.String())
+	}
+}
+
+// currentContentAnalyzer reads the analyzer configured on the live index.
+func (o *OpenSearchClient) currentContentAnalyzer(ctx context.Context) (string, error) {
+	req := opensearchapi.IndicesGetMappingRequest{
+		Index: []string{o.index},
+	}
+
+	res, err := req.Do(ctx, o.transport)
+	if err != nil {
+		return "", fmt.Errorf("매핑 조회 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", fmt.Errorf("매핑 조회 오류: %s", res.String())
+	}
+
+	var result map[string]struct {
+		Mappings struct {
+			Properties map[string]struct {
+				Analyzer string `json:"analyzer"`
+			} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("매핑 응답 파싱 실패: %w", err)
+	}
+
+	for _, idx := range result {
+		analyzer := idx.Mappings.Properties["content"].Analyzer
+		if analyzer == "" {
+			analyzer = analyzerStandard
+		}
+		return analyzer, nil
+	}
+	return "", fmt.Errorf("인덱스 매핑을 찾을 수 없습니다")
+}
+
+func (o *OpenSearchClient) checkAnalyzer(ctx context.Context) {
+	current, err := o.currentContentAnalyzer(ctx)
+	if err != nil {
+		slog.Warn("인덱스 분석기 확인 실패", "index", o.index, "error", err)
+		return
+	}
+
+	if want := o.analysis.contentAnalyzer(); current != want {
+		slog.Warn("인덱스 분석기가 설정과 다릅니다. POST /api/v1/documents/index/migrate 로 재색인하세요",
+			"index", o.index,
+			"current", current,
+			"configured", want,
+		)
+	}
+}
+
+// resolveConcreteIndices returns the physical indices behind name and
+// whether name is an alias.
+func (o *OpenSearchClient) resolveConcreteIndices(ctx context.Context, name string) ([]string, bool, error) {
+	req := opensearchapi.IndicesGetAliasRequest{
+		Name: []string{name},
+	}
+
+	res, err := req.Do(ctx, o.transport)
+	if err != nil {
+		return nil, false, fmt.Errorf("별칭 조회 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return []string{name}, false, nil
+	}
+	if res.IsError() {
+		return nil, false, fmt.Errorf("별칭 조회 오류: %s", res.String())
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, false, fmt.Errorf("별칭 응답 파싱 실패: %w", err)
+	}
+
+	indices := make([]string, 0, len(result))
+	for name := range result {
+		indices = append(indices, name)
+	}
+	if len(indices) == 0 {
+		return []string{name}, false, nil
+	}
+	return indices, true, nil
+}
+
+// MigrateIndex rebuilds o.index and then every workspace index with the
+// configured analyzer. A failure stops the run; indexes migrated before it
+// keep their new index.
+func (o *OpenSearchClient) MigrateIndex(ctx context.Context) (*rag.IndexMigrationResult, error) {
+	workspaces, err := o.workspaceIndexNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := o.migrateIndex(ctx, o.index)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range workspaces {
+		migrated, err := o.migrateIndex(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("워크스페이스 인덱스 %s: %w", name, err)
+		}
+		o.workspaceIndices.Store(name, struct{}{})
+		result.Workspaces = append(result.Workspaces, *migrated)
+	}
+	return result, nil
+}
+
+// migrateIndex copies every document behind alias into a new index built
+// with the configured analyzer, then atomically points alias at it.
+func (o *OpenSearchClient) migrateIndex(ctx context.Context, alias string) (*rag.IndexMigrationResult, error) {
+	sources, isAlias, err := o.resolveConcreteIndices(ctx, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	target := fmt.Sprintf("%s_%s", alias, time.Now().UTC().Format("20060102150405"))
+	if err := o.createIndex(ctx, target); err != nil {
+		return nil, err
+	}
+
+	reindexBody, _ := json.Marshal(map[string]interface{}{
+		"source": map[string]interface{}{"index": sources},
+		"dest":   map[string]interface{}{"index": target},
+		"script": map[string]interface{}{
+			"lang":   "painless",
+			"source": suggestReindexScript,
+		},
+	})
+
+	refresh := true
+	wait := true
+	reindex := opensearchapi.ReindexRequest{
+		Body:              bytes.NewReader(reindexBody),
+		Refresh:           &refresh,
+		WaitForCompletion: &wait,
+	}
+
+	res, err := reindex.Do(ctx, o.transport)
+	if err != nil {
+		return nil, fmt.Errorf("재색인 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("재색인 오류: %s", res.String())
+	}
+
+	var reindexResult struct {
+		Total    int64         `json:"total"`
+		Failures []interface{} `json:"failures"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&reindexResult); err != nil {
+		return nil, fmt.Errorf("재색인 응답 파싱 실패: %w", err)
+	}
+	if len(reindexResult.Failures) > 0 {
+		return nil, fmt.Errorf("재색인 중 %d건 실패", len(reindexResult.Failures))
+	}
+
+	var actions []interface{}
+	for _, source := range sources {
+		if isAlias {
+			actions = append(actions, map[string]interface{}{
+				"remove": map[string]interface{}{"index": source, "alias": alias},
+			})
+		} else {
+			actions = append(actions, map[string]interface{}{
+				"remove_index": map[string]interface{}{"index": source},
+			})
+		}
+	}
+	actions = append(actions, map[string]interface{}{
+		"add": map[string]interface{}{"index": target, "alias": alias},
+	})
+
+	aliasBody, _ := json.Marshal(map[string]interface{}{"actions": actions})
+	update := opensearchapi.IndicesUpdateAliasesRequest{
+		Body: bytes.NewReader(aliasBody),
+	}
+
+	aliasRes, err := update.Do(ctx, o.transport)
+	if err != nil {
+		return nil, fmt.Errorf("별칭 전환 실패: %w", err)
+	}
+	defer aliasRes.Body.Close()
+
+	if aliasRes.IsError() {
+		return nil, fmt.Errorf("별칭 전환 오류: %s", aliasRes.String())
+	}
+
+	slog.Info("인덱스 마이그레이션 완료", "alias", alias, "from", sources, "to", target, "documents", reindexResult.Total)
+
+	return &rag.IndexMigrationResult{
+		Alias:           alias,
+		PreviousIndices: sources,
+		NewIndex:        target,
+		Analyzer:        o.analysis.contentAnalyzer(),
+		Documents:       reindexResult.Total,
+	}, nil
+}
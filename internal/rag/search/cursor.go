@@ -0,0 +1,28 @@
+package search
+
+import "yuon/package/pagination"
+
+var ErrInvalidCursor = pagination.ErrInvalidCursor
+
+// encodeCursor packs the sort values of the last hit into an opaque token.
+func encodeCursor(sortValues []interface{}) string {
+	return pagination.EncodeCursor(sortValues...)
+}
+
+func decodeCursor(cursor string) ([]interface{}, error) {
+	return pagination.DecodeCursor(cursor)
+}
+
+// lastSortValues returns the sort array of the final hit in a search response.
+func lastSortValues(hits map[string]interface{}) []interface{} {
+	items, ok := hits["hits"].([]interface{})
+	if !ok || len(items) == 0 {
+		return nil
+	}
+	last, ok := items[len(items)-1].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	values, _ := last["sort"].([]interface{})
+	return values
+}
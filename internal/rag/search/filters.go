@@ -0,0 +1,71 @@
+package search
+
+import (
+	"time"
+
+	"yuon/internal/rag"
+)
+
+// filterClauses translates search filters into non-scoring bool filter clauses.
+func filterClauses(filters *rag.SearchFilters) []interface{} {
+	if filters.IsEmpty() {
+		return nil
+	}
+
+	var clauses []interface{}
+
+	if filters.Category != "" {
+		clauses = append(clauses, map[string]interface{}{
+			"match": map[string]interface{}{
+				"metadata.category": filters.Category,
+			},
+		})
+	}
+
+	if len(filters.Tags) > 0 {
+		clauses = append(clauses, map[string]interface{}{
+			"terms": map[string]interface{}{
+				"metadata.tags.keyword": filters.Tags,
+			},
+		})
+	}
+
+	if len(filters.Roles) > 0 {
+		clauses = append(clauses, map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []interface{}{
+					map[string]interface{}{
+						"terms": map[string]interface{}{
+							"metadata.allowedRoles.keyword": filters.Roles,
+						},
+					},
+					map[string]interface{}{
+						"bool": map[string]interface{}{
+							"must_not": map[string]interface{}{
+								"exists": map[string]interface{}{"field": "metadata.allowedRoles"},
+							},
+						},
+					},
+				},
+				"minimum_should_match": 1,
+			},
+		})
+	}
+
+	if filters.HasDateRange() {
+		bounds := map[string]interface{}{}
+		if filters.UploadedAfter != nil {
+			bounds["gte"] = filters.UploadedAfter.UTC().Format(time.RFC3339)
+		}
+		if filters.UploadedBefore != nil {
+			bounds["lte"] = filters.UploadedBefore.UTC().Format(time.RFC3339)
+		}
+		clauses = append(clauses, map[string]interface{}{
+			"range": map[string]interface{}{
+				"createdAt": bounds,
+			},
+		})
+	}
+
+	return clauses
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/opensearch-project/opensearch-go/v2"
@@ -18,8 +19,14 @@ import (
 )
 
 type OpenSearchClient struct {
-	client *opensearch.Client
-	index  string
+	client    *opensearch.Client
+	transport opensearchapi.Transport
+	index     string
+	analysis  analysisConfig
+	relevance relevanceConfig
+
+	// workspaceIndices caches workspace indices known to exist.
+	workspaceIndices sync.Map
 }
 
 var ErrDocumentNotFound = errors.New("document not found")
@@ -32,6 +39,8 @@ func NewOpenSearchClient(cfg *configuration.OpenSearchConfig) (*OpenSearchClient
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 		},
+		// retryTransport handles retries with backoff and context cancellation.
+		DisableRetry: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("OpenSearch 클라이언트 생성 실패: %w", err)
@@ -39,7 +48,23 @@ func NewOpenSearchClient(cfg *configuration.OpenSearchConfig) (*OpenSearchClient
 
 	osc := &OpenSearchClient{
 		client: client,
-		index:  cfg.Index,
+		transport: &retryTransport{
+			next:       client,
+			maxRetries: cfg.MaxRetries,
+			baseDelay:  cfg.RetryBackoff,
+		},
+		index: cfg.Index,
+		analysis: analysisConfig{
+			Analyzer:       cfg.Analyzer,
+			UserDictionary: cfg.NoriUserDictionary,
+			UserWords:      cfg.NoriUserWords,
+		},
+		relevance: relevanceConfig{
+			K1:                 cfg.BM25K1,
+			B:                  cfg.BM25B,
+			Fields:             cfg.SearchFields,
+			MinimumShouldMatch: cfg.MinimumShouldMatch,
+		},
 	}
 
 	if err := osc.ensureIndex(); err != nil {
@@ -56,37 +81,30 @@ func (o *OpenSearchClient) ensureIndex() error {
 		Index: []string{o.index},
 	}
 
-	res, err := exists.Do(ctx, o.client)
+	res, err := exists.Do(ctx, o.transport)
 	if err != nil {
 		return fmt.Errorf("인덱스 확인 실패: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode == 200 {
+		o.checkAnalyzer(ctx)
+		o.ensureMappings(ctx)
 		return nil
 	}
 
-	mapping := map[string]interface{}{
-		"mappings": map[string]interface{}{
-			"properties": map[string]interface{}{
-				"content": map[string]interface{}{
-					"type":     "text",
-					"analyzer": "standard",
-				},
-				"metadata": map[string]interface{}{
-					"type": "object",
-				},
-			},
-		},
-	}
+	return o.createIndex(ctx, o.index)
+}
 
+func (o *OpenSearchClient) createIndex(ctx context.Context, name string) error {
+	mapping := o.indexBody()
 	body, _ := json.Marshal(mapping)
 	create := opensearchapi.IndicesCreateRequest{
-		Index: o.index,
+		Index: name,
 		Body:  bytes.NewReader(body),
 	}
 
-	res, err = create.Do(ctx, o.client)
+	res, err := create.Do(ctx, o.transport)
 	if err != nil {
 		return fmt.Errorf("인덱스 생성 실패: %w", err)
 	}
@@ -100,24 +118,26 @@ func (o *OpenSearchClient) ensureIndex() error {
 }
 
 func (o *OpenSearchClient) AddDocument(ctx context.Context, doc rag.Document) error {
-	body := map[string]interface{}{
-		"content":  doc.Content,
-		"metadata": doc.Metadata,
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return err
 	}
 
+	body := documentSource(doc)
+
 	data, err := json.Marshal(body)
 	if err != nil {
 		return fmt.Errorf("문서 직렬화 실패: %w", err)
 	}
 
 	req := opensearchapi.IndexRequest{
-		Index:      o.index,
+		Index:      index,
 		DocumentID: doc.ID,
 		Body:       bytes.NewReader(data),
 		Refresh:    "true",
 	}
 
-	res, err := req.Do(ctx, o.client)
+	res, err := req.Do(ctx, o.transport)
 	if err != nil {
 		return fmt.Errorf("문서 추가 실패: %w", err)
 	}
@@ -130,14 +150,25 @@ func (o *OpenSearchClient) AddDocument(ctx context.Context, doc rag.Document) er
 	return nil
 }
 
-func (o *OpenSearchClient) Search(ctx context.Context, query string, limit int) ([]rag.Document, error) {
-	searchQuery := map[string]interface{}{
-		"query": map[string]interface{}{
-			"match": map[string]interface{}{
-				"content": query,
+func (o *OpenSearchClient) Search(ctx context.Context, query string, limit int, filters *rag.SearchFilters) ([]rag.Document, error) {
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	queryClause := o.relevance.textQuery(query)
+	if clauses := filterClauses(filters); len(clauses) > 0 {
+		queryClause = map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must":   []interface{}{queryClause},
+				"filter": clauses,
 			},
-		},
-		"size": limit,
+		}
+	}
+
+	searchQuery := map[string]interface{}{
+		"query": queryClause,
+		"size":  limit,
 	}
 
 	body, err := json.Marshal(searchQuery)
@@ -146,11 +177,11 @@ func (o *OpenSearchClient) Search(ctx context.Context, query string, limit int)
 	}
 
 	req := opensearchapi.SearchRequest{
-		Index: []string{o.index},
+		Index: []string{index},
 		Body:  bytes.NewReader(body),
 	}
 
-	res, err := req.Do(ctx, o.client)
+	res, err := req.Do(ctx, o.transport)
 	if err != nil {
 		return nil, fmt.Errorf("검색 실패: %w", err)
 	}
@@ -173,9 +204,11 @@ func (o *OpenSearchClient) Search(ctx context.Context, query string, limit int)
 		source := h["_source"].(map[string]interface{})
 
 		doc := rag.Document{
-			ID:      h["_id"].(string),
-			Content: source["content"].(string),
-			Score:   h["_score"].(float64),
+			ID:        h["_id"].(string),
+			Content:   source["content"].(string),
+			Score:     h["_score"].(float64),
+			CreatedAt: getStringValue(source["createdAt"]),
+			UpdatedAt: getStringValue(source["updatedAt"]),
 		}
 
 		if meta, ok := source["metadata"].(map[string]interface{}); ok {
@@ -189,12 +222,17 @@ func (o *OpenSearchClient) Search(ctx context.Context, query string, limit int)
 }
 
 func (o *OpenSearchClient) BulkIndex(ctx context.Context, documents []rag.Document) error {
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return err
+	}
+
 	var buf bytes.Buffer
 
 	for _, doc := range documents {
 		meta := map[string]interface{}{
 			"index": map[string]interface{}{
-				"_index": o.index,
+				"_index": index,
 				"_id":    doc.ID,
 			},
 		}
@@ -202,11 +240,7 @@ func (o *OpenSearchClient) BulkIndex(ctx context.Context, documents []rag.Docume
 		buf.Write(metaJSON)
 		buf.WriteByte('\n')
 
-		body := map[string]interface{}{
-			"content":  doc.Content,
-			"metadata": doc.Metadata,
-		}
-		bodyJSON, _ := json.Marshal(body)
+		bodyJSON, _ := json.Marshal(documentSource(doc))
 		buf.Write(bodyJSON)
 		buf.WriteByte('\n')
 	}
@@ -216,7 +250,7 @@ func (o *OpenSearchClient) BulkIndex(ctx context.Context, documents []rag.Docume
 		Refresh: "true",
 	}
 
-	res, err := req.Do(ctx, o.client)
+	res, err := req.Do(ctx, o.transport)
 	if err != nil {
 		return fmt.Errorf("벌크 인덱싱 실패: %w", err)
 	}
@@ -230,6 +264,11 @@ func (o *OpenSearchClient) BulkIndex(ctx context.Context, documents []rag.Docume
 }
 
 func (o *OpenSearchClient) ListDocuments(ctx context.Context, params *rag.DocumentListParams) (*rag.DocumentListResult, error) {
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	page := 1
 	pageSize := 20
 	if params != nil {
@@ -246,42 +285,44 @@ func (o *OpenSearchClient) ListDocuments(ctx context.Context, params *rag.Docume
 	from := (page - 1) * pageSize
 
 	query := map[string]interface{}{
-		"from": from,
 		"size": pageSize,
-		"sort": []interface{}{
-			map[string]interface{}{
-				"_score": map[string]interface{}{
-					"order": "desc",
-				},
-			},
-		},
+		"sort": sortClause(params),
 		"query": map[string]interface{}{
 			"match_all": map[string]interface{}{},
 		},
 	}
 
+	// A cursor switches to search_after, which is not bounded by max_result_window.
+	useCursor := params != nil && params.Cursor != ""
+	if useCursor {
+		searchAfter, err := decodeCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query["search_after"] = searchAfter
+		query["track_total_hits"] = true
+	} else {
+		query["from"] = from
+	}
+
 	if params != nil {
 		var must []map[string]interface{}
 		if params.Query != "" {
-			must = append(must, map[string]interface{}{
-				"match": map[string]interface{}{
-					"content": params.Query,
-				},
-			})
-		}
-		if params.Category != "" {
-			must = append(must, map[string]interface{}{
-				"match": map[string]interface{}{
-					"metadata.category": params.Category,
-				},
-			})
+			must = append(must, o.relevance.textQuery(params.Query))
 		}
 
-		if len(must) > 0 {
+		filter := filterClauses(&params.SearchFilters)
+
+		if len(must) > 0 || len(filter) > 0 {
+			boolQuery := map[string]interface{}{}
+			if len(must) > 0 {
+				boolQuery["must"] = must
+			}
+			if len(filter) > 0 {
+				boolQuery["filter"] = filter
+			}
 			query["query"] = map[string]interface{}{
-				"bool": map[string]interface{}{
-					"must": must,
-				},
+				"bool": boolQuery,
 			}
 		}
 	}
@@ -292,11 +333,11 @@ func (o *OpenSearchClient) ListDocuments(ctx context.Context, params *rag.Docume
 	}
 
 	req := opensearchapi.SearchRequest{
-		Index: []string{o.index},
+		Index: []string{index},
 		Body:  bytes.NewReader(body),
 	}
 
-	res, err := req.Do(ctx, o.client)
+	res, err := req.Do(ctx, o.transport)
 	if err != nil {
 		return nil, fmt.Errorf("문서 목록 조회 실패: %w", err)
 	}
@@ -328,23 +369,38 @@ func (o *OpenSearchClient) ListDocuments(ctx context.Context, params *rag.Docume
 
 	documents := extractDocumentsFromHits(hitsData)
 	hasNext := int64(from+pageSize) < totalVal
+	if useCursor {
+		hasNext = len(documents) == pageSize
+	}
+
+	nextCursor := ""
+	if hasNext {
+		nextCursor = encodeCursor(lastSortValues(hitsData))
+	}
 
 	return &rag.DocumentListResult{
-		Documents: documents,
-		Total:     totalVal,
-		Page:      page,
-		PageSize:  pageSize,
-		HasNext:   hasNext,
+		Documents:  documents,
+		Total:      totalVal,
+		Page:       page,
+		PageSize:   pageSize,
+		HasNext:    hasNext,
+		NextCursor: nextCursor,
+		HasMore:    hasNext,
 	}, nil
 }
 
 func (o *OpenSearchClient) GetDocument(ctx context.Context, id string) (*rag.Document, error) {
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	req := opensearchapi.GetRequest{
-		Index:      o.index,
+		Index:      index,
 		DocumentID: id,
 	}
 
-	res, err := req.Do(ctx, o.client)
+	res, err := req.Do(ctx, o.transport)
 	if err != nil {
 		return nil, fmt.Errorf("문서 조회 실패: %w", err)
 	}
@@ -369,8 +425,10 @@ func (o *OpenSearchClient) GetDocument(ctx context.Context, id string) (*rag.Doc
 	}
 
 	doc := rag.Document{
-		ID:      result["_id"].(string),
-		Content: getStringValue(source["content"]),
+		ID:        result["_id"].(string),
+		Content:   getStringValue(source["content"]),
+		CreatedAt: getStringValue(source["createdAt"]),
+		UpdatedAt: getStringValue(source["updatedAt"]),
 	}
 
 	if metadata, ok := source["metadata"].(map[string]interface{}); ok {
@@ -385,13 +443,18 @@ func (o *OpenSearchClient) UpdateDocument(ctx context.Context, doc rag.Document)
 }
 
 func (o *OpenSearchClient) DeleteDocument(ctx context.Context, id string) error {
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return err
+	}
+
 	req := opensearchapi.DeleteRequest{
-		Index:      o.index,
+		Index:      index,
 		DocumentID: id,
 		Refresh:    "true",
 	}
 
-	res, err := req.Do(ctx, o.client)
+	res, err := req.Do(ctx, o.transport)
 	if err != nil {
 		return fmt.Errorf("문서 삭제 실패: %w", err)
 	}
@@ -408,11 +471,68 @@ func (o *OpenSearchClient) DeleteDocument(ctx context.Context, id string) error
 	return nil
 }
 
+// DeleteByMetadata deletes every document whose metadata matches all
+// key/value pairs exactly and returns the number deleted.
+func (o *OpenSearchClient) DeleteByMetadata(ctx context.Context, match map[string]string) (int64, error) {
+	if len(match) == 0 {
+		return 0, fmt.Errorf("삭제 조건이 비어 있습니다")
+	}
+
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var terms []interface{}
+	for key, value := range match {
+		terms = append(terms, map[string]interface{}{
+			"term": map[string]interface{}{
+				"metadata." + key + ".keyword": value,
+			},
+		})
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"filter": terms},
+		},
+	})
+
+	refresh := true
+	req := opensearchapi.DeleteByQueryRequest{
+		Index:   []string{index},
+		Body:    bytes.NewReader(body),
+		Refresh: &refresh,
+	}
+
+	res, err := req.Do(ctx, o.transport)
+	if err != nil {
+		return 0, fmt.Errorf("조건 삭제 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, fmt.Errorf("조건 삭제 오류: %s", res.String())
+	}
+
+	var result struct {
+		Deleted int64 `json:"deleted"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("조건 삭제 응답 파싱 실패: %w", err)
+	}
+	return result.Deleted, nil
+}
+
 func (o *OpenSearchClient) FetchDocuments(ctx context.Context, ids []string) ([]rag.Document, error) {
 	if len(ids) == 0 {
 		return []rag.Document{}, nil
 	}
 
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	payload := map[string]interface{}{
 		"ids": ids,
 	}
@@ -423,11 +543,11 @@ func (o *OpenSearchClient) FetchDocuments(ctx context.Context, ids []string) ([]
 	}
 
 	req := opensearchapi.MgetRequest{
-		Index: o.index,
+		Index: index,
 		Body:  bytes.NewReader(body),
 	}
 
-	res, err := req.Do(ctx, o.client)
+	res, err := req.Do(ctx, o.transport)
 	if err != nil {
 		return nil, fmt.Errorf("문서 Fetch 실패: %w", err)
 	}
@@ -459,8 +579,10 @@ func (o *OpenSearchClient) FetchDocuments(ctx context.Context, ids []string) ([]
 			continue
 		}
 		item := rag.Document{
-			ID:      doc.ID,
-			Content: getStringValue(doc.Source["content"]),
+			ID:        doc.ID,
+			Content:   getStringValue(doc.Source["content"]),
+			CreatedAt: getStringValue(doc.Source["createdAt"]),
+			UpdatedAt: getStringValue(doc.Source["updatedAt"]),
 		}
 		if metadata, ok := doc.Source["metadata"].(map[string]interface{}); ok {
 			item.Metadata = metadata
@@ -471,12 +593,31 @@ func (o *OpenSearchClient) FetchDocuments(ctx context.Context, ids []string) ([]
 	return documents, nil
 }
 
+// Health pings the OpenSearch cluster.
+func (o *OpenSearchClient) Health(ctx context.Context) error {
+	res, err := opensearchapi.PingRequest{}.Do(ctx, o.transport)
+	if err != nil {
+		return fmt.Errorf("OpenSearch 헬스 체크 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("OpenSearch 헬스 체크 오류: %s", res.String())
+	}
+	return nil
+}
+
 func (o *OpenSearchClient) GetStats(ctx context.Context) (*rag.DocumentStats, error) {
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	req := opensearchapi.CountRequest{
-		Index: []string{o.index},
+		Index: []string{index},
 	}
 
-	res, err := req.Do(ctx, o.client)
+	res, err := req.Do(ctx, o.transport)
 	if err != nil {
 		return nil, fmt.Errorf("문서 통계 조회 실패: %w", err)
 	}
@@ -496,11 +637,66 @@ func (o *OpenSearchClient) GetStats(ctx context.Context) (*rag.DocumentStats, er
 
 	return &rag.DocumentStats{
 		TotalDocuments: result.Count,
-		Index:          o.index,
+		Index:          index,
 		LastUpdatedAt:  time.Now().UTC().Format(time.RFC3339),
 	}, nil
 }
 
+// CountDocuments returns the exact number of documents matching filters,
+// unlike search totals which stop at max_result_window.
+func (o *OpenSearchClient) CountDocuments(ctx context.Context, filters *rag.SearchFilters) (int64, error) {
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := map[string]interface{}{"match_all": map[string]interface{}{}}
+	if clauses := filterClauses(filters); len(clauses) > 0 {
+		query = map[string]interface{}{"bool": map[string]interface{}{"filter": clauses}}
+	}
+	body, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return 0, err
+	}
+
+	req := opensearchapi.CountRequest{
+		Index: []string{index},
+		Body:  bytes.NewReader(body),
+	}
+	res, err := req.Do(ctx, o.transport)
+	if err != nil {
+		return 0, fmt.Errorf("문서 수 조회 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, fmt.Errorf("문서 수 조회 오류: %s", res.String())
+	}
+
+	var result struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("문서 수 응답 파싱 실패: %w", err)
+	}
+	return result.Count, nil
+}
+
+// documentSource builds the stored _source for a document.
+func documentSource(doc rag.Document) map[string]interface{} {
+	body := map[string]interface{}{
+		"content":  doc.Content,
+		"metadata": doc.Metadata,
+	}
+	if suggest := suggestInputs(doc.Metadata); len(suggest) > 0 {
+		body["suggest"] = suggest
+	}
+	for field, value := range sortSource(doc) {
+		body[field] = value
+	}
+	return body
+}
+
 func extractDocumentsFromHits(hits map[string]interface{}) []rag.Document {
 	itemsRaw, ok := hits["hits"].([]interface{})
 	if !ok {
@@ -520,9 +716,11 @@ func extractDocumentsFromHits(hits map[string]interface{}) []rag.Document {
 		}
 
 		doc := rag.Document{
-			ID:      getStringValue(h["_id"]),
-			Content: getStringValue(source["content"]),
-			Score:   getFloatValue(h["_score"]),
+			ID:        getStringValue(h["_id"]),
+			Content:   getStringValue(source["content"]),
+			Score:     getFloatValue(h["_score"]),
+			CreatedAt: getStringValue(source["createdAt"]),
+			UpdatedAt: getStringValue(source["updatedAt"]),
 		}
 
 		if metadata, ok := source["metadata"].(map[string]interface{}); ok {
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"strings"
+)
+
+var defaultSearchFields = []string{"title^3", "keywords^2", "content"}
+
+// relevanceConfig holds the operator-tunable scoring parameters.
+type relevanceConfig struct {
+	K1                 float64
+	B                  float64
+	Fields             []string
+	MinimumShouldMatch string
+}
+
+// fields resolves configured fields such as "title^3" to indexed paths.
+// Anything other than top-level fields is looked up under metadata.
+func (r relevanceConfig) fields() []string {
+	configured := r.Fields
+	if len(configured) == 0 {
+		configured = defaultSearchFields
+	}
+
+	fields := make([]string, 0, len(configured))
+	for _, field := range configured {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(field, "^")
+		switch {
+		case name == "content", name == "filename", strings.HasPrefix(name, "metadata."):
+			fields = append(fields, field)
+		default:
+			fields = append(fields, "metadata."+field)
+		}
+	}
+	return fields
+}
+
+// textQuery builds the scored full-text clause shared by Search and ListDocuments.
+func (r relevanceConfig) textQuery(query string) map[string]interface{} {
+	multiMatch := map[string]interface{}{
+		"query":  query,
+		"fields": r.fields(),
+		"type":   "best_fields",
+	}
+	if r.MinimumShouldMatch != "" {
+		multiMatch["minimum_should_match"] = r.MinimumShouldMatch
+	}
+
+	return map[string]interface{}{
+		"multi_match": multiMatch,
+	}
+}
+
+// similaritySettings sets the default BM25 parameters. Similarity is fixed at
+// index creation, so changes apply to existing data only after a migration.
+func (r relevanceConfig) similaritySettings() map[string]interface{} {
+	if r.K1 <= 0 && r.B <= 0 {
+		return nil
+	}
+
+	bm25 := map[string]interface{}{
+		"type": "BM25",
+	}
+	if r.K1 > 0 {
+		bm25["k1"] = r.K1
+	}
+	if r.B > 0 {
+		bm25["b"] = r.B
+	}
+
+	return map[string]interface{}{
+		"default": bm25,
+	}
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+const maxRetryDelay = 5 * time.Second
+
+// retryTransport retries transient OpenSearch failures (429, 502-504 and
+// connection errors) with exponential backoff, stopping early when the
+// request context is cancelled.
+type retryTransport struct {
+	next       opensearchapi.Transport
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+func (t *retryTransport) Perform(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	for attempt := 0; ; attempt++ {
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		res, err := t.next.Perform(req)
+		if attempt >= t.maxRetries || !isRetryable(res, err) {
+			return res, err
+		}
+
+		delay := t.backoff(attempt, res)
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		slog.WarnContext(req.Context(), "OpenSearch 요청 재시도",
+			"method", req.Method,
+			"path", req.URL.Path,
+			"attempt", attempt+1,
+			"delay", delay,
+			"error", err,
+		)
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func isRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff doubles the base delay per attempt with jitter, honouring
+// Retry-After when the cluster sends one.
+func (t *retryTransport) backoff(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			return min(time.Duration(seconds)*time.Second, maxRetryDelay)
+		}
+	}
+
+	delay := t.baseDelay << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"strings"
+	"time"
+
+	"yuon/internal/rag"
+)
+
+// sortFields maps the public sortBy values to indexed fields.
+var sortFields = map[string]string{
+	"score":     "_score",
+	"createdAt": "createdAt",
+	"updatedAt": "updatedAt",
+	"filename":  "filename",
+	"size":      "size",
+}
+
+var sortFieldTypes = map[string]string{
+	"createdAt": "date",
+	"updatedAt": "date",
+	"filename":  "keyword",
+	"size":      "long",
+}
+
+// sortReindexScript fills the sort fields for documents indexed before they
+// existed. Keep it in sync with sortSource.
+const sortReindexScript = `
+def m = ctx._source.metadata;
+if (ctx._source.createdAt == null && m != null && m.uploadedAt instanceof String) {
+  ctx._source.createdAt = m.uploadedAt;
+}
+if (ctx._source.updatedAt == null && ctx._source.createdAt != null) {
+  ctx._source.updatedAt = ctx._source.createdAt;
+}
+if (ctx._source.filename == null && m != null && m.filename instanceof String) {
+  ctx._source.filename = m.filename;
+}
+if (ctx._source.size == null) {
+  if (m != null && m.fileSize instanceof Number) {
+    ctx._source.size = m.fileSize;
+  } else if (ctx._source.content instanceof String) {
+    ctx._source.size = ctx._source.content.length();
+  }
+}`
+
+// IsValidSortField reports whether sortBy is supported by ListDocuments.
+func IsValidSortField(sortBy string) bool {
+	_, ok := sortFields[sortBy]
+	return ok
+}
+
+// sortSource returns the top-level fields used for sorting.
+func sortSource(doc rag.Document) map[string]interface{} {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	fields := map[string]interface{}{
+		"createdAt": now,
+		"updatedAt": now,
+		"size":      len(doc.Content),
+	}
+	if doc.CreatedAt != "" {
+		fields["createdAt"] = doc.CreatedAt
+	} else if uploadedAt, ok := doc.Metadata["uploadedAt"].(string); ok && uploadedAt != "" {
+		fields["createdAt"] = uploadedAt
+	}
+	if doc.UpdatedAt != "" {
+		fields["updatedAt"] = doc.UpdatedAt
+	}
+	if filename, ok := doc.Metadata["filename"].(string); ok && filename != "" {
+		fields["filename"] = filename
+	}
+	switch size := doc.Metadata["fileSize"].(type) {
+	case int:
+		fields["size"] = size
+	case int64:
+		fields["size"] = size
+	case float64:
+		fields["size"] = int64(size)
+	}
+	return fields
+}
+
+func sortClause(params *rag.DocumentListParams) []interface{} {
+	sortBy := "score"
+	order := "desc"
+	if params != nil {
+		if IsValidSortField(params.SortBy) {
+			sortBy = params.SortBy
+		}
+		if strings.EqualFold(params.SortOrder, "asc") {
+			order = "asc"
+		}
+	}
+
+	// _id breaks ties so search_after cursors stay stable across pages.
+	field := sortFields[sortBy]
+	if field == "_score" {
+		return []interface{}{
+			map[string]interface{}{
+				"_score": map[string]interface{}{"order": order},
+			},
+			map[string]interface{}{
+				"_id": map[string]interface{}{"order": "asc"},
+			},
+		}
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			field: map[string]interface{}{
+				"order":         order,
+				"missing":       "_last",
+				"unmapped_type": sortFieldTypes[sortBy],
+			},
+		},
+		map[string]interface{}{
+			"_id": map[string]interface{}{"order": "asc"},
+		},
+	}
+}
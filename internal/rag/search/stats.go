@@ -0,0 +1,170 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"yuon/internal/rag"
+)
+
+// DetailedStats collects size, segment, shard health and category counts for
+// the admin dashboard.
+func (o *OpenSearchClient) DetailedStats(ctx context.Context) (*rag.DetailedIndexStats, error) {
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &rag.DetailedIndexStats{
+		Index:       index,
+		CollectedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if err := o.collectIndexStats(ctx, index, stats); err != nil {
+		return nil, err
+	}
+	if err := o.collectHealth(ctx, index, stats); err != nil {
+		return nil, err
+	}
+	if err := o.collectShards(ctx, index, stats); err != nil {
+		return nil, err
+	}
+
+	aggs, err := o.Aggregations(ctx, 50)
+	if err != nil {
+		return nil, err
+	}
+	stats.Categories = aggs.Categories
+
+	return stats, nil
+}
+
+func (o *OpenSearchClient) collectIndexStats(ctx context.Context, index string, stats *rag.DetailedIndexStats) error {
+	req := opensearchapi.IndicesStatsRequest{
+		Index:  []string{index},
+		Metric: []string{"docs", "store", "segments"},
+	}
+
+	res, err := req.Do(ctx, o.transport)
+	if err != nil {
+		return fmt.Errorf("인덱스 통계 조회 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("인덱스 통계 조회 오류: %s", res.String())
+	}
+
+	type section struct {
+		Docs struct {
+			Count   int64 `json:"count"`
+			Deleted int64 `json:"deleted"`
+		} `json:"docs"`
+		Store struct {
+			SizeInBytes int64 `json:"size_in_bytes"`
+		} `json:"store"`
+		Segments struct {
+			Count int64 `json:"count"`
+		} `json:"segments"`
+	}
+	var result struct {
+		All struct {
+			Primaries section `json:"primaries"`
+			Total     section `json:"total"`
+		} `json:"_all"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return fmt.Errorf("인덱스 통계 응답 파싱 실패: %w", err)
+	}
+
+	stats.TotalDocuments = result.All.Primaries.Docs.Count
+	stats.DeletedDocuments = result.All.Primaries.Docs.Deleted
+	stats.SizeInBytes = result.All.Total.Store.SizeInBytes
+	stats.PrimarySizeInBytes = result.All.Primaries.Store.SizeInBytes
+	stats.SegmentCount = result.All.Total.Segments.Count
+	return nil
+}
+
+func (o *OpenSearchClient) collectHealth(ctx context.Context, index string, stats *rag.DetailedIndexStats) error {
+	req := opensearchapi.ClusterHealthRequest{
+		Index: []string{index},
+	}
+
+	res, err := req.Do(ctx, o.transport)
+	if err != nil {
+		return fmt.Errorf("인덱스 상태 조회 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("인덱스 상태 조회 오류: %s", res.String())
+	}
+
+	var result struct {
+		Status              string `json:"status"`
+		ActivePrimaryShards int    `json:"active_primary_shards"`
+		ActiveShards        int    `json:"active_shards"`
+		RelocatingShards    int    `json:"relocating_shards"`
+		InitializingShards  int    `json:"initializing_shards"`
+		UnassignedShards    int    `json:"unassigned_shards"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return fmt.Errorf("인덱스 상태 응답 파싱 실패: %w", err)
+	}
+
+	stats.Health = result.Status
+	stats.ActivePrimaryShards = result.ActivePrimaryShards
+	stats.ActiveShards = result.ActiveShards
+	stats.RelocatingShards = result.RelocatingShards
+	stats.InitializingShards = result.InitializingShards
+	stats.UnassignedShards = result.UnassignedShards
+	return nil
+}
+
+func (o *OpenSearchClient) collectShards(ctx context.Context, index string, stats *rag.DetailedIndexStats) error {
+	req := opensearchapi.CatShardsRequest{
+		Index:  []string{index},
+		Format: "json",
+		Bytes:  "b",
+	}
+
+	res, err := req.Do(ctx, o.transport)
+	if err != nil {
+		return fmt.Errorf("샤드 목록 조회 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("샤드 목록 조회 오류: %s", res.String())
+	}
+
+	var rows []struct {
+		Index  string `json:"index"`
+		Shard  string `json:"shard"`
+		Prirep string `json:"prirep"`
+		State  string `json:"state"`
+		Docs   string `json:"docs"`
+		Store  string `json:"store"`
+		Node   string `json:"node"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil {
+		return fmt.Errorf("샤드 목록 응답 파싱 실패: %w", err)
+	}
+
+	stats.Shards = make([]rag.IndexShard, 0, len(rows))
+	for _, row := range rows {
+		stats.Shards = append(stats.Shards, rag.IndexShard{
+			Index:       row.Index,
+			Shard:       row.Shard,
+			Primary:     row.Prirep == "p",
+			State:       row.State,
+			Documents:   row.Docs,
+			SizeInBytes: row.Store,
+			Node:        row.Node,
+		})
+	}
+	return nil
+}
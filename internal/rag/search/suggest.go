@@ -0,0 +1,166 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"yuon/internal/rag"
+)
+
+// suggestFields are the metadata keys copied into the search_as_you_type field.
+var suggestFields = []string{"title", "filename", "category"}
+
+// suggestReindexScript fills the suggest field for documents indexed before
+// it existed. Keep it in sync with suggestInputs.
+const suggestReindexScript = `
+def m = ctx._source.metadata;
+if (m != null) {
+  def s = new ArrayList();
+  for (k in ['title', 'filename', 'category']) {
+    if (m[k] instanceof String && m[k] != '') { s.add(m[k]); }
+  }
+  if (m.keywords instanceof List) {
+    for (kw in m.keywords) { if (kw instanceof String && kw != '') { s.add(kw); } }
+  }
+  if (!s.isEmpty()) { ctx._source.suggest = s; }
+}`
+
+func suggestInputs(metadata map[string]interface{}) []string {
+	if metadata == nil {
+		return nil
+	}
+
+	var inputs []string
+	for _, key := range suggestFields {
+		if v, ok := metadata[key].(string); ok && strings.TrimSpace(v) != "" {
+			inputs = append(inputs, strings.TrimSpace(v))
+		}
+	}
+
+	switch keywords := metadata["keywords"].(type) {
+	case []interface{}:
+		for _, kw := range keywords {
+			if v, ok := kw.(string); ok && strings.TrimSpace(v) != "" {
+				inputs = append(inputs, strings.TrimSpace(v))
+			}
+		}
+	case []string:
+		for _, v := range keywords {
+			if strings.TrimSpace(v) != "" {
+				inputs = append(inputs, strings.TrimSpace(v))
+			}
+		}
+	}
+
+	return inputs
+}
+
+// Suggest returns title/keyword completions for a partially typed query,
+// from the documents roles may see (any document for nil roles).
+func (o *OpenSearchClient) Suggest(ctx context.Context, prefix string, limit int, roles []string) ([]rag.Suggestion, error) {
+	index, err := o.resolveIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return []rag.Suggestion{}, nil
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	boolQuery := map[string]interface{}{
+		"must": map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  prefix,
+				"type":   "bool_prefix",
+				"fields": []string{"suggest", "suggest._2gram", "suggest._3gram"},
+			},
+		},
+	}
+	if clauses := filterClauses(&rag.SearchFilters{Roles: roles}); len(clauses) > 0 {
+		boolQuery["filter"] = clauses
+	}
+	query := map[string]interface{}{
+		"size":    limit * 2,
+		"_source": []string{"suggest"},
+		"query":   map[string]interface{}{"bool": boolQuery},
+	}
+
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("자동완성 쿼리 직렬화 실패: %w", err)
+	}
+
+	req := opensearchapi.SearchRequest{
+		Index: []string{index},
+		Body:  bytes.NewReader(body),
+	}
+
+	res, err := req.Do(ctx, o.transport)
+	if err != nil {
+		return nil, fmt.Errorf("자동완성 조회 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("자동완성 조회 오류: %s", res.String())
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				ID     string  `json:"_id"`
+				Score  float64 `json:"_score"`
+				Source struct {
+					Suggest []string `json:"suggest"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("자동완성 응답 파싱 실패: %w", err)
+	}
+
+	needle := strings.ToLower(prefix)
+	seen := make(map[string]bool)
+	suggestions := make([]rag.Suggestion, 0, limit)
+	for _, hit := range result.Hits.Hits {
+		for _, text := range hit.Source.Suggest {
+			key := strings.ToLower(text)
+			if seen[key] || !matchesPrefix(key, needle) {
+				continue
+			}
+			seen[key] = true
+			suggestions = append(suggestions, rag.Suggestion{
+				Text:       text,
+				DocumentID: hit.ID,
+				Score:      hit.Score,
+			})
+			if len(suggestions) >= limit {
+				return suggestions, nil
+			}
+		}
+	}
+
+	return suggestions, nil
+}
+
+// matchesPrefix reports whether any word boundary in text starts with prefix.
+func matchesPrefix(text, prefix string) bool {
+	if strings.HasPrefix(text, prefix) {
+		return true
+	}
+	for _, word := range strings.Fields(text) {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return strings.Contains(text, prefix)
+}
@@ -0,0 +1,97 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"yuon/internal/rag"
+)
+
+// resolveIndex returns the index for the workspace on ctx, creating it with
+// the standard mapping on first use. Requests without a workspace use o.index.
+func (o *OpenSearchClient) resolveIndex(ctx context.Context) (string, error) {
+	workspace := rag.WorkspaceFromContext(ctx)
+	if workspace == "" {
+		return o.index, nil
+	}
+
+	name := workspaceIndexName(o.index, workspace)
+	if _, ok := o.workspaceIndices.Load(name); ok {
+		return name, nil
+	}
+
+	exists := opensearchapi.IndicesExistsRequest{
+		Index: []string{name},
+	}
+	res, err := exists.Do(ctx, o.transport)
+	if err != nil {
+		return "", fmt.Errorf("워크스페이스 인덱스 확인 실패: %w", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != 200 {
+		if err := o.createIndex(ctx, name); err != nil && !strings.Contains(err.Error(), "resource_already_exists_exception") {
+			return "", err
+		}
+	}
+
+	o.workspaceIndices.Store(name, struct{}{})
+	return name, nil
+}
+
+// workspaceIndexName derives the index of a workspace. Distinct workspaces
+// always get distinct names (see rag.WorkspaceKey).
+func workspaceIndexName(base, workspace string) string {
+	return base + "-" + rag.WorkspaceKey(workspace)
+}
+
+// workspaceIndexNames lists the workspace indexes (or, once migrated, their
+// aliases) that exist for o.index.
+func (o *OpenSearchClient) workspaceIndexNames(ctx context.Context) ([]string, error) {
+	req := opensearchapi.IndicesGetAliasRequest{
+		Index: []string{o.index + "-*"},
+	}
+	res, err := req.Do(ctx, o.transport)
+	if err != nil {
+		return nil, fmt.Errorf("워크스페이스 인덱스 조회 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("워크스페이스 인덱스 조회 오류: %s", res.String())
+	}
+
+	var result map[string]struct {
+		Aliases map[string]json.RawMessage `json:"aliases"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("워크스페이스 인덱스 응답 파싱 실패: %w", err)
+	}
+
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(o.index) + `-([a-z0-9_-]+-)?[0-9a-f]{8}$`)
+	seen := map[string]bool{}
+	var names []string
+	add := func(name string) {
+		if pattern.MatchString(name) && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for index, info := range result {
+		add(index)
+		for alias := range info.Aliases {
+			add(alias)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -4,12 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"yuon/internal/rag"
 	"yuon/internal/rag/llm"
 )
 
@@ -28,6 +28,7 @@ type AnalyticsStats struct {
 type analyticsTracker struct {
 	llm            *llm.OpenAIClient
 	store          AnalyticsStore
+	queue          *analyticsQueue
 	mu             sync.RWMutex
 	totalMessages  int
 	keywordCounts  map[string]int
@@ -45,49 +46,35 @@ func newAnalyticsTracker(llmClient *llm.OpenAIClient, store AnalyticsStore) *ana
 	}
 }
 
-func (a *analyticsTracker) Record(ctx context.Context, message string, docs []rag.Document) {
-	var tokens []string
-
+// extractKeywords asks the model for the keywords of message, none when it
+// fails.
+func (a *analyticsTracker) extractKeywords(ctx context.Context, message string) []string {
 	// LLM 기반 키워드 추출만 사용
-	if a.llm != nil {
-		if llmKeywords, err := a.llm.ExtractKeywords(ctx, message, 8); err == nil && len(llmKeywords) > 0 {
-			tokens = llmKeywords
-		}
+	if a.llm == nil {
+		return nil
+	}
+	keywords, err := a.llm.ExtractKeywords(ctx, message, 8)
+	if err != nil {
+		slog.WarnContext(ctx, "키워드 추출 실패", "error", err)
+		return nil
 	}
+	return keywords
+}
 
+// count adds an answered message to the in-memory counters, used when no
+// store is configured.
+func (a *analyticsTracker) count(keywords, categories []string, hourKey string) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	a.totalMessages++
-	for _, t := range tokens {
+	for _, t := range keywords {
 		a.keywordCounts[t]++
 	}
-
-	for _, doc := range docs {
-		if doc.Metadata == nil {
-			continue
-		}
-		if category, ok := doc.Metadata["category"].(string); ok && category != "" {
-			a.categoryCounts[strings.ToLower(category)]++
-		}
+	for _, c := range categories {
+		a.categoryCounts[strings.ToLower(c)]++
 	}
-
-	hourKey := time.Now().UTC().Format("15:00")
 	a.hourlyCounts[hourKey]++
-
-	// Persist to store if available
-	if a.store != nil {
-		cats := make([]string, 0)
-		for _, doc := range docs {
-			if doc.Metadata == nil {
-				continue
-			}
-			if c, ok := doc.Metadata["category"].(string); ok && c != "" {
-				cats = append(cats, c)
-			}
-		}
-		_ = a.store.Record(ctx, tokens, cats, hourKey)
-	}
 }
 
 func (a *analyticsTracker) Snapshot() AnalyticsStats {
@@ -126,6 +113,18 @@ func topN(m map[string]int, n int) []keywordStat {
 	return items
 }
 
+// uniqueStrings drops repeated values, keeping their order.
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
 
 func (a *analyticsTracker) StatsJSON() string {
 	stats := a.Snapshot()
@@ -140,14 +139,35 @@ func (s *ChatbotService) GetAnalyticsStats() AnalyticsStats {
 	return s.analytics.Snapshot()
 }
 
-func (s *ChatbotService) GenerateKnowledgeNeedAnalysis(ctx context.Context) (string, error) {
+// GenerateKnowledgeNeedAnalysis asks the model which material is missing,
+// from the question statistics and the latest knowledge gap report, which
+// it also returns (nil before the first report).
+func (s *ChatbotService) GenerateKnowledgeNeedAnalysis(ctx context.Context) (string, *KnowledgeGapReport, error) {
 	if s.analytics == nil {
-		return "", fmt.Errorf("analytics tracker not configured")
+		return "", nil, fmt.Errorf("analytics tracker not configured")
 	}
 	stats := s.analytics.Snapshot()
-	payload, _ := json.Marshal(stats)
 
+	var report *KnowledgeGapReport
+	if s.analytics.store != nil {
+		reports, err := s.analytics.store.KnowledgeGapReports(ctx, time.Time{}, 1)
+		if err != nil {
+			slog.WarnContext(ctx, "지식 공백 보고서 조회 실패", "error", err)
+		} else if len(reports) > 0 {
+			report = &reports[0]
+		}
+	}
+
+	payload, _ := json.Marshal(stats)
 	prompt := fmt.Sprintf("다음은 최근 사용자 질문 통계입니다. 부족한 자료 영역을 간결하게 제안해 주세요.\n\n통계 데이터:\n%s", string(payload))
+	if report != nil && len(report.Clusters) > 0 {
+		gaps, _ := json.Marshal(report.Clusters)
+		prompt += fmt.Sprintf("\n\n답변할 자료를 찾지 못한 질문 묶음:\n%s", string(gaps))
+	}
 
-	return s.llm.GenerateText(ctx, "당신은 데이터 분석가입니다. 한국어로 3줄 이내로 부족한 지식 영역을 제안하세요.", prompt, 200)
+	analysis, err := s.llm.GenerateText(ctx, "당신은 데이터 분석가입니다. 한국어로 3줄 이내로 부족한 지식 영역을 제안하세요.", prompt, 200)
+	if err != nil {
+		return "", nil, err
+	}
+	return analysis, report, nil
 }
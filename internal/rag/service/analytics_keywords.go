@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// KeywordTrend compares how often a keyword was asked about in a period and
+// in the period of the same length before it. PercentChange is null for
+// keywords new in the period.
+type KeywordTrend struct {
+	Keyword       string   `json:"keyword"`
+	Current       int64    `json:"current"`
+	Previous      int64    `json:"previous"`
+	Change        int64    `json:"change"`
+	PercentChange *float64 `json:"percentChange"`
+}
+
+// KeywordTrends lists the keywords asked about more and less often than in
+// the previous period, the largest changes first.
+type KeywordTrends struct {
+	Days    int            `json:"days"`
+	Rising  []KeywordTrend `json:"rising"`
+	Falling []KeywordTrend `json:"falling"`
+}
+
+func (s *PostgresAnalyticsStore) KeywordCounts(ctx context.Context, days int) ([]KeywordTrend, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT keyword,
+			COALESCE(SUM(count) FILTER (WHERE date > CURRENT_DATE - $1::INT), 0),
+			COALESCE(SUM(count) FILTER (WHERE date <= CURRENT_DATE - $1::INT), 0)
+		FROM analytics_keyword_daily
+		WHERE date > CURRENT_DATE - 2 * $1::INT
+		GROUP BY keyword
+	`, days)
+	if err != nil {
+		return nil, fmt.Errorf("keyword counts query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []KeywordTrend
+	for rows.Next() {
+		var k KeywordTrend
+		if err := rows.Scan(&k.Keyword, &k.Current, &k.Previous); err != nil {
+			return nil, err
+		}
+		counts = append(counts, k)
+	}
+	return counts, rows.Err()
+}
+
+// KeywordTrends compares the keywords of the last days days, today
+// included, with the days days before. Keywords asked fewer than minCount
+// times in both periods are left out as noise, and up to limit keywords are
+// listed each way.
+func (s *ChatbotService) KeywordTrends(ctx context.Context, days, limit, minCount int) (*KeywordTrends, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, errAnalyticsStoreMissing
+	}
+	counts, err := s.analytics.store.KeywordCounts(ctx, days)
+	if err != nil {
+		return nil, err
+	}
+
+	trends := &KeywordTrends{Days: days, Rising: []KeywordTrend{}, Falling: []KeywordTrend{}}
+	for _, k := range counts {
+		if k.Current < int64(minCount) && k.Previous < int64(minCount) {
+			continue
+		}
+		k.Change = k.Current - k.Previous
+		if k.Previous > 0 {
+			change := calculatePercentChange(float64(k.Previous), float64(k.Current))
+			k.PercentChange = &change
+		}
+		switch {
+		case k.Change > 0:
+			trends.Rising = append(trends.Rising, k)
+		case k.Change < 0:
+			trends.Falling = append(trends.Falling, k)
+		}
+	}
+
+	sortTrends := func(list []KeywordTrend, less func(a, b KeywordTrend) bool) []KeywordTrend {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Change == list[j].Change {
+				return list[i].Keyword < list[j].Keyword
+			}
+			return less(list[i], list[j])
+		})
+		if len(list) > limit {
+			list = list[:limit]
+		}
+		return list
+	}
+	trends.Rising = sortTrends(trends.Rising, func(a, b KeywordTrend) bool { return a.Change > b.Change })
+	trends.Falling = sortTrends(trends.Falling, func(a, b KeywordTrend) bool { return a.Change < b.Change })
+	return trends, nil
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"yuon/internal/rag"
+	"yuon/internal/rag/llm"
+)
+
+// AnalyticsQueueOptions sizes the queue analytics are recorded through.
+// Events wait in a buffer of Size and are recorded in batches of up to
+// BatchSize, at least every FlushInterval. Events arriving while the buffer
+// is full are dropped rather than slowing down chat.
+type AnalyticsQueueOptions struct {
+	Size          int
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+var DefaultAnalyticsQueueOptions = AnalyticsQueueOptions{
+	Size:          1000,
+	BatchSize:     50,
+	FlushInterval: 2 * time.Second,
+}
+
+// analyticsFlushTimeout bounds recording one batch, keyword extraction
+// included.
+const analyticsFlushTimeout = 2 * time.Minute
+
+// analyticsEvent is something to record: an answered message, the response
+// time of an answer or the activity of a chat session.
+type analyticsEvent struct {
+	answer  *answerEvent
+	metric  *ResponseMetric
+	session *sessionEvent
+}
+
+type answerEvent struct {
+	at        time.Time
+	userID    string
+	message   string
+	answer    string
+	docs      []rag.Document
+	usage     llm.Usage
+	bestScore float64
+}
+
+type sessionEvent struct {
+	sessionID      string
+	conversationID string
+}
+
+// analyticsQueue hands events from the chat path to a single worker. Before
+// start, or without a buffer, events are recorded on the caller's goroutine.
+type analyticsQueue struct {
+	events chan analyticsEvent
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	mu      sync.Mutex
+	dropped int
+}
+
+// StartAnalyticsRecorder moves analytics recording off the chat path onto a
+// worker sized by opts, until StopAnalyticsRecorder.
+func (s *ChatbotService) StartAnalyticsRecorder(opts AnalyticsQueueOptions) {
+	if s.analytics == nil || s.analytics.queue != nil {
+		return
+	}
+	if opts.Size <= 0 {
+		opts.Size = DefaultAnalyticsQueueOptions.Size
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultAnalyticsQueueOptions.BatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultAnalyticsQueueOptions.FlushInterval
+	}
+	q := &analyticsQueue{
+		events: make(chan analyticsEvent, opts.Size),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	s.analytics.queue = q
+	go s.runAnalyticsQueue(q, opts)
+}
+
+// StopAnalyticsRecorder records the events still queued and stops the
+// worker, waiting until it is done or ctx is.
+func (s *ChatbotService) StopAnalyticsRecorder(ctx context.Context) error {
+	if s.analytics == nil || s.analytics.queue == nil {
+		return nil
+	}
+	q := s.analytics.queue
+	q.once.Do(func() { close(q.stop) })
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueueAnalytics queues ev, or records it right away without a worker.
+func (s *ChatbotService) enqueueAnalytics(ev analyticsEvent) {
+	if s.analytics == nil {
+		return
+	}
+	q := s.analytics.queue
+	if q == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), analyticsFlushTimeout)
+		defer cancel()
+		s.recordAnalytics(ctx, []analyticsEvent{ev})
+		return
+	}
+	select {
+	case <-q.stop:
+	case q.events <- ev:
+	default:
+		q.mu.Lock()
+		q.dropped++
+		q.mu.Unlock()
+	}
+}
+
+func (s *ChatbotService) runAnalyticsQueue(q *analyticsQueue, opts AnalyticsQueueOptions) {
+	defer close(q.done)
+	ticker := time.NewTicker(opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]analyticsEvent, 0, opts.BatchSize)
+	flush := func() {
+		q.mu.Lock()
+		dropped := q.dropped
+		q.dropped = 0
+		q.mu.Unlock()
+		if dropped > 0 {
+			slog.Warn("분석 기록 대기열이 가득 차 이벤트를 버렸습니다", "dropped", dropped)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), analyticsFlushTimeout)
+		s.recordAnalytics(ctx, batch)
+		cancel()
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case ev := <-q.events:
+			batch = append(batch, ev)
+			if len(batch) >= opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-q.stop:
+			for {
+				select {
+				case ev := <-q.events:
+					batch = append(batch, ev)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// recordAnalytics extracts the keywords of the answered messages in events
+// and records everything, adding up what the events share so each counter
+// is written once. Failures are logged, never returned.
+func (s *ChatbotService) recordAnalytics(ctx context.Context, events []analyticsEvent) {
+	a := s.analytics
+	counts := AnalyticsCounts{
+		Keywords:   make(map[string]int),
+		Categories: make(map[string]int),
+		Hours:      make(map[time.Time]int),
+	}
+	users := make(map[string]*UserActivity)
+	var userOrder []string
+	hits := make(map[string]*DocumentHit)
+	var hitOrder []string
+	sessions := make(map[string]string)
+	var metrics []ResponseMetric
+
+	for _, ev := range events {
+		switch {
+		case ev.metric != nil:
+			metrics = append(metrics, *ev.metric)
+		case ev.session != nil:
+			sessions[ev.session.sessionID] = ev.session.conversationID
+		case ev.answer != nil:
+			ans := ev.answer
+			keywords := a.extractKeywords(ctx, ans.message)
+			var cats []string
+			for _, doc := range ans.docs {
+				if c, ok := doc.Metadata["category"].(string); ok && c != "" {
+					cats = append(cats, c)
+				}
+			}
+			hour := ans.at.UTC().Truncate(time.Hour)
+			a.count(keywords, cats, hour.Format("15:00"))
+
+			for _, kw := range keywords {
+				counts.Keywords[kw]++
+			}
+			for _, c := range cats {
+				counts.Categories[c]++
+			}
+			counts.Hours[hour]++
+
+			if ans.userID != "" {
+				u, ok := users[ans.userID]
+				if !ok {
+					u = &UserActivity{UserID: ans.userID, Keywords: make(map[string]int), Categories: make(map[string]int)}
+					users[ans.userID] = u
+					userOrder = append(userOrder, ans.userID)
+				}
+				u.Messages++
+				u.PromptTokens += ans.usage.PromptTokens
+				u.CompletionTokens += ans.usage.CompletionTokens
+				for _, kw := range keywords {
+					u.Keywords[kw]++
+				}
+				for _, c := range uniqueStrings(cats) {
+					u.Categories[c]++
+				}
+			}
+
+			cited := citedSources(ans.answer, ans.docs)
+			for i, doc := range ans.docs {
+				hit, ok := hits[doc.ID]
+				if !ok {
+					hit = &DocumentHit{DocumentID: doc.ID}
+					hits[doc.ID] = hit
+					hitOrder = append(hitOrder, doc.ID)
+				}
+				if filename, _ := doc.Metadata["filename"].(string); filename != "" {
+					hit.Filename = filename
+				}
+				hit.Retrieved++
+				if cited[i] {
+					hit.Cited++
+				}
+			}
+
+			s.recordKnowledgeGap(ctx, ans.message, len(ans.docs), ans.bestScore)
+		}
+	}
+
+	if a.store == nil {
+		return
+	}
+	warn := func(what string, err error) {
+		if err != nil {
+			slog.WarnContext(ctx, "분석 기록 실패", "kind", what, "events", len(events), "error", err)
+		}
+	}
+	if len(counts.Hours) > 0 {
+		warn("counters", a.store.Record(ctx, counts))
+	}
+	if len(userOrder) > 0 {
+		activities := make([]UserActivity, len(userOrder))
+		for i, id := range userOrder {
+			activities[i] = *users[id]
+		}
+		warn("users", a.store.RecordUserActivity(ctx, activities))
+	}
+	if len(hitOrder) > 0 {
+		docHits := make([]DocumentHit, len(hitOrder))
+		for i, id := range hitOrder {
+			docHits[i] = *hits[id]
+		}
+		warn("document_hits", a.store.RecordDocumentHits(ctx, docHits))
+	}
+	if len(metrics) > 0 {
+		warn("response_metrics", a.store.RecordResponseTimes(ctx, metrics))
+	}
+	for sessionID, conversationID := range sessions {
+		warn("sessions", a.store.RecordSession(ctx, sessionID, conversationID))
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"yuon/package/scheduler"
+)
+
+// AnalyticsRetention is how long response metrics are kept at each level of
+// detail before being rolled up into the next. Zero keeps that level.
+type AnalyticsRetention struct {
+	Raw    time.Duration
+	Hourly time.Duration
+}
+
+func (s *PostgresAnalyticsStore) RollupResponseMetrics(ctx context.Context, before time.Time) (int64, error) {
+	var moved int64
+	err := s.db.QueryRowContext(ctx, `
+		WITH moved AS (
+			DELETE FROM response_metrics
+			WHERE created_at < $1
+			RETURNING conversation_id, response_time_ms, token_count, created_at
+		), merged AS (
+			INSERT INTO response_metrics_hourly (hour, responses, conversations, total_response_ms, total_tokens)
+			SELECT date_trunc('hour', created_at), COUNT(*), COUNT(DISTINCT conversation_id),
+				SUM(response_time_ms), COALESCE(SUM(token_count), 0)
+			FROM moved
+			GROUP BY 1
+			ON CONFLICT (hour) DO UPDATE SET
+				responses = response_metrics_hourly.responses + EXCLUDED.responses,
+				conversations = response_metrics_hourly.conversations + EXCLUDED.conversations,
+				total_response_ms = response_metrics_hourly.total_response_ms + EXCLUDED.total_response_ms,
+				total_tokens = response_metrics_hourly.total_tokens + EXCLUDED.total_tokens
+			RETURNING 1
+		)
+		SELECT COUNT(*) FROM moved
+	`, before).Scan(&moved)
+	if err != nil {
+		return 0, fmt.Errorf("response metrics rollup failed: %w", err)
+	}
+	return moved, nil
+}
+
+// responseTotals is a row of the rolled up response metrics.
+type responseTotals struct {
+	responses, conversations, totalResponseMs, totalTokens int64
+}
+
+func (s *PostgresAnalyticsStore) RollupHourlyMetrics(ctx context.Context, before time.Time, loc *time.Location) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Days are grouped here rather than in SQL, since loc may be the
+	// server's local zone, which PostgreSQL has no name for.
+	rows, err := tx.QueryContext(ctx, `
+		SELECT hour, responses, conversations, total_response_ms, total_tokens
+		FROM response_metrics_hourly
+		WHERE hour < $1
+		FOR UPDATE
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("hourly metrics query failed: %w", err)
+	}
+	days := make(map[time.Time]responseTotals)
+	var moved int64
+	for rows.Next() {
+		var hour time.Time
+		var t responseTotals
+		if err := rows.Scan(&hour, &t.responses, &t.conversations, &t.totalResponseMs, &t.totalTokens); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		day := scheduler.Day(hour, loc)
+		sum := days[day]
+		sum.responses += t.responses
+		sum.conversations += t.conversations
+		sum.totalResponseMs += t.totalResponseMs
+		sum.totalTokens += t.totalTokens
+		days[day] = sum
+		moved++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if moved == 0 {
+		return 0, nil
+	}
+
+	for day, t := range days {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO response_metrics_daily (day, responses, conversations, total_response_ms, total_tokens)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (day) DO UPDATE SET
+				responses = response_metrics_daily.responses + EXCLUDED.responses,
+				conversations = response_metrics_daily.conversations + EXCLUDED.conversations,
+				total_response_ms = response_metrics_daily.total_response_ms + EXCLUDED.total_response_ms,
+				total_tokens = response_metrics_daily.total_tokens + EXCLUDED.total_tokens
+		`, day, t.responses, t.conversations, t.totalResponseMs, t.totalTokens); err != nil {
+			return 0, fmt.Errorf("daily metrics upsert failed: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM response_metrics_hourly WHERE hour < $1`, before); err != nil {
+		return 0, fmt.Errorf("hourly metrics prune failed: %w", err)
+	}
+	return moved, tx.Commit()
+}
+
+// RollupAnalytics applies retention as of now. Hourly totals are only
+// rolled up by whole days in loc.
+func (s *ChatbotService) RollupAnalytics(ctx context.Context, retention AnalyticsRetention, loc *time.Location, now time.Time) error {
+	if s.analytics == nil || s.analytics.store == nil {
+		return errAnalyticsStoreMissing
+	}
+	if retention.Raw > 0 {
+		moved, err := s.analytics.store.RollupResponseMetrics(ctx, now.Add(-retention.Raw).Truncate(time.Hour))
+		if err != nil {
+			return err
+		}
+		if moved > 0 {
+			slog.InfoContext(ctx, "응답 지표를 시간별 합계로 정리", "rows", moved)
+		}
+	}
+	if retention.Hourly > 0 {
+		moved, err := s.analytics.store.RollupHourlyMetrics(ctx, scheduler.Day(now.Add(-retention.Hourly), loc), loc)
+		if err != nil {
+			return err
+		}
+		if moved > 0 {
+			slog.InfoContext(ctx, "시간별 응답 지표를 일별 합계로 정리", "rows", moved)
+		}
+	}
+	return nil
+}
+
+// RunAnalyticsRetention applies retention right away and then after every
+// midnight in loc, until ctx is done.
+func (s *ChatbotService) RunAnalyticsRetention(ctx context.Context, retention AnalyticsRetention, loc *time.Location) {
+	rollup := func(ctx context.Context, _ time.Time) error {
+		return s.RollupAnalytics(ctx, retention, loc, time.Now())
+	}
+	if err := rollup(ctx, time.Time{}); err != nil && ctx.Err() == nil {
+		slog.ErrorContext(ctx, "응답 지표 정리 실패", "error", err)
+	}
+	scheduler.Daily(ctx, "analytics_retention", loc, rollup)
+}
@@ -4,17 +4,72 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"yuon/internal/rag"
 )
 
 type AnalyticsStore interface {
-	Record(ctx context.Context, keywords []string, categories []string, hourKey string) error
+	// Record adds counts to the keyword, category and hourly counters, and
+	// the keyword counts to today's.
+	Record(ctx context.Context, counts AnalyticsCounts) error
 	Snapshot(ctx context.Context) (AnalyticsStats, error)
 	RecordSession(ctx context.Context, sessionID, conversationID string) error
-	RecordResponseTime(ctx context.Context, conversationID string, responseTimeMs, tokenCount int) error
+	RecordResponseTimes(ctx context.Context, metrics []ResponseMetric) error
+	// KeywordCounts returns how often each keyword was asked about in the
+	// last days days, today included, and in the days days before.
+	KeywordCounts(ctx context.Context, days int) ([]KeywordTrend, error)
+	// RecordUserActivity attributes answered messages to their users for
+	// today.
+	RecordUserActivity(ctx context.Context, activities []UserActivity) error
+	// TopUsers returns up to limit users with the most messages in the last
+	// days days, today included.
+	TopUsers(ctx context.Context, days, limit int) ([]UserUsage, error)
 	GetActiveUsers(ctx context.Context, withinMinutes int) (int64, error)
 	GetAvgResponseTime(ctx context.Context, withinHours int) (float64, error)
-	SnapshotDailyStats(ctx context.Context) error
+	// GetActivity summarizes answered chat sessions in [from, to).
+	GetActivity(ctx context.Context, from, to time.Time) (*PeriodActivity, error)
+	// GetLatency computes latency percentiles of the answers in [from, to)
+	// whose response metrics are not rolled up yet.
+	GetLatency(ctx context.Context, from, to time.Time) (*rag.LatencyStats, error)
+	// HourlyRequests returns the answered messages of each hour in [from, to)
+	// that had any, oldest first.
+	HourlyRequests(ctx context.Context, from, to time.Time) ([]HourlyRequestCount, error)
+	// ResponseSamples returns the response metrics recorded in [from, to) at
+	// the finest detail still kept.
+	ResponseSamples(ctx context.Context, from, to time.Time) ([]ResponseSample, error)
+	// RollupResponseMetrics moves response metrics recorded before a time
+	// into hourly totals, and RollupHourlyMetrics moves hourly totals before
+	// a time into daily totals of days in loc. Both return how many rows
+	// they removed.
+	RollupResponseMetrics(ctx context.Context, before time.Time) (int64, error)
+	RollupHourlyMetrics(ctx context.Context, before time.Time, loc *time.Location) (int64, error)
+	// SnapshotDailyStats records snap, replacing any snapshot of its date.
+	SnapshotDailyStats(ctx context.Context, snap DailyStatsSnapshot) error
+	// RecordDocumentHits counts the sources of answers for today.
+	RecordDocumentHits(ctx context.Context, hits []DocumentHit) error
+	// TopDocuments returns up to limit documents retrieved in the last days
+	// days, today included, the most retrieved first, or the most cited
+	// with byCited.
+	TopDocuments(ctx context.Context, days, limit int, byCited bool) ([]DocumentHitStats, error)
+	// RetrievedDocumentIDs returns the documents retrieved in the last days
+	// days.
+	RetrievedDocumentIDs(ctx context.Context, days int) ([]string, error)
+	// RecordKnowledgeGap stores a question answered without relevant
+	// documents; bestScore is negative when unknown.
+	RecordKnowledgeGap(ctx context.Context, query, reason string, bestScore float64) error
+	// KnowledgeGapQueries returns up to limit distinct gap questions asked in
+	// [from, to), most asked first.
+	KnowledgeGapQueries(ctx context.Context, from, to time.Time, limit int) ([]GapQuery, error)
+	SaveKnowledgeGapReport(ctx context.Context, report KnowledgeGapReport) error
+	// KnowledgeGapReports returns up to limit reports, the latest period
+	// first, ending no later than before unless it is zero.
+	KnowledgeGapReports(ctx context.Context, before time.Time, limit int) ([]KnowledgeGapReport, error)
 	GetDailyStats(ctx context.Context, daysAgo int) (*DailyStatsSnapshot, error)
+	// ListDailyStats returns the snapshots dated from through to, both
+	// "2006-01-02" and inclusive, oldest first.
+	ListDailyStats(ctx context.Context, from, to string) ([]DailyStatsSnapshot, error)
 }
 
 type PostgresAnalyticsStore struct {
@@ -25,45 +80,60 @@ func NewPostgresAnalyticsStore(db *sql.DB) *PostgresAnalyticsStore {
 	return &PostgresAnalyticsStore{db: db}
 }
 
-func (s *PostgresAnalyticsStore) Record(ctx context.Context, keywords []string, categories []string, hourKey string) error {
+// AnalyticsCounts are how many times each keyword, category and hour
+// (truncated, UTC) came up in a batch of answered messages.
+type AnalyticsCounts struct {
+	Keywords   map[string]int
+	Categories map[string]int
+	Hours      map[time.Time]int
+}
+
+func (s *PostgresAnalyticsStore) Record(ctx context.Context, counts AnalyticsCounts) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	for _, kw := range keywords {
+	for kw, n := range counts.Keywords {
 		if kw == "" {
 			continue
 		}
 		if _, err := tx.ExecContext(ctx, `
 			INSERT INTO analytics_keywords (keyword, count)
-			VALUES ($1, 1)
-			ON CONFLICT (keyword) DO UPDATE SET count = analytics_keywords.count + 1
-		`, kw); err != nil {
+			VALUES ($1, $2)
+			ON CONFLICT (keyword) DO UPDATE SET count = analytics_keywords.count + EXCLUDED.count
+		`, kw, n); err != nil {
 			return fmt.Errorf("keyword upsert failed: %w", err)
 		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO analytics_keyword_daily (date, keyword, count)
+			VALUES (CURRENT_DATE, $1, $2)
+			ON CONFLICT (date, keyword) DO UPDATE SET count = analytics_keyword_daily.count + EXCLUDED.count
+		`, kw, n); err != nil {
+			return fmt.Errorf("daily keyword upsert failed: %w", err)
+		}
 	}
 
-	for _, cat := range categories {
+	for cat, n := range counts.Categories {
 		if cat == "" {
 			continue
 		}
 		if _, err := tx.ExecContext(ctx, `
 			INSERT INTO analytics_categories (category, count)
-			VALUES ($1, 1)
-			ON CONFLICT (category) DO UPDATE SET count = analytics_categories.count + 1
-		`, cat); err != nil {
+			VALUES ($1, $2)
+			ON CONFLICT (category) DO UPDATE SET count = analytics_categories.count + EXCLUDED.count
+		`, cat, n); err != nil {
 			return fmt.Errorf("category upsert failed: %w", err)
 		}
 	}
 
-	if hourKey != "" {
+	for hour, n := range counts.Hours {
 		if _, err := tx.ExecContext(ctx, `
-			INSERT INTO analytics_hourly (hour_key, count)
-			VALUES ($1, 1)
-			ON CONFLICT (hour_key) DO UPDATE SET count = analytics_hourly.count + 1
-		`, hourKey); err != nil {
+			INSERT INTO analytics_hourly (hour, count)
+			VALUES ($1, $2)
+			ON CONFLICT (hour) DO UPDATE SET count = analytics_hourly.count + EXCLUDED.count
+		`, hour, n); err != nil {
 			return fmt.Errorf("hourly upsert failed: %w", err)
 		}
 	}
@@ -110,7 +180,7 @@ func (s *PostgresAnalyticsStore) Snapshot(ctx context.Context) (AnalyticsStats,
 		}
 	}
 
-	if items, err := read(`SELECT hour_key, count FROM analytics_hourly ORDER BY hour_key DESC LIMIT 24`); err == nil {
+	if items, err := read(`SELECT hour_key, count FROM analytics_hour_of_day ORDER BY hour_key DESC LIMIT 24`); err == nil {
 		for _, it := range items {
 			stats.RequestsByHour = append(stats.RequestsByHour, keywordStat{Keyword: it.key, Count: it.value})
 		}
@@ -135,12 +205,39 @@ func (s *PostgresAnalyticsStore) RecordSession(ctx context.Context, sessionID, c
 	return err
 }
 
-func (s *PostgresAnalyticsStore) RecordResponseTime(ctx context.Context, conversationID string, responseTimeMs, tokenCount int) error {
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO response_metrics (conversation_id, response_time_ms, token_count)
-		VALUES ($1, $2, $3)
-	`, conversationID, responseTimeMs, tokenCount)
-	return err
+// ResponseTiming is how long an answer took in milliseconds, in total and
+// in its retrieval and LLM parts.
+type ResponseTiming struct {
+	ResponseMs  int
+	RetrievalMs int
+	LLMMs       int
+}
+
+// ResponseMetric is the timing and token count of one answer, answered at
+// At.
+type ResponseMetric struct {
+	ConversationID string
+	Timing         ResponseTiming
+	Tokens         int
+	At             time.Time
+}
+
+func (s *PostgresAnalyticsStore) RecordResponseTimes(ctx context.Context, metrics []ResponseMetric) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range metrics {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO response_metrics (conversation_id, response_time_ms, retrieval_ms, llm_ms, token_count, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, m.ConversationID, m.Timing.ResponseMs, m.Timing.RetrievalMs, m.Timing.LLMMs, m.Tokens, m.At); err != nil {
+			return fmt.Errorf("response metric insert failed: %w", err)
+		}
+	}
+	return tx.Commit()
 }
 
 func (s *PostgresAnalyticsStore) GetActiveUsers(ctx context.Context, withinMinutes int) (int64, error) {
@@ -174,6 +271,72 @@ func (s *PostgresAnalyticsStore) GetAvgResponseTime(ctx context.Context, withinH
 	return avg.Float64, nil
 }
 
+// PeriodActivity counts answers and the distinct chat sessions that received
+// them, with their average response time in seconds. Sessions are distinct
+// per hour or day within rolled up metrics, so ActiveUsers is approximate
+// there.
+type PeriodActivity struct {
+	Responses       int64
+	ActiveUsers     int64
+	AvgResponseTime float64
+}
+
+func (s *PostgresAnalyticsStore) GetActivity(ctx context.Context, from, to time.Time) (*PeriodActivity, error) {
+	var activity PeriodActivity
+	var avg sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(responses), 0)::BIGINT, COALESCE(SUM(conversations), 0)::BIGINT,
+			(SUM(total_response_ms) / NULLIF(SUM(responses), 0) / 1000.0)::REAL
+		FROM (
+			SELECT COUNT(DISTINCT conversation_id) AS conversations, COUNT(*) AS responses, SUM(response_time_ms) AS total_response_ms
+			FROM response_metrics
+			WHERE created_at >= $1 AND created_at < $2
+			UNION ALL
+			SELECT conversations, responses, total_response_ms
+			FROM response_metrics_hourly
+			WHERE hour >= $1 AND hour < $2
+			UNION ALL
+			SELECT conversations, responses, total_response_ms
+			FROM response_metrics_daily
+			WHERE day >= $1 AND day < $2
+		) m
+	`, from, to).Scan(&activity.Responses, &activity.ActiveUsers, &avg)
+	if err != nil {
+		return nil, fmt.Errorf("get activity failed: %w", err)
+	}
+	activity.AvgResponseTime = avg.Float64
+	return &activity, nil
+}
+
+func (s *PostgresAnalyticsStore) GetLatency(ctx context.Context, from, to time.Time) (*rag.LatencyStats, error) {
+	var stats rag.LatencyStats
+	var chat, retrieval, llm pq.Float64Array
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+			percentile_cont(ARRAY[0.5, 0.95, 0.99]) WITHIN GROUP (ORDER BY response_time_ms),
+			percentile_cont(ARRAY[0.5, 0.95, 0.99]) WITHIN GROUP (ORDER BY retrieval_ms),
+			percentile_cont(ARRAY[0.5, 0.95, 0.99]) WITHIN GROUP (ORDER BY llm_ms)
+		FROM response_metrics
+		WHERE created_at >= $1 AND created_at < $2
+	`, from, to).Scan(&stats.Samples, &chat, &retrieval, &llm)
+	if err != nil {
+		return nil, fmt.Errorf("get latency failed: %w", err)
+	}
+	stats.Chat = latencyPercentiles(chat)
+	stats.Retrieval = latencyPercentiles(retrieval)
+	stats.LLM = latencyPercentiles(llm)
+	return &stats, nil
+}
+
+// latencyPercentiles reads p50, p95 and p99 as returned by
+// percentile_cont, NULL without samples.
+func latencyPercentiles(values pq.Float64Array) *rag.LatencyPercentiles {
+	if len(values) != 3 {
+		return nil
+	}
+	return &rag.LatencyPercentiles{P50: values[0], P95: values[1], P99: values[2]}
+}
+
 type DailyStatsSnapshot struct {
 	Date               string  `json:"date"`
 	TotalDocuments     int64   `json:"total_documents"`
@@ -183,9 +346,21 @@ type DailyStatsSnapshot struct {
 	AvgResponseTime    float64 `json:"avg_response_time"`
 }
 
-func (s *PostgresAnalyticsStore) SnapshotDailyStats(ctx context.Context) error {
-	// This should be called daily by a cron job
-	// For now, it's a placeholder
+func (s *PostgresAnalyticsStore) SnapshotDailyStats(ctx context.Context, snap DailyStatsSnapshot) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO daily_stats (date, total_documents, total_conversations, total_messages, active_users, avg_response_time)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (date) DO UPDATE SET
+			total_documents = EXCLUDED.total_documents,
+			total_conversations = EXCLUDED.total_conversations,
+			total_messages = EXCLUDED.total_messages,
+			active_users = EXCLUDED.active_users,
+			avg_response_time = EXCLUDED.avg_response_time,
+			created_at = NOW()
+	`, snap.Date, snap.TotalDocuments, snap.TotalConversations, snap.TotalMessages, snap.ActiveUsers, snap.AvgResponseTime)
+	if err != nil {
+		return fmt.Errorf("daily stats upsert failed: %w", err)
+	}
 	return nil
 }
 
@@ -215,3 +390,39 @@ func (s *PostgresAnalyticsStore) GetDailyStats(ctx context.Context, daysAgo int)
 	}
 	return &snap, err
 }
+
+func (s *PostgresAnalyticsStore) ListDailyStats(ctx context.Context, from, to string) ([]DailyStatsSnapshot, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			date::TEXT,
+			total_documents,
+			total_conversations,
+			total_messages,
+			active_users,
+			COALESCE(avg_response_time, 0)
+		FROM daily_stats
+		WHERE date BETWEEN $1 AND $2
+		ORDER BY date
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("list daily stats failed: %w", err)
+	}
+	defer rows.Close()
+
+	var snaps []DailyStatsSnapshot
+	for rows.Next() {
+		var snap DailyStatsSnapshot
+		if err := rows.Scan(
+			&snap.Date,
+			&snap.TotalDocuments,
+			&snap.TotalConversations,
+			&snap.TotalMessages,
+			&snap.ActiveUsers,
+			&snap.AvgResponseTime,
+		); err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, rows.Err()
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yuon/package/scheduler"
+)
+
+// Time series metrics and intervals.
+const (
+	MetricMessages    = "messages"
+	MetricTokens      = "tokens"
+	MetricActiveUsers = "active_users"
+
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+)
+
+// MaxTimeSeriesPoints bounds how many buckets one series may span.
+const MaxTimeSeriesPoints = 24 * 31
+
+// TimeSeriesPoint is the value of a metric over the bucket starting at Time.
+type TimeSeriesPoint struct {
+	Time  time.Time `json:"time"`
+	Value int64     `json:"value"`
+}
+
+// TimeSeries is a metric bucketed by interval over [From, To), one point per
+// bucket including empty ones, oldest first.
+type TimeSeries struct {
+	Metric   string            `json:"metric"`
+	Interval string            `json:"interval"`
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Timezone string            `json:"timezone"`
+	Points   []TimeSeriesPoint `json:"points"`
+}
+
+// ResponseSample is a group of response metrics at one time. Samples of
+// metrics not rolled up yet are per conversation and hour and carry the
+// conversation; rolled up ones count their conversations instead.
+type ResponseSample struct {
+	At             time.Time
+	ConversationID string
+	Responses      int64
+	Conversations  int64
+	Tokens         int64
+}
+
+func (s *PostgresAnalyticsStore) ResponseSamples(ctx context.Context, from, to time.Time) ([]ResponseSample, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT date_trunc('hour', created_at), COALESCE(conversation_id, ''), COUNT(*), 1, COALESCE(SUM(token_count), 0)
+		FROM response_metrics
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY 1, 2
+		UNION ALL
+		SELECT hour, '', responses, conversations, total_tokens
+		FROM response_metrics_hourly
+		WHERE hour >= $1 AND hour < $2
+		UNION ALL
+		SELECT day, '', responses, conversations, total_tokens
+		FROM response_metrics_daily
+		WHERE day >= $1 AND day < $2
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("response samples query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []ResponseSample
+	for rows.Next() {
+		var sample ResponseSample
+		if err := rows.Scan(&sample.At, &sample.ConversationID, &sample.Responses, &sample.Conversations, &sample.Tokens); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
+
+// bucketStart is the start of the interval bucket t falls in, in loc.
+func bucketStart(t time.Time, interval string, loc *time.Location) time.Time {
+	if interval == IntervalDay {
+		return scheduler.Day(t, loc)
+	}
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+}
+
+func nextBucket(t time.Time, interval string) time.Time {
+	if interval == IntervalDay {
+		return t.AddDate(0, 0, 1)
+	}
+	return t.Add(time.Hour)
+}
+
+// TimeSeries buckets metric by interval in loc over [from, to), counting
+// answered messages, their tokens or the chat sessions that got an answer.
+// Metrics rolled up by day land in the bucket of their day's midnight.
+func (s *ChatbotService) TimeSeries(ctx context.Context, metric, interval string, from, to time.Time, loc *time.Location) (*TimeSeries, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, errAnalyticsStoreMissing
+	}
+	switch metric {
+	case MetricMessages, MetricTokens, MetricActiveUsers:
+	default:
+		return nil, fmt.Errorf("unknown metric %q", metric)
+	}
+	if interval != IntervalHour && interval != IntervalDay {
+		return nil, fmt.Errorf("unknown interval %q", interval)
+	}
+
+	series := &TimeSeries{Metric: metric, Interval: interval, From: from, To: to, Timezone: loc.String()}
+	index := make(map[time.Time]int)
+	for t := bucketStart(from, interval, loc); t.Before(to); t = nextBucket(t, interval) {
+		if len(series.Points) == MaxTimeSeriesPoints {
+			return nil, fmt.Errorf("time series exceeds %d points", MaxTimeSeriesPoints)
+		}
+		index[t] = len(series.Points)
+		series.Points = append(series.Points, TimeSeriesPoint{Time: t})
+	}
+
+	samples, err := s.analytics.store.ResponseSamples(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[time.Time]map[string]bool)
+	for _, sample := range samples {
+		bucket := bucketStart(sample.At, interval, loc)
+		i, ok := index[bucket]
+		if !ok {
+			continue
+		}
+		point := &series.Points[i]
+		switch metric {
+		case MetricMessages:
+			point.Value += sample.Responses
+		case MetricTokens:
+			point.Value += sample.Tokens
+		case MetricActiveUsers:
+			if sample.ConversationID == "" {
+				point.Value += sample.Conversations
+				continue
+			}
+			if seen[bucket] == nil {
+				seen[bucket] = make(map[string]bool)
+			}
+			if !seen[bucket][sample.ConversationID] {
+				seen[bucket][sample.ConversationID] = true
+				point.Value++
+			}
+		}
+	}
+	return series, nil
+}
+
+// HourlyRequestCount is how many messages were answered in the hour
+// starting at Hour.
+type HourlyRequestCount struct {
+	Hour  time.Time `json:"hour"`
+	Count int64     `json:"count"`
+}
+
+// HourOfDayCount is how many messages were answered at Hour (0~23) o'clock
+// over a range of days.
+type HourOfDayCount struct {
+	Hour  int   `json:"hour"`
+	Count int64 `json:"count"`
+}
+
+// HourlyRequests are the answered messages of [From, To) by hour, one entry
+// per hour including empty ones, and added up by hour of day in Timezone.
+type HourlyRequests struct {
+	From        time.Time            `json:"from"`
+	To          time.Time            `json:"to"`
+	Timezone    string               `json:"timezone"`
+	Hours       []HourlyRequestCount `json:"hours"`
+	ByHourOfDay []HourOfDayCount     `json:"byHourOfDay"`
+}
+
+func (s *PostgresAnalyticsStore) HourlyRequests(ctx context.Context, from, to time.Time) ([]HourlyRequestCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT hour, count FROM analytics_hourly
+		WHERE hour >= $1 AND hour < $2
+		ORDER BY hour
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("hourly requests query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []HourlyRequestCount
+	for rows.Next() {
+		var c HourlyRequestCount
+		if err := rows.Scan(&c.Hour, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// HourlyRequests lists the answered messages of every hour in [from, to),
+// from rounded down to its hour, and adds them up by hour of day in loc.
+func (s *ChatbotService) HourlyRequests(ctx context.Context, from, to time.Time, loc *time.Location) (*HourlyRequests, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, errAnalyticsStoreMissing
+	}
+	from = from.Truncate(time.Hour)
+	result := &HourlyRequests{From: from, To: to, Timezone: loc.String(), ByHourOfDay: make([]HourOfDayCount, 24)}
+	for h := range result.ByHourOfDay {
+		result.ByHourOfDay[h].Hour = h
+	}
+	index := make(map[time.Time]int)
+	for t := from; t.Before(to); t = t.Add(time.Hour) {
+		if len(result.Hours) == MaxTimeSeriesPoints {
+			return nil, fmt.Errorf("hourly requests exceed %d hours", MaxTimeSeriesPoints)
+		}
+		index[t.UTC()] = len(result.Hours)
+		result.Hours = append(result.Hours, HourlyRequestCount{Hour: t.UTC()})
+	}
+
+	counts, err := s.analytics.store.HourlyRequests(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range counts {
+		if i, ok := index[c.Hour.UTC()]; ok {
+			result.Hours[i].Count += c.Count
+		}
+		result.ByHourOfDay[c.Hour.In(loc).Hour()].Count += c.Count
+	}
+	return result, nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// userTopTopics is how many keywords and categories are shown per user.
+const userTopTopics = 5
+
+// UserActivity is what answered messages add to their user's analytics:
+// the messages, how many of them mentioned each keyword and category, and
+// their tokens.
+type UserActivity struct {
+	UserID           string
+	Messages         int
+	Keywords         map[string]int
+	Categories       map[string]int
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// UserUsage is the chat volume of one user over a period. MessagesTrend is
+// the percent change against the period of the same length before it.
+type UserUsage struct {
+	UserID           string           `json:"userId"`
+	Name             string           `json:"name"`
+	Email            string           `json:"email"`
+	Messages         int64            `json:"messages"`
+	PromptTokens     int64            `json:"promptTokens"`
+	CompletionTokens int64            `json:"completionTokens"`
+	TotalTokens      int64            `json:"totalTokens"`
+	MessagesTrend    float64          `json:"messagesTrend"`
+	TopKeywords      []keywordStat    `json:"topKeywords"`
+	TopCategories    []keywordStat    `json:"topCategories"`
+	Daily            []UserDailyUsage `json:"daily"`
+	// Satisfaction is the share of the user's rated answers rated helpful,
+	// null until message feedback is recorded.
+	Satisfaction *float64 `json:"satisfaction"`
+}
+
+// UserDailyUsage is one day of a user's volume; days without messages are
+// left out.
+type UserDailyUsage struct {
+	Date        string `json:"date"`
+	Messages    int64  `json:"messages"`
+	TotalTokens int64  `json:"totalTokens"`
+}
+
+func (s *PostgresAnalyticsStore) RecordUserActivity(ctx context.Context, activities []UserActivity) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, activity := range activities {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO analytics_user_daily (user_id, date, messages, prompt_tokens, completion_tokens)
+			VALUES ($1, CURRENT_DATE, $2, $3, $4)
+			ON CONFLICT (user_id, date) DO UPDATE SET
+				messages = analytics_user_daily.messages + EXCLUDED.messages,
+				prompt_tokens = analytics_user_daily.prompt_tokens + EXCLUDED.prompt_tokens,
+				completion_tokens = analytics_user_daily.completion_tokens + EXCLUDED.completion_tokens
+		`, activity.UserID, activity.Messages, activity.PromptTokens, activity.CompletionTokens); err != nil {
+			return fmt.Errorf("user usage upsert failed: %w", err)
+		}
+
+		for kind, topics := range map[string]map[string]int{"keyword": activity.Keywords, "category": activity.Categories} {
+			for topic, n := range topics {
+				if topic == "" {
+					continue
+				}
+				if _, err := tx.ExecContext(ctx, `
+					INSERT INTO analytics_user_topics (user_id, date, kind, topic, count)
+					VALUES ($1, CURRENT_DATE, $2, $3, $4)
+					ON CONFLICT (user_id, date, kind, topic) DO UPDATE SET count = analytics_user_topics.count + EXCLUDED.count
+				`, activity.UserID, kind, topic, n); err != nil {
+					return fmt.Errorf("user topic upsert failed: %w", err)
+				}
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresAnalyticsStore) TopUsers(ctx context.Context, days, limit int) ([]UserUsage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT d.user_id, COALESCE(u.name, ''), COALESCE(u.email, ''),
+			COALESCE(SUM(d.messages) FILTER (WHERE d.date > CURRENT_DATE - $1::INT), 0) AS messages,
+			COALESCE(SUM(d.prompt_tokens) FILTER (WHERE d.date > CURRENT_DATE - $1::INT), 0),
+			COALESCE(SUM(d.completion_tokens) FILTER (WHERE d.date > CURRENT_DATE - $1::INT), 0),
+			COALESCE(SUM(d.messages) FILTER (WHERE d.date <= CURRENT_DATE - $1::INT), 0)
+		FROM analytics_user_daily d
+		LEFT JOIN users u ON u.id = d.user_id
+		WHERE d.date > CURRENT_DATE - 2 * $1::INT
+		GROUP BY d.user_id, u.name, u.email
+		HAVING COALESCE(SUM(d.messages) FILTER (WHERE d.date > CURRENT_DATE - $1::INT), 0) > 0
+		ORDER BY messages DESC, d.user_id
+		LIMIT $2
+	`, days, limit)
+	if err != nil {
+		return nil, fmt.Errorf("top users query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var users []UserUsage
+	index := make(map[string]int)
+	for rows.Next() {
+		var u UserUsage
+		var previous int64
+		if err := rows.Scan(&u.UserID, &u.Name, &u.Email, &u.Messages, &u.PromptTokens, &u.CompletionTokens, &previous); err != nil {
+			return nil, err
+		}
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+		u.MessagesTrend = calculatePercentChange(float64(previous), float64(u.Messages))
+		u.TopKeywords, u.TopCategories, u.Daily = []keywordStat{}, []keywordStat{}, []UserDailyUsage{}
+		index[u.UserID] = len(users)
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return users, nil
+	}
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.UserID
+	}
+
+	daily, err := s.db.QueryContext(ctx, `
+		SELECT user_id, date::TEXT, messages, prompt_tokens + completion_tokens
+		FROM analytics_user_daily
+		WHERE user_id = ANY($1) AND date > CURRENT_DATE - $2::INT
+		ORDER BY date
+	`, pq.Array(ids), days)
+	if err != nil {
+		return nil, fmt.Errorf("user daily usage query failed: %w", err)
+	}
+	defer daily.Close()
+	for daily.Next() {
+		var userID string
+		var day UserDailyUsage
+		if err := daily.Scan(&userID, &day.Date, &day.Messages, &day.TotalTokens); err != nil {
+			return nil, err
+		}
+		u := &users[index[userID]]
+		u.Daily = append(u.Daily, day)
+	}
+	if err := daily.Err(); err != nil {
+		return nil, err
+	}
+
+	topics, err := s.db.QueryContext(ctx, `
+		SELECT user_id, kind, topic, SUM(count) AS total
+		FROM analytics_user_topics
+		WHERE user_id = ANY($1) AND date > CURRENT_DATE - $2::INT
+		GROUP BY user_id, kind, topic
+		ORDER BY total DESC, topic
+	`, pq.Array(ids), days)
+	if err != nil {
+		return nil, fmt.Errorf("user topics query failed: %w", err)
+	}
+	defer topics.Close()
+	for topics.Next() {
+		var userID, kind string
+		var stat keywordStat
+		if err := topics.Scan(&userID, &kind, &stat.Keyword, &stat.Count); err != nil {
+			return nil, err
+		}
+		u := &users[index[userID]]
+		switch {
+		case kind == "keyword" && len(u.TopKeywords) < userTopTopics:
+			u.TopKeywords = append(u.TopKeywords, stat)
+		case kind == "category" && len(u.TopCategories) < userTopTopics:
+			u.TopCategories = append(u.TopCategories, stat)
+		}
+	}
+	return users, topics.Err()
+}
+
+// TopUsers reports the users who sent the most messages in the last days
+// days, with their daily volume and most asked topics.
+func (s *ChatbotService) TopUsers(ctx context.Context, days, limit int) ([]UserUsage, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, errAnalyticsStoreMissing
+	}
+	return s.analytics.store.TopUsers(ctx, days, limit)
+}
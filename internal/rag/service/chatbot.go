@@ -2,67 +2,136 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"math"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"gonum.org/v1/gonum/mat"
+	"yuon/internal/alert"
 	"yuon/internal/rag"
 	"yuon/internal/rag/llm"
 	"yuon/internal/rag/search"
 	"yuon/internal/rag/vectorstore"
+	"yuon/package/pagination"
 )
 
 type ChatbotService struct {
 	llm           *llm.OpenAIClient
-	vectorStore   *vectorstore.QdrantClient
+	vectorStore   rag.VectorStore
 	fullText      *search.OpenSearchClient
 	conversations *ConversationStore
 	convRepo      ConversationRepository
 	analytics     *analyticsTracker
+	gapOptions    KnowledgeGapOptions
+
+	// chatRequests and chatErrors count answers since start for alerts.
+	chatRequests atomic.Int64
+	chatErrors   atomic.Int64
 }
 
 func NewChatbotService(
 	llmClient *llm.OpenAIClient,
-	vectorStore *vectorstore.QdrantClient,
+	vectorStore rag.VectorStore,
 	fullText *search.OpenSearchClient,
 	convStore ConversationRepository,
 	analyticsStore AnalyticsStore,
+	historyCacheSize int,
 ) *ChatbotService {
 	return &ChatbotService{
 		llm:           llmClient,
 		vectorStore:   vectorStore,
 		fullText:      fullText,
-		conversations: NewConversationStore(),
+		conversations: NewConversationStore(convStore, historyCacheSize),
 		convRepo:      convStore,
 		analytics:     newAnalyticsTracker(llmClient, analyticsStore),
+		gapOptions:    DefaultKnowledgeGapOptions,
 	}
 }
 
+// Chat answers req and stores the exchange, so the conversation survives
+// restarts whichever transport carried it. Without History the stored
+// history of the conversation is used.
 func (s *ChatbotService) Chat(ctx context.Context, req *rag.ChatRequest) (*rag.ChatResponse, error) {
+	askedAt := time.Now().UTC()
+	if req.History == nil {
+		req.History = s.ConversationHistory(req.ConversationID)
+	}
+
+	resp, err := s.Answer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.SaveExchange(ctx, req.ConversationID, req.Message, askedAt, resp)
+	return resp, nil
+}
+
+// Answer answers req without storing anything, for callers that decide
+// themselves whether the exchange is kept.
+func (s *ChatbotService) Answer(ctx context.Context, req *rag.ChatRequest) (*rag.ChatResponse, error) {
+	s.chatRequests.Add(1)
+	resp, err := s.answer(ctx, req)
+	// 클라이언트가 취소한 요청은 오류로 세지 않음
+	if err != nil && ctx.Err() == nil {
+		s.chatErrors.Add(1)
+	}
+	return resp, err
+}
+
+func (s *ChatbotService) answer(ctx context.Context, req *rag.ChatRequest) (*rag.ChatResponse, error) {
 	var retrievedDocs []rag.Document
+	startedAt := time.Now()
 
 	if req.TopK == 0 {
 		req.TopK = 5
 	}
 
+	hybrid, useHybrid := s.vectorStore.(vectorstore.HybridSearcher)
+	useHybrid = useHybrid && hybrid.HybridEnabled() && (req.UseVectorSearch || req.UseFullText)
+
+	// 벡터 저장소가 응답하지 않으면 전문 검색만으로 답변 (degraded)
+	vectorFailed := false
+	// 단독 벡터 검색의 최고 유사도 (지식 공백 판단용, 없으면 -1)
+	bestScore := -1.0
+
+	// 하이브리드 검색 (Qdrant dense + sparse, 전문 검색 대체)
+	if useHybrid {
+		hybridDocs, err := s.searchHybrid(ctx, hybrid, req.Message, req.VectorSpace, req.TopK, req.Filters)
+		if err != nil {
+			slog.ErrorContext(ctx, "하이브리드 검색 실패", "error", err)
+			vectorFailed = true
+		} else {
+			retrievedDocs = append(retrievedDocs, hybridDocs...)
+		}
+	}
+
 	// 벡터 검색
-	if req.UseVectorSearch {
-		vectorDocs, err := s.searchByVector(ctx, req.Message, req.TopK)
+	if req.UseVectorSearch && !useHybrid {
+		vectorDocs, err := s.searchByVector(ctx, req.Message, req.VectorSpace, req.TopK, req.Filters)
 		if err != nil {
-			slog.Error("벡터 검색 실패", "error", err)
+			slog.ErrorContext(ctx, "벡터 검색 실패", "error", err)
+			vectorFailed = true
 		} else {
 			retrievedDocs = append(retrievedDocs, vectorDocs...)
+			for _, doc := range vectorDocs {
+				bestScore = math.Max(bestScore, doc.Score)
+			}
 		}
 	}
 
+	if vectorFailed {
+		slog.WarnContext(ctx, "벡터 검색을 사용할 수 없어 전문 검색으로 대체합니다", "conversation_id", req.ConversationID)
+	}
+
 	// 전문 검색
-	if req.UseFullText {
-		fullTextDocs, err := s.searchByFullText(ctx, req.Message, req.TopK)
+	if (req.UseFullText && !useHybrid) || vectorFailed {
+		fullTextDocs, err := s.searchByFullText(ctx, req.Message, req.TopK, req.Filters)
 		if err != nil {
-			slog.Error("전문 검색 실패", "error", err)
+			slog.ErrorContext(ctx, "전문 검색 실패", "error", err)
 		} else {
 			retrievedDocs = append(retrievedDocs, fullTextDocs...)
 		}
@@ -78,32 +147,139 @@ func (s *ChatbotService) Chat(ctx context.Context, req *rag.ChatRequest) (*rag.C
 	})
 
 	// LLM 응답 생성
-	answer, tokensUsed, err := s.llm.Chat(ctx, messages, retrievedDocs)
+	retrievalTime := time.Since(startedAt)
+	answer, usage, err := s.llm.Chat(ctx, messages, retrievedDocs)
 	if err != nil {
 		return nil, fmt.Errorf("LLM 응답 생성 실패: %w", err)
 	}
+	llmTime := time.Since(startedAt) - retrievalTime
+
+	s.enqueueAnalytics(analyticsEvent{answer: &answerEvent{
+		at:        time.Now(),
+		userID:    req.UserID,
+		message:   req.Message,
+		answer:    answer,
+		docs:      retrievedDocs,
+		usage:     usage,
+		bestScore: bestScore,
+	}})
+
+	resp := &rag.ChatResponse{
+		Answer:           answer,
+		ConversationID:   req.ConversationID,
+		Sources:          retrievedDocs,
+		TokensUsed:       usage.TotalTokens,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Model:            usage.Model,
+		RetrievalTime:    retrievalTime,
+		LLMTime:          llmTime,
+	}
+	if vectorFailed {
+		resp.Degraded = true
+		resp.Warning = "벡터 검색을 사용할 수 없어 전문 검색 결과만 사용했습니다"
+	}
+
+	return resp, nil
+}
 
-	if s.analytics != nil {
-		s.analytics.Record(ctx, req.Message, retrievedDocs)
+// ValidateEmbeddingDimensions embeds a probe text with each space's model and
+// fails when the output size differs from the vector size the store reports,
+// instead of letting every upsert fail later.
+func (s *ChatbotService) ValidateEmbeddingDimensions(ctx context.Context) error {
+	stats, err := s.vectorStore.CollectionStats(ctx)
+	if err != nil {
+		return fmt.Errorf("벡터 저장소 정보 조회 실패: %w", err)
 	}
 
-	return &rag.ChatResponse{
-		Answer:         answer,
-		ConversationID: req.ConversationID,
-		Sources:        retrievedDocs,
-		TokensUsed:     tokensUsed,
-	}, nil
+	for _, info := range stats.Vectors {
+		model := ""
+		if info.Name != "" {
+			space, ok := s.vectorStore.Space(info.Name)
+			if !ok {
+				continue
+			}
+			model = space.Model
+		}
+
+		probe, err := s.llm.GenerateEmbeddingWithModel(ctx, "dimension probe", model)
+		if err != nil {
+			return fmt.Errorf("임베딩 모델 확인 실패 (vector=%q): %w", info.Name, err)
+		}
+		if uint64(len(probe)) != info.Size {
+			return fmt.Errorf("임베딩 차원 불일치 (vector=%q): 모델은 %d차원, 벡터 저장소는 %d차원입니다. QDRANT_VECTOR_SIZE/QDRANT_NAMED_VECTORS 또는 임베딩 모델 설정을 확인하세요",
+				info.Name, len(probe), info.Size)
+		}
+	}
+
+	return nil
+}
+
+// Readiness checks the vector store and the full-text index. A down vector
+// store only degrades retrieval; without full-text search the service is
+// unavailable.
+func (s *ChatbotService) Readiness(ctx context.Context) *rag.ReadinessReport {
+	report := &rag.ReadinessReport{Status: rag.ReadinessReady}
+
+	check := func(name string, err error) bool {
+		component := rag.ComponentHealth{Name: name, Status: "up"}
+		if err != nil {
+			component.Status = "down"
+			component.Error = err.Error()
+		}
+		report.Components = append(report.Components, component)
+		return err == nil
+	}
+
+	vectorUp := check("vectorStore", s.vectorStore.Health(ctx))
+	fullTextUp := check("fullText", s.fullText.Health(ctx))
+
+	switch {
+	case !fullTextUp:
+		report.Status = rag.ReadinessUnavailable
+	case !vectorUp:
+		report.Status = rag.ReadinessDegraded
+	}
+	return report
 }
 
-func (s *ChatbotService) searchByVector(ctx context.Context, query string, topK int) ([]rag.Document, error) {
-	// 쿼리를 벡터로 변환
-	vector, err := s.llm.GenerateEmbedding(ctx, query)
+// embedQuery embeds the query with the model of the space it searches and
+// returns the resolved space name.
+func (s *ChatbotService) embedQuery(ctx context.Context, query, space string) (string, []float32, error) {
+	// 쿼리는 검색할 벡터 공간과 같은 모델로 임베딩
+	model := ""
+	if len(s.vectorStore.Spaces()) > 0 {
+		vs, ok := s.vectorStore.Space(space)
+		if !ok {
+			return "", nil, fmt.Errorf("알 수 없는 벡터 공간입니다: %s", space)
+		}
+		space, model = vs.Name, vs.Model
+	}
+
+	vector, err := s.llm.GenerateEmbeddingWithModel(ctx, query, model)
 	if err != nil {
-		return nil, fmt.Errorf("임베딩 생성 실패: %w", err)
+		return "", nil, fmt.Errorf("임베딩 생성 실패: %w", err)
+	}
+	return space, vector, nil
+}
+
+func (s *ChatbotService) searchHybrid(ctx context.Context, hybrid vectorstore.HybridSearcher, query, space string, topK int, filters *rag.SearchFilters) ([]rag.Document, error) {
+	space, vector, err := s.embedQuery(ctx, query, space)
+	if err != nil {
+		return nil, err
+	}
+
+	return hybrid.HybridSearch(ctx, space, vector, query, topK, filters)
+}
+
+func (s *ChatbotService) searchByVector(ctx context.Context, query, space string, topK int, filters *rag.SearchFilters) ([]rag.Document, error) {
+	space, vector, err := s.embedQuery(ctx, query, space)
+	if err != nil {
+		return nil, err
 	}
 
 	// 벡터 검색
-	docs, err := s.vectorStore.Search(ctx, vector, topK)
+	docs, err := s.vectorStore.Search(ctx, space, vector, topK, filters)
 	if err != nil {
 		return nil, fmt.Errorf("벡터 검색 실패: %w", err)
 	}
@@ -111,8 +287,8 @@ func (s *ChatbotService) searchByVector(ctx context.Context, query string, topK
 	return docs, nil
 }
 
-func (s *ChatbotService) searchByFullText(ctx context.Context, query string, topK int) ([]rag.Document, error) {
-	docs, err := s.fullText.Search(ctx, query, topK)
+func (s *ChatbotService) searchByFullText(ctx context.Context, query string, topK int, filters *rag.SearchFilters) ([]rag.Document, error) {
+	docs, err := s.fullText.Search(ctx, query, topK, filters)
 	if err != nil {
 		return nil, fmt.Errorf("전문 검색 실패: %w", err)
 	}
@@ -149,48 +325,106 @@ func (s *ChatbotService) deduplicateAndRank(docs []rag.Document, topK int) []rag
 
 func (s *ChatbotService) AddDocument(ctx context.Context, doc rag.Document) error {
 	s.enrichDocumentMetadata(ctx, &doc)
+	stampTimestamps(&doc, s.existingCreatedAt(ctx, doc.ID))
 
 	// OpenSearch에 추가 (전체 문서)
 	if err := s.fullText.AddDocument(ctx, doc); err != nil {
 		return fmt.Errorf("OpenSearch 문서 추가 실패: %w", err)
 	}
 
-	// 텍스트가 너무 길면 청크로 나눔
-	chunks := s.splitTextIntoChunks(doc.Content, 6000) // ~6000 tokens max per chunk
+	rag.ReportProgress(ctx, rag.StageEmbedding, 0, 1)
+	vectors, err := s.embedVectors(ctx, doc)
+	if err != nil {
+		return err
+	}
 
-	if len(chunks) == 1 {
-		// 단일 청크: 그대로 임베딩
-		vector, err := s.llm.GenerateEmbedding(ctx, doc.Content)
+	rag.ReportProgress(ctx, rag.StageIndexing, 0, 1)
+	if err := s.vectorStore.AddDocument(ctx, doc, vectors); err != nil {
+		return fmt.Errorf("Qdrant 문서 추가 실패: %w", err)
+	}
+
+	slog.InfoContext(ctx, "문서 추가 완료", "id", doc.ID)
+	return nil
+}
+
+// embedVectors builds the embeddings for every configured vector space.
+// Spaces whose source text is empty are left out of the point.
+func (s *ChatbotService) embedVectors(ctx context.Context, doc rag.Document) (rag.Vectors, error) {
+	spaces := s.vectorStore.Spaces()
+	if len(spaces) == 0 {
+		vector, err := s.embedText(ctx, doc.ID, doc.Content, "")
 		if err != nil {
-			return fmt.Errorf("임베딩 생성 실패: %w", err)
+			return nil, err
 		}
+		return rag.Vectors{"": vector}, nil
+	}
 
-		if err := s.vectorStore.AddDocument(ctx, doc, vector); err != nil {
-			return fmt.Errorf("Qdrant 문서 추가 실패: %w", err)
+	vectors := make(rag.Vectors, len(spaces))
+	for _, space := range spaces {
+		text := doc.Content
+		if space.Source != "content" {
+			text, _ = doc.Metadata[space.Source].(string)
 		}
-	} else {
-		// 여러 청크: 각 청크마다 임베딩 생성하고 평균 계산
-		slog.Info("문서가 크므로 청크로 분할", "id", doc.ID, "chunks", len(chunks))
-
-		vectors := make([][]float32, len(chunks))
-		for i, chunk := range chunks {
-			vector, err := s.llm.GenerateEmbedding(ctx, chunk)
-			if err != nil {
-				return fmt.Errorf("청크 %d 임베딩 생성 실패: %w", i, err)
-			}
-			vectors[i] = vector
+		if strings.TrimSpace(text) == "" {
+			continue
 		}
 
-		// 벡터 평균 계산
-		avgVector := s.averageVectors(vectors)
+		vector, err := s.embedText(ctx, doc.ID, text, space.Model)
+		if err != nil {
+			return nil, fmt.Errorf("%s 벡터: %w", space.Name, err)
+		}
+		vectors[space.Name] = vector
+	}
+	return vectors, nil
+}
+
+// embedText embeds text, averaging chunk embeddings when the text is too long
+// for a single request.
+func (s *ChatbotService) embedText(ctx context.Context, docID, text, model string) ([]float32, error) {
+	// 텍스트가 너무 길면 청크로 나눔
+	chunks := s.splitTextIntoChunks(text, 6000) // ~6000 tokens max per chunk
 
-		if err := s.vectorStore.AddDocument(ctx, doc, avgVector); err != nil {
-			return fmt.Errorf("Qdrant 문서 추가 실패: %w", err)
+	if len(chunks) == 1 {
+		vector, err := s.llm.GenerateEmbeddingWithModel(ctx, text, model)
+		if err != nil {
+			return nil, fmt.Errorf("임베딩 생성 실패: %w", err)
 		}
+		return vector, nil
 	}
 
-	slog.Info("문서 추가 완료", "id", doc.ID)
-	return nil
+	// 여러 청크: 각 청크마다 임베딩 생성하고 평균 계산
+	slog.InfoContext(ctx, "문서가 크므로 청크로 분할", "id", docID, "chunks", len(chunks))
+
+	vectors := make([][]float32, len(chunks))
+	for i, chunk := range chunks {
+		vector, err := s.llm.GenerateEmbeddingWithModel(ctx, chunk, model)
+		if err != nil {
+			return nil, fmt.Errorf("청크 %d 임베딩 생성 실패: %w", i, err)
+		}
+		vectors[i] = vector
+	}
+
+	return s.averageVectors(vectors), nil
+}
+
+// existingCreatedAt returns the createdAt of the stored document with id, or
+// "" when there is none, so re-adding a document keeps its creation time.
+func (s *ChatbotService) existingCreatedAt(ctx context.Context, id string) string {
+	if existing, err := s.fullText.GetDocument(ctx, id); err == nil {
+		return existing.CreatedAt
+	}
+	return ""
+}
+
+// stampTimestamps sets server-side timestamps, keeping createdAt when the
+// document already exists.
+func stampTimestamps(doc *rag.Document, createdAt string) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	if createdAt == "" {
+		createdAt = now
+	}
+	doc.CreatedAt = createdAt
+	doc.UpdatedAt = now
 }
 
 // splitTextIntoChunks splits text into chunks of approximately maxChars characters
@@ -246,8 +480,22 @@ func (s *ChatbotService) averageVectors(vectors [][]float32) []float32 {
 }
 
 func (s *ChatbotService) BulkAddDocuments(ctx context.Context, docs []rag.Document) error {
+	ids := make([]string, len(docs))
+	for i := range docs {
+		ids[i] = docs[i].ID
+	}
+	createdAt := make(map[string]string)
+	existing, err := s.fullText.FetchDocuments(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("기존 문서 조회 실패: %w", err)
+	}
+	for _, doc := range existing {
+		createdAt[doc.ID] = doc.CreatedAt
+	}
+
 	for i := range docs {
 		s.enrichDocumentMetadata(ctx, &docs[i])
+		stampTimestamps(&docs[i], createdAt[docs[i].ID])
 	}
 
 	// OpenSearch 벌크 인덱싱
@@ -255,21 +503,26 @@ func (s *ChatbotService) BulkAddDocuments(ctx context.Context, docs []rag.Docume
 		return fmt.Errorf("OpenSearch 벌크 인덱싱 실패: %w", err)
 	}
 
-	// Qdrant에 개별 추가
-	for _, doc := range docs {
-		vector, err := s.llm.GenerateEmbedding(ctx, doc.Content)
+	// Qdrant에 배치 업서트
+	embedded := make([]rag.Document, 0, len(docs))
+	vectors := make([]rag.Vectors, 0, len(docs))
+	for i, doc := range docs {
+		rag.ReportProgress(ctx, rag.StageEmbedding, i, len(docs))
+		docVectors, err := s.embedVectors(ctx, doc)
 		if err != nil {
-			slog.Error("임베딩 생성 실패", "id", doc.ID, "error", err)
+			slog.ErrorContext(ctx, "임베딩 생성 실패", "id", doc.ID, "error", err)
 			continue
 		}
+		embedded = append(embedded, doc)
+		vectors = append(vectors, docVectors)
+	}
 
-		if err := s.vectorStore.AddDocument(ctx, doc, vector); err != nil {
-			slog.Error("Qdrant 문서 추가 실패", "id", doc.ID, "error", err)
-			continue
-		}
+	rag.ReportProgress(ctx, rag.StageIndexing, 0, len(docs))
+	if written, err := s.vectorStore.UpsertBatch(ctx, embedded, vectors); err != nil {
+		slog.ErrorContext(ctx, "Qdrant 배치 업서트 실패", "written", written, "total", len(embedded), "error", err)
 	}
 
-	slog.Info("벌크 문서 추가 완료", "count", len(docs))
+	slog.InfoContext(ctx, "벌크 문서 추가 완료", "count", len(docs))
 	return nil
 }
 
@@ -277,23 +530,30 @@ func (s *ChatbotService) ListDocuments(ctx context.Context, params *rag.Document
 	return s.fullText.ListDocuments(ctx, params)
 }
 
+// SuggestDocuments completes prefix from the documents roles may see, any
+// document for nil roles.
+func (s *ChatbotService) SuggestDocuments(ctx context.Context, prefix string, limit int, roles []string) ([]rag.Suggestion, error) {
+	return s.fullText.Suggest(ctx, prefix, limit, roles)
+}
+
 func (s *ChatbotService) GetDocument(ctx context.Context, id string) (*rag.Document, error) {
 	return s.fullText.GetDocument(ctx, id)
 }
 
 func (s *ChatbotService) UpdateDocument(ctx context.Context, doc rag.Document) error {
 	s.enrichDocumentMetadata(ctx, &doc)
+	stampTimestamps(&doc, s.existingCreatedAt(ctx, doc.ID))
 
 	if err := s.fullText.UpdateDocument(ctx, doc); err != nil {
 		return fmt.Errorf("OpenSearch 문서 업데이트 실패: %w", err)
 	}
 
-	vector, err := s.llm.GenerateEmbedding(ctx, doc.Content)
+	vectors, err := s.embedVectors(ctx, doc)
 	if err != nil {
-		return fmt.Errorf("임베딩 생성 실패: %w", err)
+		return err
 	}
 
-	if err := s.vectorStore.AddDocument(ctx, doc, vector); err != nil {
+	if err := s.vectorStore.AddDocument(ctx, doc, vectors); err != nil {
 		return fmt.Errorf("Qdrant 문서 업데이트 실패: %w", err)
 	}
 
@@ -312,6 +572,27 @@ func (s *ChatbotService) DeleteDocument(ctx context.Context, id string) error {
 	return nil
 }
 
+// DeleteDocumentsByFilter removes every document and vector whose metadata
+// matches the filter, without listing IDs first.
+func (s *ChatbotService) DeleteDocumentsByFilter(ctx context.Context, filter map[string]string) (*rag.DeleteByFilterResult, error) {
+	deleter, ok := s.vectorStore.(vectorstore.FilterDeleter)
+	if !ok {
+		return nil, vectorstore.ErrUnsupported
+	}
+
+	documents, err := s.fullText.DeleteByMetadata(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("OpenSearch 조건 삭제 실패: %w", err)
+	}
+
+	vectors, err := deleter.DeleteByFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rag.DeleteByFilterResult{Documents: documents, Vectors: vectors}, nil
+}
+
 func (s *ChatbotService) ReindexDocuments(ctx context.Context, ids []string) (*rag.ReindexResult, error) {
 	if len(ids) == 0 {
 		return nil, fmt.Errorf("재색인할 문서 ID가 없습니다")
@@ -331,6 +612,9 @@ func (s *ChatbotService) ReindexDocuments(ctx context.Context, ids []string) (*r
 		existing[doc.ID] = doc
 	}
 
+	var pending []rag.Document
+	var vectors []rag.Vectors
+
 	for _, id := range ids {
 		doc, ok := existing[id]
 		if !ok {
@@ -340,118 +624,219 @@ func (s *ChatbotService) ReindexDocuments(ctx context.Context, ids []string) (*r
 
 		// Enrich metadata (category classification, etc.)
 		s.enrichDocumentMetadata(ctx, &doc)
+		if doc.CreatedAt == "" {
+			stampTimestamps(&doc, "")
+		}
 
 		// Update OpenSearch
 		if err := s.fullText.AddDocument(ctx, doc); err != nil {
-			slog.Error("OpenSearch 재색인 실패", "id", doc.ID, "error", err)
+			slog.ErrorContext(ctx, "OpenSearch 재색인 실패", "id", doc.ID, "error", err)
 			result.Failed = append(result.Failed, doc.ID)
 			continue
 		}
 
-		// Handle chunking for large documents (same as AddDocument)
-		chunks := s.splitTextIntoChunks(doc.Content, 6000)
+		docVectors, err := s.embedVectors(ctx, doc)
+		if err != nil {
+			slog.ErrorContext(ctx, "임베딩 생성 실패", "id", doc.ID, "error", err)
+			result.Failed = append(result.Failed, doc.ID)
+			continue
+		}
 
-		if len(chunks) == 1 {
-			// Single chunk: direct embedding
-			vector, err := s.llm.GenerateEmbedding(ctx, doc.Content)
-			if err != nil {
-				slog.Error("임베딩 생성 실패", "id", doc.ID, "error", err)
-				result.Failed = append(result.Failed, doc.ID)
-				continue
-			}
+		pending = append(pending, doc)
+		vectors = append(vectors, docVectors)
+	}
 
-			if err := s.vectorStore.AddDocument(ctx, doc, vector); err != nil {
-				slog.Error("Qdrant 재색인 실패", "id", doc.ID, "error", err)
-				result.Failed = append(result.Failed, doc.ID)
-				continue
-			}
-		} else {
-			// Multiple chunks: generate embeddings and average
-			slog.Info("재색인 중 문서 청크 분할", "id", doc.ID, "chunks", len(chunks))
-
-			vectors := make([][]float32, len(chunks))
-			for i, chunk := range chunks {
-				vector, err := s.llm.GenerateEmbedding(ctx, chunk)
-				if err != nil {
-					slog.Error("청크 임베딩 생성 실패", "id", doc.ID, "chunk", i, "error", err)
-					result.Failed = append(result.Failed, doc.ID)
-					continue
-				}
-				vectors[i] = vector
-			}
+	written, err := s.vectorStore.UpsertBatch(ctx, pending, vectors)
+	if err != nil {
+		slog.ErrorContext(ctx, "Qdrant 재색인 실패", "written", written, "total", len(pending), "error", err)
+		for _, doc := range pending[written:] {
+			result.Failed = append(result.Failed, doc.ID)
+		}
+	}
+	result.Reindexed = written
 
-			// Skip if any chunk failed
-			if len(vectors) != len(chunks) {
-				continue
-			}
+	return result, nil
+}
 
-			// Average vectors
-			avgVector := s.averageVectors(vectors)
-			if err := s.vectorStore.AddDocument(ctx, doc, avgVector); err != nil {
-				slog.Error("Qdrant 재색인 실패 (평균 벡터)", "id", doc.ID, "error", err)
-				result.Failed = append(result.Failed, doc.ID)
-				continue
-			}
-		}
+// MigrateSearchIndex rebuilds the full-text index with the configured analyzer.
+func (s *ChatbotService) MigrateSearchIndex(ctx context.Context) (*rag.IndexMigrationResult, error) {
+	return s.fullText.MigrateIndex(ctx)
+}
+
+func (s *ChatbotService) GetDocumentAggregations(ctx context.Context, size int) (*rag.DocumentAggregations, error) {
+	return s.fullText.Aggregations(ctx, size)
+}
+
+func (s *ChatbotService) GetDetailedIndexStats(ctx context.Context) (*rag.DetailedIndexStats, error) {
+	return s.fullText.DetailedStats(ctx)
+}
 
-		result.Reindexed++
+func (s *ChatbotService) GetVectorStats(ctx context.Context) (*rag.VectorCollectionStats, error) {
+	stats, err := s.vectorStore.CollectionStats(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	docStats, err := s.fullText.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.SearchIndex = docStats.Index
+	stats.SearchDocuments = docStats.TotalDocuments
+	stats.Drift = int64(stats.PointsCount) - docStats.TotalDocuments
+
+	return stats, nil
+}
+
+// snapshots returns the vector store's snapshot support, if any.
+func (s *ChatbotService) snapshots() (vectorstore.SnapshotStore, error) {
+	store, ok := s.vectorStore.(vectorstore.SnapshotStore)
+	if !ok {
+		return nil, vectorstore.ErrUnsupported
+	}
+	return store, nil
+}
+
+func (s *ChatbotService) CreateVectorSnapshot(ctx context.Context) (*rag.VectorSnapshot, error) {
+	store, err := s.snapshots()
+	if err != nil {
+		return nil, err
+	}
+	return store.CreateSnapshot(ctx)
+}
+
+func (s *ChatbotService) ListVectorSnapshots(ctx context.Context) ([]rag.VectorSnapshot, error) {
+	store, err := s.snapshots()
+	if err != nil {
+		return nil, err
+	}
+	return store.ListSnapshots(ctx)
+}
+
+func (s *ChatbotService) DownloadVectorSnapshot(ctx context.Context, name string) (io.ReadCloser, int64, error) {
+	store, err := s.snapshots()
+	if err != nil {
+		return nil, 0, err
+	}
+	return store.DownloadSnapshot(ctx, name)
+}
+
+func (s *ChatbotService) RestoreVectorSnapshot(ctx context.Context, collection, filename string, snapshot io.Reader) error {
+	store, err := s.snapshots()
+	if err != nil {
+		return err
+	}
+	return store.RestoreSnapshot(ctx, collection, filename, snapshot)
 }
 
 func (s *ChatbotService) GetDocumentStats(ctx context.Context) (*rag.DocumentStats, error) {
 	return s.fullText.GetStats(ctx)
 }
 
-func (s *ChatbotService) GetDashboardStats(ctx context.Context) (*rag.DashboardStats, error) {
-	stats := &rag.DashboardStats{}
+// GetDashboardStats reports current totals and activity over the last
+// period, with trends as percent change against the period before it.
+// Each figure is best effort: a failing backend leaves its fields zero.
+func (s *ChatbotService) GetDashboardStats(ctx context.Context, period time.Duration) (*rag.DashboardStats, error) {
+	now := time.Now()
+	start := now.Add(-period)
+	stats := &rag.DashboardStats{PeriodHours: int(period.Hours())}
 
-	// Get total documents
 	if docStats, err := s.fullText.GetStats(ctx); err == nil {
 		stats.TotalDocuments = docStats.TotalDocuments
+		added, err := s.fullText.CountDocuments(ctx, &rag.SearchFilters{UploadedAfter: &start})
+		if err == nil {
+			stats.DocumentsTrend = calculatePercentChange(float64(stats.TotalDocuments-added), float64(stats.TotalDocuments))
+		} else {
+			slog.WarnContext(ctx, "기간 내 문서 수 조회 실패", "error", err)
+		}
+	} else {
+		slog.WarnContext(ctx, "문서 수 조회 실패", "error", err)
 	}
 
-	// Get total conversations (only those with messages)
 	if s.convRepo != nil {
-		if conversations, err := s.convRepo.List(ctx, 10000); err == nil {
-			stats.TotalConversations = int64(len(conversations))
+		total, err := s.convRepo.Count(ctx, now)
+		previous, prevErr := s.convRepo.Count(ctx, start)
+		if err == nil && prevErr == nil {
+			stats.TotalConversations = total
+			stats.ConversationsTrend = calculatePercentChange(float64(previous), float64(total))
+		} else {
+			slog.WarnContext(ctx, "대화 수 조회 실패", "error", errors.Join(err, prevErr))
 		}
 	}
 
-	// Get active users (within last 24 hours)
 	if s.analytics != nil && s.analytics.store != nil {
-		if activeUsers, err := s.analytics.store.GetActiveUsers(ctx, 1440); err == nil {
-			stats.ActiveUsers = activeUsers
+		if latency, err := s.analytics.store.GetLatency(ctx, start, now); err == nil {
+			stats.Latency = latency
+		} else {
+			slog.WarnContext(ctx, "응답 지연 백분위 조회 실패", "error", err)
 		}
-	}
 
-	// Get average response time (within last 24 hours)
-	if s.analytics != nil && s.analytics.store != nil {
-		if avgTime, err := s.analytics.store.GetAvgResponseTime(ctx, 24); err == nil {
-			stats.AvgResponseTime = avgTime
+		current, err := s.analytics.store.GetActivity(ctx, start, now)
+		previous, prevErr := s.analytics.store.GetActivity(ctx, start.Add(-period), start)
+		if err == nil && prevErr == nil {
+			stats.ActiveUsers = current.ActiveUsers
+			stats.AvgResponseTime = current.AvgResponseTime
+			stats.ActiveUsersTrend = calculatePercentChange(float64(previous.ActiveUsers), float64(current.ActiveUsers))
+			stats.ResponseTimeTrend = calculatePercentChange(previous.AvgResponseTime, current.AvgResponseTime)
+		} else {
+			slog.WarnContext(ctx, "사용 지표 조회 실패", "error", errors.Join(err, prevErr))
 		}
 	}
 
-	// Calculate trends (compare with yesterday)
-	if s.analytics != nil && s.analytics.store != nil {
-		if yesterday, err := s.analytics.store.GetDailyStats(ctx, 1); err == nil && yesterday != nil {
-			if yesterday.TotalDocuments > 0 {
-				stats.DocumentsTrend = calculatePercentChange(float64(yesterday.TotalDocuments), float64(stats.TotalDocuments))
-			}
-			if yesterday.TotalConversations > 0 {
-				stats.ConversationsTrend = calculatePercentChange(float64(yesterday.TotalConversations), float64(stats.TotalConversations))
-			}
-			if yesterday.ActiveUsers > 0 {
-				stats.ActiveUsersTrend = calculatePercentChange(float64(yesterday.ActiveUsers), float64(stats.ActiveUsers))
-			}
-			if yesterday.AvgResponseTime > 0 && stats.AvgResponseTime > 0 {
-				stats.ResponseTimeTrend = calculatePercentChange(yesterday.AvgResponseTime, stats.AvgResponseTime)
-			}
-		}
+	return stats, nil
+}
+
+// AlertCounters returns the answers and OpenAI requests since start and how
+// many of them failed.
+func (s *ChatbotService) AlertCounters() alert.Counters {
+	counters := alert.Counters{
+		ChatRequests: s.chatRequests.Load(),
+		ChatErrors:   s.chatErrors.Load(),
 	}
+	if s.llm != nil {
+		counters.LLMRequests, counters.LLMFailures = s.llm.RequestCounts()
+	}
+	return counters
+}
 
-	return stats, nil
+// P95Latency is the 95th percentile response time of the answers in
+// [from, to) whose response metrics are not rolled up yet.
+func (s *ChatbotService) P95Latency(ctx context.Context, from, to time.Time) (time.Duration, int64, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return 0, 0, errAnalyticsStoreMissing
+	}
+	stats, err := s.analytics.store.GetLatency(ctx, from, to)
+	if err != nil || stats.Chat == nil {
+		return 0, 0, err
+	}
+	return time.Duration(stats.Chat.P95 * float64(time.Millisecond)), stats.Samples, nil
+}
+
+// liveActiveMinutes is how recently a chat session must have been active to
+// count in live dashboard updates.
+const liveActiveMinutes = 5
+
+// GetDashboardDelta reports the chat activity in [from, to) for live
+// dashboard updates.
+func (s *ChatbotService) GetDashboardDelta(ctx context.Context, from, to time.Time) (*rag.DashboardDelta, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, errAnalyticsStoreMissing
+	}
+	activity, err := s.analytics.store.GetActivity(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.analytics.store.GetActiveUsers(ctx, liveActiveMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("get active users failed: %w", err)
+	}
+	return &rag.DashboardDelta{
+		From:            from,
+		To:              to,
+		Messages:        activity.Responses,
+		ActiveUsers:     active,
+		AvgResponseTime: activity.AvgResponseTime,
+	}, nil
 }
 
 func calculatePercentChange(oldValue, newValue float64) float64 {
@@ -477,7 +862,7 @@ func (s *ChatbotService) QueryDocumentVectors(ctx context.Context, req *rag.Vect
 			return nil, fmt.Errorf("문서 벡터 조회 실패: %w", err)
 		}
 
-		if len(vectors) == 0 {
+		if len(vectors) == 0 || !rag.RolesAllow(vectors[0].Metadata, req.Roles) {
 			return &rag.VectorQueryResponse{
 				Vectors:    []rag.DocumentVector{},
 				Count:      0,
@@ -492,7 +877,11 @@ func (s *ChatbotService) QueryDocumentVectors(ctx context.Context, req *rag.Vect
 			limit = 5
 		}
 
-		similarDocs, err := s.vectorStore.Search(ctx, vectors[0].Vector, limit+1) // +1 to account for self
+		var filters *rag.SearchFilters
+		if req.Roles != nil {
+			filters = &rag.SearchFilters{Roles: req.Roles}
+		}
+		similarDocs, err := s.vectorStore.Search(ctx, "", vectors[0].Vector, limit+1, filters) // +1 to account for self
 		if err != nil {
 			return nil, fmt.Errorf("유사 문서 검색 실패: %w", err)
 		}
@@ -523,11 +912,26 @@ func (s *ChatbotService) QueryDocumentVectors(ctx context.Context, req *rag.Vect
 		}, nil
 	}
 
-	// If no DocumentIDs, return all vectors (original behavior)
-	vectors, hasMore, nextOffset, err := s.vectorStore.QueryDocumentVectors(ctx, req.DocumentIDs, req.Limit, req.WithPayload, req.Offset)
+	// If no DocumentIDs, return all vectors (original behavior). Restricted
+	// callers need the payloads to leave out what they may not see.
+	withPayload := req.WithPayload || req.Roles != nil
+	vectors, hasMore, nextOffset, err := s.vectorStore.QueryDocumentVectors(ctx, req.DocumentIDs, req.Limit, withPayload, req.Offset)
 	if err != nil {
 		return nil, err
 	}
+	if req.Roles != nil {
+		visible := vectors[:0]
+		for _, v := range vectors {
+			if !rag.RolesAllow(v.Metadata, req.Roles) {
+				continue
+			}
+			if !req.WithPayload {
+				v.Content, v.Metadata = "", nil
+			}
+			visible = append(visible, v)
+		}
+		vectors = visible
+	}
 
 	return &rag.VectorQueryResponse{
 		Vectors:    vectors,
@@ -537,29 +941,67 @@ func (s *ChatbotService) QueryDocumentVectors(ctx context.Context, req *rag.Vect
 	}, nil
 }
 
+// ConversationHistory returns the messages of conversationID from the
+// history cache, which loads them from the conversation store on a miss.
 func (s *ChatbotService) ConversationHistory(conversationID string) []rag.ChatMessage {
-	if s.conversations == nil || conversationID == "" {
+	if conversationID == "" {
 		return nil
 	}
-	return s.conversations.History(conversationID)
+	history, err := s.conversations.History(context.Background(), conversationID)
+	if err != nil {
+		slog.Warn("대화 기록 조회 실패", "error", err, "conversationID", conversationID)
+		return nil
+	}
+	return history
 }
 
-func (s *ChatbotService) AppendConversationMessage(conversationID string, msg rag.ChatMessage) {
-	if s.conversations == nil || conversationID == "" {
+// SaveExchange stores a question asked at askedAt and its answer, and adds
+// the tokens used to the conversation.
+func (s *ChatbotService) SaveExchange(ctx context.Context, conversationID, question string, askedAt time.Time, resp *rag.ChatResponse) {
+	if conversationID == "" {
 		return
 	}
-	s.conversations.Append(conversationID, msg)
-
-	if s.convRepo != nil {
-		_ = s.convRepo.AddMessage(context.Background(), conversationID, msg.Role, msg.Content, time.Now().UTC())
+	// The exchange is kept even when the caller's context ends meanwhile.
+	ctx = context.WithoutCancel(ctx)
+	if err := s.conversations.Append(ctx, conversationID, ConversationMessage{Role: "user", Content: question, Timestamp: askedAt}); err != nil {
+		slog.WarnContext(ctx, "대화 메시지 저장 실패", "error", err, "conversationID", conversationID)
+		return
+	}
+	answeredAt := time.Now().UTC()
+	if !answeredAt.After(askedAt) {
+		answeredAt = askedAt.Add(time.Microsecond)
+	}
+	answer := ConversationMessage{
+		Role:             "assistant",
+		Content:          resp.Answer,
+		Timestamp:        answeredAt,
+		Model:            resp.Model,
+		PromptTokens:     resp.PromptTokens,
+		CompletionTokens: resp.CompletionTokens,
+	}
+	for _, doc := range resp.Sources {
+		filename, _ := doc.Metadata["filename"].(string)
+		answer.Sources = append(answer.Sources, ConversationSource{DocumentID: doc.ID, Filename: filename, Score: doc.Score})
+	}
+	if err := s.conversations.Append(ctx, conversationID, answer); err != nil {
+		slog.WarnContext(ctx, "대화 메시지 저장 실패", "error", err, "conversationID", conversationID)
+		return
+	}
+	if s.convRepo == nil {
+		return
+	}
+	if err := s.convRepo.UpdateTokenUsage(ctx, conversationID, resp.TokensUsed); err != nil {
+		slog.WarnContext(ctx, "토큰 사용량 저장 실패", "error", err, "conversationID", conversationID)
 	}
 }
 
+// CloseConversation drops the cached history of conversationID; it is
+// loaded again from the conversation store when needed.
 func (s *ChatbotService) CloseConversation(conversationID string) {
-	if s.conversations == nil || conversationID == "" {
+	if conversationID == "" {
 		return
 	}
-	s.conversations.End(conversationID)
+	s.conversations.Invalidate(conversationID)
 }
 
 func (s *ChatbotService) EnsureConversation(conversationID string) {
@@ -568,9 +1010,10 @@ func (s *ChatbotService) EnsureConversation(conversationID string) {
 	}
 }
 
-func (s *ChatbotService) RecordTokenUsage(conversationID string, tokens int) {
-	if s.convRepo != nil && conversationID != "" {
-		_ = s.convRepo.UpdateTokenUsage(context.Background(), conversationID, tokens)
+// SetConversationOwner attributes a conversation to the user who started it.
+func (s *ChatbotService) SetConversationOwner(conversationID, ownerID, ownerName string) {
+	if s.convRepo != nil && conversationID != "" && ownerID != "" {
+		_ = s.convRepo.SetOwner(context.Background(), conversationID, ownerID, ownerName)
 	}
 }
 
@@ -581,12 +1024,12 @@ func (s *ChatbotService) GenerateAndSetConversationTitle(ctx context.Context, co
 
 	title, err := s.llm.GenerateConversationTitle(ctx, firstMessage)
 	if err != nil {
-		slog.Warn("대화 제목 생성 실패", "error", err, "conversationID", conversationID)
+		slog.WarnContext(ctx, "대화 제목 생성 실패", "error", err, "conversationID", conversationID)
 		return
 	}
 
 	if err := s.convRepo.UpdateTitle(ctx, conversationID, title); err != nil {
-		slog.Warn("대화 제목 업데이트 실패", "error", err, "conversationID", conversationID)
+		slog.WarnContext(ctx, "대화 제목 업데이트 실패", "error", err, "conversationID", conversationID)
 	}
 }
 
@@ -594,21 +1037,39 @@ func (s *ChatbotService) RecordSessionActivity(ctx context.Context, sessionID, c
 	if s.analytics == nil || s.analytics.store == nil {
 		return
 	}
-	_ = s.analytics.store.RecordSession(ctx, sessionID, conversationID)
+	s.enqueueAnalytics(analyticsEvent{session: &sessionEvent{sessionID: sessionID, conversationID: conversationID}})
 }
 
-func (s *ChatbotService) RecordResponseMetrics(ctx context.Context, conversationID string, responseTimeMs, tokenCount int) {
+// RecordResponseMetrics stores how long resp took to answer in total and
+// in its retrieval and LLM parts.
+func (s *ChatbotService) RecordResponseMetrics(ctx context.Context, conversationID string, responseTime time.Duration, resp *rag.ChatResponse) {
 	if s.analytics == nil || s.analytics.store == nil {
 		return
 	}
-	_ = s.analytics.store.RecordResponseTime(ctx, conversationID, responseTimeMs, tokenCount)
+	s.enqueueAnalytics(analyticsEvent{metric: &ResponseMetric{
+		ConversationID: conversationID,
+		Timing: ResponseTiming{
+			ResponseMs:  int(responseTime.Milliseconds()),
+			RetrievalMs: int(resp.RetrievalTime.Milliseconds()),
+			LLMMs:       int(resp.LLMTime.Milliseconds()),
+		},
+		Tokens: resp.TokensUsed,
+		At:     time.Now(),
+	}})
 }
 
-func (s *ChatbotService) ListConversationSummaries(ctx context.Context, limit int) ([]ConversationSummary, error) {
+func (s *ChatbotService) ListConversationSummaries(ctx context.Context, filter ConversationFilter, limit int, cursor string) ([]ConversationSummary, pagination.Page, error) {
 	if s.convRepo == nil {
-		return nil, fmt.Errorf("conversation store not configured")
+		return nil, pagination.Page{}, fmt.Errorf("conversation store not configured")
 	}
-	return s.convRepo.List(ctx, limit)
+	return s.convRepo.List(ctx, filter, limit, cursor)
+}
+
+func (s *ChatbotService) GetConversationSummary(ctx context.Context, id string) (*ConversationSummary, error) {
+	if s.convRepo == nil {
+		return nil, ErrNoConversationStore
+	}
+	return s.convRepo.Get(ctx, id)
 }
 
 func (s *ChatbotService) GetConversationMessages(ctx context.Context, id string) ([]ConversationMessage, error) {
@@ -618,6 +1079,103 @@ func (s *ChatbotService) GetConversationMessages(ctx context.Context, id string)
 	return s.convRepo.Messages(ctx, id)
 }
 
+func (s *ChatbotService) SearchConversations(ctx context.Context, query, ownerID string, limit int, cursor string) ([]ConversationSearchResult, pagination.Page, error) {
+	if s.convRepo == nil {
+		return nil, pagination.Page{}, fmt.Errorf("conversation store not configured")
+	}
+	return s.convRepo.Search(ctx, query, ownerID, limit, cursor)
+}
+
+func (s *ChatbotService) SetConversationPinned(ctx context.Context, id string, pinned bool) error {
+	if s.convRepo == nil {
+		return fmt.Errorf("conversation store not configured")
+	}
+	return s.convRepo.SetPinned(ctx, id, pinned)
+}
+
+func (s *ChatbotService) SetConversationArchived(ctx context.Context, id string, archived bool) error {
+	if s.convRepo == nil {
+		return fmt.Errorf("conversation store not configured")
+	}
+	return s.convRepo.SetArchived(ctx, id, archived)
+}
+
+func (s *ChatbotService) ListConversationMessages(ctx context.Context, id string, limit int, cursor string, oldestFirst bool) ([]ConversationMessage, pagination.Page, error) {
+	if s.convRepo == nil {
+		return nil, pagination.Page{}, fmt.Errorf("conversation store not configured")
+	}
+	return s.convRepo.MessagePage(ctx, id, limit, cursor, oldestFirst)
+}
+
+// DeleteConversationMessage and RedactConversationMessage also drop the
+// in-memory history, so the next answer reloads it without the message.
+func (s *ChatbotService) DeleteConversationMessage(ctx context.Context, id, messageID string) error {
+	if s.convRepo == nil {
+		return fmt.Errorf("conversation store not configured")
+	}
+	if err := s.convRepo.DeleteMessage(ctx, id, messageID); err != nil {
+		return err
+	}
+	s.CloseConversation(id)
+	return nil
+}
+
+func (s *ChatbotService) RedactConversationMessage(ctx context.Context, id, messageID string) error {
+	if s.convRepo == nil {
+		return fmt.Errorf("conversation store not configured")
+	}
+	if err := s.convRepo.RedactMessage(ctx, id, messageID); err != nil {
+		return err
+	}
+	s.CloseConversation(id)
+	return nil
+}
+
+// summaryTranscriptLimit bounds the transcript sent for summarization, in
+// runes; longer conversations keep their latest messages.
+const summaryTranscriptLimit = 12000
+
+// SummarizeConversation asks the LLM for a summary and action items of the
+// conversation and stores them, returning the updated conversation.
+// Redacted messages are left out.
+func (s *ChatbotService) SummarizeConversation(ctx context.Context, id string) (*ConversationSummary, error) {
+	if s.convRepo == nil || s.llm == nil {
+		return nil, fmt.Errorf("conversation store not configured")
+	}
+	messages, err := s.convRepo.Messages(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, m := range messages {
+		if m.Redacted || strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+		speaker := "사용자"
+		if m.Role == "assistant" {
+			speaker = "챗봇"
+		}
+		lines = append(lines, speaker+": "+m.Content)
+	}
+	if len(lines) == 0 {
+		return nil, ErrConversationEmpty
+	}
+	transcript := []rune(strings.Join(lines, "\n\n"))
+	if len(transcript) > summaryTranscriptLimit {
+		transcript = transcript[len(transcript)-summaryTranscriptLimit:]
+	}
+
+	digest, err := s.llm.SummarizeConversation(ctx, string(transcript))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.convRepo.UpdateSummary(ctx, id, digest.Summary, digest.ActionItems); err != nil {
+		return nil, err
+	}
+	return s.convRepo.Get(ctx, id)
+}
+
 func (s *ChatbotService) DeleteConversation(ctx context.Context, id string) error {
 	if s.convRepo == nil {
 		return fmt.Errorf("conversation store not configured")
@@ -625,6 +1183,27 @@ func (s *ChatbotService) DeleteConversation(ctx context.Context, id string) erro
 	return s.convRepo.Delete(ctx, id)
 }
 
+// DescribeImage converts an image into indexable text using the vision model.
+func (s *ChatbotService) DescribeImage(ctx context.Context, data []byte, contentType string) (string, error) {
+	desc, err := s.llm.DescribeImage(ctx, data, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	builder.WriteString(desc.Description)
+	if desc.Text != "" {
+		builder.WriteString("\n\n[이미지 속 텍스트]\n")
+		builder.WriteString(desc.Text)
+	}
+
+	text := strings.TrimSpace(builder.String())
+	if text == "" {
+		return "", fmt.Errorf("이미지에서 색인할 내용을 추출하지 못했습니다")
+	}
+	return text, nil
+}
+
 func (s *ChatbotService) enrichDocumentMetadata(ctx context.Context, doc *rag.Document) {
 	if doc.Metadata == nil {
 		doc.Metadata = make(map[string]interface{})
@@ -636,7 +1215,7 @@ func (s *ChatbotService) enrichDocumentMetadata(ctx context.Context, doc *rag.Do
 
 	category, err := s.llm.ClassifyCategory(ctx, doc.Content)
 	if err != nil {
-		slog.Warn("문서 카테고리 분류 실패", "error", err)
+		slog.WarnContext(ctx, "문서 카테고리 분류 실패", "error", err)
 		return
 	}
 
@@ -645,7 +1224,7 @@ func (s *ChatbotService) enrichDocumentMetadata(ctx context.Context, doc *rag.Do
 	}
 
 	doc.Metadata["category"] = category
-	slog.Info("문서 카테고리 자동 분류", "id", doc.ID, "category", category)
+	slog.InfoContext(ctx, "문서 카테고리 자동 분류", "id", doc.ID, "category", category)
 }
 
 func (s *ChatbotService) ProjectVectors(ctx context.Context, req *rag.VectorProjectionRequest) (*rag.VectorProjectionResponse, error) {
@@ -653,6 +1232,7 @@ func (s *ChatbotService) ProjectVectors(ctx context.Context, req *rag.VectorProj
 		Limit:       req.Limit,
 		Offset:      req.Offset,
 		WithPayload: req.WithPayload,
+		Roles:       req.Roles,
 	}
 
 	vectorsResp, err := s.QueryDocumentVectors(ctx, query)
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"yuon/configuration"
+	"yuon/internal/rag"
+	"yuon/internal/rag/search"
+)
+
+// documentIndex is an OpenSearch index kept in memory. It answers get,
+// index, mget and bulk requests for the "documents" index.
+type documentIndex struct {
+	mu      sync.Mutex
+	sources map[string]map[string]interface{}
+}
+
+func (d *documentIndex) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 3 && parts[1] == "_doc" && r.Method == http.MethodGet:
+		source, ok := d.sources[parts[2]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"found":false}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"_id": parts[2], "found": true, "_source": source})
+	case len(parts) == 3 && parts[1] == "_doc":
+		var source map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&source)
+		d.sources[parts[2]] = source
+		w.Write([]byte(`{"result":"created"}`))
+	case parts[len(parts)-1] == "_mget":
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		docs := make([]map[string]interface{}, 0, len(req.IDs))
+		for _, id := range req.IDs {
+			source, ok := d.sources[id]
+			docs = append(docs, map[string]interface{}{"_id": id, "found": ok, "_source": source})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"docs": docs})
+	case parts[len(parts)-1] == "_bulk":
+		lines := bufio.NewScanner(r.Body)
+		for lines.Scan() {
+			var action struct {
+				Index struct {
+					ID string `json:"_id"`
+				} `json:"index"`
+			}
+			json.Unmarshal(lines.Bytes(), &action)
+			if !lines.Scan() {
+				break
+			}
+			var source map[string]interface{}
+			json.Unmarshal(lines.Bytes(), &source)
+			d.sources[action.Index.ID] = source
+		}
+		w.Write([]byte(`{"errors":false,"items":[]}`))
+	default:
+		w.Write([]byte(`{}`))
+	}
+}
+
+func (d *documentIndex) createdAt(id string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	value, _ := d.sources[id]["createdAt"].(string)
+	return value
+}
+
+// titleVectors is a rag.VectorStore with a single space embedding the
+// "title" metadata, so documents without a title need no embedding client.
+type titleVectors struct {
+	rag.VectorStore
+}
+
+func (titleVectors) Spaces() []rag.VectorSpace {
+	return []rag.VectorSpace{{Name: "title", Source: "title"}}
+}
+
+func (titleVectors) AddDocument(ctx context.Context, doc rag.Document, vectors rag.Vectors) error {
+	return nil
+}
+
+func (titleVectors) UpsertBatch(ctx context.Context, docs []rag.Document, vectors []rag.Vectors) (int, error) {
+	return len(docs), nil
+}
+
+func TestAddDocumentKeepsCreatedAt(t *testing.T) {
+	const created = "2024-01-02T03:04:05Z"
+	index := &documentIndex{sources: map[string]map[string]interface{}{
+		"existing": {"content": "이전 내용", "createdAt": created, "updatedAt": created},
+	}}
+	server := httptest.NewServer(index)
+	t.Cleanup(server.Close)
+
+	fullText, err := search.NewOpenSearchClient(&configuration.OpenSearchConfig{URL: server.URL, Index: "documents", RetryBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewChatbotService(nil, titleVectors{}, fullText, nil, nil, 0)
+	ctx := context.Background()
+	doc := func(id string) rag.Document {
+		return rag.Document{ID: id, Content: "새 내용", Metadata: map[string]interface{}{"category": "general"}}
+	}
+
+	t.Run("add", func(t *testing.T) {
+		if err := svc.AddDocument(ctx, doc("existing")); err != nil {
+			t.Fatal(err)
+		}
+		if got := index.createdAt("existing"); got != created {
+			t.Errorf("createdAt = %q, want %q", got, created)
+		}
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		if err := svc.BulkAddDocuments(ctx, []rag.Document{doc("existing"), doc("new")}); err != nil {
+			t.Fatal(err)
+		}
+		if got := index.createdAt("existing"); got != created {
+			t.Errorf("createdAt = %q, want %q", got, created)
+		}
+		if got := index.createdAt("new"); got == "" || got == created {
+			t.Errorf("new document createdAt = %q", got)
+		}
+	})
+}
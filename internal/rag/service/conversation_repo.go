@@ -3,10 +3,33 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
+	"yuon/package/pagination"
+)
+
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrMessageNotFound      = errors.New("conversation message not found")
+	ErrTagNotFound          = errors.New("conversation tag not found")
+	ErrShareNotFound        = errors.New("conversation share not found")
+	// ErrConversationEmpty is returned when summarizing a conversation
+	// without any readable message.
+	ErrConversationEmpty = errors.New("conversation has no messages")
+	// ErrNoConversationStore is returned by conversation lookups when the
+	// service runs without a conversation repository.
+	ErrNoConversationStore = errors.New("conversation store not configured")
 )
 
+// RedactedMessage replaces the content of redacted messages.
+const RedactedMessage = "[삭제된 내용입니다]"
+
 type ConversationSummary struct {
 	ID           string
 	Preview      string
@@ -14,22 +37,161 @@ type ConversationSummary struct {
 	CreatedAt    time.Time
 	TokenUsage   int
 	UpdatedAt    time.Time
+	OwnerID      string
+	OwnerName    string
+	Pinned       bool
+	Archived     bool
+	// Summary and ActionItems are the last LLM summary of the conversation,
+	// cleared when a message is deleted or redacted.
+	Summary      string
+	ActionItems  []string
+	SummarizedAt *time.Time
+	// Tags are staff triage labels, sorted.
+	Tags []string
+}
+
+// ListPreview is what conversation lists show: the summary once there is
+// one, the first question or generated title before that.
+func (c ConversationSummary) ListPreview() string {
+	if c.Summary != "" {
+		return c.Summary
+	}
+	return c.Preview
 }
 
 type ConversationMessage struct {
+	ID        string
 	Role      string
 	Content   string
 	Timestamp time.Time
+	// Sources are the documents an assistant answer was based on.
+	Sources []ConversationSource
+	// Redacted marks content replaced by RedactedMessage.
+	Redacted bool
+	// Model and the token counts are recorded for assistant answers.
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+type ConversationSource struct {
+	DocumentID string  `json:"documentId"`
+	Filename   string  `json:"filename,omitempty"`
+	Score      float64 `json:"score,omitempty"`
+}
+
+// ConversationFilter narrows List to conversations of OwnerID started in
+// [CreatedFrom, CreatedTo) and with the given flags; zero fields match all.
+type ConversationFilter struct {
+	OwnerID     string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Pinned      *bool
+	Archived    *bool
+	// Tags matches conversations having every one of them.
+	Tags []string
+}
+
+// ConversationShare is a public read-only link to a conversation. Only the
+// hash of its token is stored.
+type ConversationShare struct {
+	ID             string     `json:"id"`
+	ConversationID string     `json:"conversationId"`
+	CreatedBy      string     `json:"createdBy,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	RevokedAt      *time.Time `json:"revokedAt,omitempty"`
+	Views          int        `json:"views"`
+}
+
+// ConversationTagCount is a tag in use and how many conversations have it.
+type ConversationTagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
 }
 
+// ConversationSearchResult is a conversation matching a search with its
+// first matching messages, whose Snippet wraps the matched terms in <mark>.
+type ConversationSearchResult struct {
+	ConversationSummary
+	MatchCount int
+	Matches    []ConversationMatch
+}
+
+type ConversationMatch struct {
+	Role      string
+	Snippet   string
+	Timestamp time.Time
+}
+
+// conversationSearchSnippets is how many matching messages are returned per
+// conversation.
+const conversationSearchSnippets = 3
+
 type ConversationRepository interface {
 	EnsureConversation(ctx context.Context, id string) error
-	AddMessage(ctx context.Context, id, role, content string, ts time.Time) error
+	// SetOwner records who started the conversation; an existing owner is kept.
+	SetOwner(ctx context.Context, id, ownerID, ownerName string) error
+	AddMessage(ctx context.Context, id string, msg ConversationMessage) error
 	UpdateTokenUsage(ctx context.Context, id string, tokens int) error
 	UpdateTitle(ctx context.Context, id, title string) error
-	List(ctx context.Context, limit int) ([]ConversationSummary, error)
+	UpdateSummary(ctx context.Context, id, summary string, actionItems []string) error
+	// SetPinned and SetArchived flag a conversation without touching
+	// updated_at, so the list order stays that of the last message.
+	SetPinned(ctx context.Context, id string, pinned bool) error
+	SetArchived(ctx context.Context, id string, archived bool) error
+	// List returns conversations with messages, most recently updated first,
+	// starting after cursor ("" for the first page).
+	List(ctx context.Context, filter ConversationFilter, limit int, cursor string) ([]ConversationSummary, pagination.Page, error)
+	Get(ctx context.Context, id string) (*ConversationSummary, error)
+	// Count returns how many conversations with messages were started before t.
+	Count(ctx context.Context, before time.Time) (int64, error)
+	// CountMessages returns how many messages were stored before t.
+	CountMessages(ctx context.Context, before time.Time) (int64, error)
 	Messages(ctx context.Context, id string) ([]ConversationMessage, error)
+	// MessagePage returns up to limit messages, newest first unless
+	// oldestFirst, starting after cursor ("" for the first page).
+	MessagePage(ctx context.Context, id string, limit int, cursor string, oldestFirst bool) ([]ConversationMessage, pagination.Page, error)
+	// Search returns conversations, of ownerID unless empty, with messages
+	// containing every term of query, most recently updated first, starting
+	// after cursor.
+	Search(ctx context.Context, query, ownerID string, limit int, cursor string) ([]ConversationSearchResult, pagination.Page, error)
 	Delete(ctx context.Context, id string) error
+	// Tags returns the tags in use, most used first.
+	Tags(ctx context.Context) ([]ConversationTagCount, error)
+	// AddTags tags a conversation; tags it already has are kept as they are.
+	AddTags(ctx context.Context, id string, tags []string, createdBy string) error
+	RemoveTag(ctx context.Context, id, tag string) error
+	// RenameTag renames tag on every conversation, merging it into to where
+	// that exists, and DeleteTag removes it everywhere. Both return how
+	// many conversations had tag.
+	RenameTag(ctx context.Context, tag, to string) (int, error)
+	DeleteTag(ctx context.Context, tag string) (int, error)
+	CreateShare(ctx context.Context, share ConversationShare, tokenHash string) error
+	Shares(ctx context.Context, id string) ([]ConversationShare, error)
+	// RevokeShare revokes an active share of conversation id.
+	RevokeShare(ctx context.Context, id, shareID string) error
+	// ViewShare returns the share with tokenHash if it is active at now and
+	// counts the view.
+	ViewShare(ctx context.Context, tokenHash string, now time.Time) (*ConversationShare, error)
+	// DeleteMessage removes a message and RedactMessage replaces its content
+	// with RedactedMessage, keeping the message count. Both clear the
+	// conversation preview when it repeats the message.
+	DeleteMessage(ctx context.Context, id, messageID string) error
+	RedactMessage(ctx context.Context, id, messageID string) error
+	// Purge deletes, or anonymizes, the conversations matched by p and
+	// returns their IDs.
+	Purge(ctx context.Context, p ConversationPurge) ([]string, error)
+}
+
+// ConversationPurge selects conversations last updated before Before whose
+// owner belongs to one of Workspaces, or to none of ExcludeWorkspaces when
+// Workspaces is empty. Conversations without an owner count as workspace "".
+type ConversationPurge struct {
+	Before            time.Time
+	Workspaces        []string
+	ExcludeWorkspaces []string
+	Anonymize         bool
 }
 
 type PostgresConversationStore struct {
@@ -52,14 +214,35 @@ func (s *PostgresConversationStore) EnsureConversation(ctx context.Context, id s
 	return nil
 }
 
-func (s *PostgresConversationStore) AddMessage(ctx context.Context, id, role, content string, ts time.Time) error {
+func (s *PostgresConversationStore) SetOwner(ctx context.Context, id, ownerID, ownerName string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE conversations
+		SET owner_id = $2, owner_name = $3
+		WHERE id = $1 AND owner_id IS NULL
+	`, id, ownerID, ownerName)
+	if err != nil {
+		return fmt.Errorf("set conversation owner failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresConversationStore) AddMessage(ctx context.Context, id string, msg ConversationMessage) error {
 	if err := s.EnsureConversation(ctx, id); err != nil {
 		return err
 	}
 
+	var sources []byte
+	if len(msg.Sources) > 0 {
+		encoded, err := json.Marshal(msg.Sources)
+		if err != nil {
+			return fmt.Errorf("encode message sources failed: %w", err)
+		}
+		sources = encoded
+	}
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO conversation_messages (conversation_id, role, content, ts)
-		VALUES ($1, $2, $3, $4)`, id, role, content, ts)
+		INSERT INTO conversation_messages (conversation_id, role, content, ts, sources, model, prompt_tokens, completion_tokens)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)`,
+		id, msg.Role, msg.Content, msg.Timestamp, sources, msg.Model, msg.PromptTokens, msg.CompletionTokens)
 	if err != nil {
 		return fmt.Errorf("insert conversation message failed: %w", err)
 	}
@@ -72,7 +255,7 @@ func (s *PostgresConversationStore) AddMessage(ctx context.Context, id, role, co
 			preview = COALESCE(preview, CASE WHEN $2 = 'user' THEN $3 ELSE preview END),
 			updated_at = NOW()
 		WHERE id = $1
-	`, id, role, content)
+	`, id, msg.Role, msg.Content)
 	if err != nil {
 		return fmt.Errorf("update conversation summary failed: %w", err)
 	}
@@ -111,43 +294,198 @@ func (s *PostgresConversationStore) UpdateTitle(ctx context.Context, id, title s
 	return nil
 }
 
-func (s *PostgresConversationStore) List(ctx context.Context, limit int) ([]ConversationSummary, error) {
+func (s *PostgresConversationStore) UpdateSummary(ctx context.Context, id, summary string, actionItems []string) error {
+	if actionItems == nil {
+		actionItems = []string{}
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE conversations
+		SET summary = $2, action_items = $3, summarized_at = NOW()
+		WHERE id = $1
+	`, id, summary, pq.Array(actionItems))
+	if err != nil {
+		return fmt.Errorf("update conversation summary failed: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrConversationNotFound
+	}
+	return nil
+}
+
+func (s *PostgresConversationStore) SetPinned(ctx context.Context, id string, pinned bool) error {
+	return s.setFlag(ctx, id, "pinned", pinned)
+}
+
+func (s *PostgresConversationStore) SetArchived(ctx context.Context, id string, archived bool) error {
+	return s.setFlag(ctx, id, "archived", archived)
+}
+
+// setFlag updates a boolean column; column is never user input.
+func (s *PostgresConversationStore) setFlag(ctx context.Context, id, column string, value bool) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE conversations SET `+column+` = $2 WHERE id = $1`, id, value)
+	if err != nil {
+		return fmt.Errorf("update conversation %s failed: %w", column, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrConversationNotFound
+	}
+	return nil
+}
+
+func (s *PostgresConversationStore) List(ctx context.Context, filter ConversationFilter, limit int, cursor string) ([]ConversationSummary, pagination.Page, error) {
 	if limit <= 0 {
 		limit = 100
 	}
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, preview, message_count, token_usage, created_at, updated_at
+
+	query := `
+		SELECT id, preview, message_count, token_usage, created_at, updated_at, owner_id, owner_name, pinned, archived,
+			summary, action_items, summarized_at,
+			ARRAY(SELECT tag FROM conversation_tags WHERE conversation_id = conversations.id ORDER BY tag)
 		FROM conversations
-		WHERE message_count > 0
-		ORDER BY updated_at DESC
-		LIMIT $1
-	`, limit)
+		WHERE message_count > 0`
+	args := []any{limit + 1}
+	if cursor != "" {
+		after, afterID, err := pagination.DecodeTimeCursor(cursor)
+		if err != nil {
+			return nil, pagination.Page{}, err
+		}
+		args = append(args, after, afterID)
+		query += fmt.Sprintf(` AND (updated_at, id) < ($%d, $%d)`, len(args)-1, len(args))
+	}
+	if filter.OwnerID != "" {
+		args = append(args, filter.OwnerID)
+		query += fmt.Sprintf(` AND owner_id = $%d`, len(args))
+	}
+	if filter.CreatedFrom != nil {
+		args = append(args, *filter.CreatedFrom)
+		query += fmt.Sprintf(` AND created_at >= $%d`, len(args))
+	}
+	if filter.CreatedTo != nil {
+		args = append(args, *filter.CreatedTo)
+		query += fmt.Sprintf(` AND created_at < $%d`, len(args))
+	}
+	if filter.Pinned != nil {
+		args = append(args, *filter.Pinned)
+		query += fmt.Sprintf(` AND pinned = $%d`, len(args))
+	}
+	if filter.Archived != nil {
+		args = append(args, *filter.Archived)
+		query += fmt.Sprintf(` AND archived = $%d`, len(args))
+	}
+	if len(filter.Tags) > 0 {
+		args = append(args, pq.Array(filter.Tags), len(filter.Tags))
+		query += fmt.Sprintf(` AND id IN (
+			SELECT conversation_id FROM conversation_tags
+			WHERE tag = ANY($%d)
+			GROUP BY conversation_id
+			HAVING COUNT(*) = $%d)`, len(args)-1, len(args))
+	}
+	query += `
+		ORDER BY updated_at DESC, id DESC
+		LIMIT $1`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list conversations failed: %w", err)
+		return nil, pagination.Page{}, fmt.Errorf("list conversations failed: %w", err)
 	}
 	defer rows.Close()
 
 	var result []ConversationSummary
 	for rows.Next() {
 		var item ConversationSummary
-		var preview sql.NullString
-		if err := rows.Scan(&item.ID, &preview, &item.MessageCount, &item.TokenUsage, &item.CreatedAt, &item.UpdatedAt); err != nil {
-			return nil, err
+		var preview, ownerID, ownerName, summary sql.NullString
+		var summarizedAt sql.NullTime
+		if err := rows.Scan(&item.ID, &preview, &item.MessageCount, &item.TokenUsage, &item.CreatedAt, &item.UpdatedAt, &ownerID, &ownerName, &item.Pinned, &item.Archived,
+			&summary, pq.Array(&item.ActionItems), &summarizedAt, pq.Array(&item.Tags)); err != nil {
+			return nil, pagination.Page{}, err
 		}
 		if preview.Valid {
 			item.Preview = preview.String
 		}
+		item.OwnerID = ownerID.String
+		item.OwnerName = ownerName.String
+		item.setSummary(summary, summarizedAt)
 		result = append(result, item)
 	}
-	return result, nil
+	if err := rows.Err(); err != nil {
+		return nil, pagination.Page{}, fmt.Errorf("list conversations failed: %w", err)
+	}
+
+	var page pagination.Page
+	if len(result) > limit {
+		result = result[:limit]
+		last := result[limit-1]
+		page = pagination.Page{NextCursor: pagination.EncodeTimeCursor(last.UpdatedAt, last.ID), HasMore: true}
+	}
+	return result, page, nil
+}
+
+func (s *PostgresConversationStore) Count(ctx context.Context, before time.Time) (int64, error) {
+	var count int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM conversations
+		WHERE message_count > 0 AND created_at < $1
+	`, before).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count conversations failed: %w", err)
+	}
+	return count, nil
+}
+
+func (s *PostgresConversationStore) CountMessages(ctx context.Context, before time.Time) (int64, error) {
+	var count int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM conversation_messages WHERE ts < $1
+	`, before).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count messages failed: %w", err)
+	}
+	return count, nil
+}
+
+func (s *PostgresConversationStore) Get(ctx context.Context, id string) (*ConversationSummary, error) {
+	var item ConversationSummary
+	var preview, ownerID, ownerName, summary sql.NullString
+	var summarizedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, preview, message_count, token_usage, created_at, updated_at, owner_id, owner_name, pinned, archived,
+			summary, action_items, summarized_at,
+			ARRAY(SELECT tag FROM conversation_tags WHERE conversation_id = conversations.id ORDER BY tag)
+		FROM conversations
+		WHERE id = $1
+	`, id).Scan(&item.ID, &preview, &item.MessageCount, &item.TokenUsage, &item.CreatedAt, &item.UpdatedAt, &ownerID, &ownerName, &item.Pinned, &item.Archived,
+		&summary, pq.Array(&item.ActionItems), &summarizedAt, pq.Array(&item.Tags))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get conversation failed: %w", err)
+	}
+	item.Preview = preview.String
+	item.OwnerID = ownerID.String
+	item.OwnerName = ownerName.String
+	item.setSummary(summary, summarizedAt)
+	return &item, nil
+}
+
+// setSummary fills the columns scanned into nullable values.
+func (c *ConversationSummary) setSummary(summary sql.NullString, at sql.NullTime) {
+	c.Summary = summary.String
+	if at.Valid {
+		c.SummarizedAt = &at.Time
+	}
+	if c.Tags == nil {
+		c.Tags = []string{}
+	}
 }
 
 func (s *PostgresConversationStore) Messages(ctx context.Context, id string) ([]ConversationMessage, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT role, content, ts
+		SELECT id, role, content, ts, sources, redacted_at IS NOT NULL,
+			COALESCE(model, ''), prompt_tokens, completion_tokens
 		FROM conversation_messages
 		WHERE conversation_id = $1
-		ORDER BY ts ASC
+		ORDER BY ts ASC, id ASC
 	`, id)
 	if err != nil {
 		return nil, fmt.Errorf("list conversation messages failed: %w", err)
@@ -157,14 +495,255 @@ func (s *PostgresConversationStore) Messages(ctx context.Context, id string) ([]
 	var msgs []ConversationMessage
 	for rows.Next() {
 		var msg ConversationMessage
-		if err := rows.Scan(&msg.Role, &msg.Content, &msg.Timestamp); err != nil {
+		var sources []byte
+		if err := rows.Scan(&msg.ID, &msg.Role, &msg.Content, &msg.Timestamp, &sources, &msg.Redacted,
+			&msg.Model, &msg.PromptTokens, &msg.CompletionTokens); err != nil {
 			return nil, err
 		}
+		if len(sources) > 0 {
+			if err := json.Unmarshal(sources, &msg.Sources); err != nil {
+				return nil, fmt.Errorf("decode message sources failed: %w", err)
+			}
+		}
 		msgs = append(msgs, msg)
 	}
 	return msgs, nil
 }
 
+func (s *PostgresConversationStore) Search(ctx context.Context, query, ownerID string, limit int, cursor string) ([]ConversationSearchResult, pagination.Page, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	tsQuery := prefixTSQuery(query)
+	if tsQuery == "" {
+		return nil, pagination.Page{}, nil
+	}
+
+	args := []any{tsQuery, limit + 1, conversationSearchSnippets}
+	where := ""
+	if cursor != "" {
+		updatedAt, afterID, err := pagination.DecodeTimeCursor(cursor)
+		if err != nil {
+			return nil, pagination.Page{}, err
+		}
+		args = append(args, updatedAt, afterID)
+		where += fmt.Sprintf(` AND (c.updated_at, c.id) < ($%d, $%d)`, len(args)-1, len(args))
+	}
+	if ownerID != "" {
+		args = append(args, ownerID)
+		where += fmt.Sprintf(` AND c.owner_id = $%d`, len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		WITH q AS (
+			SELECT to_tsquery('simple', $1) AS query
+		), matches AS (
+			SELECT m.conversation_id, m.role, m.content, m.ts
+			FROM conversation_messages m, q
+			WHERE to_tsvector('simple', m.content) @@ q.query
+		), hits AS (
+			SELECT c.id, c.preview, c.message_count, c.token_usage, c.created_at, c.updated_at, c.owner_id, c.owner_name, c.pinned, c.archived,
+				c.summary, c.action_items, c.summarized_at,
+				ARRAY(SELECT tag FROM conversation_tags WHERE conversation_id = c.id ORDER BY tag) AS tags,
+				(SELECT COUNT(*) FROM matches WHERE conversation_id = c.id) AS match_count
+			FROM conversations c
+			WHERE EXISTS (SELECT 1 FROM matches WHERE conversation_id = c.id)`+where+`
+			ORDER BY c.updated_at DESC, c.id DESC
+			LIMIT $2
+		)
+		SELECT h.id, h.preview, h.message_count, h.token_usage, h.created_at, h.updated_at, h.owner_id, h.owner_name, h.pinned, h.archived,
+			h.summary, h.action_items, h.summarized_at, h.tags, h.match_count,
+			m.role, m.ts,
+			ts_headline('simple', m.content, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10')
+		FROM hits h
+		CROSS JOIN q
+		CROSS JOIN LATERAL (
+			SELECT role, content, ts FROM matches
+			WHERE conversation_id = h.id
+			ORDER BY ts ASC
+			LIMIT $3
+		) m
+		ORDER BY h.updated_at DESC, h.id DESC, m.ts ASC
+	`, args...)
+	if err != nil {
+		return nil, pagination.Page{}, fmt.Errorf("search conversations failed: %w", err)
+	}
+	defer rows.Close()
+
+	var result []ConversationSearchResult
+	for rows.Next() {
+		var item ConversationSearchResult
+		var match ConversationMatch
+		var preview, ownerID, ownerName, summary sql.NullString
+		var summarizedAt sql.NullTime
+		if err := rows.Scan(&item.ID, &preview, &item.MessageCount, &item.TokenUsage, &item.CreatedAt, &item.UpdatedAt,
+			&ownerID, &ownerName, &item.Pinned, &item.Archived, &summary, pq.Array(&item.ActionItems), &summarizedAt,
+			pq.Array(&item.Tags), &item.MatchCount, &match.Role, &match.Timestamp, &match.Snippet); err != nil {
+			return nil, pagination.Page{}, err
+		}
+		if n := len(result); n > 0 && result[n-1].ID == item.ID {
+			result[n-1].Matches = append(result[n-1].Matches, match)
+			continue
+		}
+		item.Preview = preview.String
+		item.OwnerID = ownerID.String
+		item.OwnerName = ownerName.String
+		item.setSummary(summary, summarizedAt)
+		item.Matches = []ConversationMatch{match}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pagination.Page{}, fmt.Errorf("search conversations failed: %w", err)
+	}
+
+	var page pagination.Page
+	if len(result) > limit {
+		result = result[:limit]
+		last := result[limit-1]
+		page = pagination.Page{NextCursor: pagination.EncodeTimeCursor(last.UpdatedAt, last.ID), HasMore: true}
+	}
+	return result, page, nil
+}
+
+// prefixTSQuery turns free text into a tsquery requiring every word as a
+// prefix, so "장학금" also finds "장학금은". Operators in the input are
+// dropped rather than interpreted.
+func prefixTSQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		word = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`&|!():*'\<>`, r) {
+				return -1
+			}
+			return r
+		}, word)
+		if word != "" {
+			terms = append(terms, "'"+word+"':*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+func (s *PostgresConversationStore) DeleteMessage(ctx context.Context, id, messageID string) error {
+	seq, err := strconv.ParseInt(messageID, 10, 64)
+	if err != nil {
+		return ErrMessageNotFound
+	}
+	res, err := s.db.ExecContext(ctx, `
+		WITH deleted AS (
+			DELETE FROM conversation_messages
+			WHERE id = $2 AND conversation_id = $1
+			RETURNING content
+		)
+		UPDATE conversations
+		SET message_count = message_count - 1,
+		    preview = CASE WHEN preview = (SELECT content FROM deleted) THEN NULL ELSE preview END,
+		    summary = NULL, action_items = NULL, summarized_at = NULL
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM deleted)
+	`, id, seq)
+	if err != nil {
+		return fmt.Errorf("delete conversation message failed: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrMessageNotFound
+	}
+	return nil
+}
+
+func (s *PostgresConversationStore) RedactMessage(ctx context.Context, id, messageID string) error {
+	seq, err := strconv.ParseInt(messageID, 10, 64)
+	if err != nil {
+		return ErrMessageNotFound
+	}
+	res, err := s.db.ExecContext(ctx, `
+		WITH target AS (
+			SELECT content FROM conversation_messages
+			WHERE id = $2 AND conversation_id = $1
+			FOR UPDATE
+		), redacted AS (
+			UPDATE conversation_messages
+			SET content = $3, redacted_at = NOW()
+			WHERE id = $2 AND conversation_id = $1
+		)
+		UPDATE conversations
+		SET preview = CASE WHEN preview = (SELECT content FROM target) THEN $3 ELSE preview END,
+		    summary = NULL, action_items = NULL, summarized_at = NULL
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM target)
+	`, id, seq, RedactedMessage)
+	if err != nil {
+		return fmt.Errorf("redact conversation message failed: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrMessageNotFound
+	}
+	return nil
+}
+
+func (s *PostgresConversationStore) MessagePage(ctx context.Context, id string, limit int, cursor string, oldestFirst bool) ([]ConversationMessage, pagination.Page, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	order, cmp := "DESC", "<"
+	if oldestFirst {
+		order, cmp = "ASC", ">"
+	}
+
+	query := `
+		SELECT id, role, content, ts, sources, redacted_at IS NOT NULL,
+			COALESCE(model, ''), prompt_tokens, completion_tokens
+		FROM conversation_messages
+		WHERE conversation_id = $1`
+	args := []any{id, limit + 1}
+	if cursor != "" {
+		after, afterID, err := pagination.DecodeTimeCursor(cursor)
+		if err != nil {
+			return nil, pagination.Page{}, err
+		}
+		seq, err := strconv.ParseInt(afterID, 10, 64)
+		if err != nil {
+			return nil, pagination.Page{}, pagination.ErrInvalidCursor
+		}
+		query += ` AND (ts, id) ` + cmp + ` ($3, $4)`
+		args = append(args, after, seq)
+	}
+	query += `
+		ORDER BY ts ` + order + `, id ` + order + `
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, pagination.Page{}, fmt.Errorf("list conversation messages failed: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []ConversationMessage
+	for rows.Next() {
+		var msg ConversationMessage
+		var sources []byte
+		if err := rows.Scan(&msg.ID, &msg.Role, &msg.Content, &msg.Timestamp, &sources, &msg.Redacted,
+			&msg.Model, &msg.PromptTokens, &msg.CompletionTokens); err != nil {
+			return nil, pagination.Page{}, err
+		}
+		if len(sources) > 0 {
+			if err := json.Unmarshal(sources, &msg.Sources); err != nil {
+				return nil, pagination.Page{}, fmt.Errorf("decode message sources failed: %w", err)
+			}
+		}
+		msgs = append(msgs, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pagination.Page{}, fmt.Errorf("list conversation messages failed: %w", err)
+	}
+
+	var page pagination.Page
+	if len(msgs) > limit {
+		msgs = msgs[:limit]
+		last := msgs[limit-1]
+		page = pagination.Page{NextCursor: pagination.EncodeTimeCursor(last.Timestamp, last.ID), HasMore: true}
+	}
+	return msgs, page, nil
+}
+
 func (s *PostgresConversationStore) Delete(ctx context.Context, id string) error {
 	// Delete messages first (foreign key constraint)
 	_, err := s.db.ExecContext(ctx, `DELETE FROM conversation_messages WHERE conversation_id = $1`, id)
@@ -189,3 +768,234 @@ func (s *PostgresConversationStore) Delete(ctx context.Context, id string) error
 
 	return nil
 }
+
+func (s *PostgresConversationStore) Purge(ctx context.Context, p ConversationPurge) ([]string, error) {
+	query, args := purgeQuery(p)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("purge conversations failed: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("purge conversations failed: %w", err)
+	}
+	return ids, nil
+}
+
+// purgeQuery builds the statement Purge runs, returning the IDs of the
+// conversations it deleted or anonymized.
+func purgeQuery(p ConversationPurge) (string, []any) {
+	workspace := `COALESCE((SELECT u.workspace FROM users u WHERE u.id = c.owner_id), '')`
+	where := `c.updated_at < $1`
+	args := []any{p.Before}
+	if len(p.Workspaces) > 0 {
+		args = append(args, pq.Array(p.Workspaces))
+		where += fmt.Sprintf(` AND %s = ANY($%d)`, workspace, len(args))
+	} else if len(p.ExcludeWorkspaces) > 0 {
+		args = append(args, pq.Array(p.ExcludeWorkspaces))
+		where += fmt.Sprintf(` AND NOT %s = ANY($%d)`, workspace, len(args))
+	}
+
+	query := `DELETE FROM conversations c WHERE ` + where + ` RETURNING c.id`
+	if p.Anonymize {
+		// Anonymized conversations keep their counts and token usage.
+		query = `
+			WITH expired AS (
+				SELECT c.id FROM conversations c
+				WHERE c.anonymized_at IS NULL AND ` + where + `
+				FOR UPDATE
+			), messages AS (
+				UPDATE conversation_messages m
+				SET content = '', sources = NULL
+				FROM expired e
+				WHERE m.conversation_id = e.id
+			), shares AS (
+				UPDATE conversation_shares sh
+				SET revoked_at = NOW()
+				FROM expired e
+				WHERE sh.conversation_id = e.id AND sh.revoked_at IS NULL
+			)
+			UPDATE conversations c
+			SET owner_id = NULL, owner_name = NULL, preview = NULL, anonymized_at = NOW(),
+				summary = NULL, action_items = NULL, summarized_at = NULL
+			FROM expired e
+			WHERE c.id = e.id
+			RETURNING c.id`
+	}
+	return query, args
+}
+
+func (s *PostgresConversationStore) Tags(ctx context.Context) ([]ConversationTagCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT tag, COUNT(*) FROM conversation_tags
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list conversation tags failed: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []ConversationTagCount
+	for rows.Next() {
+		var t ConversationTagCount
+		if err := rows.Scan(&t.Tag, &t.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+func (s *PostgresConversationStore) AddTags(ctx context.Context, id string, tags []string, createdBy string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO conversation_tags (conversation_id, tag, created_by)
+		SELECT $1, tag, NULLIF($3, '') FROM unnest($2::text[]) AS tag
+		ON CONFLICT (conversation_id, tag) DO NOTHING
+	`, id, pq.Array(tags), createdBy)
+	if err != nil {
+		return fmt.Errorf("add conversation tags failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresConversationStore) RemoveTag(ctx context.Context, id, tag string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM conversation_tags WHERE conversation_id = $1 AND tag = $2`, id, tag)
+	if err != nil {
+		return fmt.Errorf("remove conversation tag failed: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+func (s *PostgresConversationStore) RenameTag(ctx context.Context, tag, to string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		WITH moved AS (
+			DELETE FROM conversation_tags WHERE tag = $1
+			RETURNING conversation_id, created_by, created_at
+		), renamed AS (
+			INSERT INTO conversation_tags (conversation_id, tag, created_by, created_at)
+			SELECT conversation_id, $2, created_by, created_at FROM moved
+			ON CONFLICT (conversation_id, tag) DO NOTHING
+		)
+		SELECT COUNT(*) FROM moved
+	`, tag, to).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("rename conversation tag failed: %w", err)
+	}
+	if count == 0 {
+		return 0, ErrTagNotFound
+	}
+	return count, nil
+}
+
+func (s *PostgresConversationStore) DeleteTag(ctx context.Context, tag string) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM conversation_tags WHERE tag = $1`, tag)
+	if err != nil {
+		return 0, fmt.Errorf("delete conversation tag failed: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, ErrTagNotFound
+	}
+	return int(n), nil
+}
+
+func (s *PostgresConversationStore) CreateShare(ctx context.Context, share ConversationShare, tokenHash string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO conversation_shares (id, conversation_id, token_hash, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+	`, share.ID, share.ConversationID, tokenHash, share.CreatedBy, share.CreatedAt, share.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("create conversation share failed: %w", err)
+	}
+	return nil
+}
+
+const conversationShareColumns = `id, conversation_id, created_by, created_at, expires_at, revoked_at, view_count`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanConversationShare(row rowScanner) (*ConversationShare, error) {
+	var share ConversationShare
+	var createdBy sql.NullString
+	var revokedAt sql.NullTime
+	if err := row.Scan(&share.ID, &share.ConversationID, &createdBy, &share.CreatedAt, &share.ExpiresAt, &revokedAt, &share.Views); err != nil {
+		return nil, err
+	}
+	share.CreatedBy = createdBy.String
+	if revokedAt.Valid {
+		share.RevokedAt = &revokedAt.Time
+	}
+	return &share, nil
+}
+
+func (s *PostgresConversationStore) Shares(ctx context.Context, id string) ([]ConversationShare, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+conversationShareColumns+`
+		FROM conversation_shares
+		WHERE conversation_id = $1
+		ORDER BY created_at DESC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("list conversation shares failed: %w", err)
+	}
+	defer rows.Close()
+
+	var shares []ConversationShare
+	for rows.Next() {
+		share, err := scanConversationShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, *share)
+	}
+	return shares, rows.Err()
+}
+
+func (s *PostgresConversationStore) RevokeShare(ctx context.Context, id, shareID string) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE conversation_shares
+		SET revoked_at = NOW()
+		WHERE id = $2 AND conversation_id = $1 AND revoked_at IS NULL
+	`, id, shareID)
+	if err != nil {
+		return fmt.Errorf("revoke conversation share failed: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrShareNotFound
+	}
+	return nil
+}
+
+func (s *PostgresConversationStore) ViewShare(ctx context.Context, tokenHash string, now time.Time) (*ConversationShare, error) {
+	share, err := scanConversationShare(s.db.QueryRowContext(ctx, `
+		UPDATE conversation_shares
+		SET view_count = view_count + 1
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > $2
+		RETURNING `+conversationShareColumns, tokenHash, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("view conversation share failed: %w", err)
+	}
+	return share, nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"yuon/internal/database"
+)
+
+func purgeVariants() map[string]ConversationPurge {
+	before := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	variants := make(map[string]ConversationPurge)
+	for _, anonymize := range []bool{false, true} {
+		mode := "delete"
+		if anonymize {
+			mode = "anonymize"
+		}
+		variants[mode] = ConversationPurge{Before: before, Anonymize: anonymize}
+		variants[mode+"/workspaces"] = ConversationPurge{Before: before, Workspaces: []string{"a", "b"}, Anonymize: anonymize}
+		variants[mode+"/exclude"] = ConversationPurge{Before: before, ExcludeWorkspaces: []string{"a"}, Anonymize: anonymize}
+	}
+	return variants
+}
+
+func TestPurgeQueryShape(t *testing.T) {
+	for name, p := range purgeVariants() {
+		t.Run(name, func(t *testing.T) {
+			query, args := purgeQuery(p)
+
+			depth := 0
+			inString := false
+			for i, r := range query {
+				switch {
+				case r == '\'':
+					inString = !inString
+				case inString:
+				case r == '(':
+					depth++
+				case r == ')':
+					depth--
+					if depth < 0 {
+						t.Fatalf("unbalanced ')' at offset %d in %s", i, query)
+					}
+				}
+			}
+			if depth != 0 || inString {
+				t.Fatalf("unbalanced parentheses or quotes in %s", query)
+			}
+
+			for i := 1; i <= len(args); i++ {
+				if !strings.Contains(query, fmt.Sprintf("$%d", i)) {
+					t.Errorf("placeholder $%d unused", i)
+				}
+			}
+			if strings.Contains(query, fmt.Sprintf("$%d", len(args)+1)) {
+				t.Errorf("placeholder $%d has no argument", len(args)+1)
+			}
+			if !strings.HasSuffix(strings.TrimSpace(query), "RETURNING c.id") {
+				t.Errorf("query does not return the conversation IDs: %s", query)
+			}
+
+			if p.Anonymize {
+				for _, part := range []string{"WITH expired AS (", "), messages AS (", "), shares AS (", "UPDATE conversations c"} {
+					if !strings.Contains(query, part) {
+						t.Errorf("anonymize query lacks %q", part)
+					}
+				}
+				if strings.Contains(query, "DELETE") {
+					t.Errorf("anonymize query deletes rows: %s", query)
+				}
+			} else if !strings.HasPrefix(query, "DELETE FROM conversations c WHERE ") {
+				t.Errorf("delete query has unexpected shape: %s", query)
+			}
+		})
+	}
+}
+
+// TestPurgeIntegration runs both purge modes against the PostgreSQL
+// database named by YUON_TEST_DATABASE_URL, skipping without one.
+func TestPurgeIntegration(t *testing.T) {
+	dsn := os.Getenv("YUON_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("YUON_TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := database.EnsureSchemas(db); err != nil {
+		t.Fatal(err)
+	}
+	store := NewPostgresConversationStore(db)
+	ctx := context.Background()
+	old := time.Now().Add(-48 * time.Hour)
+
+	seed := func(t *testing.T) string {
+		t.Helper()
+		id := uuid.NewString()
+		if _, err := db.ExecContext(ctx, `
+			INSERT INTO conversations (id, preview, message_count, owner_id, owner_name, updated_at)
+			VALUES ($1, 'preview', 1, 'purge-test-owner', 'owner', $2)
+		`, id, old); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.ExecContext(ctx, `
+			INSERT INTO conversation_messages (conversation_id, role, content) VALUES ($1, 'user', 'secret')
+		`, id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.ExecContext(ctx, `
+			INSERT INTO conversation_shares (id, conversation_id, token_hash, expires_at)
+			VALUES ($1, $2, $1, NOW() + INTERVAL '1 day')
+		`, uuid.NewString(), id); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Exec(`DELETE FROM conversations WHERE id = $1`, id) })
+		return id
+	}
+	purged := func(t *testing.T, ids []string, id string) {
+		t.Helper()
+		for _, got := range ids {
+			if got == id {
+				return
+			}
+		}
+		t.Fatalf("conversation %s not purged, got %v", id, ids)
+	}
+	before := time.Now().Add(-time.Hour)
+
+	t.Run("delete", func(t *testing.T) {
+		id := seed(t)
+		ids, err := store.Purge(ctx, ConversationPurge{Before: before})
+		if err != nil {
+			t.Fatal(err)
+		}
+		purged(t, ids, id)
+		var n int
+		db.QueryRowContext(ctx, `SELECT COUNT(*) FROM conversations WHERE id = $1`, id).Scan(&n)
+		if n != 0 {
+			t.Fatalf("conversation %s still exists", id)
+		}
+	})
+
+	t.Run("anonymize", func(t *testing.T) {
+		id := seed(t)
+		ids, err := store.Purge(ctx, ConversationPurge{Before: before, Anonymize: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		purged(t, ids, id)
+
+		var owner, preview sql.NullString
+		var count int
+		if err := db.QueryRowContext(ctx, `SELECT owner_id, preview, message_count FROM conversations WHERE id = $1`, id).Scan(&owner, &preview, &count); err != nil {
+			t.Fatal(err)
+		}
+		if owner.Valid || preview.Valid || count != 1 {
+			t.Fatalf("conversation not anonymized: owner=%v preview=%v count=%d", owner, preview, count)
+		}
+		var content string
+		db.QueryRowContext(ctx, `SELECT content FROM conversation_messages WHERE conversation_id = $1`, id).Scan(&content)
+		if content != "" {
+			t.Fatalf("message content kept: %q", content)
+		}
+		var active int
+		db.QueryRowContext(ctx, `SELECT COUNT(*) FROM conversation_shares WHERE conversation_id = $1 AND revoked_at IS NULL`, id).Scan(&active)
+		if active != 0 {
+			t.Fatalf("%d shares still active", active)
+		}
+
+		again, err := store.Purge(ctx, ConversationPurge{Before: before, Anonymize: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, got := range again {
+			if got == id {
+				t.Fatalf("conversation %s anonymized twice", id)
+			}
+		}
+	})
+}
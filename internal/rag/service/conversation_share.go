@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateConversationShare creates a public link to conversation id that
+// expires after ttl. The returned token is not stored and cannot be shown
+// again.
+func (s *ChatbotService) CreateConversationShare(ctx context.Context, id, createdBy string, ttl time.Duration) (string, *ConversationShare, error) {
+	if s.convRepo == nil {
+		return "", nil, fmt.Errorf("conversation store not configured")
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("share token generation failed: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now().UTC()
+	share := ConversationShare{
+		ID:             uuid.NewString(),
+		ConversationID: id,
+		CreatedBy:      createdBy,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(ttl),
+	}
+	if err := s.convRepo.CreateShare(ctx, share, hashShareToken(token)); err != nil {
+		return "", nil, err
+	}
+	return token, &share, nil
+}
+
+func (s *ChatbotService) ListConversationShares(ctx context.Context, id string) ([]ConversationShare, error) {
+	if s.convRepo == nil {
+		return nil, fmt.Errorf("conversation store not configured")
+	}
+	return s.convRepo.Shares(ctx, id)
+}
+
+func (s *ChatbotService) RevokeConversationShare(ctx context.Context, id, shareID string) error {
+	if s.convRepo == nil {
+		return fmt.Errorf("conversation store not configured")
+	}
+	return s.convRepo.RevokeShare(ctx, id, shareID)
+}
+
+// SharedConversation resolves a share token to its conversation and
+// messages. Unknown, expired and revoked tokens give ErrShareNotFound.
+func (s *ChatbotService) SharedConversation(ctx context.Context, token string) (*ConversationSummary, []ConversationMessage, error) {
+	if s.convRepo == nil {
+		return nil, nil, fmt.Errorf("conversation store not configured")
+	}
+	if token == "" {
+		return nil, nil, ErrShareNotFound
+	}
+
+	share, err := s.convRepo.ViewShare(ctx, hashShareToken(token), time.Now().UTC())
+	if err != nil {
+		return nil, nil, err
+	}
+	summary, err := s.convRepo.Get(ctx, share.ConversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	messages, err := s.convRepo.Messages(ctx, share.ConversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return summary, messages, nil
+}
+
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,43 +1,168 @@
 package service
 
 import (
+	"container/list"
+	"context"
 	"sync"
 
 	"yuon/internal/rag"
 )
 
+// DefaultConversationCacheSize is how many conversation histories are kept
+// in memory when no size is configured.
+const DefaultConversationCacheSize = 1000
+
+// ConversationStore is a write-through cache of conversation histories over
+// a ConversationRepository, evicting the least recently used conversations
+// beyond its capacity. Without a repository it only keeps the histories in
+// memory, and evicted ones are lost.
 type ConversationStore struct {
-	mu        sync.RWMutex
-	histories map[string][]rag.ChatMessage
+	repo     ConversationRepository
+	capacity int
+
+	mu sync.Mutex
+	// order holds *cachedHistory, most recently used first.
+	order   *list.List
+	entries map[string]*list.Element
+	// loading and writing count History loads and Append writes in flight
+	// per conversation. A load overlapping a write is not cached, since it
+	// may miss or repeat the written message; stale marks those that
+	// overlapped a finished write or an Invalidate.
+	loading map[string]int
+	writing map[string]int
+	stale   map[string]bool
 }
 
-func NewConversationStore() *ConversationStore {
+type cachedHistory struct {
+	id       string
+	messages []rag.ChatMessage
+}
+
+func NewConversationStore(repo ConversationRepository, capacity int) *ConversationStore {
+	if capacity <= 0 {
+		capacity = DefaultConversationCacheSize
+	}
 	return &ConversationStore{
-		histories: make(map[string][]rag.ChatMessage),
+		repo:     repo,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		loading:  make(map[string]int),
+		writing:  make(map[string]int),
+		stale:    make(map[string]bool),
 	}
 }
 
-func (s *ConversationStore) Append(conversationID string, msg rag.ChatMessage) {
+// History returns the messages of conversationID, loading them from the
+// repository when they are not cached.
+func (s *ConversationStore) History(ctx context.Context, conversationID string) ([]rag.ChatMessage, error) {
+	s.mu.Lock()
+	if el, ok := s.entries[conversationID]; ok {
+		s.order.MoveToFront(el)
+		history := cloneHistory(el.Value.(*cachedHistory).messages)
+		s.mu.Unlock()
+		return history, nil
+	}
+	if s.repo == nil {
+		s.mu.Unlock()
+		return nil, nil
+	}
+	s.loading[conversationID]++
+	s.mu.Unlock()
+
+	stored, err := s.repo.Messages(ctx, conversationID)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.histories[conversationID] = append(s.histories[conversationID], msg)
+	stale := s.stale[conversationID] || s.writing[conversationID] > 0
+	if s.loading[conversationID]--; s.loading[conversationID] == 0 {
+		delete(s.loading, conversationID)
+		delete(s.stale, conversationID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]rag.ChatMessage, 0, len(stored))
+	for _, m := range stored {
+		history = append(history, rag.ChatMessage{Role: m.Role, Content: m.Content})
+	}
+	if el, ok := s.entries[conversationID]; ok {
+		// Loaded concurrently; keep the cached copy, which may be newer.
+		s.order.MoveToFront(el)
+		return cloneHistory(el.Value.(*cachedHistory).messages), nil
+	}
+	if !stale {
+		s.add(conversationID, cloneHistory(history))
+	}
+	return history, nil
 }
 
-func (s *ConversationStore) History(conversationID string) []rag.ChatMessage {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	history := s.histories[conversationID]
-	if len(history) == 0 {
-		return nil
+// Append stores msg in the repository and then adds it to the cached
+// history. If storing fails the cached history is dropped, so it is not
+// ahead of the repository.
+func (s *ConversationStore) Append(ctx context.Context, conversationID string, msg ConversationMessage) error {
+	s.mu.Lock()
+	s.writing[conversationID]++
+	s.mu.Unlock()
+
+	var err error
+	if s.repo != nil {
+		err = s.repo.AddMessage(ctx, conversationID, msg)
 	}
 
-	clone := make([]rag.ChatMessage, len(history))
-	copy(clone, history)
-	return clone
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writing[conversationID]--; s.writing[conversationID] == 0 {
+		delete(s.writing, conversationID)
+	}
+	if s.loading[conversationID] > 0 {
+		s.stale[conversationID] = true
+	}
+	el, cached := s.entries[conversationID]
+	switch {
+	case err != nil:
+		if cached {
+			s.remove(el)
+		}
+		return err
+	case cached:
+		entry := el.Value.(*cachedHistory)
+		entry.messages = append(entry.messages, rag.ChatMessage{Role: msg.Role, Content: msg.Content})
+		s.order.MoveToFront(el)
+	case s.repo == nil:
+		s.add(conversationID, []rag.ChatMessage{{Role: msg.Role, Content: msg.Content}})
+	}
+	return nil
 }
 
-func (s *ConversationStore) End(conversationID string) {
+// Invalidate drops the cached history of conversationID, after it was
+// changed or deleted in the repository.
+func (s *ConversationStore) Invalidate(conversationID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	delete(s.histories, conversationID)
+	if el, ok := s.entries[conversationID]; ok {
+		s.remove(el)
+	}
+	if s.loading[conversationID] > 0 {
+		s.stale[conversationID] = true
+	}
+}
+
+func (s *ConversationStore) add(conversationID string, messages []rag.ChatMessage) {
+	s.entries[conversationID] = s.order.PushFront(&cachedHistory{id: conversationID, messages: messages})
+	for s.order.Len() > s.capacity {
+		s.remove(s.order.Back())
+	}
+}
+
+func (s *ConversationStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*cachedHistory).id)
+}
+
+func cloneHistory(history []rag.ChatMessage) []rag.ChatMessage {
+	clone := make([]rag.ChatMessage, len(history))
+	copy(clone, history)
+	return clone
 }
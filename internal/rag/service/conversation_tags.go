@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxConversationTagLength is the longest tag accepted, in characters.
+const MaxConversationTagLength = 30
+
+// ErrInvalidTag is returned for empty or too long tags and tags with commas,
+// which separate tags in list filters.
+var ErrInvalidTag = errors.New("invalid conversation tag")
+
+// NormalizeConversationTags trims tags and drops duplicates, keeping their
+// order.
+func NormalizeConversationTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || utf8.RuneCountInString(tag) > MaxConversationTagLength || strings.Contains(tag, ",") {
+			return nil, ErrInvalidTag
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	return result, nil
+}
+
+func (s *ChatbotService) ListConversationTags(ctx context.Context) ([]ConversationTagCount, error) {
+	if s.convRepo == nil {
+		return nil, fmt.Errorf("conversation store not configured")
+	}
+	return s.convRepo.Tags(ctx)
+}
+
+func (s *ChatbotService) AddConversationTags(ctx context.Context, id string, tags []string, createdBy string) error {
+	if s.convRepo == nil {
+		return fmt.Errorf("conversation store not configured")
+	}
+	tags, err := NormalizeConversationTags(tags)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return ErrInvalidTag
+	}
+	return s.convRepo.AddTags(ctx, id, tags, createdBy)
+}
+
+func (s *ChatbotService) RemoveConversationTag(ctx context.Context, id, tag string) error {
+	if s.convRepo == nil {
+		return fmt.Errorf("conversation store not configured")
+	}
+	return s.convRepo.RemoveTag(ctx, id, tag)
+}
+
+func (s *ChatbotService) RenameConversationTag(ctx context.Context, tag, to string) (int, error) {
+	if s.convRepo == nil {
+		return 0, fmt.Errorf("conversation store not configured")
+	}
+	renamed, err := NormalizeConversationTags([]string{to})
+	if err != nil {
+		return 0, err
+	}
+	if renamed[0] == tag {
+		return 0, ErrInvalidTag
+	}
+	return s.convRepo.RenameTag(ctx, tag, renamed[0])
+}
+
+func (s *ChatbotService) DeleteConversationTag(ctx context.Context, tag string) (int, error) {
+	if s.convRepo == nil {
+		return 0, fmt.Errorf("conversation store not configured")
+	}
+	return s.convRepo.DeleteTag(ctx, tag)
+}
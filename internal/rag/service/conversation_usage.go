@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yuon/configuration"
+)
+
+// MessageUsage is the tokens one answer used and their estimated cost in
+// USD, nil when its model has no configured price.
+type MessageUsage struct {
+	MessageID        string    `json:"messageId"`
+	Timestamp        time.Time `json:"timestamp"`
+	Model            string    `json:"model,omitempty"`
+	PromptTokens     int       `json:"promptTokens"`
+	CompletionTokens int       `json:"completionTokens"`
+	TotalTokens      int       `json:"totalTokens"`
+	Cost             *float64  `json:"cost"`
+}
+
+// UsageTotals sums the answers of a conversation. Cost leaves out
+// UnpricedTokens, used by models without a price.
+type UsageTotals struct {
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	TotalTokens      int     `json:"totalTokens"`
+	Cost             float64 `json:"cost"`
+	UnpricedTokens   int     `json:"unpricedTokens"`
+}
+
+// ConversationUsage breaks the token usage of a conversation down by
+// answer. UntrackedTokens are counted in the conversation total but were
+// used before tokens were recorded per message, or by answers since
+// deleted.
+type ConversationUsage struct {
+	ConversationID  string         `json:"conversationId"`
+	Currency        string         `json:"currency"`
+	Messages        []MessageUsage `json:"messages"`
+	Totals          UsageTotals    `json:"totals"`
+	UntrackedTokens int            `json:"untrackedTokens"`
+}
+
+// ConversationTokenUsage estimates the cost of each answer in conversation
+// id with prices, per million tokens by model.
+func (s *ChatbotService) ConversationTokenUsage(ctx context.Context, id string, prices map[string]configuration.ModelPrice) (*ConversationUsage, error) {
+	if s.convRepo == nil {
+		return nil, fmt.Errorf("conversation store not configured")
+	}
+	summary, err := s.convRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.convRepo.Messages(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &ConversationUsage{ConversationID: id, Currency: "USD", Messages: []MessageUsage{}}
+	for _, m := range messages {
+		total := m.PromptTokens + m.CompletionTokens
+		if total == 0 {
+			continue
+		}
+		item := MessageUsage{
+			MessageID:        m.ID,
+			Timestamp:        m.Timestamp,
+			Model:            m.Model,
+			PromptTokens:     m.PromptTokens,
+			CompletionTokens: m.CompletionTokens,
+			TotalTokens:      total,
+		}
+		if price, ok := prices[m.Model]; ok {
+			cost := (float64(m.PromptTokens)*price.Prompt + float64(m.CompletionTokens)*price.Completion) / 1e6
+			item.Cost = &cost
+			usage.Totals.Cost += cost
+		} else {
+			usage.Totals.UnpricedTokens += total
+		}
+		usage.Totals.PromptTokens += item.PromptTokens
+		usage.Totals.CompletionTokens += item.CompletionTokens
+		usage.Totals.TotalTokens += total
+		usage.Messages = append(usage.Messages, item)
+	}
+	if untracked := summary.TokenUsage - usage.Totals.TotalTokens; untracked > 0 {
+		usage.UntrackedTokens = untracked
+	}
+	return usage, nil
+}
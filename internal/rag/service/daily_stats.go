@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"yuon/internal/rag"
+	"yuon/package/scheduler"
+)
+
+// MaxDailyStatsRange is the most days one backfill or listing may span.
+const MaxDailyStatsRange = 366
+
+var errAnalyticsStoreMissing = errors.New("analytics store not configured")
+
+// AggregateDailyStats computes the snapshot of the day starting at
+// midnight day: documents, conversations and messages as of its end, and
+// the chat sessions answered and their average response time during it.
+// Totals count what is stored now, so a backfilled day leaves out
+// documents and conversations deleted since.
+func (s *ChatbotService) AggregateDailyStats(ctx context.Context, day time.Time) (*DailyStatsSnapshot, error) {
+	if s.convRepo == nil {
+		return nil, fmt.Errorf("conversation store not configured")
+	}
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, errAnalyticsStoreMissing
+	}
+	end := day.AddDate(0, 0, 1)
+	snap := &DailyStatsSnapshot{Date: day.Format(time.DateOnly)}
+
+	documents, err := s.fullText.CountDocuments(ctx, &rag.SearchFilters{UploadedBefore: &end})
+	if err != nil {
+		return nil, fmt.Errorf("count documents failed: %w", err)
+	}
+	snap.TotalDocuments = documents
+	if snap.TotalConversations, err = s.convRepo.Count(ctx, end); err != nil {
+		return nil, err
+	}
+	if snap.TotalMessages, err = s.convRepo.CountMessages(ctx, end); err != nil {
+		return nil, err
+	}
+	activity, err := s.analytics.store.GetActivity(ctx, day, end)
+	if err != nil {
+		return nil, err
+	}
+	snap.ActiveUsers = activity.ActiveUsers
+	snap.AvgResponseTime = activity.AvgResponseTime
+	return snap, nil
+}
+
+// SnapshotDailyStats records the statistics of the day starting at
+// midnight day, replacing an earlier snapshot of it.
+func (s *ChatbotService) SnapshotDailyStats(ctx context.Context, day time.Time) error {
+	snap, err := s.AggregateDailyStats(ctx, day)
+	if err != nil {
+		return err
+	}
+	if err := s.analytics.store.SnapshotDailyStats(ctx, *snap); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "일별 통계 기록",
+		"date", snap.Date, "documents", snap.TotalDocuments, "conversations", snap.TotalConversations,
+		"messages", snap.TotalMessages, "active_users", snap.ActiveUsers)
+	return nil
+}
+
+// BackfillDailyStats snapshots every day from through to, both midnights in
+// the same location. Days already recorded are kept unless overwrite. It
+// returns the dates recorded.
+func (s *ChatbotService) BackfillDailyStats(ctx context.Context, from, to time.Time, overwrite bool) ([]string, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, errAnalyticsStoreMissing
+	}
+	recorded := make(map[string]bool)
+	if !overwrite {
+		existing, err := s.analytics.store.ListDailyStats(ctx, from.Format(time.DateOnly), to.Format(time.DateOnly))
+		if err != nil {
+			return nil, err
+		}
+		for _, snap := range existing {
+			recorded[snap.Date] = true
+		}
+	}
+
+	dates := []string{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		if recorded[date] {
+			continue
+		}
+		if err := s.SnapshotDailyStats(ctx, day); err != nil {
+			return dates, fmt.Errorf("snapshot %s: %w", date, err)
+		}
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+func (s *ChatbotService) ListDailyStats(ctx context.Context, from, to time.Time) ([]DailyStatsSnapshot, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, errAnalyticsStoreMissing
+	}
+	return s.analytics.store.ListDailyStats(ctx, from.Format(time.DateOnly), to.Format(time.DateOnly))
+}
+
+// RunDailyStats fills in the last backfillDays days that have no snapshot,
+// then snapshots each day after midnight in loc until ctx is done.
+func (s *ChatbotService) RunDailyStats(ctx context.Context, loc *time.Location, backfillDays int) {
+	if backfillDays > 0 {
+		yesterday := scheduler.Day(time.Now(), loc).AddDate(0, 0, -1)
+		dates, err := s.BackfillDailyStats(ctx, yesterday.AddDate(0, 0, 1-backfillDays), yesterday, false)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "일별 통계 보충 실패", "error", err)
+		} else if len(dates) > 0 {
+			slog.InfoContext(ctx, "일별 통계 보충 완료", "days", len(dates))
+		}
+	}
+	scheduler.Daily(ctx, "daily_stats", loc, s.SnapshotDailyStats)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"yuon/internal/rag"
+)
+
+// DocumentHit is how many answers returned a document as a source, and how
+// many of them cited it.
+type DocumentHit struct {
+	DocumentID string
+	Filename   string
+	Retrieved  int
+	Cited      int
+}
+
+// DocumentHitStats is how often a document was a source over a period.
+// CitationRate is the share of those answers that cited it.
+type DocumentHitStats struct {
+	DocumentID      string  `json:"documentId"`
+	Filename        string  `json:"filename"`
+	Retrieved       int64   `json:"retrieved"`
+	Cited           int64   `json:"cited"`
+	CitationRate    float64 `json:"citationRate"`
+	LastRetrievedOn string  `json:"lastRetrievedOn"`
+}
+
+// UnusedDocument is a document no answer retrieved over a period.
+type UnusedDocument struct {
+	DocumentID string `json:"documentId"`
+	Filename   string `json:"filename"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+func (s *PostgresAnalyticsStore) RecordDocumentHits(ctx context.Context, hits []DocumentHit) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, hit := range hits {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO document_hits (document_id, date, filename, retrieved, cited)
+			VALUES ($1, CURRENT_DATE, $2, $3, $4)
+			ON CONFLICT (document_id, date) DO UPDATE SET
+				filename = EXCLUDED.filename,
+				retrieved = document_hits.retrieved + EXCLUDED.retrieved,
+				cited = document_hits.cited + EXCLUDED.cited
+		`, hit.DocumentID, hit.Filename, hit.Retrieved, hit.Cited); err != nil {
+			return fmt.Errorf("document hit upsert failed: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresAnalyticsStore) TopDocuments(ctx context.Context, days, limit int, byCited bool) ([]DocumentHitStats, error) {
+	order := "retrieved DESC, cited DESC"
+	if byCited {
+		order = "cited DESC, retrieved DESC"
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT document_id,
+			(ARRAY_AGG(filename ORDER BY date DESC))[1],
+			SUM(retrieved) AS retrieved,
+			SUM(cited) AS cited,
+			MAX(date)::TEXT
+		FROM document_hits
+		WHERE date > CURRENT_DATE - $1::INT
+		GROUP BY document_id
+		ORDER BY `+order+`, document_id
+		LIMIT $2
+	`, days, limit)
+	if err != nil {
+		return nil, fmt.Errorf("top documents query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []DocumentHitStats
+	for rows.Next() {
+		var d DocumentHitStats
+		if err := rows.Scan(&d.DocumentID, &d.Filename, &d.Retrieved, &d.Cited, &d.LastRetrievedOn); err != nil {
+			return nil, err
+		}
+		if d.Retrieved > 0 {
+			d.CitationRate = float64(d.Cited) / float64(d.Retrieved)
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+func (s *PostgresAnalyticsStore) RetrievedDocumentIDs(ctx context.Context, days int) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT document_id FROM document_hits
+		WHERE date > CURRENT_DATE - $1::INT
+	`, days)
+	if err != nil {
+		return nil, fmt.Errorf("retrieved documents query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// citationPattern matches the [문서 N] markers the model is asked to cite
+// sources with, also as [문서 1, 3].
+var citationPattern = regexp.MustCompile(`\[문서\s*(\d+(?:\s*,\s*(?:문서\s*)?\d+)*)\]`)
+
+var citationNumber = regexp.MustCompile(`\d+`)
+
+// citedSources reports which of docs, numbered from 1 in the prompt,
+// answer cites.
+func citedSources(answer string, docs []rag.Document) []bool {
+	cited := make([]bool, len(docs))
+	for _, match := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		for _, num := range citationNumber.FindAllString(match[1], -1) {
+			if n, err := strconv.Atoi(num); err == nil && n >= 1 && n <= len(docs) {
+				cited[n-1] = true
+			}
+		}
+	}
+	return cited
+}
+
+// TopDocuments reports the documents most often used as sources in the
+// last days days.
+func (s *ChatbotService) TopDocuments(ctx context.Context, days, limit int, byCited bool) ([]DocumentHitStats, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, errAnalyticsStoreMissing
+	}
+	return s.analytics.store.TopDocuments(ctx, days, limit, byCited)
+}
+
+// UnusedDocuments returns up to limit documents, oldest first, that were
+// uploaded before the last days days and retrieved by no answer during
+// them, and how many such documents there are.
+func (s *ChatbotService) UnusedDocuments(ctx context.Context, days, limit int) ([]UnusedDocument, int, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, 0, errAnalyticsStoreMissing
+	}
+	ids, err := s.analytics.store.RetrievedDocumentIDs(ctx, days)
+	if err != nil {
+		return nil, 0, err
+	}
+	retrieved := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		retrieved[id] = true
+	}
+
+	before := time.Now().AddDate(0, 0, -days)
+	params := &rag.DocumentListParams{
+		PageSize:      100,
+		SortBy:        "createdAt",
+		SortOrder:     "asc",
+		SearchFilters: rag.SearchFilters{UploadedBefore: &before},
+	}
+	unused := []UnusedDocument{}
+	total := 0
+	for {
+		page, err := s.fullText.ListDocuments(ctx, params)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, doc := range page.Documents {
+			if retrieved[doc.ID] {
+				continue
+			}
+			total++
+			if len(unused) < limit {
+				filename, _ := doc.Metadata["filename"].(string)
+				unused = append(unused, UnusedDocument{DocumentID: doc.ID, Filename: filename, CreatedAt: doc.CreatedAt})
+			}
+		}
+		if !page.HasNext || page.NextCursor == "" {
+			break
+		}
+		params.Cursor = page.NextCursor
+	}
+	return unused, total, nil
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"yuon/package/scheduler"
+)
+
+// Why a question was recorded as a knowledge gap.
+const (
+	GapNoResults = "no_results"
+	GapLowScore  = "low_score"
+)
+
+const (
+	// maxGapQueries is how many distinct questions one report clusters.
+	maxGapQueries = 500
+	// maxGapClusters is how many clusters a report keeps, largest first.
+	maxGapClusters = 10
+	// gapExamples is how many example questions a cluster shows.
+	gapExamples = 3
+	// gapEmbeddingBatch is how many questions are embedded per request.
+	gapEmbeddingBatch = 100
+)
+
+// KnowledgeGapOptions decide which questions count as knowledge gaps and
+// how they are grouped.
+type KnowledgeGapOptions struct {
+	// MinScore is the vector similarity below which the best document found
+	// does not count as an answer.
+	MinScore float64
+	// Similarity is the cosine similarity above which questions are
+	// clustered together.
+	Similarity float64
+}
+
+var DefaultKnowledgeGapOptions = KnowledgeGapOptions{MinScore: 0.35, Similarity: 0.8}
+
+// GapQuery is a distinct question and how often it was asked.
+type GapQuery struct {
+	Query string
+	Count int
+}
+
+// KnowledgeGapCluster is a group of similar unanswered questions and the
+// document suggested to cover them. PreviousCount is the size of the
+// matching cluster in the previous report, and Trend compares the two:
+// "new", "rising", "falling" or "steady".
+type KnowledgeGapCluster struct {
+	Topic         string   `json:"topic"`
+	Reason        string   `json:"reason,omitempty"`
+	Count         int      `json:"count"`
+	Examples      []string `json:"examples"`
+	PreviousCount int      `json:"previousCount"`
+	Trend         string   `json:"trend"`
+
+	centroid []float32
+}
+
+// KnowledgeGapReport ranks the knowledge gaps of the questions asked in
+// [PeriodStart, PeriodEnd).
+type KnowledgeGapReport struct {
+	ID           string                `json:"id"`
+	PeriodStart  time.Time             `json:"periodStart"`
+	PeriodEnd    time.Time             `json:"periodEnd"`
+	TotalQueries int                   `json:"totalQueries"`
+	Clusters     []KnowledgeGapCluster `json:"clusters"`
+	CreatedAt    time.Time             `json:"createdAt"`
+}
+
+// storedGapCluster keeps the centroid with the cluster, to match clusters
+// of the next report against.
+type storedGapCluster struct {
+	KnowledgeGapCluster
+	Centroid []float32 `json:"centroid"`
+}
+
+func (s *PostgresAnalyticsStore) RecordKnowledgeGap(ctx context.Context, query, reason string, bestScore float64) error {
+	var score any
+	if bestScore >= 0 {
+		score = bestScore
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO knowledge_gap_queries (query, reason, best_score)
+		VALUES ($1, $2, $3)
+	`, query, reason, score)
+	return err
+}
+
+func (s *PostgresAnalyticsStore) KnowledgeGapQueries(ctx context.Context, from, to time.Time, limit int) ([]GapQuery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT MIN(query), COUNT(*) AS asked
+		FROM knowledge_gap_queries
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY LOWER(BTRIM(query))
+		ORDER BY asked DESC, MIN(query)
+		LIMIT $3
+	`, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge gap query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var queries []GapQuery
+	for rows.Next() {
+		var q GapQuery
+		if err := rows.Scan(&q.Query, &q.Count); err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	return queries, rows.Err()
+}
+
+func (s *PostgresAnalyticsStore) SaveKnowledgeGapReport(ctx context.Context, report KnowledgeGapReport) error {
+	stored := make([]storedGapCluster, len(report.Clusters))
+	for i, c := range report.Clusters {
+		stored[i] = storedGapCluster{KnowledgeGapCluster: c, Centroid: c.centroid}
+	}
+	clusters, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO knowledge_gap_reports (id, period_start, period_end, total_queries, clusters, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, report.ID, report.PeriodStart, report.PeriodEnd, report.TotalQueries, clusters, report.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("knowledge gap report insert failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresAnalyticsStore) KnowledgeGapReports(ctx context.Context, before time.Time, limit int) ([]KnowledgeGapReport, error) {
+	query := `
+		SELECT id, period_start, period_end, total_queries, clusters, created_at
+		FROM knowledge_gap_reports`
+	args := []any{limit}
+	if !before.IsZero() {
+		query += ` WHERE period_end <= $2`
+		args = append(args, before)
+	}
+	query += ` ORDER BY period_end DESC, created_at DESC LIMIT $1`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge gap reports query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []KnowledgeGapReport
+	for rows.Next() {
+		var r KnowledgeGapReport
+		var raw []byte
+		if err := rows.Scan(&r.ID, &r.PeriodStart, &r.PeriodEnd, &r.TotalQueries, &raw, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		var stored []storedGapCluster
+		if err := json.Unmarshal(raw, &stored); err != nil {
+			return nil, fmt.Errorf("knowledge gap report %s: %w", r.ID, err)
+		}
+		r.Clusters = make([]KnowledgeGapCluster, len(stored))
+		for i, c := range stored {
+			r.Clusters[i] = c.KnowledgeGapCluster
+			r.Clusters[i].centroid = c.Centroid
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+// SetKnowledgeGapOptions replaces DefaultKnowledgeGapOptions.
+func (s *ChatbotService) SetKnowledgeGapOptions(opts KnowledgeGapOptions) {
+	s.gapOptions = opts
+}
+
+// recordKnowledgeGap stores message as a knowledge gap when no documents
+// were found, or when vector search scored all of them below MinScore;
+// bestScore is negative when vector search did not run on its own.
+func (s *ChatbotService) recordKnowledgeGap(ctx context.Context, message string, found int, bestScore float64) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return
+	}
+	reason := ""
+	switch {
+	case found == 0:
+		reason = GapNoResults
+	case bestScore >= 0 && bestScore < s.gapOptions.MinScore:
+		reason = GapLowScore
+	default:
+		return
+	}
+	if err := s.analytics.store.RecordKnowledgeGap(ctx, message, reason, bestScore); err != nil {
+		slog.WarnContext(ctx, "지식 공백 질문 기록 실패", "error", err)
+	}
+}
+
+// GenerateKnowledgeGapReport clusters the knowledge gap questions asked in
+// [from, to) by embedding similarity, suggests a document for each of the
+// largest clusters, compares them with the previous report and stores the
+// report.
+func (s *ChatbotService) GenerateKnowledgeGapReport(ctx context.Context, from, to time.Time) (*KnowledgeGapReport, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, errAnalyticsStoreMissing
+	}
+	queries, err := s.analytics.store.KnowledgeGapQueries(ctx, from, to, maxGapQueries)
+	if err != nil {
+		return nil, err
+	}
+
+	report := KnowledgeGapReport{
+		ID:          uuid.NewString(),
+		PeriodStart: from,
+		PeriodEnd:   to,
+		Clusters:    []KnowledgeGapCluster{},
+		CreatedAt:   time.Now().UTC(),
+	}
+	for _, q := range queries {
+		report.TotalQueries += q.Count
+	}
+
+	if len(queries) > 0 {
+		texts := make([]string, len(queries))
+		for i, q := range queries {
+			texts[i] = q.Query
+		}
+		vectors := make([][]float32, 0, len(texts))
+		for start := 0; start < len(texts); start += gapEmbeddingBatch {
+			end := min(start+gapEmbeddingBatch, len(texts))
+			batch, err := s.llm.GenerateEmbeddings(ctx, texts[start:end])
+			if err != nil {
+				return nil, err
+			}
+			vectors = append(vectors, batch...)
+		}
+
+		report.Clusters = clusterGapQueries(queries, vectors, s.gapOptions.Similarity)
+		s.suggestGapTopics(ctx, report.Clusters)
+	}
+
+	previous, err := s.analytics.store.KnowledgeGapReports(ctx, from, 1)
+	if err != nil {
+		return nil, err
+	}
+	var before []KnowledgeGapCluster
+	if len(previous) > 0 {
+		before = previous[0].Clusters
+	}
+	compareGapClusters(report.Clusters, before, s.gapOptions.Similarity)
+
+	if err := s.analytics.store.SaveKnowledgeGapReport(ctx, report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (s *ChatbotService) ListKnowledgeGapReports(ctx context.Context, limit int) ([]KnowledgeGapReport, error) {
+	if s.analytics == nil || s.analytics.store == nil {
+		return nil, errAnalyticsStoreMissing
+	}
+	return s.analytics.store.KnowledgeGapReports(ctx, time.Time{}, limit)
+}
+
+// RunKnowledgeGapReports reports on the previous week every Monday at
+// midnight in loc, until ctx is done.
+func (s *ChatbotService) RunKnowledgeGapReports(ctx context.Context, loc *time.Location) {
+	scheduler.Weekly(ctx, "knowledge_gap_report", loc, time.Monday, func(ctx context.Context, weekStart time.Time) error {
+		report, err := s.GenerateKnowledgeGapReport(ctx, weekStart, weekStart.AddDate(0, 0, 7))
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "지식 공백 주간 보고서 생성", "id", report.ID, "queries", report.TotalQueries, "clusters", len(report.Clusters))
+		return nil
+	})
+}
+
+// suggestGapTopics names the document each cluster needs. Without an answer
+// from the model a cluster is named after its most asked question.
+func (s *ChatbotService) suggestGapTopics(ctx context.Context, clusters []KnowledgeGapCluster) {
+	groups := make([][]string, len(clusters))
+	for i, c := range clusters {
+		groups[i] = c.Examples
+		clusters[i].Topic = c.Examples[0]
+	}
+	suggestions, err := s.llm.SuggestDocumentTopics(ctx, groups)
+	if err != nil {
+		slog.WarnContext(ctx, "문서 주제 제안 실패", "error", err)
+		return
+	}
+	for i, suggestion := range suggestions {
+		if suggestion.Topic != "" {
+			clusters[i].Topic = suggestion.Topic
+			clusters[i].Reason = suggestion.Reason
+		}
+	}
+}
+
+// clusterGapQueries groups queries, most asked first, into the first
+// cluster whose centroid is at least similarity close, and returns the
+// largest clusters.
+func clusterGapQueries(queries []GapQuery, vectors [][]float32, similarity float64) []KnowledgeGapCluster {
+	var clusters []KnowledgeGapCluster
+	var sums [][]float64
+	for i, q := range queries {
+		vec := normalizeVector(vectors[i])
+		best, bestSim := -1, similarity
+		for j, sum := range sums {
+			if sim := cosineSimilarity(sum, vec); sim >= bestSim {
+				best, bestSim = j, sim
+			}
+		}
+		if best < 0 {
+			clusters = append(clusters, KnowledgeGapCluster{Examples: []string{}})
+			sums = append(sums, make([]float64, len(vec)))
+			best = len(clusters) - 1
+		}
+		c := &clusters[best]
+		c.Count += q.Count
+		if len(c.Examples) < gapExamples {
+			c.Examples = append(c.Examples, q.Query)
+		}
+		for k, v := range vec {
+			sums[best][k] += v * float64(q.Count)
+		}
+	}
+
+	for i := range clusters {
+		centroid := normalizeVector64(sums[i])
+		clusters[i].centroid = make([]float32, len(centroid))
+		for k, v := range centroid {
+			clusters[i].centroid[k] = float32(v)
+		}
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].Count > clusters[j].Count
+	})
+	if len(clusters) > maxGapClusters {
+		clusters = clusters[:maxGapClusters]
+	}
+	return clusters
+}
+
+// compareGapClusters sets the trend of each cluster against the closest
+// cluster of the previous report.
+func compareGapClusters(clusters, previous []KnowledgeGapCluster, similarity float64) {
+	for i := range clusters {
+		c := &clusters[i]
+		c.Trend = "new"
+		vec := normalizeVector(c.centroid)
+		bestSim := similarity
+		for _, p := range previous {
+			if len(p.centroid) != len(vec) {
+				continue
+			}
+			if sim := cosineSimilarity(normalizeVector(p.centroid), vec); sim >= bestSim {
+				bestSim = sim
+				c.PreviousCount = p.Count
+			}
+		}
+		switch {
+		case c.PreviousCount == 0:
+		case float64(c.Count) > float64(c.PreviousCount)*1.2:
+			c.Trend = "rising"
+		case float64(c.Count) < float64(c.PreviousCount)*0.8:
+			c.Trend = "falling"
+		default:
+			c.Trend = "steady"
+		}
+	}
+}
+
+func normalizeVector(vec []float32) []float64 {
+	out := make([]float64, len(vec))
+	for i, v := range vec {
+		out[i] = float64(v)
+	}
+	return normalizeVector64(out)
+}
+
+func normalizeVector64(vec []float64) []float64 {
+	var sum float64
+	for _, v := range vec {
+		sum += v * v
+	}
+	norm := math.Sqrt(sum)
+	if norm == 0 {
+		return vec
+	}
+	out := make([]float64, len(vec))
+	for i, v := range vec {
+		out[i] = v / norm
+	}
+	return out
+}
+
+// cosineSimilarity of a and b, the first not necessarily normalized.
+func cosineSimilarity(a, b []float64) float64 {
+	var dot, norm float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += a[i] * b[i]
+		norm += a[i] * a[i]
+	}
+	if norm == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(norm)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"yuon/internal/audit"
+)
+
+// RetentionPolicy is how long conversations are kept after their last
+// activity, by workspace of their owner. Zero keeps them forever.
+type RetentionPolicy struct {
+	Default    time.Duration
+	Workspaces map[string]time.Duration
+	// Anonymize erases owners and message contents instead of deleting.
+	Anonymize bool
+}
+
+// PurgeExpiredConversations applies policy as of now and records an audit
+// event for each workspace rule that removed conversations; the default
+// rule is recorded as workspace "*".
+func (s *ChatbotService) PurgeExpiredConversations(ctx context.Context, policy RetentionPolicy, logger audit.Logger, now time.Time) (int, error) {
+	if s.convRepo == nil {
+		return 0, fmt.Errorf("conversation store not configured")
+	}
+
+	purges := make(map[string]ConversationPurge, len(policy.Workspaces)+1)
+	overridden := make([]string, 0, len(policy.Workspaces))
+	for workspace, keep := range policy.Workspaces {
+		overridden = append(overridden, workspace)
+		if keep > 0 {
+			purges[workspace] = ConversationPurge{Before: now.Add(-keep), Workspaces: []string{workspace}, Anonymize: policy.Anonymize}
+		}
+	}
+	if policy.Default > 0 {
+		purges["*"] = ConversationPurge{Before: now.Add(-policy.Default), ExcludeWorkspaces: overridden, Anonymize: policy.Anonymize}
+	}
+
+	mode := "delete"
+	if policy.Anonymize {
+		mode = "anonymize"
+	}
+	total := 0
+	for workspace, purge := range purges {
+		ids, err := s.convRepo.Purge(ctx, purge)
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		total += len(ids)
+		for _, id := range ids {
+			s.CloseConversation(id)
+		}
+
+		slog.InfoContext(ctx, "보존 기간이 지난 대화 정리", "workspace", workspace, "mode", mode, "count", len(ids))
+		if logger == nil {
+			continue
+		}
+		err = logger.Record(ctx, audit.Event{
+			Action:     audit.ActionConversationsPurged,
+			ActorID:    "system",
+			ActorName:  "retention",
+			TargetType: "conversation",
+			TargetID:   workspace,
+			Details: map[string]interface{}{
+				"mode":   mode,
+				"before": purge.Before,
+				"count":  len(ids),
+			},
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "대화 정리 감사 기록 실패", "error", err, "workspace", workspace)
+		}
+	}
+	return total, nil
+}
+
+// RunConversationRetention purges expired conversations every interval,
+// starting right away, until ctx is done.
+func (s *ChatbotService) RunConversationRetention(ctx context.Context, policy RetentionPolicy, interval time.Duration, logger audit.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeExpiredConversations(ctx, policy, logger, time.Now().UTC()); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "대화 보존 기간 정리 실패", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,12 +1,33 @@
 package rag
 
+import "time"
+
 type Document struct {
-	ID       string                 `json:"id"`
-	Content  string                 `json:"content"`
-	Metadata map[string]interface{} `json:"metadata"`
-	Score    float64                `json:"score,omitempty"`
-	FileKey  string                 `json:"fileKey,omitempty"`
-	FileURL  string                 `json:"fileUrl,omitempty"`
+	ID        string                 `json:"id"`
+	Content   string                 `json:"content"`
+	Metadata  map[string]interface{} `json:"metadata"`
+	Score     float64                `json:"score,omitempty"`
+	FileKey   string                 `json:"fileKey,omitempty"`
+	FileURL   string                 `json:"fileUrl,omitempty"`
+	CreatedAt string                 `json:"createdAt,omitempty"`
+	UpdatedAt string                 `json:"updatedAt,omitempty"`
+}
+
+type Suggestion struct {
+	Text       string  `json:"text"`
+	DocumentID string  `json:"documentId"`
+	Score      float64 `json:"score"`
+}
+
+type DocumentPreview struct {
+	ID               string                 `json:"id"`
+	Excerpt          string                 `json:"excerpt"`
+	ContentLength    int                    `json:"contentLength"`
+	Truncated        bool                   `json:"truncated"`
+	Summary          string                 `json:"summary,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	FileURL          string                 `json:"fileUrl,omitempty"`
+	FileURLExpiresAt string                 `json:"fileUrlExpiresAt,omitempty"`
 }
 
 type ChatMessage struct {
@@ -15,34 +36,128 @@ type ChatMessage struct {
 }
 
 type ChatRequest struct {
-	Message         string        `json:"message" binding:"required"`
-	ConversationID  string        `json:"conversationId,omitempty"`
-	UseVectorSearch bool          `json:"useVectorSearch"`
-	UseFullText     bool          `json:"useFullText"`
-	TopK            int           `json:"topK,omitempty"`
-	History         []ChatMessage `json:"history,omitempty"`
+	Message         string         `json:"message" binding:"required"`
+	ConversationID  string         `json:"conversationId,omitempty"`
+	UseVectorSearch bool           `json:"useVectorSearch"`
+	UseFullText     bool           `json:"useFullText"`
+	TopK            int            `json:"topK,omitempty"`
+	History         []ChatMessage  `json:"history,omitempty"`
+	Filters         *SearchFilters `json:"filters,omitempty"`
+	VectorSpace     string         `json:"vectorSpace,omitempty"`
+	// UserID attributes the message to its user in analytics.
+	UserID string `json:"-"`
+}
+
+// SearchFilters restricts retrieval by metadata. Roles limits results to
+// documents whose allowedRoles include one of the caller's roles; documents
+// without allowedRoles are visible to everyone.
+type SearchFilters struct {
+	Category       string     `json:"category,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	Roles          []string   `json:"-"`
+	UploadedAfter  *time.Time `json:"uploadedAfter,omitempty"`
+	UploadedBefore *time.Time `json:"uploadedBefore,omitempty"`
+}
+
+func (f *SearchFilters) IsEmpty() bool {
+	return f == nil || (f.Category == "" && len(f.Tags) == 0 && len(f.Roles) == 0 &&
+		f.UploadedAfter == nil && f.UploadedBefore == nil)
+}
+
+// RolesAllow reports whether the document with metadata passes a
+// SearchFilters.Roles filter of roles; nil roles let every document pass.
+func RolesAllow(metadata map[string]interface{}, roles []string) bool {
+	if roles == nil {
+		return true
+	}
+	var allowed []string
+	switch v := metadata["allowedRoles"].(type) {
+	case nil:
+		return true
+	case string:
+		allowed = []string{v}
+	case []string:
+		allowed = v
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				allowed = append(allowed, s)
+			}
+		}
+	}
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		for _, r := range roles {
+			if a == r {
+				return true
+			}
+		}
+	}
+	return false
 }
 
+func (f *SearchFilters) HasDateRange() bool {
+	return f != nil && (f.UploadedAfter != nil || f.UploadedBefore != nil)
+}
+
+// ChatResponse is Degraded when vector retrieval failed and the answer is
+// based on full-text results only.
 type ChatResponse struct {
 	Answer         string     `json:"answer"`
 	ConversationID string     `json:"conversationId"`
 	Sources        []Document `json:"sources,omitempty"`
 	TokensUsed     int        `json:"tokensUsed,omitempty"`
+	// PromptTokens and CompletionTokens split TokensUsed; Model is the
+	// model that answered.
+	PromptTokens     int    `json:"promptTokens,omitempty"`
+	CompletionTokens int    `json:"completionTokens,omitempty"`
+	Model            string `json:"model,omitempty"`
+	Degraded         bool   `json:"degraded,omitempty"`
+	Warning          string `json:"warning,omitempty"`
+	// RetrievalTime and LLMTime split the time taken to answer between
+	// finding documents and generating the answer.
+	RetrievalTime time.Duration `json:"-"`
+	LLMTime       time.Duration `json:"-"`
+}
+
+const (
+	ReadinessReady       = "ready"
+	ReadinessDegraded    = "degraded"
+	ReadinessUnavailable = "unavailable"
+)
+
+type ComponentHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ReadinessReport struct {
+	Status     string            `json:"status"`
+	Components []ComponentHealth `json:"components"`
 }
 
 type DocumentListParams struct {
-	Page     int    `json:"page"`
-	PageSize int    `json:"pageSize"`
-	Query    string `json:"query,omitempty"`
-	Category string `json:"category,omitempty"`
+	Page      int    `json:"page"`
+	PageSize  int    `json:"pageSize"`
+	Query     string `json:"query,omitempty"`
+	SortBy    string `json:"sortBy,omitempty"`
+	SortOrder string `json:"sortOrder,omitempty"`
+	Cursor    string `json:"cursor,omitempty"`
+	SearchFilters
 }
 
 type DocumentListResult struct {
-	Documents []Document `json:"documents"`
-	Total     int64      `json:"total"`
-	Page      int        `json:"page"`
-	PageSize  int        `json:"pageSize"`
-	HasNext   bool       `json:"hasNext"`
+	Documents  []Document `json:"documents"`
+	Total      int64      `json:"total"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"pageSize"`
+	HasNext    bool       `json:"hasNext"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+	// HasMore mirrors HasNext under the name shared by all list APIs.
+	HasMore bool `json:"hasMore"`
 }
 
 type DocumentStats struct {
@@ -51,16 +166,147 @@ type DocumentStats struct {
 	LastUpdatedAt  string `json:"lastUpdatedAt,omitempty"`
 }
 
+type VectorSpaceInfo struct {
+	Name     string `json:"name"`
+	Size     uint64 `json:"size"`
+	Distance string `json:"distance"`
+	OnDisk   bool   `json:"onDisk"`
+}
+
+// VectorCollectionStats reports Qdrant collection info next to the OpenSearch
+// document count. Drift is PointsCount minus SearchDocuments.
+type VectorCollectionStats struct {
+	Collection          string            `json:"collection"`
+	Status              string            `json:"status"`
+	OptimizerOK         bool              `json:"optimizerOk"`
+	OptimizerError      string            `json:"optimizerError,omitempty"`
+	PointsCount         uint64            `json:"pointsCount"`
+	IndexedVectorsCount uint64            `json:"indexedVectorsCount"`
+	SegmentsCount       uint64            `json:"segmentsCount"`
+	Indexed             bool              `json:"indexed"`
+	Vectors             []VectorSpaceInfo `json:"vectors"`
+	SearchIndex         string            `json:"searchIndex"`
+	SearchDocuments     int64             `json:"searchDocuments"`
+	Drift               int64             `json:"drift"`
+}
+
+type IndexShard struct {
+	Index       string `json:"index"`
+	Shard       string `json:"shard"`
+	Primary     bool   `json:"primary"`
+	State       string `json:"state"`
+	Documents   string `json:"documents,omitempty"`
+	SizeInBytes string `json:"sizeInBytes,omitempty"`
+	Node        string `json:"node,omitempty"`
+}
+
+type DetailedIndexStats struct {
+	Index               string              `json:"index"`
+	Health              string              `json:"health"`
+	TotalDocuments      int64               `json:"totalDocuments"`
+	DeletedDocuments    int64               `json:"deletedDocuments"`
+	SizeInBytes         int64               `json:"sizeInBytes"`
+	PrimarySizeInBytes  int64               `json:"primarySizeInBytes"`
+	SegmentCount        int64               `json:"segmentCount"`
+	ActivePrimaryShards int                 `json:"activePrimaryShards"`
+	ActiveShards        int                 `json:"activeShards"`
+	RelocatingShards    int                 `json:"relocatingShards"`
+	InitializingShards  int                 `json:"initializingShards"`
+	UnassignedShards    int                 `json:"unassignedShards"`
+	Shards              []IndexShard        `json:"shards"`
+	Categories          []AggregationBucket `json:"categories"`
+	CollectedAt         string              `json:"collectedAt"`
+}
+
+type AggregationBucket struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+type DocumentAggregations struct {
+	TotalDocuments int64               `json:"totalDocuments"`
+	Categories     []AggregationBucket `json:"categories"`
+	Tags           []AggregationBucket `json:"tags"`
+	UploadsByMonth []AggregationBucket `json:"uploadsByMonth"`
+}
+
+// DashboardStats covers the last PeriodHours; trends are percent changes
+// against the preceding period of the same length.
 type DashboardStats struct {
+	PeriodHours        int     `json:"period_hours"`
 	TotalDocuments     int64   `json:"total_documents"`
 	TotalConversations int64   `json:"total_conversations"`
 	ActiveUsers        int64   `json:"active_users"`
 	AvgResponseTime    float64 `json:"avg_response_time,omitempty"`
-	// Trends (compared to previous period)
+	// Trends (compared to previous period). Active users are distinct chat
+	// sessions that received an answer within the period.
 	DocumentsTrend     float64 `json:"documents_trend,omitempty"`
 	ConversationsTrend float64 `json:"conversations_trend,omitempty"`
 	ActiveUsersTrend   float64 `json:"active_users_trend,omitempty"`
 	ResponseTimeTrend  float64 `json:"response_time_trend,omitempty"`
+	// Latency gives response time percentiles over the period, from the
+	// response metrics not yet rolled up.
+	Latency *LatencyStats `json:"latency,omitempty"`
+}
+
+// DashboardDelta is the chat activity since the previous live dashboard
+// update: the answers given in [From, To) and their average response time
+// in seconds, and the chat sessions active in the last few minutes.
+type DashboardDelta struct {
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	Messages        int64     `json:"messages"`
+	ActiveUsers     int64     `json:"active_users"`
+	AvgResponseTime float64   `json:"avg_response_time"`
+}
+
+// LatencyStats are latency percentiles of the answers in a period: the
+// whole chat response, retrieval and LLM generation. A part is nil without
+// samples.
+type LatencyStats struct {
+	Samples   int64               `json:"samples"`
+	Chat      *LatencyPercentiles `json:"chat"`
+	Retrieval *LatencyPercentiles `json:"retrieval"`
+	LLM       *LatencyPercentiles `json:"llm"`
+}
+
+// LatencyPercentiles are in milliseconds.
+type LatencyPercentiles struct {
+	P50 float64 `json:"p50_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+}
+
+type IndexMigrationResult struct {
+	Alias           string   `json:"alias"`
+	PreviousIndices []string `json:"previousIndices"`
+	NewIndex        string   `json:"newIndex"`
+	Analyzer        string   `json:"analyzer"`
+	Documents       int64    `json:"documents"`
+	// Workspaces lists the workspace indexes migrated after the base index.
+	Workspaces []IndexMigrationResult `json:"workspaces,omitempty"`
+}
+
+// VectorSnapshot describes a Qdrant collection snapshot. FileKey is set once
+// the snapshot has been copied to object storage.
+type VectorSnapshot struct {
+	Name       string `json:"name"`
+	Collection string `json:"collection"`
+	Size       int64  `json:"size"`
+	Checksum   string `json:"checksum,omitempty"`
+	CreatedAt  string `json:"createdAt,omitempty"`
+	FileKey    string `json:"fileKey,omitempty"`
+}
+
+// DeleteByFilterRequest deletes documents whose metadata matches every
+// key/value pair, e.g. {"parentId": "X"} or {"category": "Y"}.
+type DeleteByFilterRequest struct {
+	Filter map[string]string `json:"filter" binding:"required"`
+}
+
+type DeleteByFilterResult struct {
+	Documents int64  `json:"documents"`
+	Vectors   uint64 `json:"vectors"`
 }
 
 type ReindexRequest struct {
@@ -85,6 +331,9 @@ type VectorQueryRequest struct {
 	Limit       int      `json:"limit,omitempty"`
 	WithPayload bool     `json:"withPayload"`
 	Offset      string   `json:"offset,omitempty"`
+	// Roles leaves out documents these roles may not see, as in
+	// SearchFilters.
+	Roles []string `json:"-"`
 }
 
 type VectorQueryResponse struct {
@@ -95,9 +344,10 @@ type VectorQueryResponse struct {
 }
 
 type VectorProjectionRequest struct {
-	Limit       int    `json:"limit,omitempty"`
-	Offset      string `json:"offset,omitempty"`
-	WithPayload bool   `json:"withPayload"`
+	Limit       int      `json:"limit,omitempty"`
+	Offset      string   `json:"offset,omitempty"`
+	WithPayload bool     `json:"withPayload"`
+	Roles       []string `json:"-"`
 }
 
 type ProjectedVector struct {
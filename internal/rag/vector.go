@@ -0,0 +1,34 @@
+package rag
+
+import "context"
+
+// Vectors holds one embedding per vector space. Stores without named
+// vectors use the empty key.
+type Vectors map[string][]float32
+
+// VectorSpace describes a named vector stored with every document.
+type VectorSpace struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+	// Source is "content" or the metadata key whose text is embedded.
+	Source string `json:"source"`
+	// Model overrides the default embedding model for this space.
+	Model string `json:"model,omitempty"`
+}
+
+// VectorStore is implemented by every vector backend (Qdrant, pgvector,
+// Weaviate). QueryDocumentVectors scrolls through stored vectors; offset is
+// an opaque, backend-specific cursor.
+type VectorStore interface {
+	AddDocument(ctx context.Context, doc Document, vectors Vectors) error
+	UpsertBatch(ctx context.Context, docs []Document, vectors []Vectors) (int, error)
+	Search(ctx context.Context, space string, vector []float32, limit int, filters *SearchFilters) ([]Document, error)
+	DeleteDocument(ctx context.Context, docID string) error
+	GetDocumentVector(ctx context.Context, docID string, withPayload bool) (*DocumentVector, error)
+	QueryDocumentVectors(ctx context.Context, docIDs []string, limit int, withPayload bool, offset string) ([]DocumentVector, bool, string, error)
+	CollectionStats(ctx context.Context) (*VectorCollectionStats, error)
+	Spaces() []VectorSpace
+	Space(name string) (VectorSpace, bool)
+	Health(ctx context.Context) error
+	Close() error
+}
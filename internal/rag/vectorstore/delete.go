@@ -0,0 +1,89 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/qdrant/go-client/qdrant"
+	"yuon/internal/rag"
+)
+
+// FilterDeleter is implemented by backends that can delete every point
+// matching exact payload values without enumerating IDs.
+type FilterDeleter interface {
+	DeleteByFilter(ctx context.Context, match map[string]string) (uint64, error)
+}
+
+var (
+	_ FilterDeleter = (*QdrantClient)(nil)
+	_ FilterDeleter = (*PgVectorStore)(nil)
+)
+
+// DeleteByFilter deletes all points of the workspace on ctx whose payload
+// matches every key/value pair (e.g. parentId=X, category=Y) and returns how
+// many were matched.
+func (q *QdrantClient) DeleteByFilter(ctx context.Context, match map[string]string) (uint64, error) {
+	if len(match) == 0 {
+		return 0, fmt.Errorf("삭제 조건이 비어 있습니다")
+	}
+
+	filter := &qdrant.Filter{Must: []*qdrant.Condition{qdrantWorkspace(ctx)}}
+	for key, value := range match {
+		filter.Must = append(filter.Must, qdrant.NewMatchKeyword(key, value))
+	}
+
+	count, err := q.client.Count(ctx, &qdrant.CountPoints{
+		CollectionName: q.collection,
+		Filter:         filter,
+		Exact:          qdrant.PtrOf(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Qdrant 삭제 대상 집계 실패: %w", err)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	_, err = q.client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: q.collection,
+		Wait:           qdrant.PtrOf(true),
+		Points:         qdrant.NewPointsSelectorFilter(filter),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Qdrant 필터 삭제 실패: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteByFilter deletes documents of the workspace on ctx whose metadata
+// matches every key/value pair; embeddings cascade.
+func (p *PgVectorStore) DeleteByFilter(ctx context.Context, match map[string]string) (uint64, error) {
+	if len(match) == 0 {
+		return 0, fmt.Errorf("삭제 조건이 비어 있습니다")
+	}
+
+	keys := make([]string, 0, len(match))
+	for key := range match {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := []interface{}{rag.WorkspaceFromContext(ctx)}
+	clauses := []string{"workspace = $1"}
+	for _, key := range keys {
+		args = append(args, match[key])
+		clauses = append(clauses, fmt.Sprintf("metadata->>%s = $%d", pq.QuoteLiteral(key), len(args)))
+	}
+
+	res, err := p.db.ExecContext(ctx, `DELETE FROM vector_documents WHERE `+strings.Join(clauses, " AND "), args...)
+	if err != nil {
+		return 0, fmt.Errorf("pgvector 필터 삭제 실패: %w", err)
+	}
+
+	affected, _ := res.RowsAffected()
+	return uint64(affected), nil
+}
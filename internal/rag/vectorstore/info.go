@@ -0,0 +1,56 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qdrant/go-client/qdrant"
+	"yuon/internal/rag"
+)
+
+// CollectionStats reads the collection info. Indexed is true when the
+// collection is green with no pending optimizations; small collections below
+// the indexing threshold report zero indexed vectors even then.
+func (q *QdrantClient) CollectionStats(ctx context.Context) (*rag.VectorCollectionStats, error) {
+	info, err := q.client.GetCollectionInfo(ctx, q.collection)
+	if err != nil {
+		return nil, fmt.Errorf("Qdrant 컬렉션 정보 조회 실패: %w", err)
+	}
+
+	stats := &rag.VectorCollectionStats{
+		Collection:          q.collection,
+		Status:              strings.ToLower(info.GetStatus().String()),
+		OptimizerOK:         info.GetOptimizerStatus().GetOk(),
+		OptimizerError:      info.GetOptimizerStatus().GetError(),
+		PointsCount:         info.GetPointsCount(),
+		IndexedVectorsCount: info.GetIndexedVectorsCount(),
+		SegmentsCount:       info.GetSegmentsCount(),
+		Vectors:             []rag.VectorSpaceInfo{},
+	}
+
+	vectors := info.GetConfig().GetParams().GetVectorsConfig()
+	if params := vectors.GetParams(); params != nil {
+		stats.Vectors = append(stats.Vectors, vectorSpaceInfo("", params))
+	}
+	for name, params := range vectors.GetParamsMap().GetMap() {
+		stats.Vectors = append(stats.Vectors, vectorSpaceInfo(name, params))
+	}
+	sort.Slice(stats.Vectors, func(i, j int) bool {
+		return stats.Vectors[i].Name < stats.Vectors[j].Name
+	})
+
+	stats.Indexed = info.GetStatus() == qdrant.CollectionStatus_Green && stats.OptimizerOK
+
+	return stats, nil
+}
+
+func vectorSpaceInfo(name string, params *qdrant.VectorParams) rag.VectorSpaceInfo {
+	return rag.VectorSpaceInfo{
+		Name:     name,
+		Size:     params.GetSize(),
+		Distance: strings.ToLower(params.GetDistance().String()),
+		OnDisk:   params.GetOnDisk(),
+	}
+}
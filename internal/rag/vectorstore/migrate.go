@@ -0,0 +1,117 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// PointIDMigrationResult summarizes a MigratePointIDs run.
+type PointIDMigrationResult struct {
+	Scanned  int `json:"scanned"`
+	Migrated int `json:"migrated"`
+	Skipped  int `json:"skipped"`
+}
+
+// MigratePointIDs rewrites points stored under legacy IDs, numeric (hashed)
+// ones and UUIDs that ignore the point's workspace, to the point IDs derived
+// from the "id" and workspace payload fields. Numeric points without an id
+// payload are left untouched and counted as skipped. Safe to re-run.
+func (q *QdrantClient) MigratePointIDs(ctx context.Context, batchSize int) (*PointIDMigrationResult, error) {
+	if batchSize <= 0 {
+		batchSize = q.batchSize
+	}
+
+	result := &PointIDMigrationResult{}
+	var offset *qdrant.PointId
+
+	for {
+		points, next, err := q.client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+			CollectionName: q.collection,
+			Offset:         offset,
+			Limit:          qdrant.PtrOf(uint32(batchSize)),
+			WithVectors:    qdrant.NewWithVectors(true),
+			WithPayload:    qdrant.NewWithPayload(true),
+		})
+		if err != nil {
+			return result, fmt.Errorf("Qdrant 포인트 스크롤 실패: %w", err)
+		}
+
+		var upserts []*qdrant.PointStruct
+		var legacyIDs []*qdrant.PointId
+		for _, point := range points {
+			result.Scanned++
+			_, numeric := point.GetId().GetPointIdOptions().(*qdrant.PointId_Num)
+			docID := getStringFromValue(point.GetPayload()["id"])
+			workspace := getStringFromValue(point.GetPayload()[workspaceField])
+			if !numeric && (docID == "" || pointIDToString(point.GetId()) == pointIDToString(pointID(workspace, docID))) {
+				continue
+			}
+
+			vectors := retrievedVectors(point)
+			if docID == "" || len(vectors) == 0 {
+				slog.Warn("마이그레이션할 수 없는 포인트", "point", pointIDToString(point.GetId()))
+				result.Skipped++
+				continue
+			}
+
+			upserts = append(upserts, &qdrant.PointStruct{
+				Id:      pointID(workspace, docID),
+				Vectors: q.withSparse(q.pointVectors(vectors), getStringFromValue(point.GetPayload()["content"])),
+				Payload: point.GetPayload(),
+			})
+			legacyIDs = append(legacyIDs, point.GetId())
+		}
+
+		if len(upserts) > 0 {
+			wait := true
+			if _, err := q.client.Upsert(ctx, &qdrant.UpsertPoints{
+				CollectionName: q.collection,
+				Points:         upserts,
+				Wait:           &wait,
+			}); err != nil {
+				return result, fmt.Errorf("UUID 포인트 업서트 실패: %w", err)
+			}
+			if _, err := q.client.Delete(ctx, &qdrant.DeletePoints{
+				CollectionName: q.collection,
+				Points:         qdrant.NewPointsSelector(legacyIDs...),
+				Wait:           &wait,
+			}); err != nil {
+				return result, fmt.Errorf("기존 포인트 삭제 실패: %w", err)
+			}
+			result.Migrated += len(upserts)
+		}
+
+		if next == nil {
+			break
+		}
+		offset = next
+	}
+
+	return result, nil
+}
+
+// retrievedVectors copies every vector on a retrieved point, keyed by name.
+func retrievedVectors(point *qdrant.RetrievedPoint) Vectors {
+	vectors := Vectors{}
+	output := point.GetVectors()
+	if output == nil {
+		return vectors
+	}
+
+	if single := output.GetVector(); single != nil {
+		if v := extractVector(point, ""); len(v) > 0 {
+			vectors[""] = v
+		}
+		return vectors
+	}
+
+	for name := range output.GetVectors().GetVectors() {
+		if v := extractVector(point, name); len(v) > 0 {
+			vectors[name] = v
+		}
+	}
+	return vectors
+}
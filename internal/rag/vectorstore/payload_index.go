@@ -0,0 +1,54 @@
+package vectorstore
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// payloadIndexes are the payload fields used by buildFilter. createdAt holds
+// RFC3339 strings, so it gets a datetime (not integer) index.
+var payloadIndexes = map[string]qdrant.FieldType{
+	"id":           qdrant.FieldType_FieldTypeKeyword,
+	"category":     qdrant.FieldType_FieldTypeKeyword,
+	"tags":         qdrant.FieldType_FieldTypeKeyword,
+	"allowedRoles": qdrant.FieldType_FieldTypeKeyword,
+	"workspace":    qdrant.FieldType_FieldTypeKeyword,
+	"createdAt":    qdrant.FieldType_FieldTypeDatetime,
+}
+
+// ensurePayloadIndexes creates missing payload indexes. Existing indexes are
+// skipped so restarts don't trigger rebuilds.
+func (q *QdrantClient) ensurePayloadIndexes(ctx context.Context) {
+	fields := make(map[string]qdrant.FieldType, len(payloadIndexes)+len(q.keywordIndexes))
+	for field, fieldType := range payloadIndexes {
+		fields[field] = fieldType
+	}
+	for _, field := range q.keywordIndexes {
+		fields[field] = qdrant.FieldType_FieldTypeKeyword
+	}
+
+	existing := map[string]*qdrant.PayloadSchemaInfo{}
+	if info, err := q.client.GetCollectionInfo(ctx, q.collection); err == nil {
+		existing = info.GetPayloadSchema()
+	}
+
+	for field, fieldType := range fields {
+		if _, ok := existing[field]; ok {
+			continue
+		}
+
+		_, err := q.client.CreateFieldIndex(ctx, &qdrant.CreateFieldIndexCollection{
+			CollectionName: q.collection,
+			FieldName:      field,
+			FieldType:      fieldType.Enum(),
+			Wait:           qdrant.PtrOf(true),
+		})
+		if err != nil {
+			slog.Warn("Qdrant payload 인덱스 생성 실패", "collection", q.collection, "field", field, "error", err)
+			continue
+		}
+		slog.Info("Qdrant payload 인덱스 생성", "collection", q.collection, "field", field, "type", fieldType.String())
+	}
+}
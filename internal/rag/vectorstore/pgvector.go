@@ -0,0 +1,517 @@
+package vectorstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"yuon/configuration"
+	"yuon/internal/rag"
+)
+
+// PgVectorStore keeps documents and embeddings in Postgres using the pgvector
+// extension. Documents live in vector_documents; each vector space is a row
+// in vector_embeddings.
+type PgVectorStore struct {
+	db           *sql.DB
+	vectorSize   int
+	batchSize    int
+	spaces       []VectorSpace
+	searchVector string
+}
+
+var indexNameSanitizer = regexp.MustCompile(`[^a-z0-9_]`)
+
+func NewPgVectorStore(db *sql.DB, cfg *configuration.QdrantConfig) (*PgVectorStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("pgvector 사용 시 데이터베이스 연결이 필요합니다")
+	}
+
+	store := &PgVectorStore{
+		db:         db,
+		vectorSize: cfg.VectorSize,
+		batchSize:  cfg.BatchSize,
+		spaces:     vectorSpacesFromConfig(cfg),
+	}
+	if store.batchSize <= 0 {
+		store.batchSize = 64
+	}
+	store.searchVector = defaultSearchVector(store.spaces, cfg.SearchVector)
+
+	if err := store.ensureSchema(context.Background()); err != nil {
+		return nil, fmt.Errorf("pgvector 스키마 초기화 실패: %w", err)
+	}
+
+	return store, nil
+}
+
+func (p *PgVectorStore) ensureSchema(ctx context.Context) error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		`CREATE TABLE IF NOT EXISTS vector_documents (
+			workspace TEXT NOT NULL DEFAULT '',
+			id TEXT NOT NULL,
+			content TEXT NOT NULL DEFAULT '',
+			metadata JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ,
+			PRIMARY KEY (workspace, id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_vector_documents_created_at ON vector_documents(created_at);`,
+		`ALTER TABLE vector_documents ADD COLUMN IF NOT EXISTS workspace TEXT NOT NULL DEFAULT '';`,
+		`CREATE INDEX IF NOT EXISTS idx_vector_documents_workspace ON vector_documents(workspace);`,
+		`CREATE TABLE IF NOT EXISTS vector_embeddings (
+			workspace TEXT NOT NULL DEFAULT '',
+			doc_id TEXT NOT NULL,
+			space TEXT NOT NULL DEFAULT '',
+			embedding vector NOT NULL,
+			PRIMARY KEY (workspace, doc_id, space),
+			FOREIGN KEY (workspace, doc_id) REFERENCES vector_documents(workspace, id) ON DELETE CASCADE
+		);`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := p.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	if err := p.migrateWorkspaceKeys(ctx); err != nil {
+		return fmt.Errorf("워크스페이스 키 마이그레이션 실패: %w", err)
+	}
+
+	// HNSW 인덱스는 차원이 고정되어야 하므로 공간별 부분 표현식 인덱스로 생성
+	for _, space := range p.layout() {
+		name := "idx_vector_embeddings_hnsw"
+		if space.Name != "" {
+			name += "_" + indexNameSanitizer.ReplaceAllString(strings.ToLower(space.Name), "_")
+		}
+		stmt := fmt.Sprintf(
+			`CREATE INDEX IF NOT EXISTS %s ON vector_embeddings USING hnsw ((embedding::vector(%d)) vector_cosine_ops) WHERE space = %s`,
+			pq.QuoteIdentifier(name), space.Size, pq.QuoteLiteral(space.Name),
+		)
+		if _, err := p.db.ExecContext(ctx, stmt); err != nil {
+			slog.Warn("pgvector HNSW 인덱스 생성 실패, 순차 검색으로 동작합니다", "space", space.Name, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// migrateWorkspaceKeys rekeys tables created when documents were keyed by
+// id alone, so the same document ID can exist in several workspaces.
+func (p *PgVectorStore) migrateWorkspaceKeys(ctx context.Context) error {
+	var keyColumns int
+	err := p.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = 'vector_documents'::regclass AND i.indisprimary`).Scan(&keyColumns)
+	if err != nil || keyColumns != 1 {
+		return err
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`ALTER TABLE vector_embeddings ADD COLUMN IF NOT EXISTS workspace TEXT NOT NULL DEFAULT ''`,
+		`UPDATE vector_embeddings e SET workspace = d.workspace FROM vector_documents d WHERE d.id = e.doc_id`,
+		`ALTER TABLE vector_embeddings DROP CONSTRAINT IF EXISTS vector_embeddings_doc_id_fkey`,
+		`ALTER TABLE vector_embeddings DROP CONSTRAINT IF EXISTS vector_embeddings_pkey`,
+		`ALTER TABLE vector_documents DROP CONSTRAINT vector_documents_pkey`,
+		`ALTER TABLE vector_documents ADD PRIMARY KEY (workspace, id)`,
+		`ALTER TABLE vector_embeddings ADD PRIMARY KEY (workspace, doc_id, space)`,
+		`ALTER TABLE vector_embeddings ADD FOREIGN KEY (workspace, doc_id) REFERENCES vector_documents(workspace, id) ON DELETE CASCADE`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// layout returns the stored vector spaces, including the single unnamed
+// vector when no named vectors are configured.
+func (p *PgVectorStore) layout() []VectorSpace {
+	if len(p.spaces) > 0 {
+		return p.spaces
+	}
+	return []VectorSpace{{Name: "", Size: p.vectorSize, Source: "content"}}
+}
+
+func (p *PgVectorStore) Spaces() []VectorSpace {
+	return p.spaces
+}
+
+func (p *PgVectorStore) Space(name string) (VectorSpace, bool) {
+	if name == "" {
+		name = p.searchVector
+	}
+	return findSpace(p.spaces, name)
+}
+
+func (p *PgVectorStore) AddDocument(ctx context.Context, doc rag.Document, vectors Vectors) error {
+	if _, err := p.UpsertBatch(ctx, []rag.Document{doc}, []Vectors{vectors}); err != nil {
+		return fmt.Errorf("문서 추가 실패: %w", err)
+	}
+	return nil
+}
+
+// UpsertBatch writes documents in one transaction per batch. It returns the
+// number of documents written before the first failing batch.
+func (p *PgVectorStore) UpsertBatch(ctx context.Context, docs []rag.Document, vectors []Vectors) (int, error) {
+	if len(docs) != len(vectors) {
+		return 0, fmt.Errorf("문서 수(%d)와 벡터 수(%d)가 다릅니다", len(docs), len(vectors))
+	}
+
+	written := 0
+	for start := 0; start < len(docs); start += p.batchSize {
+		end := min(start+p.batchSize, len(docs))
+		if err := p.upsertTx(ctx, docs[start:end], vectors[start:end]); err != nil {
+			return written, fmt.Errorf("배치 업서트 실패 (%d~%d): %w", start, end-1, err)
+		}
+		written = end
+	}
+
+	return written, nil
+}
+
+func (p *PgVectorStore) upsertTx(ctx context.Context, docs []rag.Document, vectors []Vectors) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	workspace := rag.WorkspaceFromContext(ctx)
+	for i, doc := range docs {
+		if doc.ID == "" {
+			doc.ID = uuid.New().String()
+		}
+
+		metadata, err := json.Marshal(doc.Metadata)
+		if err != nil {
+			return fmt.Errorf("메타데이터 직렬화 실패: %w", err)
+		}
+		if doc.Metadata == nil {
+			metadata = []byte("{}")
+		}
+
+		createdAt := time.Now().UTC()
+		if t, ok := parseTimestamp(doc.CreatedAt); ok {
+			createdAt = t
+		} else if uploadedAt, _ := doc.Metadata["uploadedAt"].(string); uploadedAt != "" {
+			if t, ok := parseTimestamp(uploadedAt); ok {
+				createdAt = t
+			}
+		}
+		var updatedAt *time.Time
+		if t, ok := parseTimestamp(doc.UpdatedAt); ok {
+			updatedAt = &t
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO vector_documents (id, content, metadata, created_at, updated_at, workspace)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (workspace, id) DO UPDATE
+			SET content = EXCLUDED.content, metadata = EXCLUDED.metadata,
+				created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at`,
+			doc.ID, doc.Content, metadata, createdAt, updatedAt, workspace,
+		)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM vector_embeddings WHERE workspace = $1 AND doc_id = $2`, workspace, doc.ID); err != nil {
+			return err
+		}
+		for space, vector := range vectors[i] {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO vector_embeddings (workspace, doc_id, space, embedding) VALUES ($1, $2, $3, $4::vector)`,
+				workspace, doc.ID, space, formatVector(vector),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Search queries one vector space by cosine similarity. An empty space uses
+// the default search space.
+func (p *PgVectorStore) Search(ctx context.Context, space string, vector []float32, limit int, filters *rag.SearchFilters) ([]rag.Document, error) {
+	if space == "" {
+		space = p.searchVector
+	}
+
+	// 인덱스와 같은 표현식을 써야 HNSW 인덱스가 사용됨
+	distance := fmt.Sprintf("e.embedding::vector(%d) <=> $1::vector(%d)", len(vector), len(vector))
+	args := []interface{}{formatVector(vector), space}
+	where, args := pgFilterClauses(ctx, filters, args)
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT d.id, d.content, d.metadata, d.created_at, d.updated_at, 1 - (%s) AS score
+		FROM vector_embeddings e
+		JOIN vector_documents d ON d.workspace = e.workspace AND d.id = e.doc_id
+		WHERE e.space = $2%s
+		ORDER BY %s
+		LIMIT $%d`, distance, where, distance, len(args))
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("검색 실패: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []rag.Document
+	for rows.Next() {
+		var (
+			doc       rag.Document
+			metadata  []byte
+			createdAt time.Time
+			updatedAt sql.NullTime
+		)
+		if err := rows.Scan(&doc.ID, &doc.Content, &metadata, &createdAt, &updatedAt, &doc.Score); err != nil {
+			return nil, fmt.Errorf("검색 결과 파싱 실패: %w", err)
+		}
+		doc.Metadata = decodeMetadata(metadata)
+		doc.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+		if updatedAt.Valid {
+			doc.UpdatedAt = updatedAt.Time.UTC().Format(time.RFC3339)
+		}
+		documents = append(documents, doc)
+	}
+
+	return documents, rows.Err()
+}
+
+// pgFilterClauses mirrors buildFilter for the vector_documents metadata.
+func pgFilterClauses(ctx context.Context, filters *rag.SearchFilters, args []interface{}) (string, []interface{}) {
+	var clauses []string
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	clauses = append(clauses, "d.workspace = "+arg(rag.WorkspaceFromContext(ctx)))
+	if filters.IsEmpty() {
+		return " AND " + clauses[0], args
+	}
+
+	if filters.Category != "" {
+		clauses = append(clauses, "d.metadata->>'category' = "+arg(filters.Category))
+	}
+	if len(filters.Tags) > 0 {
+		clauses = append(clauses, "d.metadata->'tags' ?| "+arg(pq.Array(filters.Tags)))
+	}
+	if len(filters.Roles) > 0 {
+		clauses = append(clauses, fmt.Sprintf(
+			"(NOT d.metadata ? 'allowedRoles' OR d.metadata->'allowedRoles' IN ('null'::jsonb, '[]'::jsonb) OR d.metadata->'allowedRoles' ?| %s)",
+			arg(pq.Array(filters.Roles)),
+		))
+	}
+	if filters.UploadedAfter != nil {
+		clauses = append(clauses, "d.created_at >= "+arg(*filters.UploadedAfter))
+	}
+	if filters.UploadedBefore != nil {
+		clauses = append(clauses, "d.created_at <= "+arg(*filters.UploadedBefore))
+	}
+
+	return " AND " + strings.Join(clauses, " AND "), args
+}
+
+func (p *PgVectorStore) DeleteDocument(ctx context.Context, docID string) error {
+	if _, err := p.db.ExecContext(ctx, `DELETE FROM vector_documents WHERE id = $1 AND workspace = $2`, docID, rag.WorkspaceFromContext(ctx)); err != nil {
+		return fmt.Errorf("pgvector 문서 삭제 실패: %w", err)
+	}
+	return nil
+}
+
+func (p *PgVectorStore) GetDocumentVector(ctx context.Context, docID string, withPayload bool) (*rag.DocumentVector, error) {
+	vectors, err := p.selectVectors(ctx, `d.id = $2`, []interface{}{p.searchVector, docID}, withPayload)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("벡터를 찾을 수 없습니다")
+	}
+	return &vectors[0], nil
+}
+
+// QueryDocumentVectors pages by document ID; offset is the last ID returned.
+func (p *PgVectorStore) QueryDocumentVectors(ctx context.Context, docIDs []string, limit int, withPayload bool, offset string) ([]rag.DocumentVector, bool, string, error) {
+	if len(docIDs) > 0 {
+		vectors, err := p.selectVectors(ctx, `d.id = ANY($2)`, []interface{}{p.searchVector, pq.Array(docIDs)}, withPayload)
+		return vectors, false, "", err
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 512 {
+		limit = 512
+	}
+
+	vectors, err := p.selectVectors(ctx,
+		fmt.Sprintf(`d.id > $2 ORDER BY d.id LIMIT %d`, limit+1),
+		[]interface{}{p.searchVector, offset},
+		withPayload,
+	)
+	if err != nil {
+		return nil, false, "", err
+	}
+
+	if len(vectors) <= limit {
+		return vectors, false, "", nil
+	}
+	vectors = vectors[:limit]
+	return vectors, true, vectors[limit-1].ID, nil
+}
+
+// selectVectors reads the vectors of the workspace on ctx matching
+// condition, which may end in ORDER BY and LIMIT.
+func (p *PgVectorStore) selectVectors(ctx context.Context, condition string, args []interface{}, withPayload bool) ([]rag.DocumentVector, error) {
+	args = append(args, rag.WorkspaceFromContext(ctx))
+	query := `
+		SELECT d.id, d.content, d.metadata, e.embedding::text
+		FROM vector_documents d
+		JOIN vector_embeddings e ON e.workspace = d.workspace AND e.doc_id = d.id AND e.space = $1
+		WHERE d.workspace = $` + strconv.Itoa(len(args)) + ` AND ` + condition
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pgvector 벡터 조회 실패: %w", err)
+	}
+	defer rows.Close()
+
+	var vectors []rag.DocumentVector
+	for rows.Next() {
+		var (
+			vector    rag.DocumentVector
+			content   string
+			metadata  []byte
+			embedding string
+		)
+		if err := rows.Scan(&vector.ID, &content, &metadata, &embedding); err != nil {
+			return nil, fmt.Errorf("pgvector 벡터 파싱 실패: %w", err)
+		}
+		vector.Vector = parseVector(embedding)
+		if withPayload {
+			vector.Content = content
+			if meta := decodeMetadata(metadata); len(meta) > 0 {
+				vector.Metadata = meta
+			}
+		}
+		vectors = append(vectors, vector)
+	}
+
+	return vectors, rows.Err()
+}
+
+func (p *PgVectorStore) CollectionStats(ctx context.Context) (*rag.VectorCollectionStats, error) {
+	stats := &rag.VectorCollectionStats{
+		Collection:  "vector_documents",
+		Status:      "green",
+		OptimizerOK: true,
+		Indexed:     true,
+		Vectors:     []rag.VectorSpaceInfo{},
+	}
+
+	var points, embeddings int64
+	err := p.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM vector_documents), (SELECT COUNT(*) FROM vector_embeddings)`,
+	).Scan(&points, &embeddings)
+	if err != nil {
+		return nil, fmt.Errorf("pgvector 통계 조회 실패: %w", err)
+	}
+	stats.PointsCount = uint64(points)
+	stats.IndexedVectorsCount = uint64(embeddings)
+
+	for _, space := range p.layout() {
+		stats.Vectors = append(stats.Vectors, rag.VectorSpaceInfo{
+			Name:     space.Name,
+			Size:     uint64(space.Size),
+			Distance: "cosine",
+		})
+	}
+
+	return stats, nil
+}
+
+func (p *PgVectorStore) Health(ctx context.Context) error {
+	if err := p.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("pgvector 헬스 체크 실패: %w", err)
+	}
+	return nil
+}
+
+// Close is a no-op; the database connection is owned by the caller.
+func (p *PgVectorStore) Close() error {
+	return nil
+}
+
+func formatVector(vector []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range vector {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func parseVector(raw string) []float32 {
+	raw = strings.Trim(raw, "[] ")
+	if raw == "" {
+		return nil
+	}
+
+	parts := strings.Split(raw, ",")
+	vector := make([]float32, 0, len(parts))
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil
+		}
+		vector = append(vector, float32(v))
+	}
+	return vector
+}
+
+func decodeMetadata(raw []byte) map[string]interface{} {
+	metadata := make(map[string]interface{})
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &metadata)
+	}
+	return metadata
+}
+
+func parseTimestamp(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,61 @@
+package vectorstore
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"yuon/configuration"
+	"yuon/internal/rag"
+)
+
+// TestPgVectorWorkspacesShareDocumentIDs runs against the PostgreSQL database
+// (with the vector extension) named by YUON_TEST_DATABASE_URL, skipping
+// without one.
+func TestPgVectorWorkspacesShareDocumentIDs(t *testing.T) {
+	dsn := os.Getenv("YUON_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("YUON_TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store, err := NewPgVectorStore(db, &configuration.QdrantConfig{VectorSize: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docID := "faq-" + uuid.NewString()
+	a := rag.WithWorkspace(context.Background(), "a-"+docID)
+	b := rag.WithWorkspace(context.Background(), "b-"+docID)
+	defer store.DeleteDocument(a, docID)
+	defer store.DeleteDocument(b, docID)
+
+	if err := store.AddDocument(a, rag.Document{ID: docID, Content: "A"}, Vectors{"": {1, 0, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddDocument(b, rag.Document{ID: docID, Content: "B"}, Vectors{"": {0, 1, 0}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for ctx, want := range map[context.Context]string{a: "A", b: "B"} {
+		vector, err := store.GetDocumentVector(ctx, docID, true)
+		if err != nil {
+			t.Fatalf("%s: %v", rag.WorkspaceFromContext(ctx), err)
+		}
+		if vector.Content != want {
+			t.Errorf("%s: content = %q, want %q", rag.WorkspaceFromContext(ctx), vector.Content, want)
+		}
+	}
+
+	if err := store.DeleteDocument(b, docID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetDocumentVector(a, docID, false); err != nil {
+		t.Errorf("deleting from workspace b removed workspace a's document: %v", err)
+	}
+}
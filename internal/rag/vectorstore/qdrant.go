@@ -3,18 +3,35 @@ package vectorstore
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"yuon/configuration"
 	"yuon/internal/rag"
 )
 
 type QdrantClient struct {
-	client     *qdrant.Client
-	collection string
+	client       *qdrant.Client
+	collection   string
+	batchSize    int
+	spaces       []VectorSpace
+	searchVector string
+	distance     qdrant.Distance
+	quantization quantizationConfig
+	onDisk       bool
+
+	sparseVector   string
+	hybrid         bool
+	keywordIndexes []string
+
+	restURL    string
+	apiKey     string
+	httpClient *http.Client
 }
 
 func NewQdrantClient(cfg *configuration.QdrantConfig) (*QdrantClient, error) {
@@ -27,9 +44,29 @@ func NewQdrantClient(cfg *configuration.QdrantConfig) (*QdrantClient, error) {
 	}
 
 	qc := &QdrantClient{
-		client:     client,
-		collection: cfg.Collection,
+		client:         client,
+		collection:     cfg.Collection,
+		batchSize:      cfg.BatchSize,
+		spaces:         vectorSpacesFromConfig(cfg),
+		quantization:   quantizationFromConfig(cfg),
+		onDisk:         cfg.OnDiskVectors,
+		sparseVector:   cfg.SparseVector,
+		hybrid:         cfg.HybridSearch,
+		keywordIndexes: cfg.PayloadIndexes,
+		restURL:        strings.TrimRight(cfg.URL, "/"),
+		apiKey:         cfg.APIKey,
+		httpClient:     &http.Client{Timeout: 30 * time.Minute},
+	}
+	if err := qc.quantization.validate(); err != nil {
+		return nil, err
+	}
+	if qc.distance, err = parseDistance(cfg.Distance); err != nil {
+		return nil, err
 	}
+	if qc.batchSize <= 0 {
+		qc.batchSize = 64
+	}
+	qc.searchVector = defaultSearchVector(qc.spaces, cfg.SearchVector)
 
 	if err := qc.ensureCollection(cfg.VectorSize); err != nil {
 		return nil, fmt.Errorf("컬렉션 초기화 실패: %w", err)
@@ -43,22 +80,68 @@ func (q *QdrantClient) ensureCollection(vectorSize int) error {
 
 	// 컬렉션 생성 시도 (이미 존재하면 무시)
 	err := q.client.CreateCollection(ctx, &qdrant.CreateCollection{
-		CollectionName: q.collection,
-		VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
-			Size:     uint64(vectorSize),
-			Distance: qdrant.Distance_Cosine,
-		}),
+		CollectionName:      q.collection,
+		VectorsConfig:       q.vectorsConfig(vectorSize),
+		SparseVectorsConfig: q.sparseVectorsConfig(),
+		QuantizationConfig:  q.quantization.create(),
 	})
 
 	// 이미 존재하는 경우 에러 무시
 	if err != nil && !isAlreadyExistsError(err) {
 		return fmt.Errorf("컬렉션 생성 실패: %w", err)
 	}
+	if err != nil {
+		q.checkVectorSpaces(ctx)
+		q.applyQuantization(ctx)
+	}
+	q.ensurePayloadIndexes(ctx)
 
 	return nil
 }
 
-func (q *QdrantClient) AddDocument(ctx context.Context, doc rag.Document, vector []float32) error {
+func (q *QdrantClient) AddDocument(ctx context.Context, doc rag.Document, vectors Vectors) error {
+	_, err := q.client.Upsert(ctx, &qdrant.UpsertPoints{
+		CollectionName: q.collection,
+		Points:         []*qdrant.PointStruct{q.newPoint(rag.WorkspaceFromContext(ctx), doc, vectors)},
+	})
+	if err != nil {
+		return fmt.Errorf("문서 추가 실패: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertBatch writes many points with one Upsert call per batch. It returns
+// the number of documents written before the first failing batch.
+func (q *QdrantClient) UpsertBatch(ctx context.Context, docs []rag.Document, vectors []Vectors) (int, error) {
+	if len(docs) != len(vectors) {
+		return 0, fmt.Errorf("문서 수(%d)와 벡터 수(%d)가 다릅니다", len(docs), len(vectors))
+	}
+
+	workspace := rag.WorkspaceFromContext(ctx)
+	written := 0
+	for start := 0; start < len(docs); start += q.batchSize {
+		end := min(start+q.batchSize, len(docs))
+
+		points := make([]*qdrant.PointStruct, 0, end-start)
+		for i := start; i < end; i++ {
+			points = append(points, q.newPoint(workspace, docs[i], vectors[i]))
+		}
+
+		_, err := q.client.Upsert(ctx, &qdrant.UpsertPoints{
+			CollectionName: q.collection,
+			Points:         points,
+		})
+		if err != nil {
+			return written, fmt.Errorf("배치 업서트 실패 (%d~%d): %w", start, end-1, err)
+		}
+		written = end
+	}
+
+	return written, nil
+}
+
+func (q *QdrantClient) newPoint(workspace string, doc rag.Document, vectors Vectors) *qdrant.PointStruct {
 	if doc.ID == "" {
 		doc.ID = uuid.New().String()
 	}
@@ -70,30 +153,43 @@ func (q *QdrantClient) AddDocument(ctx context.Context, doc rag.Document, vector
 	for k, v := range doc.Metadata {
 		payload[k] = v
 	}
-
-	pointID := hashString(doc.ID)
-
-	_, err := q.client.Upsert(ctx, &qdrant.UpsertPoints{
-		CollectionName: q.collection,
-		Points: []*qdrant.PointStruct{
-			{
-				Id:      qdrant.NewIDNum(pointID),
-				Vectors: qdrant.NewVectors(vector...),
-				Payload: qdrant.NewValueMap(payload),
-			},
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("문서 추가 실패: %w", err)
+	payload["createdAt"] = time.Now().UTC().Format(time.RFC3339)
+	if doc.CreatedAt != "" {
+		payload["createdAt"] = doc.CreatedAt
+	} else if uploadedAt, ok := doc.Metadata["uploadedAt"].(string); ok && uploadedAt != "" {
+		payload["createdAt"] = uploadedAt
+	}
+	if doc.UpdatedAt != "" {
+		payload["updatedAt"] = doc.UpdatedAt
+	}
+	delete(payload, workspaceField)
+	if workspace != "" {
+		payload[workspaceField] = workspace
 	}
 
-	return nil
+	return &qdrant.PointStruct{
+		Id:      pointID(workspace, doc.ID),
+		Vectors: q.withSparse(q.pointVectors(vectors), doc.Content),
+		Payload: qdrant.NewValueMap(payload),
+	}
 }
 
-func (q *QdrantClient) Search(ctx context.Context, vector []float32, limit int) ([]rag.Document, error) {
+// Search queries one vector space. An empty space uses the configured
+// QDRANT_SEARCH_VECTOR (or the unnamed vector).
+func (q *QdrantClient) Search(ctx context.Context, space string, vector []float32, limit int, filters *rag.SearchFilters) ([]rag.Document, error) {
+	var using *string
+	if len(q.spaces) > 0 {
+		if space == "" {
+			space = q.searchVector
+		}
+		using = &space
+	}
+
 	resp, err := q.client.Query(ctx, &qdrant.QueryPoints{
 		CollectionName: q.collection,
 		Query:          qdrant.NewQuery(vector...),
+		Using:          using,
+		Filter:         buildFilter(ctx, filters),
 		Limit:          qdrant.PtrOf(uint64(limit)),
 		WithPayload:    qdrant.NewWithPayload(true),
 	})
@@ -103,30 +199,83 @@ func (q *QdrantClient) Search(ctx context.Context, vector []float32, limit int)
 
 	var documents []rag.Document
 	for _, point := range resp {
-		payload := point.GetPayload()
+		documents = append(documents, scoredPointDocument(point))
+	}
 
-		doc := rag.Document{
-			ID:       getStringFromValue(payload["id"]),
-			Content:  getStringFromValue(payload["content"]),
-			Metadata: make(map[string]interface{}),
-			Score:    float64(point.GetScore()),
-		}
+	return documents, nil
+}
 
-		// ID가 없으면 point ID 사용
-		if doc.ID == "" {
-			doc.ID = fmt.Sprintf("%v", point.GetId())
-		}
+func scoredPointDocument(point *qdrant.ScoredPoint) rag.Document {
+	payload := point.GetPayload()
 
-		for key, value := range payload {
-			if key != "content" && key != "id" {
-				doc.Metadata[key] = extractValue(value)
-			}
+	doc := rag.Document{
+		ID:       getStringFromValue(payload["id"]),
+		Content:  getStringFromValue(payload["content"]),
+		Metadata: make(map[string]interface{}),
+		Score:    float64(point.GetScore()),
+	}
+
+	// ID가 없으면 point ID 사용
+	if doc.ID == "" {
+		doc.ID = fmt.Sprintf("%v", point.GetId())
+	}
+
+	doc.CreatedAt = getStringFromValue(payload["createdAt"])
+	doc.UpdatedAt = getStringFromValue(payload["updatedAt"])
+
+	for key, value := range payload {
+		switch key {
+		case "content", "id", "createdAt", "updatedAt", workspaceField:
+			continue
 		}
+		doc.Metadata[key] = extractValue(value)
+	}
+
+	return doc
+}
 
-		documents = append(documents, doc)
+// buildFilter translates search filters into Qdrant payload conditions,
+// limited to the workspace on ctx.
+func buildFilter(ctx context.Context, filters *rag.SearchFilters) *qdrant.Filter {
+	must := []*qdrant.Condition{qdrantWorkspace(ctx)}
+	if filters.IsEmpty() {
+		return &qdrant.Filter{Must: must}
 	}
 
-	return documents, nil
+	if filters.Category != "" {
+		must = append(must, qdrant.NewMatchKeyword("category", filters.Category))
+	}
+	if len(filters.Tags) > 0 {
+		must = append(must, qdrant.NewMatchKeywords("tags", filters.Tags...))
+	}
+	if len(filters.Roles) > 0 {
+		must = append(must, qdrant.NewFilterAsCondition(&qdrant.Filter{
+			Should: []*qdrant.Condition{
+				qdrant.NewMatchKeywords("allowedRoles", filters.Roles...),
+				qdrant.NewIsEmpty("allowedRoles"),
+			},
+		}))
+	}
+	if filters.HasDateRange() {
+		dateRange := &qdrant.DatetimeRange{}
+		if filters.UploadedAfter != nil {
+			dateRange.Gte = timestamppb.New(*filters.UploadedAfter)
+		}
+		if filters.UploadedBefore != nil {
+			dateRange.Lte = timestamppb.New(*filters.UploadedBefore)
+		}
+		must = append(must, qdrant.NewDatetimeRange("createdAt", dateRange))
+	}
+
+	return &qdrant.Filter{Must: must}
+}
+
+// Health reports whether the Qdrant server answers its health check.
+func (q *QdrantClient) Health(ctx context.Context) error {
+	if _, err := q.client.HealthCheck(ctx); err != nil {
+		return fmt.Errorf("Qdrant 헬스 체크 실패: %w", err)
+	}
+	return nil
 }
 
 func (q *QdrantClient) Close() error {
@@ -137,11 +286,9 @@ func (q *QdrantClient) Close() error {
 }
 
 func (q *QdrantClient) DeleteDocument(ctx context.Context, docID string) error {
-	pointID := hashString(docID)
-
 	_, err := q.client.Delete(ctx, &qdrant.DeletePoints{
 		CollectionName: q.collection,
-		Points:         qdrant.NewPointsSelector(qdrant.NewIDNum(pointID)),
+		Points:         qdrant.NewPointsSelectorFilter(qdrantWorkspaceIDs(ctx, pointID(rag.WorkspaceFromContext(ctx), docID))),
 	})
 	if err != nil {
 		return fmt.Errorf("Qdrant 문서 삭제 실패: %w", err)
@@ -151,11 +298,10 @@ func (q *QdrantClient) DeleteDocument(ctx context.Context, docID string) error {
 }
 
 func (q *QdrantClient) GetDocumentVector(ctx context.Context, docID string, withPayload bool) (*rag.DocumentVector, error) {
-	pointID := hashString(docID)
-
-	points, err := q.client.Get(ctx, &qdrant.GetPoints{
+	points, err := q.client.Scroll(ctx, &qdrant.ScrollPoints{
 		CollectionName: q.collection,
-		Ids:            []*qdrant.PointId{qdrant.NewIDNum(pointID)},
+		Filter:         qdrantWorkspaceIDs(ctx, pointID(rag.WorkspaceFromContext(ctx), docID)),
+		Limit:          qdrant.PtrOf(uint32(1)),
 		WithVectors:    qdrant.NewWithVectors(true),
 		WithPayload:    qdrant.NewWithPayload(withPayload),
 	})
@@ -167,7 +313,7 @@ func (q *QdrantClient) GetDocumentVector(ctx context.Context, docID string, with
 		return nil, fmt.Errorf("벡터를 찾을 수 없습니다")
 	}
 
-	vector := convertPointToDocumentVector(points[0], withPayload)
+	vector := convertPointToDocumentVector(points[0], withPayload, q.searchVector)
 	return &vector, nil
 }
 
@@ -185,6 +331,7 @@ func (q *QdrantClient) QueryDocumentVectors(ctx context.Context, docIDs []string
 
 	scrollReq := &qdrant.ScrollPoints{
 		CollectionName: q.collection,
+		Filter:         &qdrant.Filter{Must: []*qdrant.Condition{qdrantWorkspace(ctx)}},
 		Limit:          qdrant.PtrOf(uint32(limit)),
 		WithVectors:    qdrant.NewWithVectors(true),
 		WithPayload:    qdrant.NewWithPayload(withPayload),
@@ -203,7 +350,7 @@ func (q *QdrantClient) QueryDocumentVectors(ctx context.Context, docIDs []string
 
 	var vectors []rag.DocumentVector
 	for _, point := range points {
-		vectors = append(vectors, convertPointToDocumentVector(point, withPayload))
+		vectors = append(vectors, convertPointToDocumentVector(point, withPayload, q.searchVector))
 	}
 
 	hasMore := nextOffset != nil
@@ -216,14 +363,16 @@ func (q *QdrantClient) QueryDocumentVectors(ctx context.Context, docIDs []string
 }
 
 func (q *QdrantClient) getVectorsByIDs(ctx context.Context, docIDs []string, withPayload bool) ([]rag.DocumentVector, bool, string, error) {
+	workspace := rag.WorkspaceFromContext(ctx)
 	var ids []*qdrant.PointId
 	for _, id := range docIDs {
-		ids = append(ids, qdrant.NewIDNum(hashString(id)))
+		ids = append(ids, pointID(workspace, id))
 	}
 
-	points, err := q.client.Get(ctx, &qdrant.GetPoints{
+	points, err := q.client.Scroll(ctx, &qdrant.ScrollPoints{
 		CollectionName: q.collection,
-		Ids:            ids,
+		Filter:         qdrantWorkspaceIDs(ctx, ids...),
+		Limit:          qdrant.PtrOf(uint32(len(ids))),
 		WithVectors:    qdrant.NewWithVectors(true),
 		WithPayload:    qdrant.NewWithPayload(withPayload),
 	})
@@ -233,18 +382,18 @@ func (q *QdrantClient) getVectorsByIDs(ctx context.Context, docIDs []string, wit
 
 	var vectors []rag.DocumentVector
 	for _, point := range points {
-		vectors = append(vectors, convertPointToDocumentVector(point, withPayload))
+		vectors = append(vectors, convertPointToDocumentVector(point, withPayload, q.searchVector))
 	}
 
 	return vectors, false, "", nil
 }
 
-func convertPointToDocumentVector(point *qdrant.RetrievedPoint, withPayload bool) rag.DocumentVector {
+func convertPointToDocumentVector(point *qdrant.RetrievedPoint, withPayload bool, searchVector string) rag.DocumentVector {
 	vector := rag.DocumentVector{
 		ID: pointIDToString(point.GetId()),
 	}
 
-	vector.Vector = extractVector(point)
+	vector.Vector = extractVector(point, searchVector)
 
 	if withPayload {
 		payloadMap := make(map[string]interface{})
@@ -260,6 +409,7 @@ func convertPointToDocumentVector(point *qdrant.RetrievedPoint, withPayload bool
 			delete(payloadMap, "content")
 		}
 		delete(payloadMap, "id")
+		delete(payloadMap, workspaceField)
 
 		if len(payloadMap) > 0 {
 			vector.Metadata = payloadMap
@@ -269,7 +419,9 @@ func convertPointToDocumentVector(point *qdrant.RetrievedPoint, withPayload bool
 	return vector
 }
 
-func extractVector(point *qdrant.RetrievedPoint) []float32 {
+// extractVector returns the unnamed vector, or the preferred named vector
+// falling back to any named vector present.
+func extractVector(point *qdrant.RetrievedPoint, preferred string) []float32 {
 	vectors := point.GetVectors()
 	if vectors == nil {
 		return nil
@@ -290,6 +442,14 @@ func extractVector(point *qdrant.RetrievedPoint) []float32 {
 	}
 
 	if named := vectors.GetVectors(); named != nil {
+		if vector, ok := named.GetVectors()[preferred]; ok {
+			if dense := vector.GetDense(); dense != nil && len(dense.GetData()) > 0 {
+				return append([]float32(nil), dense.GetData()...)
+			}
+			if data := vector.GetData(); len(data) > 0 {
+				return append([]float32(nil), data...)
+			}
+		}
 		for _, vector := range named.GetVectors() {
 			if dense := vector.GetDense(); dense != nil && len(dense.GetData()) > 0 {
 				data := dense.GetData()
@@ -338,12 +498,26 @@ func parsePointID(raw string) (*qdrant.PointId, error) {
 	return qdrant.NewIDNum(num), nil
 }
 
-func hashString(s string) uint64 {
-	var hash uint64 = 5381
-	for i := 0; i < len(s); i++ {
-		hash = ((hash << 5) + hash) + uint64(s[i])
+// pointNamespace derives UUID point IDs for document IDs that are not UUIDs.
+var pointNamespace = uuid.MustParse("6f1c7e52-4b0a-4a8e-9d53-2f7a0c1e8b64")
+
+// pointID maps a document ID of a workspace to its Qdrant point ID. All
+// workspaces share one collection, so outside the default workspace the ID
+// is derived from both and the same document ID never collides across
+// workspaces. In the default workspace UUID document IDs are used as-is and
+// other IDs get a deterministic name-based UUID, as before workspaces.
+func pointID(workspace, docID string) *qdrant.PointId {
+	if workspace == "" {
+		return qdrant.NewIDUUID(pointUUID(docID))
+	}
+	return qdrant.NewIDUUID(uuid.NewSHA1(pointNamespace, []byte(workspace+"\x00"+docID)).String())
+}
+
+func pointUUID(docID string) string {
+	if id, err := uuid.Parse(docID); err == nil {
+		return id.String()
 	}
-	return hash
+	return uuid.NewSHA1(pointNamespace, []byte(docID)).String()
 }
 
 func getStringFromValue(value *qdrant.Value) string {
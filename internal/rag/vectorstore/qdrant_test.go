@@ -0,0 +1,32 @@
+package vectorstore
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPointIDSeparatesWorkspaces(t *testing.T) {
+	docUUID := uuid.NewString()
+	for _, docID := range []string{"faq-1", docUUID} {
+		a := pointIDToString(pointID("a", docID))
+		b := pointIDToString(pointID("b", docID))
+		if a == b {
+			t.Errorf("%s: workspaces a and b share point %s", docID, a)
+		}
+		if again := pointIDToString(pointID("a", docID)); again != a {
+			t.Errorf("%s: point ID not deterministic: %s, %s", docID, a, again)
+		}
+		if def := pointIDToString(pointID("", docID)); def == a || def == b {
+			t.Errorf("%s: default workspace shares point %s", docID, def)
+		}
+	}
+
+	// 기본 워크스페이스는 워크스페이스 도입 전과 같은 ID를 유지
+	if got := pointIDToString(pointID("", docUUID)); got != docUUID {
+		t.Errorf("default workspace UUID document = %s, want %s", got, docUUID)
+	}
+	if got, want := pointIDToString(pointID("", "faq-1")), uuid.NewSHA1(pointNamespace, []byte("faq-1")).String(); got != want {
+		t.Errorf("default workspace point = %s, want %s", got, want)
+	}
+}
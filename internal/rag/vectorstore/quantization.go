@@ -0,0 +1,119 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/qdrant/go-client/qdrant"
+	"yuon/configuration"
+)
+
+const (
+	quantizationNone    = "none"
+	quantizationScalar  = "scalar"
+	quantizationProduct = "product"
+)
+
+var compressionRatios = map[string]qdrant.CompressionRatio{
+	"x4":  qdrant.CompressionRatio_x4,
+	"x8":  qdrant.CompressionRatio_x8,
+	"x16": qdrant.CompressionRatio_x16,
+	"x32": qdrant.CompressionRatio_x32,
+	"x64": qdrant.CompressionRatio_x64,
+}
+
+type quantizationConfig struct {
+	Mode        string
+	AlwaysRAM   bool
+	Quantile    float32
+	Compression string
+}
+
+func quantizationFromConfig(cfg *configuration.QdrantConfig) quantizationConfig {
+	return quantizationConfig{
+		Mode:        strings.ToLower(strings.TrimSpace(cfg.Quantization)),
+		AlwaysRAM:   cfg.QuantizationAlwaysRAM,
+		Quantile:    cfg.QuantizationQuantile,
+		Compression: strings.ToLower(cfg.QuantizationCompression),
+	}
+}
+
+func (c quantizationConfig) validate() error {
+	switch c.Mode {
+	case "", quantizationNone, quantizationScalar:
+		return nil
+	case quantizationProduct:
+		if _, ok := compressionRatios[c.Compression]; !ok {
+			return fmt.Errorf("지원하지 않는 product quantization 압축률입니다: %s", c.Compression)
+		}
+		return nil
+	default:
+		return fmt.Errorf("지원하지 않는 quantization 방식입니다: %s", c.Mode)
+	}
+}
+
+func (c quantizationConfig) scalar() *qdrant.ScalarQuantization {
+	scalar := &qdrant.ScalarQuantization{
+		Type:      qdrant.QuantizationType_Int8,
+		AlwaysRam: qdrant.PtrOf(c.AlwaysRAM),
+	}
+	if c.Quantile > 0 && c.Quantile <= 1 {
+		scalar.Quantile = qdrant.PtrOf(c.Quantile)
+	}
+	return scalar
+}
+
+func (c quantizationConfig) product() *qdrant.ProductQuantization {
+	return &qdrant.ProductQuantization{
+		Compression: compressionRatios[c.Compression],
+		AlwaysRam:   qdrant.PtrOf(c.AlwaysRAM),
+	}
+}
+
+// create returns the quantization for a new collection, or nil for none.
+func (c quantizationConfig) create() *qdrant.QuantizationConfig {
+	switch c.Mode {
+	case quantizationScalar:
+		return qdrant.NewQuantizationScalar(c.scalar())
+	case quantizationProduct:
+		return qdrant.NewQuantizationProduct(c.product())
+	default:
+		return nil
+	}
+}
+
+// diff returns the update for an existing collection. An empty mode leaves
+// the collection untouched; "none" disables quantization.
+func (c quantizationConfig) diff() *qdrant.QuantizationConfigDiff {
+	switch c.Mode {
+	case quantizationScalar:
+		return qdrant.NewQuantizationDiffScalar(c.scalar())
+	case quantizationProduct:
+		return qdrant.NewQuantizationDiffProduct(c.product())
+	case quantizationNone:
+		return qdrant.NewQuantizationDiffDisabled()
+	default:
+		return nil
+	}
+}
+
+// applyQuantization updates an existing collection to the configured
+// quantization. Qdrant rebuilds the quantized vectors in the background.
+func (q *QdrantClient) applyQuantization(ctx context.Context) {
+	diff := q.quantization.diff()
+	if diff == nil {
+		return
+	}
+
+	err := q.client.UpdateCollection(ctx, &qdrant.UpdateCollection{
+		CollectionName:     q.collection,
+		QuantizationConfig: diff,
+	})
+	if err != nil {
+		slog.Warn("Qdrant quantization 설정 적용 실패", "collection", q.collection, "mode", q.quantization.Mode, "error", err)
+		return
+	}
+	slog.Info("Qdrant quantization 설정 적용", "collection", q.collection, "mode", q.quantization.Mode, "alwaysRam", q.quantization.AlwaysRAM)
+}
@@ -0,0 +1,158 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+
+	"yuon/internal/rag"
+)
+
+var (
+	ErrSnapshotNotFound     = errors.New("snapshot not found")
+	ErrInvalidRestoreTarget = errors.New("invalid restore target")
+)
+
+// Snapshot operations that the gRPC API lacks (download and upload-recover)
+// go through the Qdrant REST API at QDRANT_URL.
+
+func (q *QdrantClient) CreateSnapshot(ctx context.Context) (*rag.VectorSnapshot, error) {
+	desc, err := q.client.CreateSnapshot(ctx, q.collection)
+	if err != nil {
+		return nil, fmt.Errorf("Qdrant 스냅샷 생성 실패: %w", err)
+	}
+
+	snapshot := &rag.VectorSnapshot{
+		Name:       desc.GetName(),
+		Collection: q.collection,
+		Size:       desc.GetSize(),
+		Checksum:   desc.GetChecksum(),
+	}
+	if desc.GetCreationTime() != nil {
+		snapshot.CreatedAt = desc.GetCreationTime().AsTime().UTC().Format(time.RFC3339)
+	}
+	return snapshot, nil
+}
+
+func (q *QdrantClient) ListSnapshots(ctx context.Context) ([]rag.VectorSnapshot, error) {
+	descs, err := q.client.ListSnapshots(ctx, q.collection)
+	if err != nil {
+		return nil, fmt.Errorf("Qdrant 스냅샷 목록 조회 실패: %w", err)
+	}
+
+	snapshots := make([]rag.VectorSnapshot, 0, len(descs))
+	for _, desc := range descs {
+		snapshot := rag.VectorSnapshot{
+			Name:       desc.GetName(),
+			Collection: q.collection,
+			Size:       desc.GetSize(),
+			Checksum:   desc.GetChecksum(),
+		}
+		if desc.GetCreationTime() != nil {
+			snapshot.CreatedAt = desc.GetCreationTime().AsTime().UTC().Format(time.RFC3339)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// DownloadSnapshot streams a snapshot file of the active collection. size is
+// -1 when Qdrant does not report it; the caller closes the reader.
+func (q *QdrantClient) DownloadSnapshot(ctx context.Context, name string) (io.ReadCloser, int64, error) {
+	endpoint := fmt.Sprintf("%s/collections/%s/snapshots/%s", q.restURL, url.PathEscape(q.collection), url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res, err := q.doREST(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Qdrant 스냅샷 다운로드 실패: %w", err)
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, 0, ErrSnapshotNotFound
+	}
+	if res.StatusCode >= 300 {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return nil, 0, fmt.Errorf("Qdrant 스냅샷 다운로드 오류 (%d): %s", res.StatusCode, body)
+	}
+
+	return res.Body, res.ContentLength, nil
+}
+
+// RestoreSnapshot recovers the snapshot file read from snapshot into
+// collection, which must not exist yet. The active collection is never
+// overwritten.
+func (q *QdrantClient) RestoreSnapshot(ctx context.Context, collection, filename string, snapshot io.Reader) error {
+	if collection == "" || collection == q.collection {
+		return fmt.Errorf("%w: 복원 대상은 현재 컬렉션과 다른 새 컬렉션이어야 합니다", ErrInvalidRestoreTarget)
+	}
+
+	exists, err := q.client.CollectionExists(ctx, collection)
+	if err != nil {
+		return fmt.Errorf("Qdrant 컬렉션 확인 실패: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("%w: 이미 존재하는 컬렉션입니다: %s", ErrInvalidRestoreTarget, collection)
+	}
+
+	return q.uploadSnapshot(ctx, collection, filename, snapshot)
+}
+
+// uploadSnapshot posts snapshot to Qdrant's upload-recover endpoint as a
+// multipart form, streaming it through a pipe instead of buffering the file.
+func (q *QdrantClient) uploadSnapshot(ctx context.Context, collection, filename string, snapshot io.Reader) error {
+	body, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		part, err := writer.CreateFormFile("snapshot", filename)
+		if err == nil {
+			_, err = io.Copy(part, snapshot)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	// 요청이 본문을 끝까지 읽지 않고 끝나도 복사 고루틴이 snapshot을 놓고 끝나도록 닫고 기다림
+	defer func() {
+		body.Close()
+		<-done
+	}()
+
+	endpoint := fmt.Sprintf("%s/collections/%s/snapshots/upload?priority=snapshot&wait=true", q.restURL, url.PathEscape(collection))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	res, err := q.doREST(req)
+	if err != nil {
+		return fmt.Errorf("Qdrant 스냅샷 복원 실패: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return fmt.Errorf("Qdrant 스냅샷 복원 오류 (%d): %s", res.StatusCode, msg)
+	}
+	return nil
+}
+
+func (q *QdrantClient) doREST(req *http.Request) (*http.Response, error) {
+	if q.apiKey != "" {
+		req.Header.Set("api-key", q.apiKey)
+	}
+	return q.httpClient.Do(req)
+}
@@ -0,0 +1,96 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDownloadSnapshot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("api-key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/collections/docs/snapshots/snap-1.snapshot" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, "snapshot-bytes")
+	}))
+	defer server.Close()
+	q := &QdrantClient{collection: "docs", restURL: server.URL, apiKey: "key", httpClient: server.Client()}
+
+	snapshot, size, err := q.DownloadSnapshot(context.Background(), "snap-1.snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.Close()
+	data, err := io.ReadAll(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "snapshot-bytes" || size != int64(len(data)) {
+		t.Errorf("got %q (size %d)", data, size)
+	}
+
+	if _, _, err := q.DownloadSnapshot(context.Background(), "missing.snapshot"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("missing snapshot: err = %v, want ErrSnapshotNotFound", err)
+	}
+}
+
+func TestUploadSnapshot(t *testing.T) {
+	var got, filename string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/collections/restored/snapshots/upload" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		file, header, err := r.FormFile("snapshot")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		got, filename = string(data), header.Filename
+		io.WriteString(w, `{"result":true}`)
+	}))
+	defer server.Close()
+	q := &QdrantClient{collection: "docs", restURL: server.URL, httpClient: server.Client()}
+
+	content := strings.Repeat("vector", 100000)
+	if err := q.uploadSnapshot(context.Background(), "restored", "snap-1.snapshot", strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+	if got != content || filename != "snap-1.snapshot" {
+		t.Errorf("Qdrant received %d bytes as %q, want %d bytes", len(got), filename, len(content))
+	}
+}
+
+func TestUploadSnapshotReportsRejection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "bad snapshot")
+	}))
+	defer server.Close()
+	q := &QdrantClient{collection: "docs", restURL: server.URL, httpClient: server.Client()}
+
+	err := q.uploadSnapshot(context.Background(), "restored", "snap-1.snapshot", strings.NewReader(strings.Repeat("x", 1<<20)))
+	if err == nil || !strings.Contains(err.Error(), "bad snapshot") {
+		t.Errorf("err = %v, want the Qdrant rejection", err)
+	}
+}
+
+func TestRestoreSnapshotRejectsActiveCollection(t *testing.T) {
+	q := &QdrantClient{collection: "docs"}
+	for _, collection := range []string{"", "docs"} {
+		if err := q.RestoreSnapshot(context.Background(), collection, "snap.snapshot", strings.NewReader("")); !errors.Is(err, ErrInvalidRestoreTarget) {
+			t.Errorf("collection %q: err = %v, want ErrInvalidRestoreTarget", collection, err)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/qdrant/go-client/qdrant"
+	"yuon/configuration"
+	"yuon/internal/rag"
+)
+
+type (
+	Vectors     = rag.Vectors
+	VectorSpace = rag.VectorSpace
+)
+
+func vectorSpacesFromConfig(cfg *configuration.QdrantConfig) []VectorSpace {
+	if len(cfg.NamedVectors) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(cfg.NamedVectors))
+	for name := range cfg.NamedVectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	spaces := make([]VectorSpace, 0, len(names))
+	for _, name := range names {
+		source := cfg.VectorSources[name]
+		if source == "" {
+			source = "content"
+		}
+		spaces = append(spaces, VectorSpace{
+			Name:   name,
+			Size:   cfg.NamedVectors[name],
+			Source: source,
+			Model:  cfg.VectorModels[name],
+		})
+	}
+	return spaces
+}
+
+var distanceNames = map[string]qdrant.Distance{
+	"cosine":    qdrant.Distance_Cosine,
+	"dot":       qdrant.Distance_Dot,
+	"euclid":    qdrant.Distance_Euclid,
+	"manhattan": qdrant.Distance_Manhattan,
+}
+
+func parseDistance(name string) (qdrant.Distance, error) {
+	if name == "" {
+		return qdrant.Distance_Cosine, nil
+	}
+	distance, ok := distanceNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("지원하지 않는 거리 함수입니다: %s (cosine, dot, euclid, manhattan)", name)
+	}
+	return distance, nil
+}
+
+// defaultSearchVector picks the configured search space, falling back to
+// "content" and then the first space.
+func defaultSearchVector(spaces []VectorSpace, configured string) string {
+	if len(spaces) == 0 {
+		return ""
+	}
+	if _, ok := findSpace(spaces, configured); ok {
+		return configured
+	}
+	if _, ok := findSpace(spaces, "content"); ok {
+		return "content"
+	}
+	return spaces[0].Name
+}
+
+func findSpace(spaces []VectorSpace, name string) (VectorSpace, bool) {
+	for _, space := range spaces {
+		if space.Name == name {
+			return space, true
+		}
+	}
+	return VectorSpace{}, false
+}
+
+// Spaces returns the configured named vector spaces, or nil when the
+// collection uses a single unnamed vector.
+func (q *QdrantClient) Spaces() []VectorSpace {
+	return q.spaces
+}
+
+// Space looks up a vector space by name. The empty name resolves to the
+// default search space.
+func (q *QdrantClient) Space(name string) (VectorSpace, bool) {
+	if name == "" {
+		name = q.searchVector
+	}
+	return findSpace(q.spaces, name)
+}
+
+func (q *QdrantClient) vectorsConfig(vectorSize int) *qdrant.VectorsConfig {
+	if len(q.spaces) == 0 {
+		return qdrant.NewVectorsConfig(&qdrant.VectorParams{
+			Size:     uint64(vectorSize),
+			Distance: q.distance,
+			OnDisk:   qdrant.PtrOf(q.onDisk),
+		})
+	}
+
+	params := make(map[string]*qdrant.VectorParams, len(q.spaces))
+	for _, space := range q.spaces {
+		params[space.Name] = &qdrant.VectorParams{
+			Size:     uint64(space.Size),
+			Distance: q.distance,
+			OnDisk:   qdrant.PtrOf(q.onDisk),
+		}
+	}
+	return qdrant.NewVectorsConfigMap(params)
+}
+
+// checkVectorSpaces warns when an existing collection was created with a
+// different vector layout than the one configured.
+func (q *QdrantClient) checkVectorSpaces(ctx context.Context) {
+	info, err := q.client.GetCollectionInfo(ctx, q.collection)
+	if err != nil {
+		slog.Warn("Qdrant 컬렉션 정보 조회 실패", "collection", q.collection, "error", err)
+		return
+	}
+
+	if q.sparseVector != "" {
+		if _, ok := info.GetConfig().GetParams().GetSparseVectorsConfig().GetMap()[q.sparseVector]; !ok {
+			slog.Warn("컬렉션에 sparse vector가 없습니다. 새 컬렉션으로 재색인해야 하이브리드 검색을 사용할 수 있습니다",
+				"collection", q.collection,
+				"vector", q.sparseVector,
+			)
+		}
+	}
+
+	vectors := info.GetConfig().GetParams().GetVectorsConfig()
+	distances := map[string]qdrant.Distance{}
+	if params := vectors.GetParams(); params != nil {
+		distances[""] = params.GetDistance()
+	}
+	for name, params := range vectors.GetParamsMap().GetMap() {
+		distances[name] = params.GetDistance()
+	}
+	for name, distance := range distances {
+		if distance != q.distance {
+			slog.Warn("컬렉션의 거리 함수가 QDRANT_DISTANCE와 다릅니다. 새 컬렉션으로 재색인이 필요합니다",
+				"collection", q.collection,
+				"vector", name,
+				"current", distance.String(),
+				"configured", q.distance.String(),
+			)
+		}
+	}
+
+	existing := info.GetConfig().GetParams().GetVectorsConfig().GetParamsMap().GetMap()
+	if len(q.spaces) == 0 {
+		if len(existing) > 0 {
+			slog.Warn("컬렉션은 named vector를 사용하지만 QDRANT_NAMED_VECTORS가 비어 있습니다", "collection", q.collection)
+		}
+		return
+	}
+
+	for _, space := range q.spaces {
+		if _, ok := existing[space.Name]; !ok {
+			slog.Warn("컬렉션에 설정된 named vector가 없습니다. 새 컬렉션으로 재색인이 필요합니다",
+				"collection", q.collection,
+				"vector", space.Name,
+			)
+		}
+	}
+}
+
+func (q *QdrantClient) pointVectors(vectors Vectors) *qdrant.Vectors {
+	if len(q.spaces) == 0 {
+		return qdrant.NewVectors(vectors[""]...)
+	}
+
+	named := make(map[string]*qdrant.Vector, len(vectors))
+	for name, vector := range vectors {
+		if name == "" || len(vector) == 0 {
+			continue
+		}
+		named[name] = qdrant.NewVector(vector...)
+	}
+	return qdrant.NewVectorsMap(named)
+}
@@ -0,0 +1,140 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/qdrant/go-client/qdrant"
+	"yuon/internal/rag"
+)
+
+// Sparse vectors are BM25-style term weights computed here; Qdrant applies
+// IDF at query time (Modifier_Idf), so the collection needs no vocabulary.
+const sparseK1 = 1.2
+
+// sparseTerms counts hashed lowercase word tokens.
+func sparseTerms(text string) map[uint32]float32 {
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make(map[uint32]float32, len(tokens))
+	for _, token := range tokens {
+		h := fnv.New32a()
+		h.Write([]byte(token))
+		terms[h.Sum32()]++
+	}
+	return terms
+}
+
+// sparseVector returns sorted indices and values. Document weights saturate
+// term frequency like BM25; query terms all weigh 1.
+func sparseVector(text string, query bool) ([]uint32, []float32) {
+	terms := sparseTerms(text)
+
+	indices := make([]uint32, 0, len(terms))
+	for index := range terms {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+	values := make([]float32, len(indices))
+	for i, index := range indices {
+		if query {
+			values[i] = 1
+			continue
+		}
+		tf := terms[index]
+		values[i] = tf * (sparseK1 + 1) / (tf + sparseK1)
+	}
+	return indices, values
+}
+
+func (q *QdrantClient) sparseVectorsConfig() *qdrant.SparseVectorConfig {
+	if q.sparseVector == "" {
+		return nil
+	}
+	return qdrant.NewSparseVectorsConfig(map[string]*qdrant.SparseVectorParams{
+		q.sparseVector: {Modifier: qdrant.Modifier_Idf.Enum()},
+	})
+}
+
+// withSparse adds the content sparse vector to dense point vectors.
+func (q *QdrantClient) withSparse(vectors *qdrant.Vectors, content string) *qdrant.Vectors {
+	if q.sparseVector == "" {
+		return vectors
+	}
+
+	named := map[string]*qdrant.Vector{}
+	if dense := vectors.GetVector(); dense != nil {
+		// 기본(이름 없는) 벡터는 빈 이름으로 저장
+		named[""] = dense
+	}
+	for name, vector := range vectors.GetVectors().GetVectors() {
+		named[name] = vector
+	}
+
+	indices, values := sparseVector(content, false)
+	if len(indices) > 0 {
+		named[q.sparseVector] = qdrant.NewVectorSparse(indices, values)
+	}
+	return qdrant.NewVectorsMap(named)
+}
+
+// HybridEnabled reports whether HybridSearch can replace the separate
+// full-text pass.
+func (q *QdrantClient) HybridEnabled() bool {
+	return q.hybrid && q.sparseVector != ""
+}
+
+// HybridSearch fuses a dense query on space with a sparse keyword query on
+// the sparse vector using reciprocal rank fusion.
+func (q *QdrantClient) HybridSearch(ctx context.Context, space string, vector []float32, query string, limit int, filters *rag.SearchFilters) ([]rag.Document, error) {
+	var using *string
+	if len(q.spaces) > 0 {
+		if space == "" {
+			space = q.searchVector
+		}
+		using = &space
+	}
+
+	filter := buildFilter(ctx, filters)
+	prefetchLimit := qdrant.PtrOf(uint64(limit * 4))
+	prefetch := []*qdrant.PrefetchQuery{
+		{
+			Query:  qdrant.NewQueryDense(vector),
+			Using:  using,
+			Filter: filter,
+			Limit:  prefetchLimit,
+		},
+	}
+	if indices, values := sparseVector(query, true); len(indices) > 0 {
+		prefetch = append(prefetch, &qdrant.PrefetchQuery{
+			Query:  qdrant.NewQuerySparse(indices, values),
+			Using:  qdrant.PtrOf(q.sparseVector),
+			Filter: filter,
+			Limit:  prefetchLimit,
+		})
+	}
+
+	resp, err := q.client.Query(ctx, &qdrant.QueryPoints{
+		CollectionName: q.collection,
+		Prefetch:       prefetch,
+		Query:          qdrant.NewQueryFusion(qdrant.Fusion_RRF),
+		Limit:          qdrant.PtrOf(uint64(limit)),
+		WithPayload:    qdrant.NewWithPayload(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("하이브리드 검색 실패: %w", err)
+	}
+
+	documents := make([]rag.Document, 0, len(resp))
+	for _, point := range resp {
+		documents = append(documents, scoredPointDocument(point))
+	}
+	return documents, nil
+}
@@ -0,0 +1,41 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"yuon/internal/rag"
+)
+
+// ErrUnsupported is returned for operations the configured backend lacks.
+var ErrUnsupported = errors.New("operation not supported by vector store")
+
+const (
+	BackendQdrant   = "qdrant"
+	BackendPgVector = "pgvector"
+	BackendWeaviate = "weaviate"
+)
+
+// SnapshotStore is implemented by backends with native collection snapshots.
+type SnapshotStore interface {
+	CreateSnapshot(ctx context.Context) (*rag.VectorSnapshot, error)
+	ListSnapshots(ctx context.Context) ([]rag.VectorSnapshot, error)
+	DownloadSnapshot(ctx context.Context, name string) (io.ReadCloser, int64, error)
+	RestoreSnapshot(ctx context.Context, collection, filename string, snapshot io.Reader) error
+}
+
+// HybridSearcher is implemented by backends that combine dense and keyword
+// retrieval in one query, replacing the separate OpenSearch pass.
+type HybridSearcher interface {
+	HybridEnabled() bool
+	HybridSearch(ctx context.Context, space string, vector []float32, query string, limit int, filters *rag.SearchFilters) ([]rag.Document, error)
+}
+
+var (
+	_ HybridSearcher  = (*QdrantClient)(nil)
+	_ rag.VectorStore = (*QdrantClient)(nil)
+	_ SnapshotStore   = (*QdrantClient)(nil)
+	_ rag.VectorStore = (*PgVectorStore)(nil)
+	_ rag.VectorStore = (*WeaviateStore)(nil)
+)
@@ -0,0 +1,705 @@
+package vectorstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"yuon/configuration"
+	"yuon/internal/rag"
+)
+
+// WeaviateStore talks to an existing Weaviate cluster over its REST and
+// GraphQL APIs. Documents are objects of one class per workspace with
+// caller-supplied vectors (vectorizer "none"); named vectors map to Weaviate
+// target vectors.
+type WeaviateStore struct {
+	baseURL      string
+	apiKey       string
+	class        string
+	vectorSize   int
+	batchSize    int
+	spaces       []VectorSpace
+	searchVector string
+	httpClient   *http.Client
+
+	// workspaceClasses caches workspace classes known to exist.
+	workspaceClasses sync.Map
+}
+
+// weaviateProperties are the object properties read back on every query.
+const weaviateProperties = "docId content metadata createdAt updatedAt"
+
+func NewWeaviateStore(cfg *configuration.VectorStoreConfig, layout *configuration.QdrantConfig) (*WeaviateStore, error) {
+	store := &WeaviateStore{
+		baseURL:    strings.TrimRight(cfg.WeaviateURL, "/"),
+		apiKey:     cfg.WeaviateAPIKey,
+		class:      cfg.WeaviateClass,
+		vectorSize: layout.VectorSize,
+		batchSize:  layout.BatchSize,
+		spaces:     vectorSpacesFromConfig(layout),
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+	if store.batchSize <= 0 {
+		store.batchSize = 64
+	}
+	store.searchVector = defaultSearchVector(store.spaces, layout.SearchVector)
+
+	if err := store.ensureClass(context.Background(), store.class); err != nil {
+		return nil, fmt.Errorf("Weaviate 클래스 초기화 실패: %w", err)
+	}
+
+	return store, nil
+}
+
+// classFor returns the class of the workspace on ctx, creating it on first
+// use. Requests without a workspace use w.class.
+func (w *WeaviateStore) classFor(ctx context.Context) (string, error) {
+	workspace := rag.WorkspaceFromContext(ctx)
+	if workspace == "" {
+		return w.class, nil
+	}
+
+	class := w.class + "_" + strings.ReplaceAll(rag.WorkspaceKey(workspace), "-", "_")
+	if _, ok := w.workspaceClasses.Load(class); ok {
+		return class, nil
+	}
+	if err := w.ensureClass(ctx, class); err != nil {
+		return "", fmt.Errorf("Weaviate 워크스페이스 클래스 초기화 실패: %w", err)
+	}
+	w.workspaceClasses.Store(class, struct{}{})
+	return class, nil
+}
+
+func (w *WeaviateStore) ensureClass(ctx context.Context, name string) error {
+	status, _, err := w.do(ctx, http.MethodGet, "/v1/schema/"+url.PathEscape(name), nil, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	text := func(name string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "dataType": []string{"text"}, "tokenization": "field"}
+	}
+	class := map[string]interface{}{
+		"class": name,
+		"invertedIndexConfig": map[string]interface{}{
+			"indexNullState": true,
+		},
+		"properties": []map[string]interface{}{
+			text("docId"),
+			{"name": "content", "dataType": []string{"text"}},
+			{"name": "metadata", "dataType": []string{"text"}, "indexFilterable": false, "indexSearchable": false},
+			text("category"),
+			{"name": "tags", "dataType": []string{"text[]"}, "tokenization": "field"},
+			{"name": "allowedRoles", "dataType": []string{"text[]"}, "tokenization": "field"},
+			{"name": "createdAt", "dataType": []string{"date"}},
+			{"name": "updatedAt", "dataType": []string{"date"}},
+		},
+	}
+
+	if len(w.spaces) == 0 {
+		class["vectorizer"] = "none"
+		class["vectorIndexConfig"] = map[string]interface{}{"distance": "cosine"}
+	} else {
+		vectorConfig := make(map[string]interface{}, len(w.spaces))
+		for _, space := range w.spaces {
+			vectorConfig[space.Name] = map[string]interface{}{
+				"vectorizer":        map[string]interface{}{"none": map[string]interface{}{}},
+				"vectorIndexType":   "hnsw",
+				"vectorIndexConfig": map[string]interface{}{"distance": "cosine"},
+			}
+		}
+		class["vectorConfig"] = vectorConfig
+	}
+
+	status, body, err := w.do(ctx, http.MethodPost, "/v1/schema", nil, class)
+	if err != nil {
+		return err
+	}
+	if status >= 300 {
+		return fmt.Errorf("Weaviate 클래스 생성 오류 (%d): %s", status, body)
+	}
+	return nil
+}
+
+func (w *WeaviateStore) Spaces() []VectorSpace {
+	return w.spaces
+}
+
+func (w *WeaviateStore) Space(name string) (VectorSpace, bool) {
+	if name == "" {
+		name = w.searchVector
+	}
+	return findSpace(w.spaces, name)
+}
+
+func (w *WeaviateStore) AddDocument(ctx context.Context, doc rag.Document, vectors Vectors) error {
+	if _, err := w.UpsertBatch(ctx, []rag.Document{doc}, []Vectors{vectors}); err != nil {
+		return fmt.Errorf("문서 추가 실패: %w", err)
+	}
+	return nil
+}
+
+// UpsertBatch writes objects through the batch API, which replaces objects
+// with the same ID. It returns the number of documents written before the
+// first failing batch.
+func (w *WeaviateStore) UpsertBatch(ctx context.Context, docs []rag.Document, vectors []Vectors) (int, error) {
+	if len(docs) != len(vectors) {
+		return 0, fmt.Errorf("문서 수(%d)와 벡터 수(%d)가 다릅니다", len(docs), len(vectors))
+	}
+
+	class, err := w.classFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for start := 0; start < len(docs); start += w.batchSize {
+		end := min(start+w.batchSize, len(docs))
+
+		objects := make([]map[string]interface{}, 0, end-start)
+		for i := start; i < end; i++ {
+			objects = append(objects, w.newObject(class, docs[i], vectors[i]))
+		}
+
+		status, body, err := w.do(ctx, http.MethodPost, "/v1/batch/objects", nil, map[string]interface{}{"objects": objects})
+		if err == nil && status >= 300 {
+			err = fmt.Errorf("status %d: %s", status, body)
+		}
+		if err == nil {
+			err = batchObjectErrors(body)
+		}
+		if err != nil {
+			return written, fmt.Errorf("배치 업서트 실패 (%d~%d): %w", start, end-1, err)
+		}
+		written = end
+	}
+
+	return written, nil
+}
+
+func (w *WeaviateStore) newObject(class string, doc rag.Document, vectors Vectors) map[string]interface{} {
+	if doc.ID == "" {
+		doc.ID = uuid.New().String()
+	}
+
+	metadata, _ := json.Marshal(doc.Metadata)
+	properties := map[string]interface{}{
+		"docId":    doc.ID,
+		"content":  doc.Content,
+		"metadata": string(metadata),
+	}
+	if category, ok := doc.Metadata["category"].(string); ok && category != "" {
+		properties["category"] = category
+	}
+	if tags := stringList(doc.Metadata["tags"]); len(tags) > 0 {
+		properties["tags"] = tags
+	}
+	if roles := stringList(doc.Metadata["allowedRoles"]); len(roles) > 0 {
+		properties["allowedRoles"] = roles
+	}
+
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	if _, ok := parseTimestamp(doc.CreatedAt); ok {
+		createdAt = doc.CreatedAt
+	} else if uploadedAt, _ := doc.Metadata["uploadedAt"].(string); uploadedAt != "" {
+		if _, ok := parseTimestamp(uploadedAt); ok {
+			createdAt = uploadedAt
+		}
+	}
+	properties["createdAt"] = createdAt
+	if _, ok := parseTimestamp(doc.UpdatedAt); ok {
+		properties["updatedAt"] = doc.UpdatedAt
+	}
+
+	object := map[string]interface{}{
+		"class":      class,
+		"id":         pointUUID(doc.ID),
+		"properties": properties,
+	}
+	if len(w.spaces) == 0 {
+		object["vector"] = vectors[""]
+	} else {
+		object["vectors"] = vectors
+	}
+	return object
+}
+
+// Search runs a nearVector GraphQL query against one vector space. An empty
+// space uses the default search space.
+func (w *WeaviateStore) Search(ctx context.Context, space string, vector []float32, limit int, filters *rag.SearchFilters) ([]rag.Document, error) {
+	class, err := w.classFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"nearVector: {vector: " + gqlValue(vector) + w.targetVectors(space) + "}",
+		"limit: " + strconv.Itoa(limit),
+	}
+	if where := weaviateWhere(filters); where != nil {
+		args = append(args, "where: "+gqlValue(where))
+	}
+
+	query := fmt.Sprintf("{ Get { %s(%s) { %s _additional { distance } } } }",
+		class, strings.Join(args, ", "), weaviateProperties)
+
+	var result struct {
+		Data struct {
+			Get map[string][]weaviateObjectProperties `json:"Get"`
+		} `json:"data"`
+	}
+	if err := w.graphql(ctx, query, &result); err != nil {
+		return nil, fmt.Errorf("검색 실패: %w", err)
+	}
+
+	var documents []rag.Document
+	for _, object := range result.Data.Get[class] {
+		doc := object.document()
+		doc.Score = 1 - object.Additional.Distance
+		documents = append(documents, doc)
+	}
+	return documents, nil
+}
+
+func (w *WeaviateStore) targetVectors(space string) string {
+	if len(w.spaces) == 0 {
+		return ""
+	}
+	if space == "" {
+		space = w.searchVector
+	}
+	return ", targetVectors: " + gqlValue([]string{space})
+}
+
+// weaviateWhere mirrors buildFilter as a Weaviate where filter.
+func weaviateWhere(filters *rag.SearchFilters) map[string]interface{} {
+	if filters.IsEmpty() {
+		return nil
+	}
+
+	var operands []interface{}
+	if filters.Category != "" {
+		operands = append(operands, map[string]interface{}{
+			"path": []string{"category"}, "operator": gqlEnum("Equal"), "valueText": filters.Category,
+		})
+	}
+	if len(filters.Tags) > 0 {
+		operands = append(operands, map[string]interface{}{
+			"path": []string{"tags"}, "operator": gqlEnum("ContainsAny"), "valueText": filters.Tags,
+		})
+	}
+	if len(filters.Roles) > 0 {
+		operands = append(operands, map[string]interface{}{
+			"operator": gqlEnum("Or"),
+			"operands": []interface{}{
+				map[string]interface{}{"path": []string{"allowedRoles"}, "operator": gqlEnum("ContainsAny"), "valueText": filters.Roles},
+				map[string]interface{}{"path": []string{"allowedRoles"}, "operator": gqlEnum("IsNull"), "valueBoolean": true},
+			},
+		})
+	}
+	if filters.UploadedAfter != nil {
+		operands = append(operands, map[string]interface{}{
+			"path": []string{"createdAt"}, "operator": gqlEnum("GreaterThanEqual"), "valueDate": filters.UploadedAfter.UTC().Format(time.RFC3339),
+		})
+	}
+	if filters.UploadedBefore != nil {
+		operands = append(operands, map[string]interface{}{
+			"path": []string{"createdAt"}, "operator": gqlEnum("LessThanEqual"), "valueDate": filters.UploadedBefore.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return map[string]interface{}{"operator": gqlEnum("And"), "operands": operands}
+}
+
+func (w *WeaviateStore) DeleteDocument(ctx context.Context, docID string) error {
+	class, err := w.classFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	status, body, err := w.do(ctx, http.MethodDelete, objectPath(class, docID), nil, nil)
+	if err == nil && status >= 300 && status != http.StatusNotFound {
+		err = fmt.Errorf("status %d: %s", status, body)
+	}
+	if err != nil {
+		return fmt.Errorf("Weaviate 문서 삭제 실패: %w", err)
+	}
+	return nil
+}
+
+func (w *WeaviateStore) GetDocumentVector(ctx context.Context, docID string, withPayload bool) (*rag.DocumentVector, error) {
+	object, err := w.getObject(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, fmt.Errorf("벡터를 찾을 수 없습니다")
+	}
+
+	vector := w.documentVector(object, withPayload)
+	return &vector, nil
+}
+
+// QueryDocumentVectors pages with the object listing cursor; offset is the
+// last object UUID returned.
+func (w *WeaviateStore) QueryDocumentVectors(ctx context.Context, docIDs []string, limit int, withPayload bool, offset string) ([]rag.DocumentVector, bool, string, error) {
+	if len(docIDs) > 0 {
+		var vectors []rag.DocumentVector
+		for _, id := range docIDs {
+			object, err := w.getObject(ctx, id)
+			if err != nil {
+				return nil, false, "", err
+			}
+			if object != nil {
+				vectors = append(vectors, w.documentVector(object, withPayload))
+			}
+		}
+		return vectors, false, "", nil
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 512 {
+		limit = 512
+	}
+
+	class, err := w.classFor(ctx)
+	if err != nil {
+		return nil, false, "", err
+	}
+	query := url.Values{
+		"class":   {class},
+		"limit":   {strconv.Itoa(limit + 1)},
+		"include": {"vector"},
+	}
+	if offset != "" {
+		query.Set("after", offset)
+	}
+
+	status, body, err := w.do(ctx, http.MethodGet, "/v1/objects", query, nil)
+	if err == nil && status >= 300 {
+		err = fmt.Errorf("status %d: %s", status, body)
+	}
+	if err != nil {
+		return nil, false, "", fmt.Errorf("Weaviate 벡터 스크롤 실패: %w", err)
+	}
+
+	var result struct {
+		Objects []weaviateObject `json:"objects"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, false, "", fmt.Errorf("Weaviate 응답 파싱 실패: %w", err)
+	}
+
+	hasMore := len(result.Objects) > limit
+	if hasMore {
+		result.Objects = result.Objects[:limit]
+	}
+
+	vectors := make([]rag.DocumentVector, 0, len(result.Objects))
+	for i := range result.Objects {
+		vectors = append(vectors, w.documentVector(&result.Objects[i], withPayload))
+	}
+
+	next := ""
+	if hasMore {
+		next = result.Objects[len(result.Objects)-1].ID
+	}
+	return vectors, hasMore, next, nil
+}
+
+func (w *WeaviateStore) CollectionStats(ctx context.Context) (*rag.VectorCollectionStats, error) {
+	var result struct {
+		Data struct {
+			Aggregate map[string][]struct {
+				Meta struct {
+					Count uint64 `json:"count"`
+				} `json:"meta"`
+			} `json:"Aggregate"`
+		} `json:"data"`
+	}
+	query := fmt.Sprintf("{ Aggregate { %s { meta { count } } } }", w.class)
+	if err := w.graphql(ctx, query, &result); err != nil {
+		return nil, fmt.Errorf("Weaviate 통계 조회 실패: %w", err)
+	}
+
+	stats := &rag.VectorCollectionStats{
+		Collection:  w.class,
+		Status:      "green",
+		OptimizerOK: true,
+		Indexed:     true,
+		Vectors:     []rag.VectorSpaceInfo{},
+	}
+	if aggregate := result.Data.Aggregate[w.class]; len(aggregate) > 0 {
+		stats.PointsCount = aggregate[0].Meta.Count
+		stats.IndexedVectorsCount = stats.PointsCount * uint64(max(len(w.spaces), 1))
+	}
+
+	if len(w.spaces) == 0 {
+		stats.Vectors = append(stats.Vectors, rag.VectorSpaceInfo{Size: uint64(w.vectorSize), Distance: "cosine"})
+	}
+	for _, space := range w.spaces {
+		stats.Vectors = append(stats.Vectors, rag.VectorSpaceInfo{Name: space.Name, Size: uint64(space.Size), Distance: "cosine"})
+	}
+
+	return stats, nil
+}
+
+func (w *WeaviateStore) Health(ctx context.Context) error {
+	status, _, err := w.do(ctx, http.MethodGet, "/v1/.well-known/ready", nil, nil)
+	if err == nil && status >= 300 {
+		err = fmt.Errorf("status %d", status)
+	}
+	if err != nil {
+		return fmt.Errorf("Weaviate 헬스 체크 실패: %w", err)
+	}
+	return nil
+}
+
+// Close is a no-op; requests use a plain HTTP client.
+func (w *WeaviateStore) Close() error {
+	return nil
+}
+
+type weaviateObjectProperties struct {
+	DocID      string `json:"docId"`
+	Content    string `json:"content"`
+	Metadata   string `json:"metadata"`
+	CreatedAt  string `json:"createdAt"`
+	UpdatedAt  string `json:"updatedAt"`
+	Additional struct {
+		Distance float64 `json:"distance"`
+	} `json:"_additional"`
+}
+
+func (p weaviateObjectProperties) document() rag.Document {
+	doc := rag.Document{
+		ID:        p.DocID,
+		Content:   p.Content,
+		Metadata:  decodeMetadata([]byte(p.Metadata)),
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+	if t, ok := parseTimestamp(p.CreatedAt); ok {
+		doc.CreatedAt = t.UTC().Format(time.RFC3339)
+	}
+	if t, ok := parseTimestamp(p.UpdatedAt); ok {
+		doc.UpdatedAt = t.UTC().Format(time.RFC3339)
+	}
+	return doc
+}
+
+type weaviateObject struct {
+	ID         string                   `json:"id"`
+	Properties weaviateObjectProperties `json:"properties"`
+	Vector     []float32                `json:"vector"`
+	Vectors    map[string][]float32     `json:"vectors"`
+}
+
+func (w *WeaviateStore) documentVector(object *weaviateObject, withPayload bool) rag.DocumentVector {
+	vector := rag.DocumentVector{
+		ID:     object.Properties.DocID,
+		Vector: object.Vector,
+	}
+	if vector.ID == "" {
+		vector.ID = object.ID
+	}
+	if len(vector.Vector) == 0 && len(object.Vectors) > 0 {
+		vector.Vector = object.Vectors[w.searchVector]
+		if len(vector.Vector) == 0 {
+			names := make([]string, 0, len(object.Vectors))
+			for name := range object.Vectors {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			vector.Vector = object.Vectors[names[0]]
+		}
+	}
+
+	if withPayload {
+		doc := object.Properties.document()
+		vector.Content = doc.Content
+		if len(doc.Metadata) > 0 {
+			vector.Metadata = doc.Metadata
+		}
+	}
+	return vector
+}
+
+func objectPath(class, docID string) string {
+	return "/v1/objects/" + url.PathEscape(class) + "/" + pointUUID(docID)
+}
+
+// getObject returns nil when the object does not exist in the workspace on
+// ctx.
+func (w *WeaviateStore) getObject(ctx context.Context, docID string) (*weaviateObject, error) {
+	class, err := w.classFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status, body, err := w.do(ctx, http.MethodGet, objectPath(class, docID), url.Values{"include": {"vector"}}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Weaviate 벡터 조회 실패: %w", err)
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status >= 300 {
+		return nil, fmt.Errorf("Weaviate 벡터 조회 오류 (%d): %s", status, body)
+	}
+
+	var object weaviateObject
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, fmt.Errorf("Weaviate 응답 파싱 실패: %w", err)
+	}
+	return &object, nil
+}
+
+func (w *WeaviateStore) graphql(ctx context.Context, query string, out interface{}) error {
+	status, body, err := w.do(ctx, http.MethodPost, "/v1/graphql", nil, map[string]string{"query": query})
+	if err != nil {
+		return err
+	}
+	if status >= 300 {
+		return fmt.Errorf("status %d: %s", status, body)
+	}
+
+	var errs struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &errs); err == nil && len(errs.Errors) > 0 {
+		return fmt.Errorf("GraphQL 오류: %s", errs.Errors[0].Message)
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+func (w *WeaviateStore) do(ctx context.Context, method, path string, query url.Values, payload interface{}) (int, []byte, error) {
+	endpoint := w.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if w.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+w.apiKey)
+	}
+
+	res, err := w.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	return res.StatusCode, body, err
+}
+
+// batchObjectErrors reports the first per-object error in a batch response.
+func batchObjectErrors(body []byte) error {
+	var results []struct {
+		Result struct {
+			Errors *struct {
+				Error []struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			} `json:"errors"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil
+	}
+	for _, result := range results {
+		if result.Result.Errors != nil && len(result.Result.Errors.Error) > 0 {
+			return fmt.Errorf("%s", result.Result.Errors.Error[0].Message)
+		}
+	}
+	return nil
+}
+
+// gqlEnum is rendered unquoted by gqlValue.
+type gqlEnum string
+
+// gqlValue renders v as a GraphQL input literal (unquoted object keys).
+func gqlValue(v interface{}) string {
+	switch value := v.(type) {
+	case gqlEnum:
+		return string(value)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		parts := make([]string, 0, len(keys))
+		for _, key := range keys {
+			parts = append(parts, key+": "+gqlValue(value[key]))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case []interface{}:
+		parts := make([]string, 0, len(value))
+		for _, item := range value {
+			parts = append(parts, gqlValue(item))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	default:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+}
+
+func stringList(v interface{}) []string {
+	switch value := v.(type) {
+	case string:
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	case []string:
+		return value
+	case []interface{}:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok && s != "" {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
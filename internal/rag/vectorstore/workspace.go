@@ -0,0 +1,28 @@
+package vectorstore
+
+import (
+	"context"
+
+	"github.com/qdrant/go-client/qdrant"
+	"yuon/internal/rag"
+)
+
+// workspaceField records the workspace (rag.WithWorkspace) a document was
+// stored from. Qdrant and pgvector keep every workspace in one collection and
+// filter on it (pgvector in a column of that name); Weaviate uses a class per
+// workspace instead (see classFor). The default workspace is stored as no or
+// an empty value, so documents stored before workspaces existed stay in it.
+const workspaceField = "workspace"
+
+// qdrantWorkspace matches the points of the workspace on ctx.
+func qdrantWorkspace(ctx context.Context) *qdrant.Condition {
+	if workspace := rag.WorkspaceFromContext(ctx); workspace != "" {
+		return qdrant.NewMatchKeyword(workspaceField, workspace)
+	}
+	return qdrant.NewIsEmpty(workspaceField)
+}
+
+// qdrantWorkspaceIDs matches the points with ids in the workspace on ctx.
+func qdrantWorkspaceIDs(ctx context.Context, ids ...*qdrant.PointId) *qdrant.Filter {
+	return &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewHasID(ids...), qdrantWorkspace(ctx)}}
+}
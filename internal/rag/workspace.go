@@ -0,0 +1,45 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+type workspaceKey struct{}
+
+// WithWorkspace attaches the caller's workspace to ctx so storage backends can
+// route to the workspace's own index.
+func WithWorkspace(ctx context.Context, workspace string) context.Context {
+	if workspace == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, workspaceKey{}, workspace)
+}
+
+// WorkspaceFromContext returns the workspace set by WithWorkspace, or "".
+func WorkspaceFromContext(ctx context.Context) string {
+	workspace, _ := ctx.Value(workspaceKey{}).(string)
+	return workspace
+}
+
+// WorkspaceKey returns a lowercase name fragment for per-workspace indexes
+// and collections: the workspace's ASCII letters, digits, '-' and '_',
+// followed by a hash of the full name so that workspaces differing only in
+// case or other characters never share one.
+func WorkspaceKey(workspace string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(workspace) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		}
+	}
+	sum := sha256.Sum256([]byte(workspace))
+	hash := hex.EncodeToString(sum[:4])
+	if b.Len() == 0 {
+		return hash
+	}
+	return b.String() + "-" + hash
+}
@@ -0,0 +1,24 @@
+package rag
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestWorkspaceKey(t *testing.T) {
+	valid := regexp.MustCompile(`^([a-z0-9_-]+-)?[0-9a-f]{8}$`)
+	seen := map[string]string{}
+	for _, workspace := range []string{"campus-a", "Campus-A", "campus_a", "campus a", "campusa", "인사팀", "재무팀", "a.b", "ab"} {
+		key := WorkspaceKey(workspace)
+		if !valid.MatchString(key) {
+			t.Errorf("WorkspaceKey(%q) = %q is not a valid name fragment", workspace, key)
+		}
+		if other, ok := seen[key]; ok {
+			t.Errorf("WorkspaceKey(%q) = WorkspaceKey(%q) = %q", workspace, other, key)
+		}
+		seen[key] = workspace
+	}
+	if WorkspaceKey("campus-a") != WorkspaceKey("campus-a") {
+		t.Error("WorkspaceKey is not deterministic")
+	}
+}
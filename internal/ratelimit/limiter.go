@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"yuon/configuration"
+)
+
+// Limit is a token bucket: Burst requests at once, refilled at PerMinute.
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.PerMinute
+}
+
+// Result describes the bucket after a request was counted.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is the wait until the next token when not allowed.
+	RetryAfter time.Duration
+	// ResetAfter is the wait until the bucket is full again.
+	ResetAfter time.Duration
+}
+
+// Limiter takes one token from the bucket identified by key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// New returns a Redis limiter shared by all instances, or an in-process
+// limiter when REDIS_URL is not set.
+func New(cfg *configuration.RateLimitConfig) (Limiter, error) {
+	if cfg.RedisURL == "" {
+		return NewMemoryLimiter(), nil
+	}
+	return NewRedisLimiter(cfg.RedisURL)
+}
+
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	rate := limit.rate()
+	burst := limit.burst()
+	res := Result{
+		Allowed:    allowed,
+		Limit:      burst,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((float64(burst) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return res
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryLimiter keeps buckets in process. Limits are per instance.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+func (m *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	if limit.PerMinute <= 0 {
+		return Result{Allowed: true}, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	burst := float64(limit.burst())
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.rate())
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	if now.Sub(m.lastSweep) > time.Minute {
+		m.sweep(now)
+	}
+	return newResult(limit, b.tokens, allowed), nil
+}
+
+// sweep drops buckets idle for more than an hour; they would be full anyway
+// for any limit above one request per hour.
+func (m *MemoryLimiter) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if now.Sub(b.last) > time.Hour {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
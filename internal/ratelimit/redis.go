@@ -0,0 +1,242 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tokenBucketScript refills and takes from a bucket atomically using the
+// Redis clock so instances with skewed clocks agree.
+// KEYS[1]=bucket, ARGV[1]=tokens per second, ARGV[2]=burst.
+const tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1]) or burst
+local ts = tonumber(data[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+const redisPoolSize = 16
+
+// RedisLimiter shares buckets between instances through Redis. It speaks
+// just enough RESP to run the token bucket script.
+type RedisLimiter struct {
+	addr     string
+	password string
+	db       int
+	useTLS   bool
+	timeout  time.Duration
+	pool     chan *redisConn
+}
+
+// NewRedisLimiter accepts redis://[:password@]host:port[/db] or rediss://.
+func NewRedisLimiter(rawURL string) (*RedisLimiter, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse redis url failed: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("unsupported redis url scheme: %s", u.Scheme)
+	}
+
+	l := &RedisLimiter{
+		addr:    u.Host,
+		useTLS:  u.Scheme == "rediss",
+		timeout: 2 * time.Second,
+		pool:    make(chan *redisConn, redisPoolSize),
+	}
+	if u.Port() == "" {
+		l.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		l.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if l.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis db: %s", db)
+		}
+	}
+	return l, nil
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if limit.PerMinute <= 0 {
+		return Result{Allowed: true}, nil
+	}
+
+	reply, err := l.do(ctx, "EVAL", tokenBucketScript, "1", key,
+		strconv.FormatFloat(limit.rate(), 'f', -1, 64), strconv.Itoa(limit.burst()))
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]any)
+	if !ok || len(values) != 2 {
+		return Result{}, errors.New("unexpected redis rate limit reply")
+	}
+	allowed, _ := values[0].(int64)
+	tokenStr, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokenStr, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("parse redis tokens failed: %w", err)
+	}
+	return newResult(limit, tokens, allowed == 1), nil
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (l *RedisLimiter) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(l.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	reply, err := conn.command(args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		conn.Close()
+		return nil, err
+	}
+	l.put(conn)
+	return reply, err
+}
+
+func (l *RedisLimiter) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-l.pool:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: l.timeout}
+	var (
+		nc  net.Conn
+		err error
+	)
+	if l.useTLS {
+		host, _, _ := net.SplitHostPort(l.addr)
+		nc, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", l.addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", l.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis dial failed: %w", err)
+	}
+
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	conn.SetDeadline(time.Now().Add(l.timeout))
+	if l.password != "" {
+		if _, err := conn.command("AUTH", l.password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis auth failed: %w", err)
+		}
+	}
+	if l.db != 0 {
+		if _, err := conn.command("SELECT", strconv.Itoa(l.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis select failed: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (l *RedisLimiter) put(conn *redisConn) {
+	select {
+	case l.pool <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func (c *redisConn) command(args ...string) (any, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(c.Conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+func (c *redisConn) readReply() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]any, n)
+		for i := range values {
+			v, err := c.readReply()
+			var redisErr redisError
+			if errors.As(err, &redisErr) {
+				v = redisErr
+			} else if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unexpected redis reply: %q", line)
+}
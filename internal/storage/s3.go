@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime"
 	"strings"
 	"time"
 
@@ -23,6 +24,7 @@ type S3Client struct {
 	baseURL  string
 	uploader *manager.Uploader
 	client   *s3.Client
+	presign  *s3.PresignClient
 }
 
 func NewS3Client(cfg *configuration.StorageConfig) (*S3Client, error) {
@@ -65,6 +67,7 @@ func NewS3Client(cfg *configuration.StorageConfig) (*S3Client, error) {
 		baseURL:  strings.TrimRight(cfg.BaseURL, "/"),
 		uploader: uploader,
 		client:   s3Client,
+		presign:  s3.NewPresignClient(s3Client),
 	}, nil
 }
 
@@ -94,6 +97,28 @@ func (c *S3Client) Upload(ctx context.Context, key string, data []byte, contentT
 	return key, nil
 }
 
+func (c *S3Client) UploadStream(ctx context.Context, key string, r io.Reader, contentType string) (string, error) {
+	if c.bucket == "" {
+		return "", fmt.Errorf("bucket is not configured")
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(c.bucket),
+		Key:         aws.String(key),
+		Body:        r,
+		ContentType: aws.String(contentType),
+		ACL:         types.ObjectCannedACLPrivate,
+	}
+	if _, err := c.uploader.Upload(ctx, input); err != nil {
+		return "", fmt.Errorf("s3 upload failed: %w", err)
+	}
+
+	if c.baseURL != "" {
+		return fmt.Sprintf("%s/%s", c.baseURL, key), nil
+	}
+	return key, nil
+}
+
 func (c *S3Client) Download(ctx context.Context, key string) ([]byte, string, error) {
 	if c.bucket == "" {
 		return nil, "", fmt.Errorf("bucket is not configured")
@@ -123,3 +148,59 @@ func (c *S3Client) Download(ctx context.Context, key string) ([]byte, string, er
 
 	return body, contentType, nil
 }
+
+func (c *S3Client) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if c.bucket == "" {
+		return nil, fmt.Errorf("bucket is not configured")
+	}
+
+	resp, err := c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3 download failed: %w", err)
+	}
+	return resp.Body, nil
+}
+
+func (c *S3Client) Delete(ctx context.Context, key string) error {
+	if c.bucket == "" {
+		return fmt.Errorf("bucket is not configured")
+	}
+
+	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("s3 delete failed: %w", err)
+	}
+	return nil
+}
+
+// PresignURL returns a time-limited GET URL for the object stored at key.
+// The URL points at the configured endpoint, so clients must be able to
+// reach it.
+func (c *S3Client) PresignURL(ctx context.Context, key, filename string, expires time.Duration) (string, error) {
+	if c.bucket == "" {
+		return "", fmt.Errorf("bucket is not configured")
+	}
+	if expires <= 0 {
+		expires = 15 * time.Minute
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	}
+	if filename != "" {
+		input.ResponseContentDisposition = aws.String(mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+	req, err := c.presign.PresignGetObject(ctx, input, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", fmt.Errorf("s3 presign failed: %w", err)
+	}
+
+	return req.URL, nil
+}
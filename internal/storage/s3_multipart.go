@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func (c *S3Client) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	if c.bucket == "" {
+		return "", fmt.Errorf("bucket is not configured")
+	}
+
+	resp, err := c.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(c.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		ACL:         types.ObjectCannedACLPrivate,
+	})
+	if err != nil {
+		return "", fmt.Errorf("s3 create multipart upload failed: %w", err)
+	}
+
+	return aws.ToString(resp.UploadId), nil
+}
+
+func (c *S3Client) UploadPart(ctx context.Context, key, uploadID string, partNumber int32, data []byte) (string, error) {
+	if c.bucket == "" {
+		return "", fmt.Errorf("bucket is not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	resp, err := c.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(c.bucket),
+		Key:           aws.String(key),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int32(partNumber),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	})
+	if err != nil {
+		return "", fmt.Errorf("s3 upload part failed: %w", err)
+	}
+
+	return aws.ToString(resp.ETag), nil
+}
+
+func (c *S3Client) ListParts(ctx context.Context, key, uploadID string) ([]UploadedPart, error) {
+	if c.bucket == "" {
+		return nil, fmt.Errorf("bucket is not configured")
+	}
+
+	var parts []UploadedPart
+	var marker *string
+	for {
+		resp, err := c.client.ListParts(ctx, &s3.ListPartsInput{
+			Bucket:           aws.String(c.bucket),
+			Key:              aws.String(key),
+			UploadId:         aws.String(uploadID),
+			PartNumberMarker: marker,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("s3 list parts failed: %w", err)
+		}
+
+		for _, p := range resp.Parts {
+			parts = append(parts, UploadedPart{
+				PartNumber: aws.ToInt32(p.PartNumber),
+				ETag:       aws.ToString(p.ETag),
+				Size:       aws.ToInt64(p.Size),
+			})
+		}
+
+		if !aws.ToBool(resp.IsTruncated) || resp.NextPartNumberMarker == nil {
+			break
+		}
+		marker = resp.NextPartNumberMarker
+	}
+
+	return parts, nil
+}
+
+func (c *S3Client) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []UploadedPart) (string, error) {
+	if c.bucket == "" {
+		return "", fmt.Errorf("bucket is not configured")
+	}
+
+	sorted := make([]UploadedPart, len(parts))
+	copy(sorted, parts)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].PartNumber < sorted[j].PartNumber })
+
+	completed := make([]types.CompletedPart, 0, len(sorted))
+	for _, p := range sorted {
+		completed = append(completed, types.CompletedPart{
+			ETag:       aws.String(p.ETag),
+			PartNumber: aws.Int32(p.PartNumber),
+		})
+	}
+
+	_, err := c.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(c.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return "", fmt.Errorf("s3 complete multipart upload failed: %w", err)
+	}
+
+	if c.baseURL != "" {
+		return fmt.Sprintf("%s/%s", c.baseURL, key), nil
+	}
+	return key, nil
+}
+
+func (c *S3Client) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	if c.bucket == "" {
+		return fmt.Errorf("bucket is not configured")
+	}
+
+	_, err := c.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(c.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil {
+		return fmt.Errorf("s3 abort multipart upload failed: %w", err)
+	}
+	return nil
+}
@@ -1,9 +1,36 @@
 package storage
 
-import "context"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 // FileStorage defines uploading interface.
 type FileStorage interface {
 	Upload(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	// UploadStream uploads everything read from r, in parts, without
+	// holding the whole object in memory.
+	UploadStream(ctx context.Context, key string, r io.Reader, contentType string) (string, error)
 	Download(ctx context.Context, key string) ([]byte, string, error)
+	// Open streams the object at key; the caller closes the reader.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// PresignURL returns a GET URL for the object at key that is valid for
+	// expires. With a filename, the URL downloads the object as an
+	// attachment of that name.
+	PresignURL(ctx context.Context, key, filename string, expires time.Duration) (string, error)
+	Delete(ctx context.Context, key string) error
+
+	CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error)
+	UploadPart(ctx context.Context, key, uploadID string, partNumber int32, data []byte) (string, error)
+	ListParts(ctx context.Context, key, uploadID string) ([]UploadedPart, error)
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []UploadedPart) (string, error)
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+}
+
+// UploadedPart describes a single part of a multipart upload.
+type UploadedPart struct {
+	PartNumber int32  `json:"partNumber"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
 }
@@ -38,6 +38,47 @@ func ExtractText(filename string, data []byte) (string, error) {
 	}
 }
 
+// ExtractTextFile is ExtractText for a file on disk, for uploads too large
+// to hold in memory.
+func ExtractTextFile(filename, path string) (string, error) {
+	ext := strings.ToLower(strings.TrimPrefix(filepathExt(filename), "."))
+
+	switch ext {
+	case "txt":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("text file read failed: %w", err)
+		}
+		return string(data), nil
+	case "pdf":
+		return extractPDFFile(path)
+	case "docx":
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return "", fmt.Errorf("docx unzip 실패: %w", err)
+		}
+		defer zr.Close()
+		return docxText(&zr.Reader)
+	case "doc":
+		return "", fmt.Errorf(".doc format is not supported; please convert to .docx")
+	case "hwp":
+		return extractHWPFile(path)
+	default:
+		return "", fmt.Errorf("unsupported file type: %s", ext)
+	}
+}
+
+// IsImage reports whether the file is an image that needs vision captioning
+// instead of text extraction.
+func IsImage(filename string) bool {
+	switch strings.ToLower(strings.TrimPrefix(filepathExt(filename), ".")) {
+	case "png", "jpg", "jpeg":
+		return true
+	default:
+		return false
+	}
+}
+
 func filepathExt(name string) string {
 	for i := len(name) - 1; i >= 0; i-- {
 		if name[i] == '.' {
@@ -65,9 +106,12 @@ func extractPDF(data []byte) (string, error) {
 	if err := tmpPDF.Close(); err != nil {
 		return "", fmt.Errorf("pdf temp file close failed: %w", err)
 	}
+	return extractPDFFile(tmpPDF.Name())
+}
 
+func extractPDFFile(path string) (string, error) {
 	// 1) ConvertAPI 우선 시도
-	if text, err := extractPDFViaConvertAPI(tmpPDF.Name()); err == nil && text != "" {
+	if text, err := extractPDFViaConvertAPI(path); err == nil && text != "" {
 		return text, nil
 	}
 
@@ -80,7 +124,7 @@ func extractPDF(data []byte) (string, error) {
 
 	// Extract text using pdfcpu content extractor (text content only)
 	conf := model.NewDefaultConfiguration()
-	err = api.ExtractContentFile(tmpPDF.Name(), tmpDir, nil, conf)
+	err = api.ExtractContentFile(path, tmpDir, nil, conf)
 	if err != nil {
 		return "", fmt.Errorf("pdf text extraction failed: %w", err)
 	}
@@ -161,8 +205,11 @@ func extractHWP(data []byte) (string, error) {
 	if err := tmp.Close(); err != nil {
 		return "", fmt.Errorf("hwp temp file close failed: %w", err)
 	}
+	return extractHWPFile(tmp.Name())
+}
 
-	cmd := exec.Command("hwp5txt", tmp.Name())
+func extractHWPFile(path string) (string, error) {
+	cmd := exec.Command("hwp5txt", path)
 	out, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("hwp5txt execution failed: %w", err)
@@ -180,7 +227,10 @@ func extractDocx(data []byte) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("docx unzip 실패: %w", err)
 	}
+	return docxText(zr)
+}
 
+func docxText(zr *zip.Reader) (string, error) {
 	var docXML io.Reader
 	for _, f := range zr.File {
 		if f.Name == "word/document.xml" {
@@ -0,0 +1,185 @@
+// Package upload keeps the sessions of resumable multipart uploads, so an
+// upload can continue on any replica and across restarts.
+package upload
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrSessionNotFound is returned for unknown and expired sessions.
+var ErrSessionNotFound = errors.New("upload session not found")
+
+// Session is a resumable upload in progress. Principal is the caller that
+// started it; nobody else may add parts to, complete or abort it.
+type Session struct {
+	ID          string
+	UploadID    string
+	Key         string
+	Principal   string
+	Filename    string
+	ContentType string
+	DocumentID  string
+	Size        int64
+	Metadata    map[string]interface{}
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Store persists upload sessions.
+type Store interface {
+	Create(ctx context.Context, session *Session) error
+	// Get returns ErrSessionNotFound when the session does not exist or has
+	// expired.
+	Get(ctx context.Context, id string) (*Session, error)
+	Delete(ctx context.Context, id string) error
+	// Expired returns up to limit sessions that expired before now.
+	Expired(ctx context.Context, now time.Time, limit int) ([]Session, error)
+}
+
+// Aborter cancels the multipart upload behind a session.
+type Aborter interface {
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+}
+
+const expireBatch = 100
+
+// Expire aborts the multipart uploads of sessions that expired before now
+// and deletes those sessions. A session whose abort fails is kept for the
+// next run.
+func Expire(ctx context.Context, store Store, aborter Aborter, now time.Time) (int, error) {
+	expired, err := store.Expired(ctx, now, expireBatch)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, session := range expired {
+		if err := aborter.AbortMultipartUpload(ctx, session.Key, session.UploadID); err != nil {
+			slog.WarnContext(ctx, "만료된 업로드 취소 실패", "error", err, "uploadId", session.ID, "key", session.Key)
+			continue
+		}
+		if err := store.Delete(ctx, session.ID); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// RunExpiry calls Expire every interval, starting right away, until ctx is
+// done.
+func RunExpiry(ctx context.Context, store Store, aborter Aborter, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := Expire(ctx, store, aborter, time.Now().UTC()); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "만료된 업로드 정리 실패", "error", err)
+		} else if n > 0 {
+			slog.InfoContext(ctx, "만료된 업로드 정리", "count", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Create(ctx context.Context, session *Session) error {
+	metadata, err := json.Marshal(session.Metadata)
+	if err != nil {
+		return fmt.Errorf("marshal upload metadata failed: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO upload_sessions (id, upload_id, key, principal, filename, content_type, document_id, size, metadata, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		session.ID, session.UploadID, session.Key, session.Principal, session.Filename, session.ContentType,
+		session.DocumentID, session.Size, metadata, session.CreatedAt, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("create upload session failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) (*Session, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM upload_sessions
+		WHERE id = $1 AND expires_at > NOW()`, id)
+	session, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load upload session failed: %w", err)
+	}
+	return session, nil
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM upload_sessions WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("delete upload session failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Expired(ctx context.Context, now time.Time, limit int) ([]Session, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM upload_sessions
+		WHERE expires_at <= $1
+		ORDER BY expires_at
+		LIMIT $2`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list expired upload sessions failed: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan upload session failed: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+const sessionColumns = `id, upload_id, key, principal, filename, content_type, document_id, size, metadata, created_at, expires_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row rowScanner) (*Session, error) {
+	var (
+		session  Session
+		metadata []byte
+	)
+	if err := row.Scan(&session.ID, &session.UploadID, &session.Key, &session.Principal, &session.Filename,
+		&session.ContentType, &session.DocumentID, &session.Size, &metadata, &session.CreatedAt, &session.ExpiresAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(metadata, &session.Metadata); err != nil {
+		return nil, fmt.Errorf("unmarshal upload metadata failed: %w", err)
+	}
+	if session.Metadata == nil {
+		session.Metadata = make(map[string]interface{})
+	}
+	return &session, nil
+}
@@ -0,0 +1,84 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeStore struct {
+	sessions map[string]Session
+}
+
+func (s *fakeStore) Create(ctx context.Context, session *Session) error {
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *fakeStore) Get(ctx context.Context, id string) (*Session, error) {
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (s *fakeStore) Delete(ctx context.Context, id string) error {
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *fakeStore) Expired(ctx context.Context, now time.Time, limit int) ([]Session, error) {
+	var expired []Session
+	for _, session := range s.sessions {
+		if !session.ExpiresAt.After(now) && len(expired) < limit {
+			expired = append(expired, session)
+		}
+	}
+	return expired, nil
+}
+
+// fakeAborter fails to abort the uploads in fail and records the others.
+type fakeAborter struct {
+	fail    map[string]bool
+	aborted []string
+}
+
+func (a *fakeAborter) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	if a.fail[uploadID] {
+		return errors.New("storage unavailable")
+	}
+	a.aborted = append(a.aborted, uploadID)
+	return nil
+}
+
+func TestExpire(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{sessions: map[string]Session{
+		"live":    {ID: "live", UploadID: "u-live", ExpiresAt: now.Add(time.Hour)},
+		"expired": {ID: "expired", UploadID: "u-expired", ExpiresAt: now.Add(-time.Hour)},
+		"stuck":   {ID: "stuck", UploadID: "u-stuck", ExpiresAt: now.Add(-time.Minute)},
+	}}
+	aborter := &fakeAborter{fail: map[string]bool{"u-stuck": true}}
+
+	removed, err := Expire(context.Background(), store, aborter, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("removed = %d, want 1", removed)
+	}
+	if len(aborter.aborted) != 1 || aborter.aborted[0] != "u-expired" {
+		t.Errorf("aborted = %v, want [u-expired]", aborter.aborted)
+	}
+	if _, ok := store.sessions["expired"]; ok {
+		t.Error("expired session was kept")
+	}
+	if _, ok := store.sessions["stuck"]; !ok {
+		t.Error("session whose abort failed was deleted")
+	}
+	if _, ok := store.sessions["live"]; !ok {
+		t.Error("live session was deleted")
+	}
+}
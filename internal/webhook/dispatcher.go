@@ -0,0 +1,257 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"yuon/configuration"
+)
+
+const (
+	HeaderEvent     = "X-Yuon-Event"
+	HeaderDelivery  = "X-Yuon-Delivery"
+	HeaderTimestamp = "X-Yuon-Timestamp"
+	HeaderSignature = "X-Yuon-Signature"
+
+	maxRetryDelay = 10 * time.Minute
+)
+
+// Envelope is the JSON body POSTed to every subscribed webhook.
+type Envelope struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+// Dispatcher manages webhook registrations and delivers events to them.
+// Deliveries run in background goroutines, so retries still pending when the
+// process exits are lost; the delivery log keeps them as "pending".
+//
+// Publish is a no-op on a nil *Dispatcher, so callers need no nil checks.
+type Dispatcher struct {
+	store       Store
+	client      *http.Client
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+func NewDispatcher(store Store, cfg *configuration.WebhookConfig) *Dispatcher {
+	return &Dispatcher{
+		store:       store,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		maxAttempts: cfg.MaxAttempts,
+		baseDelay:   cfg.RetryBaseDelay,
+	}
+}
+
+// Create registers a webhook with a freshly generated signing secret.
+func (d *Dispatcher) Create(ctx context.Context, rawURL string, events []string, description, createdBy string) (*Webhook, error) {
+	if err := Validate(rawURL, events); err != nil {
+		return nil, err
+	}
+	secret, err := NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Webhook{
+		ID:          uuid.New().String(),
+		URL:         rawURL,
+		Events:      events,
+		Secret:      secret,
+		Description: description,
+		Active:      true,
+		CreatedBy:   createdBy,
+	}
+	if err := d.store.Create(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (d *Dispatcher) List(ctx context.Context) ([]*Webhook, error) {
+	return d.store.List(ctx)
+}
+
+func (d *Dispatcher) Get(ctx context.Context, id string) (*Webhook, error) {
+	return d.store.Get(ctx, id)
+}
+
+// Update replaces the URL, events, description and active flag. The secret is
+// regenerated when rotateSecret is set.
+func (d *Dispatcher) Update(ctx context.Context, w *Webhook, rotateSecret bool) error {
+	if err := Validate(w.URL, w.Events); err != nil {
+		return err
+	}
+	if rotateSecret {
+		secret, err := NewSecret()
+		if err != nil {
+			return err
+		}
+		w.Secret = secret
+	}
+	return d.store.Update(ctx, w)
+}
+
+func (d *Dispatcher) Delete(ctx context.Context, id string) error {
+	return d.store.Delete(ctx, id)
+}
+
+func (d *Dispatcher) Deliveries(ctx context.Context, webhookID string, limit int) ([]*Delivery, error) {
+	if _, err := d.store.Get(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	return d.store.ListDeliveries(ctx, webhookID, limit)
+}
+
+// Publish records a delivery for every active webhook subscribed to event and
+// sends them in the background. Failures are logged, never returned, so
+// callers can publish without affecting their own response.
+func (d *Dispatcher) Publish(ctx context.Context, event string, data any) {
+	if d == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	hooks, err := d.store.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "웹훅 목록 조회 실패", "event", event, "error", err)
+		return
+	}
+
+	var body []byte
+	for _, w := range hooks {
+		if !w.Subscribed(event) {
+			continue
+		}
+		if body == nil {
+			body, err = json.Marshal(Envelope{
+				ID:        uuid.New().String(),
+				Event:     event,
+				CreatedAt: time.Now().UTC(),
+				Data:      data,
+			})
+			if err != nil {
+				slog.ErrorContext(ctx, "웹훅 페이로드 생성 실패", "event", event, "error", err)
+				return
+			}
+		}
+
+		delivery := &Delivery{
+			ID:        uuid.New().String(),
+			WebhookID: w.ID,
+			Event:     event,
+			Payload:   body,
+			Status:    DeliveryPending,
+		}
+		if err := d.store.CreateDelivery(ctx, delivery); err != nil {
+			slog.ErrorContext(ctx, "웹훅 전송 기록 실패", "webhook_id", w.ID, "event", event, "error", err)
+			continue
+		}
+		go d.deliver(ctx, w, delivery)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, w *Webhook, delivery *Delivery) {
+	for {
+		delivery.Attempts++
+		status, err := d.send(ctx, w, delivery)
+		delivery.ResponseStatus = status
+		delivery.Error = ""
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+
+		switch {
+		case err == nil:
+			delivery.Status = DeliverySucceeded
+		case delivery.Attempts >= d.maxAttempts || !retryable(status):
+			delivery.Status = DeliveryFailed
+		}
+
+		if err := d.store.UpdateDelivery(ctx, delivery); err != nil {
+			slog.ErrorContext(ctx, "웹훅 전송 기록 갱신 실패", "delivery_id", delivery.ID, "error", err)
+		}
+		if delivery.Status != DeliveryPending {
+			if delivery.Status == DeliveryFailed {
+				slog.WarnContext(ctx, "웹훅 전송 실패",
+					"webhook_id", w.ID,
+					"delivery_id", delivery.ID,
+					"event", delivery.Event,
+					"attempts", delivery.Attempts,
+					"error", delivery.Error,
+				)
+			}
+			return
+		}
+
+		time.Sleep(d.backoff(delivery.Attempts))
+	}
+}
+
+// send POSTs the payload once. The signature is HMAC-SHA256 over
+// "<timestamp>.<body>" so receivers can reject replayed requests.
+func (d *Dispatcher) send(ctx context.Context, w *Webhook, delivery *Delivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "yuon-webhook/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(w.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryable treats network errors, 408, 429 and 5xx as transient. Other 4xx
+// responses mean the receiver rejected the payload and will keep doing so.
+func retryable(status int) bool {
+	return status == 0 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	if d.baseDelay <= 0 {
+		return 0
+	}
+	delay := d.baseDelay << (attempt - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,266 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	EventDocumentCreated       = "document.created"
+	EventDocumentDeleted       = "document.deleted"
+	EventIngestionFinished     = "ingestion.finished"
+	EventConversationCompleted = "conversation.completed"
+	EventAlertFired            = "alert.fired"
+	EventAlertResolved         = "alert.resolved"
+)
+
+// Events lists every event type a webhook can subscribe to.
+var Events = []string{
+	EventDocumentCreated,
+	EventDocumentDeleted,
+	EventIngestionFinished,
+	EventConversationCompleted,
+	EventAlertFired,
+	EventAlertResolved,
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidURL      = errors.New("webhook url must be an absolute http(s) url")
+	ErrInvalidEvents   = errors.New("webhook events must be a non-empty list of known event types")
+)
+
+// Webhook is an admin-registered endpoint receiving signed event payloads.
+type Webhook struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Secret      string    `json:"-"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedBy   string    `json:"createdBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Subscribed reports whether the webhook should receive event.
+func (w *Webhook) Subscribed(event string) bool {
+	return w.Active && slices.Contains(w.Events, event)
+}
+
+// Delivery is one event sent to one webhook, updated after every attempt.
+type Delivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhookId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+}
+
+// Validate normalises and checks the URL and event list.
+func Validate(rawURL string, events []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	if len(events) == 0 {
+		return ErrInvalidEvents
+	}
+	for _, e := range events {
+		if !slices.Contains(Events, e) {
+			return ErrInvalidEvents
+		}
+	}
+	return nil
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+type Store interface {
+	Create(ctx context.Context, w *Webhook) error
+	Get(ctx context.Context, id string) (*Webhook, error)
+	List(ctx context.Context) ([]*Webhook, error)
+	Update(ctx context.Context, w *Webhook) error
+	Delete(ctx context.Context, id string) error
+	CreateDelivery(ctx context.Context, d *Delivery) error
+	UpdateDelivery(ctx context.Context, d *Delivery) error
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*Delivery, error)
+}
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const webhookColumns = `id, url, events, secret, description, active, COALESCE(created_by, ''), created_at, updated_at`
+
+func (s *PostgresStore) Create(ctx context.Context, w *Webhook) error {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO webhooks (id, url, events, secret, description, active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at`,
+		w.ID, w.URL, pq.Array(w.Events), w.Secret, w.Description, w.Active, w.CreatedBy,
+	).Scan(&w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("create webhook failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) (*Webhook, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id)
+	w, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	return w, err
+}
+
+func (s *PostgresStore) List(ctx context.Context) ([]*Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []*Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, w)
+	}
+	return hooks, rows.Err()
+}
+
+func (s *PostgresStore) Update(ctx context.Context, w *Webhook) error {
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE webhooks
+		SET url = $2, events = $3, secret = $4, description = $5, active = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`,
+		w.ID, w.URL, pq.Array(w.Events), w.Secret, w.Description, w.Active,
+	).Scan(&w.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWebhookNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("update webhook failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook failed: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) CreateDelivery(ctx context.Context, d *Delivery) error {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_deliveries (id, webhook_id, event, payload, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at`,
+		d.ID, d.WebhookID, d.Event, []byte(d.Payload), d.Status,
+	).Scan(&d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("create webhook delivery failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) UpdateDelivery(ctx context.Context, d *Delivery) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, response_status = NULLIF($4, 0), error = NULLIF($5, ''), updated_at = NOW()
+		WHERE id = $1`,
+		d.ID, d.Status, d.Attempts, d.ResponseStatus, d.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("update webhook delivery failed: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, webhook_id, event, payload, status, attempts,
+			COALESCE(response_status, 0), COALESCE(error, ''), created_at, updated_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*Delivery
+	for rows.Next() {
+		var (
+			d       Delivery
+			payload []byte
+		)
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts,
+			&d.ResponseStatus, &d.Error, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWebhook(row rowScanner) (*Webhook, error) {
+	var w Webhook
+	if err := row.Scan(&w.ID, &w.URL, pq.Array(&w.Events), &w.Secret, &w.Description, &w.Active,
+		&w.CreatedBy, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
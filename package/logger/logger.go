@@ -23,7 +23,7 @@ func New(env string) *Logger {
 		handler = slog.NewTextHandler(os.Stdout, opts)
 	}
 
-	logger := slog.New(handler)
+	logger := slog.New(contextHandler{handler})
 	slog.SetDefault(logger)
 
 	return &Logger{Logger: logger}
@@ -0,0 +1,85 @@
+// Package pagination implements the opaque cursors shared by list APIs.
+// A cursor is the base64url-encoded JSON array of the sort key of the last
+// item returned; the next page starts strictly after it.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// Page is embedded in every list response.
+type Page struct {
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+// Limit clamps a requested page size to [1, MaxLimit], using def when unset.
+func Limit(requested, def int) int {
+	if requested <= 0 {
+		return def
+	}
+	if requested > MaxLimit {
+		return MaxLimit
+	}
+	return requested
+}
+
+// EncodeCursor packs sort values into an opaque token. It returns "" when
+// there is nothing to encode.
+func EncodeCursor(values ...any) string {
+	if len(values) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reverses EncodeCursor.
+func DecodeCursor(cursor string) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var values []any
+	if err := json.Unmarshal(data, &values); err != nil || len(values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return values, nil
+}
+
+// EncodeTimeCursor is the cursor for lists keyed by (timestamp, id).
+func EncodeTimeCursor(t time.Time, id string) string {
+	return EncodeCursor(t.UTC().Format(time.RFC3339Nano), id)
+}
+
+// DecodeTimeCursor reverses EncodeTimeCursor.
+func DecodeTimeCursor(cursor string) (time.Time, string, error) {
+	values, err := DecodeCursor(cursor)
+	if err != nil || len(values) != 2 {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	ts, ok := values[0].(string)
+	id, ok2 := values[1].(string)
+	if !ok || !ok2 {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return t, id, nil
+}
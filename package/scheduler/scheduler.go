@@ -0,0 +1,52 @@
+// Package scheduler runs background jobs at fixed times of day, in the
+// spirit of a one-line crontab kept inside the server process.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Day is the midnight starting the day t falls on in loc.
+func Day(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// NextMidnight is the first midnight in loc after t.
+func NextMidnight(t time.Time, loc *time.Location) time.Time {
+	return Day(t, loc).AddDate(0, 0, 1)
+}
+
+// Daily calls job at every midnight in loc with the day that just ended,
+// until ctx is done. A failing run is logged and retried the next night.
+func Daily(ctx context.Context, name string, loc *time.Location, job func(ctx context.Context, day time.Time) error) {
+	for {
+		next := NextMidnight(time.Now(), loc)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		day := next.AddDate(0, 0, -1)
+		if err := job(ctx, day); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "예약 작업 실패", "job", name, "day", day.Format(time.DateOnly), "error", err)
+		}
+	}
+}
+
+// Weekly calls job at every midnight starting weekday in loc with the first
+// day of the week that just ended, until ctx is done.
+func Weekly(ctx context.Context, name string, loc *time.Location, weekday time.Weekday, job func(ctx context.Context, weekStart time.Time) error) {
+	Daily(ctx, name, loc, func(ctx context.Context, day time.Time) error {
+		next := day.AddDate(0, 0, 1)
+		if next.Weekday() != weekday {
+			return nil
+		}
+		return job(ctx, next.AddDate(0, 0, -7))
+	})
+}
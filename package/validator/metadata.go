@@ -0,0 +1,143 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MetadataSchema describes the constraints applied to document metadata.
+type MetadataSchema struct {
+	Required   []string
+	Categories []string
+	Types      map[string]string
+}
+
+// IsEmpty reports whether the schema has no rules configured.
+func (s *MetadataSchema) IsEmpty() bool {
+	return s == nil || (len(s.Required) == 0 && len(s.Categories) == 0 && len(s.Types) == 0)
+}
+
+// ValidateMetadata checks metadata against the schema and returns field-level errors.
+func ValidateMetadata(schema *MetadataSchema, metadata map[string]interface{}) []ValidationError {
+	if schema.IsEmpty() {
+		return nil
+	}
+
+	var errors []ValidationError
+
+	for _, field := range schema.Required {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		value, ok := metadata[field]
+		if !ok || isBlank(value) {
+			errors = append(errors, ValidationError{
+				Field:   "metadata." + field,
+				Message: fmt.Sprintf("%s는 필수 항목입니다", field),
+			})
+		}
+	}
+
+	if len(schema.Categories) > 0 {
+		if value, ok := metadata["category"]; ok && !isBlank(value) {
+			category, _ := value.(string)
+			if !containsFold(schema.Categories, category) {
+				errors = append(errors, ValidationError{
+					Field:   "metadata.category",
+					Message: fmt.Sprintf("다음 값 중 하나여야 합니다: %s", strings.Join(schema.Categories, ", ")),
+				})
+			}
+		}
+	}
+
+	fields := make([]string, 0, len(schema.Types))
+	for field := range schema.Types {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		value, ok := metadata[field]
+		if !ok || value == nil {
+			continue
+		}
+		expected := strings.ToLower(strings.TrimSpace(schema.Types[field]))
+		if !matchesType(value, expected) {
+			errors = append(errors, ValidationError{
+				Field:   "metadata." + field,
+				Message: fmt.Sprintf("%s 타입이어야 합니다", expected),
+			})
+		}
+	}
+
+	return errors
+}
+
+func matchesType(value interface{}, expected string) bool {
+	switch expected {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		switch value.(type) {
+		case float64, float32, int, int64:
+			return true
+		}
+		return false
+	case "boolean", "bool":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	default:
+		return false
+	}
+}
+
+var metadataTypes = []string{"string", "number", "boolean", "bool", "array", "object"}
+
+// CheckMetadataTypes rejects type names ValidateMetadata does not know, which
+// would otherwise fail every document carrying the field.
+func CheckMetadataTypes(types map[string]string) error {
+	fields := make([]string, 0, len(types))
+	for field := range types {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		expected := strings.ToLower(strings.TrimSpace(types[field]))
+		if !containsFold(metadataTypes, expected) {
+			return fmt.Errorf("%s의 타입 %q을 알 수 없습니다 (%s 중 하나)", field, types[field], strings.Join(metadataTypes, ", "))
+		}
+	}
+	return nil
+}
+
+func isBlank(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+func containsFold(values []string, target string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), target) {
+			return true
+		}
+	}
+	return false
+}
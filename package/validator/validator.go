@@ -1,8 +1,12 @@
 package validator
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
@@ -13,22 +17,45 @@ type ValidationError struct {
 	Message string `json:"message"`
 }
 
+var (
+	mu         sync.RWMutex
+	categories []string
+)
+
+// GetValidationErrors turns binding failures into field-level errors. It
+// returns nil for errors that do not concern a particular field, such as
+// malformed JSON.
 func GetValidationErrors(err error) []ValidationError {
-	var errors []ValidationError
+	var fieldErrors []ValidationError
 
-	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
 		for _, e := range validationErrors {
-			errors = append(errors, ValidationError{
+			fieldErrors = append(fieldErrors, ValidationError{
 				Field:   getFieldName(e),
 				Message: getErrorMessage(e),
 			})
 		}
 	}
 
-	return errors
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		fieldErrors = append(fieldErrors, ValidationError{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("%s 타입이어야 합니다", jsonTypeName(typeErr.Type)),
+		})
+	}
+
+	return fieldErrors
 }
 
+// getFieldName returns the path of the field as the client sent it, e.g.
+// "scopes[0]", without the request struct name.
 func getFieldName(e validator.FieldError) string {
+	ns := e.Namespace()
+	if _, rest, ok := strings.Cut(ns, "."); ok {
+		return rest
+	}
 	field := e.Field()
 	return strings.ToLower(field[:1]) + field[1:]
 }
@@ -49,14 +76,67 @@ func getErrorMessage(e validator.FieldError) string {
 		return "유효한 URL을 입력하세요"
 	case "oneof":
 		return fmt.Sprintf("다음 값 중 하나여야 합니다: %s", e.Param())
+	case "uuid":
+		return "유효한 UUID를 입력하세요"
+	case "category":
+		return fmt.Sprintf("다음 값 중 하나여야 합니다: %s", strings.Join(allowedCategories(), ", "))
 	default:
 		return fmt.Sprintf("%s 검증에 실패했습니다", e.Field())
 	}
 }
 
-func Init() {
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		// 커스텀 검증 규칙 등록 가능
-		_ = v
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
 	}
 }
+
+// Init registers the custom rules with gin's validator and reports fields
+// by their JSON (or form) name. allowed is the list of document categories
+// accepted by the "category" rule; when empty any category passes.
+func Init(allowed []string) {
+	mu.Lock()
+	categories = allowed
+	mu.Unlock()
+
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, key := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(f.Tag.Get(key), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return f.Name
+	})
+
+	// "uuid" is built in; "category" enforces DOCUMENT_METADATA_CATEGORIES.
+	_ = v.RegisterValidation("category", func(fl validator.FieldLevel) bool {
+		allowed := allowedCategories()
+		return len(allowed) == 0 || containsFold(allowed, fl.Field().String())
+	})
+}
+
+func allowedCategories() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return categories
+}